@@ -12,6 +12,7 @@ import (
 	"github.com/ZenoN-Cloud/zeno-auth/internal/config"
 	"github.com/ZenoN-Cloud/zeno-auth/internal/repository/postgres"
 	"github.com/ZenoN-Cloud/zeno-auth/internal/service"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/storage"
 )
 
 func main() {
@@ -40,9 +41,21 @@ func main() {
 	// Initialize repositories
 	refreshTokenRepo := postgres.NewRefreshTokenRepo(db)
 	auditLogRepo := postgres.NewAuditLogRepository(db.Pool())
+	userRepo := postgres.NewUserRepo(db)
+
+	accountDeletionRepo := postgres.NewAccountDeletionRepository(db.Pool())
+	dataExportRepo := postgres.NewDataExportRepository(db.Pool())
+
+	exportStorage, err := storage.New(cfg.GDPR.ExportStorageBackend, cfg.GDPR.ExportStorageDir)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize data export storage")
+	}
 
 	// Initialize cleanup service
-	cleanupService := service.NewCleanupService(refreshTokenRepo, auditLogRepo)
+	auditService := service.NewAuditService(auditLogRepo)
+	cleanupService := service.NewCleanupService(
+		refreshTokenRepo, auditLogRepo, userRepo, accountDeletionRepo, dataExportRepo, exportStorage, auditService, cfg.FrontendBaseURL,
+	)
 
 	ctx := context.Background()
 
@@ -62,6 +75,22 @@ func main() {
 		log.Info().Msg("Old audit logs cleaned up successfully")
 	}
 
+	// Purge accounts whose deletion grace period ended
+	log.Info().Msg("Purging accounts scheduled for deletion")
+	if purged, err := cleanupService.PurgeDeletedAccounts(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to purge accounts scheduled for deletion")
+	} else {
+		log.Info().Int("purged", purged).Msg("Accounts scheduled for deletion purged successfully")
+	}
+
+	// Cleanup expired data exports
+	log.Info().Msg("Cleaning up expired data exports")
+	if _, err := cleanupService.CleanupDataExports(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to cleanup expired data exports")
+	} else {
+		log.Info().Msg("Expired data exports cleaned up successfully")
+	}
+
 	// Cleanup expired email verifications
 	log.Info().Msg("Cleaning up expired email verifications")
 	emailVerificationRepo := postgres.NewEmailVerificationRepository(db.Pool())
@@ -80,5 +109,65 @@ func main() {
 		log.Info().Msg("Expired password reset tokens cleaned up successfully")
 	}
 
+	// Cleanup expired MFA login challenges
+	log.Info().Msg("Cleaning up expired MFA challenges")
+	mfaChallengeRepo := postgres.NewMFAChallengeRepository(db.Pool())
+	if err := mfaChallengeRepo.DeleteExpired(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to cleanup expired MFA challenges")
+	} else {
+		log.Info().Msg("Expired MFA challenges cleaned up successfully")
+	}
+
+	// Cleanup expired passkey ceremonies
+	log.Info().Msg("Cleaning up expired passkey challenges")
+	webAuthnChallengeRepo := postgres.NewWebAuthnChallengeRepository(db.Pool())
+	if err := webAuthnChallengeRepo.DeleteExpired(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to cleanup expired passkey challenges")
+	} else {
+		log.Info().Msg("Expired passkey challenges cleaned up successfully")
+	}
+
+	// Cleanup expired OAuth authorization codes
+	log.Info().Msg("Cleaning up expired OAuth authorization codes")
+	oauthCodeRepo := postgres.NewOAuthAuthorizationCodeRepository(db.Pool())
+	if err := oauthCodeRepo.DeleteExpired(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to cleanup expired OAuth authorization codes")
+	} else {
+		log.Info().Msg("Expired OAuth authorization codes cleaned up successfully")
+	}
+
+	// Cleanup expired social login states
+	log.Info().Msg("Cleaning up expired social login states")
+	socialStateRepo := postgres.NewSocialAuthStateRepository(db.Pool())
+	if err := socialStateRepo.DeleteExpired(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to cleanup expired social login states")
+	} else {
+		log.Info().Msg("Expired social login states cleaned up successfully")
+	}
+
+	// Cleanup expired SAML requests and login codes
+	log.Info().Msg("Cleaning up expired SAML requests")
+	samlRequestRepo := postgres.NewSAMLRequestRepository(db.Pool())
+	if err := samlRequestRepo.DeleteExpired(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to cleanup expired SAML requests")
+	} else {
+		log.Info().Msg("Expired SAML requests cleaned up successfully")
+	}
+	samlLoginCodeRepo := postgres.NewSAMLLoginCodeRepository(db.Pool())
+	if err := samlLoginCodeRepo.DeleteExpired(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to cleanup expired SAML login codes")
+	} else {
+		log.Info().Msg("Expired SAML login codes cleaned up successfully")
+	}
+
+	// Cleanup expired access token revocations
+	log.Info().Msg("Cleaning up expired token revocations")
+	revocationRepo := postgres.NewRevocationRepository(db.Pool())
+	if err := revocationRepo.DeleteExpired(ctx); err != nil {
+		log.Error().Err(err).Msg("Failed to cleanup expired token revocations")
+	} else {
+		log.Info().Msg("Expired token revocations cleaned up successfully")
+	}
+
 	log.Info().Msg("Cleanup job completed successfully")
 }
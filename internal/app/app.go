@@ -67,6 +67,22 @@ func New() (*App, error) {
 		container.Metrics,
 		container.EmailService,
 		container.PasswordResetService,
+		container.MFAService,
+		container.PasskeyService,
+		container.Revocation,
+		container.OAuthService,
+		container.SocialService,
+		container.SAMLService,
+		container.DomainService,
+		container.SCIMService,
+		container.InvitationService,
+		container.MemberService,
+		container.RoleService,
+		container.AdminService,
+		container.ComplianceService,
+		container.MagicLinkService,
+		container.EmailChangeService,
+		container.DataExportService,
 	)
 	if router == nil {
 		return nil, fmt.Errorf("router setup failed: nil router returned")
@@ -74,7 +90,7 @@ func New() (*App, error) {
 
 	// Setup internal router for billing integration
 	orgRepoImpl := postgres.NewOrganizationRepo(container.DB)
-	internalRouter := handler.SetupInternalRouter(orgRepoImpl, log.Logger)
+	internalRouter := handler.SetupInternalRouter(orgRepoImpl, container.JWTManager, container.Revocation, log.Logger)
 	// Mount internal routes
 	router.Any("/internal/*path", gin.WrapH(internalRouter))
 
@@ -120,6 +136,12 @@ func (a *App) Run(ctx context.Context) error {
 		}
 
 		log.Info().Msg("HTTP server gracefully stopped")
+
+		// Exports that do not finish in time are marked as failed by the
+		// cleanup job
+		if err := a.container.DataExportService.Wait(shutdownCtx); err != nil {
+			log.Warn().Err(err).Msg("Data exports still running at shutdown")
+		}
 	}()
 
 	log.Info().Str("addr", a.server.Addr).Msg("HTTP server listening")
@@ -1,8 +1,11 @@
 package bootstrap
 
 import (
+	"context"
 	"fmt"
+	"time"
 
+	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog/log"
 
 	"github.com/ZenoN-Cloud/zeno-auth/internal/client"
@@ -10,6 +13,7 @@ import (
 	"github.com/ZenoN-Cloud/zeno-auth/internal/metrics"
 	"github.com/ZenoN-Cloud/zeno-auth/internal/repository/postgres"
 	"github.com/ZenoN-Cloud/zeno-auth/internal/service"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/storage"
 	"github.com/ZenoN-Cloud/zeno-auth/internal/token"
 )
 
@@ -21,6 +25,8 @@ type Container struct {
 	JWTManager      *token.JWTManager
 	RefreshManager  *token.RefreshManager
 	PasswordManager *token.PasswordManager
+	Revocation      *token.RevocationService
+	Redis           *redis.Client
 
 	AuthService          service.AuthServiceInterface
 	UserService          service.UserServiceInterface
@@ -28,10 +34,25 @@ type Container struct {
 	AuditService         *service.AuditService
 	CleanupService       *service.CleanupService
 	GDPRService          *service.GDPRService
+	DataExportService    *service.DataExportService
 	PasswordService      *service.PasswordService
 	SessionService       *service.SessionService
 	EmailService         *service.EmailService
 	PasswordResetService *service.PasswordResetService
+	MFAService           *service.MFAService
+	PasskeyService       *service.PasskeyService
+	SocialService        *service.SocialService
+	SAMLService          *service.SAMLService
+	DomainService        *service.DomainService
+	MagicLinkService     *service.MagicLinkService
+	EmailChangeService   *service.EmailChangeService
+	SCIMService          *service.SCIMService
+	InvitationService    *service.InvitationService
+	MemberService        *service.MemberService
+	RoleService          *service.RoleService
+	AdminService         *service.AdminService
+	ComplianceService    *service.ComplianceService
+	OAuthService         *service.OAuthService
 }
 
 func BuildContainer(cfg *config.Config) (*Container, error) {
@@ -50,12 +71,16 @@ func BuildContainer(cfg *config.Config) (*Container, error) {
 	log.Info().Msg("Connected to PostgreSQL")
 
 	log.Info().Msg("Initializing JWT manager...")
-	jwtManager, err := token.NewJWTManager(cfg.JWT.PrivateKey, cfg.JWT.PublicKey)
+	jwtManager, err := token.NewJWTManagerWithKeys(
+		jwtKeyConfigs(cfg.JWT),
+		cfg.JWT.SigningKeyID(),
+		time.Duration(cfg.JWT.AccessTokenTTL)*time.Second,
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create JWT manager: %w", err)
 	}
 	container.JWTManager = jwtManager
-	log.Info().Msg("JWT manager initialized")
+	log.Info().Str("active_kid", jwtManager.ActiveKeyID()).Msg("JWT manager initialized")
 
 	log.Info().Msg("Initializing metrics collector...")
 	container.Metrics = metrics.New()
@@ -72,10 +97,56 @@ func BuildContainer(cfg *config.Config) (*Container, error) {
 	auditRepo := postgres.NewAuditLogRepository(db.Pool())
 	emailVerificationRepo := postgres.NewEmailVerificationRepository(db.Pool())
 	passwordResetRepo := postgres.NewPasswordResetRepository(db.Pool())
+	mfaRepo := postgres.NewMFARepository(db.Pool())
+	mfaChallengeRepo := postgres.NewMFAChallengeRepository(db.Pool())
+	mfaRecoveryCodeRepo := postgres.NewMFARecoveryCodeRepository(db.Pool())
+	webAuthnCredentialRepo := postgres.NewWebAuthnCredentialRepository(db.Pool())
+	webAuthnChallengeRepo := postgres.NewWebAuthnChallengeRepository(db.Pool())
+	invitationRepo := postgres.NewOrgInvitationRepository(db.Pool())
+	emailChangeRepo := postgres.NewEmailChangeRepository(db.Pool())
+
+	log.Info().Str("backend", cfg.Revocation.Backend).Msg("Initializing token revocation...")
+	revocationStore, err := container.revocationStore(cfg.Revocation, db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize token revocation: %w", err)
+	}
+	container.Revocation = token.NewRevocationService(revocationStore, time.Duration(cfg.JWT.AccessTokenTTL)*time.Second)
 
 	serviceConfig := service.NewConfig(cfg)
 	container.AuditService = service.NewAuditService(auditRepo)
 	container.EmailService = service.NewEmailService(emailVerificationRepo, userRepo, container.AuditService, cfg.FrontendBaseURL)
+	container.MFAService = service.NewMFAService(
+		mfaRepo, mfaChallengeRepo, mfaRecoveryCodeRepo, userRepo,
+		token.NewTOTPManager(cfg.MFA.Issuer), container.PasswordManager, container.AuditService,
+	)
+	container.PasskeyService = service.NewPasskeyService(
+		webAuthnCredentialRepo, webAuthnChallengeRepo, userRepo,
+		token.NewWebAuthnVerifier(cfg.WebAuthn.RPID, cfg.WebAuthn.Origins), cfg.WebAuthn.RPName, container.AuditService,
+	)
+
+	userIdentityRepo := postgres.NewUserIdentityRepository(db.Pool())
+	container.SocialService = service.NewSocialService(
+		cfg.SocialProviders, userIdentityRepo, postgres.NewSocialAuthStateRepository(db.Pool()),
+		userRepo, container.AuditService,
+	)
+	samlConnectionRepo := postgres.NewSAMLConnectionRepository(db.Pool())
+	domainRepo := postgres.NewOrganizationDomainRepository(db.Pool())
+	container.SAMLService = service.NewSAMLService(
+		samlConnectionRepo, postgres.NewSAMLRequestRepository(db.Pool()),
+		postgres.NewSAMLLoginCodeRepository(db.Pool()), userIdentityRepo,
+		userRepo, orgRepo, membershipRepo, domainRepo, container.AuditService, cfg.OIDC.Issuer,
+	)
+	container.DomainService = service.NewDomainService(domainRepo, samlConnectionRepo, membershipRepo, nil, container.AuditService)
+	container.SCIMService = service.NewSCIMService(
+		postgres.NewSCIMTokenRepository(db.Pool()), membershipRepo, userRepo, domainRepo,
+		refreshRepo, container.Revocation, container.AuditService, cfg.OIDC.Issuer,
+	)
+	container.InvitationService = service.NewInvitationService(
+		invitationRepo, orgRepo, membershipRepo, userRepo,
+		container.PasswordManager, container.AuditService, cfg.FrontendBaseURL,
+	)
+	container.MemberService = service.NewMemberService(membershipRepo, refreshRepo, container.Revocation, container.AuditService)
+	container.RoleService = service.NewRoleService(postgres.NewOrgRoleRepository(db.Pool()), membershipRepo, container.AuditService)
 
 	// Initialize billing client (optional)
 	var billingClient service.BillingClient
@@ -87,23 +158,55 @@ func BuildContainer(cfg *config.Config) (*Container, error) {
 		log.Warn().Msg("Billing service URL not configured, trial subscriptions will not be created automatically")
 	}
 
+	container.MagicLinkService = service.NewMagicLinkService(
+		postgres.NewMagicLinkRepository(db.Pool()), userRepo, membershipRepo, container.AuditService, cfg.FrontendBaseURL,
+	)
+
 	container.AuthService = service.NewAuthService(
 		userRepo, orgRepo, membershipRepo, refreshRepo,
 		jwtManager, container.RefreshManager, container.PasswordManager,
-		container.EmailService, billingClient, serviceConfig, db,
+		container.EmailService, container.MFAService, container.PasskeyService, container.SocialService, container.SAMLService, container.DomainService, container.MagicLinkService, container.AuditService, container.Revocation, billingClient, serviceConfig, db,
 	)
-	container.UserService = service.NewUserService(userRepo, membershipRepo)
+	container.UserService = service.NewUserService(userRepo, membershipRepo, container.AuditService)
 	container.ConsentService = service.NewConsentService(consentRepo)
-	container.CleanupService = service.NewCleanupService(refreshRepo, auditRepo)
+	exportStorage, err := storage.New(cfg.GDPR.ExportStorageBackend, cfg.GDPR.ExportStorageDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize data export storage: %w", err)
+	}
+	deletionRepo := postgres.NewAccountDeletionRepository(db.Pool())
+	dataExportRepo := postgres.NewDataExportRepository(db.Pool())
+	container.CleanupService = service.NewCleanupService(
+		refreshRepo, auditRepo, userRepo, deletionRepo, dataExportRepo, exportStorage, container.AuditService, cfg.FrontendBaseURL,
+	)
 	container.GDPRService = service.NewGDPRService(
-		userRepo, orgRepo, membershipRepo, refreshRepo, consentRepo, auditRepo, db,
+		userRepo, refreshRepo, deletionRepo, container.Revocation, container.AuditService,
+		time.Duration(cfg.GDPR.DeletionGraceDays)*24*time.Hour, cfg.FrontendBaseURL,
+	)
+	container.DataExportService = service.NewDataExportService(
+		dataExportRepo, userRepo, orgRepo, membershipRepo, consentRepo, refreshRepo, emailVerificationRepo, passwordResetRepo,
+		userIdentityRepo, webAuthnCredentialRepo, mfaRepo, mfaRecoveryCodeRepo, emailChangeRepo, invitationRepo, auditRepo,
+		exportStorage, container.AuditService, time.Duration(cfg.GDPR.ExportLinkTTLHours)*time.Hour, cfg.FrontendBaseURL,
 	)
 	container.PasswordService = service.NewPasswordService(
-		userRepo, refreshRepo, container.PasswordManager, container.AuditService, container.EmailService, db,
+		userRepo, refreshRepo, container.PasswordManager, container.AuditService, container.EmailService, container.Revocation, db,
+	)
+	container.SessionService = service.NewSessionService(refreshRepo, container.Revocation)
+	container.EmailChangeService = service.NewEmailChangeService(
+		emailChangeRepo, userRepo, refreshRepo, container.PasswordManager,
+		container.DomainService, container.Revocation, container.AuditService, cfg.FrontendBaseURL,
 	)
-	container.SessionService = service.NewSessionService(refreshRepo)
 	container.PasswordResetService = service.NewPasswordResetService(
-		passwordResetRepo, userRepo, refreshRepo, container.PasswordManager, container.AuditService, cfg.FrontendBaseURL,
+		passwordResetRepo, userRepo, refreshRepo, container.PasswordManager, container.AuditService, container.Revocation, cfg.FrontendBaseURL,
+	)
+
+	container.AdminService = service.NewAdminService(
+		userRepo, membershipRepo, refreshRepo, deletionRepo, container.Revocation, container.PasswordResetService, container.AuditService,
+	)
+	container.ComplianceService = service.NewComplianceService(auditRepo, userRepo)
+
+	container.OAuthService = service.NewOAuthService(
+		postgres.NewOAuthClientRepository(db.Pool()), postgres.NewOAuthAuthorizationCodeRepository(db.Pool()),
+		userRepo, membershipRepo, jwtManager, container.AuditService, cfg.OIDC.Issuer, cfg.JWT.AccessTokenTTL,
 	)
 
 	log.Info().Msg("All services initialized")
@@ -112,7 +215,59 @@ func BuildContainer(cfg *config.Config) (*Container, error) {
 }
 
 func (c *Container) Close() {
+	if c.Redis != nil {
+		_ = c.Redis.Close()
+	}
 	if c.DB != nil {
 		c.DB.Close()
 	}
 }
+
+// revocationStore picks the access token denylist backend. The in-memory store
+// only works for a single instance, Postgres and Redis are shared.
+func (c *Container) revocationStore(cfg config.Revocation, db *postgres.DB) (token.RevocationStore, error) {
+	switch cfg.Backend {
+	case "redis":
+		opts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+		}
+		client := redis.NewClient(opts)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := client.Ping(ctx).Err(); err != nil {
+			_ = client.Close()
+			return nil, fmt.Errorf("failed to connect to redis: %w", err)
+		}
+		c.Redis = client
+		return token.NewRedisRevocationStore(client), nil
+	case "memory":
+		log.Warn().Msg("Using in-memory token revocation, revocations are not shared between instances")
+		return token.NewMemoryRevocationStore(), nil
+	default:
+		return postgres.NewRevocationRepository(db.Pool()), nil
+	}
+}
+
+// jwtKeyConfigs builds the signing keyring from JWT_PRIVATE_KEY/JWT_PUBLIC_KEY
+// and the additional keys in JWT_KEYS.
+func jwtKeyConfigs(cfg config.JWT) []token.KeyConfig {
+	keys := make([]token.KeyConfig, 0, len(cfg.Keys)+1)
+	if cfg.PrivateKey != "" {
+		keys = append(keys, token.KeyConfig{
+			KID:           cfg.KeyID,
+			PrivateKeyPEM: cfg.PrivateKey,
+			PublicKeyPEM:  cfg.PublicKey,
+		})
+	}
+	for _, key := range cfg.Keys {
+		keys = append(keys, token.KeyConfig{
+			KID:           key.KID,
+			PrivateKeyPEM: key.PrivateKey,
+			PublicKeyPEM:  key.PublicKey,
+			RetiredAt:     key.RetiredAt,
+		})
+	}
+	return keys
+}
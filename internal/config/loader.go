@@ -1,6 +1,7 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"os"
@@ -32,9 +33,31 @@ func Load() (*Config, error) {
 		JWT: JWT{
 			PrivateKey:      getEnv("JWT_PRIVATE_KEY", ""),
 			PublicKey:       getEnv("JWT_PUBLIC_KEY", ""),
+			KeyID:           getEnv("JWT_KEY_ID", "2024-01"),
+			ActiveKeyID:     getEnv("JWT_ACTIVE_KEY_ID", ""),
 			AccessTokenTTL:  getEnvInt("ACCESS_TOKEN_TTL", 1800),
 			RefreshTokenTTL: getEnvInt("REFRESH_TOKEN_TTL", 1209600),
 		},
+		MFA: MFA{
+			Issuer: getEnv("MFA_ISSUER", "ZenoN Cloud"),
+		},
+		WebAuthn: WebAuthn{
+			RPID:   getEnv("WEBAUTHN_RP_ID", ""),
+			RPName: getEnv("WEBAUTHN_RP_NAME", "ZenoN Cloud"),
+		},
+		Revocation: Revocation{
+			Backend:  getEnv("TOKEN_REVOCATION_BACKEND", "postgres"),
+			RedisURL: getEnv("REDIS_URL", ""),
+		},
+		OIDC: OIDC{
+			Issuer: strings.TrimRight(getEnv("OIDC_ISSUER", ""), "/"),
+		},
+		GDPR: GDPR{
+			DeletionGraceDays:    getEnvInt("ACCOUNT_DELETION_GRACE_DAYS", 30),
+			ExportStorageBackend: getEnv("DATA_EXPORT_STORAGE_BACKEND", "local"),
+			ExportStorageDir:     getEnv("DATA_EXPORT_STORAGE_DIR", "/var/lib/zeno-auth/exports"),
+			ExportLinkTTLHours:   getEnvInt("DATA_EXPORT_LINK_TTL_HOURS", 72),
+		},
 		Log: Log{
 			Level:  getEnv("LOG_LEVEL", "info"),
 			Format: getEnv("LOG_FORMAT", "json"),
@@ -79,6 +102,33 @@ func Load() (*Config, error) {
 		}
 	}
 
+	if raw := os.Getenv("JWT_KEYS"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &cfg.JWT.Keys); err != nil {
+			return nil, fmt.Errorf("JWT_KEYS must be a JSON array of keys: %w", err)
+		}
+	}
+
+	if raw := os.Getenv("SOCIAL_PROVIDERS"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &cfg.SocialProviders); err != nil {
+			return nil, fmt.Errorf("SOCIAL_PROVIDERS must be a JSON array of providers: %w", err)
+		}
+	}
+	for i := range cfg.SocialProviders {
+		applySocialProviderDefaults(&cfg.SocialProviders[i], cfg.FrontendBaseURL)
+	}
+
+	if cfg.OIDC.Issuer == "" {
+		cfg.OIDC.Issuer = "http://localhost:" + cfg.Server.Port
+	}
+
+	// Passkeys are bound to the frontend by default
+	if cfg.WebAuthn.RPID == "" {
+		if u, err := url.Parse(cfg.FrontendBaseURL); err == nil {
+			cfg.WebAuthn.RPID = u.Hostname()
+		}
+	}
+	cfg.WebAuthn.Origins = getEnvSlice("WEBAUTHN_ORIGINS", []string{strings.TrimRight(cfg.FrontendBaseURL, "/")})
+
 	if err := validate(cfg); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
@@ -90,7 +140,7 @@ func validate(cfg *Config) error {
 	// Cleanup jobs can skip DB + JWT validation
 	skipJWT := os.Getenv("SKIP_JWT_VALIDATION") == "1"
 
-	if !skipJWT && cfg.JWT.PrivateKey == "" {
+	if !skipJWT && cfg.JWT.PrivateKey == "" && len(cfg.JWT.Keys) == 0 {
 		return fmt.Errorf("JWT_PRIVATE_KEY is required")
 	}
 
@@ -125,6 +175,33 @@ func validate(cfg *Config) error {
 	return nil
 }
 
+// applySocialProviderDefaults fills in the OpenID Connect standard claims and
+// scopes. The provider redirects back to the frontend, which completes the
+// login through the API.
+func applySocialProviderDefaults(p *SocialProvider, frontendBaseURL string) {
+	if p.DisplayName == "" {
+		p.DisplayName = p.Name
+	}
+	if p.RedirectURL == "" {
+		p.RedirectURL = strings.TrimRight(frontendBaseURL, "/") + "/auth/social/" + p.Name + "/callback"
+	}
+	if len(p.Scopes) == 0 {
+		p.Scopes = []string{"openid", "email", "profile"}
+	}
+	if p.SubjectClaim == "" {
+		p.SubjectClaim = "sub"
+	}
+	if p.EmailClaim == "" {
+		p.EmailClaim = "email"
+	}
+	if p.EmailVerifiedClaim == "" && !p.TrustEmail {
+		p.EmailVerifiedClaim = "email_verified"
+	}
+	if p.NameClaim == "" {
+		p.NameClaim = "name"
+	}
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
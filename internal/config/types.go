@@ -1,15 +1,23 @@
 package config
 
+import "time"
+
 type Config struct {
-	Env               string   `json:"env"`
-	AppName           string   `json:"app_name"`
-	Timezone          string   `json:"timezone"`
-	FrontendBaseURL   string   `json:"frontend_base_url"`
-	BillingServiceURL string   `json:"billing_service_url"`
-	Server            Server   `json:"server"`
-	Database          Database `json:"database"`
-	JWT               JWT      `json:"jwt"`
-	Log               Log      `json:"log"`
+	Env               string           `json:"env"`
+	AppName           string           `json:"app_name"`
+	Timezone          string           `json:"timezone"`
+	FrontendBaseURL   string           `json:"frontend_base_url"`
+	BillingServiceURL string           `json:"billing_service_url"`
+	Server            Server           `json:"server"`
+	Database          Database         `json:"database"`
+	JWT               JWT              `json:"jwt"`
+	MFA               MFA              `json:"mfa"`
+	WebAuthn          WebAuthn         `json:"webauthn"`
+	Revocation        Revocation       `json:"revocation"`
+	OIDC              OIDC             `json:"oidc"`
+	SocialProviders   []SocialProvider `json:"social_providers"`
+	GDPR              GDPR             `json:"gdpr"`
+	Log               Log              `json:"log"`
 }
 
 type Server struct {
@@ -22,10 +30,79 @@ type Database struct {
 }
 
 type JWT struct {
-	PrivateKey      string `json:"private_key" log:"-"`
-	PublicKey       string `json:"public_key" log:"-"`
-	AccessTokenTTL  int    `json:"access_token_ttl"`
-	RefreshTokenTTL int    `json:"refresh_token_ttl"`
+	PrivateKey      string   `json:"private_key" log:"-"`
+	PublicKey       string   `json:"public_key" log:"-"`
+	KeyID           string   `json:"key_id"`
+	ActiveKeyID     string   `json:"active_key_id"`
+	Keys            []JWTKey `json:"keys" log:"-"`
+	AccessTokenTTL  int      `json:"access_token_ttl"`
+	RefreshTokenTTL int      `json:"refresh_token_ttl"`
+}
+
+// JWTKey is an additional keyring entry from JWT_KEYS. Keys without a private
+// key or with RetiredAt set are only used for verification.
+type JWTKey struct {
+	KID        string     `json:"kid"`
+	PrivateKey string     `json:"private_key" log:"-"`
+	PublicKey  string     `json:"public_key" log:"-"`
+	RetiredAt  *time.Time `json:"retired_at,omitempty"`
+}
+
+type MFA struct {
+	Issuer string `json:"issuer"`
+}
+
+// WebAuthn configures the relying party for passkeys. RPID must be the
+// frontend's registrable domain and Origins the exact origins it is served from.
+type WebAuthn struct {
+	RPID    string   `json:"rp_id"`
+	RPName  string   `json:"rp_name"`
+	Origins []string `json:"origins"`
+}
+
+// Revocation selects where the access token denylist is kept: "redis" for
+// multi-instance deployments, "postgres" or "memory" (single instance only).
+type Revocation struct {
+	Backend  string `json:"backend"`
+	RedisURL string `json:"redis_url" log:"-"`
+}
+
+// GDPR configures account deletion and personal data exports. Deleted
+// accounts can be restored for DeletionGraceDays before the cleanup job purges
+// them. Export archives are kept in ExportStorageBackend for ExportLinkTTLHours.
+type GDPR struct {
+	DeletionGraceDays    int    `json:"deletion_grace_days"`
+	ExportStorageBackend string `json:"export_storage_backend"`
+	ExportStorageDir     string `json:"export_storage_dir"`
+	ExportLinkTTLHours   int    `json:"export_link_ttl_hours"`
+}
+
+// OIDC configures the OpenID Connect provider. Issuer is the public base URL
+// of this service; discovery and all OAuth endpoints are published under it.
+type OIDC struct {
+	Issuer string `json:"issuer"`
+}
+
+// SocialProvider is an upstream OAuth 2.0 / OpenID Connect identity provider
+// users can sign in with. The profile is read from UserInfoURL; the claim
+// names default to the OpenID Connect standard claims. TrustEmail treats the
+// email as verified for providers that only return verified addresses and
+// have no email_verified claim.
+type SocialProvider struct {
+	Name               string   `json:"name"`
+	DisplayName        string   `json:"display_name"`
+	ClientID           string   `json:"client_id"`
+	ClientSecret       string   `json:"client_secret" log:"-"`
+	AuthURL            string   `json:"auth_url"`
+	TokenURL           string   `json:"token_url"`
+	UserInfoURL        string   `json:"userinfo_url"`
+	RedirectURL        string   `json:"redirect_url"`
+	Scopes             []string `json:"scopes"`
+	SubjectClaim       string   `json:"subject_claim"`
+	EmailClaim         string   `json:"email_claim"`
+	EmailVerifiedClaim string   `json:"email_verified_claim"`
+	NameClaim          string   `json:"name_claim"`
+	TrustEmail         bool     `json:"trust_email"`
 }
 
 type Log struct {
@@ -48,3 +125,12 @@ func (c *Config) GetEnv() string {
 func (c *Config) GetBillingServiceURL() string {
 	return c.BillingServiceURL
 }
+
+// SigningKeyID returns the kid used to sign new tokens: JWT_ACTIVE_KEY_ID if
+// set, otherwise the kid of JWT_PRIVATE_KEY.
+func (j *JWT) SigningKeyID() string {
+	if j.ActiveKeyID != "" {
+		return j.ActiveKeyID
+	}
+	return j.KeyID
+}
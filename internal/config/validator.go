@@ -2,10 +2,14 @@ package config
 
 import (
 	"fmt"
+	"net/url"
+	"regexp"
 	"strings"
 	"time"
 )
 
+var socialProviderName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
 // Validate проверяет корректность конфигурации
 func (c *Config) Validate() error {
 	var errs []string
@@ -16,9 +20,26 @@ func (c *Config) Validate() error {
 	}
 
 	// JWT
-	if c.JWT.PrivateKey == "" && c.Env != "development" {
+	if c.JWT.PrivateKey == "" && len(c.JWT.Keys) == 0 && c.Env != "development" {
 		errs = append(errs, "JWT_PRIVATE_KEY is required in production")
 	}
+	seenKIDs := map[string]bool{}
+	if c.JWT.PrivateKey != "" {
+		seenKIDs[c.JWT.KeyID] = true
+	}
+	for _, key := range c.JWT.Keys {
+		if key.KID == "" {
+			errs = append(errs, "JWT_KEYS entries require a kid")
+			continue
+		}
+		if seenKIDs[key.KID] {
+			errs = append(errs, fmt.Sprintf("duplicate JWT key id: %s", key.KID))
+		}
+		seenKIDs[key.KID] = true
+	}
+	if len(seenKIDs) > 0 && !seenKIDs[c.JWT.SigningKeyID()] {
+		errs = append(errs, fmt.Sprintf("JWT_ACTIVE_KEY_ID %q does not match any configured key", c.JWT.SigningKeyID()))
+	}
 	// JWT_PUBLIC_KEY is optional - we have embedded public key in internal/token/jwt_public.pem
 	if c.JWT.AccessTokenTTL <= 0 {
 		errs = append(errs, "JWT_ACCESS_TOKEN_TTL must be > 0")
@@ -33,6 +54,73 @@ func (c *Config) Validate() error {
 		errs = append(errs, "JWT_REFRESH_TOKEN_TTL too large (max 30 days)")
 	}
 
+	// Token revocation
+	switch c.Revocation.Backend {
+	case "", "postgres", "memory":
+	case "redis":
+		if c.Revocation.RedisURL == "" {
+			errs = append(errs, "REDIS_URL is required when TOKEN_REVOCATION_BACKEND=redis")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("TOKEN_REVOCATION_BACKEND must be one of: redis, postgres, memory (got %q)", c.Revocation.Backend))
+	}
+
+	// Account deletion
+	if c.GDPR.DeletionGraceDays < 0 {
+		errs = append(errs, "ACCOUNT_DELETION_GRACE_DAYS must not be negative")
+	}
+
+	// Data export
+	switch c.GDPR.ExportStorageBackend {
+	case "":
+	case "local":
+		if c.GDPR.ExportStorageDir == "" {
+			errs = append(errs, "DATA_EXPORT_STORAGE_DIR is required when DATA_EXPORT_STORAGE_BACKEND=local")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("DATA_EXPORT_STORAGE_BACKEND must be one of: local (got %q)", c.GDPR.ExportStorageBackend))
+	}
+	if c.GDPR.ExportLinkTTLHours < 0 {
+		errs = append(errs, "DATA_EXPORT_LINK_TTL_HOURS must not be negative")
+	}
+
+	// OIDC
+	if c.OIDC.Issuer != "" {
+		u, err := url.Parse(c.OIDC.Issuer)
+		if err != nil || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+			errs = append(errs, "OIDC_ISSUER must be an absolute URL without query or fragment")
+		} else if c.Env == "production" && u.Scheme != "https" {
+			errs = append(errs, "OIDC_ISSUER must use https in production")
+		}
+	}
+
+	// Social login
+	seenProviders := map[string]bool{}
+	for _, p := range c.SocialProviders {
+		if !socialProviderName.MatchString(p.Name) {
+			errs = append(errs, fmt.Sprintf("SOCIAL_PROVIDERS: invalid provider name %q (use lowercase letters, digits and dashes)", p.Name))
+			continue
+		}
+		if seenProviders[p.Name] {
+			errs = append(errs, fmt.Sprintf("SOCIAL_PROVIDERS: duplicate provider %q", p.Name))
+		}
+		seenProviders[p.Name] = true
+		if p.ClientID == "" {
+			errs = append(errs, fmt.Sprintf("SOCIAL_PROVIDERS: %s requires client_id", p.Name))
+		}
+		endpoints := []struct{ field, raw string }{
+			{"auth_url", p.AuthURL}, {"token_url", p.TokenURL}, {"userinfo_url", p.UserInfoURL}, {"redirect_url", p.RedirectURL},
+		}
+		for _, e := range endpoints {
+			u, err := url.Parse(e.raw)
+			if err != nil || u.Host == "" {
+				errs = append(errs, fmt.Sprintf("SOCIAL_PROVIDERS: %s requires an absolute %s", p.Name, e.field))
+			} else if c.Env == "production" && u.Scheme != "https" {
+				errs = append(errs, fmt.Sprintf("SOCIAL_PROVIDERS: %s %s must use https in production", p.Name, e.field))
+			}
+		}
+	}
+
 	// Server
 	if c.Server.Port == "" {
 		errs = append(errs, "SERVER_PORT is required")
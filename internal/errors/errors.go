@@ -26,6 +26,59 @@ var (
 	ErrConflict           = errors.New("conflict")
 	ErrRateLimitExceeded  = errors.New("rate limit exceeded")
 	ErrInternalServer     = errors.New("internal server error")
+	ErrInvalidMFACode     = errors.New("invalid MFA code")
+	ErrMFAAlreadyEnabled  = errors.New("MFA already enabled")
+	ErrMFANotEnabled      = errors.New("MFA not enabled")
+	ErrInvalidPasskey     = errors.New("invalid passkey")
+	ErrPasskeyNotFound    = errors.New("passkey not found")
+
+	ErrSocialProviderNotFound = errors.New("social provider not found")
+	ErrSocialLoginFailed      = errors.New("social login failed")
+	ErrSocialEmailNotVerified = errors.New("social email not verified")
+	ErrIdentityNotFound       = errors.New("identity not found")
+	ErrLastLoginMethod        = errors.New("last login method")
+
+	ErrSAMLNotConfigured   = errors.New("SAML not configured")
+	ErrInvalidSAMLMetadata = errors.New("invalid SAML metadata")
+	ErrSAMLLoginFailed     = errors.New("SAML login failed")
+	ErrSAMLUserNotMember   = errors.New("SAML user not a member")
+
+	ErrInvalidDomain            = errors.New("invalid domain")
+	ErrDomainAlreadyClaimed     = errors.New("domain already claimed")
+	ErrDomainVerificationFailed = errors.New("domain verification failed")
+	ErrDomainNotVerified        = errors.New("domain not verified")
+	ErrSSORequired              = errors.New("SSO required")
+
+	ErrSCIMTokenNotFound = errors.New("SCIM token not found")
+
+	ErrInvitationNotFound      = errors.New("invitation not found")
+	ErrInvalidInvitation       = errors.New("invalid invitation")
+	ErrInvitationExpired       = errors.New("invitation expired")
+	ErrInvitationEmailMismatch = errors.New("invitation email mismatch")
+	ErrAlreadyMember           = errors.New("already a member")
+
+	ErrMemberNotFound   = errors.New("member not found")
+	ErrOwnerImmutable   = errors.New("owner membership cannot be changed")
+	ErrInsufficientRole = errors.New("insufficient role")
+	ErrNotOrgMember     = errors.New("not a member of the organization")
+
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrRoleNameTaken     = errors.New("role name already used")
+	ErrInvalidPermission = errors.New("invalid permission")
+
+	ErrPlatformAdminProtected = errors.New("platform admin account is protected")
+
+	ErrInvalidMagicLink  = errors.New("invalid magic link")
+	ErrMagicLinkDisabled = errors.New("magic link sign-in disabled")
+
+	ErrInvalidCurrentPassword = errors.New("invalid current password")
+	ErrInvalidEmailChange     = errors.New("invalid email change token")
+
+	ErrOwnershipTransferRequired = errors.New("ownership transfer required")
+	ErrInvalidDeletionCancel     = errors.New("invalid account deletion cancellation token")
+
+	ErrDataExportNotFound    = errors.New("data export not found")
+	ErrInvalidDataExportLink = errors.New("invalid data export download token")
 )
 
 // MapError maps domain errors to HTTP status codes and messages
@@ -67,6 +120,88 @@ func MapError(err error) (int, string) {
 		return http.StatusConflict, "Resource conflict"
 	case errors.Is(err, ErrRateLimitExceeded):
 		return http.StatusTooManyRequests, "Rate limit exceeded"
+	case errors.Is(err, ErrInvalidMFACode):
+		return http.StatusUnauthorized, "Invalid verification code"
+	case errors.Is(err, ErrMFAAlreadyEnabled):
+		return http.StatusConflict, "MFA is already enabled"
+	case errors.Is(err, ErrMFANotEnabled):
+		return http.StatusBadRequest, "MFA is not set up for this account"
+	case errors.Is(err, ErrInvalidPasskey):
+		return http.StatusBadRequest, "Passkey verification failed"
+	case errors.Is(err, ErrPasskeyNotFound):
+		return http.StatusNotFound, "Passkey not found"
+	case errors.Is(err, ErrSocialProviderNotFound):
+		return http.StatusNotFound, "Identity provider not found"
+	case errors.Is(err, ErrSocialLoginFailed):
+		return http.StatusUnauthorized, "Sign-in with the identity provider failed"
+	case errors.Is(err, ErrSocialEmailNotVerified):
+		return http.StatusForbidden, "The identity provider did not confirm the email address"
+	case errors.Is(err, ErrIdentityNotFound):
+		return http.StatusNotFound, "Linked account not found"
+	case errors.Is(err, ErrLastLoginMethod):
+		return http.StatusConflict, "Cannot remove the last way to sign in"
+	case errors.Is(err, ErrSAMLNotConfigured):
+		return http.StatusNotFound, "Single sign-on is not configured for this organization"
+	case errors.Is(err, ErrInvalidSAMLMetadata):
+		return http.StatusBadRequest, "Invalid SAML identity provider metadata"
+	case errors.Is(err, ErrSAMLLoginFailed):
+		return http.StatusUnauthorized, "Single sign-on failed"
+	case errors.Is(err, ErrSAMLUserNotMember):
+		return http.StatusForbidden, "The user is not a member of this organization"
+	case errors.Is(err, ErrInvalidDomain):
+		return http.StatusBadRequest, "Invalid domain"
+	case errors.Is(err, ErrDomainAlreadyClaimed):
+		return http.StatusConflict, "The domain is already claimed"
+	case errors.Is(err, ErrDomainVerificationFailed):
+		return http.StatusBadRequest, "The verification record was not found"
+	case errors.Is(err, ErrDomainNotVerified):
+		return http.StatusConflict, "The domain is not verified"
+	case errors.Is(err, ErrSSORequired):
+		return http.StatusForbidden, "Sign in through your organization's single sign-on"
+	case errors.Is(err, ErrSCIMTokenNotFound):
+		return http.StatusNotFound, "SCIM token not found"
+	case errors.Is(err, ErrInvitationNotFound):
+		return http.StatusNotFound, "Invitation not found"
+	case errors.Is(err, ErrInvalidInvitation):
+		return http.StatusBadRequest, "Invalid invitation"
+	case errors.Is(err, ErrInvitationExpired):
+		return http.StatusBadRequest, "Invitation expired"
+	case errors.Is(err, ErrInvitationEmailMismatch):
+		return http.StatusForbidden, "The invitation was sent to another email address"
+	case errors.Is(err, ErrAlreadyMember):
+		return http.StatusConflict, "Already a member of this organization"
+	case errors.Is(err, ErrMemberNotFound):
+		return http.StatusNotFound, "Member not found"
+	case errors.Is(err, ErrOwnerImmutable):
+		return http.StatusConflict, "Transfer ownership before changing the owner's membership"
+	case errors.Is(err, ErrInsufficientRole):
+		return http.StatusForbidden, "Your role in the organization does not allow this"
+	case errors.Is(err, ErrNotOrgMember):
+		return http.StatusForbidden, "You are not an active member of this organization"
+	case errors.Is(err, ErrRoleNotFound):
+		return http.StatusNotFound, "Role not found"
+	case errors.Is(err, ErrRoleNameTaken):
+		return http.StatusConflict, "A role with this name already exists"
+	case errors.Is(err, ErrInvalidPermission):
+		return http.StatusBadRequest, "Unknown permission or permission that cannot be granted by a custom role"
+	case errors.Is(err, ErrPlatformAdminProtected):
+		return http.StatusConflict, "Platform admin accounts cannot be locked, reset or deactivated through the admin API"
+	case errors.Is(err, ErrInvalidMagicLink):
+		return http.StatusBadRequest, "Invalid or expired sign-in link"
+	case errors.Is(err, ErrMagicLinkDisabled):
+		return http.StatusForbidden, "Sign-in by email link is disabled by your organization"
+	case errors.Is(err, ErrInvalidCurrentPassword):
+		return http.StatusBadRequest, "Current password is incorrect"
+	case errors.Is(err, ErrInvalidEmailChange):
+		return http.StatusBadRequest, "Invalid or expired confirmation link"
+	case errors.Is(err, ErrOwnershipTransferRequired):
+		return http.StatusConflict, "Transfer ownership of organizations with other members first"
+	case errors.Is(err, ErrInvalidDeletionCancel):
+		return http.StatusBadRequest, "Invalid or expired cancellation link"
+	case errors.Is(err, ErrDataExportNotFound):
+		return http.StatusNotFound, "Data export not found"
+	case errors.Is(err, ErrInvalidDataExportLink):
+		return http.StatusBadRequest, "Invalid or expired download link"
 	default:
 		return http.StatusInternalServerError, "Internal server error"
 	}
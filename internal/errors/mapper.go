@@ -2,6 +2,8 @@ package errors
 
 import (
 	"errors"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/validator"
 )
 
 type HTTPError struct {
@@ -26,6 +28,116 @@ func MapErrorToHTTP(err error) HTTPError {
 	case errors.Is(err, ErrInvalidFingerprint):
 		return HTTPError{401, "invalid_fingerprint", "Invalid session fingerprint"}
 
+	// MFA errors
+	case errors.Is(err, ErrInvalidMFACode):
+		return HTTPError{401, "invalid_mfa_code", "Invalid verification code"}
+	case errors.Is(err, ErrMFAAlreadyEnabled):
+		return HTTPError{409, "mfa_already_enabled", "MFA is already enabled"}
+	case errors.Is(err, ErrMFANotEnabled):
+		return HTTPError{400, "mfa_not_enabled", "MFA is not set up for this account"}
+
+	// Passkey errors
+	case errors.Is(err, ErrInvalidPasskey):
+		return HTTPError{400, "invalid_passkey", "Passkey verification failed"}
+	case errors.Is(err, ErrPasskeyNotFound):
+		return HTTPError{404, "passkey_not_found", "Passkey not found"}
+
+	// Social login errors
+	case errors.Is(err, ErrSocialProviderNotFound):
+		return HTTPError{404, "social_provider_not_found", "Identity provider not found"}
+	case errors.Is(err, ErrSocialLoginFailed):
+		return HTTPError{401, "social_login_failed", "Sign-in with the identity provider failed"}
+	case errors.Is(err, ErrSocialEmailNotVerified):
+		return HTTPError{403, "social_email_not_verified", "The identity provider did not confirm the email address"}
+	case errors.Is(err, ErrIdentityNotFound):
+		return HTTPError{404, "identity_not_found", "Linked account not found"}
+	case errors.Is(err, ErrLastLoginMethod):
+		return HTTPError{409, "last_login_method", "Cannot remove the last way to sign in; set a password first"}
+
+	// SAML single sign-on errors
+	case errors.Is(err, ErrSAMLNotConfigured):
+		return HTTPError{404, "saml_not_configured", "Single sign-on is not configured for this organization"}
+	case errors.Is(err, ErrInvalidSAMLMetadata):
+		return HTTPError{400, "invalid_saml_metadata", "Invalid SAML identity provider metadata"}
+	case errors.Is(err, ErrSAMLLoginFailed):
+		return HTTPError{401, "saml_login_failed", "Single sign-on failed"}
+	case errors.Is(err, ErrSAMLUserNotMember):
+		return HTTPError{403, "saml_user_not_member", "The user is not a member of this organization"}
+
+	// Organization domain errors
+	case errors.Is(err, ErrInvalidDomain):
+		return HTTPError{400, "invalid_domain", "Invalid domain"}
+	case errors.Is(err, ErrDomainAlreadyClaimed):
+		return HTTPError{409, "domain_already_claimed", "The domain is already claimed by another organization"}
+	case errors.Is(err, ErrDomainVerificationFailed):
+		return HTTPError{400, "domain_verification_failed", "The DNS verification record was not found"}
+	case errors.Is(err, ErrDomainNotVerified):
+		return HTTPError{409, "domain_not_verified", "The domain is not verified"}
+	case errors.Is(err, ErrSSORequired):
+		return HTTPError{403, "sso_required", "Sign in through your organization's single sign-on"}
+
+	// SCIM errors
+	case errors.Is(err, ErrSCIMTokenNotFound):
+		return HTTPError{404, "scim_token_not_found", "SCIM token not found"}
+
+	// Organization invitation errors
+	case errors.Is(err, ErrInvitationNotFound):
+		return HTTPError{404, "invitation_not_found", "Invitation not found"}
+	case errors.Is(err, ErrInvalidInvitation):
+		return HTTPError{400, "invitation_invalid", "Invalid or already used invitation"}
+	case errors.Is(err, ErrInvitationExpired):
+		return HTTPError{400, "invitation_expired", "The invitation has expired"}
+	case errors.Is(err, ErrInvitationEmailMismatch):
+		return HTTPError{403, "invitation_email_mismatch", "The invitation was sent to another email address"}
+	case errors.Is(err, ErrAlreadyMember):
+		return HTTPError{409, "already_member", "The user is already a member of this organization"}
+
+	// Organization member errors
+	case errors.Is(err, ErrMemberNotFound):
+		return HTTPError{404, "member_not_found", "Member not found"}
+	case errors.Is(err, ErrOwnerImmutable):
+		return HTTPError{409, "owner_immutable", "Transfer ownership before changing the owner's membership"}
+	case errors.Is(err, ErrInsufficientRole):
+		return HTTPError{403, "insufficient_role", "Your role in the organization does not allow this"}
+	case errors.Is(err, ErrNotOrgMember):
+		return HTTPError{403, "not_org_member", "You are not an active member of this organization"}
+
+	// Custom role errors
+	case errors.Is(err, ErrRoleNotFound):
+		return HTTPError{404, "role_not_found", "Role not found"}
+	case errors.Is(err, ErrRoleNameTaken):
+		return HTTPError{409, "role_name_taken", "A role with this name already exists"}
+	case errors.Is(err, ErrInvalidPermission):
+		return HTTPError{400, "invalid_permission", "Unknown permission or permission that cannot be granted by a custom role"}
+
+	// Platform admin errors
+	case errors.Is(err, ErrPlatformAdminProtected):
+		return HTTPError{409, "platform_admin_protected", "Platform admin accounts cannot be locked, reset or deactivated through the admin API"}
+
+	// Magic link errors
+	case errors.Is(err, ErrInvalidMagicLink):
+		return HTTPError{400, "magic_link_invalid", "Invalid or expired sign-in link"}
+	case errors.Is(err, ErrMagicLinkDisabled):
+		return HTTPError{403, "magic_link_disabled", "Sign-in by email link is disabled by your organization"}
+
+	// Email change errors
+	case errors.Is(err, ErrInvalidCurrentPassword):
+		return HTTPError{400, "invalid_current_password", "Current password is incorrect"}
+	case errors.Is(err, ErrInvalidEmailChange):
+		return HTTPError{400, "email_change_invalid", "Invalid or expired confirmation link"}
+
+	// Account deletion errors
+	case errors.Is(err, ErrOwnershipTransferRequired):
+		return HTTPError{409, "ownership_transfer_required", "Transfer ownership of organizations with other members before deleting your account"}
+	case errors.Is(err, ErrInvalidDeletionCancel):
+		return HTTPError{400, "deletion_cancel_invalid", "Invalid or expired cancellation link"}
+
+	// Data export errors
+	case errors.Is(err, ErrDataExportNotFound):
+		return HTTPError{404, "data_export_not_found", "Data export not found"}
+	case errors.Is(err, ErrInvalidDataExportLink):
+		return HTTPError{400, "data_export_link_invalid", "Invalid or expired download link"}
+
 	// Registration errors
 	case errors.Is(err, ErrEmailAlreadyUsed):
 		return HTTPError{409, "email_exists", "Email already registered"}
@@ -39,6 +151,10 @@ func MapErrorToHTTP(err error) HTTPError {
 		return HTTPError{400, "password_too_weak", "Password does not meet security requirements"}
 	case errors.Is(err, ErrInvalidInput):
 		return HTTPError{400, "invalid_input", "Invalid input data"}
+	case errors.Is(err, validator.ErrInvalidEmail), errors.Is(err, validator.ErrEmailTooLong),
+		errors.Is(err, validator.ErrNameTooLong), errors.Is(err, validator.ErrNameInvalidChars),
+		errors.Is(err, validator.ErrInvalidLocale), errors.Is(err, validator.ErrInvalidTimezone):
+		return HTTPError{400, "invalid_input", err.Error()}
 
 	// Password reset errors
 	case errors.Is(err, ErrInvalidResetToken):
@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/response"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/service"
+)
+
+type AdminService interface {
+	PlatformAdminChecker
+	SearchUsers(ctx context.Context, adminID uuid.UUID, query string, limit, offset int, ipAddress, userAgent string) ([]*model.User, error)
+	GetUser(ctx context.Context, adminID, userID uuid.UUID, ipAddress, userAgent string) (*service.AdminUserDetails, error)
+	LockUser(ctx context.Context, adminID, userID uuid.UUID, until time.Time, ipAddress, userAgent string) (*model.User, error)
+	UnlockUser(ctx context.Context, adminID, userID uuid.UUID, ipAddress, userAgent string) (*model.User, error)
+	ForcePasswordReset(ctx context.Context, adminID, userID uuid.UUID, ipAddress, userAgent string) error
+	RevokeSessions(ctx context.Context, adminID, userID uuid.UUID, ipAddress, userAgent string) error
+	DeactivateUser(ctx context.Context, adminID, userID uuid.UUID, ipAddress, userAgent string) (*model.User, error)
+	RestoreUser(ctx context.Context, adminID, userID uuid.UUID, ipAddress, userAgent string) (*model.User, error)
+}
+
+type AdminHandler struct {
+	adminService AdminService
+}
+
+func NewAdminHandler(adminService AdminService) *AdminHandler {
+	return &AdminHandler{adminService: adminService}
+}
+
+// SearchUsers lists users whose email or name contains the q parameter.
+func (h *AdminHandler) SearchUsers(c *gin.Context) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+	users, err := h.adminService.SearchUsers(c.Request.Context(), adminID, c.Query("q"), limit, offset, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	result := make([]AdminUserResponse, 0, len(users))
+	for _, user := range users {
+		result = append(result, adminUserResponse(user))
+	}
+	response.Success(c, http.StatusOK, gin.H{"users": result})
+}
+
+// GetUser returns the user with their memberships and active sessions.
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	adminID, userID, ok := adminUserParams(c)
+	if !ok {
+		return
+	}
+
+	details, err := h.adminService.GetUser(c.Request.Context(), adminID, userID, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	memberships := make([]AdminMembershipResponse, 0, len(details.Memberships))
+	for _, membership := range details.Memberships {
+		memberships = append(memberships, AdminMembershipResponse{
+			OrgID:        membership.OrgID,
+			Role:         string(membership.Role),
+			CustomRoleID: membership.CustomRoleID,
+			IsActive:     membership.IsActive,
+			CreatedAt:    membership.CreatedAt,
+		})
+	}
+	sessions := make([]AdminSessionResponse, 0, len(details.Sessions))
+	for _, session := range details.Sessions {
+		sessions = append(sessions, AdminSessionResponse{
+			ID:        session.ID,
+			OrgID:     session.OrgID,
+			UserAgent: session.UserAgent,
+			IPAddress: session.IPAddress,
+			CreatedAt: session.CreatedAt,
+			ExpiresAt: session.ExpiresAt,
+		})
+	}
+	response.Success(c, http.StatusOK, gin.H{
+		"user":        adminUserResponse(details.User),
+		"memberships": memberships,
+		"sessions":    sessions,
+	})
+}
+
+func (h *AdminHandler) LockUser(c *gin.Context) {
+	adminID, userID, ok := adminUserParams(c)
+	if !ok {
+		return
+	}
+
+	var req AdminLockUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	user, err := h.adminService.LockUser(c.Request.Context(), adminID, userID, req.Until, c.ClientIP(), c.GetHeader("User-Agent"))
+	h.respondUser(c, user, err)
+}
+
+func (h *AdminHandler) UnlockUser(c *gin.Context) {
+	adminID, userID, ok := adminUserParams(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.adminService.UnlockUser(c.Request.Context(), adminID, userID, c.ClientIP(), c.GetHeader("User-Agent"))
+	h.respondUser(c, user, err)
+}
+
+func (h *AdminHandler) ForcePasswordReset(c *gin.Context) {
+	adminID, userID, ok := adminUserParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.adminService.ForcePasswordReset(c.Request.Context(), adminID, userID, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "Password reset email sent"})
+}
+
+func (h *AdminHandler) RevokeSessions(c *gin.Context) {
+	adminID, userID, ok := adminUserParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.adminService.RevokeSessions(c.Request.Context(), adminID, userID, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "All sessions revoked"})
+}
+
+func (h *AdminHandler) DeactivateUser(c *gin.Context) {
+	adminID, userID, ok := adminUserParams(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.adminService.DeactivateUser(c.Request.Context(), adminID, userID, c.ClientIP(), c.GetHeader("User-Agent"))
+	h.respondUser(c, user, err)
+}
+
+func (h *AdminHandler) RestoreUser(c *gin.Context) {
+	adminID, userID, ok := adminUserParams(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.adminService.RestoreUser(c.Request.Context(), adminID, userID, c.ClientIP(), c.GetHeader("User-Agent"))
+	h.respondUser(c, user, err)
+}
+
+func (h *AdminHandler) respondUser(c *gin.Context, user *model.User, err error) {
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+	response.Success(c, http.StatusOK, adminUserResponse(user))
+}
+
+func adminUserParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Unauthorized")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return adminID, userID, true
+}
+
+func adminUserResponse(user *model.User) AdminUserResponse {
+	return AdminUserResponse{
+		ID:                  user.ID,
+		Email:               user.Email,
+		FullName:            user.FullName,
+		IsActive:            user.IsActive,
+		IsPlatformAdmin:     user.IsPlatformAdmin,
+		FailedLoginAttempts: user.FailedLoginAttempts,
+		LockedUntil:         user.LockedUntil,
+		DeletedAt:           user.DeletedAt,
+		CreatedAt:           user.CreatedAt,
+		UpdatedAt:           user.UpdatedAt,
+	}
+}
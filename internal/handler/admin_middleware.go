@@ -1,14 +1,21 @@
 package handler
 
 import (
+	"context"
 	"net/http"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/token"
 )
 
-// AdminAuthMiddleware protects admin endpoints
+// AdminAuthMiddleware protects operational endpoints (metrics, debug,
+// cleanup) that are called by tooling rather than people.
 // In production: use IP whitelist or basic auth
 func AdminAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -60,3 +67,45 @@ func AdminAuthMiddleware() gin.HandlerFunc {
 		c.Abort()
 	}
 }
+
+// PlatformAdminChecker reports whether a user currently holds the platform
+// admin flag.
+type PlatformAdminChecker interface {
+	IsPlatformAdmin(ctx context.Context, userID uuid.UUID) (bool, error)
+}
+
+// RequirePlatformAdmin protects the /admin endpoints. It must run after
+// AuthMiddleware and lets the request through only for platform admins whose
+// session was authenticated with a second factor (amr claim). The flag is
+// looked up on every request, so removing it takes effect immediately.
+func RequirePlatformAdmin(checker PlatformAdminChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := currentUserID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Unauthorized"})
+			c.Abort()
+			return
+		}
+
+		if !slices.Contains(c.GetStringSlice("amr"), token.AMRMultiFactor) {
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "Multi-factor authentication required"})
+			c.Abort()
+			return
+		}
+
+		admin, err := checker.IsPlatformAdmin(c.Request.Context(), uid)
+		if err != nil {
+			log.Error().Err(err).Str("user_id", uid.String()).Msg("Platform admin check failed")
+			c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "Service temporarily unavailable"})
+			c.Abort()
+			return
+		}
+		if !admin {
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "Platform admin access required"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
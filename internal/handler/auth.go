@@ -76,12 +76,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		h.metrics.IncrementRegistrations()
 	}
 
-	response.Success(c, http.StatusCreated, UserResponse{
-		ID:       user.ID,
-		Email:    user.Email,
-		FullName: user.FullName,
-		IsActive: user.IsActive,
-	})
+	response.Success(c, http.StatusCreated, userResponse(user))
 }
 
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -94,7 +89,12 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	userAgent := c.GetHeader("User-Agent")
 	ipAddress := c.ClientIP()
 
-	accessToken, refreshToken, err := h.authService.Login(c.Request.Context(), req.Email, req.Password, userAgent, ipAddress)
+	orgID := uuid.Nil
+	if req.OrgID != nil {
+		orgID = *req.OrgID
+	}
+
+	result, err := h.authService.Login(c.Request.Context(), req.Email, req.Password, orgID, userAgent, ipAddress)
 	if err != nil {
 		// Audit log failed login
 		if h.auditService != nil {
@@ -109,9 +109,18 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	// Second factor required - tokens are issued by VerifyMFA
+	if result.MFARequired {
+		response.Success(c, http.StatusOK, MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    result.MFAToken,
+		})
+		return
+	}
+
 	// Audit log successful login
 	if h.auditService != nil {
-		_ = h.auditService.Log(c.Request.Context(), nil, "user_logged_in", map[string]interface{}{"email": req.Email}, ipAddress, userAgent)
+		_ = h.auditService.Log(c.Request.Context(), &result.UserID, "user_logged_in", map[string]interface{}{"email": req.Email}, ipAddress, userAgent)
 	}
 
 	// Metrics
@@ -120,8 +129,42 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	response.Success(c, http.StatusOK, AuthResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
+		AccessToken:  result.AccessToken,
+		RefreshToken: result.RefreshToken,
+	})
+}
+
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	userAgent := c.GetHeader("User-Agent")
+	ipAddress := c.ClientIP()
+
+	result, err := h.authService.CompleteMFALogin(c.Request.Context(), req.MFAToken, req.Code, userAgent, ipAddress)
+	if err != nil {
+		if h.metrics != nil {
+			h.metrics.IncrementLoginFailures()
+		}
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	if h.auditService != nil {
+		_ = h.auditService.Log(c.Request.Context(), &result.UserID, "user_logged_in", map[string]interface{}{"mfa": true}, ipAddress, userAgent)
+	}
+
+	if h.metrics != nil {
+		h.metrics.IncrementLogins()
+	}
+
+	response.Success(c, http.StatusOK, AuthResponse{
+		AccessToken:  result.AccessToken,
+		RefreshToken: result.RefreshToken,
 	})
 }
 
@@ -135,7 +178,7 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 	userAgent := c.GetHeader("User-Agent")
 	ipAddress := c.ClientIP()
 
-	accessToken, err := h.authService.RefreshToken(c.Request.Context(), req.RefreshToken, userAgent, ipAddress)
+	result, err := h.authService.RefreshToken(c.Request.Context(), req.RefreshToken, userAgent, ipAddress)
 	if err != nil {
 		httpErr := errors.MapErrorToHTTP(err)
 		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
@@ -147,7 +190,38 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 		h.metrics.IncrementTokenRefreshes()
 	}
 
-	response.Success(c, http.StatusOK, gin.H{"access_token": accessToken})
+	response.Success(c, http.StatusOK, AuthResponse{
+		AccessToken:  result.AccessToken,
+		RefreshToken: result.RefreshToken,
+	})
+}
+
+// SwitchOrganization replaces the current session with one scoped to another
+// organization of the user.
+func (h *AuthHandler) SwitchOrganization(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	var req SwitchOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	result, err := h.authService.SwitchOrganization(c.Request.Context(), uid, currentSessionID(c), req.OrgID, c.GetStringSlice("amr"), c.GetHeader("User-Agent"), c.ClientIP())
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, AuthResponse{
+		AccessToken:  result.AccessToken,
+		RefreshToken: result.RefreshToken,
+	})
 }
 
 func (h *AuthHandler) Logout(c *gin.Context) {
@@ -1,32 +1,98 @@
 package handler
 
 import (
+	"context"
 	"net/http"
+	"net/url"
+	"os"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/config"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/response"
 )
 
-// ComplianceReporter interface for generating compliance reports
+// ComplianceReporter aggregates the numbers of the GDPR compliance report.
+// Periods are half-open: from is included, to is not.
 type ComplianceReporter interface {
-	GetDataExportCount(startDate, endDate time.Time) (int64, error)
-	GetAccountDeletionCount(startDate, endDate time.Time) (int64, error)
-	GetActiveUsersCount() (int64, error)
-	GetAuditLogCount(startDate, endDate time.Time) (int64, error)
+	GetDataExportCount(ctx context.Context, from, to time.Time) (int64, error)
+	GetAccountDeletionCount(ctx context.Context, from, to time.Time) (int64, error)
+	GetActiveUsersCount(ctx context.Context) (int64, error)
+	GetAuditLogCount(ctx context.Context, from, to time.Time) (int64, error)
+}
+
+// ComplianceFeatures lists which GDPR and security measures are enabled in
+// this deployment. It is derived from the configuration and the services
+// wired into the router, see complianceFeatures.
+type ComplianceFeatures struct {
+	DataExport          bool // Art. 15, 20
+	AccountDeletion     bool // Art. 17
+	ConsentManagement   bool // Art. 7
+	DataRetention       bool // Art. 5.1.e
+	AuditLogging        bool // Art. 30
+	BreachNotification  bool // Art. 33-34, security emails to users
+	SessionManagement   bool
+	MFA                 bool
+	EncryptionInTransit bool
+	EncryptionAtRest    bool
 }
 
 type ComplianceHandler struct {
 	reporter ComplianceReporter
+	features ComplianceFeatures
 }
 
-func NewComplianceHandler(reporter ComplianceReporter) *ComplianceHandler {
+func NewComplianceHandler(reporter ComplianceReporter, features ComplianceFeatures) *ComplianceHandler {
 	return &ComplianceHandler{
 		reporter: reporter,
+		features: features,
 	}
 }
 
+// complianceFeatures derives the enabled measures from the configuration and
+// from which optional services are available.
+func complianceFeatures(cfg *config.Config, dataExport, deletion, consent, cleanup, audit, session, mfa bool) ComplianceFeatures {
+	features := ComplianceFeatures{
+		DataExport:         dataExport,
+		AccountDeletion:    deletion,
+		ConsentManagement:  consent,
+		DataRetention:      cleanup,
+		AuditLogging:       audit,
+		BreachNotification: os.Getenv("SENDGRID_API_KEY") != "",
+		SessionManagement:  session,
+		MFA:                mfa,
+	}
+	if cfg != nil {
+		features.EncryptionInTransit, features.EncryptionAtRest = databaseEncryption(cfg.Database.URL)
+	}
+	return features
+}
+
+// databaseEncryption reports whether the database connection is encrypted and
+// whether the database is known to encrypt its storage. Cloud SQL connections
+// through the /cloudsql socket go through the Cloud SQL proxy, which uses TLS,
+// and Cloud SQL always encrypts data at rest. Elsewhere, only an sslmode that
+// enforces TLS counts; encryption at rest cannot be told from the URL.
+func databaseEncryption(databaseURL string) (inTransit, atRest bool) {
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		return false, false
+	}
+	if strings.HasPrefix(u.Query().Get("host"), "/cloudsql/") {
+		return true, true
+	}
+	switch u.Query().Get("sslmode") {
+	case "require", "verify-ca", "verify-full":
+		return true, false
+	}
+	return false, false
+}
+
 // isValidDateString validates date string format to prevent XSS
 func isValidDateString(date string) bool {
 	matched, _ := regexp.MatchString(`^\d{4}-\d{2}-\d{2}$`, date)
@@ -42,14 +108,13 @@ func isValidDateRange(t time.Time) bool {
 	return t.After(minDate) && t.Before(maxDate)
 }
 
-// GetComplianceReport returns GDPR compliance report
+// GetComplianceReport returns GDPR compliance report. The period defaults to
+// the last 30 days; end_date is inclusive.
 func (h *ComplianceHandler) GetComplianceReport(c *gin.Context) {
-	// Only admins should access this
-	// TODO: Add admin role check
-
 	// Default: last 30 days
 	endDate := time.Now()
 	startDate := endDate.AddDate(0, 0, -30)
+	until := endDate
 
 	// Parse query params if provided
 	if start := c.Query("start_date"); start != "" {
@@ -65,70 +130,93 @@ func (h *ComplianceHandler) GetComplianceReport(c *gin.Context) {
 		if len(end) == 10 && isValidDateString(end) {
 			if t, err := time.Parse("2006-01-02", end); err == nil && isValidDateRange(t) {
 				endDate = t
+				until = t.AddDate(0, 0, 1)
 			}
 		}
 	}
+	if !startDate.Before(until) {
+		response.BadRequest(c, "start_date must not be after end_date")
+		return
+	}
 
-	// Use only validated dates in response to prevent XSS
-	safeStartDate := startDate.Format("2006-01-02")
-	safeEndDate := endDate.Format("2006-01-02")
+	ctx := c.Request.Context()
+	exports, err := h.reporter.GetDataExportCount(ctx, startDate, until)
+	if err != nil {
+		h.reportFailed(c, err)
+		return
+	}
+	deletions, err := h.reporter.GetAccountDeletionCount(ctx, startDate, until)
+	if err != nil {
+		h.reportFailed(c, err)
+		return
+	}
+	activeUsers, err := h.reporter.GetActiveUsersCount(ctx)
+	if err != nil {
+		h.reportFailed(c, err)
+		return
+	}
+	auditEntries, err := h.reporter.GetAuditLogCount(ctx, startDate, until)
+	if err != nil {
+		h.reportFailed(c, err)
+		return
+	}
 
-	report := gin.H{
+	// Use only validated dates in response to prevent XSS
+	c.JSON(http.StatusOK, gin.H{
 		"report_id":    uuid.New().String(),
 		"generated_at": time.Now().UTC().Format(time.RFC3339),
 		"period": gin.H{
-			"start_date": safeStartDate,
-			"end_date":   safeEndDate,
+			"start_date": startDate.Format("2006-01-02"),
+			"end_date":   endDate.Format("2006-01-02"),
 		},
 		"gdpr_compliance": gin.H{
-			"data_export_requests":      0,
-			"account_deletion_requests": 0,
-			"active_users":              0,
-			"audit_log_entries":         0,
+			"data_export_requests":      exports,
+			"account_deletion_requests": deletions,
+			"active_users":              activeUsers,
+			"audit_log_entries":         auditEntries,
 		},
 		"status": "success",
+	})
+}
+
+func (h *ComplianceHandler) reportFailed(c *gin.Context, err error) {
+	log.Error().Err(err).Msg("Failed to build compliance report")
+	response.InternalError(c, "Failed to build compliance report")
+}
+
+// GetComplianceStatus returns current compliance status. It is "compliant"
+// only if every GDPR measure is enabled.
+func (h *ComplianceHandler) GetComplianceStatus(c *gin.Context) {
+	f := h.features
+	gdpr := gin.H{
+		"right_to_access":       f.DataExport,         // Art. 15
+		"right_to_erasure":      f.AccountDeletion,    // Art. 17
+		"right_to_portability":  f.DataExport,         // Art. 20
+		"consent_management":    f.ConsentManagement,  // Art. 7
+		"data_retention_policy": f.DataRetention,      // Art. 5.1.e
+		"audit_logging":         f.AuditLogging,       // Art. 30
+		"breach_notification":   f.BreachNotification, // Art. 33-34
 	}
 
-	if h.reporter != nil {
-		if count, err := h.reporter.GetDataExportCount(startDate, endDate); err == nil {
-			report["gdpr_compliance"].(gin.H)["data_export_requests"] = count
-		}
-		if count, err := h.reporter.GetAccountDeletionCount(startDate, endDate); err == nil {
-			report["gdpr_compliance"].(gin.H)["account_deletion_requests"] = count
-		}
-		if count, err := h.reporter.GetActiveUsersCount(); err == nil {
-			report["gdpr_compliance"].(gin.H)["active_users"] = count
-		}
-		if count, err := h.reporter.GetAuditLogCount(startDate, endDate); err == nil {
-			report["gdpr_compliance"].(gin.H)["audit_log_entries"] = count
+	status := "compliant"
+	for _, enabled := range gdpr {
+		if !enabled.(bool) {
+			status = "action_required"
 		}
 	}
 
-	c.JSON(http.StatusOK, report)
-}
-
-// GetComplianceStatus returns current compliance status
-func (h *ComplianceHandler) GetComplianceStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"status": "compliant",
-		"gdpr": gin.H{
-			"right_to_access":       true, // Art. 15
-			"right_to_erasure":      true, // Art. 17
-			"right_to_portability":  true, // Art. 20
-			"consent_management":    true, // Art. 7
-			"data_retention_policy": true, // Art. 5.1.e
-			"audit_logging":         true, // Art. 30
-			"breach_notification":   true, // Art. 33 - Email notifications implemented
-		},
+		"status": status,
+		"gdpr":   gdpr,
 		"security": gin.H{
-			"password_hashing":      true,
-			"rate_limiting":         true,
-			"input_validation":      true,
-			"session_management":    true,
-			"audit_logging":         true,
-			"encryption_in_transit": true,
-			"encryption_at_rest":    false, // TODO
-			"mfa_support":           false, // TODO
+			"password_hashing":      true, // Argon2id, always on
+			"rate_limiting":         true, // always on
+			"input_validation":      true, // always on
+			"session_management":    f.SessionManagement,
+			"audit_logging":         f.AuditLogging,
+			"encryption_in_transit": f.EncryptionInTransit,
+			"encryption_at_rest":    f.EncryptionAtRest,
+			"mfa_support":           f.MFA,
 		},
 		"last_updated": time.Now().UTC().Format(time.RFC3339),
 	})
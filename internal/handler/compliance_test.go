@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingReporter struct {
+	from, to time.Time
+	err      error
+}
+
+func (r *recordingReporter) GetDataExportCount(_ context.Context, from, to time.Time) (int64, error) {
+	r.from, r.to = from, to
+	return 3, r.err
+}
+
+func (r *recordingReporter) GetAccountDeletionCount(context.Context, time.Time, time.Time) (int64, error) {
+	return 2, nil
+}
+
+func (r *recordingReporter) GetActiveUsersCount(context.Context) (int64, error) {
+	return 120, nil
+}
+
+func (r *recordingReporter) GetAuditLogCount(context.Context, time.Time, time.Time) (int64, error) {
+	return 4500, nil
+}
+
+func TestComplianceHandler_GetComplianceReport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(reporter ComplianceReporter, query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/admin/compliance/report"+query, nil)
+		NewComplianceHandler(reporter, ComplianceFeatures{}).GetComplianceReport(c)
+		return w
+	}
+
+	t.Run("counts with end date included", func(t *testing.T) {
+		start := time.Now().AddDate(0, -2, 0).Format("2006-01-02")
+		end := time.Now().AddDate(0, -1, 0).Format("2006-01-02")
+		reporter := &recordingReporter{}
+
+		w := serve(reporter, "?start_date="+start+"&end_date="+end)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var body struct {
+			Period         map[string]string `json:"period"`
+			GDPRCompliance map[string]int64  `json:"gdpr_compliance"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, map[string]string{"start_date": start, "end_date": end}, body.Period)
+		assert.Equal(t, map[string]int64{
+			"data_export_requests":      3,
+			"account_deletion_requests": 2,
+			"active_users":              120,
+			"audit_log_entries":         4500,
+		}, body.GDPRCompliance)
+		assert.Equal(t, start, reporter.from.Format("2006-01-02"))
+		assert.Equal(t, end, reporter.to.AddDate(0, 0, -1).Format("2006-01-02"))
+	})
+
+	t.Run("rejects reversed period", func(t *testing.T) {
+		start := time.Now().AddDate(0, -1, 0).Format("2006-01-02")
+		end := time.Now().AddDate(0, -2, 0).Format("2006-01-02")
+
+		w := serve(&recordingReporter{}, "?start_date="+start+"&end_date="+end)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("fails instead of reporting zeros", func(t *testing.T) {
+		w := serve(&recordingReporter{err: errors.New("db down")}, "")
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.NotContains(t, w.Body.String(), "gdpr_compliance")
+	})
+}
+
+func TestComplianceHandler_GetComplianceStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serve := func(features ComplianceFeatures) map[string]interface{} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/admin/compliance/status", nil)
+		NewComplianceHandler(&recordingReporter{}, features).GetComplianceStatus(c)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return body
+	}
+
+	all := ComplianceFeatures{
+		DataExport: true, AccountDeletion: true, ConsentManagement: true, DataRetention: true,
+		AuditLogging: true, BreachNotification: true, SessionManagement: true, MFA: true,
+	}
+	body := serve(all)
+	assert.Equal(t, "compliant", body["status"])
+	assert.Equal(t, false, body["security"].(map[string]interface{})["encryption_at_rest"])
+
+	all.BreachNotification = false
+	body = serve(all)
+	assert.Equal(t, "action_required", body["status"])
+	assert.Equal(t, false, body["gdpr"].(map[string]interface{})["breach_notification"])
+}
+
+func TestDatabaseEncryption(t *testing.T) {
+	tests := []struct {
+		url               string
+		inTransit, atRest bool
+	}{
+		{"postgresql://u:p@/zeno?host=/cloudsql/project:europe-west1:db", true, true},
+		{"postgresql://u:p@db:5432/zeno?sslmode=verify-full", true, false},
+		{"postgresql://u:p@db:5432/zeno?sslmode=require", true, false},
+		{"postgresql://u:p@localhost:5432/zeno?sslmode=disable", false, false},
+		{"postgresql://u:p@localhost:5432/zeno", false, false},
+	}
+	for _, tt := range tests {
+		inTransit, atRest := databaseEncryption(tt.url)
+		assert.Equal(t, tt.inTransit, inTransit, tt.url)
+		assert.Equal(t, tt.atRest, atRest, tt.url)
+	}
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// currentUserID returns the authenticated user ID set by AuthMiddleware.
+func currentUserID(c *gin.Context) (uuid.UUID, bool) {
+	uid, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil || uid == uuid.Nil {
+		return uuid.Nil, false
+	}
+	return uid, true
+}
+
+// currentSessionID returns the session of the access token, or uuid.Nil for
+// tokens issued without one.
+func currentSessionID(c *gin.Context) uuid.UUID {
+	sessionID, err := uuid.Parse(c.GetString("session_id"))
+	if err != nil {
+		return uuid.Nil
+	}
+	return sessionID
+}
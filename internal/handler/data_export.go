@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/response"
+)
+
+// dataExportDownloadTimeout replaces the server write timeout for downloads,
+// which can take longer for large archives.
+const dataExportDownloadTimeout = 10 * time.Minute
+
+type DataExportService interface {
+	RequestExport(ctx context.Context, userID uuid.UUID, ipAddress, userAgent string) (*model.DataExport, error)
+	GetExport(ctx context.Context, userID, exportID uuid.UUID) (*model.DataExport, error)
+	OpenDownload(ctx context.Context, rawToken, ipAddress, userAgent string) (*model.DataExport, io.ReadCloser, error)
+}
+
+type DataExportHandler struct {
+	dataExportService DataExportService
+}
+
+func NewDataExportHandler(dataExportService DataExportService) *DataExportHandler {
+	return &DataExportHandler{dataExportService: dataExportService}
+}
+
+// Request starts an export of the user's data. The user is emailed a
+// download link once it is ready; meanwhile the frontend can poll Get.
+func (h *DataExportHandler) Request(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	export, err := h.dataExportService.RequestExport(c.Request.Context(), uid, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, dataExportResponse(export))
+}
+
+func (h *DataExportHandler) Get(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	exportID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid export ID")
+		return
+	}
+
+	export, err := h.dataExportService.GetExport(c.Request.Context(), uid, exportID)
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, dataExportResponse(export))
+}
+
+// Download streams the archive for the token from the emailed link. It does
+// not require a session, so the link also works on another device.
+func (h *DataExportHandler) Download(c *gin.Context) {
+	var req DataExportDownloadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	export, archive, err := h.dataExportService.OpenDownload(c.Request.Context(), req.Token, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+	defer func() { _ = archive.Close() }()
+
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(dataExportDownloadTimeout)); err != nil {
+		log.Warn().Err(err).Msg("Failed to extend write deadline for data export download")
+	}
+
+	filename := fmt.Sprintf("zeno-auth-data-export-%s.zip", export.CreatedAt.UTC().Format("2006-01-02"))
+	c.DataFromReader(http.StatusOK, export.SizeBytes, "application/zip", archive, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="%s"`, filename),
+		"Cache-Control":       "no-store",
+	})
+}
+
+func dataExportResponse(export *model.DataExport) DataExportResponse {
+	return DataExportResponse{
+		ID:          export.ID,
+		Status:      string(export.Status),
+		SizeBytes:   export.SizeBytes,
+		ExpiresAt:   export.ExpiresAt,
+		CompletedAt: export.CompletedAt,
+		CreatedAt:   export.CreatedAt,
+	}
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/response"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/service"
+)
+
+type DomainService interface {
+	ListDomains(ctx context.Context, userID, orgID uuid.UUID) ([]*model.OrganizationDomain, error)
+	ClaimDomain(ctx context.Context, userID, orgID uuid.UUID, domain, ipAddress, userAgent string) (*model.OrganizationDomain, error)
+	VerifyDomain(ctx context.Context, userID, orgID, domainID uuid.UUID, ipAddress, userAgent string) (*model.OrganizationDomain, error)
+	SetSSOEnforced(ctx context.Context, userID, orgID, domainID uuid.UUID, enforced bool, ipAddress, userAgent string) (*model.OrganizationDomain, error)
+	DeleteDomain(ctx context.Context, userID, orgID, domainID uuid.UUID, ipAddress, userAgent string) error
+	SSOConnection(ctx context.Context, email string) (*model.OrganizationDomain, error)
+}
+
+type DomainHandler struct {
+	domainService DomainService
+}
+
+func NewDomainHandler(domainService DomainService) *DomainHandler {
+	return &DomainHandler{domainService: domainService}
+}
+
+func (h *DomainHandler) ListDomains(c *gin.Context) {
+	uid, orgID, ok := orgAdminParams(c)
+	if !ok {
+		return
+	}
+
+	domains, err := h.domainService.ListDomains(c.Request.Context(), uid, orgID)
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	result := make([]DomainResponse, 0, len(domains))
+	for _, domain := range domains {
+		result = append(result, domainResponse(domain))
+	}
+	response.Success(c, http.StatusOK, gin.H{"domains": result})
+}
+
+// ClaimDomain returns the DNS record that proves the claim.
+func (h *DomainHandler) ClaimDomain(c *gin.Context) {
+	uid, orgID, ok := orgAdminParams(c)
+	if !ok {
+		return
+	}
+
+	var req ClaimDomainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	domain, err := h.domainService.ClaimDomain(c.Request.Context(), uid, orgID, req.Domain, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, domainResponse(domain))
+}
+
+// VerifyDomain looks up the DNS record of a claimed domain.
+func (h *DomainHandler) VerifyDomain(c *gin.Context) {
+	uid, orgID, domainID, ok := domainParams(c)
+	if !ok {
+		return
+	}
+
+	domain, err := h.domainService.VerifyDomain(c.Request.Context(), uid, orgID, domainID, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, domainResponse(domain))
+}
+
+// UpdateDomain turns SSO enforcement of a verified domain on or off.
+func (h *DomainHandler) UpdateDomain(c *gin.Context) {
+	uid, orgID, domainID, ok := domainParams(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateDomainRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.SSOEnforced == nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	domain, err := h.domainService.SetSSOEnforced(c.Request.Context(), uid, orgID, domainID, *req.SSOEnforced, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, domainResponse(domain))
+}
+
+func (h *DomainHandler) DeleteDomain(c *gin.Context) {
+	uid, orgID, domainID, ok := domainParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.domainService.DeleteDomain(c.Request.Context(), uid, orgID, domainID, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "Domain removed"})
+}
+
+// Discover tells the login page whether the email belongs to an organization
+// that signs in through SSO. The answer depends only on the domain.
+func (h *DomainHandler) Discover(c *gin.Context) {
+	var req SSODiscoveryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	domain, err := h.domainService.SSOConnection(c.Request.Context(), req.Email)
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	if domain == nil {
+		response.Success(c, http.StatusOK, SSODiscoveryResponse{})
+		return
+	}
+	response.Success(c, http.StatusOK, SSODiscoveryResponse{
+		SSO:         true,
+		SSORequired: domain.SSOEnforced,
+		OrgID:       &domain.OrgID,
+	})
+}
+
+func domainParams(c *gin.Context) (uuid.UUID, uuid.UUID, uuid.UUID, bool) {
+	uid, orgID, ok := orgAdminParams(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	domainID, err := uuid.Parse(c.Param("domain_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid domain ID")
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	return uid, orgID, domainID, true
+}
+
+func domainResponse(domain *model.OrganizationDomain) DomainResponse {
+	result := DomainResponse{
+		ID:          domain.ID,
+		Domain:      domain.Domain,
+		Verified:    domain.IsVerified(),
+		VerifiedAt:  domain.VerifiedAt,
+		SSOEnforced: domain.SSOEnforced,
+		CreatedAt:   domain.CreatedAt,
+	}
+	if !domain.IsVerified() {
+		record := service.VerificationRecord(domain)
+		result.Verification = &DomainVerificationRecord{
+			Type:  record.Type,
+			Name:  record.Name,
+			Value: record.Value,
+		}
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/response"
+)
+
+type EmailChangeService interface {
+	RequestChange(ctx context.Context, userID uuid.UUID, currentPassword, newEmail, ipAddress, userAgent string) error
+	ConfirmChange(ctx context.Context, rawToken, ipAddress, userAgent string) (*model.User, error)
+}
+
+type EmailChangeHandler struct {
+	emailChangeService EmailChangeService
+}
+
+func NewEmailChangeHandler(emailChangeService EmailChangeService) *EmailChangeHandler {
+	return &EmailChangeHandler{emailChangeService: emailChangeService}
+}
+
+// Request sends a confirmation link to the new address. The account keeps
+// its current address until the link is confirmed.
+func (h *EmailChangeHandler) Request(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	var req EmailChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	if err := h.emailChangeService.RequestChange(c.Request.Context(), uid, req.CurrentPassword, req.NewEmail, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "A confirmation link has been sent to the new email address"})
+}
+
+// Confirm applies the change. All sessions of the user end, so the frontend
+// should ask them to sign in with the new address.
+func (h *EmailChangeHandler) Confirm(c *gin.Context) {
+	var req EmailChangeConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	user, err := h.emailChangeService.ConfirmChange(c.Request.Context(), req.Token, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"email": user.Email})
+}
@@ -3,41 +3,31 @@ package handler
 import (
 	"context"
 	"net/http"
-	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/rs/zerolog/log"
 
 	apperrors "github.com/ZenoN-Cloud/zeno-auth/internal/errors"
 	"github.com/ZenoN-Cloud/zeno-auth/internal/response"
 )
 
 type GDPRService interface {
-	ExportUserData(ctx context.Context, userID uuid.UUID) (interface{}, error)
-	DeleteUserAccount(ctx context.Context, userID uuid.UUID) error
+	RequestAccountDeletion(ctx context.Context, userID uuid.UUID, ipAddress, userAgent string) (time.Time, error)
+	CancelAccountDeletion(ctx context.Context, rawToken, ipAddress, userAgent string) error
 }
 
 type GDPRHandler struct {
-	gdprService  GDPRService
-	auditService AuditService
-	emailService EmailNotifier
+	gdprService GDPRService
 }
 
-type EmailNotifier interface {
-	SendAccountDeletionNotification(ctx context.Context, userID uuid.UUID) error
-	SendDataExportNotification(ctx context.Context, userID uuid.UUID) error
+func NewGDPRHandler(gdprService GDPRService) *GDPRHandler {
+	return &GDPRHandler{gdprService: gdprService}
 }
 
-func NewGDPRHandler(gdprService GDPRService, auditService AuditService, emailService EmailNotifier) *GDPRHandler {
-	return &GDPRHandler{
-		gdprService:  gdprService,
-		auditService: auditService,
-		emailService: emailService,
-	}
-}
-
-func (h *GDPRHandler) ExportData(c *gin.Context) {
+// DeleteAccount deactivates the account and schedules it for deletion. The
+// user receives a link to restore it until the grace period ends.
+func (h *GDPRHandler) DeleteAccount(c *gin.Context) {
 	userID := c.GetString("user_id")
 	if userID == "" {
 		response.Error(c, http.StatusUnauthorized, "unauthorized", "User ID not found")
@@ -49,65 +39,33 @@ func (h *GDPRHandler) ExportData(c *gin.Context) {
 		return
 	}
 
-	data, err := h.gdprService.ExportUserData(c.Request.Context(), uid)
+	purgeAfter, err := h.gdprService.RequestAccountDeletion(c.Request.Context(), uid, c.ClientIP(), c.GetHeader("User-Agent"))
 	if err != nil {
 		httpErr := apperrors.MapErrorToHTTP(err)
 		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
 		return
 	}
 
-	if h.auditService != nil {
-		clientIP := strings.ReplaceAll(strings.ReplaceAll(c.ClientIP(), "\n", ""), "\r", "")
-		userAgent := strings.ReplaceAll(strings.ReplaceAll(c.Request.UserAgent(), "\n", ""), "\r", "")
-		if err := h.auditService.Log(c.Request.Context(), &uid, "data_exported", nil, clientIP, userAgent); err != nil {
-			log.Error().Err(err).Msg("Failed to log audit event")
-		}
-	}
-
-	if h.emailService != nil {
-		go func() {
-			if err := h.emailService.SendDataExportNotification(c.Request.Context(), uid); err != nil {
-				log.Error().Err(err).Msg("Failed to send data export notification")
-			}
-		}()
-	}
-
-	response.Success(c, http.StatusOK, gin.H{"data": data})
+	response.Success(c, http.StatusOK, gin.H{
+		"message":     "Account scheduled for deletion",
+		"purge_after": purgeAfter,
+	})
 }
 
-func (h *GDPRHandler) DeleteAccount(c *gin.Context) {
-	userID := c.GetString("user_id")
-	if userID == "" {
-		response.Error(c, http.StatusUnauthorized, "unauthorized", "User ID not found")
-		return
-	}
-	uid, err := uuid.Parse(userID)
-	if err != nil {
-		response.Error(c, http.StatusBadRequest, "invalid_user_id", "Invalid user ID")
+// CancelDeletion restores an account scheduled for deletion with the link
+// sent to the user.
+func (h *GDPRHandler) CancelDeletion(c *gin.Context) {
+	var req CancelAccountDeletionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
 		return
 	}
 
-	if err := h.gdprService.DeleteUserAccount(c.Request.Context(), uid); err != nil {
+	if err := h.gdprService.CancelAccountDeletion(c.Request.Context(), req.Token, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
 		httpErr := apperrors.MapErrorToHTTP(err)
 		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
 		return
 	}
 
-	if h.auditService != nil {
-		clientIP := strings.ReplaceAll(strings.ReplaceAll(c.ClientIP(), "\n", ""), "\r", "")
-		userAgent := strings.ReplaceAll(strings.ReplaceAll(c.Request.UserAgent(), "\n", ""), "\r", "")
-		if err := h.auditService.Log(c.Request.Context(), &uid, "account_deleted", nil, clientIP, userAgent); err != nil {
-			log.Error().Err(err).Msg("Failed to log audit event")
-		}
-	}
-
-	if h.emailService != nil {
-		go func() {
-			if err := h.emailService.SendAccountDeletionNotification(c.Request.Context(), uid); err != nil {
-				log.Error().Err(err).Msg("Failed to send account deletion notification")
-			}
-		}()
-	}
-
-	response.Success(c, http.StatusOK, gin.H{"message": "Account deleted successfully"})
+	response.Success(c, http.StatusOK, gin.H{"message": "Account deletion cancelled"})
 }
@@ -1,17 +1,41 @@
 package handler
 
 import (
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"net/http"
+	"strings"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog"
 
 	"github.com/ZenoN-Cloud/zeno-auth/internal/repository"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/token"
 )
 
-func SetupInternalRouter(orgRepo repository.OrganizationRepository, logger zerolog.Logger) *chi.Mux {
+// ScopeOrganizationsWrite allows machine clients to update organization
+// status (billing).
+const ScopeOrganizationsWrite = "organizations:write"
+
+type clientIDContextKey struct{}
+
+// InternalRevocation checks machine client tokens and revokes the access
+// tokens of organizations whose status changes.
+type InternalRevocation interface {
+	RevocationChecker
+	OrganizationRevoker
+}
+
+func SetupInternalRouter(orgRepo repository.OrganizationRepository, jwtManager *token.JWTManager, revocation InternalRevocation, logger zerolog.Logger) *chi.Mux {
 	if orgRepo == nil {
 		logger.Error().Msg("Organization repository is nil, cannot setup internal router")
 		return nil
 	}
+	if jwtManager == nil {
+		logger.Error().Msg("JWT manager is nil, cannot setup internal router")
+		return nil
+	}
 
 	r := chi.NewRouter()
 	if r == nil {
@@ -19,15 +43,76 @@ func SetupInternalRouter(orgRepo repository.OrganizationRepository, logger zerol
 		return nil
 	}
 
-	orgHandler := NewOrganizationHandlerWithRepo(orgRepo, logger)
+	orgHandler := NewOrganizationHandlerWithRepo(orgRepo, revocation, logger)
 	if orgHandler == nil {
 		logger.Error().Msg("Failed to create organization handler")
 		return nil
 	}
 
 	r.Route("/internal/v1", func(r chi.Router) {
-		r.Put("/organizations/{org_id}/status", orgHandler.UpdateOrganizationStatus)
+		r.With(RequireScope(jwtManager, revocation, logger, ScopeOrganizationsWrite)).
+			Put("/organizations/{org_id}/status", orgHandler.UpdateOrganizationStatus)
 	})
 
 	return r
 }
+
+// RequireScope authenticates machine clients on the internal API. It accepts
+// only client_credentials tokens issued for the internal audience that carry
+// the given scope.
+func RequireScope(jwtManager *token.JWTManager, revocation RevocationChecker, logger zerolog.Logger, scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+			if authHeader == "" || tokenString == authHeader {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="zeno-internal"`)
+				writeInternalError(w, http.StatusUnauthorized, "bearer token required")
+				return
+			}
+
+			claims, err := jwtManager.ValidateForAudience(r.Context(), tokenString, token.InternalAudience)
+			if err != nil || claims.ClientID == "" {
+				logger.Warn().Err(err).Str("path", r.URL.Path).Msg("Internal API token rejected")
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				writeInternalError(w, http.StatusUnauthorized, "invalid token")
+				return
+			}
+
+			if revocation != nil {
+				if err := revocation.Check(r.Context(), claims); err != nil {
+					if stdErrors.Is(err, token.ErrTokenRevoked) {
+						w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+						writeInternalError(w, http.StatusUnauthorized, "token revoked")
+						return
+					}
+					logger.Error().Err(err).Str("client_id", claims.ClientID).Msg("Token revocation check failed")
+					writeInternalError(w, http.StatusServiceUnavailable, "service temporarily unavailable")
+					return
+				}
+			}
+
+			if !claims.HasScope(scope) {
+				logger.Warn().Str("client_id", claims.ClientID).Str("required_scope", scope).Msg("Internal API token lacks scope")
+				w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+				writeInternalError(w, http.StatusForbidden, "insufficient scope")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), clientIDContextKey{}, claims.ClientID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ClientIDFromContext returns the machine client authenticated by RequireScope.
+func ClientIDFromContext(ctx context.Context) string {
+	clientID, _ := ctx.Value(clientIDContextKey{}).(string)
+	return clientID
+}
+
+func writeInternalError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/token"
+)
+
+func TestRequireScope(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	jwtManager, err := token.NewJWTManager(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), "")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	issue := func(params token.AccessTokenParams) string {
+		params.TTLSeconds = 60
+		tok, err := jwtManager.GenerateAccessToken(ctx, params)
+		require.NoError(t, err)
+		return tok
+	}
+
+	var calledBy string
+	handler := RequireScope(jwtManager, nil, zerolog.Nop(), ScopeOrganizationsWrite)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calledBy = ClientIDFromContext(r.Context())
+			w.WriteHeader(http.StatusOK)
+		}),
+	)
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"user token", "Bearer " + issue(token.AccessTokenParams{UserID: uuid.New()}), http.StatusUnauthorized},
+		{"OIDC client token", "Bearer " + issue(token.AccessTokenParams{
+			UserID: uuid.New(), ClientID: "grafana", Scope: "openid", Audience: []string{"https://auth.zeno.test"},
+		}), http.StatusUnauthorized},
+		{"missing scope", "Bearer " + issue(token.AccessTokenParams{
+			ClientID: "billing", Scope: "organizations:read", Audience: []string{token.InternalAudience},
+		}), http.StatusForbidden},
+		{"scoped machine token", "Bearer " + issue(token.AccessTokenParams{
+			ClientID: "billing", Scope: ScopeOrganizationsWrite, Audience: []string{token.InternalAudience},
+		}), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calledBy = ""
+			req := httptest.NewRequest(http.MethodPut, "/internal/v1/organizations/"+uuid.NewString()+"/status", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, "billing", calledBy)
+			} else {
+				assert.Empty(t, calledBy)
+			}
+		})
+	}
+}
+
+type memoryOrgRepo struct {
+	orgs map[uuid.UUID]*model.Organization
+}
+
+func (r *memoryOrgRepo) Create(context.Context, *model.Organization) error { return nil }
+
+func (r *memoryOrgRepo) CreateTx(context.Context, pgx.Tx, *model.Organization) error { return nil }
+
+func (r *memoryOrgRepo) CreateWithMembership(context.Context, *model.Organization, *model.OrgMembership) error {
+	return nil
+}
+
+func (r *memoryOrgRepo) GetByID(_ context.Context, id uuid.UUID) (*model.Organization, error) {
+	org, ok := r.orgs[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	copied := *org
+	return &copied, nil
+}
+
+func (r *memoryOrgRepo) GetByUserID(context.Context, uuid.UUID) ([]*model.Organization, error) {
+	return nil, nil
+}
+
+func (r *memoryOrgRepo) Update(_ context.Context, org *model.Organization) error {
+	r.orgs[org.ID] = org
+	return nil
+}
+
+type recordingOrgRevoker struct {
+	orgs []uuid.UUID
+}
+
+func (r *recordingOrgRevoker) RevokeOrganization(_ context.Context, orgID uuid.UUID) error {
+	r.orgs = append(r.orgs, orgID)
+	return nil
+}
+
+func TestUpdateOrganizationStatus_RevokesTokens(t *testing.T) {
+	trialEndsAt := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	org := &model.Organization{ID: uuid.New(), Status: "trialing", TrialEndsAt: &trialEndsAt}
+	revoker := &recordingOrgRevoker{}
+	h := NewOrganizationHandlerWithRepo(&memoryOrgRepo{orgs: map[uuid.UUID]*model.Organization{org.ID: org}}, revoker, zerolog.Nop())
+
+	r := chi.NewRouter()
+	r.Put("/organizations/{org_id}/status", h.UpdateOrganizationStatus)
+	update := func(body string) int {
+		req := httptest.NewRequest(http.MethodPut, "/organizations/"+org.ID.String()+"/status", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Repeated callbacks with the same state keep issued tokens valid
+	require.Equal(t, http.StatusOK, update(`{"status": "trialing", "trial_ends_at": "2026-11-01T00:00:00Z"}`))
+	assert.Empty(t, revoker.orgs)
+
+	require.Equal(t, http.StatusOK, update(`{"status": "active", "subscription_id": "`+uuid.NewString()+`"}`))
+	assert.Equal(t, []uuid.UUID{org.ID}, revoker.orgs)
+}
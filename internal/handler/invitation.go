@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/response"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/validator"
+)
+
+type InvitationService interface {
+	ListInvitations(ctx context.Context, userID, orgID uuid.UUID) ([]*model.OrgInvitation, error)
+	Invite(ctx context.Context, userID, orgID uuid.UUID, email string, role model.Role, ipAddress, userAgent string) (*model.OrgInvitation, error)
+	RevokeInvitation(ctx context.Context, userID, orgID, invitationID uuid.UUID, ipAddress, userAgent string) error
+	Accept(ctx context.Context, userID uuid.UUID, invitationToken, ipAddress, userAgent string) (*model.OrgMembership, error)
+	AcceptWithRegistration(ctx context.Context, invitationToken, fullName, password, ipAddress, userAgent string) (*model.User, *model.OrgMembership, error)
+}
+
+type InvitationHandler struct {
+	invitationService InvitationService
+	metrics           MetricsCollector
+}
+
+func NewInvitationHandler(invitationService InvitationService, metrics MetricsCollector) *InvitationHandler {
+	return &InvitationHandler{invitationService: invitationService, metrics: metrics}
+}
+
+func (h *InvitationHandler) ListInvitations(c *gin.Context) {
+	uid, orgID, ok := orgAdminParams(c)
+	if !ok {
+		return
+	}
+
+	invitations, err := h.invitationService.ListInvitations(c.Request.Context(), uid, orgID)
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	result := make([]InvitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		result = append(result, invitationResponse(invitation))
+	}
+	response.Success(c, http.StatusOK, gin.H{"invitations": result})
+}
+
+// CreateInvitation emails the invitation; the token is not returned.
+func (h *InvitationHandler) CreateInvitation(c *gin.Context) {
+	uid, orgID, ok := orgAdminParams(c)
+	if !ok {
+		return
+	}
+
+	var req CreateInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	role := model.RoleMember
+	if req.Role != "" {
+		role = model.Role(strings.ToUpper(req.Role))
+	}
+
+	invitation, err := h.invitationService.Invite(c.Request.Context(), uid, orgID, req.Email, role, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, invitationResponse(invitation))
+}
+
+func (h *InvitationHandler) RevokeInvitation(c *gin.Context) {
+	uid, orgID, ok := orgAdminParams(c)
+	if !ok {
+		return
+	}
+
+	invitationID, err := uuid.Parse(c.Param("invitation_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid invitation ID")
+		return
+	}
+
+	if err := h.invitationService.RevokeInvitation(c.Request.Context(), uid, orgID, invitationID, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "Invitation revoked"})
+}
+
+// Accept adds the signed-in user to the inviting organization.
+func (h *InvitationHandler) Accept(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	var req AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	membership, err := h.invitationService.Accept(c.Request.Context(), uid, req.Token, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, AcceptInvitationResponse{
+		OrgID: membership.OrgID,
+		Role:  string(membership.Role),
+	})
+}
+
+// Register creates an account for the invited email address and accepts
+// the invitation. The user then signs in as usual.
+func (h *InvitationHandler) Register(c *gin.Context) {
+	var req InvitationRegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	inputValidator := validator.NewInputValidator()
+	if err := inputValidator.ValidateName(req.FullName); err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+	req.FullName = inputValidator.SanitizeName(req.FullName)
+
+	user, membership, err := h.invitationService.AcceptWithRegistration(
+		c.Request.Context(), req.Token, req.FullName, req.Password, c.ClientIP(), c.GetHeader("User-Agent"),
+	)
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	if h.metrics != nil {
+		h.metrics.IncrementRegistrations()
+	}
+
+	response.Success(c, http.StatusCreated, InvitationRegisterResponse{
+		User:  userResponse(user),
+		OrgID: membership.OrgID,
+		Role:  string(membership.Role),
+	})
+}
+
+func invitationResponse(invitation *model.OrgInvitation) InvitationResponse {
+	return InvitationResponse{
+		ID:        invitation.ID,
+		Email:     invitation.Email,
+		Role:      string(invitation.Role),
+		InvitedBy: invitation.InvitedBy,
+		Expired:   invitation.IsExpired(),
+		ExpiresAt: invitation.ExpiresAt,
+		CreatedAt: invitation.CreatedAt,
+	}
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/response"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/service"
+)
+
+type MagicLinkService interface {
+	RequestLink(ctx context.Context, email string, orgID uuid.UUID, ipAddress, userAgent string) error
+	IsEnabled(ctx context.Context, userID, orgID uuid.UUID) (bool, error)
+	SetEnabled(ctx context.Context, userID, orgID uuid.UUID, enabled bool, ipAddress, userAgent string) error
+}
+
+type MagicLinkHandler struct {
+	magicLinkService MagicLinkService
+	authService      service.AuthServiceInterface
+	auditService     AuditService
+	metrics          MetricsCollector
+}
+
+func NewMagicLinkHandler(magicLinkService MagicLinkService, authService service.AuthServiceInterface, auditService AuditService, metrics MetricsCollector) *MagicLinkHandler {
+	return &MagicLinkHandler{
+		magicLinkService: magicLinkService,
+		authService:      authService,
+		auditService:     auditService,
+		metrics:          metrics,
+	}
+}
+
+// Request emails a sign-in link. The response is the same whether or not the
+// account exists or may use magic links.
+func (h *MagicLinkHandler) Request(c *gin.Context) {
+	var req MagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	orgID := uuid.Nil
+	if req.OrgID != nil {
+		orgID = *req.OrgID
+	}
+
+	if err := h.magicLinkService.RequestLink(c.Request.Context(), req.Email, orgID, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		response.InternalError(c, "Failed to process request")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "If the email is registered, a sign-in link has been sent"})
+}
+
+// Verify exchanges the link token for tokens, or for an MFA challenge if the
+// user has MFA enabled.
+func (h *MagicLinkHandler) Verify(c *gin.Context) {
+	var req MagicLinkVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	userAgent := c.GetHeader("User-Agent")
+	ipAddress := c.ClientIP()
+
+	result, err := h.authService.CompleteMagicLinkLogin(c.Request.Context(), req.Token, userAgent, ipAddress)
+	if err != nil {
+		if h.auditService != nil {
+			_ = h.auditService.Log(c.Request.Context(), nil, "login_failed", map[string]interface{}{"method": "magic_link"}, ipAddress, userAgent)
+		}
+		if h.metrics != nil {
+			h.metrics.IncrementLoginFailures()
+		}
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	// Second factor required - tokens are issued by VerifyMFA
+	if result.MFARequired {
+		response.Success(c, http.StatusOK, MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    result.MFAToken,
+		})
+		return
+	}
+
+	if h.auditService != nil {
+		_ = h.auditService.Log(c.Request.Context(), &result.UserID, "user_logged_in", map[string]interface{}{"method": "magic_link"}, ipAddress, userAgent)
+	}
+
+	if h.metrics != nil {
+		h.metrics.IncrementLogins()
+	}
+
+	response.Success(c, http.StatusOK, AuthResponse{
+		AccessToken:  result.AccessToken,
+		RefreshToken: result.RefreshToken,
+	})
+}
+
+func (h *MagicLinkHandler) GetSetting(c *gin.Context) {
+	uid, orgID, ok := orgAdminParams(c)
+	if !ok {
+		return
+	}
+
+	enabled, err := h.magicLinkService.IsEnabled(c.Request.Context(), uid, orgID)
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"enabled": enabled})
+}
+
+func (h *MagicLinkHandler) UpdateSetting(c *gin.Context) {
+	uid, orgID, ok := orgAdminParams(c)
+	if !ok {
+		return
+	}
+
+	var req MagicLinkSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Enabled == nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	if err := h.magicLinkService.SetEnabled(c.Request.Context(), uid, orgID, *req.Enabled, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"enabled": *req.Enabled})
+}
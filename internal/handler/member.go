@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/response"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/service"
+)
+
+type MemberService interface {
+	ListMembers(ctx context.Context, userID, orgID uuid.UUID) ([]*model.OrgMember, error)
+	UpdateMember(ctx context.Context, userID, orgID, memberID uuid.UUID, update service.MemberUpdate, ipAddress, userAgent string) (*model.OrgMembership, error)
+	RemoveMember(ctx context.Context, userID, orgID, memberID uuid.UUID, ipAddress, userAgent string) error
+	TransferOwnership(ctx context.Context, userID, orgID, newOwnerID uuid.UUID, ipAddress, userAgent string) error
+}
+
+type MemberHandler struct {
+	memberService MemberService
+}
+
+func NewMemberHandler(memberService MemberService) *MemberHandler {
+	return &MemberHandler{memberService: memberService}
+}
+
+func (h *MemberHandler) ListMembers(c *gin.Context) {
+	uid, orgID, ok := orgAdminParams(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.memberService.ListMembers(c.Request.Context(), uid, orgID)
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	result := make([]MemberResponse, 0, len(members))
+	for _, member := range members {
+		result = append(result, MemberResponse{
+			UserID:       member.User.ID,
+			Email:        member.User.Email,
+			FullName:     member.User.FullName,
+			Role:         string(member.Membership.Role),
+			CustomRoleID: member.Membership.CustomRoleID,
+			IsActive:     member.Membership.IsActive,
+			CreatedAt:    member.Membership.CreatedAt,
+		})
+	}
+	response.Success(c, http.StatusOK, gin.H{"members": result})
+}
+
+// UpdateMember changes the role of a member or deactivates the membership.
+func (h *MemberHandler) UpdateMember(c *gin.Context) {
+	uid, orgID, memberID, ok := memberParams(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Role == nil && req.IsActive == nil) {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	update := service.MemberUpdate{IsActive: req.IsActive}
+	if req.Role != nil {
+		role := model.Role(strings.ToUpper(*req.Role))
+		update.Role = &role
+	}
+
+	membership, err := h.memberService.UpdateMember(c.Request.Context(), uid, orgID, memberID, update, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"user_id":   membership.UserID,
+		"role":      string(membership.Role),
+		"is_active": membership.IsActive,
+	})
+}
+
+// RemoveMember removes a member; members remove themselves to leave.
+func (h *MemberHandler) RemoveMember(c *gin.Context) {
+	uid, orgID, memberID, ok := memberParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.memberService.RemoveMember(c.Request.Context(), uid, orgID, memberID, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "Member removed"})
+}
+
+func (h *MemberHandler) TransferOwnership(c *gin.Context) {
+	uid, orgID, ok := orgAdminParams(c)
+	if !ok {
+		return
+	}
+
+	var req TransferOwnershipRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	if err := h.memberService.TransferOwnership(c.Request.Context(), uid, orgID, req.UserID, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "Ownership transferred"})
+}
+
+func memberParams(c *gin.Context) (uuid.UUID, uuid.UUID, uuid.UUID, bool) {
+	uid, orgID, ok := orgAdminParams(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	memberID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	return uid, orgID, memberID, true
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/response"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/service"
+)
+
+type MFAService interface {
+	GetStatus(ctx context.Context, userID uuid.UUID) (*model.UserMFA, error)
+	BeginEnrollment(ctx context.Context, userID uuid.UUID) (*service.MFAEnrollment, error)
+	ConfirmEnrollment(ctx context.Context, userID uuid.UUID, code, ipAddress, userAgent string) ([]string, error)
+	Regenerate(ctx context.Context, userID uuid.UUID, code, ipAddress, userAgent string) (*service.MFAEnrollment, error)
+	Disable(ctx context.Context, userID uuid.UUID, code, ipAddress, userAgent string) error
+	RecoveryCodesRemaining(ctx context.Context, userID uuid.UUID) (int, error)
+	RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code, ipAddress, userAgent string) ([]string, error)
+}
+
+type MFAHandler struct {
+	mfaService MFAService
+}
+
+func NewMFAHandler(mfaService MFAService) *MFAHandler {
+	return &MFAHandler{
+		mfaService: mfaService,
+	}
+}
+
+func (h *MFAHandler) GetStatus(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	mfa, err := h.mfaService.GetStatus(c.Request.Context(), uid)
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	status := gin.H{
+		"enabled":              mfa.IsEnabled(),
+		"pending_confirmation": mfa != nil && mfa.PendingSecret != "",
+	}
+	if mfa.IsEnabled() {
+		remaining, err := h.mfaService.RecoveryCodesRemaining(c.Request.Context(), uid)
+		if err != nil {
+			httpErr := errors.MapErrorToHTTP(err)
+			response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+			return
+		}
+		status["enabled_at"] = mfa.EnabledAt
+		status["recovery_codes_remaining"] = remaining
+	}
+
+	response.Success(c, http.StatusOK, status)
+}
+
+func (h *MFAHandler) BeginEnrollment(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	enrollment, err := h.mfaService.BeginEnrollment(c.Request.Context(), uid)
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, enrollment)
+}
+
+func (h *MFAHandler) ConfirmEnrollment(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	recoveryCodes, err := h.mfaService.ConfirmEnrollment(c.Request.Context(), uid, req.Code, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	result := gin.H{"message": "MFA enabled successfully"}
+	if len(recoveryCodes) > 0 {
+		result["recovery_codes"] = recoveryCodes
+	}
+
+	response.Success(c, http.StatusOK, result)
+}
+
+func (h *MFAHandler) Regenerate(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	enrollment, err := h.mfaService.Regenerate(c.Request.Context(), uid, req.Code, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, enrollment)
+}
+
+func (h *MFAHandler) Disable(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	if err := h.mfaService.Disable(c.Request.Context(), uid, req.Code, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "MFA disabled successfully"})
+}
+
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	recoveryCodes, err := h.mfaService.RegenerateRecoveryCodes(c.Request.Context(), uid, req.Code, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, gin.H{"recovery_codes": recoveryCodes})
+}
@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"context"
+	stdErrors "errors"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -10,10 +13,17 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/ZenoN-Cloud/zeno-auth/internal/metrics"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
 	"github.com/ZenoN-Cloud/zeno-auth/internal/token"
 )
 
-func AuthMiddleware(jwtManager *token.JWTManager) gin.HandlerFunc {
+// RevocationChecker rejects access tokens that were revoked before they
+// expired, e.g. on logout or password change.
+type RevocationChecker interface {
+	Check(ctx context.Context, claims *token.Claims) error
+}
+
+func AuthMiddleware(jwtManager *token.JWTManager, revocation RevocationChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -42,9 +52,78 @@ func AuthMiddleware(jwtManager *token.JWTManager) gin.HandlerFunc {
 			return
 		}
 
+		if revocation != nil {
+			if err := revocation.Check(c.Request.Context(), claims); err != nil {
+				if stdErrors.Is(err, token.ErrTokenRevoked) {
+					c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "Token revoked"})
+				} else {
+					// Fail closed: a revoked token must never be accepted
+					log.Error().Err(err).Str("user_id", claims.UserID.String()).Msg("Token revocation check failed")
+					c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "Service temporarily unavailable"})
+				}
+				c.Abort()
+				return
+			}
+		}
+
 		c.Set("user_id", claims.UserID.String())
 		c.Set("org_id", claims.OrgID.String())
+		c.Set("session_id", claims.SessionID)
 		c.Set("roles", claims.Roles)
+		c.Set("permissions", claims.Permissions)
+		c.Set("amr", claims.AMR)
+		c.Next()
+	}
+}
+
+// RequireOrgRole lets the request through if the access token was issued
+// for the organization in the :org_id path parameter with one of the roles.
+// It must run after AuthMiddleware. Tokens carry the role at issue time, so
+// services still check the current membership before changing anything.
+func RequireOrgRole(roles ...model.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if orgID := c.Param("org_id"); orgID == "" || !strings.EqualFold(orgID, c.GetString("org_id")) {
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "Access token is not for this organization"})
+			c.Abort()
+			return
+		}
+
+		granted, _ := c.Get("roles")
+		tokenRoles, _ := granted.([]string)
+		for _, tokenRole := range tokenRoles {
+			for _, role := range roles {
+				if tokenRole == string(role) {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "Insufficient role"})
+		c.Abort()
+	}
+}
+
+// RequirePermission lets the request through if the access token carries
+// the permission. On routes with an :org_id path parameter the token must
+// also be issued for that organization. It must run after AuthMiddleware.
+// Like roles, permissions are fixed at issue time.
+func RequirePermission(permission model.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if orgID := c.Param("org_id"); orgID != "" && !strings.EqualFold(orgID, c.GetString("org_id")) {
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "Access token is not for this organization"})
+			c.Abort()
+			return
+		}
+
+		granted, _ := c.Get("permissions")
+		tokenPermissions, _ := granted.([]string)
+		if !slices.Contains(tokenPermissions, string(permission)) {
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: "Missing permission " + string(permission)})
+			c.Abort()
+			return
+		}
+
 		c.Next()
 	}
 }
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+func TestRequireOrgRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	orgID := uuid.New()
+
+	tests := []struct {
+		name   string
+		orgID  uuid.UUID
+		roles  []string
+		status int
+	}{
+		{"admin of the organization", orgID, []string{"ADMIN"}, http.StatusOK},
+		{"owner of the organization", orgID, []string{"OWNER"}, http.StatusOK},
+		{"member of the organization", orgID, []string{"MEMBER"}, http.StatusForbidden},
+		{"no roles", orgID, nil, http.StatusForbidden},
+		{"token for another organization", uuid.New(), []string{"OWNER"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/organizations/:org_id/members", func(c *gin.Context) {
+				c.Set("org_id", tt.orgID.String())
+				if tt.roles != nil {
+					c.Set("roles", tt.roles)
+				}
+				c.Next()
+			}, RequireOrgRole(model.RoleOwner, model.RoleAdmin), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/organizations/"+orgID.String()+"/members", nil))
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	orgID := uuid.New()
+
+	tests := []struct {
+		name        string
+		path        string
+		orgID       uuid.UUID
+		permissions []string
+		status      int
+	}{
+		{"permission granted", "/organizations/" + orgID.String() + "/roles", orgID, []string{"org:read", "roles:manage"}, http.StatusOK},
+		{"permission missing", "/organizations/" + orgID.String() + "/roles", orgID, []string{"org:read"}, http.StatusForbidden},
+		{"no permissions", "/organizations/" + orgID.String() + "/roles", orgID, nil, http.StatusForbidden},
+		{"token for another organization", "/organizations/" + orgID.String() + "/roles", uuid.New(), []string{"roles:manage"}, http.StatusForbidden},
+		{"route without organization", "/reports", uuid.New(), []string{"roles:manage"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			setClaims := func(c *gin.Context) {
+				c.Set("org_id", tt.orgID.String())
+				if tt.permissions != nil {
+					c.Set("permissions", tt.permissions)
+				}
+				c.Next()
+			}
+			ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+			r.GET("/organizations/:org_id/roles", setClaims, RequirePermission(model.PermRolesManage), ok)
+			r.GET("/reports", setClaims, RequirePermission(model.PermRolesManage), ok)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+type staticPlatformAdmins map[uuid.UUID]bool
+
+func (a staticPlatformAdmins) IsPlatformAdmin(_ context.Context, userID uuid.UUID) (bool, error) {
+	return a[userID], nil
+}
+
+func TestRequirePlatformAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	adminID := uuid.New()
+	admins := staticPlatformAdmins{adminID: true}
+
+	tests := []struct {
+		name   string
+		userID uuid.UUID
+		amr    []string
+		status int
+	}{
+		{"admin with MFA", adminID, []string{"otp", "mfa"}, http.StatusOK},
+		{"admin with passkey", adminID, []string{"hwk", "mfa"}, http.StatusOK},
+		{"admin with password only", adminID, []string{"pwd"}, http.StatusForbidden},
+		{"admin token without amr", adminID, nil, http.StatusForbidden},
+		{"regular user with MFA", uuid.New(), []string{"otp", "mfa"}, http.StatusForbidden},
+		{"no user", uuid.Nil, []string{"mfa"}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			setClaims := func(c *gin.Context) {
+				c.Set("user_id", tt.userID.String())
+				c.Set("amr", tt.amr)
+				c.Next()
+			}
+			r.GET("/admin/users", setClaims, RequirePlatformAdmin(admins), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/users", nil))
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"context"
+	stdErrors "errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/response"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/service"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/token"
+)
+
+type OAuthService interface {
+	Issuer() string
+	Discovery() *service.DiscoveryDocument
+	ValidateAuthorizationRequest(ctx context.Context, req *service.AuthorizationRequest) (*model.OAuthClient, error)
+	Authorize(ctx context.Context, userID, orgID uuid.UUID, req *service.AuthorizationRequest, ipAddress, userAgent string) (string, error)
+	Exchange(ctx context.Context, req *service.TokenRequest) (*service.TokenResponse, error)
+	UserInfo(ctx context.Context, claims *token.Claims) (map[string]interface{}, error)
+}
+
+// OAuthHandler serves the OpenID Connect provider endpoints. Responses follow
+// the OAuth/OIDC wire format rather than the API response envelope.
+type OAuthHandler struct {
+	oauthService    OAuthService
+	jwtManager      *token.JWTManager
+	revocation      RevocationChecker
+	frontendBaseURL string
+}
+
+func NewOAuthHandler(oauthService OAuthService, jwtManager *token.JWTManager, revocation RevocationChecker, frontendBaseURL string) *OAuthHandler {
+	return &OAuthHandler{
+		oauthService:    oauthService,
+		jwtManager:      jwtManager,
+		revocation:      revocation,
+		frontendBaseURL: strings.TrimRight(frontendBaseURL, "/"),
+	}
+}
+
+func (h *OAuthHandler) Discovery(c *gin.Context) {
+	c.JSON(http.StatusOK, h.oauthService.Discovery())
+}
+
+// Authorize validates the request and hands it to the frontend, which signs
+// the user in, asks for consent and calls ApproveAuthorization.
+func (h *OAuthHandler) Authorize(c *gin.Context) {
+	var req service.AuthorizationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		writeOAuthError(c, http.StatusBadRequest, "invalid_request", "malformed authorization request")
+		return
+	}
+
+	if _, err := h.oauthService.ValidateAuthorizationRequest(c.Request.Context(), &req); err != nil {
+		h.handleAuthorizeError(c, err)
+		return
+	}
+
+	c.Redirect(http.StatusFound, h.frontendBaseURL+"/oauth/authorize?"+c.Request.URL.RawQuery)
+}
+
+// ApproveAuthorization is called by the frontend once the signed-in user has
+// approved the request. It returns the client redirect carrying the code.
+func (h *OAuthHandler) ApproveAuthorization(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+	orgID, _ := uuid.Parse(c.GetString("org_id"))
+
+	var req service.AuthorizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	redirectTo, err := h.oauthService.Authorize(c.Request.Context(), uid, orgID, &req, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		var oauthErr *service.OAuthError
+		if stdErrors.As(err, &oauthErr) {
+			if oauthErr.RedirectURI != "" {
+				response.Success(c, http.StatusOK, gin.H{"redirect_to": oauthErr.RedirectLocation()})
+				return
+			}
+			response.Error(c, http.StatusBadRequest, oauthErr.Code, oauthErr.Description)
+			return
+		}
+		log.Error().Err(err).Str("user_id", uid.String()).Msg("Failed to issue authorization code")
+		response.InternalError(c, "Failed to authorize client")
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"redirect_to": redirectTo})
+}
+
+func (h *OAuthHandler) Token(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	var req service.TokenRequest
+	if err := c.ShouldBind(&req); err != nil {
+		writeOAuthError(c, http.StatusBadRequest, "invalid_request", "malformed token request")
+		return
+	}
+
+	// client_secret_basic: credentials are form-encoded before base64 (RFC 6749 section 2.3.1)
+	if user, pass, ok := c.Request.BasicAuth(); ok {
+		if req.ClientSecret != "" {
+			writeOAuthError(c, http.StatusBadRequest, "invalid_request", "multiple client authentication methods")
+			return
+		}
+		clientID, errID := url.QueryUnescape(user)
+		clientSecret, errSecret := url.QueryUnescape(pass)
+		if errID != nil || errSecret != nil || (req.ClientID != "" && req.ClientID != clientID) {
+			writeOAuthError(c, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+			return
+		}
+		req.ClientID, req.ClientSecret = clientID, clientSecret
+	}
+
+	resp, err := h.oauthService.Exchange(c.Request.Context(), &req)
+	if err != nil {
+		var oauthErr *service.OAuthError
+		if stdErrors.As(err, &oauthErr) {
+			status := http.StatusBadRequest
+			if oauthErr.Code == "invalid_client" {
+				status = http.StatusUnauthorized
+				c.Header("WWW-Authenticate", `Basic realm="zeno-auth"`)
+			}
+			writeOAuthError(c, status, oauthErr.Code, oauthErr.Description)
+			return
+		}
+		log.Error().Err(err).Str("client_id", req.ClientID).Msg("Token exchange failed")
+		writeOAuthError(c, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// UserInfo accepts access tokens issued to OAuth clients via the
+// authorization header.
+func (h *OAuthHandler) UserInfo(c *gin.Context) {
+	tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if tokenString == "" || tokenString == c.GetHeader("Authorization") {
+		c.Header("WWW-Authenticate", `Bearer realm="zeno-auth"`)
+		writeOAuthError(c, http.StatusUnauthorized, "invalid_token", "bearer token required")
+		return
+	}
+
+	invalidToken := func() {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		writeOAuthError(c, http.StatusUnauthorized, "invalid_token", "")
+	}
+
+	claims, err := h.jwtManager.ValidateForAudience(c.Request.Context(), tokenString, h.oauthService.Issuer())
+	if err != nil {
+		invalidToken()
+		return
+	}
+	if h.revocation != nil {
+		if err := h.revocation.Check(c.Request.Context(), claims); err != nil {
+			if stdErrors.Is(err, token.ErrTokenRevoked) {
+				invalidToken()
+				return
+			}
+			log.Error().Err(err).Msg("Token revocation check failed")
+			writeOAuthError(c, http.StatusServiceUnavailable, "temporarily_unavailable", "")
+			return
+		}
+	}
+
+	info, err := h.oauthService.UserInfo(c.Request.Context(), claims)
+	if err != nil {
+		var oauthErr *service.OAuthError
+		if stdErrors.As(err, &oauthErr) && oauthErr.Code == "insufficient_scope" {
+			c.Header("WWW-Authenticate", `Bearer error="insufficient_scope", scope="openid"`)
+			writeOAuthError(c, http.StatusForbidden, oauthErr.Code, oauthErr.Description)
+			return
+		}
+		invalidToken()
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+func (h *OAuthHandler) handleAuthorizeError(c *gin.Context, err error) {
+	var oauthErr *service.OAuthError
+	if !stdErrors.As(err, &oauthErr) {
+		log.Error().Err(err).Msg("Failed to validate authorization request")
+		writeOAuthError(c, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+	if oauthErr.RedirectURI != "" {
+		c.Redirect(http.StatusFound, oauthErr.RedirectLocation())
+		return
+	}
+	writeOAuthError(c, http.StatusBadRequest, oauthErr.Code, oauthErr.Description)
+}
+
+func writeOAuthError(c *gin.Context, status int, code, description string) {
+	body := gin.H{"error": code}
+	if description != "" {
+		body["error_description"] = description
+	}
+	c.JSON(status, body)
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"time"
@@ -16,8 +17,15 @@ import (
 	"github.com/ZenoN-Cloud/zeno-auth/internal/repository/postgres"
 )
 
+// OrganizationRevoker rejects the access tokens issued for an organization so
+// far, making clients refresh them.
+type OrganizationRevoker interface {
+	RevokeOrganization(ctx context.Context, orgID uuid.UUID) error
+}
+
 type OrganizationHandler struct {
 	orgRepo repository.OrganizationRepository
+	revoker OrganizationRevoker
 	logger  zerolog.Logger
 }
 
@@ -38,9 +46,10 @@ func NewOrganizationHandler(pool *pgxpool.Pool, logger zerolog.Logger) *Organiza
 	}
 }
 
-func NewOrganizationHandlerWithRepo(orgRepo repository.OrganizationRepository, logger zerolog.Logger) *OrganizationHandler {
+func NewOrganizationHandlerWithRepo(orgRepo repository.OrganizationRepository, revoker OrganizationRevoker, logger zerolog.Logger) *OrganizationHandler {
 	return &OrganizationHandler{
 		orgRepo: orgRepo,
+		revoker: revoker,
 		logger:  logger,
 	}
 }
@@ -93,8 +102,10 @@ func (h *OrganizationHandler) UpdateOrganizationStatus(w http.ResponseWriter, r
 		return
 	}
 
+	changed := org.Status != req.Status
 	org.Status = req.Status
 	if req.TrialEndsAt != nil {
+		changed = changed || org.TrialEndsAt == nil || !org.TrialEndsAt.Equal(*req.TrialEndsAt)
 		org.TrialEndsAt = req.TrialEndsAt
 	}
 	if req.SubscriptionID != nil {
@@ -103,6 +114,7 @@ func (h *OrganizationHandler) UpdateOrganizationStatus(w http.ResponseWriter, r
 			h.respondError(w, http.StatusBadRequest, "invalid subscription ID format")
 			return
 		}
+		changed = changed || org.SubscriptionID == nil || *org.SubscriptionID != subID
 		org.SubscriptionID = &subID
 	}
 	org.UpdatedAt = time.Now()
@@ -113,9 +125,18 @@ func (h *OrganizationHandler) UpdateOrganizationStatus(w http.ResponseWriter, r
 		return
 	}
 
+	// Access tokens carry the status as claims; make clients refresh them
+	if changed && h.revoker != nil {
+		if err := h.revoker.RevokeOrganization(r.Context(), orgID); err != nil {
+			h.logger.Error().Err(err).Str("org_id", orgIDStr).Msg("failed to revoke organization access tokens")
+		}
+	}
+
 	h.logger.Info().
 		Str("org_id", orgIDStr).
 		Str("status", req.Status).
+		Bool("changed", changed).
+		Str("client_id", ClientIDFromContext(r.Context())).
 		Msg("organization status updated")
 
 	h.respondJSON(w, http.StatusOK, map[string]interface{}{
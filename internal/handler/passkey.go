@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/response"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/service"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/token"
+)
+
+type PasskeyService interface {
+	BeginRegistration(ctx context.Context, userID uuid.UUID) (*service.PasskeyCreationOptions, error)
+	FinishRegistration(ctx context.Context, userID uuid.UUID, reg *service.PasskeyRegistration, ipAddress, userAgent string) (*model.WebAuthnCredential, error)
+	List(ctx context.Context, userID uuid.UUID) ([]*model.WebAuthnCredential, error)
+	Delete(ctx context.Context, userID, passkeyID uuid.UUID, ipAddress, userAgent string) error
+	BeginLogin(ctx context.Context) (*service.PasskeyRequestOptions, error)
+}
+
+type PasskeyHandler struct {
+	passkeyService PasskeyService
+	authService    service.AuthServiceInterface
+	auditService   AuditService
+	metrics        MetricsCollector
+}
+
+func NewPasskeyHandler(passkeyService PasskeyService, authService service.AuthServiceInterface, auditService AuditService, metrics MetricsCollector) *PasskeyHandler {
+	return &PasskeyHandler{
+		passkeyService: passkeyService,
+		authService:    authService,
+		auditService:   auditService,
+		metrics:        metrics,
+	}
+}
+
+func (h *PasskeyHandler) List(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	creds, err := h.passkeyService.List(c.Request.Context(), uid)
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	passkeys := make([]PasskeyResponse, 0, len(creds))
+	for _, cred := range creds {
+		passkeys = append(passkeys, toPasskeyResponse(cred))
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"passkeys": passkeys})
+}
+
+func (h *PasskeyHandler) BeginRegistration(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	options, err := h.passkeyService.BeginRegistration(c.Request.Context(), uid)
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, options)
+}
+
+func (h *PasskeyHandler) FinishRegistration(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	var req PasskeyRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	reg, ok := decodePasskeyRegistration(&req)
+	if !ok {
+		response.BadRequest(c, "Invalid credential encoding")
+		return
+	}
+
+	cred, err := h.passkeyService.FinishRegistration(c.Request.Context(), uid, reg, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, toPasskeyResponse(cred))
+}
+
+func (h *PasskeyHandler) Delete(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	passkeyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid passkey ID")
+		return
+	}
+
+	if err := h.passkeyService.Delete(c.Request.Context(), uid, passkeyID, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "Passkey removed"})
+}
+
+func (h *PasskeyHandler) BeginLogin(c *gin.Context) {
+	options, err := h.passkeyService.BeginLogin(c.Request.Context())
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, options)
+}
+
+func (h *PasskeyHandler) FinishLogin(c *gin.Context) {
+	var req PasskeyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	assertion, ok := decodePasskeyAssertion(&req)
+	if !ok {
+		response.BadRequest(c, "Invalid credential encoding")
+		return
+	}
+
+	userAgent := c.GetHeader("User-Agent")
+	ipAddress := c.ClientIP()
+
+	result, err := h.authService.CompletePasskeyLogin(c.Request.Context(), assertion, userAgent, ipAddress)
+	if err != nil {
+		if h.auditService != nil {
+			_ = h.auditService.Log(c.Request.Context(), nil, "login_failed", map[string]interface{}{"method": "passkey"}, ipAddress, userAgent)
+		}
+		if h.metrics != nil {
+			h.metrics.IncrementLoginFailures()
+		}
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	if h.auditService != nil {
+		_ = h.auditService.Log(c.Request.Context(), &result.UserID, "user_logged_in", map[string]interface{}{"method": "passkey"}, ipAddress, userAgent)
+	}
+
+	if h.metrics != nil {
+		h.metrics.IncrementLogins()
+	}
+
+	response.Success(c, http.StatusOK, AuthResponse{
+		AccessToken:  result.AccessToken,
+		RefreshToken: result.RefreshToken,
+	})
+}
+
+func decodePasskeyRegistration(req *PasskeyRegistrationRequest) (*service.PasskeyRegistration, bool) {
+	credentialID, err1 := token.DecodeWebAuthnBase64(req.ID)
+	clientData, err2 := token.DecodeWebAuthnBase64(req.Response.ClientDataJSON)
+	attestation, err3 := token.DecodeWebAuthnBase64(req.Response.AttestationObject)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return nil, false
+	}
+
+	return &service.PasskeyRegistration{
+		Name:              req.Name,
+		CredentialID:      credentialID,
+		ClientDataJSON:    clientData,
+		AttestationObject: attestation,
+		Transports:        req.Response.Transports,
+	}, true
+}
+
+func decodePasskeyAssertion(req *PasskeyLoginRequest) (*service.PasskeyAssertion, bool) {
+	credentialID, err1 := token.DecodeWebAuthnBase64(req.ID)
+	clientData, err2 := token.DecodeWebAuthnBase64(req.Response.ClientDataJSON)
+	authData, err3 := token.DecodeWebAuthnBase64(req.Response.AuthenticatorData)
+	signature, err4 := token.DecodeWebAuthnBase64(req.Response.Signature)
+	userHandle, err5 := token.DecodeWebAuthnBase64(req.Response.UserHandle)
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil || err5 != nil {
+		return nil, false
+	}
+
+	return &service.PasskeyAssertion{
+		CredentialID:      credentialID,
+		ClientDataJSON:    clientData,
+		AuthenticatorData: authData,
+		Signature:         signature,
+		UserHandle:        userHandle,
+	}, true
+}
+
+func toPasskeyResponse(cred *model.WebAuthnCredential) PasskeyResponse {
+	transports := cred.Transports
+	if transports == nil {
+		transports = []string{}
+	}
+	return PasskeyResponse{
+		ID:         cred.ID,
+		Name:       cred.Name,
+		Transports: transports,
+		CreatedAt:  cred.CreatedAt,
+		LastUsedAt: cred.LastUsedAt,
+	}
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/response"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/service"
+)
+
+type RoleService interface {
+	ListRoles(ctx context.Context, userID, orgID uuid.UUID) ([]*model.OrgRole, error)
+	CreateRole(ctx context.Context, userID, orgID uuid.UUID, input service.RoleInput, ipAddress, userAgent string) (*model.OrgRole, error)
+	UpdateRole(ctx context.Context, userID, orgID, roleID uuid.UUID, input service.RoleInput, ipAddress, userAgent string) (*model.OrgRole, error)
+	DeleteRole(ctx context.Context, userID, orgID, roleID uuid.UUID, ipAddress, userAgent string) error
+	AssignRole(ctx context.Context, userID, orgID, memberID uuid.UUID, roleID *uuid.UUID, ipAddress, userAgent string) (*model.OrgMembership, error)
+}
+
+type RoleHandler struct {
+	roleService RoleService
+}
+
+func NewRoleHandler(roleService RoleService) *RoleHandler {
+	return &RoleHandler{roleService: roleService}
+}
+
+// ListRoles returns the built-in roles with their permissions and the
+// custom roles of the organization.
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	uid, orgID, ok := orgAdminParams(c)
+	if !ok {
+		return
+	}
+
+	roles, err := h.roleService.ListRoles(c.Request.Context(), uid, orgID)
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	builtIn := make([]BuiltInRoleResponse, 0, 4)
+	for _, role := range []model.Role{model.RoleOwner, model.RoleAdmin, model.RoleMember, model.RoleViewer} {
+		builtIn = append(builtIn, BuiltInRoleResponse{Name: string(role), Permissions: permissionStrings(role.Permissions())})
+	}
+	custom := make([]RoleResponse, 0, len(roles))
+	for _, role := range roles {
+		custom = append(custom, roleResponse(role))
+	}
+	response.Success(c, http.StatusOK, gin.H{"built_in_roles": builtIn, "roles": custom})
+}
+
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	uid, orgID, ok := orgAdminParams(c)
+	if !ok {
+		return
+	}
+
+	var req RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	role, err := h.roleService.CreateRole(c.Request.Context(), uid, orgID, roleInput(req), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, roleResponse(role))
+}
+
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	uid, orgID, roleID, ok := roleParams(c)
+	if !ok {
+		return
+	}
+
+	var req RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	role, err := h.roleService.UpdateRole(c.Request.Context(), uid, orgID, roleID, roleInput(req), c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, roleResponse(role))
+}
+
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	uid, orgID, roleID, ok := roleParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.roleService.DeleteRole(c.Request.Context(), uid, orgID, roleID, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "Role deleted"})
+}
+
+// AssignRole sets or, with a null role_id, removes the custom role of a member.
+func (h *RoleHandler) AssignRole(c *gin.Context) {
+	uid, orgID, memberID, ok := memberParams(c)
+	if !ok {
+		return
+	}
+
+	var req AssignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	membership, err := h.roleService.AssignRole(c.Request.Context(), uid, orgID, memberID, req.RoleID, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"user_id":        membership.UserID,
+		"role":           string(membership.Role),
+		"custom_role_id": membership.CustomRoleID,
+	})
+}
+
+func roleParams(c *gin.Context) (uuid.UUID, uuid.UUID, uuid.UUID, bool) {
+	uid, orgID, ok := orgAdminParams(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	roleID, err := uuid.Parse(c.Param("role_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid role ID")
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+
+	return uid, orgID, roleID, true
+}
+
+func roleInput(req RoleRequest) service.RoleInput {
+	input := service.RoleInput{Name: req.Name, Description: req.Description}
+	for _, permission := range req.Permissions {
+		input.Permissions = append(input.Permissions, model.Permission(permission))
+	}
+	return input
+}
+
+func roleResponse(role *model.OrgRole) RoleResponse {
+	return RoleResponse{
+		ID:          role.ID,
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissionStrings(role.Permissions),
+		CreatedAt:   role.CreatedAt,
+		UpdatedAt:   role.UpdatedAt,
+	}
+}
+
+func permissionStrings(permissions []model.Permission) []string {
+	result := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		result = append(result, string(permission))
+	}
+	return result
+}
@@ -39,6 +39,22 @@ func SetupRouter(
 	metricsCollector MetricsCollector,
 	emailService *service.EmailService,
 	passwordResetService *service.PasswordResetService,
+	mfaService MFAService,
+	passkeyService PasskeyService,
+	revocation RevocationChecker,
+	oauthService OAuthService,
+	socialService SocialService,
+	samlService SAMLService,
+	domainService DomainService,
+	scimService SCIMService,
+	invitationService InvitationService,
+	memberService MemberService,
+	roleService RoleService,
+	adminService AdminService,
+	complianceReporter ComplianceReporter,
+	magicLinkService MagicLinkService,
+	emailChangeService EmailChangeService,
+	dataExportService DataExportService,
 ) *gin.Engine {
 	r := gin.New()
 	r.Use(gin.Recovery())
@@ -119,10 +135,102 @@ func SetupRouter(
 		userHandler := NewUserHandler(userService, passwordService)
 		jwksHandler := NewJWKSHandler(jwtManager)
 
+		var passkeyHandler *PasskeyHandler
+		if passkeyService != nil {
+			passkeyHandler = NewPasskeyHandler(passkeyService, authService, auditService, metricsCollector)
+		}
+
+		var socialHandler *SocialHandler
+		if socialService != nil {
+			socialHandler = NewSocialHandler(socialService, authService, auditService, metricsCollector)
+		}
+
+		var magicLinkHandler *MagicLinkHandler
+		if magicLinkService != nil {
+			magicLinkHandler = NewMagicLinkHandler(magicLinkService, authService, auditService, metricsCollector)
+		}
+
+		var gdprHandler *GDPRHandler
+		if gdprService != nil {
+			gdprHandler = NewGDPRHandler(gdprService)
+		}
+
+		var dataExportHandler *DataExportHandler
+		if dataExportService != nil {
+			dataExportHandler = NewDataExportHandler(dataExportService)
+		}
+
+		var emailChangeHandler *EmailChangeHandler
+		if emailChangeService != nil {
+			emailChangeHandler = NewEmailChangeHandler(emailChangeService)
+		}
+
+		var samlHandler *SAMLHandler
+		if samlService != nil {
+			frontendBaseURL := ""
+			if cfg != nil {
+				frontendBaseURL = cfg.FrontendBaseURL
+			}
+			samlHandler = NewSAMLHandler(samlService, authService, auditService, metricsCollector, frontendBaseURL)
+		}
+
+		var domainHandler *DomainHandler
+		if domainService != nil {
+			domainHandler = NewDomainHandler(domainService)
+		}
+
+		var scimHandler *SCIMHandler
+		if scimService != nil {
+			scimHandler = NewSCIMHandler(scimService)
+		}
+
+		var invitationHandler *InvitationHandler
+		if invitationService != nil {
+			invitationHandler = NewInvitationHandler(invitationService, metricsCollector)
+		}
+
 		// JWKS endpoint (no versioning for standards compliance)
 		r.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
 		r.GET("/jwks", jwksHandler.GetJWKS) // Legacy endpoint
 
+		// OpenID Connect provider (no versioning for standards compliance)
+		var oauthHandler *OAuthHandler
+		if oauthService != nil {
+			frontendBaseURL := ""
+			if cfg != nil {
+				frontendBaseURL = cfg.FrontendBaseURL
+			}
+			oauthHandler = NewOAuthHandler(oauthService, jwtManager, revocation, frontendBaseURL)
+			r.GET("/.well-known/openid-configuration", oauthHandler.Discovery)
+			r.GET("/oauth/authorize", oauthHandler.Authorize)
+			r.POST("/oauth/token", LoginRateLimiter(), oauthHandler.Token)
+			r.GET("/userinfo", oauthHandler.UserInfo)
+			r.POST("/userinfo", oauthHandler.UserInfo)
+		}
+
+		// SCIM 2.0 provisioning (no versioning for standards compliance).
+		// Clients authenticate with an organization's SCIM token, not a
+		// browser session, so there is no CSRF check.
+		if scimHandler != nil {
+			scimAPI := r.Group("/scim/v2")
+			scimAPI.GET("/ServiceProviderConfig", scimHandler.ServiceProviderConfig)
+			scimAPI.GET("/ResourceTypes", scimHandler.ResourceTypes)
+
+			provisioning := scimAPI.Group("", scimHandler.Authenticate())
+			provisioning.GET("/Users", scimHandler.ListUsers)
+			provisioning.POST("/Users", scimHandler.CreateUser)
+			provisioning.GET("/Users/:id", scimHandler.GetUser)
+			provisioning.PUT("/Users/:id", scimHandler.ReplaceUser)
+			provisioning.PATCH("/Users/:id", scimHandler.PatchUser)
+			provisioning.DELETE("/Users/:id", scimHandler.DeleteUser)
+			provisioning.GET("/Groups", scimHandler.ListGroups)
+			provisioning.POST("/Groups", scimHandler.CreateGroup)
+			provisioning.GET("/Groups/:id", scimHandler.GetGroup)
+			provisioning.PUT("/Groups/:id", scimHandler.ReplaceGroup)
+			provisioning.PATCH("/Groups/:id", scimHandler.PatchGroup)
+			provisioning.DELETE("/Groups/:id", scimHandler.DeleteGroup)
+		}
+
 		// API v1 routes
 		v1 := r.Group("/v1")
 		{
@@ -130,26 +238,143 @@ func SetupRouter(
 			{
 				auth.POST("/register", RegisterRateLimiter(), authHandler.Register)
 				auth.POST("/login", LoginRateLimiter(), authHandler.Login)
+				auth.POST("/mfa/verify", LoginRateLimiter(), authHandler.VerifyMFA)
+				if passkeyHandler != nil {
+					auth.POST("/passkey/begin", LoginRateLimiter(), passkeyHandler.BeginLogin)
+					auth.POST("/passkey/finish", LoginRateLimiter(), passkeyHandler.FinishLogin)
+				}
+				if socialHandler != nil {
+					auth.GET("/social/providers", socialHandler.Providers)
+					auth.POST("/social/:provider/authorize", LoginRateLimiter(), socialHandler.Authorize)
+					auth.POST("/social/:provider/callback", LoginRateLimiter(), socialHandler.Callback)
+				}
+				if samlHandler != nil {
+					auth.POST("/saml/token", LoginRateLimiter(), samlHandler.Token)
+				}
+				if magicLinkHandler != nil {
+					auth.POST("/magic-link", middleware.StrictRateLimit(), CSRFMiddleware(), magicLinkHandler.Request)
+					auth.POST("/magic-link/verify", LoginRateLimiter(), magicLinkHandler.Verify)
+				}
+				if domainHandler != nil {
+					auth.POST("/sso/discover", LoginRateLimiter(), domainHandler.Discover)
+				}
 				auth.POST("/refresh", RefreshRateLimiter(), OriginCheckMiddleware(corsOrigins), CSRFMiddleware(), authHandler.Refresh)
-				auth.POST("/logout", AuthMiddleware(jwtManager), CSRFMiddleware(), authHandler.Logout)
+				auth.POST("/logout", AuthMiddleware(jwtManager, revocation), CSRFMiddleware(), authHandler.Logout)
+				auth.POST("/switch-organization", AuthMiddleware(jwtManager, revocation), CSRFMiddleware(), authHandler.SwitchOrganization)
 				auth.POST("/verify-email", CSRFMiddleware(), authHandler.VerifyEmail)
-				auth.POST("/resend-verification", AuthMiddleware(jwtManager), CSRFMiddleware(), authHandler.ResendVerification)
+				auth.POST("/resend-verification", AuthMiddleware(jwtManager, revocation), CSRFMiddleware(), authHandler.ResendVerification)
 				auth.POST("/forgot-password", middleware.StrictRateLimit(), CSRFMiddleware(), authHandler.ForgotPassword)
 				auth.POST("/reset-password", middleware.StrictRateLimit(), CSRFMiddleware(), authHandler.ResetPassword)
+				if emailChangeHandler != nil {
+					auth.POST("/confirm-email-change", middleware.StrictRateLimit(), CSRFMiddleware(), emailChangeHandler.Confirm)
+				}
+				if gdprHandler != nil {
+					auth.POST("/cancel-account-deletion", middleware.StrictRateLimit(), CSRFMiddleware(), gdprHandler.CancelDeletion)
+				}
 			}
 
-			me := v1.Group("/me", AuthMiddleware(jwtManager))
+			// Download of a personal data export with the emailed link
+			if dataExportHandler != nil {
+				v1.POST("/data-export/download", middleware.StrictRateLimit(), CSRFMiddleware(), dataExportHandler.Download)
+			}
+
+			// SAML single sign-on per organization. The ACS receives a
+			// cross-site form post from the IdP, so it has no CSRF check.
+			if samlHandler != nil {
+				v1.GET("/saml/:org_id/metadata", samlHandler.Metadata)
+				v1.POST("/saml/:org_id/login", LoginRateLimiter(), samlHandler.Login)
+				v1.POST("/saml/:org_id/acs", LoginRateLimiter(), samlHandler.ACS)
+
+				orgSAML := v1.Group("/organizations/:org_id/saml", AuthMiddleware(jwtManager, revocation))
+				orgSAML.GET("", samlHandler.GetConnection)
+				orgSAML.PUT("", CSRFMiddleware(), samlHandler.Configure)
+				orgSAML.DELETE("", CSRFMiddleware(), samlHandler.DeleteConnection)
+			}
+
+			// Email domains of an organization and SSO enforcement
+			if domainHandler != nil {
+				orgDomains := v1.Group("/organizations/:org_id/domains", AuthMiddleware(jwtManager, revocation))
+				orgDomains.GET("", domainHandler.ListDomains)
+				orgDomains.POST("", CSRFMiddleware(), domainHandler.ClaimDomain)
+				orgDomains.POST("/:domain_id/verify", CSRFMiddleware(), domainHandler.VerifyDomain)
+				orgDomains.PATCH("/:domain_id", CSRFMiddleware(), domainHandler.UpdateDomain)
+				orgDomains.DELETE("/:domain_id", CSRFMiddleware(), domainHandler.DeleteDomain)
+			}
+
+			// Whether members of an organization may sign in by email link
+			if magicLinkHandler != nil {
+				orgMagicLink := v1.Group("/organizations/:org_id/magic-link", AuthMiddleware(jwtManager, revocation))
+				orgMagicLink.GET("", magicLinkHandler.GetSetting)
+				orgMagicLink.PUT("", CSRFMiddleware(), magicLinkHandler.UpdateSetting)
+			}
+
+			// SCIM tokens of an organization
+			if scimHandler != nil {
+				orgSCIM := v1.Group("/organizations/:org_id/scim/tokens", AuthMiddleware(jwtManager, revocation))
+				orgSCIM.GET("", scimHandler.ListTokens)
+				orgSCIM.POST("", CSRFMiddleware(), scimHandler.CreateToken)
+				orgSCIM.DELETE("/:token_id", CSRFMiddleware(), scimHandler.DeleteToken)
+			}
+
+			// Invitations to join an organization
+			if invitationHandler != nil {
+				orgInvitations := v1.Group("/organizations/:org_id/invitations", AuthMiddleware(jwtManager, revocation))
+				orgInvitations.GET("", invitationHandler.ListInvitations)
+				orgInvitations.POST("", CSRFMiddleware(), invitationHandler.CreateInvitation)
+				orgInvitations.DELETE("/:invitation_id", CSRFMiddleware(), invitationHandler.RevokeInvitation)
+
+				v1.POST("/invitations/accept", AuthMiddleware(jwtManager, revocation), CSRFMiddleware(), invitationHandler.Accept)
+				v1.POST("/invitations/register", RegisterRateLimiter(), CSRFMiddleware(), invitationHandler.Register)
+			}
+
+			// Members of an organization. The access token must be issued
+			// for the organization; the service checks the current role.
+			if memberService != nil {
+				memberHandler := NewMemberHandler(memberService)
+				anyRole := RequireOrgRole(model.RoleOwner, model.RoleAdmin, model.RoleMember, model.RoleViewer)
+				orgMembers := v1.Group("/organizations/:org_id/members", AuthMiddleware(jwtManager, revocation), anyRole)
+				orgMembers.GET("", memberHandler.ListMembers)
+				orgMembers.PATCH("/:user_id", CSRFMiddleware(), RequireOrgRole(model.RoleOwner, model.RoleAdmin), memberHandler.UpdateMember)
+				orgMembers.DELETE("/:user_id", CSRFMiddleware(), memberHandler.RemoveMember)
+				v1.POST("/organizations/:org_id/transfer-ownership",
+					AuthMiddleware(jwtManager, revocation), RequireOrgRole(model.RoleOwner), CSRFMiddleware(), memberHandler.TransferOwnership)
+			}
+
+			// Custom roles of an organization and their assignment to members
+			if roleService != nil {
+				roleHandler := NewRoleHandler(roleService)
+				manageRoles := RequirePermission(model.PermRolesManage)
+				orgRoles := v1.Group("/organizations/:org_id/roles", AuthMiddleware(jwtManager, revocation))
+				orgRoles.GET("", RequirePermission(model.PermOrgRead), roleHandler.ListRoles)
+				orgRoles.POST("", CSRFMiddleware(), manageRoles, roleHandler.CreateRole)
+				orgRoles.PUT("/:role_id", CSRFMiddleware(), manageRoles, roleHandler.UpdateRole)
+				orgRoles.DELETE("/:role_id", CSRFMiddleware(), manageRoles, roleHandler.DeleteRole)
+				v1.PUT("/organizations/:org_id/members/:user_id/custom-role",
+					AuthMiddleware(jwtManager, revocation), CSRFMiddleware(), manageRoles, roleHandler.AssignRole)
+			}
+
+			if oauthHandler != nil {
+				v1.POST("/oauth/authorize", AuthMiddleware(jwtManager, revocation), CSRFMiddleware(), oauthHandler.ApproveAuthorization)
+			}
+
+			me := v1.Group("/me", AuthMiddleware(jwtManager, revocation))
 			{
 				me.GET("", userHandler.GetProfile)
 				me.GET("/status", userHandler.GetProfile)
+				if userService != nil {
+					me.PATCH("", CSRFMiddleware(), userHandler.UpdateProfile)
+				}
 				if passwordService != nil {
 					me.POST("/change-password", CSRFMiddleware(), userHandler.ChangePassword)
 				}
+				if emailChangeHandler != nil {
+					me.POST("/email", middleware.StrictRateLimit(), CSRFMiddleware(), emailChangeHandler.Request)
+				}
 
 				// Organizations
 				if db != nil {
 					orgRepo := postgres.NewOrganizationRepo(db)
-					orgHandler := NewOrganizationHandlerWithRepo(orgRepo, log.Logger)
+					orgHandler := NewOrganizationHandlerWithRepo(orgRepo, nil, log.Logger)
 					me.GET("/organizations", orgHandler.GetUserOrganizations)
 				}
 
@@ -160,12 +385,40 @@ func SetupRouter(
 					me.DELETE("/consents/:type", CSRFMiddleware(), consentHandler.RevokeConsent)
 				}
 
-				if gdprService != nil {
-					gdprHandler := NewGDPRHandler(gdprService, auditService, emailService)
-					me.GET("/data-export", gdprHandler.ExportData)
+				if gdprHandler != nil {
 					me.DELETE("/account", CSRFMiddleware(), gdprHandler.DeleteAccount)
 				}
 
+				if dataExportHandler != nil {
+					me.POST("/data-export", middleware.StrictRateLimit(), CSRFMiddleware(), dataExportHandler.Request)
+					me.GET("/data-export/:id", dataExportHandler.Get)
+				}
+
+				// Multi-factor authentication
+				if mfaService != nil {
+					mfaHandler := NewMFAHandler(mfaService)
+					me.GET("/mfa", mfaHandler.GetStatus)
+					me.POST("/mfa", CSRFMiddleware(), mfaHandler.BeginEnrollment)
+					me.POST("/mfa/confirm", CSRFMiddleware(), mfaHandler.ConfirmEnrollment)
+					me.POST("/mfa/regenerate", CSRFMiddleware(), mfaHandler.Regenerate)
+					me.POST("/mfa/recovery-codes", CSRFMiddleware(), mfaHandler.RegenerateRecoveryCodes)
+					me.DELETE("/mfa", CSRFMiddleware(), mfaHandler.Disable)
+				}
+
+				// Passkeys
+				if passkeyHandler != nil {
+					me.GET("/passkeys", passkeyHandler.List)
+					me.POST("/passkeys/begin", CSRFMiddleware(), passkeyHandler.BeginRegistration)
+					me.POST("/passkeys/finish", CSRFMiddleware(), passkeyHandler.FinishRegistration)
+					me.DELETE("/passkeys/:id", CSRFMiddleware(), passkeyHandler.Delete)
+				}
+
+				// Linked identity provider accounts
+				if socialHandler != nil {
+					me.GET("/identities", socialHandler.ListIdentities)
+					me.DELETE("/identities/:id", CSRFMiddleware(), socialHandler.Unlink)
+				}
+
 				// Session management
 				if sessionService != nil {
 					sessionHandler := NewSessionHandler(sessionService)
@@ -178,9 +431,9 @@ func SetupRouter(
 			// Organizations at v1 level
 			if db != nil {
 				orgRepo := postgres.NewOrganizationRepo(db)
-				orgHandler := NewOrganizationHandlerWithRepo(orgRepo, log.Logger)
-				v1.GET("/organizations", AuthMiddleware(jwtManager), orgHandler.GetUserOrganizations)
-				v1.GET("/status", AuthMiddleware(jwtManager), userHandler.GetProfile)
+				orgHandler := NewOrganizationHandlerWithRepo(orgRepo, nil, log.Logger)
+				v1.GET("/organizations", AuthMiddleware(jwtManager, revocation), orgHandler.GetUserOrganizations)
+				v1.GET("/status", AuthMiddleware(jwtManager, revocation), userHandler.GetProfile)
 			}
 		}
 
@@ -190,10 +443,10 @@ func SetupRouter(
 			auth.POST("/register", RegisterRateLimiter(), authHandler.Register)
 			auth.POST("/login", LoginRateLimiter(), authHandler.Login)
 			auth.POST("/refresh", RefreshRateLimiter(), OriginCheckMiddleware(corsOrigins), CSRFMiddleware(), authHandler.Refresh)
-			auth.POST("/logout", AuthMiddleware(jwtManager), CSRFMiddleware(), authHandler.Logout)
+			auth.POST("/logout", AuthMiddleware(jwtManager, revocation), CSRFMiddleware(), authHandler.Logout)
 		}
 
-		me := r.Group("/me", AuthMiddleware(jwtManager))
+		me := r.Group("/me", AuthMiddleware(jwtManager, revocation))
 		{
 			me.GET("", userHandler.GetProfile)
 		}
@@ -201,23 +454,36 @@ func SetupRouter(
 		// Legacy routes for frontend compatibility
 		if db != nil {
 			orgRepo := postgres.NewOrganizationRepo(db)
-			orgHandler := NewOrganizationHandlerWithRepo(orgRepo, log.Logger)
-			r.GET("/status", AuthMiddleware(jwtManager), userHandler.GetProfile)
-			r.GET("/organizations", AuthMiddleware(jwtManager), orgHandler.GetUserOrganizations)
+			orgHandler := NewOrganizationHandlerWithRepo(orgRepo, nil, log.Logger)
+			r.GET("/status", AuthMiddleware(jwtManager, revocation), userHandler.GetProfile)
+			r.GET("/organizations", AuthMiddleware(jwtManager, revocation), orgHandler.GetUserOrganizations)
 		}
 	}
 
-	// Admin endpoints - always protected, enabled in production
-	if env == "production" || env == "prod" {
-		_ = r.Group("/admin", AdminAuthMiddleware())
-		// TODO: Implement ComplianceReporter interface for AuditService
-		// admin := r.Group("/admin", AdminAuthMiddleware())
-		// if auditService != nil {
-		// 	complianceHandler := NewComplianceHandler(auditService)
-		// 	admin.GET("/compliance/report", complianceHandler.GetComplianceReport)
-		// 	admin.GET("/compliance/status", complianceHandler.GetComplianceStatus)
-		// }
-		_ = auditService // prevent unused variable error
+	// Admin endpoints - platform admins with an MFA session only
+	if adminService != nil {
+		admin := r.Group("/admin", AuthMiddleware(jwtManager, revocation), RequirePlatformAdmin(adminService))
+		adminHandler := NewAdminHandler(adminService)
+		users := admin.Group("/users")
+		{
+			users.GET("", adminHandler.SearchUsers)
+			users.GET("/:user_id", adminHandler.GetUser)
+			users.POST("/:user_id/lock", CSRFMiddleware(), adminHandler.LockUser)
+			users.POST("/:user_id/unlock", CSRFMiddleware(), adminHandler.UnlockUser)
+			users.POST("/:user_id/password-reset", CSRFMiddleware(), adminHandler.ForcePasswordReset)
+			users.POST("/:user_id/revoke-sessions", CSRFMiddleware(), adminHandler.RevokeSessions)
+			users.POST("/:user_id/deactivate", CSRFMiddleware(), adminHandler.DeactivateUser)
+			users.POST("/:user_id/restore", CSRFMiddleware(), adminHandler.RestoreUser)
+		}
+
+		if complianceReporter != nil {
+			complianceHandler := NewComplianceHandler(complianceReporter, complianceFeatures(
+				cfg, dataExportService != nil, gdprService != nil, consentService != nil, cleanupService != nil,
+				auditService != nil, sessionService != nil, mfaService != nil,
+			))
+			admin.GET("/compliance/report", complianceHandler.GetComplianceReport)
+			admin.GET("/compliance/status", complianceHandler.GetComplianceStatus)
+		}
 	}
 
 	return r
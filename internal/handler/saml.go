@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/response"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/service"
+)
+
+type SAMLService interface {
+	SPMetadata(ctx context.Context, orgID uuid.UUID) ([]byte, error)
+	LoginURL(ctx context.Context, orgID uuid.UUID) (string, error)
+	GetConnection(ctx context.Context, userID, orgID uuid.UUID) (*model.SAMLConnection, error)
+	Configure(ctx context.Context, userID, orgID uuid.UUID, input service.SAMLConnectionInput, ipAddress, userAgent string) (*model.SAMLConnection, error)
+	DeleteConnection(ctx context.Context, userID, orgID uuid.UUID, ipAddress, userAgent string) error
+}
+
+type SAMLHandler struct {
+	samlService     SAMLService
+	authService     service.AuthServiceInterface
+	auditService    AuditService
+	metrics         MetricsCollector
+	frontendBaseURL string
+}
+
+func NewSAMLHandler(samlService SAMLService, authService service.AuthServiceInterface, auditService AuditService, metrics MetricsCollector, frontendBaseURL string) *SAMLHandler {
+	return &SAMLHandler{
+		samlService:     samlService,
+		authService:     authService,
+		auditService:    auditService,
+		metrics:         metrics,
+		frontendBaseURL: strings.TrimRight(frontendBaseURL, "/"),
+	}
+}
+
+// Metadata serves the service provider metadata of the organization.
+func (h *SAMLHandler) Metadata(c *gin.Context) {
+	orgID, err := uuid.Parse(c.Param("org_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid organization ID")
+		return
+	}
+
+	metadata, err := h.samlService.SPMetadata(c.Request.Context(), orgID)
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/samlmetadata+xml", metadata)
+}
+
+// Login returns the IdP URL to redirect the browser to.
+func (h *SAMLHandler) Login(c *gin.Context) {
+	orgID, err := uuid.Parse(c.Param("org_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid organization ID")
+		return
+	}
+
+	redirectURL, err := h.samlService.LoginURL(c.Request.Context(), orgID)
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"redirect_url": redirectURL})
+}
+
+// ACS is the assertion consumer service the IdP posts its response to. The
+// browser is sent on to the frontend with a one-time code, or an error code.
+func (h *SAMLHandler) ACS(c *gin.Context) {
+	callback := h.frontendBaseURL + "/auth/saml/callback?"
+
+	orgID, err := uuid.Parse(c.Param("org_id"))
+	if err != nil {
+		c.Redirect(http.StatusSeeOther, callback+url.Values{"error": {"saml_login_failed"}}.Encode())
+		return
+	}
+
+	userAgent := c.GetHeader("User-Agent")
+	ipAddress := c.ClientIP()
+
+	code, err := h.authService.CompleteSAMLResponse(c.Request.Context(), orgID, c.PostForm("SAMLResponse"), userAgent, ipAddress)
+	if err != nil {
+		if h.auditService != nil {
+			_ = h.auditService.Log(c.Request.Context(), nil, "login_failed", map[string]interface{}{"method": "saml", "org_id": orgID.String()}, ipAddress, userAgent)
+		}
+		if h.metrics != nil {
+			h.metrics.IncrementLoginFailures()
+		}
+		httpErr := errors.MapErrorToHTTP(err)
+		c.Redirect(http.StatusSeeOther, callback+url.Values{"error": {httpErr.Code}}.Encode())
+		return
+	}
+
+	c.Redirect(http.StatusSeeOther, callback+url.Values{"code": {code}}.Encode())
+}
+
+// Token exchanges the code from the ACS redirect for tokens.
+func (h *SAMLHandler) Token(c *gin.Context) {
+	var req SAMLTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	userAgent := c.GetHeader("User-Agent")
+	ipAddress := c.ClientIP()
+
+	result, err := h.authService.CompleteSAMLLogin(c.Request.Context(), req.Code, userAgent, ipAddress)
+	if err != nil {
+		if h.auditService != nil {
+			_ = h.auditService.Log(c.Request.Context(), nil, "login_failed", map[string]interface{}{"method": "saml"}, ipAddress, userAgent)
+		}
+		if h.metrics != nil {
+			h.metrics.IncrementLoginFailures()
+		}
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	// Second factor required - tokens are issued by VerifyMFA
+	if result.MFARequired {
+		response.Success(c, http.StatusOK, MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    result.MFAToken,
+		})
+		return
+	}
+
+	if h.auditService != nil {
+		_ = h.auditService.Log(c.Request.Context(), &result.UserID, "user_logged_in", map[string]interface{}{"method": "saml"}, ipAddress, userAgent)
+	}
+
+	if h.metrics != nil {
+		h.metrics.IncrementLogins()
+	}
+
+	response.Success(c, http.StatusOK, AuthResponse{
+		AccessToken:  result.AccessToken,
+		RefreshToken: result.RefreshToken,
+	})
+}
+
+func (h *SAMLHandler) GetConnection(c *gin.Context) {
+	uid, orgID, ok := orgAdminParams(c)
+	if !ok {
+		return
+	}
+
+	conn, err := h.samlService.GetConnection(c.Request.Context(), uid, orgID)
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, samlConnectionResponse(conn))
+}
+
+// Configure uploads the organization's IdP metadata.
+func (h *SAMLHandler) Configure(c *gin.Context) {
+	uid, orgID, ok := orgAdminParams(c)
+	if !ok {
+		return
+	}
+
+	var req SAMLConnectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	input := service.SAMLConnectionInput{
+		MetadataXML:    req.MetadataXML,
+		EmailAttribute: req.EmailAttribute,
+		NameAttribute:  req.NameAttribute,
+		DefaultRole:    model.Role(strings.ToUpper(req.DefaultRole)),
+		Enabled:        req.Enabled == nil || *req.Enabled,
+	}
+
+	conn, err := h.samlService.Configure(c.Request.Context(), uid, orgID, input, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, samlConnectionResponse(conn))
+}
+
+func (h *SAMLHandler) DeleteConnection(c *gin.Context) {
+	uid, orgID, ok := orgAdminParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.samlService.DeleteConnection(c.Request.Context(), uid, orgID, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "Single sign-on removed"})
+}
+
+// orgAdminParams reads the authenticated user and the organization from the
+// path. Whether the user may manage the organization is checked by the service.
+func orgAdminParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Unauthorized")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	orgID, err := uuid.Parse(c.Param("org_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid organization ID")
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return uid, orgID, true
+}
+
+func samlConnectionResponse(conn *model.SAMLConnection) SAMLConnectionResponse {
+	return SAMLConnectionResponse{
+		IdPEntityID:    conn.IdPEntityID,
+		IdPSSOURL:      conn.IdPSSOURL,
+		EmailAttribute: conn.EmailAttribute,
+		NameAttribute:  conn.NameAttribute,
+		DefaultRole:    string(conn.DefaultRole),
+		Enabled:        conn.Enabled,
+		UpdatedAt:      conn.UpdatedAt,
+	}
+}
@@ -0,0 +1,418 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/response"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/scim"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/service"
+)
+
+const scimClientKey = "scim_client"
+
+type SCIMService interface {
+	ListTokens(ctx context.Context, userID, orgID uuid.UUID) ([]*model.SCIMToken, error)
+	CreateToken(ctx context.Context, userID, orgID uuid.UUID, description, ipAddress, userAgent string) (*model.SCIMToken, string, error)
+	DeleteToken(ctx context.Context, userID, orgID, tokenID uuid.UUID, ipAddress, userAgent string) error
+	Authenticate(ctx context.Context, bearer string) (*service.SCIMClient, error)
+
+	ListUsers(ctx context.Context, client *service.SCIMClient, filter string, startIndex, count int) (*scim.ListResponse, error)
+	GetUser(ctx context.Context, client *service.SCIMClient, id string) (*scim.User, error)
+	CreateUser(ctx context.Context, client *service.SCIMClient, input *scim.User) (*scim.User, error)
+	ReplaceUser(ctx context.Context, client *service.SCIMClient, id string, input *scim.User) (*scim.User, error)
+	PatchUser(ctx context.Context, client *service.SCIMClient, id string, ops []scim.PatchOperation) (*scim.User, error)
+	DeleteUser(ctx context.Context, client *service.SCIMClient, id string) error
+
+	ListGroups(ctx context.Context, client *service.SCIMClient, filter string, startIndex, count int, excludeMembers bool) (*scim.ListResponse, error)
+	GetGroup(ctx context.Context, client *service.SCIMClient, id string, excludeMembers bool) (*scim.Group, error)
+	CreateGroup(ctx context.Context, client *service.SCIMClient, input *scim.Group) (*scim.Group, error)
+	ReplaceGroup(ctx context.Context, client *service.SCIMClient, id string, input *scim.Group) (*scim.Group, error)
+	PatchGroup(ctx context.Context, client *service.SCIMClient, id string, ops []scim.PatchOperation) (*scim.Group, error)
+	DeleteGroup(ctx context.Context, client *service.SCIMClient, id string) error
+}
+
+// SCIMHandler serves the SCIM 2.0 API identity providers provision
+// organizations through, and the endpoints organization admins manage SCIM
+// tokens with. SCIM responses use the SCIM media type and error format
+// instead of the API envelope.
+type SCIMHandler struct {
+	scimService SCIMService
+}
+
+func NewSCIMHandler(scimService SCIMService) *SCIMHandler {
+	return &SCIMHandler{scimService: scimService}
+}
+
+func (h *SCIMHandler) ListTokens(c *gin.Context) {
+	uid, orgID, ok := orgAdminParams(c)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.scimService.ListTokens(c.Request.Context(), uid, orgID)
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	result := make([]SCIMTokenResponse, 0, len(tokens))
+	for _, scimToken := range tokens {
+		result = append(result, scimTokenResponse(scimToken))
+	}
+	response.Success(c, http.StatusOK, gin.H{"tokens": result})
+}
+
+// CreateToken returns the token; it cannot be retrieved again.
+func (h *SCIMHandler) CreateToken(c *gin.Context) {
+	uid, orgID, ok := orgAdminParams(c)
+	if !ok {
+		return
+	}
+
+	var req CreateSCIMTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	scimToken, secret, err := h.scimService.CreateToken(c.Request.Context(), uid, orgID, req.Description, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	result := scimTokenResponse(scimToken)
+	result.Token = secret
+	response.Success(c, http.StatusCreated, result)
+}
+
+func (h *SCIMHandler) DeleteToken(c *gin.Context) {
+	uid, orgID, ok := orgAdminParams(c)
+	if !ok {
+		return
+	}
+
+	tokenID, err := uuid.Parse(c.Param("token_id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid token ID")
+		return
+	}
+
+	if err := h.scimService.DeleteToken(c.Request.Context(), uid, orgID, tokenID, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "SCIM token revoked"})
+}
+
+// Authenticate is the middleware of the SCIM API. The bearer token
+// determines the organization the client acts on.
+func (h *SCIMHandler) Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bearer := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		client, err := h.scimService.Authenticate(c.Request.Context(), bearer)
+		if err != nil {
+			h.error(c, err)
+			c.Abort()
+			return
+		}
+
+		client.IPAddress = c.ClientIP()
+		client.UserAgent = c.GetHeader("User-Agent")
+		c.Set(scimClientKey, client)
+		c.Next()
+	}
+}
+
+func (h *SCIMHandler) ServiceProviderConfig(c *gin.Context) {
+	supported := gin.H{"supported": true}
+	unsupported := gin.H{"supported": false}
+	h.write(c, http.StatusOK, gin.H{
+		"schemas":          []string{scim.SchemaSPConfig},
+		"documentationUri": "https://github.com/ZenoN-Cloud/zeno-auth/blob/main/docs/SCIM.md",
+		"patch":            supported,
+		"bulk":             gin.H{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":           gin.H{"supported": true, "maxResults": service.SCIMMaxResults},
+		"changePassword":   unsupported,
+		"sort":             unsupported,
+		"etag":             unsupported,
+		"authenticationSchemes": []gin.H{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer token",
+			"description": "SCIM token issued to the organization",
+			"primary":     true,
+		}},
+	})
+}
+
+func (h *SCIMHandler) ResourceTypes(c *gin.Context) {
+	resources := []interface{}{
+		gin.H{
+			"schemas":  []string{scim.SchemaResourceType},
+			"id":       "User",
+			"name":     "User",
+			"endpoint": "/Users",
+			"schema":   scim.SchemaUser,
+		},
+		gin.H{
+			"schemas":  []string{scim.SchemaResourceType},
+			"id":       "Group",
+			"name":     "Group",
+			"endpoint": "/Groups",
+			"schema":   scim.SchemaGroup,
+		},
+	}
+	h.write(c, http.StatusOK, &scim.ListResponse{
+		Schemas:      []string{scim.SchemaList},
+		TotalResults: len(resources),
+		StartIndex:   1,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+func (h *SCIMHandler) ListUsers(c *gin.Context) {
+	startIndex, count, err := scimPaging(c)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	list, err := h.scimService.ListUsers(c.Request.Context(), scimClient(c), c.Query("filter"), startIndex, count)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+	h.write(c, http.StatusOK, list)
+}
+
+func (h *SCIMHandler) GetUser(c *gin.Context) {
+	user, err := h.scimService.GetUser(c.Request.Context(), scimClient(c), c.Param("id"))
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+	h.write(c, http.StatusOK, user)
+}
+
+func (h *SCIMHandler) CreateUser(c *gin.Context) {
+	var input scim.User
+	if err := h.read(c, &input); err != nil {
+		h.error(c, err)
+		return
+	}
+
+	user, err := h.scimService.CreateUser(c.Request.Context(), scimClient(c), &input)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+	c.Header("Location", user.Meta.Location)
+	h.write(c, http.StatusCreated, user)
+}
+
+func (h *SCIMHandler) ReplaceUser(c *gin.Context) {
+	var input scim.User
+	if err := h.read(c, &input); err != nil {
+		h.error(c, err)
+		return
+	}
+
+	user, err := h.scimService.ReplaceUser(c.Request.Context(), scimClient(c), c.Param("id"), &input)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+	h.write(c, http.StatusOK, user)
+}
+
+func (h *SCIMHandler) PatchUser(c *gin.Context) {
+	var req scim.PatchRequest
+	if err := h.read(c, &req); err != nil {
+		h.error(c, err)
+		return
+	}
+
+	user, err := h.scimService.PatchUser(c.Request.Context(), scimClient(c), c.Param("id"), req.Operations)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+	h.write(c, http.StatusOK, user)
+}
+
+func (h *SCIMHandler) DeleteUser(c *gin.Context) {
+	if err := h.scimService.DeleteUser(c.Request.Context(), scimClient(c), c.Param("id")); err != nil {
+		h.error(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *SCIMHandler) ListGroups(c *gin.Context) {
+	startIndex, count, err := scimPaging(c)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+
+	list, err := h.scimService.ListGroups(c.Request.Context(), scimClient(c), c.Query("filter"), startIndex, count, excludesMembers(c))
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+	h.write(c, http.StatusOK, list)
+}
+
+func (h *SCIMHandler) GetGroup(c *gin.Context) {
+	group, err := h.scimService.GetGroup(c.Request.Context(), scimClient(c), c.Param("id"), excludesMembers(c))
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+	h.write(c, http.StatusOK, group)
+}
+
+func (h *SCIMHandler) CreateGroup(c *gin.Context) {
+	var input scim.Group
+	if err := h.read(c, &input); err != nil {
+		h.error(c, err)
+		return
+	}
+
+	group, err := h.scimService.CreateGroup(c.Request.Context(), scimClient(c), &input)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+	c.Header("Location", group.Meta.Location)
+	h.write(c, http.StatusCreated, group)
+}
+
+func (h *SCIMHandler) ReplaceGroup(c *gin.Context) {
+	var input scim.Group
+	if err := h.read(c, &input); err != nil {
+		h.error(c, err)
+		return
+	}
+
+	group, err := h.scimService.ReplaceGroup(c.Request.Context(), scimClient(c), c.Param("id"), &input)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+	h.write(c, http.StatusOK, group)
+}
+
+func (h *SCIMHandler) PatchGroup(c *gin.Context) {
+	var req scim.PatchRequest
+	if err := h.read(c, &req); err != nil {
+		h.error(c, err)
+		return
+	}
+
+	group, err := h.scimService.PatchGroup(c.Request.Context(), scimClient(c), c.Param("id"), req.Operations)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+	h.write(c, http.StatusOK, group)
+}
+
+func (h *SCIMHandler) DeleteGroup(c *gin.Context) {
+	if err := h.scimService.DeleteGroup(c.Request.Context(), scimClient(c), c.Param("id")); err != nil {
+		h.error(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// read decodes a SCIM request body, which clients send as
+// application/scim+json or application/json.
+func (h *SCIMHandler) read(c *gin.Context, v interface{}) error {
+	if err := json.NewDecoder(c.Request.Body).Decode(v); err != nil {
+		return scim.BadRequest(scim.ErrTypeInvalidSyntax, "invalid request body")
+	}
+	return nil
+}
+
+func (h *SCIMHandler) write(c *gin.Context, status int, body interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		h.error(c, err)
+		return
+	}
+	c.Data(status, scim.ContentType, data)
+}
+
+func (h *SCIMHandler) error(c *gin.Context, err error) {
+	var scimErr *scim.Error
+	switch {
+	case stdErrors.As(err, &scimErr):
+	case stdErrors.Is(err, errors.ErrUnauthorized):
+		c.Header("WWW-Authenticate", `Bearer realm="scim"`)
+		scimErr = scim.NewError(http.StatusUnauthorized, "", "invalid SCIM token")
+	default:
+		log.Error().Err(err).Str("path", c.FullPath()).Msg("SCIM request failed")
+		scimErr = scim.NewError(http.StatusInternalServerError, "", "internal server error")
+	}
+
+	data, _ := json.Marshal(scimErr)
+	c.Data(scimErr.Status, scim.ContentType, data)
+}
+
+func scimClient(c *gin.Context) *service.SCIMClient {
+	client, _ := c.Get(scimClientKey)
+	return client.(*service.SCIMClient)
+}
+
+// scimPaging reads startIndex and count. Without count a full page is
+// returned.
+func scimPaging(c *gin.Context) (int, int, error) {
+	startIndex, count := 1, service.SCIMMaxResults
+	var err error
+	if value := c.Query("startIndex"); value != "" {
+		if startIndex, err = strconv.Atoi(value); err != nil {
+			return 0, 0, scim.BadRequest(scim.ErrTypeInvalidValue, "invalid startIndex")
+		}
+	}
+	if value := c.Query("count"); value != "" {
+		if count, err = strconv.Atoi(value); err != nil {
+			return 0, 0, scim.BadRequest(scim.ErrTypeInvalidValue, "invalid count")
+		}
+	}
+	return startIndex, count, nil
+}
+
+func excludesMembers(c *gin.Context) bool {
+	for _, attr := range strings.Split(c.Query("excludedAttributes"), ",") {
+		if strings.EqualFold(strings.TrimSpace(attr), "members") {
+			return true
+		}
+	}
+	return false
+}
+
+func scimTokenResponse(scimToken *model.SCIMToken) SCIMTokenResponse {
+	return SCIMTokenResponse{
+		ID:          scimToken.ID,
+		Description: scimToken.Description,
+		LastUsedAt:  scimToken.LastUsedAt,
+		CreatedAt:   scimToken.CreatedAt,
+	}
+}
@@ -2,8 +2,10 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"net/http"
 
+	appErrors "github.com/ZenoN-Cloud/zeno-auth/internal/errors"
 	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -12,7 +14,7 @@ import (
 
 type SessionService interface {
 	GetActiveSessions(ctx context.Context, userID uuid.UUID) ([]*model.RefreshToken, error)
-	RevokeSession(ctx context.Context, sessionID uuid.UUID) error
+	RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error
 	RevokeAllSessions(ctx context.Context, userID uuid.UUID) error
 }
 
@@ -45,6 +47,12 @@ func (h *SessionHandler) GetSessions(c *gin.Context) {
 }
 
 func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	uid, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
 	sessionID := c.Param("id")
 	sid, err := uuid.Parse(sessionID)
 	if err != nil {
@@ -52,7 +60,12 @@ func (h *SessionHandler) RevokeSession(c *gin.Context) {
 		return
 	}
 
-	if err := h.sessionService.RevokeSession(c.Request.Context(), sid); err != nil {
+	if err := h.sessionService.RevokeSession(c.Request.Context(), uid, sid); err != nil {
+		if errors.Is(err, appErrors.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			return
+		}
+		log.Error().Err(err).Str("user_id", uid.String()).Msg("Failed to revoke session")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
 		return
 	}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/response"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/service"
+)
+
+type SocialService interface {
+	Providers() []service.SocialProviderInfo
+	AuthorizationURL(ctx context.Context, provider string) (string, string, error)
+	ListIdentities(ctx context.Context, userID uuid.UUID) ([]*model.UserIdentity, error)
+	Unlink(ctx context.Context, userID, identityID uuid.UUID, ipAddress, userAgent string) error
+}
+
+type SocialHandler struct {
+	socialService SocialService
+	authService   service.AuthServiceInterface
+	auditService  AuditService
+	metrics       MetricsCollector
+}
+
+func NewSocialHandler(socialService SocialService, authService service.AuthServiceInterface, auditService AuditService, metrics MetricsCollector) *SocialHandler {
+	return &SocialHandler{
+		socialService: socialService,
+		authService:   authService,
+		auditService:  auditService,
+		metrics:       metrics,
+	}
+}
+
+func (h *SocialHandler) Providers(c *gin.Context) {
+	response.Success(c, http.StatusOK, gin.H{"providers": h.socialService.Providers()})
+}
+
+// Authorize returns the provider URL to redirect the browser to. The frontend
+// keeps the state and only completes the login if the provider returns it.
+func (h *SocialHandler) Authorize(c *gin.Context) {
+	authorizationURL, state, err := h.socialService.AuthorizationURL(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{
+		"authorization_url": authorizationURL,
+		"state":             state,
+	})
+}
+
+func (h *SocialHandler) Callback(c *gin.Context) {
+	var req SocialCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	provider := c.Param("provider")
+	userAgent := c.GetHeader("User-Agent")
+	ipAddress := c.ClientIP()
+
+	result, err := h.authService.CompleteSocialLogin(c.Request.Context(), provider, req.Code, req.State, userAgent, ipAddress)
+	if err != nil {
+		if h.auditService != nil {
+			_ = h.auditService.Log(c.Request.Context(), nil, "login_failed", map[string]interface{}{"method": "social", "provider": provider}, ipAddress, userAgent)
+		}
+		if h.metrics != nil {
+			h.metrics.IncrementLoginFailures()
+		}
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	// Second factor required - tokens are issued by VerifyMFA
+	if result.MFARequired {
+		response.Success(c, http.StatusOK, MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    result.MFAToken,
+		})
+		return
+	}
+
+	if h.auditService != nil {
+		_ = h.auditService.Log(c.Request.Context(), &result.UserID, "user_logged_in", map[string]interface{}{"method": "social", "provider": provider}, ipAddress, userAgent)
+	}
+
+	if h.metrics != nil {
+		h.metrics.IncrementLogins()
+	}
+
+	response.Success(c, http.StatusOK, AuthResponse{
+		AccessToken:  result.AccessToken,
+		RefreshToken: result.RefreshToken,
+	})
+}
+
+func (h *SocialHandler) ListIdentities(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	identities, err := h.socialService.ListIdentities(c.Request.Context(), uid)
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	resp := make([]IdentityResponse, 0, len(identities))
+	for _, identity := range identities {
+		resp = append(resp, IdentityResponse{
+			ID:          identity.ID,
+			Provider:    identity.Provider,
+			Email:       identity.Email,
+			CreatedAt:   identity.CreatedAt,
+			LastLoginAt: identity.LastLoginAt,
+		})
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"identities": resp})
+}
+
+func (h *SocialHandler) Unlink(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	identityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		response.BadRequest(c, "Invalid identity ID")
+		return
+	}
+
+	if err := h.socialService.Unlink(c.Request.Context(), uid, identityID, c.ClientIP(), c.GetHeader("User-Agent")); err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"message": "Account unlinked"})
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -14,8 +15,45 @@ type RegisterRequest struct {
 }
 
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required" log:"-"`
+	Email    string     `json:"email" binding:"required,email"`
+	Password string     `json:"password" binding:"required" log:"-"`
+	OrgID    *uuid.UUID `json:"org_id"`
+}
+
+type SwitchOrganizationRequest struct {
+	OrgID uuid.UUID `json:"org_id" binding:"required"`
+}
+
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required" log:"-"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required" log:"-"`
+}
+
+// PasskeyRegistrationRequest is the JSON form (PublicKeyCredential.toJSON) of a
+// navigator.credentials.create() result. Binary fields are base64url.
+type PasskeyRegistrationRequest struct {
+	Name     string `json:"name" binding:"max=100"`
+	ID       string `json:"id" binding:"required"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON" binding:"required"`
+		AttestationObject string   `json:"attestationObject" binding:"required"`
+		Transports        []string `json:"transports"`
+	} `json:"response" binding:"required"`
+}
+
+// PasskeyLoginRequest is the JSON form of a navigator.credentials.get() result.
+type PasskeyLoginRequest struct {
+	ID       string `json:"id" binding:"required"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON" binding:"required"`
+		AuthenticatorData string `json:"authenticatorData" binding:"required"`
+		Signature         string `json:"signature" binding:"required" log:"-"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response" binding:"required"`
 }
 
 type RefreshRequest struct {
@@ -27,13 +65,267 @@ type AuthResponse struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+}
+
+type PasskeyResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Transports []string   `json:"transports"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+type MagicLinkRequest struct {
+	Email string     `json:"email" binding:"required,email"`
+	OrgID *uuid.UUID `json:"org_id"`
+}
+
+type MagicLinkVerifyRequest struct {
+	Token string `json:"token" binding:"required" log:"-"`
+}
+
+type MagicLinkSettingRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+type EmailChangeRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required" log:"-"`
+	NewEmail        string `json:"new_email" binding:"required,email"`
+}
+
+type EmailChangeConfirmRequest struct {
+	Token string `json:"token" binding:"required" log:"-"`
+}
+
+type CancelAccountDeletionRequest struct {
+	Token string `json:"token" binding:"required" log:"-"`
+}
+
+type DataExportDownloadRequest struct {
+	Token string `json:"token" binding:"required" log:"-"`
+}
+
+type SocialCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+type IdentityResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	Provider    string     `json:"provider"`
+	Email       *string    `json:"email,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+type SAMLConnectionRequest struct {
+	MetadataXML    string `json:"metadata_xml" binding:"required"`
+	EmailAttribute string `json:"email_attribute"`
+	NameAttribute  string `json:"name_attribute"`
+	DefaultRole    string `json:"default_role"`
+	Enabled        *bool  `json:"enabled"`
+}
+
+type SAMLConnectionResponse struct {
+	IdPEntityID    string    `json:"idp_entity_id"`
+	IdPSSOURL      string    `json:"idp_sso_url"`
+	EmailAttribute *string   `json:"email_attribute,omitempty"`
+	NameAttribute  *string   `json:"name_attribute,omitempty"`
+	DefaultRole    string    `json:"default_role"`
+	Enabled        bool      `json:"enabled"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type SAMLTokenRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type ClaimDomainRequest struct {
+	Domain string `json:"domain" binding:"required"`
+}
+
+type UpdateDomainRequest struct {
+	SSOEnforced *bool `json:"sso_enforced"`
+}
+
+type DomainVerificationRecord struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type DomainResponse struct {
+	ID           uuid.UUID                 `json:"id"`
+	Domain       string                    `json:"domain"`
+	Verified     bool                      `json:"verified"`
+	VerifiedAt   *time.Time                `json:"verified_at,omitempty"`
+	SSOEnforced  bool                      `json:"sso_enforced"`
+	Verification *DomainVerificationRecord `json:"verification,omitempty"`
+	CreatedAt    time.Time                 `json:"created_at"`
+}
+
+type SSODiscoveryRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type SSODiscoveryResponse struct {
+	SSO         bool       `json:"sso"`
+	SSORequired bool       `json:"sso_required"`
+	OrgID       *uuid.UUID `json:"org_id,omitempty"`
+}
+
+type CreateSCIMTokenRequest struct {
+	Description string `json:"description" binding:"max=255"`
+}
+
+type SCIMTokenResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	Description string     `json:"description"`
+	Token       string     `json:"token,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type CreateInvitationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role"`
+}
+
+type InvitationResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Email     string     `json:"email"`
+	Role      string     `json:"role"`
+	InvitedBy *uuid.UUID `json:"invited_by,omitempty"`
+	Expired   bool       `json:"expired"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type AcceptInvitationRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type AcceptInvitationResponse struct {
+	OrgID uuid.UUID `json:"org_id"`
+	Role  string    `json:"role"`
+}
+
+type InvitationRegisterRequest struct {
+	Token    string `json:"token" binding:"required"`
+	FullName string `json:"full_name" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type InvitationRegisterResponse struct {
+	User  UserResponse `json:"user"`
+	OrgID uuid.UUID    `json:"org_id"`
+	Role  string       `json:"role"`
+}
+
+type MemberResponse struct {
+	UserID       uuid.UUID  `json:"user_id"`
+	Email        string     `json:"email"`
+	FullName     string     `json:"full_name"`
+	Role         string     `json:"role"`
+	CustomRoleID *uuid.UUID `json:"custom_role_id,omitempty"`
+	IsActive     bool       `json:"is_active"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type UpdateMemberRequest struct {
+	Role     *string `json:"role"`
+	IsActive *bool   `json:"is_active"`
+}
+
+type TransferOwnershipRequest struct {
+	UserID uuid.UUID `json:"user_id" binding:"required"`
+}
+
+type RoleRequest struct {
+	Name        string   `json:"name" binding:"required,max=100"`
+	Description string   `json:"description" binding:"max=500"`
+	Permissions []string `json:"permissions"`
+}
+
+type RoleResponse struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type BuiltInRoleResponse struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+type AssignRoleRequest struct {
+	RoleID *uuid.UUID `json:"role_id"`
+}
+
+type AdminUserResponse struct {
+	ID                  uuid.UUID  `json:"id"`
+	Email               string     `json:"email"`
+	FullName            string     `json:"full_name"`
+	IsActive            bool       `json:"is_active"`
+	IsPlatformAdmin     bool       `json:"is_platform_admin"`
+	FailedLoginAttempts int        `json:"failed_login_attempts"`
+	LockedUntil         *time.Time `json:"locked_until,omitempty"`
+	DeletedAt           *time.Time `json:"deleted_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+type AdminMembershipResponse struct {
+	OrgID        uuid.UUID  `json:"org_id"`
+	Role         string     `json:"role"`
+	CustomRoleID *uuid.UUID `json:"custom_role_id,omitempty"`
+	IsActive     bool       `json:"is_active"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type AdminSessionResponse struct {
+	ID        uuid.UUID `json:"id"`
+	OrgID     uuid.UUID `json:"org_id"`
+	UserAgent string    `json:"user_agent"`
+	IPAddress string    `json:"ip_address"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type AdminLockUserRequest struct {
+	Until time.Time `json:"until" binding:"required"`
+}
+
 type UserResponse struct {
 	ID       uuid.UUID `json:"id"`
 	Email    string    `json:"email"`
 	FullName string    `json:"full_name"`
+	Locale   string    `json:"locale"`
+	Timezone string    `json:"timezone"`
 	IsActive bool      `json:"is_active"`
 }
 
+type DataExportResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	Status      string     `json:"status"`
+	SizeBytes   int64      `json:"size_bytes,omitempty"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type UpdateProfileRequest struct {
+	FullName *string `json:"full_name"`
+	Locale   *string `json:"locale"`
+	Timezone *string `json:"timezone"`
+}
+
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
@@ -7,6 +7,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/response"
 	"github.com/ZenoN-Cloud/zeno-auth/internal/service"
 )
 
@@ -56,12 +59,47 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, UserResponse{
+	c.JSON(http.StatusOK, userResponse(user))
+}
+
+// UpdateProfile changes the name, locale and timezone of the current user.
+// Omitted fields are kept.
+func (h *UserHandler) UpdateProfile(c *gin.Context) {
+	uid, ok := currentUserID(c)
+	if !ok {
+		response.Unauthorized(c, "Unauthorized")
+		return
+	}
+
+	var req UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request data")
+		return
+	}
+
+	user, err := h.userService.UpdateProfile(c.Request.Context(), uid, service.ProfileUpdate{
+		FullName: req.FullName,
+		Locale:   req.Locale,
+		Timezone: req.Timezone,
+	}, c.ClientIP(), c.GetHeader("User-Agent"))
+	if err != nil {
+		httpErr := errors.MapErrorToHTTP(err)
+		response.Error(c, httpErr.StatusCode, httpErr.Code, httpErr.Message)
+		return
+	}
+
+	response.Success(c, http.StatusOK, userResponse(user))
+}
+
+func userResponse(user *model.User) UserResponse {
+	return UserResponse{
 		ID:       user.ID,
 		Email:    user.Email,
 		FullName: user.FullName,
+		Locale:   user.Locale,
+		Timezone: user.Timezone,
 		IsActive: user.IsActive,
-	})
+	}
 }
 
 type ChangePasswordRequest struct {
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountDeletionRequest schedules the purge of an account. Until PurgeAfter
+// the user can cancel it with the link sent by email. RestoreActive is the
+// state the account returns to if the request is cancelled.
+type AccountDeletionRequest struct {
+	UserID        uuid.UUID `json:"user_id"`
+	TokenHash     string    `json:"-"`
+	PurgeAfter    time.Time `json:"purge_after"`
+	RestoreActive bool      `json:"-"`
+	CreatedAt     time.Time `json:"created_at"`
+}
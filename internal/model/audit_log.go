@@ -9,16 +9,65 @@ import (
 type AuditEventType string
 
 const (
-	EventUserRegistered  AuditEventType = "user_registered"
-	EventUserLoggedIn    AuditEventType = "user_logged_in"
-	EventUserLoggedOut   AuditEventType = "user_logged_out"
-	EventLoginFailed     AuditEventType = "login_failed"
-	EventPasswordChanged AuditEventType = "password_changed"
-	EventEmailChanged    AuditEventType = "email_changed"
-	EventAccountDeleted  AuditEventType = "account_deleted"
-	EventDataExported    AuditEventType = "data_exported"
-	EventConsentGranted  AuditEventType = "consent_granted"
-	EventConsentRevoked  AuditEventType = "consent_revoked"
+	EventUserRegistered              AuditEventType = "user_registered"
+	EventUserLoggedIn                AuditEventType = "user_logged_in"
+	EventUserLoggedOut               AuditEventType = "user_logged_out"
+	EventLoginFailed                 AuditEventType = "login_failed"
+	EventPasswordChanged             AuditEventType = "password_changed"
+	EventEmailChangeRequested        AuditEventType = "email_change_requested"
+	EventEmailChanged                AuditEventType = "email_changed"
+	EventProfileUpdated              AuditEventType = "profile_updated"
+	EventAccountDeletionRequested    AuditEventType = "account_deletion_requested"
+	EventAccountDeletionCancelled    AuditEventType = "account_deletion_cancelled"
+	EventAccountDeleted              AuditEventType = "account_deleted"
+	EventDataExportRequested         AuditEventType = "data_export_requested"
+	EventDataExported                AuditEventType = "data_exported"
+	EventDataExportDownloaded        AuditEventType = "data_export_downloaded"
+	EventConsentGranted              AuditEventType = "consent_granted"
+	EventConsentRevoked              AuditEventType = "consent_revoked"
+	EventMFAEnabled                  AuditEventType = "mfa_enabled"
+	EventMFADisabled                 AuditEventType = "mfa_disabled"
+	EventMFARegenerated              AuditEventType = "mfa_regenerated"
+	EventMFAFailed                   AuditEventType = "mfa_verification_failed"
+	EventMFARecoveryCodeUsed         AuditEventType = "mfa_recovery_code_used"
+	EventMFARecoveryCodesRegenerated AuditEventType = "mfa_recovery_codes_regenerated"
+	EventPasskeyRegistered           AuditEventType = "passkey_registered"
+	EventPasskeyRemoved              AuditEventType = "passkey_removed"
+	EventRefreshTokenReuse           AuditEventType = "refresh_token_reuse_detected"
+	EventOAuthAuthorized             AuditEventType = "oauth_authorized"
+	EventIdentityLinked              AuditEventType = "identity_linked"
+	EventIdentityUnlinked            AuditEventType = "identity_unlinked"
+	EventSAMLConfigured              AuditEventType = "saml_configured"
+	EventSAMLRemoved                 AuditEventType = "saml_removed"
+	EventDomainClaimed               AuditEventType = "domain_claimed"
+	EventDomainVerified              AuditEventType = "domain_verified"
+	EventDomainRemoved               AuditEventType = "domain_removed"
+	EventSSOEnforcementChanged       AuditEventType = "sso_enforcement_changed"
+	EventMemberAdded                 AuditEventType = "member_added"
+	EventMemberUpdated               AuditEventType = "member_updated"
+	EventMemberDeactivated           AuditEventType = "member_deactivated"
+	EventMemberRemoved               AuditEventType = "member_removed"
+	EventOwnershipTransferred        AuditEventType = "ownership_transferred"
+	EventOrganizationSwitched        AuditEventType = "organization_switched"
+	EventRoleCreated                 AuditEventType = "role_created"
+	EventRoleUpdated                 AuditEventType = "role_updated"
+	EventRoleDeleted                 AuditEventType = "role_deleted"
+	EventRoleAssigned                AuditEventType = "role_assigned"
+	EventSCIMTokenCreated            AuditEventType = "scim_token_created"
+	EventSCIMTokenRevoked            AuditEventType = "scim_token_revoked"
+	EventInvitationCreated           AuditEventType = "invitation_created"
+	EventInvitationRevoked           AuditEventType = "invitation_revoked"
+	EventInvitationAccepted          AuditEventType = "invitation_accepted"
+	EventAdminUsersSearched          AuditEventType = "admin_users_searched"
+	EventAdminUserViewed             AuditEventType = "admin_user_viewed"
+	EventAdminUserLocked             AuditEventType = "admin_user_locked"
+	EventAdminUserUnlocked           AuditEventType = "admin_user_unlocked"
+	EventAdminPasswordResetForced    AuditEventType = "admin_password_reset_forced"
+	EventAdminSessionsRevoked        AuditEventType = "admin_sessions_revoked"
+	EventAdminUserDeactivated        AuditEventType = "admin_user_deactivated"
+	EventAdminUserRestored           AuditEventType = "admin_user_restored"
+	EventMagicLinkRequested          AuditEventType = "magic_link_requested"
+	EventMagicLinkSettingChanged     AuditEventType = "magic_link_setting_changed"
 )
 
 type AuditLog struct {
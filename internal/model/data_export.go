@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type DataExportStatus string
+
+const (
+	DataExportPending    DataExportStatus = "pending"
+	DataExportProcessing DataExportStatus = "processing"
+	DataExportCompleted  DataExportStatus = "completed"
+	DataExportFailed     DataExportStatus = "failed"
+)
+
+// DataExport is a background export of a user's personal data. Once
+// completed, the archive is kept in the export storage under StorageKey and
+// can be downloaded with the emailed link until ExpiresAt.
+type DataExport struct {
+	ID          uuid.UUID        `json:"id"`
+	UserID      uuid.UUID        `json:"user_id"`
+	Status      DataExportStatus `json:"status"`
+	StorageKey  string           `json:"-"`
+	SizeBytes   int64            `json:"size_bytes,omitempty"`
+	TokenHash   string           `json:"-"`
+	ExpiresAt   time.Time        `json:"expires_at"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
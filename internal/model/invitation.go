@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrgInvitation invites an email address to join an organization with a
+// role. Only the hash of the emailed token is stored.
+type OrgInvitation struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	OrgID      uuid.UUID  `json:"org_id" db:"org_id"`
+	Email      string     `json:"email" db:"email"`
+	Role       Role       `json:"role" db:"role"`
+	TokenHash  string     `json:"-" db:"token_hash"`
+	InvitedBy  *uuid.UUID `json:"invited_by,omitempty" db:"invited_by"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty" db:"accepted_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// IsExpired reports whether the invitation can no longer be accepted.
+func (i *OrgInvitation) IsExpired() bool {
+	return time.Now().After(i.ExpiresAt)
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,10 +17,33 @@ const (
 )
 
 type OrgMembership struct {
-	ID        uuid.UUID `json:"id" db:"id"`
-	UserID    uuid.UUID `json:"user_id" db:"user_id"`
-	OrgID     uuid.UUID `json:"org_id" db:"org_id"`
-	Role      Role      `json:"role" db:"role"`
-	IsActive  bool      `json:"is_active" db:"is_active"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ID         uuid.UUID `json:"id" db:"id"`
+	UserID     uuid.UUID `json:"user_id" db:"user_id"`
+	OrgID      uuid.UUID `json:"org_id" db:"org_id"`
+	Role       Role      `json:"role" db:"role"`
+	IsActive   bool      `json:"is_active" db:"is_active"`
+	ExternalID *string   `json:"external_id,omitempty" db:"external_id"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	// CustomRoleID is the custom role assigned to the member, whose
+	// permissions are loaded into CustomPermissions.
+	CustomRoleID      *uuid.UUID   `json:"custom_role_id,omitempty" db:"custom_role_id"`
+	CustomPermissions []Permission `json:"-" db:"-"`
+}
+
+// Permissions returns the permissions of the built-in role together with
+// those of the custom role, sorted.
+func (m *OrgMembership) Permissions() []Permission {
+	permissions := append(m.Role.Permissions(), m.CustomPermissions...)
+	slices.Sort(permissions)
+	return slices.Compact(permissions)
+}
+
+func (m *OrgMembership) HasPermission(permission Permission) bool {
+	return slices.Contains(m.Permissions(), permission)
+}
+
+// OrgMember is a membership together with the member's account.
+type OrgMember struct {
+	Membership OrgMembership
+	User       User
 }
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserMFA holds the TOTP configuration of a user. PendingSecret is set during
+// enrollment or regeneration and only becomes Secret once confirmed with a code.
+type UserMFA struct {
+	UserID        uuid.UUID  `json:"user_id" db:"user_id"`
+	Secret        string     `json:"-" db:"secret" log:"-"`
+	PendingSecret string     `json:"-" db:"pending_secret" log:"-"`
+	EnabledAt     *time.Time `json:"enabled_at,omitempty" db:"enabled_at"`
+	LastUsedStep  int64      `json:"-" db:"last_used_step"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+func (m *UserMFA) IsEnabled() bool {
+	return m != nil && m.EnabledAt != nil && m.Secret != ""
+}
+
+// MFAChallenge is issued after a successful password check for users with MFA
+// enabled and must be completed with a valid code before tokens are issued.
+type MFAChallenge struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	OrgID     *uuid.UUID `json:"org_id,omitempty" db:"org_id"`
+	TokenHash string     `json:"-" db:"token_hash"`
+	Attempts  int        `json:"-" db:"attempts"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// MFARecoveryCode is a single-use fallback for the TOTP second factor. Only the
+// argon2 hash is stored; the plain code is shown to the user once.
+type MFARecoveryCode struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	CodeHash  string     `json:"-" db:"code_hash" log:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OAuthClient is an application registered to sign users in through the
+// OpenID Connect provider, or a backend service obtaining tokens for the
+// internal API with the client_credentials grant. Clients without a secret
+// are public and can only authenticate with PKCE.
+type OAuthClient struct {
+	ID               uuid.UUID `json:"id" db:"id"`
+	ClientID         string    `json:"client_id" db:"client_id"`
+	ClientSecretHash *string   `json:"-" db:"client_secret_hash"`
+	Name             string    `json:"name" db:"name"`
+	RedirectURIs     []string  `json:"redirect_uris" db:"redirect_uris"`
+	Scopes           []string  `json:"scopes" db:"scopes"`
+	GrantTypes       []string  `json:"grant_types" db:"grant_types"`
+	IsActive         bool      `json:"is_active" db:"is_active"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+func (c *OAuthClient) IsPublic() bool {
+	return c.ClientSecretHash == nil || *c.ClientSecretHash == ""
+}
+
+// HasRedirectURI compares redirect URIs exactly, as required by OAuth 2.1.
+func (c *OAuthClient) HasRedirectURI(uri string) bool {
+	return slices.Contains(c.RedirectURIs, uri)
+}
+
+func (c *OAuthClient) AllowsScope(scope string) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+func (c *OAuthClient) AllowsGrant(grantType string) bool {
+	return slices.Contains(c.GrantTypes, grantType)
+}
+
+// OAuthAuthorizationCode is a pending authorization code grant. Only the hash
+// of the code is stored.
+type OAuthAuthorizationCode struct {
+	ID                  uuid.UUID  `json:"id" db:"id"`
+	CodeHash            string     `json:"-" db:"code_hash"`
+	ClientID            string     `json:"client_id" db:"client_id"`
+	UserID              uuid.UUID  `json:"user_id" db:"user_id"`
+	OrgID               *uuid.UUID `json:"org_id,omitempty" db:"org_id"`
+	RedirectURI         string     `json:"redirect_uri" db:"redirect_uri"`
+	Scope               string     `json:"scope" db:"scope"`
+	Nonce               *string    `json:"-" db:"nonce"`
+	CodeChallenge       string     `json:"-" db:"code_challenge"`
+	CodeChallengeMethod string     `json:"-" db:"code_challenge_method"`
+	ExpiresAt           time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt              *time.Time `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrganizationDomain is an email domain claimed by an organization. The claim
+// is proven by publishing VerificationToken in DNS. With SSOEnforced set, users
+// with an email at the domain must sign in through the organization's SAML
+// connection.
+type OrganizationDomain struct {
+	ID                uuid.UUID  `json:"id" db:"id"`
+	OrgID             uuid.UUID  `json:"org_id" db:"org_id"`
+	Domain            string     `json:"domain" db:"domain"`
+	VerificationToken string     `json:"-" db:"verification_token"`
+	VerifiedAt        *time.Time `json:"verified_at,omitempty" db:"verified_at"`
+	SSOEnforced       bool       `json:"sso_enforced" db:"sso_enforced"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// IsVerified reports whether the organization proved ownership of the domain.
+func (d *OrganizationDomain) IsVerified() bool {
+	return d.VerifiedAt != nil
+}
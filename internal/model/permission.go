@@ -0,0 +1,82 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Permission is a named capability within an organization, carried in the
+// permissions claim of access tokens.
+type Permission string
+
+const (
+	PermOrgRead        Permission = "org:read"
+	PermOrgManage      Permission = "org:manage"
+	PermMembersRead    Permission = "members:read"
+	PermMembersInvite  Permission = "members:invite"
+	PermMembersManage  Permission = "members:manage"
+	PermRolesManage    Permission = "roles:manage"
+	PermBillingRead    Permission = "billing:read"
+	PermBillingManage  Permission = "billing:manage"
+	PermAuditRead      Permission = "audit:read"
+	PermDocumentsRead  Permission = "documents:read"
+	PermDocumentsWrite Permission = "documents:write"
+)
+
+// Permissions lists every known permission.
+var Permissions = []Permission{
+	PermOrgRead, PermOrgManage,
+	PermMembersRead, PermMembersInvite, PermMembersManage,
+	PermRolesManage,
+	PermBillingRead, PermBillingManage,
+	PermAuditRead,
+	PermDocumentsRead, PermDocumentsWrite,
+}
+
+// administrativePermissions are only held through the built-in roles, as
+// the member, organization and role management of zeno-auth is tied to them.
+var administrativePermissions = []Permission{PermOrgManage, PermMembersManage, PermRolesManage}
+
+var rolePermissions = map[Role][]Permission{
+	RoleOwner: Permissions,
+	RoleAdmin: {
+		PermOrgRead, PermOrgManage,
+		PermMembersRead, PermMembersInvite, PermMembersManage,
+		PermRolesManage,
+		PermBillingRead,
+		PermAuditRead,
+		PermDocumentsRead, PermDocumentsWrite,
+	},
+	RoleMember: {PermOrgRead, PermMembersRead, PermDocumentsRead, PermDocumentsWrite},
+	RoleViewer: {PermOrgRead, PermMembersRead, PermDocumentsRead},
+}
+
+func (p Permission) IsValid() bool {
+	return slices.Contains(Permissions, p)
+}
+
+// Grantable reports whether a custom role may include the permission. The
+// user granting it must also hold it.
+func (p Permission) Grantable() bool {
+	return p.IsValid() && !slices.Contains(administrativePermissions, p)
+}
+
+// Permissions returns the permissions of the built-in role.
+func (r Role) Permissions() []Permission {
+	return slices.Clone(rolePermissions[r])
+}
+
+// OrgRole is a custom role defined by an organization. It grants its
+// permissions to the members it is assigned to, in addition to those of
+// their built-in role.
+type OrgRole struct {
+	ID          uuid.UUID    `json:"id" db:"id"`
+	OrgID       uuid.UUID    `json:"org_id" db:"org_id"`
+	Name        string       `json:"name" db:"name"`
+	Description string       `json:"description" db:"description"`
+	Permissions []Permission `json:"permissions" db:"permissions"`
+	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SAMLConnection is the SAML identity provider an organization signs in with.
+// EmailAttribute and NameAttribute override the attributes the user's email
+// and name are read from.
+type SAMLConnection struct {
+	ID              uuid.UUID `json:"id" db:"id"`
+	OrgID           uuid.UUID `json:"org_id" db:"org_id"`
+	IdPEntityID     string    `json:"idp_entity_id" db:"idp_entity_id"`
+	IdPSSOURL       string    `json:"idp_sso_url" db:"idp_sso_url"`
+	IdPCertificates []string  `json:"-" db:"idp_certificates"`
+	MetadataXML     string    `json:"-" db:"metadata_xml"`
+	EmailAttribute  *string   `json:"email_attribute,omitempty" db:"email_attribute"`
+	NameAttribute   *string   `json:"name_attribute,omitempty" db:"name_attribute"`
+	DefaultRole     Role      `json:"default_role" db:"default_role"`
+	Enabled         bool      `json:"enabled" db:"enabled"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SAMLRequest is an AuthnRequest sent to an organization's IdP.
+type SAMLRequest struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	RequestID string     `json:"request_id" db:"request_id"`
+	OrgID     uuid.UUID  `json:"org_id" db:"org_id"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// SAMLLoginCode is a one-time code for a user the IdP authenticated. Only the
+// hash of the code is stored.
+type SAMLLoginCode struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	CodeHash  string     `json:"-" db:"code_hash"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	OrgID     uuid.UUID  `json:"org_id" db:"org_id"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SCIMToken is a bearer token an identity provider uses to provision the
+// members of an organization over SCIM. Only its hash is stored.
+type SCIMToken struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	OrgID       uuid.UUID  `json:"org_id" db:"org_id"`
+	TokenHash   string     `json:"-" db:"token_hash"`
+	Description string     `json:"description" db:"description"`
+	CreatedBy   *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
@@ -22,11 +22,20 @@ type RefreshToken struct {
 	UserAgent       string     `json:"user_agent" db:"user_agent"`
 	IPAddress       string     `json:"ip_address" db:"ip_address"`
 	FingerprintHash *string    `json:"-" db:"fingerprint_hash"`
+	FamilyID        uuid.UUID  `json:"-" db:"family_id"`
+	ReplacedByID    *uuid.UUID `json:"-" db:"replaced_by_id"`
+	AMR             []string   `json:"-" db:"amr"`
 	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
 	ExpiresAt       time.Time  `json:"expires_at" db:"expires_at"`
 	RevokedAt       *time.Time `json:"revoked_at" db:"revoked_at"`
 }
 
+// IsRotated reports whether the token was already exchanged for a newer one.
+// Presenting a rotated token again indicates it was stolen.
+func (rt *RefreshToken) IsRotated() bool {
+	return rt.ReplacedByID != nil
+}
+
 func (rt *RefreshToken) Validate() error {
 	if rt.UserID == uuid.Nil {
 		return ErrInvalidUserID
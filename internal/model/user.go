@@ -6,12 +6,21 @@ import (
 	"github.com/google/uuid"
 )
 
+// Preferences of users who have not chosen any.
+const (
+	DefaultLocale   = "en"
+	DefaultTimezone = "UTC"
+)
+
 type User struct {
 	ID                  uuid.UUID  `json:"id" db:"id"`
 	Email               string     `json:"email" db:"email"`
 	PasswordHash        string     `json:"-" db:"password_hash" log:"-"`
 	FullName            string     `json:"full_name" db:"full_name"`
+	Locale              string     `json:"locale" db:"locale"`
+	Timezone            string     `json:"timezone" db:"timezone"`
 	IsActive            bool       `json:"is_active" db:"is_active"`
+	IsPlatformAdmin     bool       `json:"is_platform_admin" db:"is_platform_admin"`
 	FailedLoginAttempts int        `json:"-" db:"failed_login_attempts"`
 	LockedUntil         *time.Time `json:"-" db:"locked_until"`
 	DeletedAt           *time.Time `json:"-" db:"deleted_at"`
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserIdentity links a user to an account at an upstream identity provider.
+type UserIdentity struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	UserID      uuid.UUID  `json:"user_id" db:"user_id"`
+	Provider    string     `json:"provider" db:"provider"`
+	Subject     string     `json:"-" db:"subject"`
+	Email       *string    `json:"email,omitempty" db:"email"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty" db:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// SocialAuthState is a pending login at an upstream identity provider. Only
+// the hash of the state is stored.
+type SocialAuthState struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	StateHash    string     `json:"-" db:"state_hash"`
+	Provider     string     `json:"provider" db:"provider"`
+	CodeVerifier string     `json:"-" db:"code_verifier"`
+	ExpiresAt    time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt       *time.Time `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+}
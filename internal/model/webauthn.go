@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WebAuthnCeremony string
+
+const (
+	WebAuthnCeremonyRegistration WebAuthnCeremony = "registration"
+	WebAuthnCeremonyLogin        WebAuthnCeremony = "login"
+)
+
+// WebAuthnCredential is a passkey registered by a user. PublicKey holds the
+// COSE_Key exactly as returned by the authenticator.
+type WebAuthnCredential struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	UserID       uuid.UUID  `json:"user_id" db:"user_id"`
+	CredentialID []byte     `json:"-" db:"credential_id"`
+	PublicKey    []byte     `json:"-" db:"public_key"`
+	Algorithm    int64      `json:"-" db:"algorithm"`
+	SignCount    int64      `json:"-" db:"sign_count"`
+	AAGUID       []byte     `json:"-" db:"aaguid"`
+	Transports   []string   `json:"transports" db:"transports"`
+	Name         string     `json:"name" db:"name"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+}
+
+// WebAuthnChallenge is a pending passkey ceremony. Registration challenges are
+// bound to a user; login challenges are not since passkeys are discoverable.
+type WebAuthnChallenge struct {
+	ID            uuid.UUID        `json:"id" db:"id"`
+	UserID        *uuid.UUID       `json:"user_id,omitempty" db:"user_id"`
+	ChallengeHash string           `json:"-" db:"challenge_hash"`
+	Ceremony      WebAuthnCeremony `json:"ceremony" db:"ceremony"`
+	ExpiresAt     time.Time        `json:"expires_at" db:"expires_at"`
+	UsedAt        *time.Time       `json:"used_at,omitempty" db:"used_at"`
+	CreatedAt     time.Time        `json:"created_at" db:"created_at"`
+}
@@ -43,6 +43,8 @@ type RefreshTokenRepository interface {
 	RevokeByUserID(ctx context.Context, userID uuid.UUID) error
 	RevokeByUserIDTx(ctx context.Context, tx pgx.Tx, userID uuid.UUID) error
 	RevokeByID(ctx context.Context, id uuid.UUID) error
+	Rotate(ctx context.Context, oldID uuid.UUID, next *model.RefreshToken) (bool, error)
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
 	DeleteExpired(ctx context.Context) error
 }
 
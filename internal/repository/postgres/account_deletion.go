@@ -0,0 +1,204 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+type AccountDeletionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAccountDeletionRepository(db *pgxpool.Pool) *AccountDeletionRepository {
+	return &AccountDeletionRepository{db: db}
+}
+
+// Schedule stores the request and deactivates the account, marking it as
+// deleted. The request remembers whether the account was active, so that
+// cancelling restores that state. A new request for the same user replaces
+// the previous one but keeps the state from before the first.
+func (r *AccountDeletionRepository) Schedule(ctx context.Context, request *model.AccountDeletionRequest) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	query := `
+		INSERT INTO account_deletion_requests (user_id, token_hash, purge_after, restore_active)
+		SELECT $1, $2, $3, is_active FROM users WHERE id = $1
+		ON CONFLICT (user_id) DO UPDATE SET token_hash = EXCLUDED.token_hash, purge_after = EXCLUDED.purge_after, created_at = NOW()
+		RETURNING restore_active, created_at`
+	err = tx.QueryRow(ctx, query, request.UserID, request.TokenHash, request.PurgeAfter).Scan(&request.RestoreActive, &request.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	userQuery := `UPDATE users SET is_active = FALSE, deleted_at = NOW(), updated_at = NOW() WHERE id = $1`
+	if _, err := tx.Exec(ctx, userQuery, request.UserID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Cancel removes the request with the hash and restores the account to the
+// state it had before the request. It returns nil if there is no such request
+// or its grace period has ended.
+func (r *AccountDeletionRepository) Cancel(ctx context.Context, tokenHash string) (*model.AccountDeletionRequest, error) {
+	query := `
+		DELETE FROM account_deletion_requests
+		WHERE token_hash = $1 AND purge_after > NOW()
+		RETURNING user_id, token_hash, purge_after, restore_active, created_at`
+	return r.cancel(ctx, query, tokenHash)
+}
+
+// CancelByUserID removes the user's request regardless of its grace period
+// and restores the account like Cancel. It returns false if the user has no
+// pending request.
+func (r *AccountDeletionRepository) CancelByUserID(ctx context.Context, userID uuid.UUID) (bool, error) {
+	query := `
+		DELETE FROM account_deletion_requests
+		WHERE user_id = $1
+		RETURNING user_id, token_hash, purge_after, restore_active, created_at`
+	request, err := r.cancel(ctx, query, userID)
+	return request != nil, err
+}
+
+func (r *AccountDeletionRepository) cancel(ctx context.Context, query string, arg interface{}) (*model.AccountDeletionRequest, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var request model.AccountDeletionRequest
+	err = tx.QueryRow(ctx, query, arg).Scan(&request.UserID, &request.TokenHash, &request.PurgeAfter, &request.RestoreActive, &request.CreatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	userQuery := `UPDATE users SET is_active = $2, deleted_at = NULL, updated_at = NOW() WHERE id = $1`
+	if _, err := tx.Exec(ctx, userQuery, request.UserID, request.RestoreActive); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// KeepDeactivated makes cancelling the user's pending request, if any, leave
+// the account deactivated.
+func (r *AccountDeletionRepository) KeepDeactivated(ctx context.Context, userID uuid.UUID) error {
+	query := `UPDATE account_deletion_requests SET restore_active = FALSE WHERE user_id = $1`
+	_, err := r.db.Exec(ctx, query, userID)
+	return err
+}
+
+// OwnsSharedOrganization reports whether the user owns an organization that
+// has other active members. Purging the user would delete it.
+func (r *AccountDeletionRepository) OwnsSharedOrganization(ctx context.Context, userID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM organizations o
+			JOIN org_memberships m ON m.org_id = o.id
+			WHERE o.owner_user_id = $1 AND m.user_id <> $1 AND m.is_active
+		)`
+
+	var owns bool
+	err := r.db.QueryRow(ctx, query, userID).Scan(&owns)
+	return owns, err
+}
+
+// ListDue returns the users whose grace period ended before now.
+func (r *AccountDeletionRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error) {
+	query := `SELECT user_id FROM account_deletion_requests WHERE purge_after <= $1 ORDER BY purge_after LIMIT $2`
+
+	rows, err := r.db.Query(ctx, query, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []uuid.UUID
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+// Purge deletes the user with their memberships, consents and the
+// organizations they own, and expires their data exports so that cleanup
+// deletes the archives. Their audit logs keep only the event type and time;
+// the user's email is redacted from the events of others. Everything else
+// that belongs to the user is removed by ON DELETE CASCADE. It returns false
+// without deleting anything if the user owns an organization that has other
+// active members, since deleting the user would delete that organization.
+func (r *AccountDeletionRepository) Purge(ctx context.Context, userID uuid.UUID) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var shared bool
+	sharedQuery := `
+		SELECT EXISTS (
+			SELECT 1 FROM organizations o
+			JOIN org_memberships m ON m.org_id = o.id
+			WHERE o.owner_user_id = $1 AND m.user_id <> $1 AND m.is_active
+		)`
+	if err := tx.QueryRow(ctx, sharedQuery, userID).Scan(&shared); err != nil {
+		return false, err
+	}
+	if shared {
+		return false, nil
+	}
+
+	// Other users' events, such as invitations, may name the user's email
+	// under one of these keys; only those values are redacted so the events
+	// stay readable. The containment filters use idx_audit_logs_event_data.
+	var email string
+	if err := tx.QueryRow(ctx, `SELECT email FROM users WHERE id = $1`, userID).Scan(&email); err != nil {
+		return false, err
+	}
+	redactQuery := `
+		UPDATE audit_logs a SET event_data = (
+			SELECT jsonb_object_agg(e.key, CASE WHEN e.value = to_jsonb($1::text) THEN to_jsonb('[deleted]'::text) ELSE e.value END)
+			FROM jsonb_each(a.event_data) e
+		)
+		WHERE a.event_data @> jsonb_build_object('email', $1::text)
+		   OR a.event_data @> jsonb_build_object('old_email', $1::text)
+		   OR a.event_data @> jsonb_build_object('new_email', $1::text)`
+	if _, err := tx.Exec(ctx, redactQuery, email); err != nil {
+		return false, err
+	}
+
+	statements := []string{
+		`DELETE FROM org_memberships WHERE user_id = $1`,
+		`DELETE FROM user_consents WHERE user_id = $1`,
+		`UPDATE audit_logs SET user_id = NULL, event_data = NULL, ip_address = NULL, user_agent = NULL WHERE user_id = $1`,
+		`UPDATE data_exports SET user_id = NULL, token_hash = NULL, expires_at = NOW() WHERE user_id = $1`,
+		`DELETE FROM organizations WHERE owner_user_id = $1`,
+		`DELETE FROM users WHERE id = $1`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(ctx, statement, userID); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit(ctx)
+}
@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+func TestAccountDeletionRepository_PurgeAnonymizesAuditLogs(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewAccountDeletionRepository(db.Pool())
+
+	user := &model.User{Email: fmt.Sprintf("purged-%d@example.com", time.Now().UnixNano()), FullName: "Purged", IsActive: true}
+	require.NoError(t, NewUserRepo(db).Create(ctx, user))
+
+	insert := `INSERT INTO audit_logs (user_id, event_type, event_data, ip_address, user_agent) VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	var own, other uuid.UUID
+	require.NoError(t, db.Pool().QueryRow(ctx, insert, user.ID, "email_changed",
+		map[string]interface{}{"new_email": user.Email}, "192.0.2.1", "Firefox").Scan(&own))
+	require.NoError(t, db.Pool().QueryRow(ctx, insert, nil, "invitation_sent",
+		map[string]interface{}{"email": user.Email, "role": "MEMBER"}, "192.0.2.2", "Chrome").Scan(&other))
+	t.Cleanup(func() {
+		_, _ = db.Pool().Exec(ctx, `DELETE FROM audit_logs WHERE id = ANY($1)`, []uuid.UUID{own, other})
+	})
+
+	purged, err := repo.Purge(ctx, user.ID)
+	require.NoError(t, err)
+	require.True(t, purged)
+
+	var userID *uuid.UUID
+	var eventData map[string]interface{}
+	var ipAddress, userAgent *string
+	query := `SELECT user_id, event_data, ip_address, user_agent FROM audit_logs WHERE id = $1`
+	require.NoError(t, db.Pool().QueryRow(ctx, query, own).Scan(&userID, &eventData, &ipAddress, &userAgent))
+	assert.Nil(t, userID)
+	assert.Nil(t, eventData)
+	assert.Nil(t, ipAddress)
+	assert.Nil(t, userAgent)
+
+	// Other users' events keep everything but the email
+	require.NoError(t, db.Pool().QueryRow(ctx, query, other).Scan(&userID, &eventData, &ipAddress, &userAgent))
+	assert.Equal(t, map[string]interface{}{"email": "[deleted]", "role": "MEMBER"}, eventData)
+	require.NotNil(t, ipAddress)
+	assert.Equal(t, "192.0.2.2", *ipAddress)
+}
@@ -74,6 +74,43 @@ func (r *AuditLogRepository) GetByUserID(ctx context.Context, userID uuid.UUID,
 	return logs, rows.Err()
 }
 
+// ForEachByUserID calls fn for every entry of the user, oldest first, without
+// loading them all into memory. It stops at the first error fn returns.
+func (r *AuditLogRepository) ForEachByUserID(ctx context.Context, userID uuid.UUID, fn func(*model.AuditLog) error) error {
+	query := `
+		SELECT id, user_id, event_type, event_data, ip_address, user_agent, created_at
+		FROM audit_logs
+		WHERE user_id = $1
+		ORDER BY created_at, id`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var log model.AuditLog
+		var eventDataJSON []byte
+
+		if err := rows.Scan(&log.ID, &log.UserID, &log.EventType, &eventDataJSON, &log.IPAddress, &log.UserAgent, &log.CreatedAt); err != nil {
+			return err
+		}
+
+		if len(eventDataJSON) > 0 {
+			if err := json.Unmarshal(eventDataJSON, &log.EventData); err != nil {
+				return err
+			}
+		}
+
+		if err := fn(&log); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
 func (r *AuditLogRepository) DeleteOlderThan(ctx context.Context, date time.Time) error {
 	query := `DELETE FROM audit_logs WHERE created_at < $1`
 	_, err := r.db.Exec(ctx, query, date)
@@ -85,3 +122,22 @@ func (r *AuditLogRepository) AnonymizeByUserID(ctx context.Context, userID uuid.
 	_, err := r.db.Exec(ctx, query, userID)
 	return err
 }
+
+// Count returns the number of entries created in [from, to).
+func (r *AuditLogRepository) Count(ctx context.Context, from, to time.Time) (int64, error) {
+	query := `SELECT COUNT(*) FROM audit_logs WHERE created_at >= $1 AND created_at < $2`
+
+	var count int64
+	err := r.db.QueryRow(ctx, query, from, to).Scan(&count)
+	return count, err
+}
+
+// CountByEventType returns the number of entries of the event type created
+// in [from, to).
+func (r *AuditLogRepository) CountByEventType(ctx context.Context, eventType model.AuditEventType, from, to time.Time) (int64, error) {
+	query := `SELECT COUNT(*) FROM audit_logs WHERE event_type = $1 AND created_at >= $2 AND created_at < $3`
+
+	var count int64
+	err := r.db.QueryRow(ctx, query, eventType, from, to).Scan(&count)
+	return count, err
+}
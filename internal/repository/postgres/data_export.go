@@ -0,0 +1,147 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+type DataExportRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewDataExportRepository(db *pgxpool.Pool) *DataExportRepository {
+	return &DataExportRepository{db: db}
+}
+
+// Exports of purged accounts have no user_id and are only read by cleanup.
+const dataExportColumns = `id, COALESCE(user_id, '00000000-0000-0000-0000-000000000000'), status, COALESCE(storage_key, ''), COALESCE(size_bytes, 0), COALESCE(token_hash, ''), expires_at, completed_at, created_at`
+
+func scanDataExport(row pgx.Row) (*model.DataExport, error) {
+	var export model.DataExport
+	err := row.Scan(
+		&export.ID,
+		&export.UserID,
+		&export.Status,
+		&export.StorageKey,
+		&export.SizeBytes,
+		&export.TokenHash,
+		&export.ExpiresAt,
+		&export.CompletedAt,
+		&export.CreatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *DataExportRepository) Create(ctx context.Context, export *model.DataExport) error {
+	query := `
+		INSERT INTO data_exports (user_id, status, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at`
+	return r.db.QueryRow(ctx, query, export.UserID, export.Status, export.ExpiresAt).
+		Scan(&export.ID, &export.CreatedAt)
+}
+
+func (r *DataExportRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.DataExport, error) {
+	query := `SELECT ` + dataExportColumns + ` FROM data_exports WHERE id = $1`
+	return scanDataExport(r.db.QueryRow(ctx, query, id))
+}
+
+// GetActiveByUserID returns the user's latest export created after since that
+// is still pending or processing, or nil if there is none.
+func (r *DataExportRepository) GetActiveByUserID(ctx context.Context, userID uuid.UUID, since time.Time) (*model.DataExport, error) {
+	query := `
+		SELECT ` + dataExportColumns + `
+		FROM data_exports
+		WHERE user_id = $1 AND status IN ('pending', 'processing') AND created_at > $2
+		ORDER BY created_at DESC
+		LIMIT 1`
+	return scanDataExport(r.db.QueryRow(ctx, query, userID, since))
+}
+
+// GetByTokenHash returns the completed export with the download token hash,
+// or nil if there is none or its link has expired.
+func (r *DataExportRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*model.DataExport, error) {
+	query := `
+		SELECT ` + dataExportColumns + `
+		FROM data_exports
+		WHERE token_hash = $1 AND status = 'completed' AND expires_at > NOW()`
+	return scanDataExport(r.db.QueryRow(ctx, query, tokenHash))
+}
+
+func (r *DataExportRepository) MarkProcessing(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE data_exports SET status = 'processing' WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+// Complete records the stored archive and its download token.
+func (r *DataExportRepository) Complete(ctx context.Context, export *model.DataExport) error {
+	query := `
+		UPDATE data_exports
+		SET status = 'completed', storage_key = $2, size_bytes = $3, token_hash = $4, expires_at = $5, completed_at = NOW()
+		WHERE id = $1
+		RETURNING status, completed_at`
+	return r.db.QueryRow(ctx, query, export.ID, export.StorageKey, export.SizeBytes, export.TokenHash, export.ExpiresAt).
+		Scan(&export.Status, &export.CompletedAt)
+}
+
+func (r *DataExportRepository) Fail(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE data_exports SET status = 'failed' WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+// FailStale marks exports still pending or processing that were created
+// before the time as failed, e.g. after the instance building them stopped.
+func (r *DataExportRepository) FailStale(ctx context.Context, before time.Time) (int64, error) {
+	query := `UPDATE data_exports SET status = 'failed' WHERE status IN ('pending', 'processing') AND created_at < $1`
+	result, err := r.db.Exec(ctx, query, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+// ListExpired returns exports whose link expired before now.
+func (r *DataExportRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]*model.DataExport, error) {
+	query := `
+		SELECT ` + dataExportColumns + `
+		FROM data_exports
+		WHERE expires_at <= $1
+		ORDER BY expires_at
+		LIMIT $2`
+
+	rows, err := r.db.Query(ctx, query, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var exports []*model.DataExport
+	for rows.Next() {
+		export, err := scanDataExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, export)
+	}
+	return exports, rows.Err()
+}
+
+func (r *DataExportRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM data_exports WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
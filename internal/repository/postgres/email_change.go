@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+type EmailChangeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewEmailChangeRepository(db *pgxpool.Pool) *EmailChangeRepository {
+	return &EmailChangeRepository{db: db}
+}
+
+// Create replaces the pending requests of the user, so only the most recently
+// requested address can be confirmed.
+func (r *EmailChangeRepository) Create(ctx context.Context, request *model.EmailChangeRequest) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `DELETE FROM email_change_requests WHERE user_id = $1 AND confirmed_at IS NULL`, request.UserID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO email_change_requests (user_id, new_email, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+	err = tx.QueryRow(ctx, query, request.UserID, request.NewEmail, request.TokenHash, request.ExpiresAt).Scan(&request.ID, &request.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Consume marks the request with the hash as confirmed and returns it. It
+// returns nil if there is no such request or it was already confirmed or has
+// expired, so a link can only be used once even by concurrent requests.
+func (r *EmailChangeRepository) Consume(ctx context.Context, tokenHash string) (*model.EmailChangeRequest, error) {
+	query := `
+		UPDATE email_change_requests SET confirmed_at = NOW()
+		WHERE token_hash = $1 AND confirmed_at IS NULL AND expires_at > NOW()
+		RETURNING id, user_id, new_email, token_hash, expires_at, confirmed_at, created_at`
+
+	var request model.EmailChangeRequest
+	err := r.db.QueryRow(ctx, query, tokenHash).Scan(
+		&request.ID, &request.UserID, &request.NewEmail, &request.TokenHash, &request.ExpiresAt, &request.ConfirmedAt, &request.CreatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+// GetByUserID returns the email change requests of the user, including
+// confirmed and expired ones.
+func (r *EmailChangeRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*model.EmailChangeRequest, error) {
+	query := `
+		SELECT id, user_id, new_email, token_hash, expires_at, confirmed_at, created_at
+		FROM email_change_requests
+		WHERE user_id = $1
+		ORDER BY created_at`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []*model.EmailChangeRequest
+	for rows.Next() {
+		var request model.EmailChangeRequest
+		err := rows.Scan(&request.ID, &request.UserID, &request.NewEmail, &request.TokenHash, &request.ExpiresAt, &request.ConfirmedAt, &request.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, &request)
+	}
+	return requests, rows.Err()
+}
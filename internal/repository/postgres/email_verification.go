@@ -46,6 +46,37 @@ func (r *EmailVerificationRepository) GetByTokenHash(ctx context.Context, tokenH
 	return &verification, nil
 }
 
+func (r *EmailVerificationRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*model.EmailVerification, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, verified_at, created_at
+		FROM email_verifications
+		WHERE user_id = $1
+		ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var verifications []*model.EmailVerification
+	for rows.Next() {
+		var verification model.EmailVerification
+		if err := rows.Scan(
+			&verification.ID,
+			&verification.UserID,
+			&verification.TokenHash,
+			&verification.ExpiresAt,
+			&verification.VerifiedAt,
+			&verification.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		verifications = append(verifications, &verification)
+	}
+	return verifications, rows.Err()
+}
+
 func (r *EmailVerificationRepository) MarkAsVerified(ctx context.Context, id uuid.UUID) error {
 	query := `UPDATE email_verifications SET verified_at = NOW() WHERE id = $1`
 	result, err := r.db.Exec(ctx, query, id)
@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+type OrgInvitationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOrgInvitationRepository(db *pgxpool.Pool) *OrgInvitationRepository {
+	return &OrgInvitationRepository{db: db}
+}
+
+const orgInvitationColumns = `id, org_id, email, role, token_hash, invited_by, expires_at, accepted_at, created_at`
+
+func scanOrgInvitation(row pgx.Row) (*model.OrgInvitation, error) {
+	var invitation model.OrgInvitation
+	err := row.Scan(
+		&invitation.ID,
+		&invitation.OrgID,
+		&invitation.Email,
+		&invitation.Role,
+		&invitation.TokenHash,
+		&invitation.InvitedBy,
+		&invitation.ExpiresAt,
+		&invitation.AcceptedAt,
+		&invitation.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// Create replaces a pending invitation for the same email, so inviting
+// someone again sends a fresh token.
+func (r *OrgInvitationRepository) Create(ctx context.Context, invitation *model.OrgInvitation) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	_, err = tx.Exec(ctx, `DELETE FROM org_invitations WHERE org_id = $1 AND email = $2 AND accepted_at IS NULL`,
+		invitation.OrgID, invitation.Email)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO org_invitations (org_id, email, role, token_hash, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+	err = tx.QueryRow(ctx, query,
+		invitation.OrgID, invitation.Email, invitation.Role, invitation.TokenHash, invitation.InvitedBy, invitation.ExpiresAt,
+	).Scan(&invitation.ID, &invitation.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// GetByTokenHash returns nil if no invitation has the hash.
+func (r *OrgInvitationRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*model.OrgInvitation, error) {
+	query := `SELECT ` + orgInvitationColumns + ` FROM org_invitations WHERE token_hash = $1`
+	invitation, err := scanOrgInvitation(r.db.QueryRow(ctx, query, tokenHash))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// ListPending returns the invitations of the organization that were not
+// accepted yet, including expired ones.
+func (r *OrgInvitationRepository) ListPending(ctx context.Context, orgID uuid.UUID) ([]*model.OrgInvitation, error) {
+	query := `SELECT ` + orgInvitationColumns + ` FROM org_invitations
+		WHERE org_id = $1 AND accepted_at IS NULL ORDER BY created_at`
+	rows, err := r.db.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invitations []*model.OrgInvitation
+	for rows.Next() {
+		invitation, err := scanOrgInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, invitation)
+	}
+	return invitations, rows.Err()
+}
+
+// GetByEmail returns the invitations addressed to the email in any
+// organization, including accepted and expired ones.
+func (r *OrgInvitationRepository) GetByEmail(ctx context.Context, email string) ([]*model.OrgInvitation, error) {
+	query := `SELECT ` + orgInvitationColumns + ` FROM org_invitations
+		WHERE lower(email) = lower($1) ORDER BY created_at`
+	rows, err := r.db.Query(ctx, query, email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invitations []*model.OrgInvitation
+	for rows.Next() {
+		invitation, err := scanOrgInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, invitation)
+	}
+	return invitations, rows.Err()
+}
+
+// MarkAccepted reports false if the invitation was already accepted or
+// revoked.
+func (r *OrgInvitationRepository) MarkAccepted(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `UPDATE org_invitations SET accepted_at = NOW() WHERE id = $1 AND accepted_at IS NULL`
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// Delete removes a pending invitation.
+func (r *OrgInvitationRepository) Delete(ctx context.Context, orgID, id uuid.UUID) (bool, error) {
+	query := `DELETE FROM org_invitations WHERE id = $1 AND org_id = $2 AND accepted_at IS NULL`
+	result, err := r.db.Exec(ctx, query, id, orgID)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+type MagicLinkRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewMagicLinkRepository(db *pgxpool.Pool) *MagicLinkRepository {
+	return &MagicLinkRepository{db: db}
+}
+
+// Create replaces the unused links of the user, so only the most recently
+// sent link works.
+func (r *MagicLinkRepository) Create(ctx context.Context, link *model.MagicLinkToken) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `DELETE FROM magic_link_tokens WHERE user_id = $1 AND used_at IS NULL`, link.UserID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO magic_link_tokens (user_id, org_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+	err = tx.QueryRow(ctx, query, link.UserID, link.OrgID, link.TokenHash, link.ExpiresAt).Scan(&link.ID, &link.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Consume marks the link with the hash as used and returns it. It returns nil
+// if there is no such link or it was already used or has expired, so a link
+// can only be used once even by concurrent requests.
+func (r *MagicLinkRepository) Consume(ctx context.Context, tokenHash string) (*model.MagicLinkToken, error) {
+	query := `
+		UPDATE magic_link_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING id, user_id, org_id, token_hash, expires_at, used_at, created_at`
+
+	var link model.MagicLinkToken
+	err := r.db.QueryRow(ctx, query, tokenHash).Scan(
+		&link.ID, &link.UserID, &link.OrgID, &link.TokenHash, &link.ExpiresAt, &link.UsedAt, &link.CreatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &link, nil
+}
+
+// IsEnabledForUser reports whether every organization the user is an active
+// member of allows magic links.
+func (r *MagicLinkRepository) IsEnabledForUser(ctx context.Context, userID uuid.UUID) (bool, error) {
+	query := `
+		SELECT NOT EXISTS (
+			SELECT 1 FROM org_memberships m
+			JOIN organizations o ON o.id = m.org_id
+			WHERE m.user_id = $1 AND m.is_active AND NOT o.magic_link_enabled
+		)`
+
+	var enabled bool
+	err := r.db.QueryRow(ctx, query, userID).Scan(&enabled)
+	return enabled, err
+}
+
+func (r *MagicLinkRepository) IsEnabledForOrg(ctx context.Context, orgID uuid.UUID) (bool, error) {
+	var enabled bool
+	err := r.db.QueryRow(ctx, `SELECT magic_link_enabled FROM organizations WHERE id = $1`, orgID).Scan(&enabled)
+	return enabled, err
+}
+
+func (r *MagicLinkRepository) SetEnabledForOrg(ctx context.Context, orgID uuid.UUID, enabled bool) error {
+	query := `UPDATE organizations SET magic_link_enabled = $2, updated_at = NOW() WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, orgID, enabled)
+	return err
+}
@@ -18,19 +18,42 @@ func NewMembershipRepo(db *DB) *MembershipRepo {
 	return &MembershipRepo{db: db}
 }
 
+// membershipColumns selects a membership (m) with the permissions of its
+// custom role (r), see membershipFrom.
+const membershipColumns = `m.id, m.user_id, m.org_id, m.role, m.is_active, m.external_id, m.created_at, m.custom_role_id, COALESCE(r.permissions, '{}')`
+
+const membershipFrom = `org_memberships m LEFT JOIN org_roles r ON r.id = m.custom_role_id`
+
+// membershipDest returns the scan destinations of membershipColumns. The
+// custom role permissions are scanned into permissions and set with
+// setCustomPermissions.
+func membershipDest(membership *model.OrgMembership, permissions *[]string) []any {
+	return []any{
+		&membership.ID, &membership.UserID, &membership.OrgID, &membership.Role, &membership.IsActive,
+		&membership.ExternalID, &membership.CreatedAt, &membership.CustomRoleID, permissions,
+	}
+}
+
+func setCustomPermissions(membership *model.OrgMembership, permissions []string) {
+	membership.CustomPermissions = make([]model.Permission, 0, len(permissions))
+	for _, permission := range permissions {
+		membership.CustomPermissions = append(membership.CustomPermissions, model.Permission(permission))
+	}
+}
+
 func (r *MembershipRepo) Create(ctx context.Context, membership *model.OrgMembership) error {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
 	query := `
-		INSERT INTO org_memberships (user_id, org_id, role, is_active, created_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO org_memberships (user_id, org_id, role, is_active, external_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id`
 
 	membership.CreatedAt = time.Now()
 
 	return r.db.pool.QueryRow(
-		ctx, query, membership.UserID, membership.OrgID, membership.Role, membership.IsActive, membership.CreatedAt,
+		ctx, query, membership.UserID, membership.OrgID, membership.Role, membership.IsActive, membership.ExternalID, membership.CreatedAt,
 	).Scan(&membership.ID)
 }
 
@@ -39,14 +62,14 @@ func (r *MembershipRepo) CreateTx(ctx context.Context, tx pgx.Tx, membership *mo
 	defer cancel()
 
 	query := `
-		INSERT INTO org_memberships (user_id, org_id, role, is_active, created_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO org_memberships (user_id, org_id, role, is_active, external_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id`
 
 	membership.CreatedAt = time.Now()
 
 	return tx.QueryRow(
-		ctx, query, membership.UserID, membership.OrgID, membership.Role, membership.IsActive, membership.CreatedAt,
+		ctx, query, membership.UserID, membership.OrgID, membership.Role, membership.IsActive, membership.ExternalID, membership.CreatedAt,
 	).Scan(&membership.ID)
 }
 
@@ -54,13 +77,12 @@ func (r *MembershipRepo) GetByUserAndOrg(ctx context.Context, userID, orgID uuid
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	query := `SELECT id, user_id, org_id, role, is_active, created_at FROM org_memberships WHERE user_id = $1 AND org_id = $2`
+	query := `SELECT ` + membershipColumns + ` FROM ` + membershipFrom + ` WHERE m.user_id = $1 AND m.org_id = $2`
 
 	membership := &model.OrgMembership{}
-	err := r.db.pool.QueryRow(ctx, query, userID, orgID).Scan(
-		&membership.ID, &membership.UserID, &membership.OrgID, &membership.Role, &membership.IsActive,
-		&membership.CreatedAt,
-	)
+	var permissions []string
+	err := r.db.pool.QueryRow(ctx, query, userID, orgID).Scan(membershipDest(membership, &permissions)...)
+	setCustomPermissions(membership, permissions)
 	return membership, err
 }
 
@@ -68,7 +90,7 @@ func (r *MembershipRepo) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	query := `SELECT id, user_id, org_id, role, is_active, created_at FROM org_memberships WHERE user_id = $1 AND is_active = true`
+	query := `SELECT ` + membershipColumns + ` FROM ` + membershipFrom + ` WHERE m.user_id = $1 AND m.is_active = true ORDER BY m.created_at, m.id`
 
 	rows, err := r.db.pool.Query(ctx, query, userID)
 	if err != nil {
@@ -79,12 +101,11 @@ func (r *MembershipRepo) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*
 	var memberships []*model.OrgMembership
 	for rows.Next() {
 		membership := &model.OrgMembership{}
-		if err := rows.Scan(
-			&membership.ID, &membership.UserID, &membership.OrgID, &membership.Role, &membership.IsActive,
-			&membership.CreatedAt,
-		); err != nil {
+		var permissions []string
+		if err := rows.Scan(membershipDest(membership, &permissions)...); err != nil {
 			return nil, err
 		}
+		setCustomPermissions(membership, permissions)
 		memberships = append(memberships, membership)
 	}
 
@@ -95,8 +116,105 @@ func (r *MembershipRepo) Update(ctx context.Context, membership *model.OrgMember
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	query := `UPDATE org_memberships SET role = $3, is_active = $4 WHERE user_id = $1 AND org_id = $2`
+	query := `UPDATE org_memberships SET role = $3, is_active = $4, external_id = $5, custom_role_id = $6 WHERE user_id = $1 AND org_id = $2`
 
-	_, err := r.db.pool.Exec(ctx, query, membership.UserID, membership.OrgID, membership.Role, membership.IsActive)
+	_, err := r.db.pool.Exec(ctx, query, membership.UserID, membership.OrgID, membership.Role, membership.IsActive, membership.ExternalID, membership.CustomRoleID)
 	return err
 }
+
+// ListByOrgID returns the active and inactive members of the organization
+// with their accounts, oldest first.
+func (r *MembershipRepo) ListByOrgID(ctx context.Context, orgID uuid.UUID) ([]*model.OrgMember, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT ` + membershipColumns + `,
+		       u.id, u.email, u.full_name, u.is_active, u.created_at, u.updated_at
+		FROM ` + membershipFrom + `
+		JOIN users u ON u.id = m.user_id
+		WHERE m.org_id = $1 AND u.deleted_at IS NULL
+		ORDER BY m.created_at, m.id`
+
+	rows, err := r.db.pool.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []*model.OrgMember
+	for rows.Next() {
+		member := &model.OrgMember{}
+		var permissions []string
+		dest := append(membershipDest(&member.Membership, &permissions),
+			&member.User.ID, &member.User.Email, &member.User.FullName, &member.User.IsActive,
+			&member.User.CreatedAt, &member.User.UpdatedAt,
+		)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		setCustomPermissions(&member.Membership, permissions)
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// Delete removes the user from the organization. It returns false if the
+// user was not a member.
+func (r *MembershipRepo) Delete(ctx context.Context, userID, orgID uuid.UUID) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	query := `DELETE FROM org_memberships WHERE user_id = $1 AND org_id = $2`
+
+	result, err := r.db.pool.Exec(ctx, query, userID, orgID)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// TransferOwnership makes the member the owner of the organization and the
+// current owner an admin. It returns pgx.ErrNoRows if fromUserID is not the
+// owner or toUserID is not an active member.
+func (r *MembershipRepo) TransferOwnership(ctx context.Context, orgID, fromUserID, toUserID uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	// Demote first: the organization may not have two owners at any point
+	result, err := tx.Exec(ctx,
+		`UPDATE org_memberships SET role = $3 WHERE org_id = $1 AND user_id = $2 AND role = $4`,
+		orgID, fromUserID, model.RoleAdmin, model.RoleOwner)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		err = pgx.ErrNoRows
+		return err
+	}
+
+	result, err = tx.Exec(ctx,
+		`UPDATE org_memberships SET role = $3 WHERE org_id = $1 AND user_id = $2 AND is_active = true`,
+		orgID, toUserID, model.RoleOwner)
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected() == 0 {
+		err = pgx.ErrNoRows
+		return err
+	}
+
+	if _, err = tx.Exec(ctx, `UPDATE organizations SET owner_user_id = $2, updated_at = NOW() WHERE id = $1`, orgID, toUserID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
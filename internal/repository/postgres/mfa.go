@@ -0,0 +1,239 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type MFARepository struct {
+	db *pgxpool.Pool
+}
+
+func NewMFARepository(db *pgxpool.Pool) *MFARepository {
+	return &MFARepository{db: db}
+}
+
+func (r *MFARepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*model.UserMFA, error) {
+	query := `
+		SELECT user_id, secret, pending_secret, enabled_at, last_used_step, created_at, updated_at
+		FROM user_mfa
+		WHERE user_id = $1`
+
+	var mfa model.UserMFA
+	err := r.db.QueryRow(ctx, query, userID).Scan(
+		&mfa.UserID,
+		&mfa.Secret,
+		&mfa.PendingSecret,
+		&mfa.EnabledAt,
+		&mfa.LastUsedStep,
+		&mfa.CreatedAt,
+		&mfa.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &mfa, nil
+}
+
+func (r *MFARepository) Upsert(ctx context.Context, mfa *model.UserMFA) error {
+	query := `
+		INSERT INTO user_mfa (user_id, secret, pending_secret, enabled_at, last_used_step)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE SET
+			secret = EXCLUDED.secret,
+			pending_secret = EXCLUDED.pending_secret,
+			enabled_at = EXCLUDED.enabled_at,
+			last_used_step = EXCLUDED.last_used_step,
+			updated_at = NOW()
+		RETURNING created_at, updated_at`
+
+	return r.db.QueryRow(ctx, query, mfa.UserID, mfa.Secret, mfa.PendingSecret, mfa.EnabledAt, mfa.LastUsedStep).
+		Scan(&mfa.CreatedAt, &mfa.UpdatedAt)
+}
+
+// UpdateLastUsedStep records a consumed TOTP step. It returns false when the
+// step is not newer than the last one used, i.e. the code is being replayed.
+func (r *MFARepository) UpdateLastUsedStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	query := `UPDATE user_mfa SET last_used_step = $2, updated_at = NOW() WHERE user_id = $1 AND last_used_step < $2`
+	result, err := r.db.Exec(ctx, query, userID, step)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *MFARepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	query := `DELETE FROM user_mfa WHERE user_id = $1`
+	_, err := r.db.Exec(ctx, query, userID)
+	return err
+}
+
+type MFAChallengeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewMFAChallengeRepository(db *pgxpool.Pool) *MFAChallengeRepository {
+	return &MFAChallengeRepository{db: db}
+}
+
+func (r *MFAChallengeRepository) Create(ctx context.Context, challenge *model.MFAChallenge) error {
+	query := `
+		INSERT INTO mfa_challenges (user_id, org_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+	return r.db.QueryRow(ctx, query, challenge.UserID, challenge.OrgID, challenge.TokenHash, challenge.ExpiresAt).
+		Scan(&challenge.ID, &challenge.CreatedAt)
+}
+
+func (r *MFAChallengeRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*model.MFAChallenge, error) {
+	query := `
+		SELECT id, user_id, org_id, token_hash, attempts, expires_at, used_at, created_at
+		FROM mfa_challenges
+		WHERE token_hash = $1`
+
+	var challenge model.MFAChallenge
+	err := r.db.QueryRow(ctx, query, tokenHash).Scan(
+		&challenge.ID,
+		&challenge.UserID,
+		&challenge.OrgID,
+		&challenge.TokenHash,
+		&challenge.Attempts,
+		&challenge.ExpiresAt,
+		&challenge.UsedAt,
+		&challenge.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+func (r *MFAChallengeRepository) IncrementAttempts(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE mfa_challenges SET attempts = attempts + 1 WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id)
+	return err
+}
+
+// MarkAsUsed consumes the challenge. It returns false if it was already used.
+func (r *MFAChallengeRepository) MarkAsUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `UPDATE mfa_challenges SET used_at = NOW() WHERE id = $1 AND used_at IS NULL`
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *MFAChallengeRepository) DeleteExpired(ctx context.Context) error {
+	query := `DELETE FROM mfa_challenges WHERE expires_at < NOW() - INTERVAL '1 day'`
+	_, err := r.db.Exec(ctx, query)
+	return err
+}
+
+type MFARecoveryCodeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewMFARecoveryCodeRepository(db *pgxpool.Pool) *MFARecoveryCodeRepository {
+	return &MFARecoveryCodeRepository{db: db}
+}
+
+// Replace atomically swaps all recovery codes of a user for the given hashes.
+func (r *MFARecoveryCodeRepository) Replace(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	for _, codeHash := range codeHashes {
+		if _, err := tx.Exec(ctx, `INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, codeHash); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *MFARecoveryCodeRepository) GetUnusedByUserID(ctx context.Context, userID uuid.UUID) ([]*model.MFARecoveryCode, error) {
+	query := `
+		SELECT id, user_id, code_hash, used_at, created_at
+		FROM mfa_recovery_codes
+		WHERE user_id = $1 AND used_at IS NULL
+		ORDER BY created_at`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var codes []*model.MFARecoveryCode
+	for rows.Next() {
+		var code model.MFARecoveryCode
+		if err := rows.Scan(&code.ID, &code.UserID, &code.CodeHash, &code.UsedAt, &code.CreatedAt); err != nil {
+			return nil, err
+		}
+		codes = append(codes, &code)
+	}
+	return codes, rows.Err()
+}
+
+// GetByUserID returns all recovery codes of the user, including used ones.
+func (r *MFARecoveryCodeRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*model.MFARecoveryCode, error) {
+	query := `
+		SELECT id, user_id, code_hash, used_at, created_at
+		FROM mfa_recovery_codes
+		WHERE user_id = $1
+		ORDER BY created_at`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var codes []*model.MFARecoveryCode
+	for rows.Next() {
+		var code model.MFARecoveryCode
+		if err := rows.Scan(&code.ID, &code.UserID, &code.CodeHash, &code.UsedAt, &code.CreatedAt); err != nil {
+			return nil, err
+		}
+		codes = append(codes, &code)
+	}
+	return codes, rows.Err()
+}
+
+func (r *MFARecoveryCodeRepository) CountUnused(ctx context.Context, userID uuid.UUID) (int, error) {
+	query := `SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+	var count int
+	err := r.db.QueryRow(ctx, query, userID).Scan(&count)
+	return count, err
+}
+
+// MarkAsUsed consumes the code. It returns false if it was already used.
+func (r *MFARecoveryCodeRepository) MarkAsUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `UPDATE mfa_recovery_codes SET used_at = NOW() WHERE id = $1 AND used_at IS NULL`
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *MFARecoveryCodeRepository) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	query := `DELETE FROM mfa_recovery_codes WHERE user_id = $1`
+	_, err := r.db.Exec(ctx, query, userID)
+	return err
+}
@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type OAuthClientRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOAuthClientRepository(db *pgxpool.Pool) *OAuthClientRepository {
+	return &OAuthClientRepository{db: db}
+}
+
+// GetByClientID returns nil if no client is registered under clientID.
+func (r *OAuthClientRepository) GetByClientID(ctx context.Context, clientID string) (*model.OAuthClient, error) {
+	query := `
+		SELECT id, client_id, client_secret_hash, name, redirect_uris, scopes, grant_types, is_active, created_at, updated_at
+		FROM oauth_clients
+		WHERE client_id = $1`
+
+	var client model.OAuthClient
+	err := r.db.QueryRow(ctx, query, clientID).Scan(
+		&client.ID,
+		&client.ClientID,
+		&client.ClientSecretHash,
+		&client.Name,
+		&client.RedirectURIs,
+		&client.Scopes,
+		&client.GrantTypes,
+		&client.IsActive,
+		&client.CreatedAt,
+		&client.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &client, nil
+}
+
+type OAuthAuthorizationCodeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOAuthAuthorizationCodeRepository(db *pgxpool.Pool) *OAuthAuthorizationCodeRepository {
+	return &OAuthAuthorizationCodeRepository{db: db}
+}
+
+func (r *OAuthAuthorizationCodeRepository) Create(ctx context.Context, code *model.OAuthAuthorizationCode) error {
+	query := `
+		INSERT INTO oauth_authorization_codes
+			(code_hash, client_id, user_id, org_id, redirect_uri, scope, nonce, code_challenge, code_challenge_method, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at`
+	return r.db.QueryRow(ctx, query,
+		code.CodeHash, code.ClientID, code.UserID, code.OrgID, code.RedirectURI, code.Scope, code.Nonce,
+		code.CodeChallenge, code.CodeChallengeMethod, code.ExpiresAt,
+	).Scan(&code.ID, &code.CreatedAt)
+}
+
+// GetByCodeHash returns nil if the code does not exist.
+func (r *OAuthAuthorizationCodeRepository) GetByCodeHash(ctx context.Context, codeHash string) (*model.OAuthAuthorizationCode, error) {
+	query := `
+		SELECT id, code_hash, client_id, user_id, org_id, redirect_uri, scope, nonce, code_challenge,
+			code_challenge_method, expires_at, used_at, created_at
+		FROM oauth_authorization_codes
+		WHERE code_hash = $1`
+
+	var code model.OAuthAuthorizationCode
+	err := r.db.QueryRow(ctx, query, codeHash).Scan(
+		&code.ID,
+		&code.CodeHash,
+		&code.ClientID,
+		&code.UserID,
+		&code.OrgID,
+		&code.RedirectURI,
+		&code.Scope,
+		&code.Nonce,
+		&code.CodeChallenge,
+		&code.CodeChallengeMethod,
+		&code.ExpiresAt,
+		&code.UsedAt,
+		&code.CreatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &code, nil
+}
+
+// MarkAsUsed redeems the code. It returns false if it was already redeemed.
+func (r *OAuthAuthorizationCodeRepository) MarkAsUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `UPDATE oauth_authorization_codes SET used_at = NOW() WHERE id = $1 AND used_at IS NULL`
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *OAuthAuthorizationCodeRepository) DeleteExpired(ctx context.Context) error {
+	query := `DELETE FROM oauth_authorization_codes WHERE expires_at < NOW() - INTERVAL '1 day'`
+	_, err := r.db.Exec(ctx, query)
+	return err
+}
@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+type OrgRoleRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOrgRoleRepository(db *pgxpool.Pool) *OrgRoleRepository {
+	return &OrgRoleRepository{db: db}
+}
+
+const orgRoleColumns = `id, org_id, name, description, permissions, created_at, updated_at`
+
+func scanOrgRole(row pgx.Row) (*model.OrgRole, error) {
+	var role model.OrgRole
+	var permissions []string
+	err := row.Scan(
+		&role.ID,
+		&role.OrgID,
+		&role.Name,
+		&role.Description,
+		&permissions,
+		&role.CreatedAt,
+		&role.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	role.Permissions = make([]model.Permission, 0, len(permissions))
+	for _, permission := range permissions {
+		role.Permissions = append(role.Permissions, model.Permission(permission))
+	}
+	return &role, nil
+}
+
+func permissionStrings(permissions []model.Permission) []string {
+	result := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		result = append(result, string(permission))
+	}
+	return result
+}
+
+func (r *OrgRoleRepository) Create(ctx context.Context, role *model.OrgRole) error {
+	query := `
+		INSERT INTO org_roles (org_id, name, description, permissions)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at`
+	return r.db.QueryRow(ctx, query, role.OrgID, role.Name, role.Description, permissionStrings(role.Permissions)).
+		Scan(&role.ID, &role.CreatedAt, &role.UpdatedAt)
+}
+
+// GetByID returns nil if the organization has no such role.
+func (r *OrgRoleRepository) GetByID(ctx context.Context, orgID, id uuid.UUID) (*model.OrgRole, error) {
+	query := `SELECT ` + orgRoleColumns + ` FROM org_roles WHERE id = $1 AND org_id = $2`
+	role, err := scanOrgRole(r.db.QueryRow(ctx, query, id, orgID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return role, nil
+}
+
+func (r *OrgRoleRepository) ListByOrgID(ctx context.Context, orgID uuid.UUID) ([]*model.OrgRole, error) {
+	query := `SELECT ` + orgRoleColumns + ` FROM org_roles WHERE org_id = $1 ORDER BY name`
+	rows, err := r.db.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []*model.OrgRole
+	for rows.Next() {
+		role, err := scanOrgRole(rows)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+func (r *OrgRoleRepository) Update(ctx context.Context, role *model.OrgRole) error {
+	query := `
+		UPDATE org_roles
+		SET name = $3, description = $4, permissions = $5, updated_at = NOW()
+		WHERE id = $1 AND org_id = $2
+		RETURNING updated_at`
+	return r.db.QueryRow(ctx, query, role.ID, role.OrgID, role.Name, role.Description, permissionStrings(role.Permissions)).
+		Scan(&role.UpdatedAt)
+}
+
+// Delete removes the role; members it was assigned to keep their built-in
+// role only.
+func (r *OrgRoleRepository) Delete(ctx context.Context, orgID, id uuid.UUID) (bool, error) {
+	query := `DELETE FROM org_roles WHERE id = $1 AND org_id = $2`
+	result, err := r.db.Exec(ctx, query, id, orgID)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
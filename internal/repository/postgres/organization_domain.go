@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+type OrganizationDomainRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOrganizationDomainRepository(db *pgxpool.Pool) *OrganizationDomainRepository {
+	return &OrganizationDomainRepository{db: db}
+}
+
+const organizationDomainColumns = `id, org_id, domain, verification_token, verified_at, sso_enforced, created_at, updated_at`
+
+func scanOrganizationDomain(row pgx.Row) (*model.OrganizationDomain, error) {
+	var domain model.OrganizationDomain
+	err := row.Scan(
+		&domain.ID,
+		&domain.OrgID,
+		&domain.Domain,
+		&domain.VerificationToken,
+		&domain.VerifiedAt,
+		&domain.SSOEnforced,
+		&domain.CreatedAt,
+		&domain.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &domain, nil
+}
+
+func (r *OrganizationDomainRepository) Create(ctx context.Context, domain *model.OrganizationDomain) error {
+	query := `
+		INSERT INTO organization_domains (org_id, domain, verification_token)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at`
+	return r.db.QueryRow(ctx, query, domain.OrgID, domain.Domain, domain.VerificationToken).
+		Scan(&domain.ID, &domain.CreatedAt, &domain.UpdatedAt)
+}
+
+// GetByID returns nil if the organization has no such domain.
+func (r *OrganizationDomainRepository) GetByID(ctx context.Context, orgID, id uuid.UUID) (*model.OrganizationDomain, error) {
+	query := `SELECT ` + organizationDomainColumns + ` FROM organization_domains WHERE id = $1 AND org_id = $2`
+	domain, err := scanOrganizationDomain(r.db.QueryRow(ctx, query, id, orgID))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return domain, nil
+}
+
+func (r *OrganizationDomainRepository) GetByOrgID(ctx context.Context, orgID uuid.UUID) ([]*model.OrganizationDomain, error) {
+	query := `SELECT ` + organizationDomainColumns + ` FROM organization_domains WHERE org_id = $1 ORDER BY domain`
+	rows, err := r.db.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var domains []*model.OrganizationDomain
+	for rows.Next() {
+		domain, err := scanOrganizationDomain(rows)
+		if err != nil {
+			return nil, err
+		}
+		domains = append(domains, domain)
+	}
+	return domains, rows.Err()
+}
+
+// GetVerified returns the verified claim on the domain, or nil if no
+// organization has verified it.
+func (r *OrganizationDomainRepository) GetVerified(ctx context.Context, domainName string) (*model.OrganizationDomain, error) {
+	query := `SELECT ` + organizationDomainColumns + ` FROM organization_domains WHERE domain = $1 AND verified_at IS NOT NULL`
+	domain, err := scanOrganizationDomain(r.db.QueryRow(ctx, query, domainName))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return domain, nil
+}
+
+func (r *OrganizationDomainRepository) MarkVerified(ctx context.Context, domain *model.OrganizationDomain) error {
+	query := `
+		UPDATE organization_domains
+		SET verified_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+		RETURNING verified_at, updated_at`
+	return r.db.QueryRow(ctx, query, domain.ID).Scan(&domain.VerifiedAt, &domain.UpdatedAt)
+}
+
+func (r *OrganizationDomainRepository) SetSSOEnforced(ctx context.Context, domain *model.OrganizationDomain) error {
+	query := `
+		UPDATE organization_domains
+		SET sso_enforced = $2, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at`
+	return r.db.QueryRow(ctx, query, domain.ID, domain.SSOEnforced).Scan(&domain.UpdatedAt)
+}
+
+func (r *OrganizationDomainRepository) Delete(ctx context.Context, orgID, id uuid.UUID) (bool, error) {
+	query := `DELETE FROM organization_domains WHERE id = $1 AND org_id = $2`
+	result, err := r.db.Exec(ctx, query, id, orgID)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
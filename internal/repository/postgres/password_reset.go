@@ -52,6 +52,37 @@ func (r *PasswordResetRepository) GetByTokenHash(ctx context.Context, tokenHash
 	return &token, nil
 }
 
+func (r *PasswordResetRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*model.PasswordResetToken, error) {
+	query := `
+		SELECT id, user_id, token_hash, expires_at, used_at, created_at
+		FROM password_reset_tokens
+		WHERE user_id = $1
+		ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []*model.PasswordResetToken
+	for rows.Next() {
+		var token model.PasswordResetToken
+		if err := rows.Scan(
+			&token.ID,
+			&token.UserID,
+			&token.TokenHash,
+			&token.ExpiresAt,
+			&token.UsedAt,
+			&token.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, &token)
+	}
+	return tokens, rows.Err()
+}
+
 func (r *PasswordResetRepository) MarkAsUsed(ctx context.Context, id uuid.UUID) error {
 	query := `UPDATE password_reset_tokens SET used_at = NOW() WHERE id = $1`
 	_, err := r.db.Exec(ctx, query, id)
@@ -33,12 +33,16 @@ func (r *RefreshTokenRepo) Create(ctx context.Context, token *model.RefreshToken
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
+	if token.FamilyID == uuid.Nil {
+		token.FamilyID = uuid.New()
+	}
+
 	query := `
-		INSERT INTO refresh_tokens (user_id, org_id, token_hash, user_agent, ip_address, created_at, expires_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO refresh_tokens (user_id, org_id, token_hash, user_agent, ip_address, created_at, expires_at, family_id, amr)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id`
 
-	return r.db.pool.QueryRow(ctx, query, token.UserID, token.OrgID, token.TokenHash, token.UserAgent, token.IPAddress, token.CreatedAt, token.ExpiresAt).Scan(&token.ID)
+	return r.db.pool.QueryRow(ctx, query, token.UserID, token.OrgID, token.TokenHash, token.UserAgent, token.IPAddress, token.CreatedAt, token.ExpiresAt, token.FamilyID, amrValue(token.AMR)).Scan(&token.ID)
 }
 
 func (r *RefreshTokenRepo) CreateTx(ctx context.Context, tx pgx.Tx, token *model.RefreshToken) error {
@@ -49,12 +53,16 @@ func (r *RefreshTokenRepo) CreateTx(ctx context.Context, tx pgx.Tx, token *model
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
+	if token.FamilyID == uuid.Nil {
+		token.FamilyID = uuid.New()
+	}
+
 	query := `
-		INSERT INTO refresh_tokens (user_id, org_id, token_hash, user_agent, ip_address, created_at, expires_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO refresh_tokens (user_id, org_id, token_hash, user_agent, ip_address, created_at, expires_at, family_id, amr)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id`
 
-	return tx.QueryRow(ctx, query, token.UserID, token.OrgID, token.TokenHash, token.UserAgent, token.IPAddress, token.CreatedAt, token.ExpiresAt).Scan(&token.ID)
+	return tx.QueryRow(ctx, query, token.UserID, token.OrgID, token.TokenHash, token.UserAgent, token.IPAddress, token.CreatedAt, token.ExpiresAt, token.FamilyID, amrValue(token.AMR)).Scan(&token.ID)
 }
 
 func (r *RefreshTokenRepo) GetByTokenHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
@@ -68,10 +76,10 @@ func (r *RefreshTokenRepo) GetByTokenHash(ctx context.Context, tokenHash string)
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	query := `SELECT id, user_id, org_id, token_hash, user_agent, ip_address, created_at, expires_at, revoked_at FROM refresh_tokens WHERE token_hash = $1`
+	query := `SELECT id, user_id, org_id, token_hash, user_agent, ip_address, created_at, expires_at, revoked_at, family_id, replaced_by_id, amr FROM refresh_tokens WHERE token_hash = $1`
 
 	token := &model.RefreshToken{}
-	err := r.db.pool.QueryRow(ctx, query, tokenHash).Scan(&token.ID, &token.UserID, &token.OrgID, &token.TokenHash, &token.UserAgent, &token.IPAddress, &token.CreatedAt, &token.ExpiresAt, &token.RevokedAt)
+	err := r.db.pool.QueryRow(ctx, query, tokenHash).Scan(&token.ID, &token.UserID, &token.OrgID, &token.TokenHash, &token.UserAgent, &token.IPAddress, &token.CreatedAt, &token.ExpiresAt, &token.RevokedAt, &token.FamilyID, &token.ReplacedByID, &token.AMR)
 	if err != nil {
 		return nil, err
 	}
@@ -120,6 +128,57 @@ func (r *RefreshTokenRepo) RevokeByID(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+// Rotate stores next as the successor of the token with oldID and revokes the
+// old token in one transaction. It returns false without storing next if the
+// old token was already revoked or rotated, e.g. by a concurrent refresh.
+func (r *RefreshTokenRepo) Rotate(ctx context.Context, oldID uuid.UUID, next *model.RefreshToken) (bool, error) {
+	if r.db == nil || r.db.pool == nil {
+		return false, sql.ErrConnDone
+	}
+	if next == nil {
+		return false, sql.ErrNoRows
+	}
+
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if err := r.CreateTx(ctx, tx, next); err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	query := `UPDATE refresh_tokens SET revoked_at = $2, replaced_by_id = $3 WHERE id = $1 AND revoked_at IS NULL`
+	result, err := tx.Exec(ctx, query, oldID, time.Now(), next.ID)
+	if err != nil {
+		return false, err
+	}
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	return true, tx.Commit(ctx)
+}
+
+// RevokeFamily revokes every token descended from the same login.
+func (r *RefreshTokenRepo) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	if r.db == nil || r.db.pool == nil {
+		return sql.ErrConnDone
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+
+	query := `UPDATE refresh_tokens SET revoked_at = $2 WHERE family_id = $1 AND revoked_at IS NULL`
+
+	_, err := r.db.pool.Exec(ctx, query, familyID, time.Now())
+	return err
+}
+
 func (r *RefreshTokenRepo) DeleteExpired(ctx context.Context) error {
 	if r.db == nil || r.db.pool == nil {
 		return sql.ErrConnDone
@@ -144,7 +203,7 @@ func (r *RefreshTokenRepo) GetActiveByUserID(ctx context.Context, userID uuid.UU
 	defer cancel()
 
 	query := `
-		SELECT id, user_id, org_id, token_hash, user_agent, ip_address, fingerprint_hash, created_at, expires_at, revoked_at
+		SELECT id, user_id, org_id, token_hash, user_agent, ip_address, fingerprint_hash, created_at, expires_at, revoked_at, family_id
 		FROM refresh_tokens
 		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
 		ORDER BY created_at DESC`
@@ -158,7 +217,7 @@ func (r *RefreshTokenRepo) GetActiveByUserID(ctx context.Context, userID uuid.UU
 	var tokens []*model.RefreshToken
 	for rows.Next() {
 		token := &model.RefreshToken{}
-		err := rows.Scan(&token.ID, &token.UserID, &token.OrgID, &token.TokenHash, &token.UserAgent, &token.IPAddress, &token.FingerprintHash, &token.CreatedAt, &token.ExpiresAt, &token.RevokedAt)
+		err := rows.Scan(&token.ID, &token.UserID, &token.OrgID, &token.TokenHash, &token.UserAgent, &token.IPAddress, &token.FingerprintHash, &token.CreatedAt, &token.ExpiresAt, &token.RevokedAt, &token.FamilyID)
 		if err != nil {
 			return nil, err
 		}
@@ -167,3 +226,43 @@ func (r *RefreshTokenRepo) GetActiveByUserID(ctx context.Context, userID uuid.UU
 
 	return tokens, rows.Err()
 }
+
+// GetByUserID returns all sessions of the user, including revoked and expired
+// ones that have not been cleaned up yet.
+func (r *RefreshTokenRepo) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*model.RefreshToken, error) {
+	if r.db == nil || r.db.pool == nil {
+		return nil, sql.ErrConnDone
+	}
+
+	query := `
+		SELECT id, user_id, org_id, token_hash, user_agent, ip_address, fingerprint_hash, created_at, expires_at, revoked_at, family_id
+		FROM refresh_tokens
+		WHERE user_id = $1
+		ORDER BY created_at DESC`
+
+	rows, err := r.db.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []*model.RefreshToken
+	for rows.Next() {
+		token := &model.RefreshToken{}
+		err := rows.Scan(&token.ID, &token.UserID, &token.OrgID, &token.TokenHash, &token.UserAgent, &token.IPAddress, &token.FingerprintHash, &token.CreatedAt, &token.ExpiresAt, &token.RevokedAt, &token.FamilyID)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// amrValue stores a missing amr as an empty array, the column is NOT NULL.
+func amrValue(amr []string) []string {
+	if amr == nil {
+		return []string{}
+	}
+	return amr
+}
@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// RevocationRepository stores the access token denylist in Postgres. It
+// implements token.RevocationStore.
+type RevocationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRevocationRepository(db *pgxpool.Pool) *RevocationRepository {
+	return &RevocationRepository{db: db}
+}
+
+func (r *RevocationRepository) Set(ctx context.Context, key string, value int64, ttl time.Duration) error {
+	query := `
+		INSERT INTO token_revocations (key, value, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (key) DO UPDATE SET
+			value = EXCLUDED.value,
+			expires_at = GREATEST(token_revocations.expires_at, EXCLUDED.expires_at)`
+
+	_, err := r.db.Exec(ctx, query, key, value, time.Now().Add(ttl))
+	return err
+}
+
+func (r *RevocationRepository) Get(ctx context.Context, key string) (int64, bool, error) {
+	query := `SELECT value FROM token_revocations WHERE key = $1 AND expires_at > NOW()`
+
+	var value int64
+	if err := r.db.QueryRow(ctx, query, key).Scan(&value); err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	return value, true, nil
+}
+
+func (r *RevocationRepository) DeleteExpired(ctx context.Context) error {
+	query := `DELETE FROM token_revocations WHERE expires_at < NOW()`
+	_, err := r.db.Exec(ctx, query)
+	return err
+}
@@ -0,0 +1,200 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+type SAMLConnectionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSAMLConnectionRepository(db *pgxpool.Pool) *SAMLConnectionRepository {
+	return &SAMLConnectionRepository{db: db}
+}
+
+// Upsert creates the connection of the organization or replaces its settings.
+func (r *SAMLConnectionRepository) Upsert(ctx context.Context, conn *model.SAMLConnection) error {
+	query := `
+		INSERT INTO saml_connections (
+			org_id, idp_entity_id, idp_sso_url, idp_certificates, metadata_xml,
+			email_attribute, name_attribute, default_role, enabled
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (org_id) DO UPDATE SET
+			idp_entity_id = EXCLUDED.idp_entity_id,
+			idp_sso_url = EXCLUDED.idp_sso_url,
+			idp_certificates = EXCLUDED.idp_certificates,
+			metadata_xml = EXCLUDED.metadata_xml,
+			email_attribute = EXCLUDED.email_attribute,
+			name_attribute = EXCLUDED.name_attribute,
+			default_role = EXCLUDED.default_role,
+			enabled = EXCLUDED.enabled,
+			updated_at = NOW()
+		RETURNING id, created_at, updated_at`
+	return r.db.QueryRow(ctx, query,
+		conn.OrgID, conn.IdPEntityID, conn.IdPSSOURL, conn.IdPCertificates, conn.MetadataXML,
+		conn.EmailAttribute, conn.NameAttribute, conn.DefaultRole, conn.Enabled,
+	).Scan(&conn.ID, &conn.CreatedAt, &conn.UpdatedAt)
+}
+
+// GetByOrgID returns nil if the organization has no SAML connection.
+func (r *SAMLConnectionRepository) GetByOrgID(ctx context.Context, orgID uuid.UUID) (*model.SAMLConnection, error) {
+	query := `
+		SELECT id, org_id, idp_entity_id, idp_sso_url, idp_certificates, metadata_xml,
+		       email_attribute, name_attribute, default_role, enabled, created_at, updated_at
+		FROM saml_connections
+		WHERE org_id = $1`
+
+	var conn model.SAMLConnection
+	err := r.db.QueryRow(ctx, query, orgID).Scan(
+		&conn.ID,
+		&conn.OrgID,
+		&conn.IdPEntityID,
+		&conn.IdPSSOURL,
+		&conn.IdPCertificates,
+		&conn.MetadataXML,
+		&conn.EmailAttribute,
+		&conn.NameAttribute,
+		&conn.DefaultRole,
+		&conn.Enabled,
+		&conn.CreatedAt,
+		&conn.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &conn, nil
+}
+
+func (r *SAMLConnectionRepository) Delete(ctx context.Context, orgID uuid.UUID) (bool, error) {
+	query := `DELETE FROM saml_connections WHERE org_id = $1`
+	result, err := r.db.Exec(ctx, query, orgID)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+type SAMLRequestRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSAMLRequestRepository(db *pgxpool.Pool) *SAMLRequestRepository {
+	return &SAMLRequestRepository{db: db}
+}
+
+func (r *SAMLRequestRepository) Create(ctx context.Context, request *model.SAMLRequest) error {
+	query := `
+		INSERT INTO saml_requests (request_id, org_id, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at`
+	return r.db.QueryRow(ctx, query, request.RequestID, request.OrgID, request.ExpiresAt).Scan(&request.ID, &request.CreatedAt)
+}
+
+// GetByRequestID returns nil if no such request was sent.
+func (r *SAMLRequestRepository) GetByRequestID(ctx context.Context, requestID string) (*model.SAMLRequest, error) {
+	query := `
+		SELECT id, request_id, org_id, expires_at, used_at, created_at
+		FROM saml_requests
+		WHERE request_id = $1`
+
+	var request model.SAMLRequest
+	err := r.db.QueryRow(ctx, query, requestID).Scan(
+		&request.ID,
+		&request.RequestID,
+		&request.OrgID,
+		&request.ExpiresAt,
+		&request.UsedAt,
+		&request.CreatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+// MarkAsUsed consumes the request. It returns false if it was already used.
+func (r *SAMLRequestRepository) MarkAsUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `UPDATE saml_requests SET used_at = NOW() WHERE id = $1 AND used_at IS NULL`
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *SAMLRequestRepository) DeleteExpired(ctx context.Context) error {
+	query := `DELETE FROM saml_requests WHERE expires_at < NOW() - INTERVAL '1 day'`
+	_, err := r.db.Exec(ctx, query)
+	return err
+}
+
+type SAMLLoginCodeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSAMLLoginCodeRepository(db *pgxpool.Pool) *SAMLLoginCodeRepository {
+	return &SAMLLoginCodeRepository{db: db}
+}
+
+func (r *SAMLLoginCodeRepository) Create(ctx context.Context, code *model.SAMLLoginCode) error {
+	query := `
+		INSERT INTO saml_login_codes (code_hash, user_id, org_id, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+	return r.db.QueryRow(ctx, query, code.CodeHash, code.UserID, code.OrgID, code.ExpiresAt).Scan(&code.ID, &code.CreatedAt)
+}
+
+// GetByCodeHash returns nil if the code does not exist.
+func (r *SAMLLoginCodeRepository) GetByCodeHash(ctx context.Context, codeHash string) (*model.SAMLLoginCode, error) {
+	query := `
+		SELECT id, code_hash, user_id, org_id, expires_at, used_at, created_at
+		FROM saml_login_codes
+		WHERE code_hash = $1`
+
+	var code model.SAMLLoginCode
+	err := r.db.QueryRow(ctx, query, codeHash).Scan(
+		&code.ID,
+		&code.CodeHash,
+		&code.UserID,
+		&code.OrgID,
+		&code.ExpiresAt,
+		&code.UsedAt,
+		&code.CreatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &code, nil
+}
+
+// MarkAsUsed consumes the code. It returns false if it was already used.
+func (r *SAMLLoginCodeRepository) MarkAsUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `UPDATE saml_login_codes SET used_at = NOW() WHERE id = $1 AND used_at IS NULL`
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *SAMLLoginCodeRepository) DeleteExpired(ctx context.Context) error {
+	query := `DELETE FROM saml_login_codes WHERE expires_at < NOW() - INTERVAL '1 day'`
+	_, err := r.db.Exec(ctx, query)
+	return err
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+type SCIMTokenRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSCIMTokenRepository(db *pgxpool.Pool) *SCIMTokenRepository {
+	return &SCIMTokenRepository{db: db}
+}
+
+const scimTokenColumns = `id, org_id, token_hash, description, created_by, last_used_at, created_at`
+
+func scanSCIMToken(row pgx.Row) (*model.SCIMToken, error) {
+	var token model.SCIMToken
+	err := row.Scan(
+		&token.ID,
+		&token.OrgID,
+		&token.TokenHash,
+		&token.Description,
+		&token.CreatedBy,
+		&token.LastUsedAt,
+		&token.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *SCIMTokenRepository) Create(ctx context.Context, token *model.SCIMToken) error {
+	query := `
+		INSERT INTO scim_tokens (org_id, token_hash, description, created_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+	return r.db.QueryRow(ctx, query, token.OrgID, token.TokenHash, token.Description, token.CreatedBy).
+		Scan(&token.ID, &token.CreatedAt)
+}
+
+// GetByTokenHash returns nil if no token has the hash.
+func (r *SCIMTokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*model.SCIMToken, error) {
+	query := `SELECT ` + scimTokenColumns + ` FROM scim_tokens WHERE token_hash = $1`
+	token, err := scanSCIMToken(r.db.QueryRow(ctx, query, tokenHash))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return token, nil
+}
+
+func (r *SCIMTokenRepository) ListByOrgID(ctx context.Context, orgID uuid.UUID) ([]*model.SCIMToken, error) {
+	query := `SELECT ` + scimTokenColumns + ` FROM scim_tokens WHERE org_id = $1 ORDER BY created_at`
+	rows, err := r.db.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []*model.SCIMToken
+	for rows.Next() {
+		token, err := scanSCIMToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+func (r *SCIMTokenRepository) UpdateLastUsed(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `UPDATE scim_tokens SET last_used_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+func (r *SCIMTokenRepository) Delete(ctx context.Context, orgID, id uuid.UUID) (bool, error) {
+	query := `DELETE FROM scim_tokens WHERE id = $1 AND org_id = $2`
+	result, err := r.db.Exec(ctx, query, id, orgID)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
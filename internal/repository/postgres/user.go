@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -25,7 +26,7 @@ func (r *UserRepo) Create(ctx context.Context, user *model.User) error {
 	query := `
 		INSERT INTO users (email, password_hash, full_name, is_active, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id`
+		RETURNING id, locale, timezone`
 
 	now := time.Now()
 	user.CreatedAt = now
@@ -33,7 +34,7 @@ func (r *UserRepo) Create(ctx context.Context, user *model.User) error {
 
 	return r.db.pool.QueryRow(
 		ctx, query, user.Email, user.PasswordHash, user.FullName, user.IsActive, user.CreatedAt, user.UpdatedAt,
-	).Scan(&user.ID)
+	).Scan(&user.ID, &user.Locale, &user.Timezone)
 }
 
 func (r *UserRepo) CreateTx(ctx context.Context, tx pgx.Tx, user *model.User) error {
@@ -43,7 +44,7 @@ func (r *UserRepo) CreateTx(ctx context.Context, tx pgx.Tx, user *model.User) er
 	query := `
 		INSERT INTO users (email, password_hash, full_name, is_active, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id`
+		RETURNING id, locale, timezone`
 
 	now := time.Now()
 	user.CreatedAt = now
@@ -51,20 +52,59 @@ func (r *UserRepo) CreateTx(ctx context.Context, tx pgx.Tx, user *model.User) er
 
 	return tx.QueryRow(
 		ctx, query, user.Email, user.PasswordHash, user.FullName, user.IsActive, user.CreatedAt, user.UpdatedAt,
-	).Scan(&user.ID)
+	).Scan(&user.ID, &user.Locale, &user.Timezone)
+}
+
+// CreateWithMembership creates the user as a member of an organization in one
+// transaction.
+func (r *UserRepo) CreateWithMembership(ctx context.Context, user *model.User, membership *model.OrgMembership) error {
+	tx, err := r.db.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	now := time.Now()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+
+	userQuery := `INSERT INTO users (email, password_hash, full_name, is_active, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, locale, timezone`
+	if err = tx.QueryRow(ctx, userQuery, user.Email, user.PasswordHash, user.FullName, user.IsActive, user.CreatedAt, user.UpdatedAt).Scan(&user.ID, &user.Locale, &user.Timezone); err != nil {
+		return err
+	}
+
+	membership.UserID = user.ID
+	membership.CreatedAt = now
+
+	membershipQuery := `INSERT INTO org_memberships (user_id, org_id, role, is_active, external_id, created_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	if err = tx.QueryRow(ctx, membershipQuery, membership.UserID, membership.OrgID, membership.Role, membership.IsActive, membership.ExternalID, membership.CreatedAt).Scan(&membership.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+const userColumns = `id, email, password_hash, full_name, locale, timezone, is_active, is_platform_admin, failed_login_attempts, locked_until, deleted_at, created_at, updated_at`
+
+func scanUser(row pgx.Row, user *model.User) error {
+	return row.Scan(
+		&user.ID, &user.Email, &user.PasswordHash, &user.FullName, &user.Locale, &user.Timezone, &user.IsActive, &user.IsPlatformAdmin,
+		&user.FailedLoginAttempts, &user.LockedUntil, &user.DeletedAt, &user.CreatedAt, &user.UpdatedAt,
+	)
 }
 
 func (r *UserRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	query := `SELECT id, email, password_hash, full_name, is_active, failed_login_attempts, locked_until, created_at, updated_at FROM users WHERE id = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE id = $1`
 
 	user := &model.User{}
-	err := r.db.pool.QueryRow(ctx, query, id).Scan(
-		&user.ID, &user.Email, &user.PasswordHash, &user.FullName, &user.IsActive, &user.FailedLoginAttempts,
-		&user.LockedUntil, &user.CreatedAt, &user.UpdatedAt,
-	)
+	err := scanUser(r.db.pool.QueryRow(ctx, query, id), user)
 	return user, err
 }
 
@@ -72,26 +112,68 @@ func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*model.User, e
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	query := `SELECT id, email, password_hash, full_name, is_active, failed_login_attempts, locked_until, created_at, updated_at FROM users WHERE email = $1`
+	query := `SELECT ` + userColumns + ` FROM users WHERE email = $1`
 
 	user := &model.User{}
-	err := r.db.pool.QueryRow(ctx, query, email).Scan(
-		&user.ID, &user.Email, &user.PasswordHash, &user.FullName, &user.IsActive, &user.FailedLoginAttempts,
-		&user.LockedUntil, &user.CreatedAt, &user.UpdatedAt,
-	)
+	err := scanUser(r.db.pool.QueryRow(ctx, query, email), user)
 	return user, err
 }
 
+// likeEscaper escapes the wildcards of LIKE patterns.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Search returns users whose email or full name contains query, newest
+// first. An empty query matches every user.
+func (r *UserRepo) Search(ctx context.Context, query string, limit, offset int) ([]*model.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	pattern := "%" + likeEscaper.Replace(query) + "%"
+	sqlQuery := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE email ILIKE $1 OR full_name ILIKE $1
+		ORDER BY created_at DESC, id
+		LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.pool.Query(ctx, sqlQuery, pattern, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*model.User
+	for rows.Next() {
+		user := &model.User{}
+		if err := scanUser(rows, user); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// CountActive returns the number of active accounts that are not deleted.
+func (r *UserRepo) CountActive(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var count int64
+	err := r.db.pool.QueryRow(ctx, `SELECT COUNT(*) FROM users WHERE is_active AND deleted_at IS NULL`).Scan(&count)
+	return count, err
+}
+
+// Update saves the user. An empty Locale or Timezone keeps the stored value.
 func (r *UserRepo) Update(ctx context.Context, user *model.User) error {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	query := `UPDATE users SET email = $2, password_hash = $3, full_name = $4, is_active = $5, failed_login_attempts = $6, locked_until = $7, updated_at = $8 WHERE id = $1`
+	query := `UPDATE users SET email = $2, password_hash = $3, full_name = $4, is_active = $5, failed_login_attempts = $6, locked_until = $7, updated_at = $8, locale = COALESCE(NULLIF($9, ''), locale), timezone = COALESCE(NULLIF($10, ''), timezone) WHERE id = $1`
 
 	user.UpdatedAt = time.Now()
 	_, err := r.db.pool.Exec(
 		ctx, query, user.ID, user.Email, user.PasswordHash, user.FullName, user.IsActive, user.FailedLoginAttempts,
-		user.LockedUntil, user.UpdatedAt,
+		user.LockedUntil, user.UpdatedAt, user.Locale, user.Timezone,
 	)
 	return err
 }
@@ -100,12 +182,12 @@ func (r *UserRepo) UpdateTx(ctx context.Context, tx pgx.Tx, user *model.User) er
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	query := `UPDATE users SET email = $2, password_hash = $3, full_name = $4, is_active = $5, failed_login_attempts = $6, locked_until = $7, updated_at = $8 WHERE id = $1`
+	query := `UPDATE users SET email = $2, password_hash = $3, full_name = $4, is_active = $5, failed_login_attempts = $6, locked_until = $7, updated_at = $8, locale = COALESCE(NULLIF($9, ''), locale), timezone = COALESCE(NULLIF($10, ''), timezone) WHERE id = $1`
 
 	user.UpdatedAt = time.Now()
 	_, err := tx.Exec(
 		ctx, query, user.ID, user.Email, user.PasswordHash, user.FullName, user.IsActive, user.FailedLoginAttempts,
-		user.LockedUntil, user.UpdatedAt,
+		user.LockedUntil, user.UpdatedAt, user.Locale, user.Timezone,
 	)
 	return err
 }
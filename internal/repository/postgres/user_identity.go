@@ -0,0 +1,163 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+type UserIdentityRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewUserIdentityRepository(db *pgxpool.Pool) *UserIdentityRepository {
+	return &UserIdentityRepository{db: db}
+}
+
+func (r *UserIdentityRepository) Create(ctx context.Context, identity *model.UserIdentity) error {
+	query := `
+		INSERT INTO user_identities (user_id, provider, subject, email, last_login_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+	return r.db.QueryRow(ctx, query,
+		identity.UserID, identity.Provider, identity.Subject, identity.Email, identity.LastLoginAt,
+	).Scan(&identity.ID, &identity.CreatedAt)
+}
+
+// GetByProviderSubject returns nil if the account is not linked to any user.
+func (r *UserIdentityRepository) GetByProviderSubject(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	query := `
+		SELECT id, user_id, provider, subject, email, last_login_at, created_at
+		FROM user_identities
+		WHERE provider = $1 AND subject = $2`
+
+	var identity model.UserIdentity
+	err := r.db.QueryRow(ctx, query, provider, subject).Scan(
+		&identity.ID,
+		&identity.UserID,
+		&identity.Provider,
+		&identity.Subject,
+		&identity.Email,
+		&identity.LastLoginAt,
+		&identity.CreatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *UserIdentityRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*model.UserIdentity, error) {
+	query := `
+		SELECT id, user_id, provider, subject, email, last_login_at, created_at
+		FROM user_identities
+		WHERE user_id = $1
+		ORDER BY created_at`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var identities []*model.UserIdentity
+	for rows.Next() {
+		var identity model.UserIdentity
+		if err := rows.Scan(
+			&identity.ID,
+			&identity.UserID,
+			&identity.Provider,
+			&identity.Subject,
+			&identity.Email,
+			&identity.LastLoginAt,
+			&identity.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		identities = append(identities, &identity)
+	}
+	return identities, rows.Err()
+}
+
+// UpdateLogin records a login and the email the provider currently reports.
+func (r *UserIdentityRepository) UpdateLogin(ctx context.Context, id uuid.UUID, email *string) error {
+	query := `UPDATE user_identities SET email = $2, last_login_at = NOW() WHERE id = $1`
+	_, err := r.db.Exec(ctx, query, id, email)
+	return err
+}
+
+func (r *UserIdentityRepository) Delete(ctx context.Context, id, userID uuid.UUID) (bool, error) {
+	query := `DELETE FROM user_identities WHERE id = $1 AND user_id = $2`
+	result, err := r.db.Exec(ctx, query, id, userID)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+type SocialAuthStateRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSocialAuthStateRepository(db *pgxpool.Pool) *SocialAuthStateRepository {
+	return &SocialAuthStateRepository{db: db}
+}
+
+func (r *SocialAuthStateRepository) Create(ctx context.Context, state *model.SocialAuthState) error {
+	query := `
+		INSERT INTO social_auth_states (state_hash, provider, code_verifier, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+	return r.db.QueryRow(ctx, query,
+		state.StateHash, state.Provider, state.CodeVerifier, state.ExpiresAt,
+	).Scan(&state.ID, &state.CreatedAt)
+}
+
+// GetByStateHash returns nil if the state does not exist.
+func (r *SocialAuthStateRepository) GetByStateHash(ctx context.Context, stateHash string) (*model.SocialAuthState, error) {
+	query := `
+		SELECT id, state_hash, provider, code_verifier, expires_at, used_at, created_at
+		FROM social_auth_states
+		WHERE state_hash = $1`
+
+	var state model.SocialAuthState
+	err := r.db.QueryRow(ctx, query, stateHash).Scan(
+		&state.ID,
+		&state.StateHash,
+		&state.Provider,
+		&state.CodeVerifier,
+		&state.ExpiresAt,
+		&state.UsedAt,
+		&state.CreatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &state, nil
+}
+
+// MarkAsUsed consumes the state. It returns false if it was already used.
+func (r *SocialAuthStateRepository) MarkAsUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `UPDATE social_auth_states SET used_at = NOW() WHERE id = $1 AND used_at IS NULL`
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *SocialAuthStateRepository) DeleteExpired(ctx context.Context) error {
+	query := `DELETE FROM social_auth_states WHERE expires_at < NOW() - INTERVAL '1 day'`
+	_, err := r.db.Exec(ctx, query)
+	return err
+}
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+// newTestDB connects to the migrated database in TEST_DATABASE_URL and skips
+// the test if it is not set.
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("Skipping database test in short mode")
+	}
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	db, err := New(databaseURL)
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+	return db
+}
+
+func TestUserRepo_CreateWithMembership(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	users := NewUserRepo(db)
+	orgs := NewOrganizationRepo(db)
+
+	suffix := time.Now().UnixNano()
+	owner := &model.User{Email: fmt.Sprintf("owner-%d@example.com", suffix), FullName: "Owner", IsActive: true}
+	require.NoError(t, users.Create(ctx, owner))
+	org := &model.Organization{Name: "Acme", OwnerUserID: owner.ID, Status: string(model.OrgStatusActive)}
+	require.NoError(t, orgs.Create(ctx, org))
+	t.Cleanup(func() {
+		_, _ = db.Pool().Exec(ctx, `DELETE FROM organizations WHERE id = $1`, org.ID)
+		_, _ = db.Pool().Exec(ctx, `DELETE FROM users WHERE email LIKE $1`, fmt.Sprintf("%%-%d@example.com", suffix))
+	})
+
+	user := &model.User{Email: fmt.Sprintf("member-%d@example.com", suffix), FullName: "Member", IsActive: true}
+	membership := &model.OrgMembership{OrgID: org.ID, Role: model.RoleMember, IsActive: true}
+	require.NoError(t, users.CreateWithMembership(ctx, user, membership))
+	assert.NotEmpty(t, user.ID)
+	assert.Equal(t, "en", user.Locale)
+	assert.Equal(t, "UTC", user.Timezone)
+	assert.Equal(t, user.ID, membership.UserID)
+	assert.NotEmpty(t, membership.ID)
+
+	stored, err := users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user.Email, stored.Email)
+}
@@ -0,0 +1,182 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type WebAuthnCredentialRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWebAuthnCredentialRepository(db *pgxpool.Pool) *WebAuthnCredentialRepository {
+	return &WebAuthnCredentialRepository{db: db}
+}
+
+func (r *WebAuthnCredentialRepository) Create(ctx context.Context, cred *model.WebAuthnCredential) error {
+	query := `
+		INSERT INTO webauthn_credentials (user_id, credential_id, public_key, algorithm, sign_count, aaguid, transports, name)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at`
+
+	transports := cred.Transports
+	if transports == nil {
+		transports = []string{}
+	}
+
+	return r.db.QueryRow(ctx, query,
+		cred.UserID,
+		cred.CredentialID,
+		cred.PublicKey,
+		cred.Algorithm,
+		cred.SignCount,
+		cred.AAGUID,
+		transports,
+		cred.Name,
+	).Scan(&cred.ID, &cred.CreatedAt)
+}
+
+func (r *WebAuthnCredentialRepository) GetByCredentialID(ctx context.Context, credentialID []byte) (*model.WebAuthnCredential, error) {
+	query := `
+		SELECT id, user_id, credential_id, public_key, algorithm, sign_count, aaguid, transports, name, last_used_at, created_at
+		FROM webauthn_credentials
+		WHERE credential_id = $1`
+
+	var cred model.WebAuthnCredential
+	err := r.db.QueryRow(ctx, query, credentialID).Scan(
+		&cred.ID,
+		&cred.UserID,
+		&cred.CredentialID,
+		&cred.PublicKey,
+		&cred.Algorithm,
+		&cred.SignCount,
+		&cred.AAGUID,
+		&cred.Transports,
+		&cred.Name,
+		&cred.LastUsedAt,
+		&cred.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &cred, nil
+}
+
+func (r *WebAuthnCredentialRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*model.WebAuthnCredential, error) {
+	query := `
+		SELECT id, user_id, credential_id, public_key, algorithm, sign_count, aaguid, transports, name, last_used_at, created_at
+		FROM webauthn_credentials
+		WHERE user_id = $1
+		ORDER BY created_at`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var creds []*model.WebAuthnCredential
+	for rows.Next() {
+		var cred model.WebAuthnCredential
+		if err := rows.Scan(
+			&cred.ID,
+			&cred.UserID,
+			&cred.CredentialID,
+			&cred.PublicKey,
+			&cred.Algorithm,
+			&cred.SignCount,
+			&cred.AAGUID,
+			&cred.Transports,
+			&cred.Name,
+			&cred.LastUsedAt,
+			&cred.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		creds = append(creds, &cred)
+	}
+	return creds, rows.Err()
+}
+
+// UpdateSignCount stores the authenticator's counter after a successful login.
+// It returns false if the counter went backwards concurrently.
+func (r *WebAuthnCredentialRepository) UpdateSignCount(ctx context.Context, id uuid.UUID, signCount int64) (bool, error) {
+	query := `
+		UPDATE webauthn_credentials
+		SET sign_count = $2, last_used_at = NOW()
+		WHERE id = $1 AND (sign_count < $2 OR $2 = 0)`
+	result, err := r.db.Exec(ctx, query, id, signCount)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// Delete removes a credential owned by the user. It returns false if no such
+// credential exists.
+func (r *WebAuthnCredentialRepository) Delete(ctx context.Context, id, userID uuid.UUID) (bool, error) {
+	query := `DELETE FROM webauthn_credentials WHERE id = $1 AND user_id = $2`
+	result, err := r.db.Exec(ctx, query, id, userID)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+type WebAuthnChallengeRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewWebAuthnChallengeRepository(db *pgxpool.Pool) *WebAuthnChallengeRepository {
+	return &WebAuthnChallengeRepository{db: db}
+}
+
+func (r *WebAuthnChallengeRepository) Create(ctx context.Context, challenge *model.WebAuthnChallenge) error {
+	query := `
+		INSERT INTO webauthn_challenges (user_id, challenge_hash, ceremony, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+	return r.db.QueryRow(ctx, query, challenge.UserID, challenge.ChallengeHash, challenge.Ceremony, challenge.ExpiresAt).
+		Scan(&challenge.ID, &challenge.CreatedAt)
+}
+
+func (r *WebAuthnChallengeRepository) GetByChallengeHash(ctx context.Context, challengeHash string) (*model.WebAuthnChallenge, error) {
+	query := `
+		SELECT id, user_id, challenge_hash, ceremony, expires_at, used_at, created_at
+		FROM webauthn_challenges
+		WHERE challenge_hash = $1`
+
+	var challenge model.WebAuthnChallenge
+	err := r.db.QueryRow(ctx, query, challengeHash).Scan(
+		&challenge.ID,
+		&challenge.UserID,
+		&challenge.ChallengeHash,
+		&challenge.Ceremony,
+		&challenge.ExpiresAt,
+		&challenge.UsedAt,
+		&challenge.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+// MarkAsUsed consumes the challenge. It returns false if it was already used.
+func (r *WebAuthnChallengeRepository) MarkAsUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `UPDATE webauthn_challenges SET used_at = NOW() WHERE id = $1 AND used_at IS NULL`
+	result, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+func (r *WebAuthnChallengeRepository) DeleteExpired(ctx context.Context) error {
+	query := `DELETE FROM webauthn_challenges WHERE expires_at < NOW() - INTERVAL '1 day'`
+	_, err := r.db.Exec(ctx, query)
+	return err
+}
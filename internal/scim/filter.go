@@ -0,0 +1,494 @@
+package scim
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Filter is a parsed SCIM filter expression (RFC 7644 section 3.4.2.2). It
+// is evaluated against the JSON representation of a resource; attribute
+// names and string values are compared case-insensitively.
+type Filter interface {
+	Match(resource map[string]interface{}) bool
+}
+
+// ParseFilter parses a filter such as
+// `userName eq "jane@example.com" and emails[type eq "work"]`.
+func ParseFilter(filter string) (Filter, error) {
+	p, err := newParser(filter)
+	if err != nil {
+		return nil, err
+	}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, p.errorf("unexpected %q", p.peek().text)
+	}
+	return f, nil
+}
+
+// Path is a PATCH operation target: an attribute, optionally narrowed to the
+// entries of a multi-valued attribute matching Filter, and a sub-attribute.
+type Path struct {
+	Attr   string
+	Filter Filter
+	Sub    string
+}
+
+// ParsePath parses a PATCH path such as `name.givenName` or
+// `members[value eq "2819c223"]`.
+func ParsePath(path string) (*Path, error) {
+	p, err := newParser(path)
+	if err != nil {
+		return nil, BadRequest(ErrTypeInvalidPath, err.(*Error).Detail)
+	}
+
+	tok := p.next()
+	if tok.kind != tokenWord {
+		return nil, BadRequest(ErrTypeInvalidPath, "invalid path")
+	}
+	attr, sub := splitAttrPath(tok.text)
+	result := &Path{Attr: attr, Sub: sub}
+
+	if p.peek().kind == tokenOpenBracket {
+		if sub != "" {
+			return nil, BadRequest(ErrTypeInvalidPath, "invalid path")
+		}
+		p.next()
+		if result.Filter, err = p.parseOr(); err != nil {
+			return nil, BadRequest(ErrTypeInvalidPath, err.(*Error).Detail)
+		}
+		if p.next().kind != tokenCloseBracket {
+			return nil, BadRequest(ErrTypeInvalidPath, "missing ]")
+		}
+		if tok := p.peek(); tok.kind == tokenWord && strings.HasPrefix(tok.text, ".") && len(tok.text) > 1 {
+			result.Sub = tok.text[1:]
+			p.next()
+		}
+	}
+	if !p.done() || result.Attr == "" {
+		return nil, BadRequest(ErrTypeInvalidPath, "invalid path")
+	}
+	return result, nil
+}
+
+// splitAttrPath strips a schema URN and splits "name.givenName".
+func splitAttrPath(path string) (string, string) {
+	if strings.HasPrefix(strings.ToLower(path), "urn:") {
+		path = path[strings.LastIndex(path, ":")+1:]
+	}
+	attr, sub, _ := strings.Cut(path, ".")
+	return attr, sub
+}
+
+type logicalFilter struct {
+	and         bool
+	left, right Filter
+}
+
+func (f *logicalFilter) Match(resource map[string]interface{}) bool {
+	if f.and {
+		return f.left.Match(resource) && f.right.Match(resource)
+	}
+	return f.left.Match(resource) || f.right.Match(resource)
+}
+
+type notFilter struct {
+	filter Filter
+}
+
+func (f *notFilter) Match(resource map[string]interface{}) bool {
+	return !f.filter.Match(resource)
+}
+
+type presentFilter struct {
+	attr, sub string
+}
+
+func (f *presentFilter) Match(resource map[string]interface{}) bool {
+	for _, value := range Values(resource, f.attr, f.sub) {
+		if s, ok := value.(string); !ok || s != "" {
+			return true
+		}
+	}
+	return false
+}
+
+type compareFilter struct {
+	attr, sub string
+	op        string
+	value     interface{}
+}
+
+func (f *compareFilter) Match(resource map[string]interface{}) bool {
+	values := Values(resource, f.attr, f.sub)
+	if f.op == "ne" {
+		for _, value := range values {
+			if compare(value, "eq", f.value) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, value := range values {
+		if compare(value, f.op, f.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// valueFilter matches resources with an entry of a multi-valued attribute
+// that matches the inner filter, as in emails[type eq "work"].
+type valueFilter struct {
+	attr   string
+	filter Filter
+}
+
+func (f *valueFilter) Match(resource map[string]interface{}) bool {
+	entries, _ := Get(resource, f.attr).([]interface{})
+	for _, entry := range entries {
+		if m, ok := entry.(map[string]interface{}); ok && f.filter.Match(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns the attribute of a resource, matching the name
+// case-insensitively.
+func Get(resource map[string]interface{}, attr string) interface{} {
+	if value, ok := resource[attr]; ok {
+		return value
+	}
+	for key, value := range resource {
+		if strings.EqualFold(key, attr) {
+			return value
+		}
+	}
+	return nil
+}
+
+// Values returns the values an attribute path refers to. Complex
+// multi-valued attributes without a sub-attribute refer to their "value".
+func Values(resource map[string]interface{}, attr, sub string) []interface{} {
+	value := Get(resource, attr)
+	if value == nil {
+		return nil
+	}
+
+	var values []interface{}
+	collect := func(v interface{}) {
+		if m, ok := v.(map[string]interface{}); ok {
+			key := sub
+			if key == "" {
+				key = "value"
+			}
+			if inner := Get(m, key); inner != nil {
+				values = append(values, inner)
+			}
+			return
+		}
+		if sub == "" {
+			values = append(values, v)
+		}
+	}
+
+	if entries, ok := value.([]interface{}); ok {
+		for _, entry := range entries {
+			collect(entry)
+		}
+		return values
+	}
+	if sub == "" {
+		if _, ok := value.(map[string]interface{}); !ok {
+			return []interface{}{value}
+		}
+	}
+	collect(value)
+	return values
+}
+
+func compare(actual interface{}, op string, expected interface{}) bool {
+	switch a := actual.(type) {
+	case string:
+		e, ok := expected.(string)
+		if !ok {
+			return false
+		}
+		a, e = strings.ToLower(a), strings.ToLower(e)
+		switch op {
+		case "eq":
+			return a == e
+		case "co":
+			return strings.Contains(a, e)
+		case "sw":
+			return strings.HasPrefix(a, e)
+		case "ew":
+			return strings.HasSuffix(a, e)
+		case "gt":
+			return a > e
+		case "ge":
+			return a >= e
+		case "lt":
+			return a < e
+		case "le":
+			return a <= e
+		}
+	case bool:
+		e, ok := expected.(bool)
+		if s, isString := expected.(string); isString {
+			e, ok = parseBool(s)
+		}
+		return ok && op == "eq" && a == e
+	case float64:
+		e, ok := expected.(float64)
+		if !ok {
+			return false
+		}
+		switch op {
+		case "eq":
+			return a == e
+		case "gt":
+			return a > e
+		case "ge":
+			return a >= e
+		case "lt":
+			return a < e
+		case "le":
+			return a <= e
+		}
+	}
+	return false
+}
+
+func equalFold(a, b string) bool {
+	return strings.EqualFold(a, b)
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOpenParen
+	tokenCloseParen
+	tokenOpenBracket
+	tokenCloseBracket
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func newParser(input string) (*parser, error) {
+	p := &parser{}
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			p.tokens = append(p.tokens, token{tokenOpenParen, "("})
+			i++
+		case r == ')':
+			p.tokens = append(p.tokens, token{tokenCloseParen, ")"})
+			i++
+		case r == '[':
+			p.tokens = append(p.tokens, token{tokenOpenBracket, "["})
+			i++
+		case r == ']':
+			p.tokens = append(p.tokens, token{tokenCloseBracket, "]"})
+			i++
+		case r == '"':
+			j := i + 1
+			for j < len(runes) && runes[j] != '"' {
+				if runes[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(runes) {
+				return nil, p.errorf("unterminated string")
+			}
+			text, err := strconv.Unquote(string(runes[i : j+1]))
+			if err != nil {
+				return nil, p.errorf("invalid string %s", string(runes[i:j+1]))
+			}
+			p.tokens = append(p.tokens, token{tokenString, text})
+			i = j + 1
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) && !strings.ContainsRune(`()[]"`, runes[j]) {
+				j++
+			}
+			p.tokens = append(p.tokens, token{tokenWord, string(runes[i:j])})
+			i = j
+		}
+	}
+	return p, nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return BadRequest(ErrTypeInvalidFilter, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) peek() token {
+	if p.done() {
+		return token{kind: tokenEOF}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.peek()
+	if !p.done() {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) keyword(word string) bool {
+	tok := p.peek()
+	return tok.kind == tokenWord && strings.EqualFold(tok.text, word)
+}
+
+func (p *parser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalFilter{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Filter, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalFilter{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Filter, error) {
+	negate := false
+	if p.keyword("not") {
+		p.next()
+		negate = true
+		if p.peek().kind != tokenOpenParen {
+			return nil, p.errorf("expected ( after not")
+		}
+	}
+
+	var f Filter
+	var err error
+	if p.peek().kind == tokenOpenParen {
+		p.next()
+		if f, err = p.parseOr(); err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokenCloseParen {
+			return nil, p.errorf("missing )")
+		}
+	} else if f, err = p.parseAttrExpr(); err != nil {
+		return nil, err
+	}
+
+	if negate {
+		return &notFilter{filter: f}, nil
+	}
+	return f, nil
+}
+
+func (p *parser) parseAttrExpr() (Filter, error) {
+	tok := p.next()
+	if tok.kind != tokenWord {
+		return nil, p.errorf("expected attribute, got %q", tok.text)
+	}
+	attr, sub := splitAttrPath(tok.text)
+
+	if p.peek().kind == tokenOpenBracket {
+		if sub != "" {
+			return nil, p.errorf("invalid attribute %q", tok.text)
+		}
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokenCloseBracket {
+			return nil, p.errorf("missing ]")
+		}
+		return &valueFilter{attr: attr, filter: inner}, nil
+	}
+
+	opTok := p.next()
+	if opTok.kind != tokenWord {
+		return nil, p.errorf("expected operator after %q", tok.text)
+	}
+	op := strings.ToLower(opTok.text)
+	if op == "pr" {
+		return &presentFilter{attr: attr, sub: sub}, nil
+	}
+	switch op {
+	case "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le":
+	default:
+		return nil, p.errorf("unknown operator %q", opTok.text)
+	}
+
+	valueTok := p.next()
+	var value interface{}
+	switch valueTok.kind {
+	case tokenString:
+		value = valueTok.text
+	case tokenWord:
+		switch strings.ToLower(valueTok.text) {
+		case "true":
+			value = true
+		case "false":
+			value = false
+		case "null":
+			value = nil
+		default:
+			n, err := strconv.ParseFloat(valueTok.text, 64)
+			if err != nil {
+				return nil, p.errorf("invalid value %q", valueTok.text)
+			}
+			value = n
+		}
+	default:
+		return nil, p.errorf("expected value after %q", opTok.text)
+	}
+	return &compareFilter{attr: attr, sub: sub, op: op, value: value}, nil
+}
@@ -0,0 +1,95 @@
+package scim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testUser() map[string]interface{} {
+	return map[string]interface{}{
+		"schemas":    []interface{}{SchemaUser},
+		"id":         "2819c223",
+		"userName":   "Jane@Example.com",
+		"externalId": "00u1",
+		"active":     true,
+		"name":       map[string]interface{}{"givenName": "Jane", "familyName": "Doe"},
+		"emails": []interface{}{
+			map[string]interface{}{"value": "jane@example.com", "type": "work", "primary": true},
+			map[string]interface{}{"value": "jane@home.test", "type": "home"},
+		},
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	user := testUser()
+	cases := map[string]bool{
+		`userName eq "jane@example.com"`:                             true,
+		`USERNAME Eq "JANE@EXAMPLE.COM"`:                             true,
+		`userName eq "john@example.com"`:                             false,
+		`userName ne "john@example.com"`:                             true,
+		`externalId eq "00u1" and active eq true`:                    true,
+		`externalId eq "00u1" and active eq false`:                   false,
+		`externalId eq "x" or name.givenName sw "ja"`:                true,
+		`not (userName co "example")`:                                false,
+		`emails co "home.test"`:                                      true,
+		`emails[type eq "work" and value ew "example.com"]`:          true,
+		`emails[type eq "other"]`:                                    false,
+		`emails.type eq "home"`:                                      true,
+		`title pr`:                                                   false,
+		`name.familyName pr`:                                         true,
+		`urn:ietf:params:scim:schemas:core:2.0:User:userName sw "j"`: true,
+		`(id eq "1" or id eq "2819c223") and not (active eq false)`:  true,
+		`meta.created gt "2020-01-01"`:                               false,
+	}
+	for input, expected := range cases {
+		f, err := ParseFilter(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, f.Match(user), input)
+	}
+}
+
+func TestParseFilter_Invalid(t *testing.T) {
+	for _, input := range []string{
+		``,
+		`userName`,
+		`userName eq`,
+		`userName like "x"`,
+		`userName eq "unterminated`,
+		`(userName eq "x"`,
+		`emails[type eq "work"`,
+		`userName eq "x" extra`,
+		`not userName eq "x"`,
+	} {
+		_, err := ParseFilter(input)
+		var scimErr *Error
+		require.ErrorAs(t, err, &scimErr, input)
+		assert.Equal(t, ErrTypeInvalidFilter, scimErr.ScimType, input)
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	path, err := ParsePath("name.givenName")
+	require.NoError(t, err)
+	assert.Equal(t, "name", path.Attr)
+	assert.Equal(t, "givenName", path.Sub)
+	assert.Nil(t, path.Filter)
+
+	path, err = ParsePath(`emails[type eq "work"].value`)
+	require.NoError(t, err)
+	assert.Equal(t, "emails", path.Attr)
+	assert.Equal(t, "value", path.Sub)
+	require.NotNil(t, path.Filter)
+
+	path, err = ParsePath("urn:ietf:params:scim:schemas:core:2.0:User:active")
+	require.NoError(t, err)
+	assert.Equal(t, "active", path.Attr)
+
+	for _, input := range []string{"", `members[value eq "1"`, "name.givenName[x eq 1]", "a b"} {
+		_, err := ParsePath(input)
+		var scimErr *Error
+		require.ErrorAs(t, err, &scimErr, input)
+		assert.Equal(t, ErrTypeInvalidPath, scimErr.ScimType, input)
+	}
+}
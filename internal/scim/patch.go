@@ -0,0 +1,259 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// ApplyPatch applies PATCH operations (RFC 7644 section 3.5.2) to the
+// generic representation of a resource.
+func ApplyPatch(resource map[string]interface{}, ops []PatchOperation) error {
+	for _, op := range ops {
+		var value interface{}
+		if len(op.Value) > 0 {
+			if err := json.Unmarshal(op.Value, &value); err != nil {
+				return BadRequest(ErrTypeInvalidValue, "invalid operation value")
+			}
+		}
+
+		kind := strings.ToLower(op.Op)
+		switch kind {
+		case "add", "replace":
+			if op.Path == "" {
+				attrs, ok := value.(map[string]interface{})
+				if !ok {
+					return BadRequest(ErrTypeInvalidValue, "operation without path needs an object value")
+				}
+				for attr, v := range attrs {
+					path, err := ParsePath(attr)
+					if err != nil {
+						return err
+					}
+					if err := applyPath(resource, kind, path, v); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+		case "remove":
+			if op.Path == "" {
+				return BadRequest(ErrTypeNoTarget, "remove needs a path")
+			}
+		default:
+			return BadRequest(ErrTypeInvalidSyntax, "unknown operation "+op.Op)
+		}
+
+		path, err := ParsePath(op.Path)
+		if err != nil {
+			return err
+		}
+		if err := applyPath(resource, kind, path, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyPath(resource map[string]interface{}, op string, path *Path, value interface{}) error {
+	if path.Filter != nil {
+		return applyFiltered(resource, op, path, value)
+	}
+
+	current := Get(resource, path.Attr)
+	if path.Sub != "" {
+		switch target := current.(type) {
+		case []interface{}:
+			for _, entry := range target {
+				if m, ok := entry.(map[string]interface{}); ok {
+					setOrDelete(m, op, path.Sub, value)
+				}
+			}
+		case map[string]interface{}:
+			setOrDelete(target, op, path.Sub, value)
+		case nil:
+			if op != "remove" {
+				setKey(resource, path.Attr, map[string]interface{}{path.Sub: value})
+			}
+		default:
+			return BadRequest(ErrTypeInvalidPath, path.Attr+" has no sub-attributes")
+		}
+		return nil
+	}
+
+	switch op {
+	case "remove":
+		entries, isList := current.([]interface{})
+		if isList && value != nil {
+			// Some clients name the values to remove in the operation
+			// instead of a filter.
+			setKey(resource, path.Attr, withoutValues(entries, toList(value)))
+			return nil
+		}
+		deleteKey(resource, path.Attr)
+	case "add":
+		switch target := current.(type) {
+		case []interface{}:
+			setKey(resource, path.Attr, appendValues(target, toList(value)))
+		case map[string]interface{}:
+			if m, ok := value.(map[string]interface{}); ok {
+				for k, v := range m {
+					setKey(target, k, v)
+				}
+				return nil
+			}
+			setKey(resource, path.Attr, value)
+		default:
+			setKey(resource, path.Attr, value)
+		}
+	default:
+		setKey(resource, path.Attr, value)
+	}
+	return nil
+}
+
+// applyFiltered applies an operation to the entries of a multi-valued
+// attribute selected by a value filter.
+func applyFiltered(resource map[string]interface{}, op string, path *Path, value interface{}) error {
+	entries, _ := Get(resource, path.Attr).([]interface{})
+
+	var matched []map[string]interface{}
+	kept := make([]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		m, ok := entry.(map[string]interface{})
+		if ok && path.Filter.Match(m) {
+			matched = append(matched, m)
+			if op == "remove" && path.Sub == "" {
+				continue
+			}
+		}
+		kept = append(kept, entry)
+	}
+
+	if op == "remove" {
+		if path.Sub == "" {
+			setKey(resource, path.Attr, kept)
+			return nil
+		}
+		for _, m := range matched {
+			deleteKey(m, path.Sub)
+		}
+		return nil
+	}
+
+	if len(matched) == 0 {
+		// Azure AD adds entries such as emails[type eq "work"].value this
+		// way; only simple equality filters say what the new entry is.
+		f, ok := path.Filter.(*compareFilter)
+		if !ok || f.op != "eq" || f.sub != "" {
+			return NewError(http.StatusBadRequest, ErrTypeNoTarget, "no value matches the filter")
+		}
+		entry := map[string]interface{}{f.attr: f.value}
+		matched = append(matched, entry)
+		setKey(resource, path.Attr, append(entries, entry))
+	}
+
+	for _, m := range matched {
+		if path.Sub != "" {
+			setKey(m, path.Sub, value)
+			continue
+		}
+		attrs, ok := value.(map[string]interface{})
+		if !ok {
+			return BadRequest(ErrTypeInvalidValue, "value must be an object")
+		}
+		if op == "replace" {
+			for k := range m {
+				delete(m, k)
+			}
+		}
+		for k, v := range attrs {
+			setKey(m, k, v)
+		}
+	}
+	return nil
+}
+
+func setOrDelete(m map[string]interface{}, op, key string, value interface{}) {
+	if op == "remove" {
+		deleteKey(m, key)
+		return
+	}
+	setKey(m, key, value)
+}
+
+// setKey sets an attribute, keeping the spelling of an existing key.
+func setKey(m map[string]interface{}, key string, value interface{}) {
+	for k := range m {
+		if strings.EqualFold(k, key) {
+			m[k] = value
+			return
+		}
+	}
+	m[key] = value
+}
+
+func deleteKey(m map[string]interface{}, key string) {
+	for k := range m {
+		if strings.EqualFold(k, key) {
+			delete(m, k)
+		}
+	}
+}
+
+func toList(value interface{}) []interface{} {
+	if list, ok := value.([]interface{}); ok {
+		return list
+	}
+	if value == nil {
+		return nil
+	}
+	return []interface{}{value}
+}
+
+// entryValue returns the "value" of a multi-valued attribute entry.
+func entryValue(entry interface{}) interface{} {
+	if m, ok := entry.(map[string]interface{}); ok {
+		return Get(m, "value")
+	}
+	return entry
+}
+
+func sameValue(a, b interface{}) bool {
+	as, aok := a.(string)
+	bs, bok := b.(string)
+	if aok && bok {
+		return strings.EqualFold(as, bs)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func containsValue(entries []interface{}, entry interface{}) bool {
+	v := entryValue(entry)
+	for _, existing := range entries {
+		if sameValue(entryValue(existing), v) {
+			return true
+		}
+	}
+	return false
+}
+
+func appendValues(entries, values []interface{}) []interface{} {
+	for _, value := range values {
+		if !containsValue(entries, value) {
+			entries = append(entries, value)
+		}
+	}
+	return entries
+}
+
+func withoutValues(entries, values []interface{}) []interface{} {
+	kept := make([]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		if !containsValue(values, entry) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,110 @@
+package scim
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func patchOps(t *testing.T, ops string) []PatchOperation {
+	t.Helper()
+	var req PatchRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"Operations":`+ops+`}`), &req))
+	return req.Operations
+}
+
+func TestApplyPatch(t *testing.T) {
+	t.Run("replace without path", func(t *testing.T) {
+		user := testUser()
+		err := ApplyPatch(user, patchOps(t, `[{"op":"Replace","value":{"active":false,"name.givenName":"Janet"}}]`))
+		require.NoError(t, err)
+		assert.Equal(t, false, user["active"])
+		assert.Equal(t, "Janet", user["name"].(map[string]interface{})["givenName"])
+		assert.Equal(t, "Doe", user["name"].(map[string]interface{})["familyName"])
+	})
+
+	t.Run("replace attribute with different case", func(t *testing.T) {
+		user := testUser()
+		require.NoError(t, ApplyPatch(user, patchOps(t, `[{"op":"replace","path":"ACTIVE","value":"False"}]`)))
+		assert.Equal(t, "False", user["active"])
+		_, ok := user["ACTIVE"]
+		assert.False(t, ok)
+
+		var decoded User
+		require.NoError(t, FromMap(user, &decoded))
+		require.NotNil(t, decoded.Active)
+		assert.False(t, *decoded.Active)
+	})
+
+	t.Run("filtered sub-attribute", func(t *testing.T) {
+		user := testUser()
+		require.NoError(t, ApplyPatch(user, patchOps(t, `[{"op":"replace","path":"emails[type eq \"work\"].value","value":"j.doe@example.com"}]`)))
+		var decoded User
+		require.NoError(t, FromMap(user, &decoded))
+		assert.Equal(t, "j.doe@example.com", decoded.PrimaryEmail())
+		assert.Len(t, decoded.Emails, 2)
+	})
+
+	t.Run("filtered add creates the entry", func(t *testing.T) {
+		user := map[string]interface{}{"userName": "jane@example.com"}
+		require.NoError(t, ApplyPatch(user, patchOps(t, `[{"op":"add","path":"emails[type eq \"work\"].value","value":"jane@example.com"}]`)))
+		var decoded User
+		require.NoError(t, FromMap(user, &decoded))
+		require.Len(t, decoded.Emails, 1)
+		assert.Equal(t, "work", decoded.Emails[0].Type)
+		assert.Equal(t, "jane@example.com", decoded.Emails[0].Value)
+	})
+
+	t.Run("boolean sent as string", func(t *testing.T) {
+		user := map[string]interface{}{"roles": []interface{}{map[string]interface{}{"value": "MEMBER", "primary": true}}}
+		require.NoError(t, ApplyPatch(user, patchOps(t, `[{"op":"Add","path":"roles[primary eq \"True\"].value","value":"ADMIN"}]`)))
+		var decoded User
+		require.NoError(t, FromMap(user, &decoded))
+		require.Len(t, decoded.Roles, 1)
+		assert.Equal(t, "ADMIN", decoded.Roles[0].Value)
+		assert.True(t, decoded.Roles[0].Primary)
+	})
+
+	t.Run("replace without match", func(t *testing.T) {
+		err := ApplyPatch(testUser(), patchOps(t, `[{"op":"replace","path":"emails[type sw \"x\"].value","value":"a"}]`))
+		var scimErr *Error
+		require.ErrorAs(t, err, &scimErr)
+		assert.Equal(t, ErrTypeNoTarget, scimErr.ScimType)
+	})
+
+	t.Run("members", func(t *testing.T) {
+		group := map[string]interface{}{
+			"displayName": "admin",
+			"members":     []interface{}{map[string]interface{}{"value": "a"}, map[string]interface{}{"value": "b"}},
+		}
+		ops := patchOps(t, `[
+			{"op":"add","path":"members","value":[{"value":"b"},{"value":"c"}]},
+			{"op":"remove","path":"members[value eq \"a\"]"},
+			{"op":"remove","path":"members","value":[{"value":"c"}]}
+		]`)
+		require.NoError(t, ApplyPatch(group, ops))
+		var decoded Group
+		require.NoError(t, FromMap(group, &decoded))
+		require.Len(t, decoded.Members, 1)
+		assert.Equal(t, "b", decoded.Members[0].Value)
+
+		require.NoError(t, ApplyPatch(group, patchOps(t, `[{"op":"remove","path":"members"}]`)))
+		_, ok := group["members"]
+		assert.False(t, ok)
+	})
+
+	t.Run("invalid operations", func(t *testing.T) {
+		for _, ops := range []string{
+			`[{"op":"move","path":"active"}]`,
+			`[{"op":"remove"}]`,
+			`[{"op":"add","value":"x"}]`,
+			`[{"op":"add","path":"userName.x","value":"x"}]`,
+		} {
+			err := ApplyPatch(testUser(), patchOps(t, ops))
+			var scimErr *Error
+			assert.ErrorAs(t, err, &scimErr, ops)
+		}
+	})
+}
@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+const (
+	defaultAdminSearchLimit = 50
+	maxAdminSearchLimit     = 100
+)
+
+type AdminUserRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*model.User, error)
+	Update(ctx context.Context, user *model.User) error
+	Search(ctx context.Context, query string, limit, offset int) ([]*model.User, error)
+}
+
+type AdminMembershipRepository interface {
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*model.OrgMembership, error)
+}
+
+type AdminSessionRepository interface {
+	GetActiveByUserID(ctx context.Context, userID uuid.UUID) ([]*model.RefreshToken, error)
+	RevokeByUserID(ctx context.Context, userID uuid.UUID) error
+}
+
+// AdminAccountDeletionRepository keeps pending account deletions consistent
+// with admin deactivation and restore.
+type AdminAccountDeletionRepository interface {
+	KeepDeactivated(ctx context.Context, userID uuid.UUID) error
+	CancelByUserID(ctx context.Context, userID uuid.UUID) (bool, error)
+}
+
+// PasswordResetRequester sends a password reset link to the account with the
+// email, implemented by PasswordResetService.
+type PasswordResetRequester interface {
+	RequestPasswordReset(ctx context.Context, email, ipAddress, userAgent string) (string, error)
+}
+
+// AdminUserDetails is a user as shown to platform admins.
+type AdminUserDetails struct {
+	User        *model.User
+	Memberships []*model.OrgMembership
+	Sessions    []*model.RefreshToken
+}
+
+// AdminService lets platform admins manage user accounts across
+// organizations. Every call is recorded in the audit log under the acting
+// admin. Platform admin accounts cannot be locked, reset or deactivated
+// through it, so that one compromised admin session cannot lock out the
+// others; the flag itself is only granted in the database.
+type AdminService struct {
+	userRepo       AdminUserRepository
+	membershipRepo AdminMembershipRepository
+	sessionRepo    AdminSessionRepository
+	deletionRepo   AdminAccountDeletionRepository
+	tokenRevoker   TokenRevoker
+	passwordReset  PasswordResetRequester
+	auditService   *AuditService
+}
+
+func NewAdminService(
+	userRepo AdminUserRepository,
+	membershipRepo AdminMembershipRepository,
+	sessionRepo AdminSessionRepository,
+	deletionRepo AdminAccountDeletionRepository,
+	tokenRevoker TokenRevoker,
+	passwordReset PasswordResetRequester,
+	auditService *AuditService,
+) *AdminService {
+	return &AdminService{
+		userRepo:       userRepo,
+		membershipRepo: membershipRepo,
+		sessionRepo:    sessionRepo,
+		deletionRepo:   deletionRepo,
+		tokenRevoker:   tokenRevoker,
+		passwordReset:  passwordReset,
+		auditService:   auditService,
+	}
+}
+
+// IsPlatformAdmin reports whether the user is an active platform admin. It
+// is checked on every admin request, so revoking the flag takes effect
+// immediately.
+func (s *AdminService) IsPlatformAdmin(ctx context.Context, userID uuid.UUID) (bool, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user.IsActive && user.IsPlatformAdmin, nil
+}
+
+// SearchUsers returns the users whose email or name contains query.
+func (s *AdminService) SearchUsers(ctx context.Context, adminID uuid.UUID, query string, limit, offset int, ipAddress, userAgent string) ([]*model.User, error) {
+	if limit <= 0 {
+		limit = defaultAdminSearchLimit
+	}
+	if limit > maxAdminSearchLimit {
+		limit = maxAdminSearchLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	query = strings.TrimSpace(query)
+
+	users, err := s.userRepo.Search(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search users: %w", err)
+	}
+
+	s.audit(ctx, adminID, model.EventAdminUsersSearched, map[string]interface{}{
+		"query":   query,
+		"results": len(users),
+	}, ipAddress, userAgent)
+	return users, nil
+}
+
+// GetUser returns the user with their memberships and active sessions.
+func (s *AdminService) GetUser(ctx context.Context, adminID, userID uuid.UUID, ipAddress, userAgent string) (*AdminUserDetails, error) {
+	user, err := s.user(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships, err := s.membershipRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get memberships: %w", err)
+	}
+	sessions, err := s.sessionRepo.GetActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	s.audit(ctx, adminID, model.EventAdminUserViewed, targetData(userID), ipAddress, userAgent)
+	return &AdminUserDetails{User: user, Memberships: memberships, Sessions: sessions}, nil
+}
+
+// LockUser blocks sign-in until the given time and signs the user out.
+func (s *AdminService) LockUser(ctx context.Context, adminID, userID uuid.UUID, until time.Time, ipAddress, userAgent string) (*model.User, error) {
+	if !until.After(time.Now()) {
+		return nil, errors.ErrInvalidInput
+	}
+	user, err := s.unprotectedUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.LockedUntil = &until
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	if err := s.endSessions(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	data := targetData(userID)
+	data["locked_until"] = until.UTC().Format(time.RFC3339)
+	s.audit(ctx, adminID, model.EventAdminUserLocked, data, ipAddress, userAgent)
+	return user, nil
+}
+
+// UnlockUser lifts a lock set by an admin or by failed login attempts.
+func (s *AdminService) UnlockUser(ctx context.Context, adminID, userID uuid.UUID, ipAddress, userAgent string) (*model.User, error) {
+	user, err := s.user(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.LockedUntil = nil
+	user.FailedLoginAttempts = 0
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	s.audit(ctx, adminID, model.EventAdminUserUnlocked, targetData(userID), ipAddress, userAgent)
+	return user, nil
+}
+
+// ForcePasswordReset invalidates the password, signs the user out and emails
+// a reset link. The user cannot sign in with a password until they set a new
+// one.
+func (s *AdminService) ForcePasswordReset(ctx context.Context, adminID, userID uuid.UUID, ipAddress, userAgent string) error {
+	user, err := s.unprotectedUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	user.PasswordHash = ""
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if err := s.endSessions(ctx, userID); err != nil {
+		return err
+	}
+	if s.passwordReset != nil {
+		if _, err := s.passwordReset.RequestPasswordReset(ctx, user.Email, ipAddress, userAgent); err != nil {
+			return err
+		}
+	}
+
+	s.audit(ctx, adminID, model.EventAdminPasswordResetForced, targetData(userID), ipAddress, userAgent)
+	return nil
+}
+
+// RevokeSessions signs the user out of every session.
+func (s *AdminService) RevokeSessions(ctx context.Context, adminID, userID uuid.UUID, ipAddress, userAgent string) error {
+	if _, err := s.user(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.endSessions(ctx, userID); err != nil {
+		return err
+	}
+
+	s.audit(ctx, adminID, model.EventAdminSessionsRevoked, targetData(userID), ipAddress, userAgent)
+	return nil
+}
+
+// DeactivateUser disables the account and ends all of its sessions at once,
+// including access tokens that have not expired yet, so nobody holding them
+// can keep acting as the user. If the user has requested deletion,
+// cancelling it later keeps the account deactivated.
+func (s *AdminService) DeactivateUser(ctx context.Context, adminID, userID uuid.UUID, ipAddress, userAgent string) (*model.User, error) {
+	user, err := s.unprotectedUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.IsActive = false
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	if err := s.deletionRepo.KeepDeactivated(ctx, userID); err != nil {
+		return nil, fmt.Errorf("failed to update account deletion request: %w", err)
+	}
+	if err := s.endSessions(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, adminID, model.EventAdminUserDeactivated, targetData(userID), ipAddress, userAgent)
+	return user, nil
+}
+
+// RestoreUser reactivates a deactivated account and lifts any lock. A pending
+// deletion request is cancelled, so that cleanup does not purge the restored
+// account.
+func (s *AdminService) RestoreUser(ctx context.Context, adminID, userID uuid.UUID, ipAddress, userAgent string) (*model.User, error) {
+	user, err := s.user(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cancelled, err := s.deletionRepo.CancelByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel account deletion: %w", err)
+	}
+
+	user.IsActive = true
+	user.LockedUntil = nil
+	user.FailedLoginAttempts = 0
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	data := targetData(userID)
+	if cancelled {
+		data["deletion_cancelled"] = true
+	}
+	s.audit(ctx, adminID, model.EventAdminUserRestored, data, ipAddress, userAgent)
+	return user, nil
+}
+
+func (s *AdminService) user(ctx context.Context, userID uuid.UUID) (*model.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// unprotectedUser returns the user if it is not a platform admin, which
+// includes the acting admin.
+func (s *AdminService) unprotectedUser(ctx context.Context, userID uuid.UUID) (*model.User, error) {
+	user, err := s.user(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsPlatformAdmin {
+		return nil, errors.ErrPlatformAdminProtected
+	}
+	return user, nil
+}
+
+func (s *AdminService) endSessions(ctx context.Context, userID uuid.UUID) error {
+	if err := s.sessionRepo.RevokeByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	if s.tokenRevoker != nil {
+		if err := s.tokenRevoker.RevokeUser(ctx, userID); err != nil {
+			return fmt.Errorf("failed to revoke access tokens: %w", err)
+		}
+	}
+	return nil
+}
+
+func targetData(userID uuid.UUID) map[string]interface{} {
+	return map[string]interface{}{"target_user_id": userID.String()}
+}
+
+func (s *AdminService) audit(ctx context.Context, adminID uuid.UUID, eventType model.AuditEventType, data map[string]interface{}, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	if err := s.auditService.Log(ctx, &adminID, eventType, data, ipAddress, userAgent); err != nil {
+		log.Error().Err(err).Str("event", string(eventType)).Msg("Failed to log admin audit event")
+	}
+}
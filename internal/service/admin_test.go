@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+type memoryAdminUserRepo struct{ memorySCIMUserRepo }
+
+func (r memoryAdminUserRepo) Search(_ context.Context, query string, limit, offset int) ([]*model.User, error) {
+	var users []*model.User
+	for _, user := range r.users {
+		if strings.Contains(user.Email, query) {
+			copied := *user
+			users = append(users, &copied)
+		}
+	}
+	if offset >= len(users) {
+		return nil, nil
+	}
+	users = users[offset:]
+	return users[:min(limit, len(users))], nil
+}
+
+type memoryAdminMembershipRepo struct{ *memoryDirectory }
+
+func (r memoryAdminMembershipRepo) GetByUserID(_ context.Context, userID uuid.UUID) ([]*model.OrgMembership, error) {
+	var memberships []*model.OrgMembership
+	for _, membership := range r.memberships {
+		if membership.UserID == userID {
+			memberships = append(memberships, membership)
+		}
+	}
+	return memberships, nil
+}
+
+type memoryAdminSessionRepo struct {
+	revoked []uuid.UUID
+}
+
+func (r *memoryAdminSessionRepo) GetActiveByUserID(context.Context, uuid.UUID) ([]*model.RefreshToken, error) {
+	return nil, nil
+}
+
+func (r *memoryAdminSessionRepo) RevokeByUserID(_ context.Context, userID uuid.UUID) error {
+	r.revoked = append(r.revoked, userID)
+	return nil
+}
+
+type recordingPasswordReset struct {
+	emails []string
+}
+
+func (r *recordingPasswordReset) RequestPasswordReset(_ context.Context, email, _, _ string) (string, error) {
+	r.emails = append(r.emails, email)
+	return "reset-token", nil
+}
+
+type adminTestDeps struct {
+	directory     *memoryDirectory
+	sessions      *memoryAdminSessionRepo
+	deletions     *memoryAccountDeletionRepo
+	revoker       *recordingRevoker
+	passwordReset *recordingPasswordReset
+	admin         *model.User
+	user          *model.User
+}
+
+func newTestAdminService() (*AdminService, *adminTestDeps) {
+	deps := &adminTestDeps{
+		directory:     &memoryDirectory{users: map[uuid.UUID]*model.User{}},
+		sessions:      &memoryAdminSessionRepo{},
+		deletions:     newMemoryAccountDeletionRepo(),
+		revoker:       &recordingRevoker{},
+		passwordReset: &recordingPasswordReset{},
+	}
+	deps.admin = deps.directory.addUser("admin@zeno.test")
+	deps.admin.IsPlatformAdmin = true
+	deps.user = deps.directory.addUser("jane@example.com")
+	deps.user.PasswordHash = "hash"
+
+	svc := NewAdminService(
+		memoryAdminUserRepo{memorySCIMUserRepo{deps.directory}}, memoryAdminMembershipRepo{deps.directory},
+		deps.sessions, deps.deletions, deps.revoker, deps.passwordReset, nil,
+	)
+	return svc, deps
+}
+
+func TestAdminService_IsPlatformAdmin(t *testing.T) {
+	ctx := context.Background()
+	svc, deps := newTestAdminService()
+
+	admin, err := svc.IsPlatformAdmin(ctx, deps.admin.ID)
+	require.NoError(t, err)
+	assert.True(t, admin)
+
+	admin, err = svc.IsPlatformAdmin(ctx, deps.user.ID)
+	require.NoError(t, err)
+	assert.False(t, admin)
+
+	admin, err = svc.IsPlatformAdmin(ctx, uuid.New())
+	require.NoError(t, err)
+	assert.False(t, admin)
+
+	deps.directory.users[deps.admin.ID].IsActive = false
+	admin, err = svc.IsPlatformAdmin(ctx, deps.admin.ID)
+	require.NoError(t, err)
+	assert.False(t, admin)
+}
+
+func TestAdminService_LockAndUnlock(t *testing.T) {
+	ctx := context.Background()
+	svc, deps := newTestAdminService()
+	until := time.Now().Add(time.Hour)
+
+	_, err := svc.LockUser(ctx, deps.admin.ID, deps.user.ID, time.Now().Add(-time.Minute), "", "")
+	assert.ErrorIs(t, err, appErrors.ErrInvalidInput)
+
+	user, err := svc.LockUser(ctx, deps.admin.ID, deps.user.ID, until, "", "")
+	require.NoError(t, err)
+	require.NotNil(t, user.LockedUntil)
+	assert.True(t, deps.directory.users[deps.user.ID].LockedUntil.Equal(until))
+	assert.Equal(t, []uuid.UUID{deps.user.ID}, deps.sessions.revoked)
+	assert.Equal(t, []uuid.UUID{deps.user.ID}, deps.revoker.users)
+
+	deps.directory.users[deps.user.ID].FailedLoginAttempts = 5
+	user, err = svc.UnlockUser(ctx, deps.admin.ID, deps.user.ID, "", "")
+	require.NoError(t, err)
+	assert.Nil(t, user.LockedUntil)
+	assert.Zero(t, deps.directory.users[deps.user.ID].FailedLoginAttempts)
+
+	_, err = svc.LockUser(ctx, deps.admin.ID, uuid.New(), until, "", "")
+	assert.ErrorIs(t, err, appErrors.ErrUserNotFound)
+}
+
+func TestAdminService_ForcePasswordReset(t *testing.T) {
+	ctx := context.Background()
+	svc, deps := newTestAdminService()
+
+	require.NoError(t, svc.ForcePasswordReset(ctx, deps.admin.ID, deps.user.ID, "", ""))
+	assert.Empty(t, deps.directory.users[deps.user.ID].PasswordHash)
+	assert.Equal(t, []string{deps.user.Email}, deps.passwordReset.emails)
+	assert.Equal(t, []uuid.UUID{deps.user.ID}, deps.revoker.users)
+}
+
+func TestAdminService_DeactivateAndRestore(t *testing.T) {
+	ctx := context.Background()
+	svc, deps := newTestAdminService()
+
+	user, err := svc.DeactivateUser(ctx, deps.admin.ID, deps.user.ID, "", "")
+	require.NoError(t, err)
+	assert.False(t, user.IsActive)
+	assert.False(t, deps.directory.users[deps.user.ID].IsActive)
+	assert.Equal(t, []uuid.UUID{deps.user.ID}, deps.sessions.revoked)
+	assert.Equal(t, []uuid.UUID{deps.user.ID}, deps.revoker.users)
+
+	user, err = svc.RestoreUser(ctx, deps.admin.ID, deps.user.ID, "", "")
+	require.NoError(t, err)
+	assert.True(t, user.IsActive)
+	assert.True(t, deps.directory.users[deps.user.ID].IsActive)
+}
+
+func TestAdminService_DeactivateAndRestoreWithPendingDeletion(t *testing.T) {
+	ctx := context.Background()
+	svc, deps := newTestAdminService()
+	deps.deletions.requests[deps.user.ID] = &model.AccountDeletionRequest{
+		UserID: deps.user.ID, PurgeAfter: time.Now().Add(time.Hour), RestoreActive: true,
+	}
+
+	// Cancelling the deletion must not undo the deactivation
+	_, err := svc.DeactivateUser(ctx, deps.admin.ID, deps.user.ID, "", "")
+	require.NoError(t, err)
+	require.Contains(t, deps.deletions.requests, deps.user.ID)
+	assert.False(t, deps.deletions.requests[deps.user.ID].RestoreActive)
+
+	// Cleanup must not purge the restored account
+	_, err = svc.RestoreUser(ctx, deps.admin.ID, deps.user.ID, "", "")
+	require.NoError(t, err)
+	assert.NotContains(t, deps.deletions.requests, deps.user.ID)
+	assert.True(t, deps.directory.users[deps.user.ID].IsActive)
+}
+
+func TestAdminService_PlatformAdminsAreProtected(t *testing.T) {
+	ctx := context.Background()
+	svc, deps := newTestAdminService()
+	other := deps.directory.addUser("other-admin@zeno.test")
+	other.IsPlatformAdmin = true
+
+	for _, target := range []uuid.UUID{deps.admin.ID, other.ID} {
+		_, err := svc.LockUser(ctx, deps.admin.ID, target, time.Now().Add(time.Hour), "", "")
+		assert.ErrorIs(t, err, appErrors.ErrPlatformAdminProtected)
+		err = svc.ForcePasswordReset(ctx, deps.admin.ID, target, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrPlatformAdminProtected)
+		_, err = svc.DeactivateUser(ctx, deps.admin.ID, target, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrPlatformAdminProtected)
+	}
+	assert.True(t, deps.directory.users[other.ID].IsActive)
+	assert.Empty(t, deps.revoker.users)
+
+	// Revoking sessions stays possible, e.g. after an admin's device is lost
+	require.NoError(t, svc.RevokeSessions(ctx, deps.admin.ID, other.ID, "", ""))
+	assert.Equal(t, []uuid.UUID{other.ID}, deps.revoker.users)
+}
+
+func TestAdminService_SearchUsers(t *testing.T) {
+	ctx := context.Background()
+	svc, deps := newTestAdminService()
+
+	users, err := svc.SearchUsers(ctx, deps.admin.ID, " jane ", 0, 0, "", "")
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, deps.user.ID, users[0].ID)
+
+	details, err := svc.GetUser(ctx, deps.admin.ID, deps.user.ID, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, deps.user.Email, details.User.Email)
+
+	_, err = svc.GetUser(ctx, deps.admin.ID, uuid.New(), "", "")
+	assert.ErrorIs(t, err, appErrors.ErrUserNotFound)
+}
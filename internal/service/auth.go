@@ -22,18 +22,36 @@ type BillingClient interface {
 	CreateTrialSubscription(ctx context.Context, orgID uuid.UUID) error
 }
 
+// LoginResult is returned by the login and refresh flows. When MFARequired is
+// set no tokens are issued and MFAToken must be exchanged via CompleteMFALogin.
+type LoginResult struct {
+	UserID       uuid.UUID
+	AccessToken  string
+	RefreshToken string
+	MFARequired  bool
+	MFAToken     string
+}
+
 type AuthService struct {
-	userRepo        repository.UserRepository
-	orgRepo         repository.OrganizationRepository
-	membershipRepo  repository.MembershipRepository
-	refreshRepo     repository.RefreshTokenRepository
-	jwtManager      *token.JWTManager
-	refreshManager  *token.RefreshManager
-	passwordManager token.PasswordHasher
-	emailService    *EmailService
-	billingClient   BillingClient
-	config          *Config
-	db              *postgres.DB
+	userRepo         repository.UserRepository
+	orgRepo          repository.OrganizationRepository
+	membershipRepo   repository.MembershipRepository
+	refreshRepo      repository.RefreshTokenRepository
+	jwtManager       *token.JWTManager
+	refreshManager   *token.RefreshManager
+	passwordManager  token.PasswordHasher
+	emailService     *EmailService
+	mfaService       *MFAService
+	passkeyService   *PasskeyService
+	socialService    *SocialService
+	samlService      *SAMLService
+	domainService    *DomainService
+	magicLinkService *MagicLinkService
+	auditService     *AuditService
+	tokenRevoker     TokenRevoker
+	billingClient    BillingClient
+	config           *Config
+	db               *postgres.DB
 }
 
 func NewAuthService(
@@ -45,22 +63,38 @@ func NewAuthService(
 	refreshManager *token.RefreshManager,
 	passwordManager token.PasswordHasher,
 	emailService *EmailService,
+	mfaService *MFAService,
+	passkeyService *PasskeyService,
+	socialService *SocialService,
+	samlService *SAMLService,
+	domainService *DomainService,
+	magicLinkService *MagicLinkService,
+	auditService *AuditService,
+	tokenRevoker TokenRevoker,
 	billingClient BillingClient,
 	config *Config,
 	db *postgres.DB,
 ) *AuthService {
 	return &AuthService{
-		userRepo:        userRepo,
-		orgRepo:         orgRepo,
-		membershipRepo:  membershipRepo,
-		refreshRepo:     refreshRepo,
-		jwtManager:      jwtManager,
-		refreshManager:  refreshManager,
-		passwordManager: passwordManager,
-		emailService:    emailService,
-		billingClient:   billingClient,
-		config:          config,
-		db:              db,
+		userRepo:         userRepo,
+		orgRepo:          orgRepo,
+		membershipRepo:   membershipRepo,
+		refreshRepo:      refreshRepo,
+		jwtManager:       jwtManager,
+		refreshManager:   refreshManager,
+		passwordManager:  passwordManager,
+		emailService:     emailService,
+		mfaService:       mfaService,
+		passkeyService:   passkeyService,
+		socialService:    socialService,
+		samlService:      samlService,
+		domainService:    domainService,
+		magicLinkService: magicLinkService,
+		auditService:     auditService,
+		tokenRevoker:     tokenRevoker,
+		billingClient:    billingClient,
+		config:           config,
+		db:               db,
 	}
 }
 
@@ -90,10 +124,31 @@ func (s *AuthService) Register(ctx context.Context, email, password, fullName, o
 		return nil, err
 	}
 
+	user := &model.User{
+		Email:        email,
+		PasswordHash: passwordHash,
+		FullName:     fullName,
+		IsActive:     false, // User must verify email first
+	}
+	if err := s.createAccount(ctx, user, organizationName); err != nil {
+		return nil, err
+	}
+
+	// Send email verification (outside transaction)
+	if s.emailService != nil {
+		_, _ = s.emailService.SendVerificationEmail(ctx, user.ID)
+		// Errors are logged internally, don't fail registration
+	}
+
+	return user, nil
+}
+
+// createAccount creates the user together with an organization they own.
+func (s *AuthService) createAccount(ctx context.Context, user *model.User, organizationName string) error {
 	// Start transaction for atomic registration
 	tx, err := s.db.BeginTx(ctx)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer func() {
 		if r := recover(); r != nil {
@@ -102,17 +157,10 @@ func (s *AuthService) Register(ctx context.Context, email, password, fullName, o
 		}
 	}()
 
-	user := &model.User{
-		Email:        email,
-		PasswordHash: passwordHash,
-		FullName:     fullName,
-		IsActive:     false, // User must verify email first
-	}
-
 	// Create user
 	if err := s.userRepo.CreateTx(ctx, tx, user); err != nil {
 		_ = tx.Rollback(ctx)
-		return nil, err
+		return err
 	}
 
 	// Create organization for user
@@ -124,7 +172,7 @@ func (s *AuthService) Register(ctx context.Context, email, password, fullName, o
 
 	if err := s.orgRepo.CreateTx(ctx, tx, org); err != nil {
 		_ = tx.Rollback(ctx)
-		return nil, err
+		return err
 	}
 
 	// Create membership with OWNER role
@@ -137,12 +185,12 @@ func (s *AuthService) Register(ctx context.Context, email, password, fullName, o
 
 	if err := s.membershipRepo.CreateTx(ctx, tx, membership); err != nil {
 		_ = tx.Rollback(ctx)
-		return nil, err
+		return err
 	}
 
 	// Commit transaction
 	if err := tx.Commit(ctx); err != nil {
-		return nil, err
+		return err
 	}
 
 	// Create trial subscription in billing service (async, don't fail registration)
@@ -155,38 +203,35 @@ func (s *AuthService) Register(ctx context.Context, email, password, fullName, o
 		}()
 	}
 
-	// Send email verification (outside transaction)
-	if s.emailService != nil {
-		_, _ = s.emailService.SendVerificationEmail(ctx, user.ID)
-		// Errors are logged internally, don't fail registration
-	}
-
-	return user, nil
+	return nil
 }
 
-func (s *AuthService) Login(ctx context.Context, email, password, userAgent, ipAddress string) (string, string, error) {
+// Login verifies a password and issues tokens scoped to one of the user's
+// organizations: orgID if set, otherwise the oldest active membership.
+func (s *AuthService) Login(ctx context.Context, email, password string, orgID uuid.UUID, userAgent, ipAddress string) (*LoginResult, error) {
 	email = strings.ToLower(strings.TrimSpace(email))
 
 	user, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
 		if stdErrors.Is(err, pgx.ErrNoRows) {
-			return "", "", appErrors.ErrInvalidCredentials
+			return nil, appErrors.ErrInvalidCredentials
 		}
-		return "", "", err
+		return nil, err
 	}
 
-	if !user.IsActive {
-		return "", "", appErrors.ErrInvalidCredentials
+	// Accounts created through social login have no password
+	if !user.IsActive || user.PasswordHash == "" {
+		return nil, appErrors.ErrInvalidCredentials
 	}
 
 	// LockedUntil
 	if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
-		return "", "", appErrors.ErrInvalidCredentials
+		return nil, appErrors.ErrInvalidCredentials
 	}
 
 	valid, err := s.passwordManager.Verify(ctx, password, user.PasswordHash)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 	// Update user state based on login result
 	needsUpdate := false
@@ -214,88 +259,696 @@ func (s *AuthService) Login(ctx context.Context, email, password, userAgent, ipA
 	}
 
 	if !valid {
-		return "", "", appErrors.ErrInvalidCredentials
+		return nil, appErrors.ErrInvalidCredentials
+	}
+
+	// Checked only once the password is verified so that sso_required does
+	// not reveal to anyone without credentials whether the account exists or
+	// belongs to an owner
+	if err := s.checkSSOEnforcement(ctx, user); err != nil {
+		return nil, err
+	}
+
+	membership, err := s.loginMembership(ctx, user.ID, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Users with MFA enabled must complete a second factor before tokens are issued
+	if result, err := s.mfaChallenge(ctx, user.ID, orgID); err != nil || result != nil {
+		return result, err
 	}
 
-	// Get user's first active membership (includes org and role)
-	memberships, err := s.membershipRepo.GetByUserID(ctx, user.ID)
+	return s.issueTokens(ctx, user.ID, membership, []string{token.AMRPassword}, userAgent, ipAddress)
+}
+
+// loginMembership picks the membership the tokens are scoped to: the one in
+// orgID if set, otherwise the oldest active membership.
+func (s *AuthService) loginMembership(ctx context.Context, userID, orgID uuid.UUID) (*model.OrgMembership, error) {
+	memberships, err := s.membershipRepo.GetByUserID(ctx, userID)
 	if err != nil || len(memberships) == 0 {
-		log.Error().Err(err).Str("user_id", user.ID.String()).Msg("User has no active memberships")
-		return "", "", appErrors.ErrInvalidCredentials
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("User has no active memberships")
+		return nil, appErrors.ErrInvalidCredentials
+	}
+	if orgID == uuid.Nil {
+		return memberships[0], nil
+	}
+	for _, membership := range memberships {
+		if membership.OrgID == orgID {
+			return membership, nil
+		}
+	}
+	return nil, appErrors.ErrNotOrgMember
+}
+
+// SwitchOrganization ends the current session and starts a new one scoped to
+// another organization the user is an active member of. The new session keeps
+// the authentication methods (amr) of the current one.
+func (s *AuthService) SwitchOrganization(ctx context.Context, userID, sessionID, orgID uuid.UUID, amr []string, userAgent, ipAddress string) (*LoginResult, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return nil, appErrors.ErrUnauthorized
+		}
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, appErrors.ErrUnauthorized
+	}
+
+	membership, err := s.membershipRepo.GetByUserAndOrg(ctx, userID, orgID)
+	if err != nil && !stdErrors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	if membership == nil || !membership.IsActive {
+		return nil, appErrors.ErrNotOrgMember
+	}
+
+	result, err := s.issueTokens(ctx, userID, membership, amr, userAgent, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	// The previous tokens stay scoped to the old organization, so they must
+	// not outlive the switch
+	if sessionID != uuid.Nil {
+		if err := s.refreshRepo.RevokeFamily(ctx, sessionID); err != nil {
+			log.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to revoke previous session")
+		}
+		if s.tokenRevoker != nil {
+			if err := s.tokenRevoker.RevokeSession(ctx, sessionID); err != nil {
+				log.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to revoke previous access tokens")
+			}
+		}
+	}
+
+	if s.auditService != nil {
+		data := map[string]interface{}{"org_id": orgID.String()}
+		if err := s.auditService.Log(ctx, &userID, model.EventOrganizationSwitched, data, ipAddress, userAgent); err != nil {
+			log.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to log organization switch")
+		}
+	}
+
+	return result, nil
+}
+
+// CompleteSocialLogin finishes a login at an upstream identity provider. A
+// new account is created when no user has the verified email yet. Like a
+// password, the provider is a first factor: users with MFA enabled get a
+// challenge instead of tokens.
+func (s *AuthService) CompleteSocialLogin(ctx context.Context, provider, code, state, userAgent, ipAddress string) (*LoginResult, error) {
+	if s.socialService == nil {
+		return nil, appErrors.ErrSocialProviderNotFound
+	}
+
+	profile, err := s.socialService.Authenticate(ctx, provider, code, state)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := s.socialService.ResolveUser(ctx, profile, ipAddress, userAgent)
+	if stdErrors.Is(err, appErrors.ErrUserNotFound) {
+		// Accounts at domains that enforce SSO are provisioned by the IdP
+		enforcement, enforcementErr := s.ssoEnforcement(ctx, profile.Email)
+		if enforcementErr != nil {
+			return nil, enforcementErr
+		}
+		if enforcement != nil {
+			return nil, appErrors.ErrSSORequired
+		}
+		userID, err = s.registerSocialUser(ctx, profile, ipAddress, userAgent)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return nil, appErrors.ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if !user.IsActive || (user.LockedUntil != nil && user.LockedUntil.After(time.Now())) {
+		return nil, appErrors.ErrInvalidCredentials
+	}
+	if err := s.checkSSOEnforcement(ctx, user); err != nil {
+		return nil, err
+	}
+
+	if result, err := s.mfaChallenge(ctx, user.ID, uuid.Nil); err != nil || result != nil {
+		return result, err
+	}
+
+	return s.completeLogin(ctx, user.ID, uuid.Nil, []string{token.AMRFederated}, userAgent, ipAddress)
+}
+
+// registerSocialUser creates an account without a password for a profile
+// whose email the provider verified, so no verification email is sent.
+func (s *AuthService) registerSocialUser(ctx context.Context, profile *SocialProfile, ipAddress, userAgent string) (uuid.UUID, error) {
+	fullName := profile.Name
+	if fullName == "" {
+		fullName = strings.SplitN(profile.Email, "@", 2)[0]
+	}
+
+	user := &model.User{
+		Email:    profile.Email,
+		FullName: fullName,
+		IsActive: true,
+	}
+	if err := s.createAccount(ctx, user, fullName); err != nil {
+		return uuid.Nil, err
+	}
+
+	if s.auditService != nil {
+		if err := s.auditService.Log(ctx, &user.ID, model.EventUserRegistered, map[string]interface{}{
+			"email":    user.Email,
+			"provider": profile.Provider,
+		}, ipAddress, userAgent); err != nil {
+			log.Error().Err(err).Str("user_id", user.ID.String()).Msg("Failed to log registration audit event")
+		}
 	}
 
-	// Use first active membership
-	membership := memberships[0]
+	if err := s.socialService.Link(ctx, user.ID, profile, ipAddress, userAgent); err != nil {
+		return uuid.Nil, err
+	}
+	return user.ID, nil
+}
+
+// CompleteSAMLResponse verifies a response posted by an organization's IdP
+// and returns a one-time code for CompleteSAMLLogin. Users the IdP asserts
+// whose email is not registered yet are provisioned as members of the
+// organization if the email is at one of its verified domains.
+func (s *AuthService) CompleteSAMLResponse(ctx context.Context, orgID uuid.UUID, samlResponse, userAgent, ipAddress string) (string, error) {
+	if s.samlService == nil {
+		return "", appErrors.ErrSAMLNotConfigured
+	}
+
+	profile, err := s.samlService.ConsumeResponse(ctx, orgID, samlResponse)
+	if err != nil {
+		return "", err
+	}
+
+	userID, err := s.samlService.ResolveUser(ctx, profile, ipAddress, userAgent)
+	if stdErrors.Is(err, appErrors.ErrUserNotFound) {
+		userID, err = s.registerSAMLUser(ctx, profile, ipAddress, userAgent)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return s.samlService.CreateLoginCode(ctx, userID, profile.OrgID)
+}
+
+// registerSAMLUser creates an account without a password that is a member of
+// the organization with the connection's default role. Only emails at a
+// verified domain of the organization are provisioned; otherwise its IdP
+// could claim any address and keep a login into the account once the owner
+// of the address signs up.
+func (s *AuthService) registerSAMLUser(ctx context.Context, profile *SAMLProfile, ipAddress, userAgent string) (uuid.UUID, error) {
+	if !s.samlService.ownsEmailDomain(ctx, profile) {
+		return uuid.Nil, appErrors.ErrSAMLUserNotMember
+	}
+
+	fullName := profile.Name
+	if fullName == "" {
+		fullName = strings.SplitN(profile.Email, "@", 2)[0]
+	}
+
+	user := &model.User{
+		Email:    profile.Email,
+		FullName: fullName,
+		IsActive: true,
+	}
+
+	tx, err := s.db.BeginTx(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if err := s.userRepo.CreateTx(ctx, tx, user); err != nil {
+		_ = tx.Rollback(ctx)
+		return uuid.Nil, err
+	}
+	membership := &model.OrgMembership{
+		UserID:   user.ID,
+		OrgID:    profile.OrgID,
+		Role:     profile.DefaultRole,
+		IsActive: true,
+	}
+	if err := s.membershipRepo.CreateTx(ctx, tx, membership); err != nil {
+		_ = tx.Rollback(ctx)
+		return uuid.Nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return uuid.Nil, err
+	}
+
+	if s.auditService != nil {
+		if err := s.auditService.Log(ctx, &user.ID, model.EventUserRegistered, map[string]interface{}{
+			"email":    user.Email,
+			"provider": profile.Provider,
+			"org_id":   profile.OrgID.String(),
+		}, ipAddress, userAgent); err != nil {
+			log.Error().Err(err).Str("user_id", user.ID.String()).Msg("Failed to log registration audit event")
+		}
+	}
+
+	if err := s.samlService.Link(ctx, user.ID, profile, ipAddress, userAgent); err != nil {
+		return uuid.Nil, err
+	}
+	return user.ID, nil
+}
+
+// CompleteSAMLLogin exchanges the code issued by CompleteSAMLResponse for
+// tokens scoped to the organization the user signed in through. As with
+// social login, users with MFA enabled get a challenge instead.
+func (s *AuthService) CompleteSAMLLogin(ctx context.Context, code, userAgent, ipAddress string) (*LoginResult, error) {
+	if s.samlService == nil {
+		return nil, appErrors.ErrSAMLLoginFailed
+	}
+
+	loginCode, err := s.samlService.RedeemLoginCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, loginCode.UserID)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return nil, appErrors.ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if !user.IsActive || (user.LockedUntil != nil && user.LockedUntil.After(time.Now())) {
+		return nil, appErrors.ErrInvalidCredentials
+	}
+
+	membership, err := s.membershipRepo.GetByUserAndOrg(ctx, user.ID, loginCode.OrgID)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return nil, appErrors.ErrSAMLUserNotMember
+		}
+		return nil, err
+	}
+	if !membership.IsActive {
+		return nil, appErrors.ErrSAMLUserNotMember
+	}
+
+	// The challenge keeps the organization of the IdP so that the tokens
+	// issued after the second factor are scoped to it
+	if result, err := s.mfaChallenge(ctx, user.ID, loginCode.OrgID); err != nil || result != nil {
+		return result, err
+	}
+
+	return s.issueTokens(ctx, user.ID, membership, []string{token.AMRFederated}, userAgent, ipAddress)
+}
+
+// mfaChallenge starts the second factor for users with MFA enabled. It
+// returns nil if tokens can be issued right away. The requested orgID is
+// kept with the challenge.
+func (s *AuthService) mfaChallenge(ctx context.Context, userID, orgID uuid.UUID) (*LoginResult, error) {
+	if s.mfaService == nil {
+		return nil, nil
+	}
+	enabled, err := s.mfaService.IsEnabled(ctx, userID)
+	if err != nil || !enabled {
+		return nil, err
+	}
+	mfaToken, err := s.mfaService.CreateChallenge(ctx, userID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{UserID: userID, MFARequired: true, MFAToken: mfaToken}, nil
+}
+
+// CompleteMFALogin finishes a login started by Login for a user with MFA
+// enabled, exchanging the challenge token and a TOTP or recovery code for
+// tokens scoped to the organization requested at login.
+func (s *AuthService) CompleteMFALogin(ctx context.Context, mfaToken, code, userAgent, ipAddress string) (*LoginResult, error) {
+	if s.mfaService == nil {
+		return nil, appErrors.ErrInvalidToken
+	}
+
+	challenge, err := s.mfaService.VerifyChallenge(ctx, mfaToken, code, ipAddress, userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	orgID := uuid.Nil
+	if challenge.OrgID != nil {
+		orgID = *challenge.OrgID
+	}
+	return s.completeLogin(ctx, challenge.UserID, orgID, []string{token.AMROTP, token.AMRMultiFactor}, userAgent, ipAddress)
+}
+
+// CompletePasskeyLogin verifies a passkey assertion and issues tokens. A
+// passkey requires user verification on the authenticator, so it satisfies
+// MFA on its own.
+func (s *AuthService) CompletePasskeyLogin(ctx context.Context, assertion *PasskeyAssertion, userAgent, ipAddress string) (*LoginResult, error) {
+	if s.passkeyService == nil {
+		return nil, appErrors.ErrInvalidCredentials
+	}
+
+	userID, err := s.passkeyService.FinishLogin(ctx, assertion)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return nil, appErrors.ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if err := s.checkSSOEnforcement(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return s.completeLogin(ctx, userID, uuid.Nil, []string{token.AMRHardwareKey, token.AMRMultiFactor}, userAgent, ipAddress)
+}
+
+// CompleteMagicLinkLogin redeems a link sent by MagicLinkService.RequestLink
+// and issues tokens scoped to the organization requested with it. Like a
+// password, the link is a single factor: users with MFA enabled get a
+// challenge instead, and SSO enforcement applies.
+func (s *AuthService) CompleteMagicLinkLogin(ctx context.Context, magicToken, userAgent, ipAddress string) (*LoginResult, error) {
+	if s.magicLinkService == nil {
+		return nil, appErrors.ErrInvalidMagicLink
+	}
+
+	link, err := s.magicLinkService.Redeem(ctx, magicToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, link.UserID)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return nil, appErrors.ErrInvalidMagicLink
+		}
+		return nil, err
+	}
+	if !user.IsActive || (user.LockedUntil != nil && user.LockedUntil.After(time.Now())) {
+		return nil, appErrors.ErrInvalidCredentials
+	}
+	if err := s.checkSSOEnforcement(ctx, user); err != nil {
+		return nil, err
+	}
+
+	orgID := uuid.Nil
+	if link.OrgID != nil {
+		orgID = *link.OrgID
+	}
+	if _, err := s.loginMembership(ctx, user.ID, orgID); err != nil {
+		return nil, err
+	}
+	if result, err := s.mfaChallenge(ctx, user.ID, orgID); err != nil || result != nil {
+		return result, err
+	}
+
+	return s.completeLogin(ctx, user.ID, orgID, []string{token.AMREmail}, userAgent, ipAddress)
+}
+
+// ssoEnforcement returns the domain claim that requires users with the email
+// to sign in through their organization's SSO, or nil.
+func (s *AuthService) ssoEnforcement(ctx context.Context, email string) (*model.OrganizationDomain, error) {
+	if s.domainService == nil {
+		return nil, nil
+	}
+	return s.domainService.SSOEnforcement(ctx, email)
+}
+
+// requireSSO rejects sign-in methods other than SSO while the domain enforces
+// it. Owners of the organization are exempt so that a misconfigured IdP
+// cannot lock the organization out.
+func (s *AuthService) requireSSO(ctx context.Context, enforcement *model.OrganizationDomain, userID uuid.UUID) error {
+	if enforcement == nil {
+		return nil
+	}
+	owner, err := s.domainService.IsOrgOwner(ctx, userID, enforcement.OrgID)
+	if err != nil {
+		return err
+	}
+	if !owner {
+		return appErrors.ErrSSORequired
+	}
+	return nil
+}
+
+func (s *AuthService) checkSSOEnforcement(ctx context.Context, user *model.User) error {
+	enforcement, err := s.ssoEnforcement(ctx, user.Email)
+	if err != nil {
+		return err
+	}
+	return s.requireSSO(ctx, enforcement, user.ID)
+}
+
+// completeLogin issues tokens for a user whose credentials were verified by
+// one of the login flows with the given methods, re-checking that the
+// account may still sign in.
+func (s *AuthService) completeLogin(ctx context.Context, userID, orgID uuid.UUID, amr []string, userAgent, ipAddress string) (*LoginResult, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return nil, appErrors.ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if !user.IsActive || (user.LockedUntil != nil && user.LockedUntil.After(time.Now())) {
+		return nil, appErrors.ErrInvalidCredentials
+	}
+
+	membership, err := s.loginMembership(ctx, user.ID, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(ctx, user.ID, membership, amr, userAgent, ipAddress)
+}
+
+// issueTokens creates an access token and a fingerprinted refresh token
+// session for the given membership, authenticated with the amr methods.
+func (s *AuthService) issueTokens(ctx context.Context, userID uuid.UUID, membership *model.OrgMembership, amr []string, userAgent, ipAddress string) (*LoginResult, error) {
 	orgID := membership.OrgID
 	roles := []string{string(membership.Role)}
+	permissions := permissionClaim(membership)
+
+	// The refresh token family doubles as the session id of access tokens
+	familyID := uuid.New()
 
-	accessToken, err := s.jwtManager.Generate(ctx, user.ID, orgID, roles, s.config.AccessTokenTTL)
+	params := token.AccessTokenParams{
+		UserID:      userID,
+		OrgID:       orgID,
+		SessionID:   familyID,
+		Roles:       roles,
+		Permissions: permissions,
+		AMR:         amr,
+		TTLSeconds:  s.config.AccessTokenTTL,
+	}
+	if err := s.orgStatusClaims(ctx, &params); err != nil {
+		return nil, err
+	}
+	accessToken, err := s.jwtManager.GenerateAccessToken(ctx, params)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
 	refreshTokenStr, err := s.refreshManager.Generate(ctx)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
 	// Generate fingerprint for session security
 	fingerprint, err := token.GenerateFingerprint(userAgent, ipAddress, "")
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
-	refreshToken, err := s.refreshManager.CreateToken(ctx, user.ID, orgID, refreshTokenStr, userAgent, ipAddress, s.config.RefreshTokenTTL)
+	refreshToken, err := s.refreshManager.CreateToken(ctx, userID, orgID, refreshTokenStr, userAgent, ipAddress, s.config.RefreshTokenTTL)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 	refreshToken.FingerprintHash = &fingerprint
+	refreshToken.FamilyID = familyID
+	refreshToken.AMR = amr
 	if err := s.refreshRepo.Create(ctx, refreshToken); err != nil {
-		return "", "", err
+		return nil, err
 	}
 
-	return accessToken, refreshTokenStr, nil
+	return &LoginResult{
+		UserID:       userID,
+		AccessToken:  accessToken,
+		RefreshToken: refreshTokenStr,
+	}, nil
+}
+
+// permissionClaim returns the permissions claim for the membership.
+func permissionClaim(membership *model.OrgMembership) []string {
+	permissions := membership.Permissions()
+	claim := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		claim = append(claim, string(permission))
+	}
+	return claim
 }
 
-func (s *AuthService) RefreshToken(ctx context.Context, refreshTokenStr, userAgent, ipAddress string) (string, error) {
+// orgStatusClaims fills the organization and subscription status claims from
+// the current state of the token's organization. Billing updates that state
+// through the internal API, which also revokes the access tokens issued
+// before the change so that clients refresh and pick it up.
+func (s *AuthService) orgStatusClaims(ctx context.Context, params *token.AccessTokenParams) error {
+	if s.orgRepo == nil || params.OrgID == uuid.Nil {
+		return nil
+	}
+
+	org, err := s.orgRepo.GetByID(ctx, params.OrgID)
+	if err != nil {
+		return err
+	}
+
+	// subscription_status stays unset: zeno-auth does not know the status of
+	// the billing subscription, only that of the organization
+	params.OrgStatus = org.Status
+	if org.TrialEndsAt != nil {
+		trialEndsAt := org.TrialEndsAt.Unix()
+		params.TrialEndsAt = &trialEndsAt
+	}
+	return nil
+}
+
+// RefreshToken exchanges a refresh token for a new access/refresh token pair.
+// The presented token is revoked and replaced by one in the same family;
+// presenting an already rotated token again means it was copied, so the whole
+// family is revoked and the caller has to log in again.
+func (s *AuthService) RefreshToken(ctx context.Context, refreshTokenStr, userAgent, ipAddress string) (*LoginResult, error) {
 	tokenHash, err := s.refreshManager.Hash(ctx, refreshTokenStr)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	refreshToken, err := s.refreshRepo.GetByTokenHash(ctx, tokenHash)
 	if err != nil {
 		if stdErrors.Is(err, pgx.ErrNoRows) {
-			return "", appErrors.ErrInvalidCredentials
+			return nil, appErrors.ErrInvalidCredentials
 		}
-		return "", err
+		return nil, err
+	}
+
+	if refreshToken.IsRotated() {
+		return nil, s.handleRefreshTokenReuse(ctx, refreshToken, userAgent, ipAddress)
 	}
 
 	if refreshToken.RevokedAt != nil || refreshToken.ExpiresAt.Before(time.Now()) {
-		return "", appErrors.ErrInvalidCredentials
+		return nil, appErrors.ErrInvalidCredentials
 	}
 
 	if refreshToken.FingerprintHash != nil && *refreshToken.FingerprintHash != "" {
 		currentFingerprint, err := token.GenerateFingerprint(userAgent, ipAddress, "")
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 		if currentFingerprint != *refreshToken.FingerprintHash {
-			return "", appErrors.ErrInvalidFingerprint
+			return nil, appErrors.ErrInvalidFingerprint
 		}
 	}
 
-	// Get roles if user has organization membership
-	var roles []string
+	// Get roles and permissions if user has organization membership
+	var roles, permissions []string
 	if refreshToken.OrgID != uuid.Nil {
 		if membership, err := s.membershipRepo.GetByUserAndOrg(ctx, refreshToken.UserID, refreshToken.OrgID); err == nil && membership != nil {
 			roles = []string{string(membership.Role)}
+			permissions = permissionClaim(membership)
 		}
 	}
-	return s.jwtManager.Generate(ctx, refreshToken.UserID, refreshToken.OrgID, roles, s.config.AccessTokenTTL)
+
+	params := token.AccessTokenParams{
+		UserID:      refreshToken.UserID,
+		OrgID:       refreshToken.OrgID,
+		SessionID:   refreshToken.FamilyID,
+		Roles:       roles,
+		Permissions: permissions,
+		AMR:         refreshToken.AMR,
+		TTLSeconds:  s.config.AccessTokenTTL,
+	}
+	if err := s.orgStatusClaims(ctx, &params); err != nil {
+		return nil, err
+	}
+
+	refreshTokenStr, err = s.refreshManager.Generate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fingerprint, err := token.GenerateFingerprint(userAgent, ipAddress, "")
+	if err != nil {
+		return nil, err
+	}
+	next, err := s.refreshManager.CreateToken(ctx, refreshToken.UserID, refreshToken.OrgID, refreshTokenStr, userAgent, ipAddress, s.config.RefreshTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	next.FingerprintHash = &fingerprint
+	next.FamilyID = refreshToken.FamilyID
+	next.AMR = refreshToken.AMR
+
+	rotated, err := s.refreshRepo.Rotate(ctx, refreshToken.ID, next)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// Lost a race against another request presenting the same token
+		return nil, appErrors.ErrInvalidCredentials
+	}
+
+	accessToken, err := s.jwtManager.GenerateAccessToken(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{
+		UserID:       refreshToken.UserID,
+		AccessToken:  accessToken,
+		RefreshToken: refreshTokenStr,
+	}, nil
 }
 
+func (s *AuthService) handleRefreshTokenReuse(ctx context.Context, refreshToken *model.RefreshToken, userAgent, ipAddress string) error {
+	if err := s.refreshRepo.RevokeFamily(ctx, refreshToken.FamilyID); err != nil {
+		return err
+	}
+	if s.tokenRevoker != nil {
+		if err := s.tokenRevoker.RevokeSession(ctx, refreshToken.FamilyID); err != nil {
+			return err
+		}
+	}
+
+	log.Warn().
+		Str("user_id", refreshToken.UserID.String()).
+		Str("family_id", refreshToken.FamilyID.String()).
+		Msg("Refresh token reuse detected, session revoked")
+
+	if s.auditService != nil {
+		data := map[string]interface{}{
+			"family_id": refreshToken.FamilyID.String(),
+			"token_id":  refreshToken.ID.String(),
+		}
+		if err := s.auditService.Log(ctx, &refreshToken.UserID, model.EventRefreshTokenReuse, data, ipAddress, userAgent); err != nil {
+			log.Error().Err(err).Str("user_id", refreshToken.UserID.String()).Msg("Failed to log refresh token reuse")
+		}
+	}
+
+	return appErrors.ErrTokenRevoked
+}
+
+// Logout ends all of the user's sessions, including access tokens that have
+// not expired yet.
 func (s *AuthService) Logout(ctx context.Context, userID uuid.UUID) error {
-	return s.refreshRepo.RevokeByUserID(ctx, userID)
+	if err := s.refreshRepo.RevokeByUserID(ctx, userID); err != nil {
+		return err
+	}
+	if s.tokenRevoker != nil {
+		return s.tokenRevoker.RevokeUser(ctx, userID)
+	}
+	return nil
 }
 
 func (s *AuthService) LogoutToken(ctx context.Context, refreshTokenStr string) error {
@@ -310,5 +963,11 @@ func (s *AuthService) LogoutToken(ctx context.Context, refreshTokenStr string) e
 		}
 		return err
 	}
-	return s.refreshRepo.RevokeByID(ctx, refreshToken.ID)
+	if err := s.refreshRepo.RevokeFamily(ctx, refreshToken.FamilyID); err != nil {
+		return err
+	}
+	if s.tokenRevoker != nil {
+		return s.tokenRevoker.RevokeSession(ctx, refreshToken.FamilyID)
+	}
+	return nil
 }
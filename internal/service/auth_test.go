@@ -2,12 +2,22 @@ package service
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
+	appErrors "github.com/ZenoN-Cloud/zeno-auth/internal/errors"
 	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/token"
 )
 
 // Mock repositories
@@ -41,7 +51,7 @@ func (m *MockUserRepo) Update(ctx context.Context, user *model.User) error {
 	return args.Error(0)
 }
 
-func (m *MockUserRepo) CreateTx(ctx context.Context, tx interface{}, user *model.User) error {
+func (m *MockUserRepo) CreateTx(ctx context.Context, tx pgx.Tx, user *model.User) error {
 	args := m.Called(ctx, tx, user)
 	if args.Error(0) == nil {
 		user.ID = uuid.New()
@@ -49,7 +59,7 @@ func (m *MockUserRepo) CreateTx(ctx context.Context, tx interface{}, user *model
 	return args.Error(0)
 }
 
-func (m *MockUserRepo) UpdateTx(ctx context.Context, tx interface{}, user *model.User) error {
+func (m *MockUserRepo) UpdateTx(ctx context.Context, tx pgx.Tx, user *model.User) error {
 	args := m.Called(ctx, tx, user)
 	return args.Error(0)
 }
@@ -98,7 +108,7 @@ func (m *MockOrgRepo) Update(ctx context.Context, org *model.Organization) error
 	return args.Error(0)
 }
 
-func (m *MockOrgRepo) CreateTx(ctx context.Context, tx interface{}, org *model.Organization) error {
+func (m *MockOrgRepo) CreateTx(ctx context.Context, tx pgx.Tx, org *model.Organization) error {
 	args := m.Called(ctx, tx, org)
 	if args.Error(0) == nil {
 		org.ID = uuid.New()
@@ -106,6 +116,11 @@ func (m *MockOrgRepo) CreateTx(ctx context.Context, tx interface{}, org *model.O
 	return args.Error(0)
 }
 
+func (m *MockOrgRepo) CreateWithMembership(ctx context.Context, org *model.Organization, membership *model.OrgMembership) error {
+	args := m.Called(ctx, org, membership)
+	return args.Error(0)
+}
+
 type MockMembershipRepo struct {
 	mock.Mock
 }
@@ -133,7 +148,7 @@ func (m *MockMembershipRepo) Update(ctx context.Context, membership *model.OrgMe
 	return args.Error(0)
 }
 
-func (m *MockMembershipRepo) CreateTx(ctx context.Context, tx interface{}, membership *model.OrgMembership) error {
+func (m *MockMembershipRepo) CreateTx(ctx context.Context, tx pgx.Tx, membership *model.OrgMembership) error {
 	args := m.Called(ctx, tx, membership)
 	if args.Error(0) == nil {
 		membership.ID = uuid.New()
@@ -146,3 +161,280 @@ func TestAuthService_Register(t *testing.T) {
 	// Full transaction testing is done in integration tests.
 	t.Skip("Skipping unit test - requires database transaction mocking. See integration tests.")
 }
+
+type MockRefreshTokenRepo struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepo) Create(ctx context.Context, rt *model.RefreshToken) error {
+	args := m.Called(ctx, rt)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepo) CreateTx(ctx context.Context, tx pgx.Tx, rt *model.RefreshToken) error {
+	args := m.Called(ctx, tx, rt)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepo) GetByTokenHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepo) GetActiveByUserID(ctx context.Context, userID uuid.UUID) ([]*model.RefreshToken, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepo) RevokeByUserID(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepo) RevokeByUserIDTx(ctx context.Context, tx pgx.Tx, userID uuid.UUID) error {
+	args := m.Called(ctx, tx, userID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepo) RevokeByID(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepo) Rotate(ctx context.Context, oldID uuid.UUID, next *model.RefreshToken) (bool, error) {
+	args := m.Called(ctx, oldID, next)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepo) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	args := m.Called(ctx, familyID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepo) DeleteExpired(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func newTestJWTManager(t *testing.T) *token.JWTManager {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+	pubDER, err := x509.MarshalPKIXPublicKey(priv.Public())
+	require.NoError(t, err)
+
+	jwtManager, err := token.NewJWTManager(
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})),
+	)
+	require.NoError(t, err)
+	return jwtManager
+}
+
+func newRefreshTestService(t *testing.T, refreshRepo *MockRefreshTokenRepo, revocation *token.RevocationService) *AuthService {
+	jwtManager := newTestJWTManager(t)
+	return NewAuthService(nil, nil, nil, refreshRepo, jwtManager, token.NewRefreshManager(), nil, nil, nil, nil, nil, nil, nil, nil, nil, revocation, nil,
+		&Config{AccessTokenTTL: 900, RefreshTokenTTL: 3600}, nil)
+}
+
+func TestAuthService_RefreshToken_Rotates(t *testing.T) {
+	refreshRepo := new(MockRefreshTokenRepo)
+	svc := newRefreshTestService(t, refreshRepo, nil)
+	ctx := context.Background()
+
+	hash, _ := token.NewRefreshManager().Hash(ctx, "old-token")
+	current := &model.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		TokenHash: hash,
+		FamilyID:  uuid.New(),
+		ExpiresAt: time.Now().Add(time.Hour),
+		AMR:       []string{token.AMROTP, token.AMRMultiFactor},
+	}
+	refreshRepo.On("GetByTokenHash", ctx, hash).Return(current, nil)
+	refreshRepo.On("Rotate", ctx, current.ID, mock.MatchedBy(func(next *model.RefreshToken) bool {
+		return next.FamilyID == current.FamilyID && next.UserID == current.UserID && next.TokenHash != hash &&
+			assert.ObjectsAreEqual(current.AMR, next.AMR)
+	})).Return(true, nil)
+
+	result, err := svc.RefreshToken(ctx, "old-token", "test-agent", "127.0.0.1")
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.AccessToken)
+	assert.NotEmpty(t, result.RefreshToken)
+	assert.NotEqual(t, "old-token", result.RefreshToken)
+	refreshRepo.AssertExpectations(t)
+
+	claims, err := svc.jwtManager.Validate(ctx, result.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, current.FamilyID.String(), claims.SessionID)
+	assert.True(t, claims.HasMFA())
+}
+
+func TestAuthService_RefreshToken_OrgStatusClaims(t *testing.T) {
+	ctx := context.Background()
+	orgRepo := new(MockOrgRepo)
+	membershipRepo := new(MockMembershipRepo)
+	refreshRepo := new(MockRefreshTokenRepo)
+	svc := NewAuthService(nil, orgRepo, membershipRepo, refreshRepo, newTestJWTManager(t), token.NewRefreshManager(), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		&Config{AccessTokenTTL: 900, RefreshTokenTTL: 3600}, nil)
+
+	trialEndsAt := time.Now().Add(14 * 24 * time.Hour).Truncate(time.Second)
+	subscriptionID := uuid.New()
+	org := &model.Organization{ID: uuid.New(), Status: "trialing", TrialEndsAt: &trialEndsAt}
+	current := &model.RefreshToken{ID: uuid.New(), UserID: uuid.New(), OrgID: org.ID, FamilyID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)}
+	membership := &model.OrgMembership{UserID: current.UserID, OrgID: org.ID, Role: model.RoleMember, IsActive: true}
+	orgRepo.On("GetByID", ctx, org.ID).Return(org, nil)
+	membershipRepo.On("GetByUserAndOrg", ctx, current.UserID, org.ID).Return(membership, nil)
+	refreshRepo.On("GetByTokenHash", ctx, mock.Anything).Return(current, nil)
+	refreshRepo.On("Rotate", ctx, current.ID, mock.Anything).Return(true, nil)
+
+	result, err := svc.RefreshToken(ctx, "old-token", "test-agent", "127.0.0.1")
+	require.NoError(t, err)
+	claims, err := svc.jwtManager.Validate(ctx, result.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "trialing", claims.OrgStatus)
+	assert.Empty(t, claims.SubscriptionStatus)
+	require.NotNil(t, claims.TrialEndsAt)
+	assert.Equal(t, trialEndsAt.Unix(), *claims.TrialEndsAt)
+
+	// Billing activated the subscription
+	org.Status = "active"
+	org.SubscriptionID = &subscriptionID
+	result, err = svc.RefreshToken(ctx, "old-token", "test-agent", "127.0.0.1")
+	require.NoError(t, err)
+	claims, err = svc.jwtManager.Validate(ctx, result.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "active", claims.OrgStatus)
+	assert.Empty(t, claims.SubscriptionStatus)
+}
+
+func TestAuthService_RefreshToken_ConcurrentRotation(t *testing.T) {
+	refreshRepo := new(MockRefreshTokenRepo)
+	svc := newRefreshTestService(t, refreshRepo, nil)
+	ctx := context.Background()
+
+	current := &model.RefreshToken{ID: uuid.New(), UserID: uuid.New(), FamilyID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)}
+	refreshRepo.On("GetByTokenHash", ctx, mock.Anything).Return(current, nil)
+	refreshRepo.On("Rotate", ctx, current.ID, mock.Anything).Return(false, nil)
+
+	_, err := svc.RefreshToken(ctx, "old-token", "test-agent", "127.0.0.1")
+	assert.ErrorIs(t, err, appErrors.ErrInvalidCredentials)
+}
+
+func TestAuthService_RefreshToken_ReuseRevokesFamily(t *testing.T) {
+	refreshRepo := new(MockRefreshTokenRepo)
+	revocation := token.NewRevocationService(token.NewMemoryRevocationStore(), 15*time.Minute)
+	svc := newRefreshTestService(t, refreshRepo, revocation)
+	ctx := context.Background()
+
+	revokedAt := time.Now().Add(-time.Minute)
+	successor := uuid.New()
+	stolen := &model.RefreshToken{
+		ID:           uuid.New(),
+		UserID:       uuid.New(),
+		FamilyID:     uuid.New(),
+		ExpiresAt:    time.Now().Add(time.Hour),
+		RevokedAt:    &revokedAt,
+		ReplacedByID: &successor,
+	}
+	refreshRepo.On("GetByTokenHash", ctx, mock.Anything).Return(stolen, nil)
+	refreshRepo.On("RevokeFamily", ctx, stolen.FamilyID).Return(nil)
+
+	_, err := svc.RefreshToken(ctx, "stolen-token", "test-agent", "127.0.0.1")
+	assert.ErrorIs(t, err, appErrors.ErrTokenRevoked)
+	refreshRepo.AssertExpectations(t)
+	refreshRepo.AssertNotCalled(t, "Rotate", mock.Anything, mock.Anything, mock.Anything)
+
+	// Access tokens already issued for the session are denylisted as well
+	claims := &token.Claims{UserID: stolen.UserID, SessionID: stolen.FamilyID.String()}
+	assert.ErrorIs(t, revocation.Check(ctx, claims), token.ErrTokenRevoked)
+}
+
+func TestAuthService_Login_OrgHint(t *testing.T) {
+	ctx := context.Background()
+	userRepo := new(MockUserRepo)
+	hasher := new(MockPasswordHasher)
+	membershipRepo := new(MockMembershipRepo)
+	refreshRepo := new(MockRefreshTokenRepo)
+	svc := NewAuthService(userRepo, nil, membershipRepo, refreshRepo, newTestJWTManager(t), token.NewRefreshManager(), hasher, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		&Config{AccessTokenTTL: 900, RefreshTokenTTL: 3600}, nil)
+
+	user := &model.User{ID: uuid.New(), Email: "jane@example.com", PasswordHash: "hash", IsActive: true}
+	first := &model.OrgMembership{UserID: user.ID, OrgID: uuid.New(), Role: model.RoleOwner, IsActive: true}
+	second := &model.OrgMembership{UserID: user.ID, OrgID: uuid.New(), Role: model.RoleViewer, IsActive: true}
+	userRepo.On("GetByEmail", ctx, user.Email).Return(user, nil)
+	hasher.On("Verify", ctx, "password", "hash").Return(true, nil)
+	membershipRepo.On("GetByUserID", ctx, user.ID).Return([]*model.OrgMembership{first, second}, nil)
+	refreshRepo.On("Create", ctx, mock.Anything).Return(nil)
+
+	result, err := svc.Login(ctx, user.Email, "password", uuid.Nil, "test-agent", "127.0.0.1")
+	require.NoError(t, err)
+	claims, err := svc.jwtManager.Validate(ctx, result.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, first.OrgID, claims.OrgID)
+	assert.Equal(t, []string{token.AMRPassword}, claims.AMR)
+	assert.False(t, claims.HasMFA())
+
+	result, err = svc.Login(ctx, user.Email, "password", second.OrgID, "test-agent", "127.0.0.1")
+	require.NoError(t, err)
+	claims, err = svc.jwtManager.Validate(ctx, result.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, second.OrgID, claims.OrgID)
+	assert.Equal(t, []string{string(model.RoleViewer)}, claims.Roles)
+	assert.Equal(t, []string{"documents:read", "members:read", "org:read"}, claims.Permissions)
+
+	_, err = svc.Login(ctx, user.Email, "password", uuid.New(), "test-agent", "127.0.0.1")
+	assert.ErrorIs(t, err, appErrors.ErrNotOrgMember)
+}
+
+func TestAuthService_SwitchOrganization(t *testing.T) {
+	ctx := context.Background()
+	userRepo := new(MockUserRepo)
+	membershipRepo := new(MockMembershipRepo)
+	refreshRepo := new(MockRefreshTokenRepo)
+	revocation := token.NewRevocationService(token.NewMemoryRevocationStore(), 15*time.Minute)
+	svc := NewAuthService(userRepo, nil, membershipRepo, refreshRepo, newTestJWTManager(t), token.NewRefreshManager(), nil, nil, nil, nil, nil, nil, nil, nil, nil, revocation, nil,
+		&Config{AccessTokenTTL: 900, RefreshTokenTTL: 3600}, nil)
+
+	user := &model.User{ID: uuid.New(), Email: "jane@example.com", IsActive: true}
+	target := &model.OrgMembership{UserID: user.ID, OrgID: uuid.New(), Role: model.RoleAdmin, IsActive: true}
+	inactive := &model.OrgMembership{UserID: user.ID, OrgID: uuid.New(), Role: model.RoleMember}
+	strangerOrg := uuid.New()
+	sessionID := uuid.New()
+	userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	membershipRepo.On("GetByUserAndOrg", ctx, user.ID, target.OrgID).Return(target, nil)
+	membershipRepo.On("GetByUserAndOrg", ctx, user.ID, inactive.OrgID).Return(inactive, nil)
+	membershipRepo.On("GetByUserAndOrg", ctx, user.ID, strangerOrg).Return(nil, pgx.ErrNoRows)
+	refreshRepo.On("Create", ctx, mock.MatchedBy(func(rt *model.RefreshToken) bool {
+		return rt.OrgID == target.OrgID && rt.FamilyID != sessionID
+	})).Return(nil)
+	refreshRepo.On("RevokeFamily", ctx, sessionID).Return(nil)
+
+	_, err := svc.SwitchOrganization(ctx, user.ID, sessionID, inactive.OrgID, nil, "", "")
+	assert.ErrorIs(t, err, appErrors.ErrNotOrgMember)
+	_, err = svc.SwitchOrganization(ctx, user.ID, sessionID, strangerOrg, nil, "", "")
+	assert.ErrorIs(t, err, appErrors.ErrNotOrgMember)
+	refreshRepo.AssertNotCalled(t, "RevokeFamily", mock.Anything, mock.Anything)
+
+	result, err := svc.SwitchOrganization(ctx, user.ID, sessionID, target.OrgID, []string{token.AMRPassword}, "test-agent", "127.0.0.1")
+	require.NoError(t, err)
+	claims, err := svc.jwtManager.Validate(ctx, result.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, target.OrgID, claims.OrgID)
+	assert.Equal(t, []string{string(model.RoleAdmin)}, claims.Roles)
+	assert.NotEqual(t, sessionID.String(), claims.SessionID)
+	assert.Equal(t, []string{token.AMRPassword}, claims.AMR)
+	refreshRepo.AssertExpectations(t)
+
+	// Access tokens of the previous session are denylisted
+	previous := &token.Claims{UserID: user.ID, SessionID: sessionID.String()}
+	assert.ErrorIs(t, revocation.Check(ctx, previous), token.ErrTokenRevoked)
+	assert.NoError(t, revocation.Check(ctx, claims))
+}
@@ -4,18 +4,61 @@ import (
 	"context"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/storage"
 )
 
+// purgeBatchSize is the number of accounts PurgeDeletedAccounts loads at a
+// time.
+const purgeBatchSize = 100
+
+// AccountPurgeRepository removes accounts whose deletion grace period ended.
+type AccountPurgeRepository interface {
+	ListDue(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error)
+	Purge(ctx context.Context, userID uuid.UUID) (bool, error)
+	CancelByUserID(ctx context.Context, userID uuid.UUID) (bool, error)
+}
+
+// DataExportCleanupRepository removes data exports whose link expired.
+type DataExportCleanupRepository interface {
+	ListExpired(ctx context.Context, now time.Time, limit int) ([]*model.DataExport, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	FailStale(ctx context.Context, before time.Time) (int64, error)
+}
+
 type CleanupService struct {
 	refreshTokenRepo RefreshTokenRepository
 	auditRepo        AuditLogRepository
+	userRepo         UserRepository
+	purgeRepo        AccountPurgeRepository
+	exportRepo       DataExportCleanupRepository
+	exportStorage    storage.Storage
+	auditService     *AuditService
+	emailSender      EmailSender
 }
 
-func NewCleanupService(refreshTokenRepo RefreshTokenRepository, auditRepo AuditLogRepository) *CleanupService {
+func NewCleanupService(
+	refreshTokenRepo RefreshTokenRepository,
+	auditRepo AuditLogRepository,
+	userRepo UserRepository,
+	purgeRepo AccountPurgeRepository,
+	exportRepo DataExportCleanupRepository,
+	exportStorage storage.Storage,
+	auditService *AuditService,
+	frontendBaseURL string,
+) *CleanupService {
 	return &CleanupService{
 		refreshTokenRepo: refreshTokenRepo,
 		auditRepo:        auditRepo,
+		userRepo:         userRepo,
+		purgeRepo:        purgeRepo,
+		exportRepo:       exportRepo,
+		exportStorage:    exportStorage,
+		auditService:     auditService,
+		emailSender:      NewSendGridEmailSender(frontendBaseURL),
 	}
 }
 
@@ -48,3 +91,138 @@ func (s *CleanupService) CleanupOldAuditLogs(ctx context.Context, retentionDays
 	log.Info().Msg("Old audit logs cleanup completed")
 	return 0, nil
 }
+
+// PurgeDeletedAccounts permanently deletes the accounts whose deletion grace
+// period ended, together with their memberships and consents. An account that
+// came to own an organization with other members during the grace period
+// cannot be purged without deleting the organization; its deletion is
+// cancelled and the user is told to transfer the organization first. It
+// returns the number of purged accounts.
+func (s *CleanupService) PurgeDeletedAccounts(ctx context.Context) (int, error) {
+	log.Info().Msg("Starting purge of accounts scheduled for deletion")
+
+	if s.purgeRepo == nil {
+		return 0, ErrRepositoryNotInitialized
+	}
+
+	now := time.Now()
+	purged := 0
+	cancelled := 0
+	for {
+		userIDs, err := s.purgeRepo.ListDue(ctx, now, purgeBatchSize)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to list accounts scheduled for deletion")
+			return purged, err
+		}
+		if len(userIDs) == 0 {
+			break
+		}
+
+		for _, userID := range userIDs {
+			ok, err := s.purgeRepo.Purge(ctx, userID)
+			if err != nil {
+				log.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to purge account")
+				return purged, err
+			}
+			if !ok {
+				if err := s.cancelPurge(ctx, userID); err != nil {
+					return purged, err
+				}
+				cancelled++
+				continue
+			}
+			purged++
+
+			// The user no longer exists, so the event is not linked to them
+			if s.auditService != nil {
+				if err := s.auditService.Log(ctx, nil, model.EventAccountDeleted, nil, "", ""); err != nil {
+					log.Error().Err(err).Msg("Failed to log account purge audit event")
+				}
+			}
+		}
+	}
+
+	log.Info().Int("purged", purged).Int("cancelled", cancelled).Msg("Account purge completed")
+	return purged, nil
+}
+
+// cancelPurge cancels the deletion of an account that owns an organization
+// with other members and notifies the user, instead of leaving the account
+// deactivated and due for purge on every run.
+func (s *CleanupService) cancelPurge(ctx context.Context, userID uuid.UUID) error {
+	log.Warn().Str("user_id", userID.String()).Msg("Account owns an organization with other members, cancelling deletion")
+
+	if _, err := s.purgeRepo.CancelByUserID(ctx, userID); err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to cancel account deletion")
+		return err
+	}
+
+	if s.auditService != nil {
+		data := map[string]interface{}{"reason": "ownership_transfer_required"}
+		if err := s.auditService.Log(ctx, &userID, model.EventAccountDeletionCancelled, data, "", ""); err != nil {
+			log.Error().Err(err).Msg("Failed to log account deletion cancellation audit event")
+		}
+	}
+
+	if s.userRepo == nil || s.emailSender == nil {
+		return nil
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to get user for account deletion notice")
+		return nil
+	}
+	if err := s.emailSender.SendAccountDeletionCancelledEmail(ctx, user.Email); err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to send account deletion cancelled email")
+	}
+	return nil
+}
+
+// CleanupDataExports deletes the archives and records of data exports whose
+// download link expired, and marks exports abandoned while being built as
+// failed. It returns the number of deleted exports.
+func (s *CleanupService) CleanupDataExports(ctx context.Context) (int, error) {
+	log.Info().Msg("Starting cleanup of expired data exports")
+
+	if s.exportRepo == nil || s.exportStorage == nil {
+		return 0, ErrRepositoryNotInitialized
+	}
+
+	now := time.Now()
+	stale, err := s.exportRepo.FailStale(ctx, now.Add(-dataExportTimeout))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to mark abandoned data exports as failed")
+		return 0, err
+	}
+
+	deleted := 0
+	for {
+		exports, err := s.exportRepo.ListExpired(ctx, now, purgeBatchSize)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to list expired data exports")
+			return deleted, err
+		}
+		if len(exports) == 0 {
+			break
+		}
+
+		for _, export := range exports {
+			// The record is only removed with its archive, so that a failed
+			// deletion is retried on the next run
+			if export.StorageKey != "" {
+				if err := s.exportStorage.Delete(ctx, export.StorageKey); err != nil {
+					log.Error().Err(err).Str("export_id", export.ID.String()).Msg("Failed to delete data export archive")
+					return deleted, err
+				}
+			}
+			if err := s.exportRepo.Delete(ctx, export.ID); err != nil {
+				log.Error().Err(err).Str("export_id", export.ID.String()).Msg("Failed to delete data export")
+				return deleted, err
+			}
+			deleted++
+		}
+	}
+
+	log.Info().Int("deleted", deleted).Int64("abandoned", stale).Msg("Data export cleanup completed")
+	return deleted, nil
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+type ComplianceAuditRepository interface {
+	Count(ctx context.Context, from, to time.Time) (int64, error)
+	CountByEventType(ctx context.Context, eventType model.AuditEventType, from, to time.Time) (int64, error)
+}
+
+type ComplianceUserRepository interface {
+	CountActive(ctx context.Context) (int64, error)
+}
+
+// ComplianceService aggregates the audit log and the user base for the GDPR
+// Art. 30 records. Periods are half-open: from is included, to is not.
+type ComplianceService struct {
+	auditRepo ComplianceAuditRepository
+	userRepo  ComplianceUserRepository
+}
+
+func NewComplianceService(auditRepo ComplianceAuditRepository, userRepo ComplianceUserRepository) *ComplianceService {
+	return &ComplianceService{
+		auditRepo: auditRepo,
+		userRepo:  userRepo,
+	}
+}
+
+// GetDataExportCount returns the number of personal data export requests
+// (Art. 15, 20), including ones that failed.
+func (s *ComplianceService) GetDataExportCount(ctx context.Context, from, to time.Time) (int64, error) {
+	return s.countEvents(ctx, model.EventDataExportRequested, from, to)
+}
+
+// GetAccountDeletionCount returns the number of account deletion requests
+// (Art. 17), including ones cancelled during the grace period.
+func (s *ComplianceService) GetAccountDeletionCount(ctx context.Context, from, to time.Time) (int64, error) {
+	return s.countEvents(ctx, model.EventAccountDeletionRequested, from, to)
+}
+
+// GetActiveUsersCount returns the number of active, not deleted accounts,
+// i.e. the data subjects whose data is currently processed.
+func (s *ComplianceService) GetActiveUsersCount(ctx context.Context) (int64, error) {
+	count, err := s.userRepo.CountActive(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count active users: %w", err)
+	}
+	return count, nil
+}
+
+// GetAuditLogCount returns the number of audit log entries in the period.
+func (s *ComplianceService) GetAuditLogCount(ctx context.Context, from, to time.Time) (int64, error) {
+	if !from.Before(to) {
+		return 0, nil
+	}
+	count, err := s.auditRepo.Count(ctx, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+	return count, nil
+}
+
+func (s *ComplianceService) countEvents(ctx context.Context, eventType model.AuditEventType, from, to time.Time) (int64, error) {
+	if !from.Before(to) {
+		return 0, nil
+	}
+	count, err := s.auditRepo.CountByEventType(ctx, eventType, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count %s events: %w", eventType, err)
+	}
+	return count, nil
+}
@@ -0,0 +1,674 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/storage"
+)
+
+const (
+	// dataExportTimeout bounds building a single archive. Exports still
+	// pending after it are considered abandoned.
+	dataExportTimeout        = 30 * time.Minute
+	defaultDataExportLinkTTL = 72 * time.Hour
+)
+
+type DataExportRepository interface {
+	Create(ctx context.Context, export *model.DataExport) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.DataExport, error)
+	GetActiveByUserID(ctx context.Context, userID uuid.UUID, since time.Time) (*model.DataExport, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*model.DataExport, error)
+	MarkProcessing(ctx context.Context, id uuid.UUID) error
+	Complete(ctx context.Context, export *model.DataExport) error
+	Fail(ctx context.Context, id uuid.UUID) error
+}
+
+type DataExportUserRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*model.User, error)
+}
+
+type DataExportOrganizationRepository interface {
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*model.Organization, error)
+}
+
+type DataExportMembershipRepository interface {
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*model.OrgMembership, error)
+}
+
+type DataExportConsentRepository interface {
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*model.UserConsent, error)
+}
+
+type DataExportSessionRepository interface {
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*model.RefreshToken, error)
+}
+
+type DataExportEmailVerificationRepository interface {
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*model.EmailVerification, error)
+}
+
+type DataExportPasswordResetRepository interface {
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*model.PasswordResetToken, error)
+}
+
+type DataExportIdentityRepository interface {
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*model.UserIdentity, error)
+}
+
+type DataExportPasskeyRepository interface {
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*model.WebAuthnCredential, error)
+}
+
+type DataExportMFARepository interface {
+	GetByUserID(ctx context.Context, userID uuid.UUID) (*model.UserMFA, error)
+}
+
+type DataExportRecoveryCodeRepository interface {
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*model.MFARecoveryCode, error)
+}
+
+type DataExportEmailChangeRepository interface {
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*model.EmailChangeRequest, error)
+}
+
+type DataExportInvitationRepository interface {
+	GetByEmail(ctx context.Context, email string) ([]*model.OrgInvitation, error)
+}
+
+type DataExportAuditLogRepository interface {
+	ForEachByUserID(ctx context.Context, userID uuid.UUID, fn func(*model.AuditLog) error) error
+}
+
+// DataExportService exports the personal data of a user (GDPR Art. 15, 20).
+// Exports are built in the background into a ZIP archive with a JSON and a
+// CSV file per data category, which is written to the export storage. The
+// user receives a link to download it until it expires; the cleanup job
+// removes expired archives.
+type DataExportService struct {
+	exportRepo       DataExportRepository
+	userRepo         DataExportUserRepository
+	orgRepo          DataExportOrganizationRepository
+	membershipRepo   DataExportMembershipRepository
+	consentRepo      DataExportConsentRepository
+	sessionRepo      DataExportSessionRepository
+	verificationRepo DataExportEmailVerificationRepository
+	passwordRepo     DataExportPasswordResetRepository
+	identityRepo     DataExportIdentityRepository
+	passkeyRepo      DataExportPasskeyRepository
+	mfaRepo          DataExportMFARepository
+	recoveryCodeRepo DataExportRecoveryCodeRepository
+	emailChangeRepo  DataExportEmailChangeRepository
+	invitationRepo   DataExportInvitationRepository
+	auditRepo        DataExportAuditLogRepository
+	storage          storage.Storage
+	auditService     *AuditService
+	emailSender      EmailSender
+	linkTTL          time.Duration
+	jobs             sync.WaitGroup
+}
+
+func NewDataExportService(
+	exportRepo DataExportRepository,
+	userRepo DataExportUserRepository,
+	orgRepo DataExportOrganizationRepository,
+	membershipRepo DataExportMembershipRepository,
+	consentRepo DataExportConsentRepository,
+	sessionRepo DataExportSessionRepository,
+	verificationRepo DataExportEmailVerificationRepository,
+	passwordRepo DataExportPasswordResetRepository,
+	identityRepo DataExportIdentityRepository,
+	passkeyRepo DataExportPasskeyRepository,
+	mfaRepo DataExportMFARepository,
+	recoveryCodeRepo DataExportRecoveryCodeRepository,
+	emailChangeRepo DataExportEmailChangeRepository,
+	invitationRepo DataExportInvitationRepository,
+	auditRepo DataExportAuditLogRepository,
+	exportStorage storage.Storage,
+	auditService *AuditService,
+	linkTTL time.Duration,
+	frontendBaseURL string,
+) *DataExportService {
+	if linkTTL <= 0 {
+		linkTTL = defaultDataExportLinkTTL
+	}
+	return &DataExportService{
+		exportRepo:       exportRepo,
+		userRepo:         userRepo,
+		orgRepo:          orgRepo,
+		membershipRepo:   membershipRepo,
+		consentRepo:      consentRepo,
+		sessionRepo:      sessionRepo,
+		verificationRepo: verificationRepo,
+		passwordRepo:     passwordRepo,
+		identityRepo:     identityRepo,
+		passkeyRepo:      passkeyRepo,
+		mfaRepo:          mfaRepo,
+		recoveryCodeRepo: recoveryCodeRepo,
+		emailChangeRepo:  emailChangeRepo,
+		invitationRepo:   invitationRepo,
+		auditRepo:        auditRepo,
+		storage:          exportStorage,
+		auditService:     auditService,
+		emailSender:      NewSendGridEmailSender(frontendBaseURL),
+		linkTTL:          linkTTL,
+	}
+}
+
+// RequestExport starts building an export of the user's data. If an export
+// is already in progress it is returned instead of starting another one.
+func (s *DataExportService) RequestExport(ctx context.Context, userID uuid.UUID, ipAddress, userAgent string) (*model.DataExport, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	active, err := s.exportRepo.GetActiveByUserID(ctx, userID, time.Now().Add(-dataExportTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data export: %w", err)
+	}
+	if active != nil {
+		return active, nil
+	}
+
+	export := &model.DataExport{
+		UserID:    userID,
+		Status:    model.DataExportPending,
+		ExpiresAt: time.Now().Add(s.linkTTL),
+	}
+	if err := s.exportRepo.Create(ctx, export); err != nil {
+		return nil, fmt.Errorf("failed to create data export: %w", err)
+	}
+
+	s.audit(ctx, &userID, model.EventDataExportRequested, map[string]interface{}{
+		"export_id": export.ID.String(),
+	}, ipAddress, userAgent)
+
+	job := *export
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		s.run(&job, user)
+	}()
+	return export, nil
+}
+
+// GetExport returns an export of the user.
+func (s *DataExportService) GetExport(ctx context.Context, userID, exportID uuid.UUID) (*model.DataExport, error) {
+	export, err := s.exportRepo.GetByID(ctx, exportID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data export: %w", err)
+	}
+	if export == nil || export.UserID != userID {
+		return nil, errors.ErrDataExportNotFound
+	}
+	return export, nil
+}
+
+// OpenDownload opens the archive of the completed export the download token
+// belongs to. The link can be used until the export expires. The caller must
+// close the returned reader.
+func (s *DataExportService) OpenDownload(ctx context.Context, rawToken, ipAddress, userAgent string) (*model.DataExport, io.ReadCloser, error) {
+	if rawToken == "" {
+		return nil, nil, errors.ErrInvalidDataExportLink
+	}
+
+	export, err := s.exportRepo.GetByTokenHash(ctx, hashToken(rawToken))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get data export: %w", err)
+	}
+	if export == nil {
+		return nil, nil, errors.ErrInvalidDataExportLink
+	}
+
+	archive, err := s.storage.Open(ctx, export.StorageKey)
+	if err != nil {
+		if stdErrors.Is(err, storage.ErrNotFound) {
+			return nil, nil, errors.ErrInvalidDataExportLink
+		}
+		return nil, nil, fmt.Errorf("failed to open data export: %w", err)
+	}
+
+	s.audit(ctx, &export.UserID, model.EventDataExportDownloaded, map[string]interface{}{
+		"export_id": export.ID.String(),
+	}, ipAddress, userAgent)
+	return export, archive, nil
+}
+
+// Wait blocks until the exports being built have finished or the context is
+// done, e.g. during shutdown.
+func (s *DataExportService) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.jobs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *DataExportService) run(export *model.DataExport, user *model.User) {
+	ctx, cancel := context.WithTimeout(context.Background(), dataExportTimeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().Interface("panic", r).Str("export_id", export.ID.String()).Msg("Data export panicked")
+			s.fail(ctx, export)
+		}
+	}()
+
+	if err := s.build(ctx, export, user); err != nil {
+		log.Error().Err(err).Str("export_id", export.ID.String()).Msg("Data export failed")
+		s.fail(ctx, export)
+	}
+}
+
+func (s *DataExportService) fail(ctx context.Context, export *model.DataExport) {
+	if err := s.exportRepo.Fail(ctx, export.ID); err != nil {
+		log.Error().Err(err).Str("export_id", export.ID.String()).Msg("Failed to mark data export as failed")
+	}
+}
+
+func (s *DataExportService) build(ctx context.Context, export *model.DataExport, user *model.User) error {
+	if err := s.exportRepo.MarkProcessing(ctx, export.ID); err != nil {
+		return fmt.Errorf("failed to mark data export as processing: %w", err)
+	}
+
+	// Stream the archive into the storage instead of building it in memory
+	key := export.ID.String() + ".zip"
+	pr, pw := io.Pipe()
+	written := make(chan error, 1)
+	go func() {
+		err := s.writeArchive(ctx, pw, user)
+		_ = pw.CloseWithError(err)
+		written <- err
+	}()
+	size, putErr := s.storage.Put(ctx, key, pr)
+	_ = pr.CloseWithError(putErr)
+	if err := <-written; err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	if putErr != nil {
+		return fmt.Errorf("failed to store archive: %w", putErr)
+	}
+
+	rawToken, err := generateToken()
+	if err != nil {
+		s.deleteArchive(ctx, key)
+		return fmt.Errorf("failed to generate token: %w", err)
+	}
+	export.StorageKey = key
+	export.SizeBytes = size
+	export.TokenHash = hashToken(rawToken)
+	export.ExpiresAt = time.Now().Add(s.linkTTL)
+	if err := s.exportRepo.Complete(ctx, export); err != nil {
+		s.deleteArchive(ctx, key)
+		return fmt.Errorf("failed to complete data export: %w", err)
+	}
+
+	s.audit(ctx, &user.ID, model.EventDataExported, map[string]interface{}{
+		"export_id":  export.ID.String(),
+		"size_bytes": size,
+	}, "", "")
+
+	if s.emailSender != nil {
+		expiresAt := formatTimeForUser(export.ExpiresAt, user)
+		if err := s.emailSender.SendDataExportReadyEmail(ctx, user.Email, rawToken, expiresAt); err != nil {
+			log.Error().Err(err).Str("export_id", export.ID.String()).Msg("Failed to send data export email")
+		}
+	}
+	return nil
+}
+
+func (s *DataExportService) deleteArchive(ctx context.Context, key string) {
+	if err := s.storage.Delete(ctx, key); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Failed to delete data export archive")
+	}
+}
+
+// writeArchive writes the ZIP archive with a JSON and a CSV file per data
+// category. Audit logs are streamed, since there can be many of them. Secrets
+// such as password, token and recovery code hashes, the TOTP secret and
+// passkey keys are left out.
+func (s *DataExportService) writeArchive(ctx context.Context, w io.Writer, user *model.User) error {
+	orgs, err := s.orgRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get organizations: %w", err)
+	}
+	memberships, err := s.membershipRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get memberships: %w", err)
+	}
+	consents, err := s.consentRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get consents: %w", err)
+	}
+	sessions, err := s.sessionRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get sessions: %w", err)
+	}
+	verifications, err := s.verificationRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get email verifications: %w", err)
+	}
+	passwordResets, err := s.passwordRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get password resets: %w", err)
+	}
+	identities, err := s.identityRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get social identities: %w", err)
+	}
+	passkeys, err := s.passkeyRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get passkeys: %w", err)
+	}
+	enrollment, err := s.mfaRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get MFA enrollment: %w", err)
+	}
+	var mfa []*model.UserMFA
+	if enrollment != nil {
+		mfa = append(mfa, enrollment)
+	}
+	recoveryCodes, err := s.recoveryCodeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get recovery codes: %w", err)
+	}
+	emailChanges, err := s.emailChangeRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get email changes: %w", err)
+	}
+	invitations, err := s.invitationRepo.GetByEmail(ctx, user.Email)
+	if err != nil {
+		return fmt.Errorf("failed to get invitations: %w", err)
+	}
+
+	zw := zip.NewWriter(w)
+
+	if err := writeJSONFile(zw, "profile.json", user); err != nil {
+		return err
+	}
+	if err := writeCSVFile(zw, "profile.csv",
+		[]string{"id", "email", "full_name", "locale", "timezone", "is_active", "created_at", "updated_at"},
+		[][]string{{user.ID.String(), user.Email, user.FullName, user.Locale, user.Timezone, strconv.FormatBool(user.IsActive), csvTime(&user.CreatedAt), csvTime(&user.UpdatedAt)}},
+	); err != nil {
+		return err
+	}
+
+	if err := writeJSONFile(zw, "organizations.json", nonNil(orgs)); err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(orgs))
+	for _, org := range orgs {
+		rows = append(rows, []string{org.ID.String(), org.Name, org.OwnerUserID.String(), org.Status, org.Country, org.Currency, csvTime(&org.CreatedAt)})
+	}
+	if err := writeCSVFile(zw, "organizations.csv", []string{"id", "name", "owner_user_id", "status", "country", "currency", "created_at"}, rows); err != nil {
+		return err
+	}
+
+	if err := writeJSONFile(zw, "memberships.json", nonNil(memberships)); err != nil {
+		return err
+	}
+	rows = make([][]string, 0, len(memberships))
+	for _, membership := range memberships {
+		rows = append(rows, []string{membership.ID.String(), membership.OrgID.String(), string(membership.Role), strconv.FormatBool(membership.IsActive), csvTime(&membership.CreatedAt)})
+	}
+	if err := writeCSVFile(zw, "memberships.csv", []string{"id", "org_id", "role", "is_active", "created_at"}, rows); err != nil {
+		return err
+	}
+
+	if err := writeJSONFile(zw, "consents.json", nonNil(consents)); err != nil {
+		return err
+	}
+	rows = make([][]string, 0, len(consents))
+	for _, consent := range consents {
+		rows = append(rows, []string{consent.ID.String(), string(consent.ConsentType), consent.Version, strconv.FormatBool(consent.Granted), csvTime(&consent.GrantedAt), csvTime(consent.RevokedAt)})
+	}
+	if err := writeCSVFile(zw, "consents.csv", []string{"id", "consent_type", "version", "granted", "granted_at", "revoked_at"}, rows); err != nil {
+		return err
+	}
+
+	if err := writeJSONFile(zw, "sessions.json", nonNil(sessions)); err != nil {
+		return err
+	}
+	rows = make([][]string, 0, len(sessions))
+	for _, session := range sessions {
+		rows = append(rows, []string{session.ID.String(), session.OrgID.String(), session.UserAgent, session.IPAddress, csvTime(&session.CreatedAt), csvTime(&session.ExpiresAt), csvTime(session.RevokedAt)})
+	}
+	if err := writeCSVFile(zw, "sessions.csv", []string{"id", "org_id", "user_agent", "ip_address", "created_at", "expires_at", "revoked_at"}, rows); err != nil {
+		return err
+	}
+
+	if err := writeJSONFile(zw, "email_verifications.json", nonNil(verifications)); err != nil {
+		return err
+	}
+	rows = make([][]string, 0, len(verifications))
+	for _, verification := range verifications {
+		rows = append(rows, []string{verification.ID.String(), csvTime(&verification.CreatedAt), csvTime(&verification.ExpiresAt), csvTime(verification.VerifiedAt)})
+	}
+	if err := writeCSVFile(zw, "email_verifications.csv", []string{"id", "created_at", "expires_at", "verified_at"}, rows); err != nil {
+		return err
+	}
+
+	if err := writeJSONFile(zw, "password_resets.json", nonNil(passwordResets)); err != nil {
+		return err
+	}
+	rows = make([][]string, 0, len(passwordResets))
+	for _, reset := range passwordResets {
+		rows = append(rows, []string{reset.ID.String(), csvTime(&reset.CreatedAt), csvTime(&reset.ExpiresAt), csvTime(reset.UsedAt)})
+	}
+	if err := writeCSVFile(zw, "password_resets.csv", []string{"id", "created_at", "expires_at", "used_at"}, rows); err != nil {
+		return err
+	}
+
+	if err := writeJSONFile(zw, "social_identities.json", nonNil(identities)); err != nil {
+		return err
+	}
+	rows = make([][]string, 0, len(identities))
+	for _, identity := range identities {
+		email := ""
+		if identity.Email != nil {
+			email = *identity.Email
+		}
+		rows = append(rows, []string{identity.ID.String(), identity.Provider, email, csvTime(&identity.CreatedAt), csvTime(identity.LastLoginAt)})
+	}
+	if err := writeCSVFile(zw, "social_identities.csv", []string{"id", "provider", "email", "created_at", "last_login_at"}, rows); err != nil {
+		return err
+	}
+
+	if err := writeJSONFile(zw, "passkeys.json", nonNil(passkeys)); err != nil {
+		return err
+	}
+	rows = make([][]string, 0, len(passkeys))
+	for _, passkey := range passkeys {
+		rows = append(rows, []string{passkey.ID.String(), passkey.Name, strings.Join(passkey.Transports, " "), csvTime(&passkey.CreatedAt), csvTime(passkey.LastUsedAt)})
+	}
+	if err := writeCSVFile(zw, "passkeys.csv", []string{"id", "name", "transports", "created_at", "last_used_at"}, rows); err != nil {
+		return err
+	}
+
+	if err := writeJSONFile(zw, "mfa.json", nonNil(mfa)); err != nil {
+		return err
+	}
+	rows = make([][]string, 0, len(mfa))
+	for _, enrollment := range mfa {
+		rows = append(rows, []string{csvTime(&enrollment.CreatedAt), csvTime(enrollment.EnabledAt), csvTime(&enrollment.UpdatedAt)})
+	}
+	if err := writeCSVFile(zw, "mfa.csv", []string{"created_at", "enabled_at", "updated_at"}, rows); err != nil {
+		return err
+	}
+
+	if err := writeJSONFile(zw, "mfa_recovery_codes.json", nonNil(recoveryCodes)); err != nil {
+		return err
+	}
+	rows = make([][]string, 0, len(recoveryCodes))
+	for _, code := range recoveryCodes {
+		rows = append(rows, []string{code.ID.String(), csvTime(&code.CreatedAt), csvTime(code.UsedAt)})
+	}
+	if err := writeCSVFile(zw, "mfa_recovery_codes.csv", []string{"id", "created_at", "used_at"}, rows); err != nil {
+		return err
+	}
+
+	if err := writeJSONFile(zw, "email_changes.json", nonNil(emailChanges)); err != nil {
+		return err
+	}
+	rows = make([][]string, 0, len(emailChanges))
+	for _, change := range emailChanges {
+		rows = append(rows, []string{change.ID.String(), change.NewEmail, csvTime(&change.CreatedAt), csvTime(&change.ExpiresAt), csvTime(change.ConfirmedAt)})
+	}
+	if err := writeCSVFile(zw, "email_changes.csv", []string{"id", "new_email", "created_at", "expires_at", "confirmed_at"}, rows); err != nil {
+		return err
+	}
+
+	if err := writeJSONFile(zw, "invitations.json", nonNil(invitations)); err != nil {
+		return err
+	}
+	rows = make([][]string, 0, len(invitations))
+	for _, invitation := range invitations {
+		invitedBy := ""
+		if invitation.InvitedBy != nil {
+			invitedBy = invitation.InvitedBy.String()
+		}
+		rows = append(rows, []string{invitation.ID.String(), invitation.OrgID.String(), invitation.Email, string(invitation.Role), invitedBy, csvTime(&invitation.CreatedAt), csvTime(&invitation.ExpiresAt), csvTime(invitation.AcceptedAt)})
+	}
+	if err := writeCSVFile(zw, "invitations.csv", []string{"id", "org_id", "email", "role", "invited_by", "created_at", "expires_at", "accepted_at"}, rows); err != nil {
+		return err
+	}
+
+	if err := s.writeAuditLogs(ctx, zw, user.ID); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// writeAuditLogs streams all audit log entries of the user into
+// audit_logs.json and audit_logs.csv, reading them once per file.
+func (s *DataExportService) writeAuditLogs(ctx context.Context, zw *zip.Writer, userID uuid.UUID) error {
+	f, err := zw.Create("audit_logs.json")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, "["); err != nil {
+		return err
+	}
+	first := true
+	err = s.auditRepo.ForEachByUserID(ctx, userID, func(entry *model.AuditLog) error {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if !first {
+			if _, err := io.WriteString(f, ","); err != nil {
+				return err
+			}
+		}
+		first = false
+		_, err = f.Write(append([]byte("\n  "), data...))
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export audit logs: %w", err)
+	}
+	if _, err := io.WriteString(f, "\n]\n"); err != nil {
+		return err
+	}
+
+	f, err = zw.Create("audit_logs.csv")
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(f)
+	if err := cw.Write([]string{"id", "event_type", "event_data", "ip_address", "user_agent", "created_at"}); err != nil {
+		return err
+	}
+	err = s.auditRepo.ForEachByUserID(ctx, userID, func(entry *model.AuditLog) error {
+		eventData := ""
+		if len(entry.EventData) > 0 {
+			data, err := json.Marshal(entry.EventData)
+			if err != nil {
+				return err
+			}
+			eventData = string(data)
+		}
+		return cw.Write([]string{entry.ID.String(), string(entry.EventType), eventData, entry.IPAddress, entry.UserAgent, csvTime(&entry.CreatedAt)})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export audit logs: %w", err)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (s *DataExportService) audit(ctx context.Context, userID *uuid.UUID, eventType model.AuditEventType, data map[string]interface{}, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	if err := s.auditService.Log(ctx, userID, eventType, data, ipAddress, userAgent); err != nil {
+		log.Error().Err(err).Str("event", string(eventType)).Msg("Failed to log data export audit event")
+	}
+}
+
+func writeJSONFile(zw *zip.Writer, name string, v interface{}) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func writeCSVFile(zw *zip.Writer, name string, header []string, rows [][]string) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(f)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// nonNil makes empty categories encode as [] instead of null.
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+func csvTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,488 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	stdErrors "errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/storage"
+)
+
+// memoryDataExportRepo is safe for use by the background export goroutine.
+type memoryDataExportRepo struct {
+	mu      sync.Mutex
+	exports map[uuid.UUID]*model.DataExport
+}
+
+func (r *memoryDataExportRepo) get(id uuid.UUID) *model.DataExport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *r.exports[id]
+	return &copied
+}
+
+func (r *memoryDataExportRepo) Create(_ context.Context, export *model.DataExport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	export.ID = uuid.New()
+	export.CreatedAt = time.Now()
+	copied := *export
+	r.exports[export.ID] = &copied
+	return nil
+}
+
+func (r *memoryDataExportRepo) GetByID(_ context.Context, id uuid.UUID) (*model.DataExport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	export, ok := r.exports[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *export
+	return &copied, nil
+}
+
+func (r *memoryDataExportRepo) GetActiveByUserID(_ context.Context, userID uuid.UUID, since time.Time) (*model.DataExport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, export := range r.exports {
+		active := export.Status == model.DataExportPending || export.Status == model.DataExportProcessing
+		if export.UserID == userID && active && export.CreatedAt.After(since) {
+			copied := *export
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryDataExportRepo) GetByTokenHash(_ context.Context, tokenHash string) (*model.DataExport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, export := range r.exports {
+		if export.TokenHash == tokenHash && export.Status == model.DataExportCompleted && export.ExpiresAt.After(time.Now()) {
+			copied := *export
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryDataExportRepo) MarkProcessing(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exports[id].Status = model.DataExportProcessing
+	return nil
+}
+
+func (r *memoryDataExportRepo) Complete(_ context.Context, export *model.DataExport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	export.Status = model.DataExportCompleted
+	export.CompletedAt = &now
+	copied := *export
+	r.exports[export.ID] = &copied
+	return nil
+}
+
+func (r *memoryDataExportRepo) Fail(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exports[id].Status = model.DataExportFailed
+	return nil
+}
+
+func (r *memoryDataExportRepo) FailStale(_ context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, export := range r.exports {
+		active := export.Status == model.DataExportPending || export.Status == model.DataExportProcessing
+		if active && export.CreatedAt.Before(before) {
+			export.Status = model.DataExportFailed
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryDataExportRepo) ListExpired(_ context.Context, now time.Time, limit int) ([]*model.DataExport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var exports []*model.DataExport
+	for _, export := range r.exports {
+		if !export.ExpiresAt.After(now) && len(exports) < limit {
+			copied := *export
+			exports = append(exports, &copied)
+		}
+	}
+	return exports, nil
+}
+
+func (r *memoryDataExportRepo) Delete(_ context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.exports, id)
+	return nil
+}
+
+type memoryStorage struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	putErr  error
+}
+
+func (s *memoryStorage) Put(_ context.Context, key string, r io.Reader) (int64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	if s.putErr != nil {
+		return 0, s.putErr
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = data
+	return int64(len(data)), nil
+}
+
+func (s *memoryStorage) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *memoryStorage) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, key)
+	return nil
+}
+
+type exportOrganizations []*model.Organization
+
+func (o exportOrganizations) GetByUserID(context.Context, uuid.UUID) ([]*model.Organization, error) {
+	return o, nil
+}
+
+type exportMemberships []*model.OrgMembership
+
+func (m exportMemberships) GetByUserID(context.Context, uuid.UUID) ([]*model.OrgMembership, error) {
+	return m, nil
+}
+
+type exportConsents []*model.UserConsent
+
+func (c exportConsents) GetByUserID(context.Context, uuid.UUID) ([]*model.UserConsent, error) {
+	return c, nil
+}
+
+type exportSessions []*model.RefreshToken
+
+func (s exportSessions) GetByUserID(context.Context, uuid.UUID) ([]*model.RefreshToken, error) {
+	return s, nil
+}
+
+type exportVerifications []*model.EmailVerification
+
+func (v exportVerifications) GetByUserID(context.Context, uuid.UUID) ([]*model.EmailVerification, error) {
+	return v, nil
+}
+
+type exportPasswordResets []*model.PasswordResetToken
+
+func (p exportPasswordResets) GetByUserID(context.Context, uuid.UUID) ([]*model.PasswordResetToken, error) {
+	return p, nil
+}
+
+type exportIdentities []*model.UserIdentity
+
+func (i exportIdentities) GetByUserID(context.Context, uuid.UUID) ([]*model.UserIdentity, error) {
+	return i, nil
+}
+
+type exportPasskeys []*model.WebAuthnCredential
+
+func (p exportPasskeys) GetByUserID(context.Context, uuid.UUID) ([]*model.WebAuthnCredential, error) {
+	return p, nil
+}
+
+type exportMFA struct{ enrollment *model.UserMFA }
+
+func (m exportMFA) GetByUserID(context.Context, uuid.UUID) (*model.UserMFA, error) {
+	return m.enrollment, nil
+}
+
+type exportRecoveryCodes []*model.MFARecoveryCode
+
+func (c exportRecoveryCodes) GetByUserID(context.Context, uuid.UUID) ([]*model.MFARecoveryCode, error) {
+	return c, nil
+}
+
+type exportEmailChanges []*model.EmailChangeRequest
+
+func (c exportEmailChanges) GetByUserID(context.Context, uuid.UUID) ([]*model.EmailChangeRequest, error) {
+	return c, nil
+}
+
+// exportInvitations returns the invitations addressed to the email.
+type exportInvitations []*model.OrgInvitation
+
+func (i exportInvitations) GetByEmail(_ context.Context, email string) ([]*model.OrgInvitation, error) {
+	var invitations []*model.OrgInvitation
+	for _, invitation := range i {
+		if invitation.Email == email {
+			invitations = append(invitations, invitation)
+		}
+	}
+	return invitations, nil
+}
+
+type exportAuditLogs []*model.AuditLog
+
+func (a exportAuditLogs) ForEachByUserID(_ context.Context, _ uuid.UUID, fn func(*model.AuditLog) error) error {
+	for _, entry := range a {
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type dataExportTestDeps struct {
+	exports *memoryDataExportRepo
+	storage *memoryStorage
+	emails  *recordingEmailSender
+	user    *model.User
+}
+
+func newTestDataExportService(auditLogs exportAuditLogs) (*DataExportService, *dataExportTestDeps) {
+	deps := &dataExportTestDeps{
+		exports: &memoryDataExportRepo{exports: map[uuid.UUID]*model.DataExport{}},
+		storage: &memoryStorage{objects: map[string][]byte{}},
+		emails:  &recordingEmailSender{exports: map[string]string{}},
+		user:    &model.User{ID: uuid.New(), Email: "jane@example.com", FullName: "Jane Doe", IsActive: true},
+	}
+
+	userRepo := new(MockUserRepo)
+	userRepo.On("GetByID", mock.Anything, deps.user.ID).Return(deps.user, nil)
+
+	orgID := uuid.New()
+	revokedAt := time.Now()
+	enabledAt := time.Now()
+	socialEmail := "jane@gmail.com"
+	svc := NewDataExportService(
+		deps.exports, userRepo,
+		exportOrganizations{{ID: orgID, Name: "Acme", OwnerUserID: deps.user.ID}},
+		exportMemberships{{ID: uuid.New(), UserID: deps.user.ID, OrgID: orgID, Role: model.RoleOwner, IsActive: true}},
+		exportConsents{{ID: uuid.New(), UserID: deps.user.ID, ConsentType: model.ConsentTypeTerms, Version: "1.0", Granted: true}},
+		exportSessions{{ID: uuid.New(), UserID: deps.user.ID, OrgID: orgID, UserAgent: "Firefox", RevokedAt: &revokedAt}},
+		exportVerifications{{ID: uuid.New(), UserID: deps.user.ID}}, exportPasswordResets{},
+		exportIdentities{{ID: uuid.New(), UserID: deps.user.ID, Provider: "google", Subject: "google-subject", Email: &socialEmail}},
+		exportPasskeys{{ID: uuid.New(), UserID: deps.user.ID, CredentialID: []byte("credential"), PublicKey: []byte("public-key"), Name: "Laptop"}},
+		exportMFA{&model.UserMFA{UserID: deps.user.ID, Secret: "TOTPSECRET", EnabledAt: &enabledAt}},
+		exportRecoveryCodes{{ID: uuid.New(), UserID: deps.user.ID, CodeHash: "recovery-code-hash"}},
+		exportEmailChanges{{ID: uuid.New(), UserID: deps.user.ID, NewEmail: "jane@new.example.com", TokenHash: "email-change-hash"}},
+		exportInvitations{
+			{ID: uuid.New(), OrgID: orgID, Email: deps.user.Email, Role: model.RoleMember, TokenHash: "invitation-hash"},
+			{ID: uuid.New(), OrgID: orgID, Email: "someone@example.com", Role: model.RoleMember},
+		},
+		auditLogs, deps.storage, nil, 0, "",
+	)
+	svc.emailSender = deps.emails
+	return svc, deps
+}
+
+func readArchive(t *testing.T, archive io.Reader) map[string][]byte {
+	t.Helper()
+	data, err := io.ReadAll(archive)
+	require.NoError(t, err)
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(r)
+		require.NoError(t, err)
+		files[f.Name] = content
+	}
+	return files
+}
+
+func TestDataExportService_ExportAndDownload(t *testing.T) {
+	ctx := context.Background()
+
+	// More entries than the previous inline export returned
+	var auditLogs exportAuditLogs
+	for i := 0; i < 1500; i++ {
+		auditLogs = append(auditLogs, &model.AuditLog{ID: uuid.New(), EventType: model.EventUserLoggedIn, EventData: map[string]interface{}{"method": "password"}})
+	}
+	svc, deps := newTestDataExportService(auditLogs)
+
+	export, err := svc.RequestExport(ctx, deps.user.ID, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, model.DataExportPending, export.Status)
+	require.NoError(t, svc.Wait(ctx))
+
+	export, err = svc.GetExport(ctx, deps.user.ID, export.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.DataExportCompleted, export.Status)
+	assert.NotNil(t, export.CompletedAt)
+	assert.Positive(t, export.SizeBytes)
+
+	rawToken := deps.emails.exports["jane@example.com"]
+	require.NotEmpty(t, rawToken)
+
+	downloaded, archive, err := svc.OpenDownload(ctx, rawToken, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, export.ID, downloaded.ID)
+	files := readArchive(t, archive)
+	require.NoError(t, archive.Close())
+
+	categories := []string{
+		"profile", "organizations", "memberships", "consents", "sessions", "email_verifications", "password_resets",
+		"social_identities", "passkeys", "mfa", "mfa_recovery_codes", "email_changes", "invitations", "audit_logs",
+	}
+	for _, category := range categories {
+		assert.Contains(t, files, category+".json")
+		assert.Contains(t, files, category+".csv")
+	}
+
+	// Secrets stay out of the archive
+	for name, content := range files {
+		for _, secret := range []string{"google-subject", "TOTPSECRET", "recovery-code-hash", "email-change-hash", "invitation-hash"} {
+			assert.NotContains(t, string(content), secret, name)
+		}
+	}
+
+	var invitations []map[string]interface{}
+	require.NoError(t, json.Unmarshal(files["invitations.json"], &invitations))
+	require.Len(t, invitations, 1)
+	assert.Equal(t, "jane@example.com", invitations[0]["email"])
+
+	var passkeys []map[string]interface{}
+	require.NoError(t, json.Unmarshal(files["passkeys.json"], &passkeys))
+	require.Len(t, passkeys, 1)
+	assert.Equal(t, "Laptop", passkeys[0]["name"])
+	assert.NotContains(t, passkeys[0], "public_key")
+
+	var profile map[string]interface{}
+	require.NoError(t, json.Unmarshal(files["profile.json"], &profile))
+	assert.Equal(t, "jane@example.com", profile["email"])
+	assert.NotContains(t, profile, "password_hash")
+
+	var sessions []map[string]interface{}
+	require.NoError(t, json.Unmarshal(files["sessions.json"], &sessions))
+	require.Len(t, sessions, 1)
+	assert.NotContains(t, sessions[0], "token_hash")
+
+	// Empty categories are empty lists
+	assert.JSONEq(t, "[]", string(files["password_resets.json"]))
+
+	var entries []map[string]interface{}
+	require.NoError(t, json.Unmarshal(files["audit_logs.json"], &entries))
+	assert.Len(t, entries, 1500)
+	records, err := csv.NewReader(bytes.NewReader(files["audit_logs.csv"])).ReadAll()
+	require.NoError(t, err)
+	assert.Len(t, records, 1501)
+	assert.Equal(t, `{"method":"password"}`, records[1][2])
+}
+
+func TestDataExportService_RequestReturnsExportInProgress(t *testing.T) {
+	ctx := context.Background()
+	svc, deps := newTestDataExportService(nil)
+
+	inProgress := &model.DataExport{UserID: deps.user.ID, Status: model.DataExportProcessing, ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, deps.exports.Create(ctx, inProgress))
+
+	export, err := svc.RequestExport(ctx, deps.user.ID, "", "")
+	require.NoError(t, err)
+	require.NoError(t, svc.Wait(ctx))
+	assert.Equal(t, inProgress.ID, export.ID)
+	assert.Len(t, deps.exports.exports, 1)
+}
+
+func TestDataExportService_AccessChecks(t *testing.T) {
+	ctx := context.Background()
+	svc, deps := newTestDataExportService(nil)
+
+	export, err := svc.RequestExport(ctx, deps.user.ID, "", "")
+	require.NoError(t, err)
+	require.NoError(t, svc.Wait(ctx))
+
+	// Exports of other users are not visible
+	_, err = svc.GetExport(ctx, uuid.New(), export.ID)
+	assert.ErrorIs(t, err, appErrors.ErrDataExportNotFound)
+	_, err = svc.GetExport(ctx, deps.user.ID, uuid.New())
+	assert.ErrorIs(t, err, appErrors.ErrDataExportNotFound)
+
+	_, _, err = svc.OpenDownload(ctx, "", "", "")
+	assert.ErrorIs(t, err, appErrors.ErrInvalidDataExportLink)
+	_, _, err = svc.OpenDownload(ctx, "unknown", "", "")
+	assert.ErrorIs(t, err, appErrors.ErrInvalidDataExportLink)
+
+	// The link stops working once it expires
+	rawToken := deps.emails.exports["jane@example.com"]
+	deps.exports.exports[export.ID].ExpiresAt = time.Now().Add(-time.Minute)
+	_, _, err = svc.OpenDownload(ctx, rawToken, "", "")
+	assert.ErrorIs(t, err, appErrors.ErrInvalidDataExportLink)
+}
+
+func TestDataExportService_StorageFailure(t *testing.T) {
+	ctx := context.Background()
+	svc, deps := newTestDataExportService(nil)
+	deps.storage.putErr = stdErrors.New("disk full")
+
+	export, err := svc.RequestExport(ctx, deps.user.ID, "", "")
+	require.NoError(t, err)
+	require.NoError(t, svc.Wait(ctx))
+
+	assert.Equal(t, model.DataExportFailed, deps.exports.get(export.ID).Status)
+	assert.Empty(t, deps.emails.exports)
+}
+
+func TestCleanupService_CleanupDataExports(t *testing.T) {
+	ctx := context.Background()
+	exports := &memoryDataExportRepo{exports: map[uuid.UUID]*model.DataExport{}}
+	exportStorage := &memoryStorage{objects: map[string][]byte{}}
+
+	expired := &model.DataExport{Status: model.DataExportCompleted, StorageKey: "expired.zip", ExpiresAt: time.Now().Add(-time.Minute)}
+	valid := &model.DataExport{Status: model.DataExportCompleted, StorageKey: "valid.zip", ExpiresAt: time.Now().Add(time.Hour)}
+	abandoned := &model.DataExport{Status: model.DataExportProcessing, ExpiresAt: time.Now().Add(time.Hour)}
+	for _, export := range []*model.DataExport{expired, valid, abandoned} {
+		require.NoError(t, exports.Create(ctx, export))
+	}
+	exports.exports[abandoned.ID].CreatedAt = time.Now().Add(-2 * dataExportTimeout)
+	exportStorage.objects["expired.zip"] = []byte("archive")
+	exportStorage.objects["valid.zip"] = []byte("archive")
+
+	svc := NewCleanupService(nil, nil, nil, nil, exports, exportStorage, nil, "")
+	deleted, err := svc.CleanupDataExports(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	assert.NotContains(t, exports.exports, expired.ID)
+	assert.NotContains(t, exportStorage.objects, "expired.zip")
+	assert.Contains(t, exportStorage.objects, "valid.zip")
+	assert.Equal(t, model.DataExportFailed, exports.get(abandoned.ID).Status)
+}
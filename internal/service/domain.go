@@ -0,0 +1,378 @@
+package service
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+const (
+	// The token is published as "zeno-auth-domain-verification=<token>" in a
+	// TXT record at "_zeno-auth-challenge.<domain>"
+	domainVerificationHost   = "_zeno-auth-challenge."
+	domainVerificationPrefix = "zeno-auth-domain-verification="
+
+	dnsLookupTimeout = 5 * time.Second
+)
+
+var domainLabelRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+type OrganizationDomainRepository interface {
+	Create(ctx context.Context, domain *model.OrganizationDomain) error
+	GetByID(ctx context.Context, orgID, id uuid.UUID) (*model.OrganizationDomain, error)
+	GetByOrgID(ctx context.Context, orgID uuid.UUID) ([]*model.OrganizationDomain, error)
+	GetVerified(ctx context.Context, domain string) (*model.OrganizationDomain, error)
+	MarkVerified(ctx context.Context, domain *model.OrganizationDomain) error
+	SetSSOEnforced(ctx context.Context, domain *model.OrganizationDomain) error
+	Delete(ctx context.Context, orgID, id uuid.UUID) (bool, error)
+}
+
+// DNSResolver looks up TXT records. *net.Resolver implements it; tests
+// substitute a fake.
+type DNSResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// DomainVerification is the DNS record that proves ownership of a domain.
+type DomainVerification struct {
+	Type  string
+	Name  string
+	Value string
+}
+
+// DomainService lets organization owners claim email domains, prove them
+// through DNS and require users of a verified domain to sign in through the
+// organization's SAML connection.
+type DomainService struct {
+	domainRepo     OrganizationDomainRepository
+	connRepo       SAMLConnectionRepository
+	membershipRepo MembershipRepository
+	resolver       DNSResolver
+	auditService   *AuditService
+}
+
+func NewDomainService(
+	domainRepo OrganizationDomainRepository,
+	connRepo SAMLConnectionRepository,
+	membershipRepo MembershipRepository,
+	resolver DNSResolver,
+	auditService *AuditService,
+) *DomainService {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return &DomainService{
+		domainRepo:     domainRepo,
+		connRepo:       connRepo,
+		membershipRepo: membershipRepo,
+		resolver:       resolver,
+		auditService:   auditService,
+	}
+}
+
+// VerificationRecord returns the TXT record to publish for the domain.
+func VerificationRecord(domain *model.OrganizationDomain) DomainVerification {
+	return DomainVerification{
+		Type:  "TXT",
+		Name:  domainVerificationHost + domain.Domain,
+		Value: domainVerificationPrefix + domain.VerificationToken,
+	}
+}
+
+func (s *DomainService) ListDomains(ctx context.Context, userID, orgID uuid.UUID) ([]*model.OrganizationDomain, error) {
+	if err := s.requireOrgOwner(ctx, userID, orgID); err != nil {
+		return nil, err
+	}
+
+	domains, err := s.domainRepo.GetByOrgID(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domains: %w", err)
+	}
+	return domains, nil
+}
+
+// ClaimDomain starts the verification of a domain for the organization.
+// Claiming a domain the organization already claimed returns that claim.
+func (s *DomainService) ClaimDomain(ctx context.Context, userID, orgID uuid.UUID, domainName, ipAddress, userAgent string) (*model.OrganizationDomain, error) {
+	if err := s.requireOrgOwner(ctx, userID, orgID); err != nil {
+		return nil, err
+	}
+
+	domainName, err := normalizeDomain(domainName)
+	if err != nil {
+		return nil, err
+	}
+
+	verified, err := s.domainRepo.GetVerified(ctx, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	if verified != nil && verified.OrgID != orgID {
+		return nil, errors.ErrDomainAlreadyClaimed
+	}
+
+	existing, err := s.domainRepo.GetByOrgID(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domains: %w", err)
+	}
+	for _, domain := range existing {
+		if domain.Domain == domainName {
+			return domain, nil
+		}
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+	domain := &model.OrganizationDomain{
+		OrgID:             orgID,
+		Domain:            domainName,
+		VerificationToken: token,
+	}
+	if err := s.domainRepo.Create(ctx, domain); err != nil {
+		return nil, fmt.Errorf("failed to create domain: %w", err)
+	}
+
+	s.audit(ctx, &userID, model.EventDomainClaimed, map[string]interface{}{
+		"org_id": orgID.String(),
+		"domain": domain.Domain,
+	}, ipAddress, userAgent)
+
+	return domain, nil
+}
+
+// VerifyDomain checks the verification record in DNS.
+func (s *DomainService) VerifyDomain(ctx context.Context, userID, orgID, domainID uuid.UUID, ipAddress, userAgent string) (*model.OrganizationDomain, error) {
+	domain, err := s.getDomain(ctx, userID, orgID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	if domain.IsVerified() {
+		return domain, nil
+	}
+
+	verified, err := s.domainRepo.GetVerified(ctx, domain.Domain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	if verified != nil {
+		return nil, errors.ErrDomainAlreadyClaimed
+	}
+
+	record := VerificationRecord(domain)
+	lookupCtx, cancel := context.WithTimeout(ctx, dnsLookupTimeout)
+	defer cancel()
+	values, err := s.resolver.LookupTXT(lookupCtx, record.Name)
+	if err != nil {
+		log.Info().Err(err).Str("domain", domain.Domain).Msg("Domain verification lookup failed")
+		return nil, errors.ErrDomainVerificationFailed
+	}
+	found := false
+	for _, value := range values {
+		if strings.TrimSpace(value) == record.Value {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, errors.ErrDomainVerificationFailed
+	}
+
+	if err := s.domainRepo.MarkVerified(ctx, domain); err != nil {
+		return nil, fmt.Errorf("failed to verify domain: %w", err)
+	}
+
+	s.audit(ctx, &userID, model.EventDomainVerified, map[string]interface{}{
+		"org_id": orgID.String(),
+		"domain": domain.Domain,
+	}, ipAddress, userAgent)
+
+	return domain, nil
+}
+
+// SetSSOEnforced turns SSO enforcement of a verified domain on or off. It
+// can only be turned on while the organization's SAML connection is enabled.
+func (s *DomainService) SetSSOEnforced(ctx context.Context, userID, orgID, domainID uuid.UUID, enforced bool, ipAddress, userAgent string) (*model.OrganizationDomain, error) {
+	domain, err := s.getDomain(ctx, userID, orgID, domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	if enforced {
+		if !domain.IsVerified() {
+			return nil, errors.ErrDomainNotVerified
+		}
+		conn, err := s.connRepo.GetByOrgID(ctx, orgID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get SAML connection: %w", err)
+		}
+		if conn == nil || !conn.Enabled {
+			return nil, errors.ErrSAMLNotConfigured
+		}
+	}
+	if domain.SSOEnforced == enforced {
+		return domain, nil
+	}
+
+	domain.SSOEnforced = enforced
+	if err := s.domainRepo.SetSSOEnforced(ctx, domain); err != nil {
+		return nil, fmt.Errorf("failed to update domain: %w", err)
+	}
+
+	s.audit(ctx, &userID, model.EventSSOEnforcementChanged, map[string]interface{}{
+		"org_id":   orgID.String(),
+		"domain":   domain.Domain,
+		"enforced": enforced,
+	}, ipAddress, userAgent)
+
+	return domain, nil
+}
+
+func (s *DomainService) DeleteDomain(ctx context.Context, userID, orgID, domainID uuid.UUID, ipAddress, userAgent string) error {
+	if err := s.requireOrgOwner(ctx, userID, orgID); err != nil {
+		return err
+	}
+
+	deleted, err := s.domainRepo.Delete(ctx, orgID, domainID)
+	if err != nil {
+		return fmt.Errorf("failed to delete domain: %w", err)
+	}
+	if !deleted {
+		return errors.ErrNotFound
+	}
+
+	s.audit(ctx, &userID, model.EventDomainRemoved, map[string]interface{}{
+		"org_id":    orgID.String(),
+		"domain_id": domainID.String(),
+	}, ipAddress, userAgent)
+
+	return nil
+}
+
+// SSOConnection returns the verified claim on the email's domain if the
+// claiming organization has an enabled SAML connection, or nil.
+func (s *DomainService) SSOConnection(ctx context.Context, email string) (*model.OrganizationDomain, error) {
+	domainName := emailDomain(email)
+	if domainName == "" {
+		return nil, nil
+	}
+
+	domain, err := s.domainRepo.GetVerified(ctx, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	if domain == nil {
+		return nil, nil
+	}
+
+	conn, err := s.connRepo.GetByOrgID(ctx, domain.OrgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SAML connection: %w", err)
+	}
+	if conn == nil || !conn.Enabled {
+		return nil, nil
+	}
+	return domain, nil
+}
+
+// SSOEnforcement returns the claim that requires users with the email to
+// sign in through SSO, or nil. Enforcement lapses while the connection is
+// disabled so that users are not locked out.
+func (s *DomainService) SSOEnforcement(ctx context.Context, email string) (*model.OrganizationDomain, error) {
+	domain, err := s.SSOConnection(ctx, email)
+	if err != nil || domain == nil || !domain.SSOEnforced {
+		return nil, err
+	}
+	return domain, nil
+}
+
+// IsOrgOwner reports whether the user is an active owner of the organization.
+func (s *DomainService) IsOrgOwner(ctx context.Context, userID, orgID uuid.UUID) (bool, error) {
+	membership, err := s.membershipRepo.GetByUserAndOrg(ctx, userID, orgID)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get membership: %w", err)
+	}
+	return membership != nil && membership.IsActive && membership.Role == model.RoleOwner, nil
+}
+
+func (s *DomainService) getDomain(ctx context.Context, userID, orgID, domainID uuid.UUID) (*model.OrganizationDomain, error) {
+	if err := s.requireOrgOwner(ctx, userID, orgID); err != nil {
+		return nil, err
+	}
+
+	domain, err := s.domainRepo.GetByID(ctx, orgID, domainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	if domain == nil {
+		return nil, errors.ErrNotFound
+	}
+	return domain, nil
+}
+
+func (s *DomainService) requireOrgOwner(ctx context.Context, userID, orgID uuid.UUID) error {
+	owner, err := s.IsOrgOwner(ctx, userID, orgID)
+	if err != nil {
+		return err
+	}
+	if !owner {
+		return errors.ErrForbidden
+	}
+	return nil
+}
+
+func (s *DomainService) audit(ctx context.Context, userID *uuid.UUID, eventType model.AuditEventType, data map[string]interface{}, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	if err := s.auditService.Log(ctx, userID, eventType, data, ipAddress, userAgent); err != nil {
+		log.Error().Err(err).Str("event", string(eventType)).Msg("Failed to log domain audit event")
+	}
+}
+
+// normalizeDomain lowercases a host name and checks it is a registrable
+// domain rather than an IP address or a single label.
+func normalizeDomain(domain string) (string, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if len(domain) > 253 {
+		return "", errors.ErrInvalidDomain
+	}
+
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return "", errors.ErrInvalidDomain
+	}
+	for _, label := range labels {
+		if !domainLabelRegex.MatchString(label) {
+			return "", errors.ErrInvalidDomain
+		}
+	}
+	if strings.Trim(labels[len(labels)-1], "0123456789") == "" {
+		return "", errors.ErrInvalidDomain
+	}
+	return domain, nil
+}
+
+// emailDomain returns the lowercased domain of an email address.
+func emailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(email[at+1:]))
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+type memoryDomainRepo struct {
+	domains []*model.OrganizationDomain
+}
+
+func (r *memoryDomainRepo) Create(_ context.Context, domain *model.OrganizationDomain) error {
+	domain.ID = uuid.New()
+	domain.CreatedAt = time.Now()
+	r.domains = append(r.domains, domain)
+	return nil
+}
+
+func (r *memoryDomainRepo) GetByID(_ context.Context, orgID, id uuid.UUID) (*model.OrganizationDomain, error) {
+	for _, domain := range r.domains {
+		if domain.ID == id && domain.OrgID == orgID {
+			return domain, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryDomainRepo) GetByOrgID(_ context.Context, orgID uuid.UUID) ([]*model.OrganizationDomain, error) {
+	var domains []*model.OrganizationDomain
+	for _, domain := range r.domains {
+		if domain.OrgID == orgID {
+			domains = append(domains, domain)
+		}
+	}
+	return domains, nil
+}
+
+func (r *memoryDomainRepo) GetVerified(_ context.Context, name string) (*model.OrganizationDomain, error) {
+	for _, domain := range r.domains {
+		if domain.Domain == name && domain.IsVerified() {
+			return domain, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryDomainRepo) MarkVerified(_ context.Context, domain *model.OrganizationDomain) error {
+	now := time.Now()
+	domain.VerifiedAt = &now
+	return nil
+}
+
+func (r *memoryDomainRepo) SetSSOEnforced(context.Context, *model.OrganizationDomain) error {
+	return nil
+}
+
+func (r *memoryDomainRepo) Delete(_ context.Context, orgID, id uuid.UUID) (bool, error) {
+	for i, domain := range r.domains {
+		if domain.ID == id && domain.OrgID == orgID {
+			r.domains = append(r.domains[:i], r.domains[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakeResolver serves TXT records from a map.
+type fakeResolver map[string][]string
+
+func (r fakeResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	values, ok := r[name]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return values, nil
+}
+
+type domainTestDeps struct {
+	domains     *memoryDomainRepo
+	conns       *memorySAMLConnectionRepo
+	memberships *MockMembershipRepo
+	resolver    fakeResolver
+}
+
+func newTestDomainService() (*DomainService, *domainTestDeps) {
+	deps := &domainTestDeps{
+		domains:     &memoryDomainRepo{},
+		conns:       &memorySAMLConnectionRepo{conns: map[uuid.UUID]*model.SAMLConnection{}},
+		memberships: new(MockMembershipRepo),
+		resolver:    fakeResolver{},
+	}
+	return NewDomainService(deps.domains, deps.conns, deps.memberships, deps.resolver, nil), deps
+}
+
+func TestNormalizeDomain(t *testing.T) {
+	valid := map[string]string{
+		"example.com":        "example.com",
+		" Corp.Example.COM.": "corp.example.com",
+		"xn--80ak6aa92e.com": "xn--80ak6aa92e.com",
+	}
+	for input, expected := range valid {
+		domain, err := normalizeDomain(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, domain)
+	}
+
+	for _, input := range []string{"", "localhost", "192.168.0.1", "-bad.com", "exa mple.com", "example..com", "user@example.com"} {
+		_, err := normalizeDomain(input)
+		assert.ErrorIs(t, err, appErrors.ErrInvalidDomain, input)
+	}
+}
+
+func TestDomainService_ClaimAndVerify(t *testing.T) {
+	ctx := context.Background()
+	userID, orgID := uuid.New(), uuid.New()
+	owner := &model.OrgMembership{UserID: userID, OrgID: orgID, Role: model.RoleOwner, IsActive: true}
+
+	t.Run("verifies the TXT record", func(t *testing.T) {
+		svc, deps := newTestDomainService()
+		deps.memberships.On("GetByUserAndOrg", ctx, userID, orgID).Return(owner, nil)
+
+		domain, err := svc.ClaimDomain(ctx, userID, orgID, "Example.com", "", "")
+		require.NoError(t, err)
+		assert.Equal(t, "example.com", domain.Domain)
+		assert.False(t, domain.IsVerified())
+
+		// Claiming again returns the same claim
+		again, err := svc.ClaimDomain(ctx, userID, orgID, "example.com", "", "")
+		require.NoError(t, err)
+		assert.Equal(t, domain.ID, again.ID)
+
+		_, err = svc.VerifyDomain(ctx, userID, orgID, domain.ID, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrDomainVerificationFailed)
+
+		record := VerificationRecord(domain)
+		assert.Equal(t, "_zeno-auth-challenge.example.com", record.Name)
+		deps.resolver[record.Name] = []string{"v=spf1 -all", record.Value}
+
+		verified, err := svc.VerifyDomain(ctx, userID, orgID, domain.ID, "", "")
+		require.NoError(t, err)
+		assert.True(t, verified.IsVerified())
+	})
+
+	t.Run("wrong token", func(t *testing.T) {
+		svc, deps := newTestDomainService()
+		deps.memberships.On("GetByUserAndOrg", ctx, userID, orgID).Return(owner, nil)
+
+		domain, err := svc.ClaimDomain(ctx, userID, orgID, "example.com", "", "")
+		require.NoError(t, err)
+		deps.resolver["_zeno-auth-challenge.example.com"] = []string{"zeno-auth-domain-verification=other"}
+
+		_, err = svc.VerifyDomain(ctx, userID, orgID, domain.ID, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrDomainVerificationFailed)
+	})
+
+	t.Run("domain verified by another organization", func(t *testing.T) {
+		svc, deps := newTestDomainService()
+		deps.memberships.On("GetByUserAndOrg", ctx, userID, orgID).Return(owner, nil)
+		now := time.Now()
+		deps.domains.domains = append(deps.domains.domains, &model.OrganizationDomain{ID: uuid.New(), OrgID: uuid.New(), Domain: "example.com", VerifiedAt: &now})
+
+		_, err := svc.ClaimDomain(ctx, userID, orgID, "example.com", "", "")
+		assert.ErrorIs(t, err, appErrors.ErrDomainAlreadyClaimed)
+	})
+
+	t.Run("only owners", func(t *testing.T) {
+		svc, deps := newTestDomainService()
+		admin := &model.OrgMembership{UserID: userID, OrgID: orgID, Role: model.RoleAdmin, IsActive: true}
+		deps.memberships.On("GetByUserAndOrg", ctx, userID, orgID).Return(admin, nil)
+
+		_, err := svc.ClaimDomain(ctx, userID, orgID, "example.com", "", "")
+		assert.ErrorIs(t, err, appErrors.ErrForbidden)
+	})
+}
+
+func TestDomainService_SSOEnforcement(t *testing.T) {
+	ctx := context.Background()
+	userID, orgID := uuid.New(), uuid.New()
+	owner := &model.OrgMembership{UserID: userID, OrgID: orgID, Role: model.RoleOwner, IsActive: true}
+
+	svc, deps := newTestDomainService()
+	deps.memberships.On("GetByUserAndOrg", ctx, userID, orgID).Return(owner, nil)
+	domain := &model.OrganizationDomain{ID: uuid.New(), OrgID: orgID, Domain: "example.com"}
+	deps.domains.domains = append(deps.domains.domains, domain)
+
+	_, err := svc.SetSSOEnforced(ctx, userID, orgID, domain.ID, true, "", "")
+	assert.ErrorIs(t, err, appErrors.ErrDomainNotVerified)
+
+	now := time.Now()
+	domain.VerifiedAt = &now
+	_, err = svc.SetSSOEnforced(ctx, userID, orgID, domain.ID, true, "", "")
+	assert.ErrorIs(t, err, appErrors.ErrSAMLNotConfigured)
+
+	deps.conns.conns[orgID] = &model.SAMLConnection{OrgID: orgID, Enabled: true}
+	updated, err := svc.SetSSOEnforced(ctx, userID, orgID, domain.ID, true, "", "")
+	require.NoError(t, err)
+	assert.True(t, updated.SSOEnforced)
+
+	enforcement, err := svc.SSOEnforcement(ctx, "Jane@Example.com")
+	require.NoError(t, err)
+	require.NotNil(t, enforcement)
+	assert.Equal(t, orgID, enforcement.OrgID)
+
+	enforcement, err = svc.SSOEnforcement(ctx, "jane@sub.example.com")
+	require.NoError(t, err)
+	assert.Nil(t, enforcement)
+
+	// Enforcement lapses while the connection is disabled
+	deps.conns.conns[orgID].Enabled = false
+	enforcement, err = svc.SSOEnforcement(ctx, "jane@example.com")
+	require.NoError(t, err)
+	assert.Nil(t, enforcement)
+}
+
+func TestAuthService_Login_SSOEnforced(t *testing.T) {
+	ctx := context.Background()
+	orgID := uuid.New()
+
+	domainSvc, deps := newTestDomainService()
+	now := time.Now()
+	deps.domains.domains = append(deps.domains.domains, &model.OrganizationDomain{ID: uuid.New(), OrgID: orgID, Domain: "example.com", VerifiedAt: &now, SSOEnforced: true})
+	deps.conns.conns[orgID] = &model.SAMLConnection{OrgID: orgID, Enabled: true}
+
+	userRepo := new(MockUserRepo)
+	hasher := new(MockPasswordHasher)
+	svc := NewAuthService(userRepo, nil, nil, nil, newTestJWTManager(t), nil, hasher, nil, nil, nil, nil, nil, domainSvc, nil, nil, nil, nil, &Config{}, nil)
+
+	member := &model.User{ID: uuid.New(), Email: "member@example.com", PasswordHash: "hash", IsActive: true}
+	owner := &model.User{ID: uuid.New(), Email: "owner@example.com", PasswordHash: "hash", IsActive: true}
+	userRepo.On("GetByEmail", ctx, member.Email).Return(member, nil)
+	userRepo.On("GetByEmail", ctx, owner.Email).Return(owner, nil)
+	userRepo.On("GetByEmail", ctx, "nobody@example.com").Return(nil, pgx.ErrNoRows)
+	deps.memberships.On("GetByUserAndOrg", ctx, member.ID, orgID).Return(&model.OrgMembership{Role: model.RoleMember, IsActive: true}, nil)
+	deps.memberships.On("GetByUserAndOrg", ctx, owner.ID, orgID).Return(&model.OrgMembership{Role: model.RoleOwner, IsActive: true}, nil)
+	hasher.On("Verify", ctx, "wrong", "hash").Return(false, nil)
+	hasher.On("Verify", ctx, "password", "hash").Return(true, nil)
+	userRepo.On("Update", ctx, mock.Anything).Return(nil)
+
+	_, err := svc.Login(ctx, "member@example.com", "password", uuid.Nil, "", "")
+	assert.ErrorIs(t, err, appErrors.ErrSSORequired)
+
+	// Without valid credentials the answer does not reveal whether the
+	// account exists or belongs to an owner
+	_, err = svc.Login(ctx, "member@example.com", "wrong", uuid.Nil, "", "")
+	assert.ErrorIs(t, err, appErrors.ErrInvalidCredentials)
+	_, err = svc.Login(ctx, "nobody@example.com", "password", uuid.Nil, "", "")
+	assert.ErrorIs(t, err, appErrors.ErrInvalidCredentials)
+	_, err = svc.Login(ctx, "owner@example.com", "wrong", uuid.Nil, "", "")
+	assert.ErrorIs(t, err, appErrors.ErrInvalidCredentials)
+}
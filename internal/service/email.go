@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -149,40 +150,6 @@ func hashToken(token string) string {
 	return hex.EncodeToString(hash[:])
 }
 
-// SendAccountDeletionNotification sends email notification when account is deleted (GDPR Art. 34)
-func (s *EmailService) SendAccountDeletionNotification(ctx context.Context, userID uuid.UUID) error {
-	user, err := s.userRepo.GetByID(ctx, userID)
-	if err != nil {
-		log.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to get user for deletion notification")
-		return err
-	}
-
-	// TODO: Send actual email via SendGrid/AWS SES
-	log.Info().
-		Str("user_id", userID.String()).
-		Str("email", user.Email).
-		Msg("Account deletion notification (email sending not implemented)")
-
-	return nil
-}
-
-// SendDataExportNotification sends email notification when data is exported (GDPR Art. 34)
-func (s *EmailService) SendDataExportNotification(ctx context.Context, userID uuid.UUID) error {
-	user, err := s.userRepo.GetByID(ctx, userID)
-	if err != nil {
-		log.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to get user for export notification")
-		return err
-	}
-
-	// TODO: Send actual email via SendGrid/AWS SES
-	log.Info().
-		Str("user_id", userID.String()).
-		Str("email", user.Email).
-		Msg("Data export notification (email sending not implemented)")
-
-	return nil
-}
-
 // SendAccountLockoutNotification sends email notification when account is locked due to failed login attempts
 func (s *EmailService) SendAccountLockoutNotification(
 	ctx context.Context,
@@ -196,7 +163,7 @@ func (s *EmailService) SendAccountLockoutNotification(
 	}
 
 	if s.emailSender != nil {
-		if err := s.emailSender.SendAccountLockoutEmail(ctx, user.Email, lockedUntil.Format("2006-01-02 15:04:05 MST")); err != nil {
+		if err := s.emailSender.SendAccountLockoutEmail(ctx, user.Email, formatTimeForUser(lockedUntil, user)); err != nil {
 			log.Error().Err(err).Str("email", user.Email).Msg("Failed to send lockout email")
 		}
 	}
@@ -221,3 +188,34 @@ func (s *EmailService) SendPasswordChangedNotification(ctx context.Context, user
 
 	return nil
 }
+
+// dateLayouts are the time formats of languages that do not use the ISO
+// order; other locales get "2006-01-02 15:04 MST".
+var dateLayouts = map[string]string{
+	"en-US": "Jan 2, 2006 3:04 PM MST",
+	"en":    "2 Jan 2006 15:04 MST",
+	"de":    "02.01.2006 15:04 MST",
+	"ru":    "02.01.2006 15:04 MST",
+	"pl":    "02.01.2006 15:04 MST",
+	"fr":    "02/01/2006 15:04 MST",
+	"es":    "02/01/2006 15:04 MST",
+	"it":    "02/01/2006 15:04 MST",
+}
+
+// formatTimeForUser formats t for emails in the time zone and locale of the
+// user. Email templates are English only; the locale only picks the date
+// format.
+func formatTimeForUser(t time.Time, user *model.User) string {
+	if loc, err := time.LoadLocation(user.Timezone); err == nil {
+		t = t.In(loc)
+	}
+
+	layout, ok := dateLayouts[user.Locale]
+	if !ok {
+		language, _, _ := strings.Cut(user.Locale, "-")
+		if layout, ok = dateLayouts[language]; !ok {
+			layout = "2006-01-02 15:04 MST"
+		}
+	}
+	return t.Format(layout)
+}
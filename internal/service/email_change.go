@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/token"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/validator"
+)
+
+const emailChangeTTL = 24 * time.Hour
+
+type EmailChangeRepository interface {
+	Create(ctx context.Context, request *model.EmailChangeRequest) error
+	Consume(ctx context.Context, tokenHash string) (*model.EmailChangeRequest, error)
+}
+
+type EmailChangeUserRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*model.User, error)
+	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	Update(ctx context.Context, user *model.User) error
+}
+
+type EmailChangeSessionRepository interface {
+	RevokeByUserID(ctx context.Context, userID uuid.UUID) error
+}
+
+// EmailChangeService changes the email address of an account. The change is
+// requested with the current password and only applied once the link sent to
+// the new address is confirmed; the old address is notified when it is
+// requested. Confirming ends all sessions of the user, since access tokens
+// carry the email address.
+type EmailChangeService struct {
+	requestRepo   EmailChangeRepository
+	userRepo      EmailChangeUserRepository
+	sessionRepo   EmailChangeSessionRepository
+	hasher        token.PasswordHasher
+	domainService *DomainService
+	tokenRevoker  TokenRevoker
+	auditService  *AuditService
+	emailSender   EmailSender
+}
+
+func NewEmailChangeService(
+	requestRepo EmailChangeRepository,
+	userRepo EmailChangeUserRepository,
+	sessionRepo EmailChangeSessionRepository,
+	hasher token.PasswordHasher,
+	domainService *DomainService,
+	tokenRevoker TokenRevoker,
+	auditService *AuditService,
+	frontendBaseURL string,
+) *EmailChangeService {
+	return &EmailChangeService{
+		requestRepo:   requestRepo,
+		userRepo:      userRepo,
+		sessionRepo:   sessionRepo,
+		hasher:        hasher,
+		domainService: domainService,
+		tokenRevoker:  tokenRevoker,
+		auditService:  auditService,
+		emailSender:   NewSendGridEmailSender(frontendBaseURL),
+	}
+}
+
+// RequestChange sends a confirmation link to newEmail and a notice to the
+// current address. Accounts without a password, such as social-only ones,
+// cannot change their address until they set one.
+func (s *EmailChangeService) RequestChange(ctx context.Context, userID uuid.UUID, currentPassword, newEmail, ipAddress, userAgent string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return errors.ErrUserNotFound
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user.PasswordHash == "" {
+		return errors.ErrInvalidCurrentPassword
+	}
+	valid, err := s.hasher.Verify(ctx, currentPassword, user.PasswordHash)
+	if err != nil || !valid {
+		return errors.ErrInvalidCurrentPassword
+	}
+
+	inputValidator := validator.NewInputValidator()
+	newEmail = inputValidator.SanitizeEmail(newEmail)
+	if err := inputValidator.ValidateEmail(newEmail); err != nil || newEmail == user.Email {
+		return errors.ErrInvalidInput
+	}
+
+	// Moving off a domain that enforces SSO would let the user sign in with
+	// a password again
+	if err := s.requireNoSSOEnforcement(ctx, user); err != nil {
+		return err
+	}
+
+	if err := s.requireAvailable(ctx, newEmail); err != nil {
+		return err
+	}
+
+	rawToken, err := generateToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate token: %w", err)
+	}
+	request := &model.EmailChangeRequest{
+		UserID:    user.ID,
+		NewEmail:  newEmail,
+		TokenHash: hashToken(rawToken),
+		ExpiresAt: time.Now().Add(emailChangeTTL),
+	}
+	if err := s.requestRepo.Create(ctx, request); err != nil {
+		return fmt.Errorf("failed to create email change request: %w", err)
+	}
+
+	s.audit(ctx, &user.ID, model.EventEmailChangeRequested, map[string]interface{}{
+		"new_email": newEmail,
+	}, ipAddress, userAgent)
+
+	if s.emailSender != nil {
+		if err := s.emailSender.SendEmailChangeConfirmationEmail(ctx, newEmail, rawToken); err != nil {
+			return fmt.Errorf("failed to send confirmation email: %w", err)
+		}
+		if err := s.emailSender.SendEmailChangeRequestedEmail(ctx, user.Email, newEmail); err != nil {
+			log.Error().Err(err).Str("user_id", user.ID.String()).Msg("Failed to send email change notice")
+		}
+	}
+	return nil
+}
+
+// ConfirmChange applies the change confirmed with the link sent to the new
+// address. It does not require a session: holding the link proves control
+// of the new address, and the password was checked when it was requested.
+func (s *EmailChangeService) ConfirmChange(ctx context.Context, rawToken, ipAddress, userAgent string) (*model.User, error) {
+	if rawToken == "" {
+		return nil, errors.ErrInvalidEmailChange
+	}
+
+	request, err := s.requestRepo.Consume(ctx, hashToken(rawToken))
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume email change request: %w", err)
+	}
+	if request == nil {
+		return nil, errors.ErrInvalidEmailChange
+	}
+
+	user, err := s.userRepo.GetByID(ctx, request.UserID)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.ErrInvalidEmailChange
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// The address may have been registered since the change was requested
+	if err := s.requireAvailable(ctx, request.NewEmail); err != nil {
+		return nil, err
+	}
+
+	oldEmail := user.Email
+	user.Email = request.NewEmail
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update email: %w", err)
+	}
+
+	if s.sessionRepo != nil {
+		if err := s.sessionRepo.RevokeByUserID(ctx, user.ID); err != nil {
+			log.Error().Err(err).Str("user_id", user.ID.String()).Msg("Failed to revoke sessions after email change")
+		}
+	}
+	revokeAccessTokens(ctx, s.tokenRevoker, user.ID)
+
+	s.audit(ctx, &user.ID, model.EventEmailChanged, map[string]interface{}{
+		"old_email": oldEmail,
+		"new_email": user.Email,
+	}, ipAddress, userAgent)
+	return user, nil
+}
+
+func (s *EmailChangeService) requireAvailable(ctx context.Context, email string) error {
+	_, err := s.userRepo.GetByEmail(ctx, email)
+	if err == nil {
+		return errors.ErrEmailAlreadyUsed
+	}
+	if !stdErrors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("failed to check email: %w", err)
+	}
+	return nil
+}
+
+// requireNoSSOEnforcement rejects the change while the domain of the current
+// address enforces SSO. As for sign-in, organization owners are exempt.
+func (s *EmailChangeService) requireNoSSOEnforcement(ctx context.Context, user *model.User) error {
+	if s.domainService == nil {
+		return nil
+	}
+	enforcement, err := s.domainService.SSOEnforcement(ctx, user.Email)
+	if err != nil {
+		return err
+	}
+	if enforcement == nil {
+		return nil
+	}
+	owner, err := s.domainService.IsOrgOwner(ctx, user.ID, enforcement.OrgID)
+	if err != nil {
+		return err
+	}
+	if !owner {
+		return errors.ErrSSORequired
+	}
+	return nil
+}
+
+func (s *EmailChangeService) audit(ctx context.Context, userID *uuid.UUID, eventType model.AuditEventType, data map[string]interface{}, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	if err := s.auditService.Log(ctx, userID, eventType, data, ipAddress, userAgent); err != nil {
+		log.Error().Err(err).Str("event", string(eventType)).Msg("Failed to log email change audit event")
+	}
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+type memoryEmailChangeRepo struct {
+	requests map[string]*model.EmailChangeRequest
+}
+
+func (r *memoryEmailChangeRepo) Create(_ context.Context, request *model.EmailChangeRequest) error {
+	for hash, existing := range r.requests {
+		if existing.UserID == request.UserID && existing.ConfirmedAt == nil {
+			delete(r.requests, hash)
+		}
+	}
+	request.ID = uuid.New()
+	request.CreatedAt = time.Now()
+	copied := *request
+	r.requests[request.TokenHash] = &copied
+	return nil
+}
+
+func (r *memoryEmailChangeRepo) Consume(_ context.Context, tokenHash string) (*model.EmailChangeRequest, error) {
+	request, ok := r.requests[tokenHash]
+	if !ok || request.ConfirmedAt != nil || !request.ExpiresAt.After(time.Now()) {
+		return nil, nil
+	}
+	now := time.Now()
+	request.ConfirmedAt = &now
+	copied := *request
+	return &copied, nil
+}
+
+type emailChangeTestDeps struct {
+	directory *memoryDirectory
+	sessions  *memoryAdminSessionRepo
+	revoker   *recordingRevoker
+	emails    *recordingEmailSender
+	user      *model.User
+}
+
+func newTestEmailChangeService() (*EmailChangeService, *emailChangeTestDeps) {
+	directory := &memoryDirectory{users: map[uuid.UUID]*model.User{}}
+	deps := &emailChangeTestDeps{
+		directory: directory,
+		sessions:  &memoryAdminSessionRepo{},
+		revoker:   &recordingRevoker{},
+		emails:    &recordingEmailSender{emailChanges: map[string]string{}, notices: map[string]string{}},
+	}
+	deps.user = directory.addUser("jane@example.com")
+	deps.user.PasswordHash = "hash"
+
+	hasher := new(MockPasswordHasher)
+	hasher.On("Verify", mock.Anything, "correct-password", "hash").Return(true, nil)
+	hasher.On("Verify", mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+
+	svc := NewEmailChangeService(&memoryEmailChangeRepo{requests: map[string]*model.EmailChangeRequest{}},
+		memorySCIMUserRepo{directory}, deps.sessions, hasher, nil, deps.revoker, nil, "")
+	svc.emailSender = deps.emails
+	return svc, deps
+}
+
+func TestEmailChangeService_RequestAndConfirm(t *testing.T) {
+	ctx := context.Background()
+	svc, deps := newTestEmailChangeService()
+
+	require.NoError(t, svc.RequestChange(ctx, deps.user.ID, "correct-password", " Jane.New@Example.com ", "", ""))
+	rawToken := deps.emails.emailChanges["jane.new@example.com"]
+	require.NotEmpty(t, rawToken)
+	assert.Equal(t, "jane.new@example.com", deps.emails.notices["jane@example.com"])
+
+	// The address is only swapped after confirmation
+	assert.Equal(t, "jane@example.com", deps.directory.users[deps.user.ID].Email)
+
+	user, err := svc.ConfirmChange(ctx, rawToken, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "jane.new@example.com", user.Email)
+	assert.Equal(t, "jane.new@example.com", deps.directory.users[deps.user.ID].Email)
+	assert.Equal(t, []uuid.UUID{deps.user.ID}, deps.sessions.revoked)
+	assert.Equal(t, []uuid.UUID{deps.user.ID}, deps.revoker.users)
+
+	// Single use
+	_, err = svc.ConfirmChange(ctx, rawToken, "", "")
+	assert.ErrorIs(t, err, appErrors.ErrInvalidEmailChange)
+	_, err = svc.ConfirmChange(ctx, "", "", "")
+	assert.ErrorIs(t, err, appErrors.ErrInvalidEmailChange)
+}
+
+func TestEmailChangeService_RequestValidation(t *testing.T) {
+	ctx := context.Background()
+	svc, deps := newTestEmailChangeService()
+	deps.directory.addUser("taken@example.com")
+	socialOnly := deps.directory.addUser("social@example.com")
+
+	tests := []struct {
+		name     string
+		userID   uuid.UUID
+		password string
+		newEmail string
+		wantErr  error
+	}{
+		{"wrong password", deps.user.ID, "wrong-password", "new@example.com", appErrors.ErrInvalidCurrentPassword},
+		{"no password set", socialOnly.ID, "correct-password", "new@example.com", appErrors.ErrInvalidCurrentPassword},
+		{"invalid address", deps.user.ID, "correct-password", "not-an-email", appErrors.ErrInvalidInput},
+		{"same address", deps.user.ID, "correct-password", "Jane@Example.com", appErrors.ErrInvalidInput},
+		{"address taken", deps.user.ID, "correct-password", "taken@example.com", appErrors.ErrEmailAlreadyUsed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := svc.RequestChange(ctx, tt.userID, tt.password, tt.newEmail, "", "")
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+	assert.Empty(t, deps.emails.emailChanges)
+	assert.Empty(t, deps.emails.notices)
+}
+
+func TestEmailChangeService_ConfirmRechecksAvailability(t *testing.T) {
+	ctx := context.Background()
+	svc, deps := newTestEmailChangeService()
+
+	require.NoError(t, svc.RequestChange(ctx, deps.user.ID, "correct-password", "first@example.com", "", ""))
+	first := deps.emails.emailChanges["first@example.com"]
+	require.NoError(t, svc.RequestChange(ctx, deps.user.ID, "correct-password", "second@example.com", "", ""))
+	second := deps.emails.emailChanges["second@example.com"]
+
+	// A new request replaces the pending one
+	_, err := svc.ConfirmChange(ctx, first, "", "")
+	assert.ErrorIs(t, err, appErrors.ErrInvalidEmailChange)
+
+	// The address was registered by someone else in the meantime
+	deps.directory.addUser("second@example.com")
+	_, err = svc.ConfirmChange(ctx, second, "", "")
+	assert.ErrorIs(t, err, appErrors.ErrEmailAlreadyUsed)
+	assert.Equal(t, "jane@example.com", deps.directory.users[deps.user.ID].Email)
+	assert.Empty(t, deps.sessions.revoked)
+}
@@ -16,6 +16,13 @@ type EmailSender interface {
 	SendPasswordResetEmail(ctx context.Context, toEmail, token string) error
 	SendPasswordChangedEmail(ctx context.Context, toEmail string) error
 	SendAccountLockoutEmail(ctx context.Context, toEmail, lockedUntil string) error
+	SendInvitationEmail(ctx context.Context, toEmail, orgName, token string) error
+	SendMagicLinkEmail(ctx context.Context, toEmail, token string) error
+	SendEmailChangeConfirmationEmail(ctx context.Context, toEmail, token string) error
+	SendEmailChangeRequestedEmail(ctx context.Context, toEmail, newEmail string) error
+	SendAccountDeletionScheduledEmail(ctx context.Context, toEmail, token, purgeAfter string) error
+	SendAccountDeletionCancelledEmail(ctx context.Context, toEmail string) error
+	SendDataExportReadyEmail(ctx context.Context, toEmail, token, expiresAt string) error
 }
 
 type SendGridEmailSender struct {
@@ -280,6 +287,454 @@ ZenoN Cloud Team
 	return nil
 }
 
+func (s *SendGridEmailSender) SendInvitationEmail(ctx context.Context, toEmail, orgName, token string) error {
+	if s.apiKey == "" {
+		log.Warn().Str("to", html.EscapeString(toEmail)).Msg("SendGrid API key not set, skipping email")
+		return nil
+	}
+
+	acceptURL := fmt.Sprintf("%s#/accept-invitation?token=%s", s.baseURL, token)
+
+	from := mail.NewEmail(s.fromName, s.fromEmail)
+	to := mail.NewEmail("", toEmail)
+	subject := fmt.Sprintf("You have been invited to join %s", orgName)
+
+	plainTextContent := fmt.Sprintf(`Hello,
+
+You have been invited to join %s on ZenoN Cloud. Click the link below to accept the invitation:
+
+%s
+
+This link will expire in 7 days.
+
+If you were not expecting this invitation, please ignore this email.
+
+Best regards,
+ZenoN Cloud Team
+`, orgName, acceptURL)
+
+	htmlContent := fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+</head>
+<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
+    <div style="max-width: 600px; margin: 0 auto; padding: 20px;">
+        <h2 style="color: #2563eb;">Join %s</h2>
+        <p>Hello,</p>
+        <p>You have been invited to join <strong>%s</strong> on ZenoN Cloud. Click the button below to accept the invitation:</p>
+        <div style="margin: 30px 0;">
+            <a href="%s" style="background-color: #2563eb; color: white; padding: 12px 30px; text-decoration: none; border-radius: 5px; display: inline-block;">Accept Invitation</a>
+        </div>
+        <p style="color: #666; font-size: 14px;">Or copy and paste this link into your browser:</p>
+        <p style="color: #666; font-size: 14px; word-break: break-all;">%s</p>
+        <p style="color: #666; font-size: 14px;">This link will expire in 7 days.</p>
+        <p style="color: #666; font-size: 14px;">If you were not expecting this invitation, please ignore this email.</p>
+        <hr style="border: none; border-top: 1px solid #eee; margin: 30px 0;">
+        <p style="color: #999; font-size: 12px;">Best regards,<br>ZenoN Cloud Team</p>
+    </div>
+</body>
+</html>`, html.EscapeString(orgName), html.EscapeString(orgName), html.EscapeString(acceptURL), html.EscapeString(acceptURL))
+
+	message := mail.NewSingleEmail(from, subject, to, plainTextContent, htmlContent)
+	client := sendgrid.NewSendClient(s.apiKey)
+
+	response, err := client.Send(message)
+	if err != nil {
+		log.Error().Err(err).Str("to", html.EscapeString(toEmail)).Msg("Failed to send invitation email")
+		return err
+	}
+
+	if response.StatusCode >= 400 {
+		log.Error().Int("status", response.StatusCode).Str("to", html.EscapeString(toEmail)).Msg("SendGrid returned error")
+		return fmt.Errorf("sendgrid error: %d", response.StatusCode)
+	}
+
+	log.Info().Str("to", html.EscapeString(toEmail)).Msg("Invitation email sent")
+	return nil
+}
+
+func (s *SendGridEmailSender) SendMagicLinkEmail(ctx context.Context, toEmail, token string) error {
+	if s.apiKey == "" {
+		log.Warn().Str("to", html.EscapeString(toEmail)).Msg("SendGrid API key not set, skipping email")
+		return nil
+	}
+
+	signInURL := fmt.Sprintf("%s#/magic-link?token=%s", s.baseURL, token)
+
+	from := mail.NewEmail(s.fromName, s.fromEmail)
+	to := mail.NewEmail("", toEmail)
+	subject := "Your sign-in link"
+
+	plainTextContent := fmt.Sprintf(`Hello,
+
+Click the link below to sign in to ZenoN Cloud:
+
+%s
+
+This link will expire in 15 minutes and can only be used once.
+
+If you did not request this link, please ignore this email. Your account is safe.
+
+Best regards,
+ZenoN Cloud Team
+`, signInURL)
+
+	htmlContent := fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+</head>
+<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
+    <div style="max-width: 600px; margin: 0 auto; padding: 20px;">
+        <h2 style="color: #2563eb;">Sign in to ZenoN Cloud</h2>
+        <p>Hello,</p>
+        <p>Click the button below to sign in:</p>
+        <div style="margin: 30px 0;">
+            <a href="%s" style="background-color: #2563eb; color: white; padding: 12px 30px; text-decoration: none; border-radius: 5px; display: inline-block;">Sign In</a>
+        </div>
+        <p style="color: #666; font-size: 14px;">Or copy and paste this link into your browser:</p>
+        <p style="color: #666; font-size: 14px; word-break: break-all;">%s</p>
+        <p style="color: #666; font-size: 14px;">This link will expire in 15 minutes and can only be used once.</p>
+        <p style="color: #666; font-size: 14px;">If you did not request this link, please ignore this email. Your account is safe.</p>
+        <hr style="border: none; border-top: 1px solid #eee; margin: 30px 0;">
+        <p style="color: #999; font-size: 12px;">Best regards,<br>ZenoN Cloud Team</p>
+    </div>
+</body>
+</html>`, html.EscapeString(signInURL), html.EscapeString(signInURL))
+
+	message := mail.NewSingleEmail(from, subject, to, plainTextContent, htmlContent)
+	client := sendgrid.NewSendClient(s.apiKey)
+
+	response, err := client.Send(message)
+	if err != nil {
+		log.Error().Err(err).Str("to", html.EscapeString(toEmail)).Msg("Failed to send magic link email")
+		return err
+	}
+
+	if response.StatusCode >= 400 {
+		log.Error().Int("status", response.StatusCode).Str("to", html.EscapeString(toEmail)).Msg("SendGrid returned error")
+		return fmt.Errorf("sendgrid error: %d", response.StatusCode)
+	}
+
+	log.Info().Str("to", html.EscapeString(toEmail)).Msg("Magic link email sent")
+	return nil
+}
+
+func (s *SendGridEmailSender) SendEmailChangeConfirmationEmail(ctx context.Context, toEmail, token string) error {
+	if s.apiKey == "" {
+		log.Warn().Str("to", html.EscapeString(toEmail)).Msg("SendGrid API key not set, skipping email")
+		return nil
+	}
+
+	confirmURL := fmt.Sprintf("%s#/confirm-email-change?token=%s", s.baseURL, token)
+
+	from := mail.NewEmail(s.fromName, s.fromEmail)
+	to := mail.NewEmail("", toEmail)
+	subject := "Confirm your new email address"
+
+	plainTextContent := fmt.Sprintf(`Hello,
+
+You asked to use this address for your ZenoN Cloud account. Click the link below to confirm the change:
+
+%s
+
+This link will expire in 24 hours. Until you confirm, your account keeps its current address.
+
+If you did not request this change, please ignore this email.
+
+Best regards,
+ZenoN Cloud Team
+`, confirmURL)
+
+	htmlContent := fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+</head>
+<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
+    <div style="max-width: 600px; margin: 0 auto; padding: 20px;">
+        <h2 style="color: #2563eb;">Confirm Your New Email Address</h2>
+        <p>Hello,</p>
+        <p>You asked to use this address for your ZenoN Cloud account. Click the button below to confirm the change:</p>
+        <div style="margin: 30px 0;">
+            <a href="%s" style="background-color: #2563eb; color: white; padding: 12px 30px; text-decoration: none; border-radius: 5px; display: inline-block;">Confirm Email</a>
+        </div>
+        <p style="color: #666; font-size: 14px;">Or copy and paste this link into your browser:</p>
+        <p style="color: #666; font-size: 14px; word-break: break-all;">%s</p>
+        <p style="color: #666; font-size: 14px;">This link will expire in 24 hours. Until you confirm, your account keeps its current address.</p>
+        <p style="color: #666; font-size: 14px;">If you did not request this change, please ignore this email.</p>
+        <hr style="border: none; border-top: 1px solid #eee; margin: 30px 0;">
+        <p style="color: #999; font-size: 12px;">Best regards,<br>ZenoN Cloud Team</p>
+    </div>
+</body>
+</html>`, html.EscapeString(confirmURL), html.EscapeString(confirmURL))
+
+	message := mail.NewSingleEmail(from, subject, to, plainTextContent, htmlContent)
+	client := sendgrid.NewSendClient(s.apiKey)
+
+	response, err := client.Send(message)
+	if err != nil {
+		log.Error().Err(err).Str("to", html.EscapeString(toEmail)).Msg("Failed to send email change confirmation")
+		return err
+	}
+
+	if response.StatusCode >= 400 {
+		log.Error().Int("status", response.StatusCode).Str("to", html.EscapeString(toEmail)).Msg("SendGrid returned error")
+		return fmt.Errorf("sendgrid error: %d", response.StatusCode)
+	}
+
+	log.Info().Str("to", html.EscapeString(toEmail)).Msg("Email change confirmation sent")
+	return nil
+}
+
+func (s *SendGridEmailSender) SendEmailChangeRequestedEmail(ctx context.Context, toEmail, newEmail string) error {
+	if s.apiKey == "" {
+		log.Warn().Str("to", html.EscapeString(toEmail)).Msg("SendGrid API key not set, skipping email")
+		return nil
+	}
+
+	from := mail.NewEmail(s.fromName, s.fromEmail)
+	to := mail.NewEmail("", toEmail)
+	subject := "Your email address is being changed"
+
+	plainTextContent := fmt.Sprintf(`Hello,
+
+A request was made to change the email address of your ZenoN Cloud account to %s.
+
+The change takes effect once it is confirmed from the new address.
+
+If you did not make this request, change your password and contact support immediately.
+
+Best regards,
+ZenoN Cloud Team
+`, newEmail)
+
+	htmlContent := fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+</head>
+<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
+    <div style="max-width: 600px; margin: 0 auto; padding: 20px;">
+        <h2 style="color: #2563eb;">Email Change Requested</h2>
+        <p>Hello,</p>
+        <p>A request was made to change the email address of your ZenoN Cloud account to <strong>%s</strong>.</p>
+        <p>The change takes effect once it is confirmed from the new address.</p>
+        <p style="color: #dc2626; font-weight: bold;">If you did not make this request, change your password and contact support immediately.</p>
+        <hr style="border: none; border-top: 1px solid #eee; margin: 30px 0;">
+        <p style="color: #999; font-size: 12px;">Best regards,<br>ZenoN Cloud Team</p>
+    </div>
+</body>
+</html>`, html.EscapeString(newEmail))
+
+	message := mail.NewSingleEmail(from, subject, to, plainTextContent, htmlContent)
+	client := sendgrid.NewSendClient(s.apiKey)
+
+	response, err := client.Send(message)
+	if err != nil {
+		log.Error().Err(err).Str("to", html.EscapeString(toEmail)).Msg("Failed to send email change notice")
+		return err
+	}
+
+	if response.StatusCode >= 400 {
+		log.Error().Int("status", response.StatusCode).Str("to", html.EscapeString(toEmail)).Msg("SendGrid returned error")
+		return fmt.Errorf("sendgrid error: %d", response.StatusCode)
+	}
+
+	log.Info().Str("to", html.EscapeString(toEmail)).Msg("Email change notice sent")
+	return nil
+}
+
+func (s *SendGridEmailSender) SendAccountDeletionScheduledEmail(ctx context.Context, toEmail, token, purgeAfter string) error {
+	if s.apiKey == "" {
+		log.Warn().Str("to", html.EscapeString(toEmail)).Msg("SendGrid API key not set, skipping email")
+		return nil
+	}
+
+	cancelURL := fmt.Sprintf("%s#/cancel-account-deletion?token=%s", s.baseURL, token)
+
+	from := mail.NewEmail(s.fromName, s.fromEmail)
+	to := mail.NewEmail("", toEmail)
+	subject := "Your account is scheduled for deletion"
+
+	plainTextContent := fmt.Sprintf(`Hello,
+
+We received a request to delete your ZenoN Cloud account. Your account has been deactivated and will be permanently deleted on %s.
+
+If you changed your mind, click the link below to keep your account:
+
+%s
+
+If you did not request this, use the link above and change your password immediately.
+
+Best regards,
+ZenoN Cloud Team
+`, purgeAfter, cancelURL)
+
+	htmlContent := fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+</head>
+<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
+    <div style="max-width: 600px; margin: 0 auto; padding: 20px;">
+        <h2 style="color: #dc2626;">Account Scheduled for Deletion</h2>
+        <p>Hello,</p>
+        <p>We received a request to delete your ZenoN Cloud account. Your account has been deactivated and will be permanently deleted on <strong>%s</strong>.</p>
+        <p>If you changed your mind, click the button below to keep your account:</p>
+        <div style="margin: 30px 0;">
+            <a href="%s" style="background-color: #2563eb; color: white; padding: 12px 30px; text-decoration: none; border-radius: 5px; display: inline-block;">Keep My Account</a>
+        </div>
+        <p style="color: #666; font-size: 14px;">Or copy and paste this link into your browser:</p>
+        <p style="color: #666; font-size: 14px; word-break: break-all;">%s</p>
+        <p style="color: #dc2626; font-weight: bold;">If you did not request this, use the link above and change your password immediately.</p>
+        <hr style="border: none; border-top: 1px solid #eee; margin: 30px 0;">
+        <p style="color: #999; font-size: 12px;">Best regards,<br>ZenoN Cloud Team</p>
+    </div>
+</body>
+</html>`, html.EscapeString(purgeAfter), html.EscapeString(cancelURL), html.EscapeString(cancelURL))
+
+	message := mail.NewSingleEmail(from, subject, to, plainTextContent, htmlContent)
+	client := sendgrid.NewSendClient(s.apiKey)
+
+	response, err := client.Send(message)
+	if err != nil {
+		log.Error().Err(err).Str("to", html.EscapeString(toEmail)).Msg("Failed to send account deletion email")
+		return err
+	}
+
+	if response.StatusCode >= 400 {
+		log.Error().Int("status", response.StatusCode).Str("to", html.EscapeString(toEmail)).Msg("SendGrid returned error")
+		return fmt.Errorf("sendgrid error: %d", response.StatusCode)
+	}
+
+	log.Info().Str("to", html.EscapeString(toEmail)).Msg("Account deletion email sent")
+	return nil
+}
+
+func (s *SendGridEmailSender) SendAccountDeletionCancelledEmail(ctx context.Context, toEmail string) error {
+	if s.apiKey == "" {
+		log.Warn().Str("to", html.EscapeString(toEmail)).Msg("SendGrid API key not set, skipping email")
+		return nil
+	}
+
+	from := mail.NewEmail(s.fromName, s.fromEmail)
+	to := mail.NewEmail("", toEmail)
+	subject := "Your account could not be deleted"
+
+	plainTextContent := `Hello,
+
+Your ZenoN Cloud account was scheduled for deletion, but it could not be deleted because you own an organization that has other members. Deleting your account would delete that organization for everyone in it.
+
+The deletion request has been cancelled and your account is no longer scheduled for deletion. To delete it, transfer ownership of the organization to another member and request the deletion again.
+
+Best regards,
+ZenoN Cloud Team
+`
+
+	htmlContent := `<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+</head>
+<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
+    <div style="max-width: 600px; margin: 0 auto; padding: 20px;">
+        <h2 style="color: #2563eb;">Account Deletion Cancelled</h2>
+        <p>Hello,</p>
+        <p>Your ZenoN Cloud account was scheduled for deletion, but it could not be deleted because you own an organization that has other members. Deleting your account would delete that organization for everyone in it.</p>
+        <p>The deletion request has been cancelled and your account is no longer scheduled for deletion. To delete it, transfer ownership of the organization to another member and request the deletion again.</p>
+        <hr style="border: none; border-top: 1px solid #eee; margin: 30px 0;">
+        <p style="color: #999; font-size: 12px;">Best regards,<br>ZenoN Cloud Team</p>
+    </div>
+</body>
+</html>`
+
+	message := mail.NewSingleEmail(from, subject, to, plainTextContent, htmlContent)
+	client := sendgrid.NewSendClient(s.apiKey)
+
+	response, err := client.Send(message)
+	if err != nil {
+		log.Error().Err(err).Str("to", html.EscapeString(toEmail)).Msg("Failed to send account deletion cancelled email")
+		return err
+	}
+
+	if response.StatusCode >= 400 {
+		log.Error().Int("status", response.StatusCode).Str("to", html.EscapeString(toEmail)).Msg("SendGrid returned error")
+		return fmt.Errorf("sendgrid error: %d", response.StatusCode)
+	}
+
+	log.Info().Str("to", html.EscapeString(toEmail)).Msg("Account deletion cancelled email sent")
+	return nil
+}
+
+func (s *SendGridEmailSender) SendDataExportReadyEmail(ctx context.Context, toEmail, token, expiresAt string) error {
+	if s.apiKey == "" {
+		log.Warn().Str("to", html.EscapeString(toEmail)).Msg("SendGrid API key not set, skipping email")
+		return nil
+	}
+
+	downloadURL := fmt.Sprintf("%s#/data-export?token=%s", s.baseURL, token)
+
+	from := mail.NewEmail(s.fromName, s.fromEmail)
+	to := mail.NewEmail("", toEmail)
+	subject := "Your data export is ready"
+
+	plainTextContent := fmt.Sprintf(`Hello,
+
+The export of your ZenoN Cloud account data you requested is ready. Download it using the link below:
+
+%s
+
+The link is valid until %s.
+
+If you did not request this export, change your password immediately.
+
+Best regards,
+ZenoN Cloud Team
+`, downloadURL, expiresAt)
+
+	htmlContent := fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+</head>
+<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
+    <div style="max-width: 600px; margin: 0 auto; padding: 20px;">
+        <h2 style="color: #2563eb;">Your Data Export Is Ready</h2>
+        <p>Hello,</p>
+        <p>The export of your ZenoN Cloud account data you requested is ready.</p>
+        <div style="margin: 30px 0;">
+            <a href="%s" style="background-color: #2563eb; color: white; padding: 12px 30px; text-decoration: none; border-radius: 5px; display: inline-block;">Download My Data</a>
+        </div>
+        <p style="color: #666; font-size: 14px;">Or copy and paste this link into your browser:</p>
+        <p style="color: #666; font-size: 14px; word-break: break-all;">%s</p>
+        <p style="color: #666; font-size: 14px;">The link is valid until <strong>%s</strong>.</p>
+        <p style="color: #dc2626; font-weight: bold;">If you did not request this export, change your password immediately.</p>
+        <hr style="border: none; border-top: 1px solid #eee; margin: 30px 0;">
+        <p style="color: #999; font-size: 12px;">Best regards,<br>ZenoN Cloud Team</p>
+    </div>
+</body>
+</html>`, html.EscapeString(downloadURL), html.EscapeString(downloadURL), html.EscapeString(expiresAt))
+
+	message := mail.NewSingleEmail(from, subject, to, plainTextContent, htmlContent)
+	client := sendgrid.NewSendClient(s.apiKey)
+
+	response, err := client.Send(message)
+	if err != nil {
+		log.Error().Err(err).Str("to", html.EscapeString(toEmail)).Msg("Failed to send data export email")
+		return err
+	}
+
+	if response.StatusCode >= 400 {
+		log.Error().Int("status", response.StatusCode).Str("to", html.EscapeString(toEmail)).Msg("SendGrid returned error")
+		return fmt.Errorf("sendgrid error: %d", response.StatusCode)
+	}
+
+	log.Info().Str("to", html.EscapeString(toEmail)).Msg("Data export email sent")
+	return nil
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
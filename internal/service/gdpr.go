@@ -2,159 +2,129 @@ package service
 
 import (
 	"context"
-	"crypto/rand"
+	stdErrors "errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
 
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
 	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
-	"github.com/ZenoN-Cloud/zeno-auth/internal/repository/postgres"
 )
 
+// AccountDeletionRepository stores pending account deletions. Scheduling a
+// deletion deactivates the account and cancelling it restores its previous
+// state.
+type AccountDeletionRepository interface {
+	Schedule(ctx context.Context, request *model.AccountDeletionRequest) error
+	Cancel(ctx context.Context, tokenHash string) (*model.AccountDeletionRequest, error)
+	OwnsSharedOrganization(ctx context.Context, userID uuid.UUID) (bool, error)
+}
+
+// GDPRService handles account deletion requests (Art. 17). Personal data
+// exports are built by DataExportService.
 type GDPRService struct {
-	userRepo       UserRepository
-	orgRepo        OrganizationRepository
-	membershipRepo MembershipRepository
-	refreshRepo    RefreshTokenRepository
-	consentRepo    ConsentRepository
-	auditRepo      AuditLogRepository
-	db             *postgres.DB
+	userRepo     UserRepository
+	refreshRepo  RefreshTokenRepository
+	deletionRepo AccountDeletionRepository
+	tokenRevoker TokenRevoker
+	auditService *AuditService
+	emailSender  EmailSender
+	gracePeriod  time.Duration
 }
 
 func NewGDPRService(
 	userRepo UserRepository,
-	orgRepo OrganizationRepository,
-	membershipRepo MembershipRepository,
 	refreshRepo RefreshTokenRepository,
-	consentRepo ConsentRepository,
-	auditRepo AuditLogRepository,
-	db *postgres.DB,
+	deletionRepo AccountDeletionRepository,
+	tokenRevoker TokenRevoker,
+	auditService *AuditService,
+	gracePeriod time.Duration,
+	frontendBaseURL string,
 ) *GDPRService {
 	return &GDPRService{
-		userRepo:       userRepo,
-		orgRepo:        orgRepo,
-		membershipRepo: membershipRepo,
-		refreshRepo:    refreshRepo,
-		consentRepo:    consentRepo,
-		auditRepo:      auditRepo,
-		db:             db,
+		userRepo:     userRepo,
+		refreshRepo:  refreshRepo,
+		deletionRepo: deletionRepo,
+		tokenRevoker: tokenRevoker,
+		auditService: auditService,
+		emailSender:  NewSendGridEmailSender(frontendBaseURL),
+		gracePeriod:  gracePeriod,
 	}
 }
 
-type UserDataExport struct {
-	User          *model.User            `json:"user"`
-	Organizations []*model.Organization  `json:"organizations"`
-	Memberships   []*model.OrgMembership `json:"memberships"`
-	Consents      []*model.UserConsent   `json:"consents"`
-	AuditLogs     []*model.AuditLog      `json:"audit_logs"`
-}
-
-func (s *GDPRService) ExportUserData(ctx context.Context, userID uuid.UUID) (interface{}, error) {
-	if userID == uuid.Nil {
-		return nil, fmt.Errorf("invalid user ID")
-	}
-
+// RequestAccountDeletion deactivates the account and schedules it to be
+// purged by the cleanup job once the grace period ends. Until then the user
+// can restore it with the link sent to their address. Owners of an
+// organization with other members must transfer it first, since purging the
+// owner deletes the organization.
+func (s *GDPRService) RequestAccountDeletion(ctx context.Context, userID uuid.UUID, ipAddress, userAgent string) (time.Time, error) {
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user: %w", err)
-	}
-
-	orgs, err := s.orgRepo.GetByUserID(ctx, userID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get organizations: %w", err)
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, errors.ErrUserNotFound
+		}
+		return time.Time{}, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	memberships, err := s.membershipRepo.GetByUserID(ctx, userID)
+	shared, err := s.deletionRepo.OwnsSharedOrganization(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get memberships: %w", err)
+		return time.Time{}, fmt.Errorf("failed to check owned organizations: %w", err)
 	}
-
-	consents, err := s.consentRepo.GetByUserID(ctx, userID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get consents: %w", err)
+	if shared {
+		return time.Time{}, errors.ErrOwnershipTransferRequired
 	}
 
-	auditLogs, err := s.auditRepo.GetByUserID(ctx, userID, 1000)
+	rawToken, err := generateToken()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get audit logs: %w", err)
+		return time.Time{}, fmt.Errorf("failed to generate token: %w", err)
 	}
-
-	if user == nil {
-		return nil, fmt.Errorf("user not found")
+	request := &model.AccountDeletionRequest{
+		UserID:     userID,
+		TokenHash:  hashToken(rawToken),
+		PurgeAfter: time.Now().Add(s.gracePeriod),
 	}
-
-	export := &UserDataExport{
-		User:          user,
-		Organizations: orgs,
-		Memberships:   memberships,
-		Consents:      consents,
-		AuditLogs:     auditLogs,
+	if err := s.deletionRepo.Schedule(ctx, request); err != nil {
+		return time.Time{}, fmt.Errorf("failed to schedule account deletion: %w", err)
 	}
-	return export, nil
-}
 
-func (s *GDPRService) DeleteUserAccount(ctx context.Context, userID uuid.UUID) error {
-	if s.db == nil {
-		return fmt.Errorf("database connection not available")
+	if err := s.refreshRepo.RevokeByUserID(ctx, userID); err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to revoke sessions after deletion request")
 	}
+	revokeAccessTokens(ctx, s.tokenRevoker, userID)
 
-	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
-	defer cancel()
+	s.audit(ctx, &userID, model.EventAccountDeletionRequested, map[string]interface{}{
+		"purge_after": request.PurgeAfter.UTC().Format(time.RFC3339),
+	}, ipAddress, userAgent)
 
-	// Start transaction for atomic GDPR deletion
-	tx, err := s.db.BeginTx(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to start transaction: %w", err)
-	}
-	defer func() {
-		_ = tx.Rollback(ctx) // Ignore rollback error in defer
-	}()
-
-	// Get user first
-	user, err := s.userRepo.GetByID(ctx, userID)
-	if err != nil {
-		return fmt.Errorf("failed to get user: %w", err)
-	}
-	if user == nil {
-		return fmt.Errorf("user not found")
+	if s.emailSender != nil {
+		purgeAfter := formatTimeForUser(request.PurgeAfter, user)
+		if err := s.emailSender.SendAccountDeletionScheduledEmail(ctx, user.Email, rawToken, purgeAfter); err != nil {
+			log.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to send account deletion email")
+		}
 	}
+	return request.PurgeAfter, nil
+}
 
-	// Revoke all refresh tokens
-	if err := s.refreshRepo.RevokeByUserIDTx(ctx, tx, userID); err != nil {
-		return fmt.Errorf("failed to revoke tokens: %w", err)
+// CancelAccountDeletion restores an account scheduled for deletion. It does
+// not require a session, since signing in is not possible while the account
+// is deactivated; holding the link proves control of the address.
+func (s *GDPRService) CancelAccountDeletion(ctx context.Context, rawToken, ipAddress, userAgent string) error {
+	if rawToken == "" {
+		return errors.ErrInvalidDeletionCancel
 	}
 
-	// Anonymize user data
-	user.Email = fmt.Sprintf("deleted_%d_%s@deleted.local", time.Now().Unix(), userID.String())
-	user.FullName = "Deleted User"
-	// Generate cryptographically secure random hash
-	randomBytes := make([]byte, 32)
-	if _, err := rand.Read(randomBytes); err != nil {
-		return fmt.Errorf("failed to generate random hash: %w", err)
+	request, err := s.deletionRepo.Cancel(ctx, hashToken(rawToken))
+	if err != nil {
+		return fmt.Errorf("failed to cancel account deletion: %w", err)
 	}
-	user.PasswordHash = fmt.Sprintf("$deleted$%x", randomBytes)
-	user.IsActive = false
-
-	if err := s.userRepo.UpdateTx(ctx, tx, user); err != nil {
-		return fmt.Errorf("failed to anonymize user: %w", err)
+	if request == nil {
+		return errors.ErrInvalidDeletionCancel
 	}
 
-	// Commit transaction
-	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
-	// Anonymize audit logs (outside transaction - can be async)
-	go func() {
-		ctx := context.Background()
-		_ = s.auditRepo.AnonymizeByUserID(ctx, userID)
-		// Errors are logged internally, can be retried later
-	}()
-
-	// Note: Consents and memberships are kept for audit purposes
-	// and can be cleaned up by the cleanup job after a retention period.
-
+	s.audit(ctx, &request.UserID, model.EventAccountDeletionCancelled, nil, ipAddress, userAgent)
 	return nil
 }
 
@@ -163,3 +133,12 @@ func (s *GDPRService) DeleteOrganizationData(_ context.Context, _ uuid.UUID) err
 	// Audit logs are kept for compliance
 	return nil
 }
+
+func (s *GDPRService) audit(ctx context.Context, userID *uuid.UUID, eventType model.AuditEventType, data map[string]interface{}, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	if err := s.auditService.Log(ctx, userID, eventType, data, ipAddress, userAgent); err != nil {
+		log.Error().Err(err).Str("event", string(eventType)).Msg("Failed to log account deletion audit event")
+	}
+}
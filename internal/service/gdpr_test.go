@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+// memoryAccountDeletionRepo keeps pending deletions keyed by user. Users in
+// sharedOwners own an organization with other members.
+type memoryAccountDeletionRepo struct {
+	requests     map[uuid.UUID]*model.AccountDeletionRequest
+	sharedOwners map[uuid.UUID]bool
+	purged       []uuid.UUID
+}
+
+func newMemoryAccountDeletionRepo() *memoryAccountDeletionRepo {
+	return &memoryAccountDeletionRepo{
+		requests:     map[uuid.UUID]*model.AccountDeletionRequest{},
+		sharedOwners: map[uuid.UUID]bool{},
+	}
+}
+
+func (r *memoryAccountDeletionRepo) Schedule(_ context.Context, request *model.AccountDeletionRequest) error {
+	request.CreatedAt = time.Now()
+	copied := *request
+	r.requests[request.UserID] = &copied
+	return nil
+}
+
+func (r *memoryAccountDeletionRepo) Cancel(_ context.Context, tokenHash string) (*model.AccountDeletionRequest, error) {
+	for userID, request := range r.requests {
+		if request.TokenHash == tokenHash && request.PurgeAfter.After(time.Now()) {
+			delete(r.requests, userID)
+			return request, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryAccountDeletionRepo) CancelByUserID(_ context.Context, userID uuid.UUID) (bool, error) {
+	_, ok := r.requests[userID]
+	delete(r.requests, userID)
+	return ok, nil
+}
+
+func (r *memoryAccountDeletionRepo) KeepDeactivated(_ context.Context, userID uuid.UUID) error {
+	if request, ok := r.requests[userID]; ok {
+		request.RestoreActive = false
+	}
+	return nil
+}
+
+func (r *memoryAccountDeletionRepo) OwnsSharedOrganization(_ context.Context, userID uuid.UUID) (bool, error) {
+	return r.sharedOwners[userID], nil
+}
+
+func (r *memoryAccountDeletionRepo) ListDue(_ context.Context, now time.Time, limit int) ([]uuid.UUID, error) {
+	var userIDs []uuid.UUID
+	for userID, request := range r.requests {
+		if !request.PurgeAfter.After(now) && len(userIDs) < limit {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	return userIDs, nil
+}
+
+func (r *memoryAccountDeletionRepo) Purge(_ context.Context, userID uuid.UUID) (bool, error) {
+	if r.sharedOwners[userID] {
+		return false, nil
+	}
+	delete(r.requests, userID)
+	r.purged = append(r.purged, userID)
+	return true, nil
+}
+
+func newTestGDPRService(user *model.User, deletions *memoryAccountDeletionRepo, gracePeriod time.Duration) (*GDPRService, *recordingEmailSender, *recordingRevoker) {
+	userRepo := new(MockUserRepo)
+	userRepo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	refreshRepo := new(MockRefreshTokenRepo)
+	refreshRepo.On("RevokeByUserID", mock.Anything, user.ID).Return(nil)
+
+	emails := &recordingEmailSender{deletions: map[string]string{}}
+	revoker := &recordingRevoker{}
+	svc := NewGDPRService(userRepo, refreshRepo, deletions, revoker, nil, gracePeriod, "")
+	svc.emailSender = emails
+	return svc, emails, revoker
+}
+
+func TestGDPRService_RequestAndCancelAccountDeletion(t *testing.T) {
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Email: "jane@example.com", IsActive: true}
+	deletions := newMemoryAccountDeletionRepo()
+	svc, emails, revoker := newTestGDPRService(user, deletions, 30*24*time.Hour)
+
+	purgeAfter, err := svc.RequestAccountDeletion(ctx, user.ID, "", "")
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), purgeAfter, time.Minute)
+	require.Contains(t, deletions.requests, user.ID)
+	assert.Equal(t, []uuid.UUID{user.ID}, revoker.users)
+
+	rawToken := emails.deletions["jane@example.com"]
+	require.NotEmpty(t, rawToken)
+	assert.NotEqual(t, rawToken, deletions.requests[user.ID].TokenHash)
+
+	require.NoError(t, svc.CancelAccountDeletion(ctx, rawToken, "", ""))
+	assert.Empty(t, deletions.requests)
+
+	// Single use
+	assert.ErrorIs(t, svc.CancelAccountDeletion(ctx, rawToken, "", ""), appErrors.ErrInvalidDeletionCancel)
+	assert.ErrorIs(t, svc.CancelAccountDeletion(ctx, "", "", ""), appErrors.ErrInvalidDeletionCancel)
+}
+
+func TestGDPRService_RequestAccountDeletionRequiresOwnershipTransfer(t *testing.T) {
+	user := &model.User{ID: uuid.New(), Email: "owner@example.com", IsActive: true}
+	deletions := newMemoryAccountDeletionRepo()
+	deletions.sharedOwners[user.ID] = true
+	svc, emails, _ := newTestGDPRService(user, deletions, 30*24*time.Hour)
+
+	_, err := svc.RequestAccountDeletion(context.Background(), user.ID, "", "")
+	assert.ErrorIs(t, err, appErrors.ErrOwnershipTransferRequired)
+	assert.Empty(t, deletions.requests)
+	assert.Empty(t, emails.deletions)
+}
+
+func TestGDPRService_CancelAfterGracePeriod(t *testing.T) {
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Email: "jane@example.com", IsActive: true}
+	deletions := newMemoryAccountDeletionRepo()
+	svc, emails, _ := newTestGDPRService(user, deletions, 0)
+
+	_, err := svc.RequestAccountDeletion(ctx, user.ID, "", "")
+	require.NoError(t, err)
+
+	err = svc.CancelAccountDeletion(ctx, emails.deletions["jane@example.com"], "", "")
+	assert.ErrorIs(t, err, appErrors.ErrInvalidDeletionCancel)
+	assert.Contains(t, deletions.requests, user.ID)
+}
+
+func TestCleanupService_PurgeDeletedAccounts(t *testing.T) {
+	deletions := newMemoryAccountDeletionRepo()
+	due := uuid.New()
+	owner := &model.User{ID: uuid.New(), Email: "owner@example.com"}
+	pending := uuid.New()
+	deletions.requests[due] = &model.AccountDeletionRequest{UserID: due, PurgeAfter: time.Now().Add(-time.Hour)}
+	deletions.requests[owner.ID] = &model.AccountDeletionRequest{UserID: owner.ID, PurgeAfter: time.Now().Add(-time.Hour)}
+	deletions.requests[pending] = &model.AccountDeletionRequest{UserID: pending, PurgeAfter: time.Now().Add(time.Hour)}
+	deletions.sharedOwners[owner.ID] = true
+
+	userRepo := new(MockUserRepo)
+	userRepo.On("GetByID", mock.Anything, owner.ID).Return(owner, nil)
+	emails := &recordingEmailSender{}
+	svc := NewCleanupService(nil, nil, userRepo, deletions, nil, nil, nil, "")
+	svc.emailSender = emails
+
+	purged, err := svc.PurgeDeletedAccounts(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.Equal(t, []uuid.UUID{due}, deletions.purged)
+
+	// The owner's deletion is cancelled and they are told why, rather than
+	// staying deactivated and being retried on every run
+	assert.NotContains(t, deletions.requests, owner.ID)
+	assert.Equal(t, []string{"owner@example.com"}, emails.cancellations)
+	assert.Contains(t, deletions.requests, pending)
+
+	purged, err = svc.PurgeDeletedAccounts(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+	assert.Len(t, emails.cancellations, 1)
+}
@@ -10,13 +10,21 @@ import (
 
 type AuthServiceInterface interface {
 	Register(ctx context.Context, email, password, fullName, organizationName string) (*model.User, error)
-	Login(ctx context.Context, email, password, userAgent, ipAddress string) (string, string, error)
-	RefreshToken(ctx context.Context, refreshToken, userAgent, ipAddress string) (string, error)
+	Login(ctx context.Context, email, password string, orgID uuid.UUID, userAgent, ipAddress string) (*LoginResult, error)
+	CompleteMFALogin(ctx context.Context, mfaToken, code, userAgent, ipAddress string) (*LoginResult, error)
+	CompletePasskeyLogin(ctx context.Context, assertion *PasskeyAssertion, userAgent, ipAddress string) (*LoginResult, error)
+	CompleteSocialLogin(ctx context.Context, provider, code, state, userAgent, ipAddress string) (*LoginResult, error)
+	CompleteSAMLResponse(ctx context.Context, orgID uuid.UUID, samlResponse, userAgent, ipAddress string) (string, error)
+	CompleteSAMLLogin(ctx context.Context, code, userAgent, ipAddress string) (*LoginResult, error)
+	CompleteMagicLinkLogin(ctx context.Context, magicToken, userAgent, ipAddress string) (*LoginResult, error)
+	RefreshToken(ctx context.Context, refreshToken, userAgent, ipAddress string) (*LoginResult, error)
+	SwitchOrganization(ctx context.Context, userID, sessionID, orgID uuid.UUID, amr []string, userAgent, ipAddress string) (*LoginResult, error)
 	Logout(ctx context.Context, userID uuid.UUID) error
 }
 
 type UserServiceInterface interface {
 	GetProfile(ctx context.Context, userID uuid.UUID) (*model.User, error)
+	UpdateProfile(ctx context.Context, userID uuid.UUID, update ProfileUpdate, ipAddress, userAgent string) (*model.User, error)
 }
 
 type RefreshTokenRepository interface {
@@ -26,9 +34,17 @@ type RefreshTokenRepository interface {
 	RevokeByUserID(ctx context.Context, userID uuid.UUID) error
 	RevokeByUserIDTx(ctx context.Context, tx pgx.Tx, userID uuid.UUID) error
 	RevokeByID(ctx context.Context, id uuid.UUID) error
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
 	DeleteExpired(ctx context.Context) error
 }
 
+// TokenRevoker denylists access tokens that have not expired yet. It is
+// implemented by token.RevocationService.
+type TokenRevoker interface {
+	RevokeSession(ctx context.Context, sessionID uuid.UUID) error
+	RevokeUser(ctx context.Context, userID uuid.UUID) error
+}
+
 type UserRepository interface {
 	Create(ctx context.Context, user *model.User) error
 	GetByID(ctx context.Context, id uuid.UUID) (*model.User, error)
@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/token"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/validator"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+type OrgInvitationRepository interface {
+	Create(ctx context.Context, invitation *model.OrgInvitation) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*model.OrgInvitation, error)
+	ListPending(ctx context.Context, orgID uuid.UUID) ([]*model.OrgInvitation, error)
+	MarkAccepted(ctx context.Context, id uuid.UUID) (bool, error)
+	Delete(ctx context.Context, orgID, id uuid.UUID) (bool, error)
+}
+
+type InvitationMembershipRepository interface {
+	Create(ctx context.Context, membership *model.OrgMembership) error
+	GetByUserAndOrg(ctx context.Context, userID, orgID uuid.UUID) (*model.OrgMembership, error)
+	Update(ctx context.Context, membership *model.OrgMembership) error
+}
+
+type InvitationUserRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*model.User, error)
+	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	CreateWithMembership(ctx context.Context, user *model.User, membership *model.OrgMembership) error
+}
+
+type InvitationOrgRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Organization, error)
+}
+
+// InvitationService lets organization members with the members:invite
+// permission (owners, admins and custom roles granting it) invite people by
+// email. The invitee accepts with the emailed token, either while signed in
+// to the invited address or by creating an account for it.
+type InvitationService struct {
+	invitationRepo  OrgInvitationRepository
+	orgRepo         InvitationOrgRepository
+	membershipRepo  InvitationMembershipRepository
+	userRepo        InvitationUserRepository
+	passwordManager *token.PasswordManager
+	auditService    *AuditService
+	emailSender     EmailSender
+	validator       *validator.InputValidator
+}
+
+func NewInvitationService(
+	invitationRepo OrgInvitationRepository,
+	orgRepo InvitationOrgRepository,
+	membershipRepo InvitationMembershipRepository,
+	userRepo InvitationUserRepository,
+	passwordManager *token.PasswordManager,
+	auditService *AuditService,
+	frontendBaseURL string,
+) *InvitationService {
+	return &InvitationService{
+		invitationRepo:  invitationRepo,
+		orgRepo:         orgRepo,
+		membershipRepo:  membershipRepo,
+		userRepo:        userRepo,
+		passwordManager: passwordManager,
+		auditService:    auditService,
+		emailSender:     NewSendGridEmailSender(frontendBaseURL),
+		validator:       validator.NewInputValidator(),
+	}
+}
+
+// ListInvitations returns the pending invitations of the organization to
+// members allowed to invite.
+func (s *InvitationService) ListInvitations(ctx context.Context, userID, orgID uuid.UUID) ([]*model.OrgInvitation, error) {
+	if _, err := s.requireInviter(ctx, userID, orgID); err != nil {
+		return nil, err
+	}
+
+	invitations, err := s.invitationRepo.ListPending(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitations: %w", err)
+	}
+	return invitations, nil
+}
+
+// Invite emails an invitation to join the organization with the role.
+// Inviting an address again replaces its pending invitation. Ownership
+// cannot be granted by invitation, and only owners can invite admins.
+func (s *InvitationService) Invite(ctx context.Context, userID, orgID uuid.UUID, email string, role model.Role, ipAddress, userAgent string) (*model.OrgInvitation, error) {
+	inviter, err := s.requireInviter(ctx, userID, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	email = s.validator.SanitizeEmail(email)
+	if err := s.validator.ValidateEmail(email); err != nil {
+		return nil, errors.ErrInvalidInput
+	}
+	switch role {
+	case model.RoleMember, model.RoleViewer:
+	case model.RoleAdmin:
+		if inviter.Role != model.RoleOwner {
+			return nil, errors.ErrForbidden
+		}
+	default:
+		return nil, errors.ErrInvalidInput
+	}
+
+	org, err := s.orgRepo.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+
+	if existing, err := s.userRepo.GetByEmail(ctx, email); err == nil && existing != nil {
+		membership, err := s.membershipRepo.GetByUserAndOrg(ctx, existing.ID, orgID)
+		if err != nil && !stdErrors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("failed to get membership: %w", err)
+		}
+		if err == nil && membership != nil && membership.IsActive {
+			return nil, errors.ErrAlreadyMember
+		}
+	} else if err != nil && !stdErrors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	invitationToken, err := generateToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	invitation := &model.OrgInvitation{
+		OrgID:     orgID,
+		Email:     email,
+		Role:      role,
+		TokenHash: hashToken(invitationToken),
+		InvitedBy: &userID,
+		ExpiresAt: time.Now().Add(invitationTTL),
+	}
+	if err := s.invitationRepo.Create(ctx, invitation); err != nil {
+		return nil, fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	s.audit(ctx, &userID, model.EventInvitationCreated, map[string]interface{}{
+		"org_id":        orgID.String(),
+		"invitation_id": invitation.ID.String(),
+		"email":         email,
+		"role":          string(role),
+	}, ipAddress, userAgent)
+
+	if s.emailSender != nil {
+		if err := s.emailSender.SendInvitationEmail(ctx, email, org.Name, invitationToken); err != nil {
+			log.Error().Err(err).Str("invitation_id", invitation.ID.String()).Msg("Failed to send invitation email")
+			return nil, fmt.Errorf("failed to send invitation email: %w", err)
+		}
+	}
+
+	return invitation, nil
+}
+
+// RevokeInvitation deletes a pending invitation so that its token can no
+// longer be used.
+func (s *InvitationService) RevokeInvitation(ctx context.Context, userID, orgID, invitationID uuid.UUID, ipAddress, userAgent string) error {
+	if _, err := s.requireInviter(ctx, userID, orgID); err != nil {
+		return err
+	}
+
+	deleted, err := s.invitationRepo.Delete(ctx, orgID, invitationID)
+	if err != nil {
+		return fmt.Errorf("failed to delete invitation: %w", err)
+	}
+	if !deleted {
+		return errors.ErrInvitationNotFound
+	}
+
+	s.audit(ctx, &userID, model.EventInvitationRevoked, map[string]interface{}{
+		"org_id":        orgID.String(),
+		"invitation_id": invitationID.String(),
+	}, ipAddress, userAgent)
+	return nil
+}
+
+// Accept adds the signed-in user to the organization. The invitation must
+// have been sent to the user's email address.
+func (s *InvitationService) Accept(ctx context.Context, userID uuid.UUID, invitationToken, ipAddress, userAgent string) (*model.OrgMembership, error) {
+	invitation, err := s.pendingInvitation(ctx, invitationToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if !strings.EqualFold(user.Email, invitation.Email) {
+		return nil, errors.ErrInvitationEmailMismatch
+	}
+
+	membership, err := s.membershipRepo.GetByUserAndOrg(ctx, user.ID, invitation.OrgID)
+	switch {
+	case err == nil && membership != nil && membership.IsActive:
+		return nil, errors.ErrAlreadyMember
+	case err == nil && membership != nil:
+		// A deactivated member is brought back with the invited role
+		membership.Role = invitation.Role
+		membership.IsActive = true
+		if err := s.membershipRepo.Update(ctx, membership); err != nil {
+			return nil, fmt.Errorf("failed to update membership: %w", err)
+		}
+	case err == nil || stdErrors.Is(err, pgx.ErrNoRows):
+		membership = &model.OrgMembership{
+			UserID:   user.ID,
+			OrgID:    invitation.OrgID,
+			Role:     invitation.Role,
+			IsActive: true,
+		}
+		if err := s.membershipRepo.Create(ctx, membership); err != nil {
+			return nil, fmt.Errorf("failed to add member: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("failed to get membership: %w", err)
+	}
+
+	if err := s.complete(ctx, invitation, user.ID, ipAddress, userAgent); err != nil {
+		return nil, err
+	}
+	return membership, nil
+}
+
+// AcceptWithRegistration creates an account for the invited email address
+// and adds it to the organization. The token proves the address, so the
+// account does not need email verification.
+func (s *InvitationService) AcceptWithRegistration(ctx context.Context, invitationToken, fullName, password, ipAddress, userAgent string) (*model.User, *model.OrgMembership, error) {
+	invitation, err := s.pendingInvitation(ctx, invitationToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := validator.NewPasswordValidator().Validate(password); err != nil {
+		return nil, nil, err
+	}
+
+	_, err = s.userRepo.GetByEmail(ctx, invitation.Email)
+	if err == nil {
+		return nil, nil, errors.ErrEmailAlreadyUsed
+	}
+	if !stdErrors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	passwordHash, err := s.passwordManager.Hash(ctx, password)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user := &model.User{
+		Email:        invitation.Email,
+		PasswordHash: passwordHash,
+		FullName:     fullName,
+		IsActive:     true,
+	}
+	membership := &model.OrgMembership{
+		OrgID:    invitation.OrgID,
+		Role:     invitation.Role,
+		IsActive: true,
+	}
+	if err := s.userRepo.CreateWithMembership(ctx, user, membership); err != nil {
+		return nil, nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	s.audit(ctx, &user.ID, model.EventUserRegistered, map[string]interface{}{
+		"email":  user.Email,
+		"org_id": invitation.OrgID.String(),
+		"source": "invitation",
+	}, ipAddress, userAgent)
+
+	if err := s.complete(ctx, invitation, user.ID, ipAddress, userAgent); err != nil {
+		return nil, nil, err
+	}
+	return user, membership, nil
+}
+
+func (s *InvitationService) pendingInvitation(ctx context.Context, invitationToken string) (*model.OrgInvitation, error) {
+	if invitationToken == "" {
+		return nil, errors.ErrInvalidInvitation
+	}
+
+	invitation, err := s.invitationRepo.GetByTokenHash(ctx, hashToken(invitationToken))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+	if invitation == nil || invitation.AcceptedAt != nil {
+		return nil, errors.ErrInvalidInvitation
+	}
+	if invitation.IsExpired() {
+		return nil, errors.ErrInvitationExpired
+	}
+	return invitation, nil
+}
+
+// complete marks the invitation as used once the membership exists.
+func (s *InvitationService) complete(ctx context.Context, invitation *model.OrgInvitation, userID uuid.UUID, ipAddress, userAgent string) error {
+	if _, err := s.invitationRepo.MarkAccepted(ctx, invitation.ID); err != nil {
+		return fmt.Errorf("failed to mark invitation as accepted: %w", err)
+	}
+
+	s.audit(ctx, &userID, model.EventInvitationAccepted, map[string]interface{}{
+		"org_id":        invitation.OrgID.String(),
+		"invitation_id": invitation.ID.String(),
+	}, ipAddress, userAgent)
+	s.audit(ctx, &userID, model.EventMemberAdded, map[string]interface{}{
+		"org_id": invitation.OrgID.String(),
+		"role":   string(invitation.Role),
+		"source": "invitation",
+	}, ipAddress, userAgent)
+	return nil
+}
+
+func (s *InvitationService) requireInviter(ctx context.Context, userID, orgID uuid.UUID) (*model.OrgMembership, error) {
+	membership, err := s.membershipRepo.GetByUserAndOrg(ctx, userID, orgID)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.ErrForbidden
+		}
+		return nil, fmt.Errorf("failed to get membership: %w", err)
+	}
+	if membership == nil || !membership.IsActive || !membership.HasPermission(model.PermMembersInvite) {
+		return nil, errors.ErrForbidden
+	}
+	return membership, nil
+}
+
+func (s *InvitationService) audit(ctx context.Context, userID *uuid.UUID, eventType model.AuditEventType, data map[string]interface{}, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	if err := s.auditService.Log(ctx, userID, eventType, data, ipAddress, userAgent); err != nil {
+		log.Error().Err(err).Str("event", string(eventType)).Msg("Failed to log invitation audit event")
+	}
+}
@@ -0,0 +1,357 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/token"
+)
+
+type memoryInvitationRepo struct {
+	invitations []*model.OrgInvitation
+}
+
+func (r *memoryInvitationRepo) Create(_ context.Context, invitation *model.OrgInvitation) error {
+	for i, existing := range r.invitations {
+		if existing.OrgID == invitation.OrgID && existing.Email == invitation.Email && existing.AcceptedAt == nil {
+			r.invitations = append(r.invitations[:i], r.invitations[i+1:]...)
+			break
+		}
+	}
+	invitation.ID = uuid.New()
+	invitation.CreatedAt = time.Now()
+	r.invitations = append(r.invitations, invitation)
+	return nil
+}
+
+func (r *memoryInvitationRepo) GetByTokenHash(_ context.Context, tokenHash string) (*model.OrgInvitation, error) {
+	for _, invitation := range r.invitations {
+		if invitation.TokenHash == tokenHash {
+			copied := *invitation
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryInvitationRepo) ListPending(_ context.Context, orgID uuid.UUID) ([]*model.OrgInvitation, error) {
+	var invitations []*model.OrgInvitation
+	for _, invitation := range r.invitations {
+		if invitation.OrgID == orgID && invitation.AcceptedAt == nil {
+			invitations = append(invitations, invitation)
+		}
+	}
+	return invitations, nil
+}
+
+func (r *memoryInvitationRepo) MarkAccepted(_ context.Context, id uuid.UUID) (bool, error) {
+	for _, invitation := range r.invitations {
+		if invitation.ID == id && invitation.AcceptedAt == nil {
+			now := time.Now()
+			invitation.AcceptedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryInvitationRepo) Delete(_ context.Context, orgID, id uuid.UUID) (bool, error) {
+	for i, invitation := range r.invitations {
+		if invitation.ID == id && invitation.OrgID == orgID && invitation.AcceptedAt == nil {
+			r.invitations = append(r.invitations[:i], r.invitations[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type memoryOrgRepo map[uuid.UUID]*model.Organization
+
+func (r memoryOrgRepo) GetByID(_ context.Context, id uuid.UUID) (*model.Organization, error) {
+	return r[id], nil
+}
+
+// recordingEmailSender keeps the tokens of the invitations, magic links,
+// email change confirmations, deletion cancellations and export downloads it
+// was asked to send.
+type recordingEmailSender struct {
+	invitations  map[string]string
+	magicLinks   map[string]string
+	emailChanges map[string]string
+	notices      map[string]string
+	deletions    map[string]string
+	exports      map[string]string
+	// cancellations lists the addresses told that their deletion was cancelled
+	cancellations []string
+}
+
+func (s *recordingEmailSender) SendVerificationEmail(context.Context, string, string) error {
+	return nil
+}
+
+func (s *recordingEmailSender) SendPasswordResetEmail(context.Context, string, string) error {
+	return nil
+}
+
+func (s *recordingEmailSender) SendPasswordChangedEmail(context.Context, string) error {
+	return nil
+}
+
+func (s *recordingEmailSender) SendAccountLockoutEmail(context.Context, string, string) error {
+	return nil
+}
+
+func (s *recordingEmailSender) SendInvitationEmail(_ context.Context, toEmail, _, token string) error {
+	s.invitations[toEmail] = token
+	return nil
+}
+
+func (s *recordingEmailSender) SendMagicLinkEmail(_ context.Context, toEmail, token string) error {
+	s.magicLinks[toEmail] = token
+	return nil
+}
+
+func (s *recordingEmailSender) SendEmailChangeConfirmationEmail(_ context.Context, toEmail, token string) error {
+	s.emailChanges[toEmail] = token
+	return nil
+}
+
+func (s *recordingEmailSender) SendEmailChangeRequestedEmail(_ context.Context, toEmail, newEmail string) error {
+	s.notices[toEmail] = newEmail
+	return nil
+}
+
+func (s *recordingEmailSender) SendAccountDeletionScheduledEmail(_ context.Context, toEmail, token, _ string) error {
+	s.deletions[toEmail] = token
+	return nil
+}
+
+func (s *recordingEmailSender) SendAccountDeletionCancelledEmail(_ context.Context, toEmail string) error {
+	s.cancellations = append(s.cancellations, toEmail)
+	return nil
+}
+
+func (s *recordingEmailSender) SendDataExportReadyEmail(_ context.Context, toEmail, token, _ string) error {
+	s.exports[toEmail] = token
+	return nil
+}
+
+type invitationTestDeps struct {
+	invitations *memoryInvitationRepo
+	directory   *memoryDirectory
+	emails      *recordingEmailSender
+	orgID       uuid.UUID
+	owner       *model.User
+}
+
+func newTestInvitationService() (*InvitationService, *invitationTestDeps) {
+	deps := &invitationTestDeps{
+		invitations: &memoryInvitationRepo{},
+		directory:   &memoryDirectory{users: map[uuid.UUID]*model.User{}},
+		emails:      &recordingEmailSender{invitations: map[string]string{}},
+		orgID:       uuid.New(),
+	}
+	deps.owner = deps.directory.addUser("owner@example.com")
+	deps.directory.addMember(deps.owner, deps.orgID, model.RoleOwner)
+
+	orgs := memoryOrgRepo{deps.orgID: {ID: deps.orgID, Name: "Acme"}}
+	svc := NewInvitationService(
+		deps.invitations, orgs, memorySCIMMembershipRepo{deps.directory}, memorySCIMUserRepo{deps.directory},
+		token.NewPasswordManager(), nil, "https://app.zeno.test/",
+	)
+	svc.emailSender = deps.emails
+	return svc, deps
+}
+
+func TestInvitationService_Invite(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("emails a token and stores only its hash", func(t *testing.T) {
+		svc, deps := newTestInvitationService()
+
+		invitation, err := svc.Invite(ctx, deps.owner.ID, deps.orgID, " Jane@Example.com ", model.RoleAdmin, "", "")
+		require.NoError(t, err)
+		assert.Equal(t, "jane@example.com", invitation.Email)
+		assert.Equal(t, model.RoleAdmin, invitation.Role)
+		assert.WithinDuration(t, time.Now().Add(invitationTTL), invitation.ExpiresAt, time.Minute)
+
+		sent := deps.emails.invitations["jane@example.com"]
+		require.NotEmpty(t, sent)
+		assert.Equal(t, hashToken(sent), invitation.TokenHash)
+	})
+
+	t.Run("inviting again replaces the pending invitation", func(t *testing.T) {
+		svc, deps := newTestInvitationService()
+
+		_, err := svc.Invite(ctx, deps.owner.ID, deps.orgID, "jane@example.com", model.RoleMember, "", "")
+		require.NoError(t, err)
+		first := deps.emails.invitations["jane@example.com"]
+		_, err = svc.Invite(ctx, deps.owner.ID, deps.orgID, "jane@example.com", model.RoleViewer, "", "")
+		require.NoError(t, err)
+
+		pending, err := svc.ListInvitations(ctx, deps.owner.ID, deps.orgID)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		assert.Equal(t, model.RoleViewer, pending[0].Role)
+
+		_, err = svc.Accept(ctx, deps.owner.ID, first, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrInvalidInvitation)
+	})
+
+	t.Run("only owners and admins invite, and only owners invite admins", func(t *testing.T) {
+		svc, deps := newTestInvitationService()
+		admin := deps.directory.addUser("admin@example.com")
+		deps.directory.addMember(admin, deps.orgID, model.RoleAdmin)
+		member := deps.directory.addUser("member@example.com")
+		deps.directory.addMember(member, deps.orgID, model.RoleMember)
+
+		_, err := svc.Invite(ctx, member.ID, deps.orgID, "jane@example.com", model.RoleMember, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrForbidden)
+
+		_, err = svc.Invite(ctx, admin.ID, deps.orgID, "jane@example.com", model.RoleAdmin, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrForbidden)
+
+		_, err = svc.Invite(ctx, admin.ID, deps.orgID, "jane@example.com", model.RoleMember, "", "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("custom roles can grant members:invite", func(t *testing.T) {
+		svc, deps := newTestInvitationService()
+		member := deps.directory.addUser("member@example.com")
+		membership := deps.directory.addMember(member, deps.orgID, model.RoleMember)
+		membership.CustomPermissions = []model.Permission{model.PermMembersInvite}
+
+		_, err := svc.Invite(ctx, member.ID, deps.orgID, "jane@example.com", model.RoleMember, "", "")
+		assert.NoError(t, err)
+
+		_, err = svc.Invite(ctx, member.ID, deps.orgID, "john@example.com", model.RoleAdmin, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrForbidden)
+	})
+
+	t.Run("rejects owners, invalid emails and existing members", func(t *testing.T) {
+		svc, deps := newTestInvitationService()
+		member := deps.directory.addUser("member@example.com")
+		deps.directory.addMember(member, deps.orgID, model.RoleMember)
+
+		_, err := svc.Invite(ctx, deps.owner.ID, deps.orgID, "jane@example.com", model.RoleOwner, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrInvalidInput)
+
+		_, err = svc.Invite(ctx, deps.owner.ID, deps.orgID, "not-an-email", model.RoleMember, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrInvalidInput)
+
+		_, err = svc.Invite(ctx, deps.owner.ID, deps.orgID, "member@example.com", model.RoleMember, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrAlreadyMember)
+	})
+}
+
+func TestInvitationService_Accept(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("adds the signed-in invitee once", func(t *testing.T) {
+		svc, deps := newTestInvitationService()
+		jane := deps.directory.addUser("jane@example.com")
+		_, err := svc.Invite(ctx, deps.owner.ID, deps.orgID, "jane@example.com", model.RoleViewer, "", "")
+		require.NoError(t, err)
+		invitationToken := deps.emails.invitations["jane@example.com"]
+
+		membership, err := svc.Accept(ctx, jane.ID, invitationToken, "", "")
+		require.NoError(t, err)
+		assert.Equal(t, deps.orgID, membership.OrgID)
+		assert.Equal(t, model.RoleViewer, membership.Role)
+
+		_, err = svc.Accept(ctx, jane.ID, invitationToken, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrInvalidInvitation)
+
+		pending, err := svc.ListInvitations(ctx, deps.owner.ID, deps.orgID)
+		require.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+
+	t.Run("requires the invited email address", func(t *testing.T) {
+		svc, deps := newTestInvitationService()
+		other := deps.directory.addUser("other@example.com")
+		_, err := svc.Invite(ctx, deps.owner.ID, deps.orgID, "jane@example.com", model.RoleMember, "", "")
+		require.NoError(t, err)
+
+		_, err = svc.Accept(ctx, other.ID, deps.emails.invitations["jane@example.com"], "", "")
+		assert.ErrorIs(t, err, appErrors.ErrInvitationEmailMismatch)
+	})
+
+	t.Run("reactivates a deactivated member", func(t *testing.T) {
+		svc, deps := newTestInvitationService()
+		jane := deps.directory.addUser("jane@example.com")
+		deps.directory.addMember(jane, deps.orgID, model.RoleAdmin).IsActive = false
+		_, err := svc.Invite(ctx, deps.owner.ID, deps.orgID, "jane@example.com", model.RoleMember, "", "")
+		require.NoError(t, err)
+
+		membership, err := svc.Accept(ctx, jane.ID, deps.emails.invitations["jane@example.com"], "", "")
+		require.NoError(t, err)
+		assert.True(t, membership.IsActive)
+		assert.Equal(t, model.RoleMember, membership.Role)
+		assert.Len(t, deps.directory.memberships, 2)
+	})
+
+	t.Run("rejects expired and revoked invitations", func(t *testing.T) {
+		svc, deps := newTestInvitationService()
+		jane := deps.directory.addUser("jane@example.com")
+		invitation, err := svc.Invite(ctx, deps.owner.ID, deps.orgID, "jane@example.com", model.RoleMember, "", "")
+		require.NoError(t, err)
+		invitationToken := deps.emails.invitations["jane@example.com"]
+
+		invitation.ExpiresAt = time.Now().Add(-time.Minute)
+		_, err = svc.Accept(ctx, jane.ID, invitationToken, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrInvitationExpired)
+
+		require.NoError(t, svc.RevokeInvitation(ctx, deps.owner.ID, deps.orgID, invitation.ID, "", ""))
+		_, err = svc.Accept(ctx, jane.ID, invitationToken, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrInvalidInvitation)
+
+		err = svc.RevokeInvitation(ctx, deps.owner.ID, deps.orgID, invitation.ID, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrInvitationNotFound)
+	})
+}
+
+func TestInvitationService_AcceptWithRegistration(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("creates an active account with the membership", func(t *testing.T) {
+		svc, deps := newTestInvitationService()
+		_, err := svc.Invite(ctx, deps.owner.ID, deps.orgID, "jane@example.com", model.RoleMember, "", "")
+		require.NoError(t, err)
+
+		user, membership, err := svc.AcceptWithRegistration(ctx, deps.emails.invitations["jane@example.com"], "Jane Doe", "Str0ngPassw0rd!", "", "")
+		require.NoError(t, err)
+		assert.Equal(t, "jane@example.com", user.Email)
+		assert.True(t, user.IsActive)
+		assert.NotEmpty(t, user.PasswordHash)
+		assert.Equal(t, user.ID, membership.UserID)
+		assert.Equal(t, deps.orgID, membership.OrgID)
+	})
+
+	t.Run("existing accounts sign in to accept", func(t *testing.T) {
+		svc, deps := newTestInvitationService()
+		deps.directory.addUser("jane@example.com")
+		_, err := svc.Invite(ctx, deps.owner.ID, deps.orgID, "jane@example.com", model.RoleMember, "", "")
+		require.NoError(t, err)
+
+		_, _, err = svc.AcceptWithRegistration(ctx, deps.emails.invitations["jane@example.com"], "Jane Doe", "Str0ngPassw0rd!", "", "")
+		assert.ErrorIs(t, err, appErrors.ErrEmailAlreadyUsed)
+	})
+
+	t.Run("rejects weak passwords", func(t *testing.T) {
+		svc, deps := newTestInvitationService()
+		_, err := svc.Invite(ctx, deps.owner.ID, deps.orgID, "jane@example.com", model.RoleMember, "", "")
+		require.NoError(t, err)
+
+		_, _, err = svc.AcceptWithRegistration(ctx, deps.emails.invitations["jane@example.com"], "Jane Doe", "short", "", "")
+		assert.Error(t, err)
+		assert.Len(t, deps.directory.users, 1)
+	})
+}
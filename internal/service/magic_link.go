@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+const magicLinkTTL = 15 * time.Minute
+
+type MagicLinkRepository interface {
+	Create(ctx context.Context, link *model.MagicLinkToken) error
+	Consume(ctx context.Context, tokenHash string) (*model.MagicLinkToken, error)
+	IsEnabledForUser(ctx context.Context, userID uuid.UUID) (bool, error)
+	IsEnabledForOrg(ctx context.Context, orgID uuid.UUID) (bool, error)
+	SetEnabledForOrg(ctx context.Context, orgID uuid.UUID, enabled bool) error
+}
+
+type MagicLinkUserRepository interface {
+	GetByEmail(ctx context.Context, email string) (*model.User, error)
+}
+
+type MagicLinkMembershipRepository interface {
+	GetByUserAndOrg(ctx context.Context, userID, orgID uuid.UUID) (*model.OrgMembership, error)
+}
+
+// MagicLinkService signs users in with a single-use link sent to their email
+// address. Every organization can turn it off for its members; a user can
+// only use magic links if all of their organizations allow it, so that the
+// setting cannot be bypassed by switching organizations afterwards. Tokens
+// are issued by AuthService.CompleteMagicLinkLogin.
+type MagicLinkService struct {
+	linkRepo       MagicLinkRepository
+	userRepo       MagicLinkUserRepository
+	membershipRepo MagicLinkMembershipRepository
+	auditService   *AuditService
+	emailSender    EmailSender
+}
+
+func NewMagicLinkService(
+	linkRepo MagicLinkRepository,
+	userRepo MagicLinkUserRepository,
+	membershipRepo MagicLinkMembershipRepository,
+	auditService *AuditService,
+	frontendBaseURL string,
+) *MagicLinkService {
+	return &MagicLinkService{
+		linkRepo:       linkRepo,
+		userRepo:       userRepo,
+		membershipRepo: membershipRepo,
+		auditService:   auditService,
+		emailSender:    NewSendGridEmailSender(frontendBaseURL),
+	}
+}
+
+// RequestLink emails a sign-in link for orgID, or the user's default
+// organization if it is uuid.Nil. The result does not reveal whether the
+// account exists or may use magic links: in those cases nothing is sent and
+// no error is returned.
+func (s *MagicLinkService) RequestLink(ctx context.Context, email string, orgID uuid.UUID, ipAddress, userAgent string) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			log.Info().Msg("Magic link requested for non-existent email")
+			return nil
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if !user.IsActive {
+		return nil
+	}
+
+	enabled, err := s.linkRepo.IsEnabledForUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to check magic link setting: %w", err)
+	}
+	if !enabled {
+		log.Info().Str("user_id", user.ID.String()).Msg("Magic link requested but disabled by an organization")
+		return nil
+	}
+
+	rawToken, err := generateToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate token: %w", err)
+	}
+	link := &model.MagicLinkToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(rawToken),
+		ExpiresAt: time.Now().Add(magicLinkTTL),
+	}
+	if orgID != uuid.Nil {
+		link.OrgID = &orgID
+	}
+	if err := s.linkRepo.Create(ctx, link); err != nil {
+		return fmt.Errorf("failed to create magic link: %w", err)
+	}
+
+	s.audit(ctx, &user.ID, model.EventMagicLinkRequested, nil, ipAddress, userAgent)
+
+	// A delivery failure is only logged: returning it would reveal that the
+	// account exists
+	if s.emailSender != nil {
+		if err := s.emailSender.SendMagicLinkEmail(ctx, user.Email, rawToken); err != nil {
+			log.Error().Err(err).Str("user_id", user.ID.String()).Msg("Failed to send magic link email")
+		}
+	}
+	return nil
+}
+
+// Redeem consumes the link. It fails if the link is unknown, used or
+// expired, or if one of the user's organizations turned magic links off
+// after it was sent.
+func (s *MagicLinkService) Redeem(ctx context.Context, rawToken string) (*model.MagicLinkToken, error) {
+	if rawToken == "" {
+		return nil, errors.ErrInvalidMagicLink
+	}
+
+	link, err := s.linkRepo.Consume(ctx, hashToken(rawToken))
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume magic link: %w", err)
+	}
+	if link == nil {
+		return nil, errors.ErrInvalidMagicLink
+	}
+
+	enabled, err := s.linkRepo.IsEnabledForUser(ctx, link.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check magic link setting: %w", err)
+	}
+	if !enabled {
+		return nil, errors.ErrMagicLinkDisabled
+	}
+	return link, nil
+}
+
+// IsEnabled returns the magic link setting of the organization to its members.
+func (s *MagicLinkService) IsEnabled(ctx context.Context, userID, orgID uuid.UUID) (bool, error) {
+	if _, err := s.requirePermission(ctx, userID, orgID, model.PermOrgRead); err != nil {
+		return false, err
+	}
+
+	enabled, err := s.linkRepo.IsEnabledForOrg(ctx, orgID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get magic link setting: %w", err)
+	}
+	return enabled, nil
+}
+
+// SetEnabled turns magic links on or off for the members of the
+// organization. Links already sent stop working when it is turned off.
+func (s *MagicLinkService) SetEnabled(ctx context.Context, userID, orgID uuid.UUID, enabled bool, ipAddress, userAgent string) error {
+	if _, err := s.requirePermission(ctx, userID, orgID, model.PermOrgManage); err != nil {
+		return err
+	}
+
+	if err := s.linkRepo.SetEnabledForOrg(ctx, orgID, enabled); err != nil {
+		return fmt.Errorf("failed to update magic link setting: %w", err)
+	}
+
+	s.audit(ctx, &userID, model.EventMagicLinkSettingChanged, map[string]interface{}{
+		"org_id":  orgID.String(),
+		"enabled": enabled,
+	}, ipAddress, userAgent)
+	return nil
+}
+
+func (s *MagicLinkService) requirePermission(ctx context.Context, userID, orgID uuid.UUID, permission model.Permission) (*model.OrgMembership, error) {
+	membership, err := s.membershipRepo.GetByUserAndOrg(ctx, userID, orgID)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.ErrForbidden
+		}
+		return nil, fmt.Errorf("failed to get membership: %w", err)
+	}
+	if membership == nil || !membership.IsActive {
+		return nil, errors.ErrForbidden
+	}
+	if !membership.HasPermission(permission) {
+		return nil, errors.ErrInsufficientRole
+	}
+	return membership, nil
+}
+
+func (s *MagicLinkService) audit(ctx context.Context, userID *uuid.UUID, eventType model.AuditEventType, data map[string]interface{}, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	if err := s.auditService.Log(ctx, userID, eventType, data, ipAddress, userAgent); err != nil {
+		log.Error().Err(err).Str("event", string(eventType)).Msg("Failed to log magic link audit event")
+	}
+}
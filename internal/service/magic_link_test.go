@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/token"
+)
+
+type memoryMagicLinkRepo struct {
+	directory *memoryDirectory
+	links     map[string]*model.MagicLinkToken
+	disabled  map[uuid.UUID]bool
+}
+
+func (r *memoryMagicLinkRepo) Create(_ context.Context, link *model.MagicLinkToken) error {
+	for hash, existing := range r.links {
+		if existing.UserID == link.UserID && existing.UsedAt == nil {
+			delete(r.links, hash)
+		}
+	}
+	link.ID = uuid.New()
+	link.CreatedAt = time.Now()
+	copied := *link
+	r.links[link.TokenHash] = &copied
+	return nil
+}
+
+func (r *memoryMagicLinkRepo) Consume(_ context.Context, tokenHash string) (*model.MagicLinkToken, error) {
+	link, ok := r.links[tokenHash]
+	if !ok || link.UsedAt != nil || !link.ExpiresAt.After(time.Now()) {
+		return nil, nil
+	}
+	now := time.Now()
+	link.UsedAt = &now
+	copied := *link
+	return &copied, nil
+}
+
+func (r *memoryMagicLinkRepo) IsEnabledForUser(_ context.Context, userID uuid.UUID) (bool, error) {
+	for _, membership := range r.directory.memberships {
+		if membership.UserID == userID && membership.IsActive && r.disabled[membership.OrgID] {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (r *memoryMagicLinkRepo) IsEnabledForOrg(_ context.Context, orgID uuid.UUID) (bool, error) {
+	return !r.disabled[orgID], nil
+}
+
+func (r *memoryMagicLinkRepo) SetEnabledForOrg(_ context.Context, orgID uuid.UUID, enabled bool) error {
+	r.disabled[orgID] = !enabled
+	return nil
+}
+
+type magicLinkTestDeps struct {
+	links     *memoryMagicLinkRepo
+	directory *memoryDirectory
+	emails    *recordingEmailSender
+	orgID     uuid.UUID
+	user      *model.User
+}
+
+func newTestMagicLinkService() (*MagicLinkService, *magicLinkTestDeps) {
+	directory := &memoryDirectory{users: map[uuid.UUID]*model.User{}}
+	deps := &magicLinkTestDeps{
+		links:     &memoryMagicLinkRepo{directory: directory, links: map[string]*model.MagicLinkToken{}, disabled: map[uuid.UUID]bool{}},
+		directory: directory,
+		emails:    &recordingEmailSender{magicLinks: map[string]string{}},
+		orgID:     uuid.New(),
+	}
+	deps.user = directory.addUser("jane@example.com")
+	directory.addMember(deps.user, deps.orgID, model.RoleOwner)
+
+	svc := NewMagicLinkService(deps.links, memorySCIMUserRepo{directory}, memorySCIMMembershipRepo{directory}, nil, "")
+	svc.emailSender = deps.emails
+	return svc, deps
+}
+
+func TestMagicLinkService_RequestAndRedeem(t *testing.T) {
+	ctx := context.Background()
+	svc, deps := newTestMagicLinkService()
+
+	require.NoError(t, svc.RequestLink(ctx, " Jane@Example.com ", deps.orgID, "", ""))
+	rawToken := deps.emails.magicLinks[deps.user.Email]
+	require.NotEmpty(t, rawToken)
+
+	link, err := svc.Redeem(ctx, rawToken)
+	require.NoError(t, err)
+	assert.Equal(t, deps.user.ID, link.UserID)
+	require.NotNil(t, link.OrgID)
+	assert.Equal(t, deps.orgID, *link.OrgID)
+
+	// Single use
+	_, err = svc.Redeem(ctx, rawToken)
+	assert.ErrorIs(t, err, appErrors.ErrInvalidMagicLink)
+	_, err = svc.Redeem(ctx, "")
+	assert.ErrorIs(t, err, appErrors.ErrInvalidMagicLink)
+
+	// Requesting a new link invalidates the previous one
+	require.NoError(t, svc.RequestLink(ctx, deps.user.Email, uuid.Nil, "", ""))
+	first := deps.emails.magicLinks[deps.user.Email]
+	require.NoError(t, svc.RequestLink(ctx, deps.user.Email, uuid.Nil, "", ""))
+	_, err = svc.Redeem(ctx, first)
+	assert.ErrorIs(t, err, appErrors.ErrInvalidMagicLink)
+}
+
+func TestMagicLinkService_RequestIsEnumerationSafe(t *testing.T) {
+	ctx := context.Background()
+	svc, deps := newTestMagicLinkService()
+	inactive := deps.directory.addUser("gone@example.com")
+	inactive.IsActive = false
+	restricted := deps.directory.addUser("restricted@example.com")
+	restrictedOrg := uuid.New()
+	deps.directory.addMember(restricted, uuid.New(), model.RoleOwner)
+	deps.directory.addMember(restricted, restrictedOrg, model.RoleMember)
+	deps.links.disabled[restrictedOrg] = true
+
+	for _, email := range []string{"nobody@example.com", inactive.Email, restricted.Email} {
+		assert.NoError(t, svc.RequestLink(ctx, email, uuid.Nil, "", ""), email)
+	}
+	assert.Empty(t, deps.emails.magicLinks)
+}
+
+func TestMagicLinkService_DisablingRevokesSentLinks(t *testing.T) {
+	ctx := context.Background()
+	svc, deps := newTestMagicLinkService()
+	member := deps.directory.addUser("member@example.com")
+	deps.directory.addMember(member, deps.orgID, model.RoleMember)
+
+	err := svc.SetEnabled(ctx, member.ID, deps.orgID, false, "", "")
+	assert.ErrorIs(t, err, appErrors.ErrInsufficientRole)
+	err = svc.SetEnabled(ctx, uuid.New(), deps.orgID, false, "", "")
+	assert.ErrorIs(t, err, appErrors.ErrForbidden)
+
+	require.NoError(t, svc.RequestLink(ctx, deps.user.Email, uuid.Nil, "", ""))
+	rawToken := deps.emails.magicLinks[deps.user.Email]
+
+	require.NoError(t, svc.SetEnabled(ctx, deps.user.ID, deps.orgID, false, "", ""))
+	enabled, err := svc.IsEnabled(ctx, member.ID, deps.orgID)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+
+	_, err = svc.Redeem(ctx, rawToken)
+	assert.ErrorIs(t, err, appErrors.ErrMagicLinkDisabled)
+}
+
+func TestAuthService_CompleteMagicLinkLogin(t *testing.T) {
+	ctx := context.Background()
+	magicLinks, deps := newTestMagicLinkService()
+	userRepo := new(MockUserRepo)
+	membershipRepo := new(MockMembershipRepo)
+	refreshRepo := new(MockRefreshTokenRepo)
+	svc := NewAuthService(userRepo, nil, membershipRepo, refreshRepo, newTestJWTManager(t), token.NewRefreshManager(), nil, nil, nil, nil, nil, nil, nil, magicLinks, nil, nil, nil,
+		&Config{AccessTokenTTL: 900, RefreshTokenTTL: 3600}, nil)
+
+	membership := deps.directory.memberships[0]
+	userRepo.On("GetByID", ctx, deps.user.ID).Return(deps.user, nil)
+	membershipRepo.On("GetByUserID", ctx, deps.user.ID).Return([]*model.OrgMembership{membership}, nil)
+	refreshRepo.On("Create", ctx, mock.MatchedBy(func(rt *model.RefreshToken) bool {
+		return rt.OrgID == deps.orgID
+	})).Return(nil)
+
+	require.NoError(t, magicLinks.RequestLink(ctx, deps.user.Email, deps.orgID, "", ""))
+	result, err := svc.CompleteMagicLinkLogin(ctx, deps.emails.magicLinks[deps.user.Email], "test-agent", "127.0.0.1")
+	require.NoError(t, err)
+	claims, err := svc.jwtManager.Validate(ctx, result.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, deps.user.ID, claims.UserID)
+	assert.Equal(t, deps.orgID, claims.OrgID)
+	assert.Equal(t, []string{token.AMREmail}, claims.AMR)
+	assert.NotEmpty(t, result.RefreshToken)
+
+	_, err = svc.CompleteMagicLinkLogin(ctx, deps.emails.magicLinks[deps.user.Email], "test-agent", "127.0.0.1")
+	assert.ErrorIs(t, err, appErrors.ErrInvalidMagicLink)
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+type MemberRepository interface {
+	GetByUserAndOrg(ctx context.Context, userID, orgID uuid.UUID) (*model.OrgMembership, error)
+	Update(ctx context.Context, membership *model.OrgMembership) error
+	ListByOrgID(ctx context.Context, orgID uuid.UUID) ([]*model.OrgMember, error)
+	Delete(ctx context.Context, userID, orgID uuid.UUID) (bool, error)
+	TransferOwnership(ctx context.Context, orgID, fromUserID, toUserID uuid.UUID) error
+}
+
+// MemberUpdate lists the membership fields to change; nil fields are kept.
+type MemberUpdate struct {
+	Role     *model.Role
+	IsActive *bool
+}
+
+// MemberService manages the members of an organization. An organization
+// always has exactly one owner: the owner's membership cannot be changed or
+// removed, and ownership only moves by transfer. Admins manage members and
+// viewers; only the owner manages admins.
+type MemberService struct {
+	membershipRepo MemberRepository
+	refreshRepo    RefreshTokenRepository
+	tokenRevoker   TokenRevoker
+	auditService   *AuditService
+}
+
+func NewMemberService(
+	membershipRepo MemberRepository,
+	refreshRepo RefreshTokenRepository,
+	tokenRevoker TokenRevoker,
+	auditService *AuditService,
+) *MemberService {
+	return &MemberService{
+		membershipRepo: membershipRepo,
+		refreshRepo:    refreshRepo,
+		tokenRevoker:   tokenRevoker,
+		auditService:   auditService,
+	}
+}
+
+// ListMembers returns the members of the organization. Any active member
+// can list them.
+func (s *MemberService) ListMembers(ctx context.Context, userID, orgID uuid.UUID) ([]*model.OrgMember, error) {
+	if _, err := s.activeMembership(ctx, userID, orgID); err != nil {
+		return nil, err
+	}
+
+	members, err := s.membershipRepo.ListByOrgID(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get members: %w", err)
+	}
+	return members, nil
+}
+
+// UpdateMember changes the role of a member or deactivates and reactivates
+// the membership. Deactivated members are signed out.
+func (s *MemberService) UpdateMember(ctx context.Context, userID, orgID, memberID uuid.UUID, update MemberUpdate, ipAddress, userAgent string) (*model.OrgMembership, error) {
+	actor, err := s.activeMembership(ctx, userID, orgID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.manageableMember(ctx, actor, memberID)
+	if err != nil {
+		return nil, err
+	}
+
+	previousRole, wasActive := target.Role, target.IsActive
+	if update.Role != nil {
+		switch *update.Role {
+		case model.RoleMember, model.RoleViewer:
+		case model.RoleAdmin:
+			if actor.Role != model.RoleOwner {
+				return nil, errors.ErrInsufficientRole
+			}
+		default:
+			// Ownership moves through TransferOwnership only
+			return nil, errors.ErrInvalidInput
+		}
+		target.Role = *update.Role
+	}
+	if update.IsActive != nil {
+		target.IsActive = *update.IsActive
+	}
+
+	if err := s.membershipRepo.Update(ctx, target); err != nil {
+		return nil, fmt.Errorf("failed to update member: %w", err)
+	}
+
+	if target.Role != previousRole || (!wasActive && target.IsActive) {
+		s.audit(ctx, &userID, model.EventMemberUpdated, map[string]interface{}{
+			"org_id":        orgID.String(),
+			"member_id":     memberID.String(),
+			"role":          string(target.Role),
+			"previous_role": string(previousRole),
+			"is_active":     target.IsActive,
+		}, ipAddress, userAgent)
+	}
+	if wasActive && !target.IsActive {
+		s.endSessions(ctx, memberID)
+		s.audit(ctx, &userID, model.EventMemberDeactivated, map[string]interface{}{
+			"org_id":    orgID.String(),
+			"member_id": memberID.String(),
+		}, ipAddress, userAgent)
+	}
+
+	return target, nil
+}
+
+// RemoveMember removes the user from the organization and signs them out.
+// Members other than the owner can also remove themselves to leave.
+func (s *MemberService) RemoveMember(ctx context.Context, userID, orgID, memberID uuid.UUID, ipAddress, userAgent string) error {
+	actor, err := s.activeMembership(ctx, userID, orgID)
+	if err != nil {
+		return err
+	}
+
+	var target *model.OrgMembership
+	if memberID == userID {
+		if actor.Role == model.RoleOwner {
+			return errors.ErrOwnerImmutable
+		}
+		target = actor
+	} else if target, err = s.manageableMember(ctx, actor, memberID); err != nil {
+		return err
+	}
+
+	deleted, err := s.membershipRepo.Delete(ctx, target.UserID, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+	if !deleted {
+		return errors.ErrMemberNotFound
+	}
+
+	s.endSessions(ctx, memberID)
+	s.audit(ctx, &userID, model.EventMemberRemoved, map[string]interface{}{
+		"org_id":    orgID.String(),
+		"member_id": memberID.String(),
+		"role":      string(target.Role),
+	}, ipAddress, userAgent)
+	return nil
+}
+
+// TransferOwnership makes an active member the owner. The previous owner
+// stays in the organization as an admin.
+func (s *MemberService) TransferOwnership(ctx context.Context, userID, orgID, newOwnerID uuid.UUID, ipAddress, userAgent string) error {
+	actor, err := s.activeMembership(ctx, userID, orgID)
+	if err != nil {
+		return err
+	}
+	if actor.Role != model.RoleOwner {
+		return errors.ErrInsufficientRole
+	}
+	if newOwnerID == userID {
+		return errors.ErrInvalidInput
+	}
+
+	target, err := s.member(ctx, newOwnerID, orgID)
+	if err != nil {
+		return err
+	}
+	if !target.IsActive {
+		return errors.ErrInvalidInput
+	}
+
+	if err := s.membershipRepo.TransferOwnership(ctx, orgID, userID, newOwnerID); err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return errors.ErrConflict
+		}
+		return fmt.Errorf("failed to transfer ownership: %w", err)
+	}
+
+	s.audit(ctx, &userID, model.EventOwnershipTransferred, map[string]interface{}{
+		"org_id":         orgID.String(),
+		"new_owner_id":   newOwnerID.String(),
+		"previous_owner": userID.String(),
+	}, ipAddress, userAgent)
+	return nil
+}
+
+// manageableMember returns the membership of memberID if the actor may
+// change it: the owner is immutable and only the owner manages admins.
+func (s *MemberService) manageableMember(ctx context.Context, actor *model.OrgMembership, memberID uuid.UUID) (*model.OrgMembership, error) {
+	if actor.Role != model.RoleOwner && actor.Role != model.RoleAdmin {
+		return nil, errors.ErrInsufficientRole
+	}
+
+	target, err := s.member(ctx, memberID, actor.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	if target.Role == model.RoleOwner {
+		return nil, errors.ErrOwnerImmutable
+	}
+	if target.Role == model.RoleAdmin && actor.Role != model.RoleOwner {
+		return nil, errors.ErrInsufficientRole
+	}
+	return target, nil
+}
+
+func (s *MemberService) member(ctx context.Context, userID, orgID uuid.UUID) (*model.OrgMembership, error) {
+	membership, err := s.membershipRepo.GetByUserAndOrg(ctx, userID, orgID)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.ErrMemberNotFound
+		}
+		return nil, fmt.Errorf("failed to get membership: %w", err)
+	}
+	if membership == nil {
+		return nil, errors.ErrMemberNotFound
+	}
+	return membership, nil
+}
+
+func (s *MemberService) activeMembership(ctx context.Context, userID, orgID uuid.UUID) (*model.OrgMembership, error) {
+	membership, err := s.membershipRepo.GetByUserAndOrg(ctx, userID, orgID)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.ErrForbidden
+		}
+		return nil, fmt.Errorf("failed to get membership: %w", err)
+	}
+	if membership == nil || !membership.IsActive {
+		return nil, errors.ErrForbidden
+	}
+	return membership, nil
+}
+
+// endSessions signs the user out, as their tokens may carry the membership.
+func (s *MemberService) endSessions(ctx context.Context, userID uuid.UUID) {
+	if s.refreshRepo != nil {
+		if err := s.refreshRepo.RevokeByUserID(ctx, userID); err != nil {
+			log.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to revoke refresh tokens")
+		}
+	}
+	revokeAccessTokens(ctx, s.tokenRevoker, userID)
+}
+
+func (s *MemberService) audit(ctx context.Context, userID *uuid.UUID, eventType model.AuditEventType, data map[string]interface{}, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	if err := s.auditService.Log(ctx, userID, eventType, data, ipAddress, userAgent); err != nil {
+		log.Error().Err(err).Str("event", string(eventType)).Msg("Failed to log member audit event")
+	}
+}
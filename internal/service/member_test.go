@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+type memoryMemberRepo struct{ memorySCIMMembershipRepo }
+
+func (r memoryMemberRepo) TransferOwnership(_ context.Context, orgID, fromUserID, toUserID uuid.UUID) error {
+	var from, to *model.OrgMembership
+	for _, membership := range r.memberships {
+		if membership.OrgID != orgID {
+			continue
+		}
+		if membership.UserID == fromUserID && membership.Role == model.RoleOwner {
+			from = membership
+		}
+		if membership.UserID == toUserID && membership.IsActive {
+			to = membership
+		}
+	}
+	if from == nil || to == nil {
+		return pgx.ErrNoRows
+	}
+	from.Role = model.RoleAdmin
+	to.Role = model.RoleOwner
+	return nil
+}
+
+type memberTestDeps struct {
+	directory *memoryDirectory
+	revoker   *recordingRevoker
+	orgID     uuid.UUID
+	owner     *model.User
+	admin     *model.User
+	member    *model.User
+}
+
+func newTestMemberService() (*MemberService, *memberTestDeps) {
+	deps := &memberTestDeps{
+		directory: &memoryDirectory{users: map[uuid.UUID]*model.User{}},
+		revoker:   &recordingRevoker{},
+		orgID:     uuid.New(),
+	}
+	deps.owner = deps.directory.addUser("owner@example.com")
+	deps.directory.addMember(deps.owner, deps.orgID, model.RoleOwner)
+	deps.admin = deps.directory.addUser("admin@example.com")
+	deps.directory.addMember(deps.admin, deps.orgID, model.RoleAdmin)
+	deps.member = deps.directory.addUser("member@example.com")
+	deps.directory.addMember(deps.member, deps.orgID, model.RoleMember)
+
+	svc := NewMemberService(memoryMemberRepo{memorySCIMMembershipRepo{deps.directory}}, nil, deps.revoker, nil)
+	return svc, deps
+}
+
+func (d *memberTestDeps) membership(user *model.User) *model.OrgMembership {
+	for _, membership := range d.directory.memberships {
+		if membership.UserID == user.ID && membership.OrgID == d.orgID {
+			return membership
+		}
+	}
+	return nil
+}
+
+func rolePtr(role model.Role) *model.Role {
+	return &role
+}
+
+func TestMemberService_ListMembers(t *testing.T) {
+	ctx := context.Background()
+	svc, deps := newTestMemberService()
+
+	members, err := svc.ListMembers(ctx, deps.member.ID, deps.orgID)
+	require.NoError(t, err)
+	assert.Len(t, members, 3)
+
+	_, err = svc.ListMembers(ctx, uuid.New(), deps.orgID)
+	assert.ErrorIs(t, err, appErrors.ErrForbidden)
+
+	deps.membership(deps.member).IsActive = false
+	_, err = svc.ListMembers(ctx, deps.member.ID, deps.orgID)
+	assert.ErrorIs(t, err, appErrors.ErrForbidden)
+}
+
+func TestMemberService_UpdateMember(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("admins manage members but not admins", func(t *testing.T) {
+		svc, deps := newTestMemberService()
+
+		membership, err := svc.UpdateMember(ctx, deps.admin.ID, deps.orgID, deps.member.ID, MemberUpdate{Role: rolePtr(model.RoleViewer)}, "", "")
+		require.NoError(t, err)
+		assert.Equal(t, model.RoleViewer, membership.Role)
+		assert.Equal(t, model.RoleViewer, deps.membership(deps.member).Role)
+
+		_, err = svc.UpdateMember(ctx, deps.admin.ID, deps.orgID, deps.member.ID, MemberUpdate{Role: rolePtr(model.RoleAdmin)}, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrInsufficientRole)
+
+		other := deps.directory.addUser("other-admin@example.com")
+		deps.directory.addMember(other, deps.orgID, model.RoleAdmin)
+		_, err = svc.UpdateMember(ctx, deps.admin.ID, deps.orgID, other.ID, MemberUpdate{Role: rolePtr(model.RoleMember)}, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrInsufficientRole)
+
+		_, err = svc.UpdateMember(ctx, deps.member.ID, deps.orgID, deps.admin.ID, MemberUpdate{Role: rolePtr(model.RoleMember)}, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrInsufficientRole)
+	})
+
+	t.Run("the owner promotes and demotes admins", func(t *testing.T) {
+		svc, deps := newTestMemberService()
+
+		_, err := svc.UpdateMember(ctx, deps.owner.ID, deps.orgID, deps.member.ID, MemberUpdate{Role: rolePtr(model.RoleAdmin)}, "", "")
+		require.NoError(t, err)
+		_, err = svc.UpdateMember(ctx, deps.owner.ID, deps.orgID, deps.admin.ID, MemberUpdate{Role: rolePtr(model.RoleMember)}, "", "")
+		require.NoError(t, err)
+
+		assert.Equal(t, model.RoleAdmin, deps.membership(deps.member).Role)
+		assert.Equal(t, model.RoleMember, deps.membership(deps.admin).Role)
+	})
+
+	t.Run("the owner cannot be changed and ownership is not granted", func(t *testing.T) {
+		svc, deps := newTestMemberService()
+		inactive := false
+
+		_, err := svc.UpdateMember(ctx, deps.owner.ID, deps.orgID, deps.owner.ID, MemberUpdate{Role: rolePtr(model.RoleAdmin)}, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrOwnerImmutable)
+
+		_, err = svc.UpdateMember(ctx, deps.admin.ID, deps.orgID, deps.owner.ID, MemberUpdate{IsActive: &inactive}, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrOwnerImmutable)
+
+		_, err = svc.UpdateMember(ctx, deps.owner.ID, deps.orgID, deps.member.ID, MemberUpdate{Role: rolePtr(model.RoleOwner)}, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrInvalidInput)
+		assert.Equal(t, model.RoleOwner, deps.membership(deps.owner).Role)
+	})
+
+	t.Run("deactivation signs the member out", func(t *testing.T) {
+		svc, deps := newTestMemberService()
+		inactive := false
+
+		membership, err := svc.UpdateMember(ctx, deps.admin.ID, deps.orgID, deps.member.ID, MemberUpdate{IsActive: &inactive}, "", "")
+		require.NoError(t, err)
+		assert.False(t, membership.IsActive)
+		assert.Equal(t, []uuid.UUID{deps.member.ID}, deps.revoker.users)
+	})
+
+	t.Run("unknown members", func(t *testing.T) {
+		svc, deps := newTestMemberService()
+
+		_, err := svc.UpdateMember(ctx, deps.owner.ID, deps.orgID, uuid.New(), MemberUpdate{Role: rolePtr(model.RoleMember)}, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrMemberNotFound)
+	})
+}
+
+func TestMemberService_RemoveMember(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("admins remove members and sign them out", func(t *testing.T) {
+		svc, deps := newTestMemberService()
+
+		require.NoError(t, svc.RemoveMember(ctx, deps.admin.ID, deps.orgID, deps.member.ID, "", ""))
+		assert.Nil(t, deps.membership(deps.member))
+		assert.Equal(t, []uuid.UUID{deps.member.ID}, deps.revoker.users)
+	})
+
+	t.Run("members leave but cannot remove others", func(t *testing.T) {
+		svc, deps := newTestMemberService()
+
+		err := svc.RemoveMember(ctx, deps.member.ID, deps.orgID, deps.admin.ID, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrInsufficientRole)
+
+		require.NoError(t, svc.RemoveMember(ctx, deps.member.ID, deps.orgID, deps.member.ID, "", ""))
+		assert.Nil(t, deps.membership(deps.member))
+	})
+
+	t.Run("the owner cannot be removed or leave", func(t *testing.T) {
+		svc, deps := newTestMemberService()
+
+		err := svc.RemoveMember(ctx, deps.admin.ID, deps.orgID, deps.owner.ID, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrOwnerImmutable)
+
+		err = svc.RemoveMember(ctx, deps.owner.ID, deps.orgID, deps.owner.ID, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrOwnerImmutable)
+		assert.NotNil(t, deps.membership(deps.owner))
+	})
+}
+
+func TestMemberService_TransferOwnership(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("keeps exactly one owner", func(t *testing.T) {
+		svc, deps := newTestMemberService()
+
+		require.NoError(t, svc.TransferOwnership(ctx, deps.owner.ID, deps.orgID, deps.member.ID, "", ""))
+		assert.Equal(t, model.RoleAdmin, deps.membership(deps.owner).Role)
+		assert.Equal(t, model.RoleOwner, deps.membership(deps.member).Role)
+
+		owners := 0
+		for _, membership := range deps.directory.memberships {
+			if membership.Role == model.RoleOwner {
+				owners++
+			}
+		}
+		assert.Equal(t, 1, owners)
+	})
+
+	t.Run("only the owner transfers to an active member", func(t *testing.T) {
+		svc, deps := newTestMemberService()
+
+		err := svc.TransferOwnership(ctx, deps.admin.ID, deps.orgID, deps.admin.ID, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrInsufficientRole)
+
+		deps.membership(deps.member).IsActive = false
+		err = svc.TransferOwnership(ctx, deps.owner.ID, deps.orgID, deps.member.ID, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrInvalidInput)
+
+		err = svc.TransferOwnership(ctx, deps.owner.ID, deps.orgID, uuid.New(), "", "")
+		assert.ErrorIs(t, err, appErrors.ErrMemberNotFound)
+
+		assert.Equal(t, model.RoleOwner, deps.membership(deps.owner).Role)
+	})
+}
@@ -0,0 +1,427 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/token"
+)
+
+const (
+	mfaChallengeTTL         = 5 * time.Minute
+	mfaChallengeMaxAttempts = 5
+
+	mfaRecoveryCodeCount    = 10
+	mfaRecoveryCodeLength   = 10
+	mfaRecoveryCodeAlphabet = "abcdefghijklmnopqrstuvwxyz234567"
+)
+
+type MFARepository interface {
+	GetByUserID(ctx context.Context, userID uuid.UUID) (*model.UserMFA, error)
+	Upsert(ctx context.Context, mfa *model.UserMFA) error
+	UpdateLastUsedStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	Delete(ctx context.Context, userID uuid.UUID) error
+}
+
+type MFAChallengeRepository interface {
+	Create(ctx context.Context, challenge *model.MFAChallenge) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*model.MFAChallenge, error)
+	IncrementAttempts(ctx context.Context, id uuid.UUID) error
+	MarkAsUsed(ctx context.Context, id uuid.UUID) (bool, error)
+	DeleteExpired(ctx context.Context) error
+}
+
+type MFARecoveryCodeRepository interface {
+	Replace(ctx context.Context, userID uuid.UUID, codeHashes []string) error
+	GetUnusedByUserID(ctx context.Context, userID uuid.UUID) ([]*model.MFARecoveryCode, error)
+	CountUnused(ctx context.Context, userID uuid.UUID) (int, error)
+	MarkAsUsed(ctx context.Context, id uuid.UUID) (bool, error)
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
+}
+
+// MFAEnrollment is returned when a new TOTP secret is generated. The secret is
+// only shown once and must be confirmed with a code before it becomes active.
+type MFAEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"otpauth_uri"`
+}
+
+type MFAService struct {
+	mfaRepo       MFARepository
+	challengeRepo MFAChallengeRepository
+	recoveryRepo  MFARecoveryCodeRepository
+	userRepo      UserRepository
+	totp          *token.TOTPManager
+	hasher        token.PasswordHasher
+	auditService  *AuditService
+}
+
+func NewMFAService(
+	mfaRepo MFARepository,
+	challengeRepo MFAChallengeRepository,
+	recoveryRepo MFARecoveryCodeRepository,
+	userRepo UserRepository,
+	totp *token.TOTPManager,
+	hasher token.PasswordHasher,
+	auditService *AuditService,
+) *MFAService {
+	return &MFAService{
+		mfaRepo:       mfaRepo,
+		challengeRepo: challengeRepo,
+		recoveryRepo:  recoveryRepo,
+		userRepo:      userRepo,
+		totp:          totp,
+		hasher:        hasher,
+		auditService:  auditService,
+	}
+}
+
+func (s *MFAService) GetStatus(ctx context.Context, userID uuid.UUID) (*model.UserMFA, error) {
+	return s.mfaRepo.GetByUserID(ctx, userID)
+}
+
+func (s *MFAService) IsEnabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	mfa, err := s.mfaRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return mfa.IsEnabled(), nil
+}
+
+// RecoveryCodesRemaining returns how many unused recovery codes a user has.
+func (s *MFAService) RecoveryCodesRemaining(ctx context.Context, userID uuid.UUID) (int, error) {
+	return s.recoveryRepo.CountUnused(ctx, userID)
+}
+
+// BeginEnrollment generates a pending secret for a user without MFA.
+func (s *MFAService) BeginEnrollment(ctx context.Context, userID uuid.UUID) (*MFAEnrollment, error) {
+	mfa, err := s.mfaRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MFA settings: %w", err)
+	}
+	if mfa.IsEnabled() {
+		return nil, errors.ErrMFAAlreadyEnabled
+	}
+	if mfa == nil {
+		mfa = &model.UserMFA{UserID: userID}
+	}
+
+	return s.newPendingSecret(ctx, mfa)
+}
+
+// ConfirmEnrollment activates the pending secret once the user proves they
+// can generate codes for it. It also completes a secret regeneration. On first
+// enrollment the initial recovery codes are returned; they are never shown again.
+func (s *MFAService) ConfirmEnrollment(ctx context.Context, userID uuid.UUID, code, ipAddress, userAgent string) ([]string, error) {
+	mfa, err := s.mfaRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MFA settings: %w", err)
+	}
+	if mfa == nil || mfa.PendingSecret == "" {
+		return nil, errors.ErrMFANotEnabled
+	}
+
+	step, err := s.totp.Validate(mfa.PendingSecret, code, time.Now())
+	if err != nil {
+		return nil, errors.ErrInvalidMFACode
+	}
+
+	regenerated := mfa.IsEnabled()
+	now := time.Now()
+	mfa.Secret = mfa.PendingSecret
+	mfa.PendingSecret = ""
+	mfa.LastUsedStep = int64(step) // #nosec G115 -- TOTP steps fit in int64
+	if mfa.EnabledAt == nil {
+		mfa.EnabledAt = &now
+	}
+
+	if err := s.mfaRepo.Upsert(ctx, mfa); err != nil {
+		return nil, fmt.Errorf("failed to enable MFA: %w", err)
+	}
+
+	if regenerated {
+		s.audit(ctx, userID, model.EventMFARegenerated, nil, ipAddress, userAgent)
+		return nil, nil
+	}
+
+	s.audit(ctx, userID, model.EventMFAEnabled, nil, ipAddress, userAgent)
+
+	return s.replaceRecoveryCodes(ctx, userID)
+}
+
+// Regenerate issues a new pending secret for a user with MFA enabled. The
+// current secret stays active until the new one is confirmed.
+func (s *MFAService) Regenerate(ctx context.Context, userID uuid.UUID, code, ipAddress, userAgent string) (*MFAEnrollment, error) {
+	mfa, err := s.requireEnabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.verifyCode(ctx, mfa, code, ipAddress, userAgent); err != nil {
+		return nil, err
+	}
+
+	return s.newPendingSecret(ctx, mfa)
+}
+
+// Disable removes MFA from the account after verifying a current code.
+func (s *MFAService) Disable(ctx context.Context, userID uuid.UUID, code, ipAddress, userAgent string) error {
+	mfa, err := s.requireEnabled(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.verifyCode(ctx, mfa, code, ipAddress, userAgent); err != nil {
+		return err
+	}
+
+	if err := s.mfaRepo.Delete(ctx, userID); err != nil {
+		return fmt.Errorf("failed to disable MFA: %w", err)
+	}
+	if err := s.recoveryRepo.DeleteByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	s.audit(ctx, userID, model.EventMFADisabled, nil, ipAddress, userAgent)
+
+	return nil
+}
+
+// RegenerateRecoveryCodes invalidates all existing recovery codes and returns a
+// fresh set. The plain codes are only available in this response.
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code, ipAddress, userAgent string) ([]string, error) {
+	mfa, err := s.requireEnabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.verifyCode(ctx, mfa, code, ipAddress, userAgent); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, userID, model.EventMFARecoveryCodesRegenerated, nil, ipAddress, userAgent)
+
+	return codes, nil
+}
+
+// CreateChallenge issues a short-lived, single-use token that identifies a
+// login waiting for its second factor. A non-nil orgID records the
+// organization requested at login.
+func (s *MFAService) CreateChallenge(ctx context.Context, userID, orgID uuid.UUID) (string, error) {
+	challengeToken, err := generateToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate challenge: %w", err)
+	}
+
+	challenge := &model.MFAChallenge{
+		UserID:    userID,
+		TokenHash: hashToken(challengeToken),
+		ExpiresAt: time.Now().Add(mfaChallengeTTL),
+	}
+	if orgID != uuid.Nil {
+		challenge.OrgID = &orgID
+	}
+	if err := s.challengeRepo.Create(ctx, challenge); err != nil {
+		return "", fmt.Errorf("failed to create challenge: %w", err)
+	}
+
+	return challengeToken, nil
+}
+
+// VerifyChallenge completes a login challenge with a TOTP or recovery code and
+// returns the consumed challenge.
+func (s *MFAService) VerifyChallenge(ctx context.Context, challengeToken, code, ipAddress, userAgent string) (*model.MFAChallenge, error) {
+	challenge, err := s.challengeRepo.GetByTokenHash(ctx, hashToken(challengeToken))
+	if err != nil {
+		return nil, errors.ErrInvalidToken
+	}
+	if challenge.UsedAt != nil || time.Now().After(challenge.ExpiresAt) || challenge.Attempts >= mfaChallengeMaxAttempts {
+		return nil, errors.ErrInvalidToken
+	}
+
+	mfa, err := s.requireEnabled(ctx, challenge.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.verifyCode(ctx, mfa, code, ipAddress, userAgent); err != nil {
+		if incErr := s.challengeRepo.IncrementAttempts(ctx, challenge.ID); incErr != nil {
+			log.Error().Err(incErr).Str("challenge_id", challenge.ID.String()).Msg("Failed to record MFA attempt")
+		}
+		return nil, err
+	}
+
+	used, err := s.challengeRepo.MarkAsUsed(ctx, challenge.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume challenge: %w", err)
+	}
+	if !used {
+		return nil, errors.ErrInvalidToken
+	}
+
+	return challenge, nil
+}
+
+func (s *MFAService) requireEnabled(ctx context.Context, userID uuid.UUID) (*model.UserMFA, error) {
+	mfa, err := s.mfaRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get MFA settings: %w", err)
+	}
+	if !mfa.IsEnabled() {
+		return nil, errors.ErrMFANotEnabled
+	}
+	return mfa, nil
+}
+
+// verifyCode validates a TOTP code against the active secret and consumes its
+// time step so the same code cannot be replayed. Codes in the recovery code
+// format are checked against the user's unused recovery codes instead.
+func (s *MFAService) verifyCode(ctx context.Context, mfa *model.UserMFA, code, ipAddress, userAgent string) error {
+	if recoveryCode := normalizeRecoveryCode(code); len(recoveryCode) == mfaRecoveryCodeLength {
+		consumed, err := s.consumeRecoveryCode(ctx, mfa.UserID, recoveryCode, ipAddress, userAgent)
+		if err != nil {
+			return err
+		}
+		if consumed {
+			return nil
+		}
+
+		s.audit(ctx, mfa.UserID, model.EventMFAFailed, map[string]interface{}{"method": "recovery_code"}, ipAddress, userAgent)
+		return errors.ErrInvalidMFACode
+	}
+
+	step, err := s.totp.Validate(mfa.Secret, code, time.Now())
+	if err == nil {
+		var fresh bool
+		fresh, err = s.mfaRepo.UpdateLastUsedStep(ctx, mfa.UserID, int64(step)) // #nosec G115 -- TOTP steps fit in int64
+		if err != nil {
+			return fmt.Errorf("failed to record MFA code usage: %w", err)
+		}
+		if fresh {
+			return nil
+		}
+	}
+
+	s.audit(ctx, mfa.UserID, model.EventMFAFailed, nil, ipAddress, userAgent)
+	return errors.ErrInvalidMFACode
+}
+
+// consumeRecoveryCode marks the matching unused recovery code as used. Hashes
+// are salted, so each unused code has to be checked in turn.
+func (s *MFAService) consumeRecoveryCode(ctx context.Context, userID uuid.UUID, code, ipAddress, userAgent string) (bool, error) {
+	codes, err := s.recoveryRepo.GetUnusedByUserID(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get recovery codes: %w", err)
+	}
+
+	for _, stored := range codes {
+		match, err := s.hasher.Verify(ctx, code, stored.CodeHash)
+		if err != nil {
+			return false, fmt.Errorf("failed to verify recovery code: %w", err)
+		}
+		if !match {
+			continue
+		}
+
+		used, err := s.recoveryRepo.MarkAsUsed(ctx, stored.ID)
+		if err != nil {
+			return false, fmt.Errorf("failed to consume recovery code: %w", err)
+		}
+		if !used {
+			return false, nil
+		}
+
+		s.audit(ctx, userID, model.EventMFARecoveryCodeUsed, map[string]interface{}{
+			"recovery_code_id": stored.ID.String(),
+			"remaining":        len(codes) - 1,
+		}, ipAddress, userAgent)
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (s *MFAService) replaceRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	codes := make([]string, 0, mfaRecoveryCodeCount)
+	hashes := make([]string, 0, mfaRecoveryCodeCount)
+
+	for i := 0; i < mfaRecoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		hash, err := s.hasher.Hash(ctx, code)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash recovery code: %w", err)
+		}
+		codes = append(codes, code[:mfaRecoveryCodeLength/2]+"-"+code[mfaRecoveryCodeLength/2:])
+		hashes = append(hashes, hash)
+	}
+
+	if err := s.recoveryRepo.Replace(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("failed to store recovery codes: %w", err)
+	}
+
+	return codes, nil
+}
+
+func (s *MFAService) newPendingSecret(ctx context.Context, mfa *model.UserMFA) (*MFAEnrollment, error) {
+	user, err := s.userRepo.GetByID(ctx, mfa.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	secret, err := s.totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	mfa.PendingSecret = secret
+	if err := s.mfaRepo.Upsert(ctx, mfa); err != nil {
+		return nil, fmt.Errorf("failed to store MFA secret: %w", err)
+	}
+
+	return &MFAEnrollment{
+		Secret:          secret,
+		ProvisioningURI: s.totp.ProvisioningURI(secret, user.Email),
+	}, nil
+}
+
+func (s *MFAService) audit(ctx context.Context, userID uuid.UUID, eventType model.AuditEventType, data map[string]interface{}, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	if err := s.auditService.Log(ctx, &userID, eventType, data, ipAddress, userAgent); err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Str("event", string(eventType)).Msg("Failed to log MFA audit event")
+	}
+}
+
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, mfaRecoveryCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate recovery code: %w", err)
+	}
+	for i := range b {
+		b[i] = mfaRecoveryCodeAlphabet[int(b[i])%len(mfaRecoveryCodeAlphabet)]
+	}
+	return string(b), nil
+}
+
+// normalizeRecoveryCode strips the separator and whitespace users tend to type
+// along with the code.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+}
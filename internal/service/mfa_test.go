@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/token"
+)
+
+type MockMFARepo struct {
+	mock.Mock
+}
+
+func (m *MockMFARepo) GetByUserID(ctx context.Context, userID uuid.UUID) (*model.UserMFA, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.UserMFA), args.Error(1)
+}
+
+func (m *MockMFARepo) Upsert(ctx context.Context, mfa *model.UserMFA) error {
+	args := m.Called(ctx, mfa)
+	return args.Error(0)
+}
+
+func (m *MockMFARepo) UpdateLastUsedStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	args := m.Called(ctx, userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARepo) Delete(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+type MockMFARecoveryCodeRepo struct {
+	mock.Mock
+}
+
+func (m *MockMFARecoveryCodeRepo) Replace(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	args := m.Called(ctx, userID, codeHashes)
+	return args.Error(0)
+}
+
+func (m *MockMFARecoveryCodeRepo) GetUnusedByUserID(ctx context.Context, userID uuid.UUID) ([]*model.MFARecoveryCode, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*model.MFARecoveryCode), args.Error(1)
+}
+
+func (m *MockMFARecoveryCodeRepo) CountUnused(ctx context.Context, userID uuid.UUID) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockMFARecoveryCodeRepo) MarkAsUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockMFARecoveryCodeRepo) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func TestMFAService_BeginEnrollment_AlreadyEnabled(t *testing.T) {
+	mfaRepo := new(MockMFARepo)
+	svc := NewMFAService(mfaRepo, nil, nil, nil, token.NewTOTPManager("ZenoN Cloud"), nil, nil)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	now := time.Now()
+	mfaRepo.On("GetByUserID", ctx, userID).Return(&model.UserMFA{UserID: userID, Secret: "ABC", EnabledAt: &now}, nil)
+
+	_, err := svc.BeginEnrollment(ctx, userID)
+	assert.ErrorIs(t, err, appErrors.ErrMFAAlreadyEnabled)
+}
+
+func TestMFAService_ConfirmEnrollment(t *testing.T) {
+	mfaRepo := new(MockMFARepo)
+	recoveryRepo := new(MockMFARecoveryCodeRepo)
+	hasher := new(MockPasswordHasher)
+	totp := token.NewTOTPManager("ZenoN Cloud")
+	svc := NewMFAService(mfaRepo, nil, recoveryRepo, nil, totp, hasher, nil)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	pending := &model.UserMFA{UserID: userID, PendingSecret: secret}
+	mfaRepo.On("GetByUserID", ctx, userID).Return(pending, nil)
+	mfaRepo.On("Upsert", ctx, pending).Return(nil)
+
+	_, err = svc.ConfirmEnrollment(ctx, userID, "000000", "", "")
+	if err == nil {
+		// extremely unlikely: "000000" happened to be the current code
+		t.Skip("random secret produced code 000000")
+	}
+	assert.ErrorIs(t, err, appErrors.ErrInvalidMFACode)
+
+	code, err := totp.GenerateCode(secret, time.Now())
+	require.NoError(t, err)
+	hasher.On("Hash", ctx, mock.AnythingOfType("string")).Return("hashed", nil)
+	recoveryRepo.On("Replace", ctx, userID, mock.MatchedBy(func(hashes []string) bool {
+		return len(hashes) == mfaRecoveryCodeCount
+	})).Return(nil)
+
+	recoveryCodes, err := svc.ConfirmEnrollment(ctx, userID, code, "", "")
+	require.NoError(t, err)
+	require.Len(t, recoveryCodes, mfaRecoveryCodeCount)
+	assert.Len(t, normalizeRecoveryCode(recoveryCodes[0]), mfaRecoveryCodeLength)
+
+	assert.True(t, pending.IsEnabled())
+	assert.Equal(t, secret, pending.Secret)
+	assert.Empty(t, pending.PendingSecret)
+	mfaRepo.AssertExpectations(t)
+	recoveryRepo.AssertExpectations(t)
+}
+
+func TestMFAService_Disable_RejectsReplayedCode(t *testing.T) {
+	mfaRepo := new(MockMFARepo)
+	totp := token.NewTOTPManager("ZenoN Cloud")
+	svc := NewMFAService(mfaRepo, nil, nil, nil, totp, nil, nil)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	now := time.Now()
+	enabled := &model.UserMFA{UserID: userID, Secret: secret, EnabledAt: &now}
+	mfaRepo.On("GetByUserID", ctx, userID).Return(enabled, nil)
+	mfaRepo.On("UpdateLastUsedStep", ctx, userID, mock.AnythingOfType("int64")).Return(false, nil)
+
+	code, err := totp.GenerateCode(secret, now)
+	require.NoError(t, err)
+
+	err = svc.Disable(ctx, userID, code, "", "")
+	assert.ErrorIs(t, err, appErrors.ErrInvalidMFACode)
+	mfaRepo.AssertNotCalled(t, "Delete", ctx, userID)
+}
+
+func TestMFAService_Disable_WithRecoveryCode(t *testing.T) {
+	mfaRepo := new(MockMFARepo)
+	recoveryRepo := new(MockMFARecoveryCodeRepo)
+	hasher := new(MockPasswordHasher)
+	svc := NewMFAService(mfaRepo, nil, recoveryRepo, nil, token.NewTOTPManager("ZenoN Cloud"), hasher, nil)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	now := time.Now()
+	mfaRepo.On("GetByUserID", ctx, userID).Return(&model.UserMFA{UserID: userID, Secret: "ABC", EnabledAt: &now}, nil)
+
+	first := &model.MFARecoveryCode{ID: uuid.New(), UserID: userID, CodeHash: "hash-1"}
+	second := &model.MFARecoveryCode{ID: uuid.New(), UserID: userID, CodeHash: "hash-2"}
+	recoveryRepo.On("GetUnusedByUserID", ctx, userID).Return([]*model.MFARecoveryCode{first, second}, nil)
+	hasher.On("Verify", ctx, "abcde23456", "hash-1").Return(false, nil)
+	hasher.On("Verify", ctx, "abcde23456", "hash-2").Return(true, nil)
+	recoveryRepo.On("MarkAsUsed", ctx, second.ID).Return(true, nil)
+	mfaRepo.On("Delete", ctx, userID).Return(nil)
+	recoveryRepo.On("DeleteByUserID", ctx, userID).Return(nil)
+
+	err := svc.Disable(ctx, userID, "ABCDE-23456", "", "")
+	require.NoError(t, err)
+
+	mfaRepo.AssertExpectations(t)
+	recoveryRepo.AssertExpectations(t)
+	mfaRepo.AssertNotCalled(t, "UpdateLastUsedStep", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMFAService_RegenerateRecoveryCodes_RejectsUnknownCode(t *testing.T) {
+	mfaRepo := new(MockMFARepo)
+	recoveryRepo := new(MockMFARecoveryCodeRepo)
+	hasher := new(MockPasswordHasher)
+	svc := NewMFAService(mfaRepo, nil, recoveryRepo, nil, token.NewTOTPManager("ZenoN Cloud"), hasher, nil)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	now := time.Now()
+	mfaRepo.On("GetByUserID", ctx, userID).Return(&model.UserMFA{UserID: userID, Secret: "ABC", EnabledAt: &now}, nil)
+	stored := &model.MFARecoveryCode{ID: uuid.New(), UserID: userID, CodeHash: "hash-1"}
+	recoveryRepo.On("GetUnusedByUserID", ctx, userID).Return([]*model.MFARecoveryCode{stored}, nil)
+	hasher.On("Verify", ctx, "zzzzz77777", "hash-1").Return(false, nil)
+
+	_, err := svc.RegenerateRecoveryCodes(ctx, userID, "zzzzz-77777", "", "")
+	assert.ErrorIs(t, err, appErrors.ErrInvalidMFACode)
+	recoveryRepo.AssertNotCalled(t, "Replace", mock.Anything, mock.Anything, mock.Anything)
+	recoveryRepo.AssertNotCalled(t, "MarkAsUsed", mock.Anything, mock.Anything)
+}
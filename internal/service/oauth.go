@@ -0,0 +1,526 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/token"
+)
+
+const (
+	oauthCodeTTL = 1 * time.Minute
+
+	OAuthScopeOpenID  = "openid"
+	OAuthScopeProfile = "profile"
+	OAuthScopeEmail   = "email"
+
+	OAuthGrantAuthorizationCode = "authorization_code"
+	OAuthGrantClientCredentials = "client_credentials"
+
+	pkceMethodS256 = "S256"
+)
+
+type OAuthClientRepository interface {
+	GetByClientID(ctx context.Context, clientID string) (*model.OAuthClient, error)
+}
+
+type OAuthAuthorizationCodeRepository interface {
+	Create(ctx context.Context, code *model.OAuthAuthorizationCode) error
+	GetByCodeHash(ctx context.Context, codeHash string) (*model.OAuthAuthorizationCode, error)
+	MarkAsUsed(ctx context.Context, id uuid.UUID) (bool, error)
+	DeleteExpired(ctx context.Context) error
+}
+
+// OAuthError is an OAuth 2.0 error response (RFC 6749 sections 4.1.2.1 and
+// 5.2). When RedirectURI is set the error must be reported to the client by
+// redirecting there; otherwise it is shown to the user or returned as JSON.
+type OAuthError struct {
+	Code        string
+	Description string
+	RedirectURI string
+	State       string
+}
+
+func (e *OAuthError) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+// RedirectLocation returns RedirectURI with the error parameters appended.
+func (e *OAuthError) RedirectLocation() string {
+	params := url.Values{"error": {e.Code}}
+	if e.Description != "" {
+		params.Set("error_description", e.Description)
+	}
+	if e.State != "" {
+		params.Set("state", e.State)
+	}
+	return appendQuery(e.RedirectURI, params)
+}
+
+func newOAuthError(code, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description}
+}
+
+// AuthorizationRequest holds the parameters of the authorization endpoint.
+type AuthorizationRequest struct {
+	ResponseType        string `form:"response_type" json:"response_type"`
+	ClientID            string `form:"client_id" json:"client_id"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	Nonce               string `form:"nonce" json:"nonce"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+}
+
+// TokenRequest holds the parameters of the token endpoint. ClientID and
+// ClientSecret come from HTTP Basic auth or the form body.
+type TokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	Scope        string `form:"scope"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	IDToken     string `json:"id_token,omitempty"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+// DiscoveryDocument is the OpenID Provider Metadata published at
+// /.well-known/openid-configuration.
+type DiscoveryDocument struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// OAuthService implements the OpenID Connect provider: the authorization code
+// flow with mandatory PKCE, ID tokens and the UserInfo endpoint. The user
+// signs in and approves the request in the frontend, which then calls
+// Authorize with its own access token. Backend services obtain tokens for the
+// internal API with the client_credentials grant.
+type OAuthService struct {
+	clientRepo     OAuthClientRepository
+	codeRepo       OAuthAuthorizationCodeRepository
+	userRepo       UserRepository
+	membershipRepo MembershipRepository
+	jwtManager     *token.JWTManager
+	auditService   *AuditService
+	issuer         string
+	accessTokenTTL int
+}
+
+func NewOAuthService(
+	clientRepo OAuthClientRepository,
+	codeRepo OAuthAuthorizationCodeRepository,
+	userRepo UserRepository,
+	membershipRepo MembershipRepository,
+	jwtManager *token.JWTManager,
+	auditService *AuditService,
+	issuer string,
+	accessTokenTTL int,
+) *OAuthService {
+	return &OAuthService{
+		clientRepo:     clientRepo,
+		codeRepo:       codeRepo,
+		userRepo:       userRepo,
+		membershipRepo: membershipRepo,
+		jwtManager:     jwtManager,
+		auditService:   auditService,
+		issuer:         strings.TrimRight(issuer, "/"),
+		accessTokenTTL: accessTokenTTL,
+	}
+}
+
+// Issuer is the iss of ID tokens and the audience of access tokens issued to
+// OAuth clients.
+func (s *OAuthService) Issuer() string {
+	return s.issuer
+}
+
+func (s *OAuthService) Discovery() *DiscoveryDocument {
+	return &DiscoveryDocument{
+		Issuer:                            s.issuer,
+		AuthorizationEndpoint:             s.issuer + "/oauth/authorize",
+		TokenEndpoint:                     s.issuer + "/oauth/token",
+		UserInfoEndpoint:                  s.issuer + "/userinfo",
+		JWKSURI:                           s.issuer + "/.well-known/jwks.json",
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{OAuthGrantAuthorizationCode, OAuthGrantClientCredentials},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{s.jwtManager.SigningAlgorithm()},
+		ScopesSupported:                   []string{OAuthScopeOpenID, OAuthScopeProfile, OAuthScopeEmail},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{pkceMethodS256},
+		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "nonce", "email", "email_verified", "name"},
+	}
+}
+
+// ValidateAuthorizationRequest checks an authorization request before the
+// user is asked to approve it. Errors about the client or redirect URI are
+// returned without RedirectURI because redirecting to an unverified URI would
+// make the endpoint an open redirector.
+func (s *OAuthService) ValidateAuthorizationRequest(ctx context.Context, req *AuthorizationRequest) (*model.OAuthClient, error) {
+	client, err := s.clientRepo.GetByClientID(ctx, req.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil || !client.IsActive || !client.AllowsGrant(OAuthGrantAuthorizationCode) {
+		return nil, newOAuthError("invalid_client", "unknown client")
+	}
+	if !client.HasRedirectURI(req.RedirectURI) {
+		return nil, newOAuthError("invalid_request", "redirect_uri is not registered for this client")
+	}
+
+	redirectErr := func(code, description string) error {
+		return &OAuthError{Code: code, Description: description, RedirectURI: req.RedirectURI, State: req.State}
+	}
+
+	if req.ResponseType != "code" {
+		return nil, redirectErr("unsupported_response_type", "only response_type=code is supported")
+	}
+
+	scopes := strings.Fields(req.Scope)
+	if !slices.Contains(scopes, OAuthScopeOpenID) {
+		return nil, redirectErr("invalid_scope", "the openid scope is required")
+	}
+	for _, scope := range scopes {
+		if !client.AllowsScope(scope) {
+			return nil, redirectErr("invalid_scope", fmt.Sprintf("scope %q is not allowed for this client", scope))
+		}
+	}
+
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != pkceMethodS256 {
+		return nil, redirectErr("invalid_request", "PKCE with code_challenge_method=S256 is required")
+	}
+
+	return client, nil
+}
+
+// Authorize issues an authorization code for a request approved by the user
+// and returns the URL the user agent must be redirected to.
+func (s *OAuthService) Authorize(ctx context.Context, userID, orgID uuid.UUID, req *AuthorizationRequest, ipAddress, userAgent string) (string, error) {
+	client, err := s.ValidateAuthorizationRequest(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	code, err := generateToken()
+	if err != nil {
+		return "", err
+	}
+
+	authCode := &model.OAuthAuthorizationCode{
+		CodeHash:            hashToken(code),
+		ClientID:            client.ClientID,
+		UserID:              userID,
+		RedirectURI:         req.RedirectURI,
+		Scope:               normalizeScope(req.Scope),
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		ExpiresAt:           time.Now().Add(oauthCodeTTL),
+	}
+	if orgID != uuid.Nil {
+		authCode.OrgID = &orgID
+	}
+	if req.Nonce != "" {
+		authCode.Nonce = &req.Nonce
+	}
+	if err := s.codeRepo.Create(ctx, authCode); err != nil {
+		return "", err
+	}
+
+	s.audit(ctx, userID, model.EventOAuthAuthorized, map[string]interface{}{
+		"client_id": client.ClientID,
+		"scope":     authCode.Scope,
+	}, ipAddress, userAgent)
+
+	params := url.Values{"code": {code}, "iss": {s.issuer}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	return appendQuery(req.RedirectURI, params), nil
+}
+
+// Exchange handles the token endpoint.
+func (s *OAuthService) Exchange(ctx context.Context, req *TokenRequest) (*TokenResponse, error) {
+	if req.GrantType != OAuthGrantAuthorizationCode && req.GrantType != OAuthGrantClientCredentials {
+		return nil, newOAuthError("unsupported_grant_type", "")
+	}
+
+	client, err := s.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if !client.AllowsGrant(req.GrantType) {
+		return nil, newOAuthError("unauthorized_client", "grant type is not allowed for this client")
+	}
+
+	if req.GrantType == OAuthGrantClientCredentials {
+		return s.exchangeClientCredentials(ctx, client, req.Scope)
+	}
+
+	if req.Code == "" || req.CodeVerifier == "" {
+		return nil, newOAuthError("invalid_request", "code and code_verifier are required")
+	}
+
+	invalidGrant := newOAuthError("invalid_grant", "authorization code is invalid or expired")
+
+	authCode, err := s.codeRepo.GetByCodeHash(ctx, hashToken(req.Code))
+	if err != nil {
+		return nil, err
+	}
+	if authCode == nil || authCode.UsedAt != nil || time.Now().After(authCode.ExpiresAt) {
+		return nil, invalidGrant
+	}
+	if authCode.ClientID != client.ClientID || authCode.RedirectURI != req.RedirectURI {
+		return nil, invalidGrant
+	}
+	if !verifyPKCE(req.CodeVerifier, authCode.CodeChallenge) {
+		return nil, newOAuthError("invalid_grant", "code_verifier does not match")
+	}
+
+	// Codes are single-use; a concurrent redemption loses here
+	redeemed, err := s.codeRepo.MarkAsUsed(ctx, authCode.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !redeemed {
+		return nil, invalidGrant
+	}
+
+	user, err := s.userRepo.GetByID(ctx, authCode.UserID)
+	if err != nil || user == nil || !user.IsActive {
+		return nil, invalidGrant
+	}
+
+	var orgID uuid.UUID
+	var roles, permissions []string
+	if authCode.OrgID != nil {
+		// The user may have left the organization since the code was issued
+		orgID = *authCode.OrgID
+		membership, err := s.membershipRepo.GetByUserAndOrg(ctx, user.ID, orgID)
+		if err != nil || membership == nil || !membership.IsActive {
+			return nil, invalidGrant
+		}
+		roles = []string{string(membership.Role)}
+		permissions = permissionClaim(membership)
+	}
+
+	accessToken, err := s.jwtManager.GenerateAccessToken(ctx, token.AccessTokenParams{
+		UserID:      user.ID,
+		OrgID:       orgID,
+		Roles:       roles,
+		Permissions: permissions,
+		ClientID:    client.ClientID,
+		Scope:       authCode.Scope,
+		Audience:    []string{s.issuer},
+		TTLSeconds:  s.accessTokenTTL,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	idClaims := token.IDTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.issuer,
+			Subject:   user.ID.String(),
+			Audience:  []string{client.ClientID},
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Duration(s.accessTokenTTL) * time.Second)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	if authCode.Nonce != nil {
+		idClaims.Nonce = *authCode.Nonce
+	}
+	scopes := strings.Fields(authCode.Scope)
+	if slices.Contains(scopes, OAuthScopeEmail) {
+		idClaims.Email = user.Email
+		idClaims.EmailVerified = emailVerified(user)
+	}
+	if slices.Contains(scopes, OAuthScopeProfile) {
+		idClaims.Name = user.FullName
+	}
+
+	idToken, err := s.jwtManager.SignIDToken(ctx, idClaims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   s.accessTokenTTL,
+		IDToken:     idToken,
+		Scope:       authCode.Scope,
+	}, nil
+}
+
+// exchangeClientCredentials issues a token for a machine client acting on its
+// own behalf. The token has no user, is only accepted by the internal API and
+// carries the requested scopes, or all scopes of the client if none were
+// requested.
+func (s *OAuthService) exchangeClientCredentials(ctx context.Context, client *model.OAuthClient, scope string) (*TokenResponse, error) {
+	// Only confidential clients can prove their identity
+	if client.IsPublic() {
+		return nil, newOAuthError("unauthorized_client", "public clients cannot use client_credentials")
+	}
+
+	scope = normalizeScope(scope)
+	if scope == "" {
+		scope = strings.Join(client.Scopes, " ")
+	}
+	for _, requested := range strings.Fields(scope) {
+		if !client.AllowsScope(requested) {
+			return nil, newOAuthError("invalid_scope", fmt.Sprintf("scope %q is not allowed for this client", requested))
+		}
+	}
+
+	accessToken, err := s.jwtManager.GenerateAccessToken(ctx, token.AccessTokenParams{
+		ClientID:   client.ClientID,
+		Scope:      scope,
+		Audience:   []string{token.InternalAudience},
+		TTLSeconds: s.accessTokenTTL,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().Str("client_id", client.ClientID).Str("scope", scope).Msg("Issued client credentials token")
+
+	return &TokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   s.accessTokenTTL,
+		Scope:       scope,
+	}, nil
+}
+
+// UserInfo returns the claims about the user released by the access token's
+// scopes.
+func (s *OAuthService) UserInfo(ctx context.Context, claims *token.Claims) (map[string]interface{}, error) {
+	if claims.ClientID == "" || claims.UserID == uuid.Nil || !claims.HasScope(OAuthScopeOpenID) {
+		return nil, newOAuthError("insufficient_scope", "the openid scope is required")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil || user == nil || !user.IsActive {
+		return nil, newOAuthError("invalid_token", "")
+	}
+
+	info := map[string]interface{}{"sub": user.ID.String()}
+	if claims.HasScope(OAuthScopeEmail) {
+		info["email"] = user.Email
+		info["email_verified"] = *emailVerified(user)
+	}
+	if claims.HasScope(OAuthScopeProfile) {
+		info["name"] = user.FullName
+	}
+	return info, nil
+}
+
+// authenticateClient checks the client secret of confidential clients.
+// Public clients must not send one; PKCE authenticates their requests.
+func (s *OAuthService) authenticateClient(ctx context.Context, clientID, clientSecret string) (*model.OAuthClient, error) {
+	invalidClient := newOAuthError("invalid_client", "client authentication failed")
+
+	if clientID == "" {
+		return nil, invalidClient
+	}
+	client, err := s.clientRepo.GetByClientID(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil || !client.IsActive {
+		return nil, invalidClient
+	}
+
+	if client.IsPublic() {
+		if clientSecret != "" {
+			return nil, invalidClient
+		}
+		return client, nil
+	}
+
+	if clientSecret == "" || subtle.ConstantTimeCompare([]byte(hashToken(clientSecret)), []byte(*client.ClientSecretHash)) != 1 {
+		return nil, invalidClient
+	}
+	return client, nil
+}
+
+func (s *OAuthService) audit(ctx context.Context, userID uuid.UUID, eventType model.AuditEventType, data map[string]interface{}, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	if err := s.auditService.Log(ctx, &userID, eventType, data, ipAddress, userAgent); err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Str("event", string(eventType)).Msg("Failed to log OAuth audit event")
+	}
+}
+
+// verifyPKCE checks an S256 code verifier (RFC 7636 section 4.6).
+func verifyPKCE(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// emailVerified reflects that accounts are only activated through the email
+// verification link.
+func emailVerified(user *model.User) *bool {
+	verified := user.IsActive
+	return &verified
+}
+
+func normalizeScope(scope string) string {
+	var scopes []string
+	for _, s := range strings.Fields(scope) {
+		if !slices.Contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	return strings.Join(scopes, " ")
+}
+
+func appendQuery(rawURL string, params url.Values) string {
+	separator := "?"
+	if strings.Contains(rawURL, "?") {
+		separator = "&"
+	}
+	return rawURL + separator + params.Encode()
+}
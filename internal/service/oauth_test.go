@@ -0,0 +1,358 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/token"
+)
+
+const (
+	testIssuer      = "https://auth.zeno.test"
+	testRedirectURI = "https://grafana.zeno.test/login/generic_oauth"
+	testVerifier    = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+type MockOAuthClientRepo struct {
+	mock.Mock
+}
+
+func (m *MockOAuthClientRepo) GetByClientID(ctx context.Context, clientID string) (*model.OAuthClient, error) {
+	args := m.Called(ctx, clientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.OAuthClient), args.Error(1)
+}
+
+type MockOAuthCodeRepo struct {
+	mock.Mock
+}
+
+func (m *MockOAuthCodeRepo) Create(ctx context.Context, code *model.OAuthAuthorizationCode) error {
+	args := m.Called(ctx, code)
+	return args.Error(0)
+}
+
+func (m *MockOAuthCodeRepo) GetByCodeHash(ctx context.Context, codeHash string) (*model.OAuthAuthorizationCode, error) {
+	args := m.Called(ctx, codeHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.OAuthAuthorizationCode), args.Error(1)
+}
+
+func (m *MockOAuthCodeRepo) MarkAsUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockOAuthCodeRepo) DeleteExpired(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func testOAuthClient(secret string) *model.OAuthClient {
+	client := &model.OAuthClient{
+		ClientID:     "grafana",
+		Name:         "Grafana",
+		RedirectURIs: []string{testRedirectURI},
+		Scopes:       []string{OAuthScopeOpenID, OAuthScopeEmail, OAuthScopeProfile},
+		GrantTypes:   []string{OAuthGrantAuthorizationCode},
+		IsActive:     true,
+	}
+	if secret != "" {
+		hash := hashToken(secret)
+		client.ClientSecretHash = &hash
+	}
+	return client
+}
+
+func testAuthorizationRequest() *AuthorizationRequest {
+	return &AuthorizationRequest{
+		ResponseType:        "code",
+		ClientID:            "grafana",
+		RedirectURI:         testRedirectURI,
+		Scope:               "openid email",
+		State:               "xyz",
+		Nonce:               "n-0S6_WzA2Mj",
+		CodeChallenge:       pkceChallenge(testVerifier),
+		CodeChallengeMethod: "S256",
+	}
+}
+
+func TestOAuthService_ValidateAuthorizationRequest(t *testing.T) {
+	clientRepo := new(MockOAuthClientRepo)
+	svc := NewOAuthService(clientRepo, nil, nil, nil, nil, nil, testIssuer, 900)
+	ctx := context.Background()
+	clientRepo.On("GetByClientID", ctx, "grafana").Return(testOAuthClient(""), nil)
+	clientRepo.On("GetByClientID", ctx, "unknown").Return(nil, nil)
+
+	_, err := svc.ValidateAuthorizationRequest(ctx, testAuthorizationRequest())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		modify   func(r *AuthorizationRequest)
+		code     string
+		redirect bool
+	}{
+		{"unknown client", func(r *AuthorizationRequest) { r.ClientID = "unknown" }, "invalid_client", false},
+		{"unregistered redirect", func(r *AuthorizationRequest) { r.RedirectURI = "https://evil.test/cb" }, "invalid_request", false},
+		{"implicit flow", func(r *AuthorizationRequest) { r.ResponseType = "token" }, "unsupported_response_type", true},
+		{"missing openid scope", func(r *AuthorizationRequest) { r.Scope = "email" }, "invalid_scope", true},
+		{"scope not allowed", func(r *AuthorizationRequest) { r.Scope = "openid admin" }, "invalid_scope", true},
+		{"missing PKCE", func(r *AuthorizationRequest) { r.CodeChallenge = "" }, "invalid_request", true},
+		{"plain PKCE", func(r *AuthorizationRequest) { r.CodeChallengeMethod = "plain" }, "invalid_request", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := testAuthorizationRequest()
+			tt.modify(req)
+			_, err := svc.ValidateAuthorizationRequest(ctx, req)
+
+			var oauthErr *OAuthError
+			require.ErrorAs(t, err, &oauthErr)
+			assert.Equal(t, tt.code, oauthErr.Code)
+			assert.Equal(t, tt.redirect, oauthErr.RedirectURI != "")
+		})
+	}
+}
+
+func TestOAuthService_AuthorizationCodeFlow(t *testing.T) {
+	clientRepo := new(MockOAuthClientRepo)
+	codeRepo := new(MockOAuthCodeRepo)
+	userRepo := new(MockUserRepo)
+	jwtManager := newTestJWTManager(t)
+	svc := NewOAuthService(clientRepo, codeRepo, userRepo, new(MockMembershipRepo), jwtManager, nil, testIssuer, 900)
+	ctx := context.Background()
+
+	user := &model.User{ID: uuid.New(), Email: "user@zeno.test", FullName: "Test User", IsActive: true}
+	clientRepo.On("GetByClientID", ctx, "grafana").Return(testOAuthClient("s3cret"), nil)
+	userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+
+	var stored *model.OAuthAuthorizationCode
+	codeRepo.On("Create", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*model.OAuthAuthorizationCode)
+		stored.ID = uuid.New()
+	}).Return(nil)
+
+	redirectTo, err := svc.Authorize(ctx, user.ID, uuid.Nil, testAuthorizationRequest(), "127.0.0.1", "test-agent")
+	require.NoError(t, err)
+
+	location, err := url.Parse(redirectTo)
+	require.NoError(t, err)
+	assert.Equal(t, "xyz", location.Query().Get("state"))
+	assert.Equal(t, testIssuer, location.Query().Get("iss"))
+	code := location.Query().Get("code")
+	require.NotEmpty(t, code)
+	assert.Equal(t, hashToken(code), stored.CodeHash)
+	assert.Nil(t, stored.OrgID)
+
+	codeRepo.On("GetByCodeHash", ctx, stored.CodeHash).Return(stored, nil)
+
+	t.Run("wrong verifier", func(t *testing.T) {
+		_, err := svc.Exchange(ctx, &TokenRequest{
+			GrantType: OAuthGrantAuthorizationCode, Code: code, RedirectURI: testRedirectURI,
+			CodeVerifier: "wrong-verifier-wrong-verifier-wrong-verifier", ClientID: "grafana", ClientSecret: "s3cret",
+		})
+		var oauthErr *OAuthError
+		require.ErrorAs(t, err, &oauthErr)
+		assert.Equal(t, "invalid_grant", oauthErr.Code)
+		codeRepo.AssertNotCalled(t, "MarkAsUsed", mock.Anything, mock.Anything)
+	})
+
+	t.Run("wrong client secret", func(t *testing.T) {
+		_, err := svc.Exchange(ctx, &TokenRequest{
+			GrantType: OAuthGrantAuthorizationCode, Code: code, RedirectURI: testRedirectURI,
+			CodeVerifier: testVerifier, ClientID: "grafana", ClientSecret: "guess",
+		})
+		var oauthErr *OAuthError
+		require.ErrorAs(t, err, &oauthErr)
+		assert.Equal(t, "invalid_client", oauthErr.Code)
+	})
+
+	codeRepo.On("MarkAsUsed", ctx, stored.ID).Return(true, nil).Once()
+	resp, err := svc.Exchange(ctx, &TokenRequest{
+		GrantType: OAuthGrantAuthorizationCode, Code: code, RedirectURI: testRedirectURI,
+		CodeVerifier: testVerifier, ClientID: "grafana", ClientSecret: "s3cret",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer", resp.TokenType)
+	assert.Equal(t, "openid email", resp.Scope)
+
+	// The access token is only accepted for the issuer's own audience
+	_, err = jwtManager.Validate(ctx, resp.AccessToken)
+	assert.Error(t, err)
+	claims, err := jwtManager.ValidateForAudience(ctx, resp.AccessToken, testIssuer)
+	require.NoError(t, err)
+	assert.Equal(t, "grafana", claims.ClientID)
+	assert.True(t, claims.HasScope(OAuthScopeOpenID))
+
+	var idClaims token.IDTokenClaims
+	_, err = jwt.ParseWithClaims(resp.IDToken, &idClaims, func(*jwt.Token) (interface{}, error) {
+		return jwtManager.GetPublicKey(), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, testIssuer, idClaims.Issuer)
+	assert.Equal(t, user.ID.String(), idClaims.Subject)
+	assert.Equal(t, jwt.ClaimStrings{"grafana"}, idClaims.Audience)
+	assert.Equal(t, "n-0S6_WzA2Mj", idClaims.Nonce)
+	assert.Equal(t, user.Email, idClaims.Email)
+	assert.Empty(t, idClaims.Name, "profile scope was not granted")
+
+	info, err := svc.UserInfo(ctx, claims)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"sub": user.ID.String(), "email": user.Email, "email_verified": true}, info)
+
+	// Replayed code
+	codeRepo.On("MarkAsUsed", ctx, stored.ID).Return(false, nil)
+	_, err = svc.Exchange(ctx, &TokenRequest{
+		GrantType: OAuthGrantAuthorizationCode, Code: code, RedirectURI: testRedirectURI,
+		CodeVerifier: testVerifier, ClientID: "grafana", ClientSecret: "s3cret",
+	})
+	var oauthErr *OAuthError
+	require.ErrorAs(t, err, &oauthErr)
+	assert.Equal(t, "invalid_grant", oauthErr.Code)
+}
+
+func TestOAuthService_ExchangeExpiredCode(t *testing.T) {
+	clientRepo := new(MockOAuthClientRepo)
+	codeRepo := new(MockOAuthCodeRepo)
+	svc := NewOAuthService(clientRepo, codeRepo, nil, nil, nil, nil, testIssuer, 900)
+	ctx := context.Background()
+
+	clientRepo.On("GetByClientID", ctx, "grafana").Return(testOAuthClient(""), nil)
+	codeRepo.On("GetByCodeHash", ctx, hashToken("code")).Return(&model.OAuthAuthorizationCode{
+		ID:            uuid.New(),
+		ClientID:      "grafana",
+		RedirectURI:   testRedirectURI,
+		CodeChallenge: pkceChallenge(testVerifier),
+		ExpiresAt:     time.Now().Add(-time.Second),
+	}, nil)
+
+	_, err := svc.Exchange(ctx, &TokenRequest{
+		GrantType: OAuthGrantAuthorizationCode, Code: "code", RedirectURI: testRedirectURI,
+		CodeVerifier: testVerifier, ClientID: "grafana",
+	})
+	var oauthErr *OAuthError
+	require.ErrorAs(t, err, &oauthErr)
+	assert.Equal(t, "invalid_grant", oauthErr.Code)
+}
+
+func TestOAuthService_ExchangeCodeAfterLeavingOrganization(t *testing.T) {
+	clientRepo := new(MockOAuthClientRepo)
+	codeRepo := new(MockOAuthCodeRepo)
+	userRepo := new(MockUserRepo)
+	membershipRepo := new(MockMembershipRepo)
+	svc := NewOAuthService(clientRepo, codeRepo, userRepo, membershipRepo, newTestJWTManager(t), nil, testIssuer, 900)
+	ctx := context.Background()
+
+	user := &model.User{ID: uuid.New(), Email: "user@zeno.test", IsActive: true}
+	orgID := uuid.New()
+	authCode := &model.OAuthAuthorizationCode{
+		ID:            uuid.New(),
+		ClientID:      "grafana",
+		UserID:        user.ID,
+		OrgID:         &orgID,
+		RedirectURI:   testRedirectURI,
+		Scope:         "openid",
+		CodeChallenge: pkceChallenge(testVerifier),
+		ExpiresAt:     time.Now().Add(time.Minute),
+	}
+	clientRepo.On("GetByClientID", ctx, "grafana").Return(testOAuthClient(""), nil)
+	codeRepo.On("GetByCodeHash", ctx, hashToken("code")).Return(authCode, nil)
+	codeRepo.On("MarkAsUsed", ctx, authCode.ID).Return(true, nil)
+	userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	membershipRepo.On("GetByUserAndOrg", ctx, user.ID, orgID).Return(&model.OrgMembership{UserID: user.ID, OrgID: orgID, Role: model.RoleMember, IsActive: false}, nil)
+
+	_, err := svc.Exchange(ctx, &TokenRequest{
+		GrantType: OAuthGrantAuthorizationCode, Code: "code", RedirectURI: testRedirectURI,
+		CodeVerifier: testVerifier, ClientID: "grafana",
+	})
+	var oauthErr *OAuthError
+	require.ErrorAs(t, err, &oauthErr)
+	assert.Equal(t, "invalid_grant", oauthErr.Code)
+	membershipRepo.AssertExpectations(t)
+}
+
+func TestOAuthService_ClientCredentials(t *testing.T) {
+	clientRepo := new(MockOAuthClientRepo)
+	jwtManager := newTestJWTManager(t)
+	svc := NewOAuthService(clientRepo, nil, nil, nil, jwtManager, nil, testIssuer, 900)
+	ctx := context.Background()
+
+	secretHash := hashToken("billing-secret")
+	clientRepo.On("GetByClientID", ctx, "billing").Return(&model.OAuthClient{
+		ClientID:         "billing",
+		ClientSecretHash: &secretHash,
+		Scopes:           []string{"organizations:write", "organizations:read"},
+		GrantTypes:       []string{OAuthGrantClientCredentials},
+		IsActive:         true,
+	}, nil)
+	clientRepo.On("GetByClientID", ctx, "grafana").Return(testOAuthClient("s3cret"), nil)
+
+	resp, err := svc.Exchange(ctx, &TokenRequest{
+		GrantType: OAuthGrantClientCredentials, ClientID: "billing", ClientSecret: "billing-secret", Scope: "organizations:write",
+	})
+	require.NoError(t, err)
+	assert.Empty(t, resp.IDToken)
+	assert.Equal(t, "organizations:write", resp.Scope)
+
+	claims, err := jwtManager.ValidateForAudience(ctx, resp.AccessToken, token.InternalAudience)
+	require.NoError(t, err)
+	assert.Equal(t, "billing", claims.ClientID)
+	assert.Equal(t, "billing", claims.Subject)
+	assert.Equal(t, uuid.Nil, claims.UserID)
+	assert.True(t, claims.HasScope("organizations:write"))
+	assert.False(t, claims.HasScope("organizations:read"))
+
+	// Machine tokens are rejected by the first-party API and UserInfo
+	_, err = jwtManager.Validate(ctx, resp.AccessToken)
+	assert.Error(t, err)
+	_, err = svc.UserInfo(ctx, claims)
+	assert.Error(t, err)
+
+	t.Run("all client scopes by default", func(t *testing.T) {
+		resp, err := svc.Exchange(ctx, &TokenRequest{
+			GrantType: OAuthGrantClientCredentials, ClientID: "billing", ClientSecret: "billing-secret",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "organizations:write organizations:read", resp.Scope)
+	})
+
+	tests := []struct {
+		name string
+		req  *TokenRequest
+		code string
+	}{
+		{"wrong secret", &TokenRequest{GrantType: OAuthGrantClientCredentials, ClientID: "billing", ClientSecret: "guess"}, "invalid_client"},
+		{"scope not allowed", &TokenRequest{GrantType: OAuthGrantClientCredentials, ClientID: "billing", ClientSecret: "billing-secret", Scope: "users:write"}, "invalid_scope"},
+		{"grant not allowed", &TokenRequest{GrantType: OAuthGrantClientCredentials, ClientID: "grafana", ClientSecret: "s3cret"}, "unauthorized_client"},
+		{"machine client with authorization code", &TokenRequest{GrantType: OAuthGrantAuthorizationCode, ClientID: "billing", ClientSecret: "billing-secret", Code: "code", CodeVerifier: testVerifier}, "unauthorized_client"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Exchange(ctx, tt.req)
+			var oauthErr *OAuthError
+			require.ErrorAs(t, err, &oauthErr)
+			assert.Equal(t, tt.code, oauthErr.Code)
+		})
+	}
+}
@@ -0,0 +1,378 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	stdErrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/token"
+)
+
+const (
+	passkeyChallengeTTL = 5 * time.Minute
+	passkeyDefaultName  = "Passkey"
+)
+
+type WebAuthnCredentialRepository interface {
+	Create(ctx context.Context, cred *model.WebAuthnCredential) error
+	GetByCredentialID(ctx context.Context, credentialID []byte) (*model.WebAuthnCredential, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*model.WebAuthnCredential, error)
+	UpdateSignCount(ctx context.Context, id uuid.UUID, signCount int64) (bool, error)
+	Delete(ctx context.Context, id, userID uuid.UUID) (bool, error)
+}
+
+type WebAuthnChallengeRepository interface {
+	Create(ctx context.Context, challenge *model.WebAuthnChallenge) error
+	GetByChallengeHash(ctx context.Context, challengeHash string) (*model.WebAuthnChallenge, error)
+	MarkAsUsed(ctx context.Context, id uuid.UUID) (bool, error)
+	DeleteExpired(ctx context.Context) error
+}
+
+// PasskeyCreationOptions mirrors PublicKeyCredentialCreationOptions with
+// binary fields base64url-encoded, ready for PublicKeyCredential.parseCreationOptionsFromJSON.
+type PasskeyCreationOptions struct {
+	Challenge              string                     `json:"challenge"`
+	RP                     PasskeyRelyingParty        `json:"rp"`
+	User                   PasskeyUser                `json:"user"`
+	PubKeyCredParams       []PasskeyCredentialParam   `json:"pubKeyCredParams"`
+	Timeout                int64                      `json:"timeout"`
+	Attestation            string                     `json:"attestation"`
+	AuthenticatorSelection PasskeyAuthenticatorPolicy `json:"authenticatorSelection"`
+	ExcludeCredentials     []PasskeyDescriptor        `json:"excludeCredentials"`
+}
+
+// PasskeyRequestOptions mirrors PublicKeyCredentialRequestOptions. No
+// credentials are listed: passkeys are discoverable, so the login does not
+// reveal whether an account exists.
+type PasskeyRequestOptions struct {
+	Challenge        string `json:"challenge"`
+	RPID             string `json:"rpId"`
+	Timeout          int64  `json:"timeout"`
+	UserVerification string `json:"userVerification"`
+}
+
+type PasskeyRelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type PasskeyUser struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type PasskeyCredentialParam struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type PasskeyAuthenticatorPolicy struct {
+	ResidentKey        string `json:"residentKey"`
+	RequireResidentKey bool   `json:"requireResidentKey"`
+	UserVerification   string `json:"userVerification"`
+}
+
+type PasskeyDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+// PasskeyRegistration is a decoded navigator.credentials.create() response.
+type PasskeyRegistration struct {
+	Name              string
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AttestationObject []byte
+	Transports        []string
+}
+
+// PasskeyAssertion is a decoded navigator.credentials.get() response.
+type PasskeyAssertion struct {
+	CredentialID      []byte
+	ClientDataJSON    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+	UserHandle        []byte
+}
+
+type PasskeyService struct {
+	credentialRepo WebAuthnCredentialRepository
+	challengeRepo  WebAuthnChallengeRepository
+	userRepo       UserRepository
+	verifier       *token.WebAuthnVerifier
+	rpName         string
+	auditService   *AuditService
+}
+
+func NewPasskeyService(
+	credentialRepo WebAuthnCredentialRepository,
+	challengeRepo WebAuthnChallengeRepository,
+	userRepo UserRepository,
+	verifier *token.WebAuthnVerifier,
+	rpName string,
+	auditService *AuditService,
+) *PasskeyService {
+	return &PasskeyService{
+		credentialRepo: credentialRepo,
+		challengeRepo:  challengeRepo,
+		userRepo:       userRepo,
+		verifier:       verifier,
+		rpName:         rpName,
+		auditService:   auditService,
+	}
+}
+
+// BeginRegistration starts a passkey registration for an authenticated user.
+func (s *PasskeyService) BeginRegistration(ctx context.Context, userID uuid.UUID) (*PasskeyCreationOptions, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	existing, err := s.credentialRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get passkeys: %w", err)
+	}
+
+	challenge, err := s.createChallenge(ctx, &userID, model.WebAuthnCeremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+
+	exclude := make([]PasskeyDescriptor, 0, len(existing))
+	for _, cred := range existing {
+		exclude = append(exclude, PasskeyDescriptor{
+			Type:       "public-key",
+			ID:         token.EncodeWebAuthnBase64(cred.CredentialID),
+			Transports: cred.Transports,
+		})
+	}
+
+	displayName := user.FullName
+	if displayName == "" {
+		displayName = user.Email
+	}
+
+	return &PasskeyCreationOptions{
+		Challenge: challenge,
+		RP:        PasskeyRelyingParty{ID: s.verifier.RPID(), Name: s.rpName},
+		User: PasskeyUser{
+			ID:          token.EncodeWebAuthnBase64(userID[:]),
+			Name:        user.Email,
+			DisplayName: displayName,
+		},
+		PubKeyCredParams: []PasskeyCredentialParam{
+			{Type: "public-key", Alg: token.COSEAlgES256},
+			{Type: "public-key", Alg: token.COSEAlgEdDSA},
+			{Type: "public-key", Alg: token.COSEAlgRS256},
+		},
+		Timeout:     passkeyChallengeTTL.Milliseconds(),
+		Attestation: "none",
+		AuthenticatorSelection: PasskeyAuthenticatorPolicy{
+			ResidentKey:        "required",
+			RequireResidentKey: true,
+			UserVerification:   "required",
+		},
+		ExcludeCredentials: exclude,
+	}, nil
+}
+
+// FinishRegistration verifies the authenticator response and stores the new
+// passkey.
+func (s *PasskeyService) FinishRegistration(ctx context.Context, userID uuid.UUID, reg *PasskeyRegistration, ipAddress, userAgent string) (*model.WebAuthnCredential, error) {
+	clientData, err := s.verifier.ParseClientData(reg.ClientDataJSON)
+	if err != nil {
+		return nil, errors.ErrInvalidPasskey
+	}
+
+	challenge, err := s.consumeChallenge(ctx, clientData.Challenge, model.WebAuthnCeremonyRegistration)
+	if err != nil || challenge.UserID == nil || *challenge.UserID != userID {
+		return nil, errors.ErrInvalidPasskey
+	}
+
+	verified, err := s.verifier.VerifyRegistration(clientData.Challenge, reg.ClientDataJSON, reg.AttestationObject)
+	if err != nil {
+		log.Warn().Err(err).Str("user_id", userID.String()).Msg("Passkey registration rejected")
+		return nil, errors.ErrInvalidPasskey
+	}
+	if len(reg.CredentialID) > 0 && !bytes.Equal(reg.CredentialID, verified.ID) {
+		return nil, errors.ErrInvalidPasskey
+	}
+
+	if _, err := s.credentialRepo.GetByCredentialID(ctx, verified.ID); err == nil {
+		return nil, errors.ErrInvalidPasskey
+	} else if !stdErrors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to check passkey: %w", err)
+	}
+
+	name := reg.Name
+	if name == "" {
+		name = passkeyDefaultName
+	}
+
+	cred := &model.WebAuthnCredential{
+		UserID:       userID,
+		CredentialID: verified.ID,
+		PublicKey:    verified.PublicKey,
+		Algorithm:    verified.Algorithm,
+		SignCount:    int64(verified.SignCount),
+		AAGUID:       verified.AAGUID,
+		Transports:   reg.Transports,
+		Name:         name,
+	}
+	if err := s.credentialRepo.Create(ctx, cred); err != nil {
+		return nil, fmt.Errorf("failed to store passkey: %w", err)
+	}
+
+	s.audit(ctx, &userID, model.EventPasskeyRegistered, map[string]interface{}{
+		"passkey_id": cred.ID.String(),
+		"name":       cred.Name,
+	}, ipAddress, userAgent)
+
+	return cred, nil
+}
+
+func (s *PasskeyService) List(ctx context.Context, userID uuid.UUID) ([]*model.WebAuthnCredential, error) {
+	return s.credentialRepo.GetByUserID(ctx, userID)
+}
+
+func (s *PasskeyService) Delete(ctx context.Context, userID, passkeyID uuid.UUID, ipAddress, userAgent string) error {
+	deleted, err := s.credentialRepo.Delete(ctx, passkeyID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete passkey: %w", err)
+	}
+	if !deleted {
+		return errors.ErrPasskeyNotFound
+	}
+
+	s.audit(ctx, &userID, model.EventPasskeyRemoved, map[string]interface{}{
+		"passkey_id": passkeyID.String(),
+	}, ipAddress, userAgent)
+
+	return nil
+}
+
+// BeginLogin starts a passwordless login ceremony.
+func (s *PasskeyService) BeginLogin(ctx context.Context) (*PasskeyRequestOptions, error) {
+	challenge, err := s.createChallenge(ctx, nil, model.WebAuthnCeremonyLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PasskeyRequestOptions{
+		Challenge:        challenge,
+		RPID:             s.verifier.RPID(),
+		Timeout:          passkeyChallengeTTL.Milliseconds(),
+		UserVerification: "required",
+	}, nil
+}
+
+// FinishLogin verifies an assertion and returns the user it belongs to. All
+// verification failures are reported as invalid credentials.
+func (s *PasskeyService) FinishLogin(ctx context.Context, assertion *PasskeyAssertion) (uuid.UUID, error) {
+	clientData, err := s.verifier.ParseClientData(assertion.ClientDataJSON)
+	if err != nil {
+		return uuid.Nil, errors.ErrInvalidCredentials
+	}
+
+	if _, err := s.consumeChallenge(ctx, clientData.Challenge, model.WebAuthnCeremonyLogin); err != nil {
+		return uuid.Nil, errors.ErrInvalidCredentials
+	}
+
+	cred, err := s.credentialRepo.GetByCredentialID(ctx, assertion.CredentialID)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, errors.ErrInvalidCredentials
+		}
+		return uuid.Nil, fmt.Errorf("failed to get passkey: %w", err)
+	}
+
+	if len(assertion.UserHandle) > 0 && !bytes.Equal(assertion.UserHandle, cred.UserID[:]) {
+		return uuid.Nil, errors.ErrInvalidCredentials
+	}
+
+	signCount, err := s.verifier.VerifyAssertion(clientData.Challenge, assertion.ClientDataJSON, assertion.AuthenticatorData, assertion.Signature, cred.PublicKey)
+	if err != nil {
+		log.Warn().Err(err).Str("passkey_id", cred.ID.String()).Msg("Passkey assertion rejected")
+		return uuid.Nil, errors.ErrInvalidCredentials
+	}
+
+	// A counter that does not increase indicates a cloned authenticator.
+	// Authenticators that do not implement counters always report zero.
+	if (signCount != 0 || cred.SignCount != 0) && int64(signCount) <= cred.SignCount {
+		log.Warn().Str("passkey_id", cred.ID.String()).Str("user_id", cred.UserID.String()).Msg("Passkey sign counter did not increase, possible cloned authenticator")
+		return uuid.Nil, errors.ErrInvalidCredentials
+	}
+
+	updated, err := s.credentialRepo.UpdateSignCount(ctx, cred.ID, int64(signCount))
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to update passkey: %w", err)
+	}
+	if !updated {
+		return uuid.Nil, errors.ErrInvalidCredentials
+	}
+
+	return cred.UserID, nil
+}
+
+func (s *PasskeyService) createChallenge(ctx context.Context, userID *uuid.UUID, ceremony model.WebAuthnCeremony) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate challenge: %w", err)
+	}
+	challenge := token.EncodeWebAuthnBase64(raw)
+
+	record := &model.WebAuthnChallenge{
+		UserID:        userID,
+		ChallengeHash: hashToken(challenge),
+		Ceremony:      ceremony,
+		ExpiresAt:     time.Now().Add(passkeyChallengeTTL),
+	}
+	if err := s.challengeRepo.Create(ctx, record); err != nil {
+		return "", fmt.Errorf("failed to create challenge: %w", err)
+	}
+
+	return challenge, nil
+}
+
+// consumeChallenge looks up and invalidates the challenge echoed back in the
+// client data, so each ceremony can be completed at most once.
+func (s *PasskeyService) consumeChallenge(ctx context.Context, challenge string, ceremony model.WebAuthnCeremony) (*model.WebAuthnChallenge, error) {
+	record, err := s.challengeRepo.GetByChallengeHash(ctx, hashToken(challenge))
+	if err != nil {
+		return nil, errors.ErrInvalidToken
+	}
+	if record.Ceremony != ceremony || record.UsedAt != nil || time.Now().After(record.ExpiresAt) {
+		return nil, errors.ErrInvalidToken
+	}
+
+	used, err := s.challengeRepo.MarkAsUsed(ctx, record.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume challenge: %w", err)
+	}
+	if !used {
+		return nil, errors.ErrInvalidToken
+	}
+
+	return record, nil
+}
+
+func (s *PasskeyService) audit(ctx context.Context, userID *uuid.UUID, eventType model.AuditEventType, data map[string]interface{}, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	if err := s.auditService.Log(ctx, userID, eventType, data, ipAddress, userAgent); err != nil {
+		log.Error().Err(err).Str("event", string(eventType)).Msg("Failed to log passkey audit event")
+	}
+}
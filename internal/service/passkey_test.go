@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	appErrors "github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/token"
+)
+
+type MockWebAuthnChallengeRepo struct {
+	mock.Mock
+}
+
+func (m *MockWebAuthnChallengeRepo) Create(ctx context.Context, challenge *model.WebAuthnChallenge) error {
+	args := m.Called(ctx, challenge)
+	return args.Error(0)
+}
+
+func (m *MockWebAuthnChallengeRepo) GetByChallengeHash(ctx context.Context, challengeHash string) (*model.WebAuthnChallenge, error) {
+	args := m.Called(ctx, challengeHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.WebAuthnChallenge), args.Error(1)
+}
+
+func (m *MockWebAuthnChallengeRepo) MarkAsUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockWebAuthnChallengeRepo) DeleteExpired(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+func clientDataFor(typ, challenge string) []byte {
+	return []byte(`{"type":"` + typ + `","challenge":"` + challenge + `","origin":"https://app.zeno.test"}`)
+}
+
+func TestPasskeyService_BeginLogin(t *testing.T) {
+	challengeRepo := new(MockWebAuthnChallengeRepo)
+	svc := NewPasskeyService(nil, challengeRepo, nil, token.NewWebAuthnVerifier("app.zeno.test", []string{"https://app.zeno.test"}), "ZenoN Cloud", nil)
+	ctx := context.Background()
+
+	challengeRepo.On("Create", ctx, mock.MatchedBy(func(c *model.WebAuthnChallenge) bool {
+		return c.UserID == nil && c.Ceremony == model.WebAuthnCeremonyLogin && c.ChallengeHash != ""
+	})).Return(nil)
+
+	options, err := svc.BeginLogin(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "app.zeno.test", options.RPID)
+	assert.Equal(t, "required", options.UserVerification)
+	assert.NotEmpty(t, options.Challenge)
+	challengeRepo.AssertExpectations(t)
+}
+
+func TestPasskeyService_FinishRegistration_ChallengeOfAnotherUser(t *testing.T) {
+	challengeRepo := new(MockWebAuthnChallengeRepo)
+	svc := NewPasskeyService(nil, challengeRepo, nil, token.NewWebAuthnVerifier("app.zeno.test", []string{"https://app.zeno.test"}), "ZenoN Cloud", nil)
+	ctx := context.Background()
+
+	owner := uuid.New()
+	record := &model.WebAuthnChallenge{
+		ID:        uuid.New(),
+		UserID:    &owner,
+		Ceremony:  model.WebAuthnCeremonyRegistration,
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+	challengeRepo.On("GetByChallengeHash", ctx, hashToken("abc")).Return(record, nil)
+	challengeRepo.On("MarkAsUsed", ctx, record.ID).Return(true, nil)
+
+	_, err := svc.FinishRegistration(ctx, uuid.New(), &PasskeyRegistration{
+		ClientDataJSON: clientDataFor("webauthn.create", "abc"),
+	}, "", "")
+	assert.ErrorIs(t, err, appErrors.ErrInvalidPasskey)
+}
+
+func TestPasskeyService_FinishLogin_RejectsUsedOrWrongCeremony(t *testing.T) {
+	challengeRepo := new(MockWebAuthnChallengeRepo)
+	svc := NewPasskeyService(nil, challengeRepo, nil, token.NewWebAuthnVerifier("app.zeno.test", []string{"https://app.zeno.test"}), "ZenoN Cloud", nil)
+	ctx := context.Background()
+
+	used := time.Now()
+	challengeRepo.On("GetByChallengeHash", ctx, hashToken("used")).Return(&model.WebAuthnChallenge{
+		ID: uuid.New(), Ceremony: model.WebAuthnCeremonyLogin, ExpiresAt: time.Now().Add(time.Minute), UsedAt: &used,
+	}, nil)
+	challengeRepo.On("GetByChallengeHash", ctx, hashToken("registration")).Return(&model.WebAuthnChallenge{
+		ID: uuid.New(), Ceremony: model.WebAuthnCeremonyRegistration, ExpiresAt: time.Now().Add(time.Minute),
+	}, nil)
+
+	for _, challenge := range []string{"used", "registration"} {
+		_, err := svc.FinishLogin(ctx, &PasskeyAssertion{ClientDataJSON: clientDataFor("webauthn.get", challenge)})
+		assert.ErrorIs(t, err, appErrors.ErrInvalidCredentials, challenge)
+	}
+	challengeRepo.AssertNotCalled(t, "MarkAsUsed", mock.Anything, mock.Anything)
+}
@@ -18,6 +18,7 @@ type PasswordService struct {
 	passwordManager *token.PasswordManager
 	auditService    *AuditService
 	emailService    *EmailService
+	tokenRevoker    TokenRevoker
 	db              *postgres.DB
 }
 
@@ -27,6 +28,7 @@ func NewPasswordService(
 	passwordManager *token.PasswordManager,
 	auditService *AuditService,
 	emailService *EmailService,
+	tokenRevoker TokenRevoker,
 	db *postgres.DB,
 ) *PasswordService {
 	return &PasswordService{
@@ -35,6 +37,7 @@ func NewPasswordService(
 		passwordManager: passwordManager,
 		auditService:    auditService,
 		emailService:    emailService,
+		tokenRevoker:    tokenRevoker,
 		db:              db,
 	}
 }
@@ -91,6 +94,8 @@ func (s *PasswordService) ChangePassword(
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
+	revokeAccessTokens(ctx, s.tokenRevoker, userID)
+
 	// Audit log (outside transaction)
 	if s.auditService != nil {
 		_ = s.auditService.Log(ctx, &userID, "password_changed", nil, ipAddress, userAgent)
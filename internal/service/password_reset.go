@@ -31,6 +31,7 @@ type PasswordResetService struct {
 	refreshRepo     RefreshTokenRepository
 	passwordManager *token.PasswordManager
 	auditService    *AuditService
+	tokenRevoker    TokenRevoker
 	emailSender     EmailSender
 }
 
@@ -40,6 +41,7 @@ func NewPasswordResetService(
 	refreshRepo RefreshTokenRepository,
 	passwordManager *token.PasswordManager,
 	auditService *AuditService,
+	tokenRevoker TokenRevoker,
 	frontendBaseURL string,
 ) *PasswordResetService {
 	return &PasswordResetService{
@@ -48,6 +50,7 @@ func NewPasswordResetService(
 		refreshRepo:     refreshRepo,
 		passwordManager: passwordManager,
 		auditService:    auditService,
+		tokenRevoker:    tokenRevoker,
 		emailSender:     NewSendGridEmailSender(frontendBaseURL),
 	}
 }
@@ -143,6 +146,8 @@ func (s *PasswordResetService) ResetPassword(ctx context.Context, resetToken, ne
 		return fmt.Errorf("failed to reset password: %w", err)
 	}
 
+	revokeAccessTokens(ctx, s.tokenRevoker, user.ID)
+
 	// Audit log
 	if s.auditService != nil {
 		if err := s.auditService.Log(ctx, &user.ID, "password_reset_completed", nil, ipAddress, userAgent); err != nil {
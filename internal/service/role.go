@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+const maxRoleNameLength = 100
+
+type OrgRoleRepository interface {
+	Create(ctx context.Context, role *model.OrgRole) error
+	GetByID(ctx context.Context, orgID, id uuid.UUID) (*model.OrgRole, error)
+	ListByOrgID(ctx context.Context, orgID uuid.UUID) ([]*model.OrgRole, error)
+	Update(ctx context.Context, role *model.OrgRole) error
+	Delete(ctx context.Context, orgID, id uuid.UUID) (bool, error)
+}
+
+type RoleMembershipRepository interface {
+	GetByUserAndOrg(ctx context.Context, userID, orgID uuid.UUID) (*model.OrgMembership, error)
+	Update(ctx context.Context, membership *model.OrgMembership) error
+}
+
+// RoleInput describes a custom role to create or the new state of one.
+type RoleInput struct {
+	Name        string
+	Description string
+	Permissions []model.Permission
+}
+
+// RoleService manages the custom roles of an organization. Custom roles are
+// assigned to members and viewers on top of their built-in role; they cannot
+// grant the administrative permissions that come with the owner and admin
+// roles, nor any permission the acting user does not hold themselves.
+type RoleService struct {
+	roleRepo       OrgRoleRepository
+	membershipRepo RoleMembershipRepository
+	auditService   *AuditService
+}
+
+func NewRoleService(roleRepo OrgRoleRepository, membershipRepo RoleMembershipRepository, auditService *AuditService) *RoleService {
+	return &RoleService{
+		roleRepo:       roleRepo,
+		membershipRepo: membershipRepo,
+		auditService:   auditService,
+	}
+}
+
+// ListRoles returns the custom roles of the organization. Any active member
+// can list them.
+func (s *RoleService) ListRoles(ctx context.Context, userID, orgID uuid.UUID) ([]*model.OrgRole, error) {
+	if _, err := s.requirePermission(ctx, userID, orgID, model.PermOrgRead); err != nil {
+		return nil, err
+	}
+
+	roles, err := s.roleRepo.ListByOrgID(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roles: %w", err)
+	}
+	return roles, nil
+}
+
+func (s *RoleService) CreateRole(ctx context.Context, userID, orgID uuid.UUID, input RoleInput, ipAddress, userAgent string) (*model.OrgRole, error) {
+	actor, err := s.requirePermission(ctx, userID, orgID, model.PermRolesManage)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &model.OrgRole{OrgID: orgID}
+	if err := s.apply(ctx, actor, role, input); err != nil {
+		return nil, err
+	}
+	if err := s.roleRepo.Create(ctx, role); err != nil {
+		return nil, fmt.Errorf("failed to create role: %w", err)
+	}
+
+	s.audit(ctx, &userID, model.EventRoleCreated, map[string]interface{}{
+		"org_id":      orgID.String(),
+		"role_id":     role.ID.String(),
+		"name":        role.Name,
+		"permissions": role.Permissions,
+	}, ipAddress, userAgent)
+	return role, nil
+}
+
+// UpdateRole replaces the name, description and permissions of the role.
+// Members holding it get the new permissions with their next token.
+func (s *RoleService) UpdateRole(ctx context.Context, userID, orgID, roleID uuid.UUID, input RoleInput, ipAddress, userAgent string) (*model.OrgRole, error) {
+	actor, err := s.requirePermission(ctx, userID, orgID, model.PermRolesManage)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := s.role(ctx, orgID, roleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(ctx, actor, role, input); err != nil {
+		return nil, err
+	}
+	if err := s.roleRepo.Update(ctx, role); err != nil {
+		return nil, fmt.Errorf("failed to update role: %w", err)
+	}
+
+	s.audit(ctx, &userID, model.EventRoleUpdated, map[string]interface{}{
+		"org_id":      orgID.String(),
+		"role_id":     role.ID.String(),
+		"name":        role.Name,
+		"permissions": role.Permissions,
+	}, ipAddress, userAgent)
+	return role, nil
+}
+
+// DeleteRole removes the role; the members it was assigned to keep their
+// built-in role.
+func (s *RoleService) DeleteRole(ctx context.Context, userID, orgID, roleID uuid.UUID, ipAddress, userAgent string) error {
+	if _, err := s.requirePermission(ctx, userID, orgID, model.PermRolesManage); err != nil {
+		return err
+	}
+
+	deleted, err := s.roleRepo.Delete(ctx, orgID, roleID)
+	if err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+	if !deleted {
+		return errors.ErrRoleNotFound
+	}
+
+	s.audit(ctx, &userID, model.EventRoleDeleted, map[string]interface{}{
+		"org_id":  orgID.String(),
+		"role_id": roleID.String(),
+	}, ipAddress, userAgent)
+	return nil
+}
+
+// AssignRole gives an active member or viewer the custom role, replacing the
+// one they had. A nil roleID removes the custom role. The acting user must
+// hold every permission of the role.
+func (s *RoleService) AssignRole(ctx context.Context, userID, orgID, memberID uuid.UUID, roleID *uuid.UUID, ipAddress, userAgent string) (*model.OrgMembership, error) {
+	actor, err := s.requirePermission(ctx, userID, orgID, model.PermRolesManage)
+	if err != nil {
+		return nil, err
+	}
+
+	membership, err := s.membershipRepo.GetByUserAndOrg(ctx, memberID, orgID)
+	if err != nil && !stdErrors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get membership: %w", err)
+	}
+	if err != nil || membership == nil || !membership.IsActive {
+		return nil, errors.ErrMemberNotFound
+	}
+	// Owners and admins already hold every permission a custom role grants
+	if membership.Role != model.RoleMember && membership.Role != model.RoleViewer {
+		return nil, errors.ErrInvalidInput
+	}
+
+	if roleID != nil {
+		role, err := s.role(ctx, orgID, *roleID)
+		if err != nil {
+			return nil, err
+		}
+		if err := requireHeld(actor, role.Permissions); err != nil {
+			return nil, err
+		}
+	}
+	membership.CustomRoleID = roleID
+	if err := s.membershipRepo.Update(ctx, membership); err != nil {
+		return nil, fmt.Errorf("failed to update member: %w", err)
+	}
+
+	data := map[string]interface{}{
+		"org_id":    orgID.String(),
+		"member_id": memberID.String(),
+	}
+	if roleID != nil {
+		data["role_id"] = roleID.String()
+	}
+	s.audit(ctx, &userID, model.EventRoleAssigned, data, ipAddress, userAgent)
+	return membership, nil
+}
+
+// apply validates the input and copies it to the role. The actor can only
+// grant permissions they hold.
+func (s *RoleService) apply(ctx context.Context, actor *model.OrgMembership, role *model.OrgRole, input RoleInput) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > maxRoleNameLength {
+		return errors.ErrInvalidInput
+	}
+	// Built-in role names would be ambiguous in the API and audit log
+	for _, builtIn := range []model.Role{model.RoleOwner, model.RoleAdmin, model.RoleMember, model.RoleViewer} {
+		if strings.EqualFold(name, string(builtIn)) {
+			return errors.ErrRoleNameTaken
+		}
+	}
+
+	permissions := make([]model.Permission, 0, len(input.Permissions))
+	for _, permission := range input.Permissions {
+		if !permission.Grantable() {
+			return errors.ErrInvalidPermission
+		}
+		permissions = append(permissions, permission)
+	}
+	if err := requireHeld(actor, permissions); err != nil {
+		return err
+	}
+	slices.Sort(permissions)
+
+	existing, err := s.roleRepo.ListByOrgID(ctx, role.OrgID)
+	if err != nil {
+		return fmt.Errorf("failed to get roles: %w", err)
+	}
+	for _, other := range existing {
+		if other.ID != role.ID && strings.EqualFold(other.Name, name) {
+			return errors.ErrRoleNameTaken
+		}
+	}
+
+	role.Name = name
+	role.Description = strings.TrimSpace(input.Description)
+	role.Permissions = slices.Compact(permissions)
+	return nil
+}
+
+// requireHeld keeps the actor from handing out permissions their own role
+// lacks, e.g. an admin granting billing:manage to an account they control.
+func requireHeld(actor *model.OrgMembership, permissions []model.Permission) error {
+	for _, permission := range permissions {
+		if !actor.HasPermission(permission) {
+			return errors.ErrInsufficientRole
+		}
+	}
+	return nil
+}
+
+func (s *RoleService) role(ctx context.Context, orgID, roleID uuid.UUID) (*model.OrgRole, error) {
+	role, err := s.roleRepo.GetByID(ctx, orgID, roleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+	if role == nil {
+		return nil, errors.ErrRoleNotFound
+	}
+	return role, nil
+}
+
+func (s *RoleService) requirePermission(ctx context.Context, userID, orgID uuid.UUID, permission model.Permission) (*model.OrgMembership, error) {
+	membership, err := s.membershipRepo.GetByUserAndOrg(ctx, userID, orgID)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.ErrForbidden
+		}
+		return nil, fmt.Errorf("failed to get membership: %w", err)
+	}
+	if membership == nil || !membership.IsActive {
+		return nil, errors.ErrForbidden
+	}
+	if !membership.HasPermission(permission) {
+		return nil, errors.ErrInsufficientRole
+	}
+	return membership, nil
+}
+
+func (s *RoleService) audit(ctx context.Context, userID *uuid.UUID, eventType model.AuditEventType, data map[string]interface{}, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	if err := s.auditService.Log(ctx, userID, eventType, data, ipAddress, userAgent); err != nil {
+		log.Error().Err(err).Str("event", string(eventType)).Msg("Failed to log role audit event")
+	}
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+type memoryOrgRoleRepo struct {
+	roles []*model.OrgRole
+}
+
+func (r *memoryOrgRoleRepo) Create(_ context.Context, role *model.OrgRole) error {
+	role.ID = uuid.New()
+	role.CreatedAt = time.Now()
+	role.UpdatedAt = role.CreatedAt
+	copied := *role
+	r.roles = append(r.roles, &copied)
+	return nil
+}
+
+func (r *memoryOrgRoleRepo) GetByID(_ context.Context, orgID, id uuid.UUID) (*model.OrgRole, error) {
+	for _, role := range r.roles {
+		if role.ID == id && role.OrgID == orgID {
+			copied := *role
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryOrgRoleRepo) ListByOrgID(_ context.Context, orgID uuid.UUID) ([]*model.OrgRole, error) {
+	var roles []*model.OrgRole
+	for _, role := range r.roles {
+		if role.OrgID == orgID {
+			roles = append(roles, role)
+		}
+	}
+	return roles, nil
+}
+
+func (r *memoryOrgRoleRepo) Update(_ context.Context, role *model.OrgRole) error {
+	for i, existing := range r.roles {
+		if existing.ID == role.ID {
+			copied := *role
+			r.roles[i] = &copied
+		}
+	}
+	return nil
+}
+
+func (r *memoryOrgRoleRepo) Delete(_ context.Context, orgID, id uuid.UUID) (bool, error) {
+	for i, role := range r.roles {
+		if role.ID == id && role.OrgID == orgID {
+			r.roles = append(r.roles[:i], r.roles[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func newTestRoleService() (*RoleService, *memberTestDeps) {
+	deps := &memberTestDeps{
+		directory: &memoryDirectory{users: map[uuid.UUID]*model.User{}},
+		orgID:     uuid.New(),
+	}
+	deps.owner = deps.directory.addUser("owner@example.com")
+	deps.directory.addMember(deps.owner, deps.orgID, model.RoleOwner)
+	deps.admin = deps.directory.addUser("admin@example.com")
+	deps.directory.addMember(deps.admin, deps.orgID, model.RoleAdmin)
+	deps.member = deps.directory.addUser("member@example.com")
+	deps.directory.addMember(deps.member, deps.orgID, model.RoleMember)
+
+	return NewRoleService(&memoryOrgRoleRepo{}, memorySCIMMembershipRepo{deps.directory}, nil), deps
+}
+
+func TestOrgMembership_Permissions(t *testing.T) {
+	viewer := &model.OrgMembership{Role: model.RoleViewer}
+	assert.True(t, viewer.HasPermission(model.PermDocumentsRead))
+	assert.False(t, viewer.HasPermission(model.PermBillingRead))
+
+	viewer.CustomPermissions = []model.Permission{model.PermBillingRead, model.PermDocumentsRead}
+	assert.True(t, viewer.HasPermission(model.PermBillingRead))
+	assert.Equal(t, []model.Permission{
+		model.PermBillingRead, model.PermDocumentsRead, model.PermMembersRead, model.PermOrgRead,
+	}, viewer.Permissions())
+
+	owner := &model.OrgMembership{Role: model.RoleOwner}
+	assert.ElementsMatch(t, model.Permissions, owner.Permissions())
+	assert.False(t, (&model.OrgMembership{Role: model.RoleAdmin}).HasPermission(model.PermBillingManage))
+}
+
+func TestRoleService_CreateRole(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("admins create roles with grantable permissions", func(t *testing.T) {
+		svc, deps := newTestRoleService()
+
+		role, err := svc.CreateRole(ctx, deps.admin.ID, deps.orgID, RoleInput{
+			Name:        " Accountant ",
+			Permissions: []model.Permission{model.PermBillingRead, model.PermAuditRead, model.PermBillingRead},
+		}, "", "")
+		require.NoError(t, err)
+		assert.Equal(t, "Accountant", role.Name)
+		assert.Equal(t, []model.Permission{model.PermAuditRead, model.PermBillingRead}, role.Permissions)
+
+		roles, err := svc.ListRoles(ctx, deps.member.ID, deps.orgID)
+		require.NoError(t, err)
+		assert.Len(t, roles, 1)
+	})
+
+	t.Run("admins cannot grant permissions they lack", func(t *testing.T) {
+		svc, deps := newTestRoleService()
+
+		_, err := svc.CreateRole(ctx, deps.admin.ID, deps.orgID, RoleInput{
+			Name:        "Accountant",
+			Permissions: []model.Permission{model.PermBillingRead, model.PermBillingManage},
+		}, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrInsufficientRole)
+
+		role, err := svc.CreateRole(ctx, deps.admin.ID, deps.orgID, RoleInput{Name: "Accountant"}, "", "")
+		require.NoError(t, err)
+		_, err = svc.UpdateRole(ctx, deps.admin.ID, deps.orgID, role.ID, RoleInput{
+			Name:        "Accountant",
+			Permissions: []model.Permission{model.PermBillingManage},
+		}, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrInsufficientRole)
+
+		// A role the owner created with billing:manage cannot be assigned by an admin
+		billing, err := svc.CreateRole(ctx, deps.owner.ID, deps.orgID, RoleInput{
+			Name:        "Billing",
+			Permissions: []model.Permission{model.PermBillingManage},
+		}, "", "")
+		require.NoError(t, err)
+		_, err = svc.AssignRole(ctx, deps.admin.ID, deps.orgID, deps.member.ID, &billing.ID, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrInsufficientRole)
+		assert.Nil(t, deps.membership(deps.member).CustomRoleID)
+
+		_, err = svc.AssignRole(ctx, deps.owner.ID, deps.orgID, deps.member.ID, &billing.ID, "", "")
+		require.NoError(t, err)
+	})
+
+	t.Run("members cannot manage roles", func(t *testing.T) {
+		svc, deps := newTestRoleService()
+
+		_, err := svc.CreateRole(ctx, deps.member.ID, deps.orgID, RoleInput{Name: "Accountant"}, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrInsufficientRole)
+
+		_, err = svc.ListRoles(ctx, uuid.New(), deps.orgID)
+		assert.ErrorIs(t, err, appErrors.ErrForbidden)
+	})
+
+	t.Run("rejects administrative and unknown permissions", func(t *testing.T) {
+		svc, deps := newTestRoleService()
+
+		for _, permission := range []model.Permission{model.PermRolesManage, model.PermMembersManage, model.PermOrgManage, "billing:everything"} {
+			_, err := svc.CreateRole(ctx, deps.owner.ID, deps.orgID, RoleInput{Name: "Escalated", Permissions: []model.Permission{permission}}, "", "")
+			assert.ErrorIs(t, err, appErrors.ErrInvalidPermission, permission)
+		}
+	})
+
+	t.Run("names are unique and distinct from built-in roles", func(t *testing.T) {
+		svc, deps := newTestRoleService()
+
+		_, err := svc.CreateRole(ctx, deps.owner.ID, deps.orgID, RoleInput{Name: "Accountant"}, "", "")
+		require.NoError(t, err)
+		_, err = svc.CreateRole(ctx, deps.owner.ID, deps.orgID, RoleInput{Name: "accountant"}, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrRoleNameTaken)
+		_, err = svc.CreateRole(ctx, deps.owner.ID, deps.orgID, RoleInput{Name: "admin"}, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrRoleNameTaken)
+		_, err = svc.CreateRole(ctx, deps.owner.ID, deps.orgID, RoleInput{Name: "  "}, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrInvalidInput)
+	})
+}
+
+func TestRoleService_UpdateAndDeleteRole(t *testing.T) {
+	ctx := context.Background()
+	svc, deps := newTestRoleService()
+
+	role, err := svc.CreateRole(ctx, deps.owner.ID, deps.orgID, RoleInput{Name: "Accountant"}, "", "")
+	require.NoError(t, err)
+
+	updated, err := svc.UpdateRole(ctx, deps.owner.ID, deps.orgID, role.ID, RoleInput{
+		Name:        "Accountant",
+		Description: "Billing access",
+		Permissions: []model.Permission{model.PermAuditRead},
+	}, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "Billing access", updated.Description)
+	assert.Equal(t, []model.Permission{model.PermAuditRead}, updated.Permissions)
+
+	_, err = svc.UpdateRole(ctx, deps.owner.ID, uuid.New(), role.ID, RoleInput{Name: "Accountant"}, "", "")
+	assert.ErrorIs(t, err, appErrors.ErrForbidden)
+
+	require.NoError(t, svc.DeleteRole(ctx, deps.owner.ID, deps.orgID, role.ID, "", ""))
+	assert.ErrorIs(t, svc.DeleteRole(ctx, deps.owner.ID, deps.orgID, role.ID, "", ""), appErrors.ErrRoleNotFound)
+}
+
+func TestRoleService_AssignRole(t *testing.T) {
+	ctx := context.Background()
+	svc, deps := newTestRoleService()
+
+	role, err := svc.CreateRole(ctx, deps.owner.ID, deps.orgID, RoleInput{Name: "Accountant"}, "", "")
+	require.NoError(t, err)
+
+	membership, err := svc.AssignRole(ctx, deps.admin.ID, deps.orgID, deps.member.ID, &role.ID, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, &role.ID, membership.CustomRoleID)
+	assert.Equal(t, &role.ID, deps.membership(deps.member).CustomRoleID)
+
+	// Owners and admins already hold every grantable permission
+	_, err = svc.AssignRole(ctx, deps.owner.ID, deps.orgID, deps.admin.ID, &role.ID, "", "")
+	assert.ErrorIs(t, err, appErrors.ErrInvalidInput)
+
+	unknown := uuid.New()
+	_, err = svc.AssignRole(ctx, deps.owner.ID, deps.orgID, deps.member.ID, &unknown, "", "")
+	assert.ErrorIs(t, err, appErrors.ErrRoleNotFound)
+	_, err = svc.AssignRole(ctx, deps.owner.ID, deps.orgID, uuid.New(), &role.ID, "", "")
+	assert.ErrorIs(t, err, appErrors.ErrMemberNotFound)
+
+	// Deactivated members get no role
+	former := deps.directory.addUser("former@example.com")
+	deps.directory.addMember(former, deps.orgID, model.RoleMember).IsActive = false
+	_, err = svc.AssignRole(ctx, deps.owner.ID, deps.orgID, former.ID, &role.ID, "", "")
+	assert.ErrorIs(t, err, appErrors.ErrMemberNotFound)
+
+	_, err = svc.AssignRole(ctx, deps.owner.ID, deps.orgID, deps.member.ID, nil, "", "")
+	require.NoError(t, err)
+	assert.Nil(t, deps.membership(deps.member).CustomRoleID)
+}
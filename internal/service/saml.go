@@ -0,0 +1,531 @@
+package service
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/token"
+)
+
+const (
+	samlRequestTTL   = 10 * time.Minute
+	samlLoginCodeTTL = 2 * time.Minute
+
+	// Identities linked through an organization's IdP use "saml:<org id>" as provider
+	samlProviderPrefix = "saml:"
+)
+
+// Attributes the email and name are read from when the connection does not
+// name one: the usual Okta/Google names, the claim URIs of Azure AD and ADFS
+// and the LDAP OIDs.
+var (
+	samlEmailAttributes = []string{
+		"email",
+		"mail",
+		"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/emailaddress",
+		"urn:oid:0.9.2342.19200300.100.1.3",
+	}
+	samlNameAttributes = []string{
+		"name",
+		"displayName",
+		"http://schemas.microsoft.com/identity/claims/displayname",
+		"urn:oid:2.16.840.1.113730.3.1.241",
+	}
+)
+
+type SAMLConnectionRepository interface {
+	Upsert(ctx context.Context, conn *model.SAMLConnection) error
+	GetByOrgID(ctx context.Context, orgID uuid.UUID) (*model.SAMLConnection, error)
+	Delete(ctx context.Context, orgID uuid.UUID) (bool, error)
+}
+
+type SAMLRequestRepository interface {
+	Create(ctx context.Context, request *model.SAMLRequest) error
+	GetByRequestID(ctx context.Context, requestID string) (*model.SAMLRequest, error)
+	MarkAsUsed(ctx context.Context, id uuid.UUID) (bool, error)
+	DeleteExpired(ctx context.Context) error
+}
+
+type SAMLLoginCodeRepository interface {
+	Create(ctx context.Context, code *model.SAMLLoginCode) error
+	GetByCodeHash(ctx context.Context, codeHash string) (*model.SAMLLoginCode, error)
+	MarkAsUsed(ctx context.Context, id uuid.UUID) (bool, error)
+	DeleteExpired(ctx context.Context) error
+}
+
+// SAMLConnectionInput configures an organization's identity provider.
+type SAMLConnectionInput struct {
+	MetadataXML    string
+	EmailAttribute string
+	NameAttribute  string
+	DefaultRole    model.Role
+	Enabled        bool
+}
+
+// SAMLProfile is the user as asserted by an organization's identity provider.
+type SAMLProfile struct {
+	OrgID       uuid.UUID
+	Provider    string
+	Subject     string
+	Email       string
+	Name        string
+	DefaultRole model.Role
+}
+
+// SAMLService lets organizations sign their members in through their own
+// SAML 2.0 identity provider (Okta, Azure AD, ADFS, ...). We act as service
+// provider with SP-initiated login only: the HTTP-POST response must refer to
+// an AuthnRequest we sent, which also makes every response single-use. After
+// the assertion is verified the browser is sent to the frontend with a
+// one-time code that is exchanged for tokens.
+type SAMLService struct {
+	connRepo       SAMLConnectionRepository
+	requestRepo    SAMLRequestRepository
+	codeRepo       SAMLLoginCodeRepository
+	identityRepo   UserIdentityRepository
+	userRepo       UserRepository
+	orgRepo        OrganizationRepository
+	membershipRepo MembershipRepository
+	domainRepo     OrganizationDomainRepository
+	auditService   *AuditService
+	baseURL        string
+	now            func() time.Time
+}
+
+func NewSAMLService(
+	connRepo SAMLConnectionRepository,
+	requestRepo SAMLRequestRepository,
+	codeRepo SAMLLoginCodeRepository,
+	identityRepo UserIdentityRepository,
+	userRepo UserRepository,
+	orgRepo OrganizationRepository,
+	membershipRepo MembershipRepository,
+	domainRepo OrganizationDomainRepository,
+	auditService *AuditService,
+	baseURL string,
+) *SAMLService {
+	return &SAMLService{
+		connRepo:       connRepo,
+		requestRepo:    requestRepo,
+		codeRepo:       codeRepo,
+		identityRepo:   identityRepo,
+		userRepo:       userRepo,
+		orgRepo:        orgRepo,
+		membershipRepo: membershipRepo,
+		domainRepo:     domainRepo,
+		auditService:   auditService,
+		baseURL:        strings.TrimRight(baseURL, "/"),
+		now:            time.Now,
+	}
+}
+
+// serviceProvider describes our side for the organization. The entity ID is
+// the metadata URL, as most IdPs expect.
+func (s *SAMLService) serviceProvider(orgID uuid.UUID) *token.SAMLServiceProvider {
+	base := s.baseURL + "/v1/saml/" + orgID.String()
+	return &token.SAMLServiceProvider{
+		EntityID: base + "/metadata",
+		ACSURL:   base + "/acs",
+	}
+}
+
+// SPMetadata returns the service provider metadata for the organization's
+// IdP administrator. It is available before the IdP is configured.
+func (s *SAMLService) SPMetadata(ctx context.Context, orgID uuid.UUID) ([]byte, error) {
+	if _, err := s.orgRepo.GetByID(ctx, orgID); err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	return s.serviceProvider(orgID).Metadata(), nil
+}
+
+func (s *SAMLService) GetConnection(ctx context.Context, userID, orgID uuid.UUID) (*model.SAMLConnection, error) {
+	if err := s.requireOrgAdmin(ctx, userID, orgID); err != nil {
+		return nil, err
+	}
+
+	conn, err := s.connRepo.GetByOrgID(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SAML connection: %w", err)
+	}
+	if conn == nil {
+		return nil, errors.ErrSAMLNotConfigured
+	}
+	return conn, nil
+}
+
+// Configure stores the organization's IdP from its metadata, replacing the
+// previous configuration.
+func (s *SAMLService) Configure(ctx context.Context, userID, orgID uuid.UUID, input SAMLConnectionInput, ipAddress, userAgent string) (*model.SAMLConnection, error) {
+	if err := s.requireOrgAdmin(ctx, userID, orgID); err != nil {
+		return nil, err
+	}
+
+	metadata, err := token.ParseSAMLIdPMetadata([]byte(input.MetadataXML))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errors.ErrInvalidSAMLMetadata, err)
+	}
+
+	role := input.DefaultRole
+	if role == "" {
+		role = model.RoleMember
+	}
+	if role != model.RoleAdmin && role != model.RoleMember && role != model.RoleViewer {
+		return nil, errors.ErrInvalidInput
+	}
+
+	conn := &model.SAMLConnection{
+		OrgID:           orgID,
+		IdPEntityID:     metadata.EntityID,
+		IdPSSOURL:       metadata.SSOURL,
+		IdPCertificates: token.EncodeCertificatesPEM(metadata.Certificates),
+		MetadataXML:     input.MetadataXML,
+		EmailAttribute:  optionalString(strings.TrimSpace(input.EmailAttribute)),
+		NameAttribute:   optionalString(strings.TrimSpace(input.NameAttribute)),
+		DefaultRole:     role,
+		Enabled:         input.Enabled,
+	}
+	if err := s.connRepo.Upsert(ctx, conn); err != nil {
+		return nil, fmt.Errorf("failed to save SAML connection: %w", err)
+	}
+
+	s.audit(ctx, &userID, model.EventSAMLConfigured, map[string]interface{}{
+		"org_id":        orgID.String(),
+		"idp_entity_id": conn.IdPEntityID,
+		"enabled":       conn.Enabled,
+	}, ipAddress, userAgent)
+
+	return conn, nil
+}
+
+func (s *SAMLService) DeleteConnection(ctx context.Context, userID, orgID uuid.UUID, ipAddress, userAgent string) error {
+	if err := s.requireOrgAdmin(ctx, userID, orgID); err != nil {
+		return err
+	}
+
+	deleted, err := s.connRepo.Delete(ctx, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to delete SAML connection: %w", err)
+	}
+	if !deleted {
+		return errors.ErrSAMLNotConfigured
+	}
+
+	s.audit(ctx, &userID, model.EventSAMLRemoved, map[string]interface{}{
+		"org_id": orgID.String(),
+	}, ipAddress, userAgent)
+
+	return nil
+}
+
+// LoginURL starts a login at the organization's IdP.
+func (s *SAMLService) LoginURL(ctx context.Context, orgID uuid.UUID) (string, error) {
+	conn, sp, err := s.enabledConnection(ctx, orgID)
+	if err != nil {
+		return "", err
+	}
+
+	loginURL, requestID, err := sp.AuthnRequestURL(s.now())
+	if err != nil {
+		return "", err
+	}
+
+	request := &model.SAMLRequest{
+		RequestID: requestID,
+		OrgID:     conn.OrgID,
+		ExpiresAt: s.now().Add(samlRequestTTL),
+	}
+	if err := s.requestRepo.Create(ctx, request); err != nil {
+		return "", fmt.Errorf("failed to create SAML request: %w", err)
+	}
+
+	return loginURL, nil
+}
+
+// ConsumeResponse verifies a response posted to the organization's ACS and
+// consumes the request it answers. Every verification failure is reported as
+// ErrSAMLLoginFailed.
+func (s *SAMLService) ConsumeResponse(ctx context.Context, orgID uuid.UUID, samlResponse string) (*SAMLProfile, error) {
+	conn, sp, err := s.enabledConnection(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	assertion, err := sp.ParseResponse(samlResponse, s.now())
+	if err != nil {
+		log.Warn().Err(err).Str("org_id", orgID.String()).Msg("Rejected SAML response")
+		return nil, errors.ErrSAMLLoginFailed
+	}
+
+	return s.profile(ctx, conn, assertion)
+}
+
+// profile checks that a verified assertion answers an unused request of the
+// organization and maps it to a profile.
+func (s *SAMLService) profile(ctx context.Context, conn *model.SAMLConnection, assertion *token.SAMLAssertion) (*SAMLProfile, error) {
+	if assertion.InResponseTo == "" {
+		log.Warn().Str("org_id", conn.OrgID.String()).Msg("Rejected unsolicited SAML response")
+		return nil, errors.ErrSAMLLoginFailed
+	}
+	request, err := s.requestRepo.GetByRequestID(ctx, assertion.InResponseTo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SAML request: %w", err)
+	}
+	if request == nil || request.OrgID != conn.OrgID || request.UsedAt != nil || s.now().After(request.ExpiresAt) {
+		return nil, errors.ErrSAMLLoginFailed
+	}
+	used, err := s.requestRepo.MarkAsUsed(ctx, request.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume SAML request: %w", err)
+	}
+	if !used {
+		return nil, errors.ErrSAMLLoginFailed
+	}
+
+	profile := &SAMLProfile{
+		OrgID:       conn.OrgID,
+		Provider:    samlProviderPrefix + conn.OrgID.String(),
+		Subject:     assertion.NameID,
+		Email:       samlAttribute(assertion, conn.EmailAttribute, samlEmailAttributes),
+		Name:        samlAttribute(assertion, conn.NameAttribute, samlNameAttributes),
+		DefaultRole: conn.DefaultRole,
+	}
+	if profile.Email == "" && (assertion.NameIDFormat == token.SAMLNameIDFormatEmail || strings.Contains(assertion.NameID, "@")) {
+		profile.Email = assertion.NameID
+	}
+	profile.Email = strings.ToLower(strings.TrimSpace(profile.Email))
+
+	return profile, nil
+}
+
+// ResolveUser finds the user for a profile: an already linked account, or a
+// user with the asserted email, which is then linked. Either must already be
+// an active member of the organization, so an IdP cannot sign in users of
+// other organizations; users with an email at a domain the organization
+// verified are added as members instead. ErrUserNotFound means the email is
+// not registered and a new member may be provisioned.
+func (s *SAMLService) ResolveUser(ctx context.Context, profile *SAMLProfile, ipAddress, userAgent string) (uuid.UUID, error) {
+	identity, err := s.identityRepo.GetByProviderSubject(ctx, profile.Provider, profile.Subject)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to get identity: %w", err)
+	}
+
+	var userID uuid.UUID
+	if identity != nil {
+		userID = identity.UserID
+	} else {
+		if profile.Email == "" {
+			log.Warn().Str("org_id", profile.OrgID.String()).Msg("SAML assertion has no email")
+			return uuid.Nil, errors.ErrSAMLLoginFailed
+		}
+		user, err := s.userRepo.GetByEmail(ctx, profile.Email)
+		if err != nil {
+			if stdErrors.Is(err, pgx.ErrNoRows) {
+				return uuid.Nil, errors.ErrUserNotFound
+			}
+			return uuid.Nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		userID = user.ID
+	}
+
+	membership, err := s.membershipRepo.GetByUserAndOrg(ctx, userID, profile.OrgID)
+	if err != nil && !stdErrors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, fmt.Errorf("failed to get membership: %w", err)
+	}
+	if err != nil || membership == nil {
+		// The organization vouches for accounts at its verified domains
+		if identity != nil || !s.ownsEmailDomain(ctx, profile) {
+			return uuid.Nil, errors.ErrSAMLUserNotMember
+		}
+		if err := s.addMember(ctx, userID, profile, ipAddress, userAgent); err != nil {
+			return uuid.Nil, err
+		}
+	} else if !membership.IsActive {
+		return uuid.Nil, errors.ErrSAMLUserNotMember
+	}
+
+	if identity != nil {
+		if err := s.identityRepo.UpdateLogin(ctx, identity.ID, optionalString(profile.Email)); err != nil {
+			log.Error().Err(err).Str("identity_id", identity.ID.String()).Msg("Failed to update identity login")
+		}
+		return userID, nil
+	}
+
+	if err := s.Link(ctx, userID, profile, ipAddress, userAgent); err != nil {
+		return uuid.Nil, err
+	}
+	return userID, nil
+}
+
+// Link stores the IdP account for the user.
+func (s *SAMLService) Link(ctx context.Context, userID uuid.UUID, profile *SAMLProfile, ipAddress, userAgent string) error {
+	now := s.now()
+	identity := &model.UserIdentity{
+		UserID:      userID,
+		Provider:    profile.Provider,
+		Subject:     profile.Subject,
+		Email:       optionalString(profile.Email),
+		LastLoginAt: &now,
+	}
+	if err := s.identityRepo.Create(ctx, identity); err != nil {
+		return fmt.Errorf("failed to link identity: %w", err)
+	}
+
+	s.audit(ctx, &userID, model.EventIdentityLinked, map[string]interface{}{
+		"identity_id": identity.ID.String(),
+		"provider":    identity.Provider,
+	}, ipAddress, userAgent)
+
+	return nil
+}
+
+// ownsEmailDomain reports whether the organization verified the domain of
+// the asserted email.
+func (s *SAMLService) ownsEmailDomain(ctx context.Context, profile *SAMLProfile) bool {
+	if s.domainRepo == nil {
+		return false
+	}
+	domain, err := s.domainRepo.GetVerified(ctx, emailDomain(profile.Email))
+	if err != nil {
+		log.Error().Err(err).Str("org_id", profile.OrgID.String()).Msg("Failed to get verified domain")
+		return false
+	}
+	return domain != nil && domain.OrgID == profile.OrgID
+}
+
+// addMember adds the user to the organization with the connection's default role.
+func (s *SAMLService) addMember(ctx context.Context, userID uuid.UUID, profile *SAMLProfile, ipAddress, userAgent string) error {
+	membership := &model.OrgMembership{
+		UserID:   userID,
+		OrgID:    profile.OrgID,
+		Role:     profile.DefaultRole,
+		IsActive: true,
+	}
+	if err := s.membershipRepo.Create(ctx, membership); err != nil {
+		return fmt.Errorf("failed to add member: %w", err)
+	}
+
+	s.audit(ctx, &userID, model.EventMemberAdded, map[string]interface{}{
+		"org_id": profile.OrgID.String(),
+		"role":   string(membership.Role),
+		"source": "saml_domain",
+	}, ipAddress, userAgent)
+
+	return nil
+}
+
+// CreateLoginCode issues the one-time code the frontend exchanges for tokens.
+func (s *SAMLService) CreateLoginCode(ctx context.Context, userID, orgID uuid.UUID) (string, error) {
+	code, err := generateToken()
+	if err != nil {
+		return "", err
+	}
+
+	record := &model.SAMLLoginCode{
+		CodeHash:  hashToken(code),
+		UserID:    userID,
+		OrgID:     orgID,
+		ExpiresAt: s.now().Add(samlLoginCodeTTL),
+	}
+	if err := s.codeRepo.Create(ctx, record); err != nil {
+		return "", fmt.Errorf("failed to create SAML login code: %w", err)
+	}
+	return code, nil
+}
+
+// RedeemLoginCode consumes a login code.
+func (s *SAMLService) RedeemLoginCode(ctx context.Context, code string) (*model.SAMLLoginCode, error) {
+	if code == "" {
+		return nil, errors.ErrSAMLLoginFailed
+	}
+
+	record, err := s.codeRepo.GetByCodeHash(ctx, hashToken(code))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SAML login code: %w", err)
+	}
+	if record == nil || record.UsedAt != nil || s.now().After(record.ExpiresAt) {
+		return nil, errors.ErrSAMLLoginFailed
+	}
+	used, err := s.codeRepo.MarkAsUsed(ctx, record.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume SAML login code: %w", err)
+	}
+	if !used {
+		return nil, errors.ErrSAMLLoginFailed
+	}
+	return record, nil
+}
+
+// enabledConnection loads the organization's connection and the service
+// provider with its trusted certificates.
+func (s *SAMLService) enabledConnection(ctx context.Context, orgID uuid.UUID) (*model.SAMLConnection, *token.SAMLServiceProvider, error) {
+	conn, err := s.connRepo.GetByOrgID(ctx, orgID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get SAML connection: %w", err)
+	}
+	if conn == nil || !conn.Enabled {
+		return nil, nil, errors.ErrSAMLNotConfigured
+	}
+
+	certs, err := token.ParseCertificatesPEM(conn.IdPCertificates)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse IdP certificates: %w", err)
+	}
+
+	sp := s.serviceProvider(orgID)
+	sp.IdP = &token.SAMLIdPMetadata{
+		EntityID:     conn.IdPEntityID,
+		SSOURL:       conn.IdPSSOURL,
+		Certificates: certs,
+	}
+	return conn, sp, nil
+}
+
+// requireOrgAdmin allows owners and admins of the organization.
+func (s *SAMLService) requireOrgAdmin(ctx context.Context, userID, orgID uuid.UUID) error {
+	membership, err := s.membershipRepo.GetByUserAndOrg(ctx, userID, orgID)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return errors.ErrForbidden
+		}
+		return fmt.Errorf("failed to get membership: %w", err)
+	}
+	if membership == nil || !membership.IsActive || (membership.Role != model.RoleOwner && membership.Role != model.RoleAdmin) {
+		return errors.ErrForbidden
+	}
+	return nil
+}
+
+func (s *SAMLService) audit(ctx context.Context, userID *uuid.UUID, eventType model.AuditEventType, data map[string]interface{}, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	if err := s.auditService.Log(ctx, userID, eventType, data, ipAddress, userAgent); err != nil {
+		log.Error().Err(err).Str("event", string(eventType)).Msg("Failed to log SAML audit event")
+	}
+}
+
+// samlAttribute reads the configured attribute, or the first of the
+// well-known ones that is present.
+func samlAttribute(assertion *token.SAMLAssertion, configured *string, defaults []string) string {
+	if configured != nil {
+		return assertion.Attribute(*configured)
+	}
+	for _, name := range defaults {
+		if value := assertion.Attribute(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
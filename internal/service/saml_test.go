@@ -0,0 +1,522 @@
+package service
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/token"
+)
+
+type memorySAMLConnectionRepo struct {
+	conns map[uuid.UUID]*model.SAMLConnection
+}
+
+func (r *memorySAMLConnectionRepo) Upsert(_ context.Context, conn *model.SAMLConnection) error {
+	conn.ID = uuid.New()
+	r.conns[conn.OrgID] = conn
+	return nil
+}
+
+func (r *memorySAMLConnectionRepo) GetByOrgID(_ context.Context, orgID uuid.UUID) (*model.SAMLConnection, error) {
+	return r.conns[orgID], nil
+}
+
+func (r *memorySAMLConnectionRepo) Delete(_ context.Context, orgID uuid.UUID) (bool, error) {
+	_, ok := r.conns[orgID]
+	delete(r.conns, orgID)
+	return ok, nil
+}
+
+type memorySAMLRequestRepo struct {
+	requests map[string]*model.SAMLRequest
+}
+
+func (r *memorySAMLRequestRepo) Create(_ context.Context, request *model.SAMLRequest) error {
+	request.ID = uuid.New()
+	r.requests[request.RequestID] = request
+	return nil
+}
+
+func (r *memorySAMLRequestRepo) GetByRequestID(_ context.Context, requestID string) (*model.SAMLRequest, error) {
+	return r.requests[requestID], nil
+}
+
+func (r *memorySAMLRequestRepo) MarkAsUsed(_ context.Context, id uuid.UUID) (bool, error) {
+	for _, request := range r.requests {
+		if request.ID == id && request.UsedAt == nil {
+			now := time.Now()
+			request.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memorySAMLRequestRepo) DeleteExpired(context.Context) error {
+	return nil
+}
+
+type memorySAMLLoginCodeRepo struct {
+	codes map[string]*model.SAMLLoginCode
+}
+
+func (r *memorySAMLLoginCodeRepo) Create(_ context.Context, code *model.SAMLLoginCode) error {
+	code.ID = uuid.New()
+	r.codes[code.CodeHash] = code
+	return nil
+}
+
+func (r *memorySAMLLoginCodeRepo) GetByCodeHash(_ context.Context, codeHash string) (*model.SAMLLoginCode, error) {
+	return r.codes[codeHash], nil
+}
+
+func (r *memorySAMLLoginCodeRepo) MarkAsUsed(_ context.Context, id uuid.UUID) (bool, error) {
+	for _, code := range r.codes {
+		if code.ID == id && code.UsedAt == nil {
+			now := time.Now()
+			code.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memorySAMLLoginCodeRepo) DeleteExpired(context.Context) error {
+	return nil
+}
+
+type samlTestDeps struct {
+	conns       *memorySAMLConnectionRepo
+	requests    *memorySAMLRequestRepo
+	identities  *MockUserIdentityRepo
+	users       *MockUserRepo
+	memberships *MockMembershipRepo
+	domains     *memoryDomainRepo
+}
+
+func newTestSAMLService() (*SAMLService, *samlTestDeps) {
+	deps := &samlTestDeps{
+		conns:       &memorySAMLConnectionRepo{conns: map[uuid.UUID]*model.SAMLConnection{}},
+		requests:    &memorySAMLRequestRepo{requests: map[string]*model.SAMLRequest{}},
+		identities:  new(MockUserIdentityRepo),
+		users:       new(MockUserRepo),
+		memberships: new(MockMembershipRepo),
+		domains:     &memoryDomainRepo{},
+	}
+	codes := &memorySAMLLoginCodeRepo{codes: map[string]*model.SAMLLoginCode{}}
+	svc := NewSAMLService(deps.conns, deps.requests, codes, deps.identities, deps.users, nil, deps.memberships, deps.domains, nil, "https://auth.zeno.test/")
+	return svc, deps
+}
+
+func testSAMLMetadata(t *testing.T) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp.example.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return `<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="https://idp.example.test">
+  <md:IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <md:KeyDescriptor use="signing"><ds:KeyInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#"><ds:X509Data><ds:X509Certificate>` +
+		base64.StdEncoding.EncodeToString(der) +
+		`</ds:X509Certificate></ds:X509Data></ds:KeyInfo></md:KeyDescriptor>
+    <md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://idp.example.test/sso"/>
+  </md:IDPSSODescriptor>
+</md:EntityDescriptor>`
+}
+
+func TestSAMLService_Configure(t *testing.T) {
+	ctx := context.Background()
+	userID, orgID := uuid.New(), uuid.New()
+	admin := &model.OrgMembership{UserID: userID, OrgID: orgID, Role: model.RoleAdmin, IsActive: true}
+
+	t.Run("configures and starts login", func(t *testing.T) {
+		svc, deps := newTestSAMLService()
+		deps.memberships.On("GetByUserAndOrg", ctx, userID, orgID).Return(admin, nil)
+
+		conn, err := svc.Configure(ctx, userID, orgID, SAMLConnectionInput{MetadataXML: testSAMLMetadata(t), Enabled: true}, "127.0.0.1", "test-agent")
+		require.NoError(t, err)
+		assert.Equal(t, "https://idp.example.test", conn.IdPEntityID)
+		assert.Equal(t, model.RoleMember, conn.DefaultRole)
+		assert.Len(t, conn.IdPCertificates, 1)
+
+		loginURL, err := svc.LoginURL(ctx, orgID)
+		require.NoError(t, err)
+		assert.Contains(t, loginURL, "https://idp.example.test/sso?SAMLRequest=")
+		require.Len(t, deps.requests.requests, 1)
+		for _, request := range deps.requests.requests {
+			assert.Equal(t, orgID, request.OrgID)
+		}
+	})
+
+	t.Run("members cannot configure", func(t *testing.T) {
+		svc, deps := newTestSAMLService()
+		member := &model.OrgMembership{UserID: userID, OrgID: orgID, Role: model.RoleMember, IsActive: true}
+		deps.memberships.On("GetByUserAndOrg", ctx, userID, orgID).Return(member, nil)
+
+		_, err := svc.Configure(ctx, userID, orgID, SAMLConnectionInput{MetadataXML: testSAMLMetadata(t)}, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrForbidden)
+	})
+
+	t.Run("non-members cannot configure", func(t *testing.T) {
+		svc, deps := newTestSAMLService()
+		deps.memberships.On("GetByUserAndOrg", ctx, userID, orgID).Return(nil, pgx.ErrNoRows)
+
+		_, err := svc.Configure(ctx, userID, orgID, SAMLConnectionInput{MetadataXML: testSAMLMetadata(t)}, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrForbidden)
+	})
+
+	t.Run("invalid metadata", func(t *testing.T) {
+		svc, deps := newTestSAMLService()
+		deps.memberships.On("GetByUserAndOrg", ctx, userID, orgID).Return(admin, nil)
+
+		_, err := svc.Configure(ctx, userID, orgID, SAMLConnectionInput{MetadataXML: "<html/>"}, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrInvalidSAMLMetadata)
+	})
+
+	t.Run("owner is not a default role", func(t *testing.T) {
+		svc, deps := newTestSAMLService()
+		deps.memberships.On("GetByUserAndOrg", ctx, userID, orgID).Return(admin, nil)
+
+		_, err := svc.Configure(ctx, userID, orgID, SAMLConnectionInput{MetadataXML: testSAMLMetadata(t), DefaultRole: model.RoleOwner}, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrInvalidInput)
+	})
+
+	t.Run("login without connection", func(t *testing.T) {
+		svc, _ := newTestSAMLService()
+		_, err := svc.LoginURL(ctx, orgID)
+		assert.ErrorIs(t, err, appErrors.ErrSAMLNotConfigured)
+	})
+}
+
+func TestSAMLService_Profile(t *testing.T) {
+	ctx := context.Background()
+	orgID := uuid.New()
+	conn := &model.SAMLConnection{OrgID: orgID, DefaultRole: model.RoleViewer, Enabled: true}
+
+	newRequest := func(deps *samlTestDeps, org uuid.UUID) string {
+		requestID := "_" + uuid.NewString()
+		require.NoError(t, deps.requests.Create(ctx, &model.SAMLRequest{RequestID: requestID, OrgID: org, ExpiresAt: time.Now().Add(time.Minute)}))
+		return requestID
+	}
+
+	t.Run("maps attributes and consumes the request", func(t *testing.T) {
+		svc, deps := newTestSAMLService()
+		assertion := &token.SAMLAssertion{
+			NameID:       "00u1abcd",
+			InResponseTo: newRequest(deps, orgID),
+			Attributes: map[string][]string{
+				"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/emailaddress": {" Jane@Example.COM "},
+				"displayName": {"Jane Doe"},
+			},
+		}
+
+		profile, err := svc.profile(ctx, conn, assertion)
+		require.NoError(t, err)
+		assert.Equal(t, "saml:"+orgID.String(), profile.Provider)
+		assert.Equal(t, "00u1abcd", profile.Subject)
+		assert.Equal(t, "jane@example.com", profile.Email)
+		assert.Equal(t, "Jane Doe", profile.Name)
+		assert.Equal(t, model.RoleViewer, profile.DefaultRole)
+
+		// The same response cannot be replayed
+		_, err = svc.profile(ctx, conn, assertion)
+		assert.ErrorIs(t, err, appErrors.ErrSAMLLoginFailed)
+	})
+
+	t.Run("email from NameID", func(t *testing.T) {
+		svc, deps := newTestSAMLService()
+		profile, err := svc.profile(ctx, conn, &token.SAMLAssertion{
+			NameID:       "jane@example.com",
+			NameIDFormat: token.SAMLNameIDFormatEmail,
+			InResponseTo: newRequest(deps, orgID),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "jane@example.com", profile.Email)
+	})
+
+	t.Run("configured attribute", func(t *testing.T) {
+		svc, deps := newTestSAMLService()
+		attribute := "corpEmail"
+		configured := *conn
+		configured.EmailAttribute = &attribute
+		profile, err := svc.profile(ctx, &configured, &token.SAMLAssertion{
+			NameID:       "00u1abcd",
+			InResponseTo: newRequest(deps, orgID),
+			Attributes:   map[string][]string{"email": {"other@example.com"}, "corpEmail": {"jane@example.com"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "jane@example.com", profile.Email)
+	})
+
+	t.Run("unsolicited response", func(t *testing.T) {
+		svc, _ := newTestSAMLService()
+		_, err := svc.profile(ctx, conn, &token.SAMLAssertion{NameID: "00u1abcd"})
+		assert.ErrorIs(t, err, appErrors.ErrSAMLLoginFailed)
+	})
+
+	t.Run("request of another organization", func(t *testing.T) {
+		svc, deps := newTestSAMLService()
+		_, err := svc.profile(ctx, conn, &token.SAMLAssertion{NameID: "00u1abcd", InResponseTo: newRequest(deps, uuid.New())})
+		assert.ErrorIs(t, err, appErrors.ErrSAMLLoginFailed)
+	})
+
+	t.Run("expired request", func(t *testing.T) {
+		svc, deps := newTestSAMLService()
+		requestID := newRequest(deps, orgID)
+		svc.now = func() time.Time { return time.Now().Add(samlRequestTTL) }
+		_, err := svc.profile(ctx, conn, &token.SAMLAssertion{NameID: "00u1abcd", InResponseTo: requestID})
+		assert.ErrorIs(t, err, appErrors.ErrSAMLLoginFailed)
+	})
+}
+
+func TestSAMLService_ResolveUser(t *testing.T) {
+	ctx := context.Background()
+	orgID := uuid.New()
+	profile := &SAMLProfile{OrgID: orgID, Provider: "saml:" + orgID.String(), Subject: "00u1abcd", Email: "jane@example.com"}
+
+	t.Run("linked member", func(t *testing.T) {
+		svc, deps := newTestSAMLService()
+		identity := &model.UserIdentity{ID: uuid.New(), UserID: uuid.New()}
+		deps.identities.On("GetByProviderSubject", ctx, profile.Provider, profile.Subject).Return(identity, nil)
+		deps.memberships.On("GetByUserAndOrg", ctx, identity.UserID, orgID).Return(&model.OrgMembership{IsActive: true}, nil)
+		deps.identities.On("UpdateLogin", ctx, identity.ID, mock.Anything).Return(nil)
+
+		userID, err := svc.ResolveUser(ctx, profile, "", "")
+		require.NoError(t, err)
+		assert.Equal(t, identity.UserID, userID)
+		deps.identities.AssertExpectations(t)
+	})
+
+	t.Run("member with the email is linked", func(t *testing.T) {
+		svc, deps := newTestSAMLService()
+		user := &model.User{ID: uuid.New(), Email: profile.Email}
+		deps.identities.On("GetByProviderSubject", ctx, profile.Provider, profile.Subject).Return(nil, nil)
+		deps.users.On("GetByEmail", ctx, profile.Email).Return(user, nil)
+		deps.memberships.On("GetByUserAndOrg", ctx, user.ID, orgID).Return(&model.OrgMembership{IsActive: true}, nil)
+		deps.identities.On("Create", ctx, mock.MatchedBy(func(identity *model.UserIdentity) bool {
+			return identity.UserID == user.ID && identity.Provider == profile.Provider && identity.Subject == profile.Subject
+		})).Return(nil)
+
+		userID, err := svc.ResolveUser(ctx, profile, "", "")
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, userID)
+		deps.identities.AssertExpectations(t)
+	})
+
+	t.Run("user of another organization", func(t *testing.T) {
+		svc, deps := newTestSAMLService()
+		user := &model.User{ID: uuid.New(), Email: profile.Email}
+		deps.identities.On("GetByProviderSubject", ctx, profile.Provider, profile.Subject).Return(nil, nil)
+		deps.users.On("GetByEmail", ctx, profile.Email).Return(user, nil)
+		deps.memberships.On("GetByUserAndOrg", ctx, user.ID, orgID).Return(nil, pgx.ErrNoRows)
+
+		_, err := svc.ResolveUser(ctx, profile, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrSAMLUserNotMember)
+		deps.identities.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("user at a verified domain is added", func(t *testing.T) {
+		svc, deps := newTestSAMLService()
+		now := time.Now()
+		deps.domains.domains = append(deps.domains.domains, &model.OrganizationDomain{OrgID: orgID, Domain: "example.com", VerifiedAt: &now})
+		user := &model.User{ID: uuid.New(), Email: profile.Email}
+		domainProfile := *profile
+		domainProfile.DefaultRole = model.RoleViewer
+		deps.identities.On("GetByProviderSubject", ctx, profile.Provider, profile.Subject).Return(nil, nil)
+		deps.users.On("GetByEmail", ctx, profile.Email).Return(user, nil)
+		deps.memberships.On("GetByUserAndOrg", ctx, user.ID, orgID).Return(nil, pgx.ErrNoRows)
+		deps.memberships.On("Create", ctx, mock.MatchedBy(func(membership *model.OrgMembership) bool {
+			return membership.UserID == user.ID && membership.OrgID == orgID && membership.Role == model.RoleViewer && membership.IsActive
+		})).Return(nil)
+		deps.identities.On("Create", ctx, mock.Anything).Return(nil)
+
+		userID, err := svc.ResolveUser(ctx, &domainProfile, "", "")
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, userID)
+		deps.memberships.AssertExpectations(t)
+	})
+
+	t.Run("unknown email", func(t *testing.T) {
+		svc, deps := newTestSAMLService()
+		deps.identities.On("GetByProviderSubject", ctx, profile.Provider, profile.Subject).Return(nil, nil)
+		deps.users.On("GetByEmail", ctx, profile.Email).Return(nil, pgx.ErrNoRows)
+
+		_, err := svc.ResolveUser(ctx, profile, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrUserNotFound)
+	})
+}
+
+func TestSAMLService_LoginCode(t *testing.T) {
+	ctx := context.Background()
+	userID, orgID := uuid.New(), uuid.New()
+
+	svc, _ := newTestSAMLService()
+	code, err := svc.CreateLoginCode(ctx, userID, orgID)
+	require.NoError(t, err)
+
+	record, err := svc.RedeemLoginCode(ctx, code)
+	require.NoError(t, err)
+	assert.Equal(t, userID, record.UserID)
+	assert.Equal(t, orgID, record.OrgID)
+
+	_, err = svc.RedeemLoginCode(ctx, code)
+	assert.ErrorIs(t, err, appErrors.ErrSAMLLoginFailed)
+
+	expired, err := svc.CreateLoginCode(ctx, userID, orgID)
+	require.NoError(t, err)
+	svc.now = func() time.Time { return time.Now().Add(samlLoginCodeTTL + time.Second) }
+	_, err = svc.RedeemLoginCode(ctx, expired)
+	assert.ErrorIs(t, err, appErrors.ErrSAMLLoginFailed)
+}
+
+type memoryMFAChallengeRepo struct {
+	challenges map[string]*model.MFAChallenge
+}
+
+func (r *memoryMFAChallengeRepo) Create(_ context.Context, challenge *model.MFAChallenge) error {
+	challenge.ID = uuid.New()
+	r.challenges[challenge.TokenHash] = challenge
+	return nil
+}
+
+func (r *memoryMFAChallengeRepo) GetByTokenHash(_ context.Context, tokenHash string) (*model.MFAChallenge, error) {
+	challenge, ok := r.challenges[tokenHash]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return challenge, nil
+}
+
+func (r *memoryMFAChallengeRepo) IncrementAttempts(_ context.Context, id uuid.UUID) error {
+	return nil
+}
+
+func (r *memoryMFAChallengeRepo) MarkAsUsed(_ context.Context, id uuid.UUID) (bool, error) {
+	for _, challenge := range r.challenges {
+		if challenge.ID == id && challenge.UsedAt == nil {
+			now := time.Now()
+			challenge.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryMFAChallengeRepo) DeleteExpired(context.Context) error {
+	return nil
+}
+
+func TestAuthService_CompleteSAMLLoginWithMFA(t *testing.T) {
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Email: "jane@example.com", IsActive: true}
+	otherOrgID, samlOrgID := uuid.New(), uuid.New()
+
+	samlSvc, _ := newTestSAMLService()
+	totp := token.NewTOTPManager("ZenoN Cloud")
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	enabledAt := time.Now()
+	mfaRepo := new(MockMFARepo)
+	mfaRepo.On("GetByUserID", ctx, user.ID).Return(&model.UserMFA{UserID: user.ID, Secret: secret, EnabledAt: &enabledAt}, nil)
+	mfaRepo.On("UpdateLastUsedStep", ctx, user.ID, mock.Anything).Return(true, nil)
+	mfaSvc := NewMFAService(mfaRepo, &memoryMFAChallengeRepo{challenges: map[string]*model.MFAChallenge{}}, nil, nil, totp, nil, nil)
+
+	userRepo := new(MockUserRepo)
+	membershipRepo := new(MockMembershipRepo)
+	refreshRepo := new(MockRefreshTokenRepo)
+	svc := NewAuthService(userRepo, nil, membershipRepo, refreshRepo, newTestJWTManager(t), token.NewRefreshManager(), nil, nil, mfaSvc, nil, nil, samlSvc, nil, nil, nil, nil, nil,
+		&Config{AccessTokenTTL: 900, RefreshTokenTTL: 3600}, nil)
+
+	// The oldest membership is not the one the IdP signed the user into
+	samlMembership := &model.OrgMembership{UserID: user.ID, OrgID: samlOrgID, Role: model.RoleMember, IsActive: true}
+	userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	membershipRepo.On("GetByUserAndOrg", ctx, user.ID, samlOrgID).Return(samlMembership, nil)
+	membershipRepo.On("GetByUserID", ctx, user.ID).Return([]*model.OrgMembership{
+		{UserID: user.ID, OrgID: otherOrgID, Role: model.RoleOwner, IsActive: true},
+		samlMembership,
+	}, nil)
+	refreshRepo.On("Create", ctx, mock.Anything).Return(nil)
+
+	code, err := samlSvc.CreateLoginCode(ctx, user.ID, samlOrgID)
+	require.NoError(t, err)
+	result, err := svc.CompleteSAMLLogin(ctx, code, "test-agent", "127.0.0.1")
+	require.NoError(t, err)
+	require.True(t, result.MFARequired)
+	assert.Empty(t, result.AccessToken)
+
+	totpCode, err := totp.GenerateCode(secret, time.Now())
+	require.NoError(t, err)
+	result, err = svc.CompleteMFALogin(ctx, result.MFAToken, totpCode, "test-agent", "127.0.0.1")
+	require.NoError(t, err)
+	claims, err := svc.jwtManager.Validate(ctx, result.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, samlOrgID, claims.OrgID)
+	assert.Equal(t, []string{string(model.RoleMember)}, claims.Roles)
+}
+
+func TestAuthService_CompleteSAMLLoginRequiresMembership(t *testing.T) {
+	ctx := context.Background()
+	user := &model.User{ID: uuid.New(), Email: "jane@example.com", IsActive: true}
+	orgID := uuid.New()
+
+	samlSvc, _ := newTestSAMLService()
+	mfaRepo := new(MockMFARepo)
+	mfaSvc := NewMFAService(mfaRepo, &memoryMFAChallengeRepo{challenges: map[string]*model.MFAChallenge{}}, nil, nil, token.NewTOTPManager("ZenoN Cloud"), nil, nil)
+	userRepo := new(MockUserRepo)
+	membershipRepo := new(MockMembershipRepo)
+	svc := NewAuthService(userRepo, nil, membershipRepo, nil, newTestJWTManager(t), nil, nil, nil, mfaSvc, nil, nil, samlSvc, nil, nil, nil, nil, nil, &Config{}, nil)
+
+	userRepo.On("GetByID", ctx, user.ID).Return(user, nil)
+	membershipRepo.On("GetByUserAndOrg", ctx, user.ID, orgID).Return(nil, pgx.ErrNoRows)
+
+	// No MFA challenge is started for an organization the user is not in
+	code, err := samlSvc.CreateLoginCode(ctx, user.ID, orgID)
+	require.NoError(t, err)
+	_, err = svc.CompleteSAMLLogin(ctx, code, "", "")
+	assert.ErrorIs(t, err, appErrors.ErrSAMLUserNotMember)
+	mfaRepo.AssertNotCalled(t, "GetByUserID", mock.Anything, mock.Anything)
+}
+
+func TestAuthService_RegisterSAMLUserRequiresVerifiedDomain(t *testing.T) {
+	ctx := context.Background()
+	orgID, otherOrgID := uuid.New(), uuid.New()
+	now := time.Now()
+
+	samlSvc, deps := newTestSAMLService()
+	deps.domains.domains = append(deps.domains.domains,
+		&model.OrganizationDomain{OrgID: orgID, Domain: "example.com", VerifiedAt: &now},
+		&model.OrganizationDomain{OrgID: otherOrgID, Domain: "other.example", VerifiedAt: &now},
+	)
+	userRepo := new(MockUserRepo)
+	svc := NewAuthService(userRepo, nil, new(MockMembershipRepo), nil, newTestJWTManager(t), nil, nil, nil, nil, nil, nil, samlSvc, nil, nil, nil, nil, nil, &Config{}, nil)
+
+	for _, email := range []string{"victim@gmail.com", "jane@other.example", "jane@sub.example.com"} {
+		profile := &SAMLProfile{OrgID: orgID, Provider: "saml:" + orgID.String(), Subject: "00u1abcd", Email: email, DefaultRole: model.RoleMember}
+		_, err := svc.registerSAMLUser(ctx, profile, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrSAMLUserNotMember, email)
+	}
+	userRepo.AssertNotCalled(t, "CreateTx", mock.Anything, mock.Anything, mock.Anything)
+	deps.identities.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,867 @@
+package service
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/scim"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/validator"
+)
+
+const (
+	// SCIM tokens are prefixed so that they are recognizable in secret scanners
+	scimTokenPrefix = "scim_"
+
+	// SCIMMaxResults caps the page size of list requests.
+	SCIMMaxResults = 200
+)
+
+// Roles that can be assigned over SCIM. Each is exposed as a group whose id
+// is the lower-case role name. Owners are managed in the application only.
+var scimRoles = []model.Role{model.RoleAdmin, model.RoleMember, model.RoleViewer}
+
+type SCIMTokenRepository interface {
+	Create(ctx context.Context, token *model.SCIMToken) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*model.SCIMToken, error)
+	ListByOrgID(ctx context.Context, orgID uuid.UUID) ([]*model.SCIMToken, error)
+	UpdateLastUsed(ctx context.Context, id uuid.UUID) error
+	Delete(ctx context.Context, orgID, id uuid.UUID) (bool, error)
+}
+
+type SCIMMembershipRepository interface {
+	Create(ctx context.Context, membership *model.OrgMembership) error
+	GetByUserAndOrg(ctx context.Context, userID, orgID uuid.UUID) (*model.OrgMembership, error)
+	Update(ctx context.Context, membership *model.OrgMembership) error
+	ListByOrgID(ctx context.Context, orgID uuid.UUID) ([]*model.OrgMember, error)
+	Delete(ctx context.Context, userID, orgID uuid.UUID) (bool, error)
+}
+
+type SCIMUserRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*model.User, error)
+	GetByEmail(ctx context.Context, email string) (*model.User, error)
+	Update(ctx context.Context, user *model.User) error
+	CreateWithMembership(ctx context.Context, user *model.User, membership *model.OrgMembership) error
+}
+
+// SCIMClient is an authenticated provisioning client acting on an
+// organization.
+type SCIMClient struct {
+	OrgID     uuid.UUID
+	TokenID   uuid.UUID
+	IPAddress string
+	UserAgent string
+}
+
+// SCIMService lets an organization's identity provider provision its
+// members over SCIM 2.0. Users are the organization's members and groups are
+// its roles: adding a user to the "admin" group makes them an admin.
+//
+// The organization only controls accounts at email domains it verified. For
+// other users it manages the membership but not the profile, and it cannot
+// attach an existing account to the organization.
+type SCIMService struct {
+	tokenRepo      SCIMTokenRepository
+	membershipRepo SCIMMembershipRepository
+	userRepo       SCIMUserRepository
+	domainRepo     OrganizationDomainRepository
+	refreshRepo    RefreshTokenRepository
+	tokenRevoker   TokenRevoker
+	auditService   *AuditService
+	baseURL        string
+	validator      *validator.InputValidator
+}
+
+func NewSCIMService(
+	tokenRepo SCIMTokenRepository,
+	membershipRepo SCIMMembershipRepository,
+	userRepo SCIMUserRepository,
+	domainRepo OrganizationDomainRepository,
+	refreshRepo RefreshTokenRepository,
+	tokenRevoker TokenRevoker,
+	auditService *AuditService,
+	baseURL string,
+) *SCIMService {
+	return &SCIMService{
+		tokenRepo:      tokenRepo,
+		membershipRepo: membershipRepo,
+		userRepo:       userRepo,
+		domainRepo:     domainRepo,
+		refreshRepo:    refreshRepo,
+		tokenRevoker:   tokenRevoker,
+		auditService:   auditService,
+		baseURL:        strings.TrimRight(baseURL, "/"),
+		validator:      validator.NewInputValidator(),
+	}
+}
+
+// ListTokens returns the organization's SCIM tokens. Owners and admins only.
+func (s *SCIMService) ListTokens(ctx context.Context, userID, orgID uuid.UUID) ([]*model.SCIMToken, error) {
+	if err := s.requireOrgAdmin(ctx, userID, orgID); err != nil {
+		return nil, err
+	}
+	tokens, err := s.tokenRepo.ListByOrgID(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SCIM tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// CreateToken issues a SCIM token for the organization. The token itself is
+// returned only here.
+func (s *SCIMService) CreateToken(ctx context.Context, userID, orgID uuid.UUID, description, ipAddress, userAgent string) (*model.SCIMToken, string, error) {
+	if err := s.requireOrgAdmin(ctx, userID, orgID); err != nil {
+		return nil, "", err
+	}
+
+	description = strings.TrimSpace(description)
+	if len(description) > 255 {
+		return nil, "", errors.ErrInvalidInput
+	}
+
+	secret, err := generateToken()
+	if err != nil {
+		return nil, "", err
+	}
+	secret = scimTokenPrefix + secret
+
+	scimToken := &model.SCIMToken{
+		OrgID:       orgID,
+		TokenHash:   hashToken(secret),
+		Description: description,
+		CreatedBy:   &userID,
+	}
+	if err := s.tokenRepo.Create(ctx, scimToken); err != nil {
+		return nil, "", fmt.Errorf("failed to create SCIM token: %w", err)
+	}
+
+	s.audit(ctx, &userID, model.EventSCIMTokenCreated, map[string]interface{}{
+		"org_id":   orgID.String(),
+		"token_id": scimToken.ID.String(),
+	}, ipAddress, userAgent)
+
+	return scimToken, secret, nil
+}
+
+// DeleteToken revokes a SCIM token. Owners and admins only.
+func (s *SCIMService) DeleteToken(ctx context.Context, userID, orgID, tokenID uuid.UUID, ipAddress, userAgent string) error {
+	if err := s.requireOrgAdmin(ctx, userID, orgID); err != nil {
+		return err
+	}
+
+	deleted, err := s.tokenRepo.Delete(ctx, orgID, tokenID)
+	if err != nil {
+		return fmt.Errorf("failed to delete SCIM token: %w", err)
+	}
+	if !deleted {
+		return errors.ErrSCIMTokenNotFound
+	}
+
+	s.audit(ctx, &userID, model.EventSCIMTokenRevoked, map[string]interface{}{
+		"org_id":   orgID.String(),
+		"token_id": tokenID.String(),
+	}, ipAddress, userAgent)
+
+	return nil
+}
+
+// Authenticate resolves a bearer token to the client it was issued to.
+func (s *SCIMService) Authenticate(ctx context.Context, bearer string) (*SCIMClient, error) {
+	if !strings.HasPrefix(bearer, scimTokenPrefix) {
+		return nil, errors.ErrUnauthorized
+	}
+
+	scimToken, err := s.tokenRepo.GetByTokenHash(ctx, hashToken(bearer))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SCIM token: %w", err)
+	}
+	if scimToken == nil {
+		return nil, errors.ErrUnauthorized
+	}
+
+	if err := s.tokenRepo.UpdateLastUsed(ctx, scimToken.ID); err != nil {
+		log.Error().Err(err).Str("token_id", scimToken.ID.String()).Msg("Failed to update SCIM token usage")
+	}
+
+	return &SCIMClient{OrgID: scimToken.OrgID, TokenID: scimToken.ID}, nil
+}
+
+// ListUsers returns a page of the members matching the filter. startIndex
+// is 1-based.
+func (s *SCIMService) ListUsers(ctx context.Context, client *SCIMClient, filter string, startIndex, count int) (*scim.ListResponse, error) {
+	members, err := s.membershipRepo.ListByOrgID(ctx, client.OrgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+
+	resources := make([]interface{}, 0, len(members))
+	for _, member := range members {
+		resources = append(resources, s.userResource(member))
+	}
+	return listResponse(resources, filter, startIndex, count)
+}
+
+func (s *SCIMService) GetUser(ctx context.Context, client *SCIMClient, id string) (*scim.User, error) {
+	member, err := s.member(ctx, client.OrgID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.userResource(member), nil
+}
+
+// CreateUser adds a member to the organization, creating an account without
+// a password if there is none for the email. The user signs in through SSO
+// or sets a password with the password reset flow. The email must be at a
+// verified domain of the organization: an account created for someone else's
+// address would be signed into by its real owner later, e.g. through social
+// login or a magic link.
+func (s *SCIMService) CreateUser(ctx context.Context, client *SCIMClient, input *scim.User) (*scim.User, error) {
+	email, err := s.userEmail(input)
+	if err != nil {
+		return nil, err
+	}
+	if email == "" {
+		return nil, scim.BadRequest(scim.ErrTypeInvalidValue, "userName must be an email address")
+	}
+	role, err := scimRole(input.Roles, model.RoleMember)
+	if err != nil {
+		return nil, err
+	}
+	if !s.ownsEmail(ctx, client.OrgID, email) {
+		return nil, scim.BadRequest(scim.ErrTypeInvalidValue, "email must be at a verified domain of the organization")
+	}
+	if err := s.checkExternalID(ctx, client.OrgID, uuid.Nil, input.ExternalID); err != nil {
+		return nil, err
+	}
+
+	membership := &model.OrgMembership{
+		OrgID:      client.OrgID,
+		Role:       role,
+		IsActive:   input.Active == nil || *input.Active,
+		ExternalID: optionalString(input.ExternalID),
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil && !stdErrors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err != nil || user == nil {
+		user = &model.User{
+			Email:    email,
+			FullName: s.fullName(input),
+			IsActive: true,
+		}
+		if user.FullName == "" {
+			user.FullName = strings.SplitN(email, "@", 2)[0]
+		}
+		if err := s.userRepo.CreateWithMembership(ctx, user, membership); err != nil {
+			return nil, fmt.Errorf("failed to create user: %w", err)
+		}
+		s.audit(ctx, &user.ID, model.EventUserRegistered, map[string]interface{}{
+			"email":    user.Email,
+			"provider": "scim",
+			"org_id":   client.OrgID.String(),
+		}, client.IPAddress, client.UserAgent)
+	} else {
+		existing, err := s.membershipRepo.GetByUserAndOrg(ctx, user.ID, client.OrgID)
+		if err != nil && !stdErrors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("failed to get membership: %w", err)
+		}
+		if err == nil && existing != nil {
+			return nil, scim.NewError(http.StatusConflict, scim.ErrTypeUniqueness, "userName is already in use")
+		}
+		membership.UserID = user.ID
+		if err := s.membershipRepo.Create(ctx, membership); err != nil {
+			return nil, fmt.Errorf("failed to add member: %w", err)
+		}
+	}
+
+	s.audit(ctx, &user.ID, model.EventMemberAdded, map[string]interface{}{
+		"org_id": client.OrgID.String(),
+		"role":   string(membership.Role),
+		"source": "scim",
+	}, client.IPAddress, client.UserAgent)
+
+	return s.userResource(&model.OrgMember{Membership: *membership, User: *user}), nil
+}
+
+// ReplaceUser applies a full representation of the user. Absent roles keep
+// the member's role.
+func (s *SCIMService) ReplaceUser(ctx context.Context, client *SCIMClient, id string, input *scim.User) (*scim.User, error) {
+	member, err := s.member(ctx, client.OrgID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.updateUser(ctx, client, member, input); err != nil {
+		return nil, err
+	}
+	return s.userResource(member), nil
+}
+
+// PatchUser applies PATCH operations to the user's representation.
+func (s *SCIMService) PatchUser(ctx context.Context, client *SCIMClient, id string, ops []scim.PatchOperation) (*scim.User, error) {
+	member, err := s.member(ctx, client.OrgID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	resource, err := scim.ToMap(s.userResource(member))
+	if err != nil {
+		return nil, err
+	}
+	if err := scim.ApplyPatch(resource, ops); err != nil {
+		return nil, err
+	}
+	var input scim.User
+	if err := scim.FromMap(resource, &input); err != nil {
+		return nil, err
+	}
+
+	if err := s.updateUser(ctx, client, member, &input); err != nil {
+		return nil, err
+	}
+	return s.userResource(member), nil
+}
+
+// DeleteUser removes the user from the organization. The account itself is
+// kept; it may belong to other organizations.
+func (s *SCIMService) DeleteUser(ctx context.Context, client *SCIMClient, id string) error {
+	member, err := s.member(ctx, client.OrgID, id)
+	if err != nil {
+		return err
+	}
+	if member.Membership.Role == model.RoleOwner {
+		return scim.BadRequest(scim.ErrTypeMutability, "organization owners cannot be removed over SCIM")
+	}
+
+	if _, err := s.membershipRepo.Delete(ctx, member.User.ID, client.OrgID); err != nil {
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+	s.endSessions(ctx, member.User.ID)
+
+	s.audit(ctx, &member.User.ID, model.EventMemberRemoved, map[string]interface{}{
+		"org_id": client.OrgID.String(),
+		"source": "scim",
+	}, client.IPAddress, client.UserAgent)
+
+	return nil
+}
+
+// ListGroups returns the role groups matching the filter.
+func (s *SCIMService) ListGroups(ctx context.Context, client *SCIMClient, filter string, startIndex, count int, excludeMembers bool) (*scim.ListResponse, error) {
+	members, err := s.membershipRepo.ListByOrgID(ctx, client.OrgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+
+	resources := make([]interface{}, 0, len(scimRoles))
+	for _, role := range scimRoles {
+		resources = append(resources, s.groupResource(role, members, excludeMembers))
+	}
+	return listResponse(resources, filter, startIndex, count)
+}
+
+func (s *SCIMService) GetGroup(ctx context.Context, client *SCIMClient, id string, excludeMembers bool) (*scim.Group, error) {
+	role, ok := roleForGroupID(id)
+	if !ok {
+		return nil, scim.NotFound("group not found")
+	}
+	members, err := s.membershipRepo.ListByOrgID(ctx, client.OrgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+	return s.groupResource(role, members, excludeMembers), nil
+}
+
+// CreateGroup maps a group pushed by the identity provider to the role with
+// the same name ("Admins" to admin) and adds the members to it. Groups that
+// do not name a role are rejected.
+func (s *SCIMService) CreateGroup(ctx context.Context, client *SCIMClient, input *scim.Group) (*scim.Group, error) {
+	role, ok := roleForGroupName(input.DisplayName)
+	if !ok {
+		return nil, scim.BadRequest(scim.ErrTypeInvalidValue, "displayName must name a role: admin, member or viewer")
+	}
+	return s.setGroupMembers(ctx, client, role, input.Members, false)
+}
+
+// ReplaceGroup makes the listed users the members of the role group. Users
+// removed from a group become members.
+func (s *SCIMService) ReplaceGroup(ctx context.Context, client *SCIMClient, id string, input *scim.Group) (*scim.Group, error) {
+	role, ok := roleForGroupID(id)
+	if !ok {
+		return nil, scim.NotFound("group not found")
+	}
+	return s.setGroupMembers(ctx, client, role, input.Members, true)
+}
+
+// PatchGroup applies PATCH operations to the group's members.
+func (s *SCIMService) PatchGroup(ctx context.Context, client *SCIMClient, id string, ops []scim.PatchOperation) (*scim.Group, error) {
+	role, ok := roleForGroupID(id)
+	if !ok {
+		return nil, scim.NotFound("group not found")
+	}
+	members, err := s.membershipRepo.ListByOrgID(ctx, client.OrgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+
+	resource, err := scim.ToMap(s.groupResource(role, members, false))
+	if err != nil {
+		return nil, err
+	}
+	if err := scim.ApplyPatch(resource, ops); err != nil {
+		return nil, err
+	}
+	var input scim.Group
+	if err := scim.FromMap(resource, &input); err != nil {
+		return nil, err
+	}
+
+	return s.setGroupMembers(ctx, client, role, input.Members, true)
+}
+
+// DeleteGroup is refused: the roles always exist.
+func (s *SCIMService) DeleteGroup(ctx context.Context, client *SCIMClient, id string) error {
+	if _, ok := roleForGroupID(id); !ok {
+		return scim.NotFound("group not found")
+	}
+	return scim.BadRequest(scim.ErrTypeMutability, "role groups cannot be deleted")
+}
+
+// setGroupMembers gives the users the group's role. With exclusive set,
+// other users with the role are moved back to the member role.
+func (s *SCIMService) setGroupMembers(ctx context.Context, client *SCIMClient, role model.Role, values []scim.MultiValue, exclusive bool) (*scim.Group, error) {
+	members, err := s.membershipRepo.ListByOrgID(ctx, client.OrgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+
+	wanted := make(map[uuid.UUID]bool, len(values))
+	for _, value := range values {
+		id, err := uuid.Parse(value.Value)
+		if err != nil {
+			return nil, scim.BadRequest(scim.ErrTypeInvalidValue, "unknown member "+value.Value)
+		}
+		wanted[id] = true
+	}
+	known := make(map[uuid.UUID]bool, len(members))
+	for _, member := range members {
+		known[member.User.ID] = true
+	}
+	for id := range wanted {
+		if !known[id] {
+			return nil, scim.BadRequest(scim.ErrTypeInvalidValue, "unknown member "+id.String())
+		}
+	}
+
+	for _, member := range members {
+		if member.Membership.Role == model.RoleOwner {
+			continue
+		}
+		next := member.Membership.Role
+		switch {
+		case wanted[member.User.ID]:
+			next = role
+		case exclusive && member.Membership.Role == role:
+			next = model.RoleMember
+		}
+		if next == member.Membership.Role {
+			continue
+		}
+		if err := s.setRole(ctx, client, member, next); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.groupResource(role, members, false), nil
+}
+
+// updateUser applies a representation to the member.
+func (s *SCIMService) updateUser(ctx context.Context, client *SCIMClient, member *model.OrgMember, input *scim.User) error {
+	membership := &member.Membership
+	isOwner := membership.Role == model.RoleOwner
+
+	if input.Active != nil && !*input.Active && isOwner {
+		return scim.BadRequest(scim.ErrTypeMutability, "organization owners cannot be deactivated over SCIM")
+	}
+
+	role := membership.Role
+	if !isOwner {
+		var err error
+		if role, err = scimRole(input.Roles, membership.Role); err != nil {
+			return err
+		}
+	}
+
+	if err := s.checkExternalID(ctx, client.OrgID, member.User.ID, input.ExternalID); err != nil {
+		return err
+	}
+	if err := s.updateProfile(ctx, client, &member.User, input); err != nil {
+		return err
+	}
+
+	previousRole, wasActive := membership.Role, membership.IsActive
+	membership.Role = role
+	if input.Active != nil {
+		membership.IsActive = *input.Active
+	}
+	membership.ExternalID = optionalString(input.ExternalID)
+	if err := s.membershipRepo.Update(ctx, membership); err != nil {
+		return fmt.Errorf("failed to update member: %w", err)
+	}
+
+	if role != previousRole {
+		s.audit(ctx, &member.User.ID, model.EventMemberUpdated, map[string]interface{}{
+			"org_id":        client.OrgID.String(),
+			"role":          string(role),
+			"previous_role": string(previousRole),
+			"source":        "scim",
+		}, client.IPAddress, client.UserAgent)
+	}
+	if wasActive && !membership.IsActive {
+		s.endSessions(ctx, member.User.ID)
+		s.audit(ctx, &member.User.ID, model.EventMemberDeactivated, map[string]interface{}{
+			"org_id": client.OrgID.String(),
+			"source": "scim",
+		}, client.IPAddress, client.UserAgent)
+	}
+	return nil
+}
+
+// updateProfile changes the name and email of accounts at the
+// organization's verified domains. Changes to other accounts are ignored.
+func (s *SCIMService) updateProfile(ctx context.Context, client *SCIMClient, user *model.User, input *scim.User) error {
+	email, err := s.userEmail(input)
+	if err != nil {
+		return err
+	}
+	fullName := s.fullName(input)
+
+	changed := (email != "" && email != user.Email) || (fullName != "" && fullName != user.FullName)
+	if !changed || !s.ownsEmail(ctx, client.OrgID, user.Email) {
+		return nil
+	}
+
+	if email != "" && email != user.Email {
+		if !s.ownsEmail(ctx, client.OrgID, email) {
+			return scim.BadRequest(scim.ErrTypeInvalidValue, "email must be at a verified domain of the organization")
+		}
+		existing, err := s.userRepo.GetByEmail(ctx, email)
+		if err != nil && !stdErrors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if err == nil && existing != nil {
+			return scim.NewError(http.StatusConflict, scim.ErrTypeUniqueness, "userName is already in use")
+		}
+		s.audit(ctx, &user.ID, model.EventEmailChanged, map[string]interface{}{
+			"org_id": client.OrgID.String(),
+			"source": "scim",
+		}, client.IPAddress, client.UserAgent)
+		user.Email = email
+	}
+	if fullName != "" {
+		user.FullName = fullName
+	}
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+func (s *SCIMService) setRole(ctx context.Context, client *SCIMClient, member *model.OrgMember, role model.Role) error {
+	previous := member.Membership.Role
+	member.Membership.Role = role
+	if err := s.membershipRepo.Update(ctx, &member.Membership); err != nil {
+		return fmt.Errorf("failed to update member: %w", err)
+	}
+
+	s.audit(ctx, &member.User.ID, model.EventMemberUpdated, map[string]interface{}{
+		"org_id":        client.OrgID.String(),
+		"role":          string(role),
+		"previous_role": string(previous),
+		"source":        "scim",
+	}, client.IPAddress, client.UserAgent)
+
+	return nil
+}
+
+// checkExternalID rejects an externalId used by another member.
+func (s *SCIMService) checkExternalID(ctx context.Context, orgID, userID uuid.UUID, externalID string) error {
+	if externalID == "" {
+		return nil
+	}
+	members, err := s.membershipRepo.ListByOrgID(ctx, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to list members: %w", err)
+	}
+	for _, member := range members {
+		if member.User.ID != userID && member.Membership.ExternalID != nil && *member.Membership.ExternalID == externalID {
+			return scim.NewError(http.StatusConflict, scim.ErrTypeUniqueness, "externalId is already in use")
+		}
+	}
+	return nil
+}
+
+// member returns the organization's member with the SCIM id.
+func (s *SCIMService) member(ctx context.Context, orgID uuid.UUID, id string) (*model.OrgMember, error) {
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, scim.NotFound("user not found")
+	}
+
+	membership, err := s.membershipRepo.GetByUserAndOrg(ctx, userID, orgID)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return nil, scim.NotFound("user not found")
+		}
+		return nil, fmt.Errorf("failed to get membership: %w", err)
+	}
+	if membership == nil {
+		return nil, scim.NotFound("user not found")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return nil, scim.NotFound("user not found")
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.DeletedAt != nil {
+		return nil, scim.NotFound("user not found")
+	}
+
+	return &model.OrgMember{Membership: *membership, User: *user}, nil
+}
+
+// ownsEmail reports whether the organization verified the email's domain.
+func (s *SCIMService) ownsEmail(ctx context.Context, orgID uuid.UUID, email string) bool {
+	domainName := emailDomain(email)
+	if domainName == "" || s.domainRepo == nil {
+		return false
+	}
+	domain, err := s.domainRepo.GetVerified(ctx, domainName)
+	if err != nil {
+		log.Error().Err(err).Str("domain", domainName).Msg("Failed to get verified domain")
+		return false
+	}
+	return domain != nil && domain.OrgID == orgID
+}
+
+// endSessions signs the user out, as their tokens may carry the membership.
+func (s *SCIMService) endSessions(ctx context.Context, userID uuid.UUID) {
+	if s.refreshRepo != nil {
+		if err := s.refreshRepo.RevokeByUserID(ctx, userID); err != nil {
+			log.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to revoke refresh tokens")
+		}
+	}
+	revokeAccessTokens(ctx, s.tokenRevoker, userID)
+}
+
+// userEmail returns the normalized email of the representation: the
+// userName if it is an email address, otherwise the primary email.
+func (s *SCIMService) userEmail(input *scim.User) (string, error) {
+	email := s.validator.SanitizeEmail(input.UserName)
+	if !strings.Contains(email, "@") {
+		email = s.validator.SanitizeEmail(input.PrimaryEmail())
+	}
+	if email == "" {
+		return "", nil
+	}
+	if err := s.validator.ValidateEmail(email); err != nil {
+		return "", scim.BadRequest(scim.ErrTypeInvalidValue, "invalid email address")
+	}
+	return email, nil
+}
+
+func (s *SCIMService) fullName(input *scim.User) string {
+	var name string
+	if input.Name != nil {
+		name = strings.TrimSpace(input.Name.GivenName + " " + input.Name.FamilyName)
+		if name == "" {
+			name = input.Name.Formatted
+		}
+	}
+	if name == "" {
+		name = input.DisplayName
+	}
+	return s.validator.SanitizeName(name)
+}
+
+func (s *SCIMService) userResource(member *model.OrgMember) *scim.User {
+	user := &member.User
+	active := member.Membership.IsActive && user.IsActive
+	id := user.ID.String()
+
+	resource := &scim.User{
+		Schemas:     []string{scim.SchemaUser},
+		ID:          id,
+		UserName:    user.Email,
+		DisplayName: user.FullName,
+		Emails:      []scim.MultiValue{{Value: user.Email, Type: "work", Primary: true}},
+		Active:      &active,
+		Roles:       []scim.MultiValue{{Value: string(member.Membership.Role), Primary: true}},
+		Meta: &scim.Meta{
+			ResourceType: "User",
+			Created:      &member.Membership.CreatedAt,
+			LastModified: &user.UpdatedAt,
+			Location:     s.baseURL + "/scim/v2/Users/" + id,
+		},
+	}
+	if member.Membership.ExternalID != nil {
+		resource.ExternalID = *member.Membership.ExternalID
+	}
+	if user.FullName != "" {
+		given, family, _ := strings.Cut(user.FullName, " ")
+		resource.Name = &scim.Name{Formatted: user.FullName, GivenName: given, FamilyName: family}
+	}
+	if member.Membership.Role != model.RoleOwner {
+		resource.Groups = []scim.MultiValue{{Value: groupID(member.Membership.Role), Display: groupID(member.Membership.Role)}}
+	}
+	return resource
+}
+
+func (s *SCIMService) groupResource(role model.Role, members []*model.OrgMember, excludeMembers bool) *scim.Group {
+	id := groupID(role)
+	group := &scim.Group{
+		Schemas:     []string{scim.SchemaGroup},
+		ID:          id,
+		DisplayName: id,
+		Meta: &scim.Meta{
+			ResourceType: "Group",
+			Location:     s.baseURL + "/scim/v2/Groups/" + id,
+		},
+	}
+	if excludeMembers {
+		return group
+	}
+	for _, member := range members {
+		if member.Membership.Role == role {
+			group.Members = append(group.Members, scim.MultiValue{Value: member.User.ID.String(), Display: member.User.Email})
+		}
+	}
+	return group
+}
+
+func (s *SCIMService) requireOrgAdmin(ctx context.Context, userID, orgID uuid.UUID) error {
+	membership, err := s.membershipRepo.GetByUserAndOrg(ctx, userID, orgID)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return errors.ErrForbidden
+		}
+		return fmt.Errorf("failed to get membership: %w", err)
+	}
+	if membership == nil || !membership.IsActive || (membership.Role != model.RoleOwner && membership.Role != model.RoleAdmin) {
+		return errors.ErrForbidden
+	}
+	return nil
+}
+
+func (s *SCIMService) audit(ctx context.Context, userID *uuid.UUID, eventType model.AuditEventType, data map[string]interface{}, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	if err := s.auditService.Log(ctx, userID, eventType, data, ipAddress, userAgent); err != nil {
+		log.Error().Err(err).Str("event", string(eventType)).Msg("Failed to log SCIM audit event")
+	}
+}
+
+// scimRole returns the role named by the primary entry of roles, or
+// fallback if there is none.
+func scimRole(roles []scim.MultiValue, fallback model.Role) (model.Role, error) {
+	if len(roles) == 0 {
+		return fallback, nil
+	}
+	value := roles[0].Value
+	for _, role := range roles {
+		if role.Primary {
+			value = role.Value
+			break
+		}
+	}
+	for _, role := range scimRoles {
+		if strings.EqualFold(value, string(role)) {
+			return role, nil
+		}
+	}
+	return "", scim.BadRequest(scim.ErrTypeInvalidValue, "role must be one of ADMIN, MEMBER, VIEWER")
+}
+
+func groupID(role model.Role) string {
+	return strings.ToLower(string(role))
+}
+
+func roleForGroupID(id string) (model.Role, bool) {
+	for _, role := range scimRoles {
+		if id == groupID(role) {
+			return role, true
+		}
+	}
+	return "", false
+}
+
+// roleForGroupName matches "admin", "Admins" and "ADMIN".
+func roleForGroupName(name string) (model.Role, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, role := range scimRoles {
+		if id := groupID(role); name == id || name == id+"s" {
+			return role, true
+		}
+	}
+	return "", false
+}
+
+// listResponse filters and pages resources.
+func listResponse(resources []interface{}, filter string, startIndex, count int) (*scim.ListResponse, error) {
+	if filter != "" {
+		f, err := scim.ParseFilter(filter)
+		if err != nil {
+			return nil, err
+		}
+		matched := resources[:0]
+		for _, resource := range resources {
+			m, err := scim.ToMap(resource)
+			if err != nil {
+				return nil, err
+			}
+			if f.Match(m) {
+				matched = append(matched, resource)
+			}
+		}
+		resources = matched
+	}
+
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	if count < 0 || count > SCIMMaxResults {
+		count = SCIMMaxResults
+	}
+
+	page := []interface{}{}
+	if startIndex <= len(resources) {
+		end := startIndex - 1 + count
+		if end > len(resources) {
+			end = len(resources)
+		}
+		page = resources[startIndex-1 : end]
+	}
+
+	return &scim.ListResponse{
+		Schemas:      []string{scim.SchemaList},
+		TotalResults: len(resources),
+		StartIndex:   startIndex,
+		ItemsPerPage: len(page),
+		Resources:    page,
+	}, nil
+}
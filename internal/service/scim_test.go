@@ -0,0 +1,473 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/scim"
+)
+
+type memorySCIMTokenRepo struct {
+	tokens []*model.SCIMToken
+}
+
+func (r *memorySCIMTokenRepo) Create(_ context.Context, token *model.SCIMToken) error {
+	token.ID = uuid.New()
+	token.CreatedAt = time.Now()
+	r.tokens = append(r.tokens, token)
+	return nil
+}
+
+func (r *memorySCIMTokenRepo) GetByTokenHash(_ context.Context, tokenHash string) (*model.SCIMToken, error) {
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			return token, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memorySCIMTokenRepo) ListByOrgID(_ context.Context, orgID uuid.UUID) ([]*model.SCIMToken, error) {
+	var tokens []*model.SCIMToken
+	for _, token := range r.tokens {
+		if token.OrgID == orgID {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+func (r *memorySCIMTokenRepo) UpdateLastUsed(_ context.Context, id uuid.UUID) error {
+	now := time.Now()
+	for _, token := range r.tokens {
+		if token.ID == id {
+			token.LastUsedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *memorySCIMTokenRepo) Delete(_ context.Context, orgID, id uuid.UUID) (bool, error) {
+	for i, token := range r.tokens {
+		if token.ID == id && token.OrgID == orgID {
+			r.tokens = append(r.tokens[:i], r.tokens[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// memoryDirectory stores users and memberships for the SCIM repositories.
+type memoryDirectory struct {
+	users       map[uuid.UUID]*model.User
+	memberships []*model.OrgMembership
+}
+
+func (d *memoryDirectory) addUser(email string) *model.User {
+	user := &model.User{ID: uuid.New(), Email: email, FullName: "Jane Doe", IsActive: true}
+	d.users[user.ID] = user
+	return user
+}
+
+func (d *memoryDirectory) addMember(user *model.User, orgID uuid.UUID, role model.Role) *model.OrgMembership {
+	membership := &model.OrgMembership{ID: uuid.New(), UserID: user.ID, OrgID: orgID, Role: role, IsActive: true, CreatedAt: time.Now()}
+	d.memberships = append(d.memberships, membership)
+	return membership
+}
+
+type memorySCIMMembershipRepo struct{ *memoryDirectory }
+
+func (r memorySCIMMembershipRepo) Create(_ context.Context, membership *model.OrgMembership) error {
+	membership.ID = uuid.New()
+	membership.CreatedAt = time.Now()
+	copied := *membership
+	r.memberships = append(r.memberships, &copied)
+	return nil
+}
+
+func (r memorySCIMMembershipRepo) GetByUserAndOrg(_ context.Context, userID, orgID uuid.UUID) (*model.OrgMembership, error) {
+	for _, membership := range r.memberships {
+		if membership.UserID == userID && membership.OrgID == orgID {
+			copied := *membership
+			return &copied, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (r memorySCIMMembershipRepo) Update(_ context.Context, membership *model.OrgMembership) error {
+	for i, existing := range r.memberships {
+		if existing.UserID == membership.UserID && existing.OrgID == membership.OrgID {
+			copied := *membership
+			r.memberships[i] = &copied
+		}
+	}
+	return nil
+}
+
+func (r memorySCIMMembershipRepo) ListByOrgID(_ context.Context, orgID uuid.UUID) ([]*model.OrgMember, error) {
+	var members []*model.OrgMember
+	for _, membership := range r.memberships {
+		if membership.OrgID == orgID {
+			members = append(members, &model.OrgMember{Membership: *membership, User: *r.users[membership.UserID]})
+		}
+	}
+	return members, nil
+}
+
+func (r memorySCIMMembershipRepo) Delete(_ context.Context, userID, orgID uuid.UUID) (bool, error) {
+	for i, membership := range r.memberships {
+		if membership.UserID == userID && membership.OrgID == orgID {
+			r.memberships = append(r.memberships[:i], r.memberships[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type memorySCIMUserRepo struct{ *memoryDirectory }
+
+func (r memorySCIMUserRepo) GetByID(_ context.Context, id uuid.UUID) (*model.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r memorySCIMUserRepo) GetByEmail(_ context.Context, email string) (*model.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (r memorySCIMUserRepo) Update(_ context.Context, user *model.User) error {
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+func (r memorySCIMUserRepo) CreateWithMembership(_ context.Context, user *model.User, membership *model.OrgMembership) error {
+	user.ID = uuid.New()
+	copied := *user
+	r.users[user.ID] = &copied
+	membership.UserID = user.ID
+	return memorySCIMMembershipRepo(r).Create(context.Background(), membership)
+}
+
+// recordingRevoker records the users whose access tokens were revoked.
+type recordingRevoker struct {
+	users []uuid.UUID
+}
+
+func (r *recordingRevoker) RevokeSession(context.Context, uuid.UUID) error {
+	return nil
+}
+
+func (r *recordingRevoker) RevokeUser(_ context.Context, userID uuid.UUID) error {
+	r.users = append(r.users, userID)
+	return nil
+}
+
+type scimTestDeps struct {
+	tokens    *memorySCIMTokenRepo
+	directory *memoryDirectory
+	domains   *memoryDomainRepo
+	revoker   *recordingRevoker
+	client    *SCIMClient
+}
+
+func newTestSCIMService() (*SCIMService, *scimTestDeps) {
+	deps := &scimTestDeps{
+		tokens:    &memorySCIMTokenRepo{},
+		directory: &memoryDirectory{users: map[uuid.UUID]*model.User{}},
+		domains:   &memoryDomainRepo{},
+		revoker:   &recordingRevoker{},
+		client:    &SCIMClient{OrgID: uuid.New()},
+	}
+	svc := NewSCIMService(
+		deps.tokens, memorySCIMMembershipRepo{deps.directory}, memorySCIMUserRepo{deps.directory},
+		deps.domains, nil, deps.revoker, nil, "https://auth.zeno.test/",
+	)
+	return svc, deps
+}
+
+func (d *scimTestDeps) verifyDomain(orgID uuid.UUID, name string) {
+	now := time.Now()
+	d.domains.domains = append(d.domains.domains, &model.OrganizationDomain{ID: uuid.New(), OrgID: orgID, Domain: name, VerifiedAt: &now})
+}
+
+func scimStatus(t *testing.T, err error) (int, string) {
+	t.Helper()
+	var scimErr *scim.Error
+	require.ErrorAs(t, err, &scimErr)
+	return scimErr.Status, scimErr.ScimType
+}
+
+func scimPatch(t *testing.T, ops string) []scim.PatchOperation {
+	t.Helper()
+	var req scim.PatchRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"Operations":`+ops+`}`), &req))
+	return req.Operations
+}
+
+func TestSCIMService_Tokens(t *testing.T) {
+	ctx := context.Background()
+	svc, deps := newTestSCIMService()
+	orgID := deps.client.OrgID
+	admin := deps.directory.addUser("admin@example.com")
+	deps.directory.addMember(admin, orgID, model.RoleAdmin)
+	member := deps.directory.addUser("member@example.com")
+	deps.directory.addMember(member, orgID, model.RoleMember)
+
+	_, _, err := svc.CreateToken(ctx, member.ID, orgID, "Okta", "", "")
+	assert.ErrorIs(t, err, appErrors.ErrForbidden)
+
+	scimToken, secret, err := svc.CreateToken(ctx, admin.ID, orgID, "Okta", "", "")
+	require.NoError(t, err)
+	assert.Contains(t, secret, scimTokenPrefix)
+	assert.NotEqual(t, secret, scimToken.TokenHash)
+
+	client, err := svc.Authenticate(ctx, secret)
+	require.NoError(t, err)
+	assert.Equal(t, orgID, client.OrgID)
+	assert.NotNil(t, deps.tokens.tokens[0].LastUsedAt)
+
+	_, err = svc.Authenticate(ctx, secret+"x")
+	assert.ErrorIs(t, err, appErrors.ErrUnauthorized)
+
+	require.NoError(t, svc.DeleteToken(ctx, admin.ID, orgID, scimToken.ID, "", ""))
+	_, err = svc.Authenticate(ctx, secret)
+	assert.ErrorIs(t, err, appErrors.ErrUnauthorized)
+	assert.ErrorIs(t, svc.DeleteToken(ctx, admin.ID, orgID, scimToken.ID, "", ""), appErrors.ErrSCIMTokenNotFound)
+}
+
+func TestSCIMService_CreateUser(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("creates an account", func(t *testing.T) {
+		svc, deps := newTestSCIMService()
+		deps.verifyDomain(deps.client.OrgID, "example.com")
+		user, err := svc.CreateUser(ctx, deps.client, &scim.User{
+			UserName:   "Jane@Example.com",
+			ExternalID: "00u1",
+			Name:       &scim.Name{GivenName: "Jane", FamilyName: "Doe"},
+			Roles:      []scim.MultiValue{{Value: "admin"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "jane@example.com", user.UserName)
+		assert.Equal(t, "00u1", user.ExternalID)
+		assert.Equal(t, "Jane Doe", user.DisplayName)
+		assert.True(t, *user.Active)
+		assert.Equal(t, "ADMIN", user.Roles[0].Value)
+		assert.Equal(t, "https://auth.zeno.test/scim/v2/Users/"+user.ID, user.Meta.Location)
+
+		created := deps.directory.users[uuid.MustParse(user.ID)]
+		assert.Empty(t, created.PasswordHash)
+
+		_, err = svc.CreateUser(ctx, deps.client, &scim.User{UserName: "jane@example.com"})
+		status, scimType := scimStatus(t, err)
+		assert.Equal(t, http.StatusConflict, status)
+		assert.Equal(t, scim.ErrTypeUniqueness, scimType)
+
+		_, err = svc.CreateUser(ctx, deps.client, &scim.User{UserName: "john@example.com", ExternalID: "00u1"})
+		_, scimType = scimStatus(t, err)
+		assert.Equal(t, scim.ErrTypeUniqueness, scimType)
+	})
+
+	t.Run("existing account", func(t *testing.T) {
+		svc, deps := newTestSCIMService()
+		existing := deps.directory.addUser("jane@example.com")
+
+		// Not the organization's to claim
+		_, err := svc.CreateUser(ctx, deps.client, &scim.User{UserName: "jane@example.com"})
+		status, _ := scimStatus(t, err)
+		assert.Equal(t, http.StatusBadRequest, status)
+
+		deps.verifyDomain(deps.client.OrgID, "example.com")
+		user, err := svc.CreateUser(ctx, deps.client, &scim.User{UserName: "jane@example.com"})
+		require.NoError(t, err)
+		assert.Equal(t, existing.ID.String(), user.ID)
+		assert.Equal(t, "MEMBER", user.Roles[0].Value)
+	})
+
+	t.Run("address outside the organization's domains", func(t *testing.T) {
+		svc, deps := newTestSCIMService()
+		deps.verifyDomain(deps.client.OrgID, "example.com")
+		// Another organization's domain does not count either
+		deps.verifyDomain(uuid.New(), "other.com")
+
+		for _, email := range []string{"victim@gmail.com", "jane@other.com", "jane@sub.example.com"} {
+			_, err := svc.CreateUser(ctx, deps.client, &scim.User{UserName: email})
+			status, scimType := scimStatus(t, err)
+			assert.Equal(t, http.StatusBadRequest, status)
+			assert.Equal(t, scim.ErrTypeInvalidValue, scimType)
+		}
+		assert.Empty(t, deps.directory.users)
+	})
+
+	t.Run("owner role cannot be assigned", func(t *testing.T) {
+		svc, deps := newTestSCIMService()
+		_, err := svc.CreateUser(ctx, deps.client, &scim.User{UserName: "jane@example.com", Roles: []scim.MultiValue{{Value: "OWNER"}}})
+		_, scimType := scimStatus(t, err)
+		assert.Equal(t, scim.ErrTypeInvalidValue, scimType)
+	})
+}
+
+func TestSCIMService_PatchUser(t *testing.T) {
+	ctx := context.Background()
+	svc, deps := newTestSCIMService()
+	orgID := deps.client.OrgID
+	jane := deps.directory.addUser("jane@example.com")
+	deps.directory.addMember(jane, orgID, model.RoleMember)
+	owner := deps.directory.addUser("owner@example.com")
+	deps.directory.addMember(owner, orgID, model.RoleOwner)
+
+	// Profile changes need a verified domain
+	user, err := svc.PatchUser(ctx, deps.client, jane.ID.String(), scimPatch(t, `[{"op":"replace","path":"name.givenName","value":"Janet"}]`))
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Doe", user.DisplayName)
+
+	deps.verifyDomain(orgID, "example.com")
+	user, err = svc.PatchUser(ctx, deps.client, jane.ID.String(), scimPatch(t, `[
+		{"op":"replace","path":"name.givenName","value":"Janet"},
+		{"op":"replace","path":"roles[primary eq true].value","value":"viewer"}
+	]`))
+	require.NoError(t, err)
+	assert.Equal(t, "Janet Doe", user.DisplayName)
+	assert.Equal(t, "VIEWER", user.Roles[0].Value)
+	assert.Equal(t, "Janet Doe", deps.directory.users[jane.ID].FullName)
+
+	// Azure AD deactivates with a string value
+	user, err = svc.PatchUser(ctx, deps.client, jane.ID.String(), scimPatch(t, `[{"op":"Replace","path":"active","value":"False"}]`))
+	require.NoError(t, err)
+	assert.False(t, *user.Active)
+	assert.Equal(t, []uuid.UUID{jane.ID}, deps.revoker.users)
+
+	_, err = svc.PatchUser(ctx, deps.client, owner.ID.String(), scimPatch(t, `[{"op":"replace","value":{"active":false}}]`))
+	_, scimType := scimStatus(t, err)
+	assert.Equal(t, scim.ErrTypeMutability, scimType)
+
+	_, err = svc.PatchUser(ctx, deps.client, uuid.NewString(), nil)
+	status, _ := scimStatus(t, err)
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestSCIMService_DeleteUser(t *testing.T) {
+	ctx := context.Background()
+	svc, deps := newTestSCIMService()
+	jane := deps.directory.addUser("jane@example.com")
+	deps.directory.addMember(jane, deps.client.OrgID, model.RoleMember)
+	other := deps.directory.addMember(jane, uuid.New(), model.RoleMember)
+
+	require.NoError(t, svc.DeleteUser(ctx, deps.client, jane.ID.String()))
+	assert.Equal(t, []*model.OrgMembership{other}, deps.directory.memberships)
+	assert.Contains(t, deps.directory.users, jane.ID)
+
+	_, err := svc.GetUser(ctx, deps.client, jane.ID.String())
+	status, _ := scimStatus(t, err)
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestSCIMService_ListUsers(t *testing.T) {
+	ctx := context.Background()
+	svc, deps := newTestSCIMService()
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		deps.directory.addMember(deps.directory.addUser(email), deps.client.OrgID, model.RoleMember)
+	}
+	deps.directory.addMember(deps.directory.addUser("d@example.com"), uuid.New(), model.RoleMember)
+
+	list, err := svc.ListUsers(ctx, deps.client, `userName eq "B@example.com"`, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, list.TotalResults)
+	assert.Equal(t, "b@example.com", list.Resources[0].(*scim.User).UserName)
+
+	list, err = svc.ListUsers(ctx, deps.client, "", 2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 3, list.TotalResults)
+	assert.Equal(t, 2, list.StartIndex)
+	require.Len(t, list.Resources, 1)
+	assert.Equal(t, "b@example.com", list.Resources[0].(*scim.User).UserName)
+
+	list, err = svc.ListUsers(ctx, deps.client, "", 10, 5)
+	require.NoError(t, err)
+	assert.Empty(t, list.Resources)
+
+	_, err = svc.ListUsers(ctx, deps.client, `userName xx "a"`, 1, 10)
+	_, scimType := scimStatus(t, err)
+	assert.Equal(t, scim.ErrTypeInvalidFilter, scimType)
+}
+
+func TestSCIMService_Groups(t *testing.T) {
+	ctx := context.Background()
+	svc, deps := newTestSCIMService()
+	orgID := deps.client.OrgID
+	jane := deps.directory.addUser("jane@example.com")
+	deps.directory.addMember(jane, orgID, model.RoleMember)
+	john := deps.directory.addUser("john@example.com")
+	deps.directory.addMember(john, orgID, model.RoleAdmin)
+	owner := deps.directory.addUser("owner@example.com")
+	deps.directory.addMember(owner, orgID, model.RoleOwner)
+
+	// Pushing an "Admins" group maps it to the admin role
+	group, err := svc.CreateGroup(ctx, deps.client, &scim.Group{
+		DisplayName: "Admins",
+		Members:     []scim.MultiValue{{Value: jane.ID.String()}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "admin", group.ID)
+	assert.Len(t, group.Members, 2)
+
+	_, err = svc.CreateGroup(ctx, deps.client, &scim.Group{DisplayName: "Engineering"})
+	_, scimType := scimStatus(t, err)
+	assert.Equal(t, scim.ErrTypeInvalidValue, scimType)
+
+	group, err = svc.PatchGroup(ctx, deps.client, "admin", scimPatch(t, `[
+		{"op":"remove","path":"members[value eq \"`+john.ID.String()+`\"]"},
+		{"op":"add","path":"members","value":[{"value":"`+owner.ID.String()+`"}]}
+	]`))
+	require.NoError(t, err)
+	require.Len(t, group.Members, 1)
+	assert.Equal(t, jane.ID.String(), group.Members[0].Value)
+
+	// Owners keep their role; users removed from a group become members
+	roles := map[uuid.UUID]model.Role{}
+	for _, membership := range deps.directory.memberships {
+		roles[membership.UserID] = membership.Role
+	}
+	assert.Equal(t, model.RoleAdmin, roles[jane.ID])
+	assert.Equal(t, model.RoleMember, roles[john.ID])
+	assert.Equal(t, model.RoleOwner, roles[owner.ID])
+
+	_, err = svc.ReplaceGroup(ctx, deps.client, "viewer", &scim.Group{Members: []scim.MultiValue{{Value: uuid.NewString()}}})
+	_, scimType = scimStatus(t, err)
+	assert.Equal(t, scim.ErrTypeInvalidValue, scimType)
+
+	list, err := svc.ListGroups(ctx, deps.client, `displayName eq "viewer"`, 1, 10, true)
+	require.NoError(t, err)
+	require.Equal(t, 1, list.TotalResults)
+	assert.Empty(t, list.Resources[0].(*scim.Group).Members)
+
+	_, err = svc.GetGroup(ctx, deps.client, "owner", false)
+	status, _ := scimStatus(t, err)
+	assert.Equal(t, http.StatusNotFound, status)
+
+	err = svc.DeleteGroup(ctx, deps.client, "admin")
+	_, scimType = scimStatus(t, err)
+	assert.Equal(t, scim.ErrTypeMutability, scimType)
+}
@@ -3,17 +3,22 @@ package service
 import (
 	"context"
 
-	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
 )
 
 type SessionService struct {
-	refreshRepo RefreshTokenRepository
+	refreshRepo  RefreshTokenRepository
+	tokenRevoker TokenRevoker
 }
 
-func NewSessionService(refreshRepo RefreshTokenRepository) *SessionService {
+func NewSessionService(refreshRepo RefreshTokenRepository, tokenRevoker TokenRevoker) *SessionService {
 	return &SessionService{
-		refreshRepo: refreshRepo,
+		refreshRepo:  refreshRepo,
+		tokenRevoker: tokenRevoker,
 	}
 }
 
@@ -21,10 +26,54 @@ func (s *SessionService) GetActiveSessions(ctx context.Context, userID uuid.UUID
 	return s.refreshRepo.GetActiveByUserID(ctx, userID)
 }
 
-func (s *SessionService) RevokeSession(ctx context.Context, sessionID uuid.UUID) error {
-	return s.refreshRepo.RevokeByID(ctx, sessionID)
+// RevokeSession ends one of the user's sessions: its refresh tokens and the
+// access tokens issued for it stop working immediately.
+func (s *SessionService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	sessions, err := s.refreshRepo.GetActiveByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var session *model.RefreshToken
+	for _, candidate := range sessions {
+		if candidate.ID == sessionID {
+			session = candidate
+			break
+		}
+	}
+	if session == nil {
+		return errors.ErrNotFound
+	}
+
+	if err := s.refreshRepo.RevokeFamily(ctx, session.FamilyID); err != nil {
+		return err
+	}
+
+	if s.tokenRevoker != nil {
+		return s.tokenRevoker.RevokeSession(ctx, session.FamilyID)
+	}
+	return nil
 }
 
 func (s *SessionService) RevokeAllSessions(ctx context.Context, userID uuid.UUID) error {
-	return s.refreshRepo.RevokeByUserID(ctx, userID)
+	if err := s.refreshRepo.RevokeByUserID(ctx, userID); err != nil {
+		return err
+	}
+
+	if s.tokenRevoker != nil {
+		return s.tokenRevoker.RevokeUser(ctx, userID)
+	}
+	return nil
+}
+
+// revokeAccessTokens denylists the user's outstanding access tokens after the
+// refresh tokens were revoked as part of a larger operation. Failures are only
+// logged because the operation itself has already been committed.
+func revokeAccessTokens(ctx context.Context, tokenRevoker TokenRevoker, userID uuid.UUID) {
+	if tokenRevoker == nil {
+		return
+	}
+	if err := tokenRevoker.RevokeUser(ctx, userID); err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("Failed to revoke access tokens")
+	}
 }
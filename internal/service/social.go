@@ -0,0 +1,425 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/config"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+const (
+	socialStateTTL = 10 * time.Minute
+
+	// Upstream responses larger than this are rejected
+	socialMaxResponseBytes = 1 << 20
+)
+
+type UserIdentityRepository interface {
+	Create(ctx context.Context, identity *model.UserIdentity) error
+	GetByProviderSubject(ctx context.Context, provider, subject string) (*model.UserIdentity, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*model.UserIdentity, error)
+	UpdateLogin(ctx context.Context, id uuid.UUID, email *string) error
+	Delete(ctx context.Context, id, userID uuid.UUID) (bool, error)
+}
+
+type SocialAuthStateRepository interface {
+	Create(ctx context.Context, state *model.SocialAuthState) error
+	GetByStateHash(ctx context.Context, stateHash string) (*model.SocialAuthState, error)
+	MarkAsUsed(ctx context.Context, id uuid.UUID) (bool, error)
+	DeleteExpired(ctx context.Context) error
+}
+
+// SocialProviderInfo is what the frontend needs to render a sign-in button.
+type SocialProviderInfo struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+// SocialProfile is the user as reported by an upstream identity provider.
+type SocialProfile struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// SocialService signs users in through upstream OAuth 2.0 / OpenID Connect
+// identity providers. The provider redirects back to the frontend, which
+// checks the state it was given and posts the code to the API. The profile
+// is read from the provider's userinfo endpoint with the access token
+// obtained directly from its token endpoint.
+type SocialService struct {
+	providers    map[string]config.SocialProvider
+	order        []string
+	identityRepo UserIdentityRepository
+	stateRepo    SocialAuthStateRepository
+	userRepo     UserRepository
+	auditService *AuditService
+	httpClient   *http.Client
+}
+
+func NewSocialService(
+	providers []config.SocialProvider,
+	identityRepo UserIdentityRepository,
+	stateRepo SocialAuthStateRepository,
+	userRepo UserRepository,
+	auditService *AuditService,
+) *SocialService {
+	s := &SocialService{
+		providers:    make(map[string]config.SocialProvider, len(providers)),
+		identityRepo: identityRepo,
+		stateRepo:    stateRepo,
+		userRepo:     userRepo,
+		auditService: auditService,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, p := range providers {
+		s.providers[p.Name] = p
+		s.order = append(s.order, p.Name)
+	}
+	return s
+}
+
+// Providers lists the configured providers in configuration order.
+func (s *SocialService) Providers() []SocialProviderInfo {
+	providers := make([]SocialProviderInfo, 0, len(s.order))
+	for _, name := range s.order {
+		providers = append(providers, SocialProviderInfo{Name: name, DisplayName: s.providers[name].DisplayName})
+	}
+	return providers
+}
+
+// AuthorizationURL starts a login at the provider. The returned state must
+// be kept by the frontend and compared with the one the provider sends back.
+func (s *SocialService) AuthorizationURL(ctx context.Context, providerName string) (string, string, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", "", errors.ErrSocialProviderNotFound
+	}
+
+	state, err := generateToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := generateToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	record := &model.SocialAuthState{
+		StateHash:    hashToken(state),
+		Provider:     provider.Name,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(socialStateTTL),
+	}
+	if err := s.stateRepo.Create(ctx, record); err != nil {
+		return "", "", fmt.Errorf("failed to create social login state: %w", err)
+	}
+
+	challenge := sha256.Sum256([]byte(verifier))
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {provider.ClientID},
+		"redirect_uri":          {provider.RedirectURL},
+		"scope":                 {strings.Join(provider.Scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {pkceMethodS256},
+	}
+	return appendQuery(provider.AuthURL, params), state, nil
+}
+
+// Authenticate consumes the state, exchanges the code and fetches the
+// profile. Every failure at the provider is reported as ErrSocialLoginFailed.
+func (s *SocialService) Authenticate(ctx context.Context, providerName, code, state string) (*SocialProfile, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, errors.ErrSocialProviderNotFound
+	}
+	if code == "" || state == "" {
+		return nil, errors.ErrSocialLoginFailed
+	}
+
+	record, err := s.stateRepo.GetByStateHash(ctx, hashToken(state))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get social login state: %w", err)
+	}
+	if record == nil || record.Provider != provider.Name || record.UsedAt != nil || time.Now().After(record.ExpiresAt) {
+		return nil, errors.ErrSocialLoginFailed
+	}
+	used, err := s.stateRepo.MarkAsUsed(ctx, record.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume social login state: %w", err)
+	}
+	if !used {
+		return nil, errors.ErrSocialLoginFailed
+	}
+
+	accessToken, err := s.exchangeCode(ctx, provider, code, record.CodeVerifier)
+	if err != nil {
+		log.Warn().Err(err).Str("provider", provider.Name).Msg("Social login code exchange failed")
+		return nil, errors.ErrSocialLoginFailed
+	}
+
+	claims, err := s.fetchUserInfo(ctx, provider, accessToken)
+	if err != nil {
+		log.Warn().Err(err).Str("provider", provider.Name).Msg("Failed to fetch social login profile")
+		return nil, errors.ErrSocialLoginFailed
+	}
+
+	profile := &SocialProfile{
+		Provider: provider.Name,
+		Subject:  claimString(claims, provider.SubjectClaim),
+		Email:    strings.ToLower(strings.TrimSpace(claimString(claims, provider.EmailClaim))),
+		Name:     claimString(claims, provider.NameClaim),
+	}
+	if profile.Subject == "" {
+		log.Warn().Str("provider", provider.Name).Str("claim", provider.SubjectClaim).Msg("Social login profile has no subject")
+		return nil, errors.ErrSocialLoginFailed
+	}
+	if profile.Email != "" {
+		profile.EmailVerified = provider.TrustEmail || claimBool(claims, provider.EmailVerifiedClaim)
+	}
+
+	return profile, nil
+}
+
+// ResolveUser finds the user for a profile: an already linked account, or an
+// active user with the same email if the provider verified it, which is then
+// linked. ErrUserNotFound means a new account may be created for the profile.
+func (s *SocialService) ResolveUser(ctx context.Context, profile *SocialProfile, ipAddress, userAgent string) (uuid.UUID, error) {
+	identity, err := s.identityRepo.GetByProviderSubject(ctx, profile.Provider, profile.Subject)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to get identity: %w", err)
+	}
+	if identity != nil {
+		if err := s.identityRepo.UpdateLogin(ctx, identity.ID, optionalString(profile.Email)); err != nil {
+			log.Error().Err(err).Str("identity_id", identity.ID.String()).Msg("Failed to update identity login")
+		}
+		return identity.UserID, nil
+	}
+
+	// Linking or creating an account by email requires proof of ownership
+	if profile.Email == "" || !profile.EmailVerified {
+		return uuid.Nil, errors.ErrSocialEmailNotVerified
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, profile.Email)
+	if err != nil {
+		if stdErrors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, errors.ErrUserNotFound
+		}
+		return uuid.Nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	// An unverified account may have been registered by someone else to
+	// take over the address; its owner has to verify or reset it first.
+	if !user.IsActive {
+		return uuid.Nil, errors.ErrEmailAlreadyUsed
+	}
+
+	if err := s.Link(ctx, user.ID, profile, ipAddress, userAgent); err != nil {
+		return uuid.Nil, err
+	}
+	return user.ID, nil
+}
+
+// Link stores the provider account for the user.
+func (s *SocialService) Link(ctx context.Context, userID uuid.UUID, profile *SocialProfile, ipAddress, userAgent string) error {
+	now := time.Now()
+	identity := &model.UserIdentity{
+		UserID:      userID,
+		Provider:    profile.Provider,
+		Subject:     profile.Subject,
+		Email:       optionalString(profile.Email),
+		LastLoginAt: &now,
+	}
+	if err := s.identityRepo.Create(ctx, identity); err != nil {
+		return fmt.Errorf("failed to link identity: %w", err)
+	}
+
+	s.audit(ctx, &userID, model.EventIdentityLinked, map[string]interface{}{
+		"identity_id": identity.ID.String(),
+		"provider":    identity.Provider,
+	}, ipAddress, userAgent)
+
+	return nil
+}
+
+func (s *SocialService) ListIdentities(ctx context.Context, userID uuid.UUID) ([]*model.UserIdentity, error) {
+	return s.identityRepo.GetByUserID(ctx, userID)
+}
+
+// Unlink removes a linked provider account. The last one cannot be removed
+// from an account without a password, or the user could not sign in again.
+func (s *SocialService) Unlink(ctx context.Context, userID, identityID uuid.UUID, ipAddress, userAgent string) error {
+	identities, err := s.identityRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get identities: %w", err)
+	}
+
+	var target *model.UserIdentity
+	for _, identity := range identities {
+		if identity.ID == identityID {
+			target = identity
+		}
+	}
+	if target == nil {
+		return errors.ErrIdentityNotFound
+	}
+
+	if len(identities) == 1 {
+		user, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if user.PasswordHash == "" {
+			return errors.ErrLastLoginMethod
+		}
+	}
+
+	deleted, err := s.identityRepo.Delete(ctx, identityID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to unlink identity: %w", err)
+	}
+	if !deleted {
+		return errors.ErrIdentityNotFound
+	}
+
+	s.audit(ctx, &userID, model.EventIdentityUnlinked, map[string]interface{}{
+		"identity_id": identityID.String(),
+		"provider":    target.Provider,
+	}, ipAddress, userAgent)
+
+	return nil
+}
+
+func (s *SocialService) exchangeCode(ctx context.Context, provider config.SocialProvider, code, verifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {provider.RedirectURL},
+		"client_id":     {provider.ClientID},
+		"code_verifier": {verifier},
+	}
+	if provider.ClientSecret != "" {
+		form.Set("client_secret", provider.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, provider.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// GitHub answers with a form-encoded body unless JSON is requested
+	req.Header.Set("Accept", "application/json")
+
+	var tokenResponse struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	if err := s.doJSON(req, &tokenResponse); err != nil {
+		return "", err
+	}
+	if tokenResponse.Error != "" {
+		return "", fmt.Errorf("token endpoint returned %s", tokenResponse.Error)
+	}
+	if tokenResponse.AccessToken == "" {
+		return "", fmt.Errorf("token endpoint returned no access token")
+	}
+	return tokenResponse.AccessToken, nil
+}
+
+func (s *SocialService) fetchUserInfo(ctx context.Context, provider config.SocialProvider, accessToken string) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, provider.UserInfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	var claims map[string]interface{}
+	if err := s.doJSON(req, &claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (s *SocialService) doJSON(req *http.Request, v interface{}) error {
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, socialMaxResponseBytes))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", req.URL.Host, resp.StatusCode)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+func (s *SocialService) audit(ctx context.Context, userID *uuid.UUID, eventType model.AuditEventType, data map[string]interface{}, ipAddress, userAgent string) {
+	if s.auditService == nil {
+		return
+	}
+	if err := s.auditService.Log(ctx, userID, eventType, data, ipAddress, userAgent); err != nil {
+		log.Error().Err(err).Str("event", string(eventType)).Msg("Failed to log social login audit event")
+	}
+}
+
+// claimString reads a string claim. Numeric ids (GitHub) are converted.
+func claimString(claims map[string]interface{}, name string) string {
+	switch v := claims[name].(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	default:
+		return ""
+	}
+}
+
+// claimBool reads a boolean claim. Some providers send "true" as a string.
+func claimBool(claims map[string]interface{}, name string) bool {
+	if name == "" {
+		return false
+	}
+	switch v := claims[name].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	default:
+		return false
+	}
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
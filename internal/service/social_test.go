@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ZenoN-Cloud/zeno-auth/internal/config"
+	appErrors "github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+)
+
+type MockUserIdentityRepo struct {
+	mock.Mock
+}
+
+func (m *MockUserIdentityRepo) Create(ctx context.Context, identity *model.UserIdentity) error {
+	args := m.Called(ctx, identity)
+	return args.Error(0)
+}
+
+func (m *MockUserIdentityRepo) GetByProviderSubject(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	args := m.Called(ctx, provider, subject)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.UserIdentity), args.Error(1)
+}
+
+func (m *MockUserIdentityRepo) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*model.UserIdentity, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]*model.UserIdentity), args.Error(1)
+}
+
+func (m *MockUserIdentityRepo) UpdateLogin(ctx context.Context, id uuid.UUID, email *string) error {
+	args := m.Called(ctx, id, email)
+	return args.Error(0)
+}
+
+func (m *MockUserIdentityRepo) Delete(ctx context.Context, id, userID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, id, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+// memorySocialStateRepo keeps states in memory so the whole redirect flow can
+// run against the mock identity provider.
+type memorySocialStateRepo struct {
+	states map[string]*model.SocialAuthState
+}
+
+func (r *memorySocialStateRepo) Create(_ context.Context, state *model.SocialAuthState) error {
+	state.ID = uuid.New()
+	r.states[state.StateHash] = state
+	return nil
+}
+
+func (r *memorySocialStateRepo) GetByStateHash(_ context.Context, stateHash string) (*model.SocialAuthState, error) {
+	return r.states[stateHash], nil
+}
+
+func (r *memorySocialStateRepo) MarkAsUsed(_ context.Context, id uuid.UUID) (bool, error) {
+	for _, state := range r.states {
+		if state.ID == id && state.UsedAt == nil {
+			now := time.Now()
+			state.UsedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memorySocialStateRepo) DeleteExpired(context.Context) error {
+	return nil
+}
+
+// mockIdP is a minimal OAuth 2.0 provider issuing one code for one profile.
+func mockIdP(t *testing.T, profile map[string]interface{}) (*httptest.Server, *string) {
+	var challenge string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if r.PostForm.Get("code") != "idp-code" || r.PostForm.Get("client_secret") != "idp-secret" ||
+			base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "idp-access-token", "token_type": "Bearer"})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer idp-access-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(profile)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &challenge
+}
+
+func newTestSocialService(t *testing.T, serverURL string, identityRepo *MockUserIdentityRepo, userRepo *MockUserRepo, provider config.SocialProvider) *SocialService {
+	provider.Name = "mock"
+	provider.ClientID = "idp-client"
+	provider.ClientSecret = "idp-secret"
+	provider.AuthURL = serverURL + "/authorize"
+	provider.TokenURL = serverURL + "/token"
+	provider.UserInfoURL = serverURL + "/userinfo"
+	provider.RedirectURL = "https://app.zeno.test/auth/social/mock/callback"
+	provider.Scopes = []string{"openid", "email"}
+	if provider.SubjectClaim == "" {
+		provider.SubjectClaim = "sub"
+	}
+	provider.EmailClaim = "email"
+	if provider.EmailVerifiedClaim == "" && !provider.TrustEmail {
+		provider.EmailVerifiedClaim = "email_verified"
+	}
+	provider.NameClaim = "name"
+
+	stateRepo := &memorySocialStateRepo{states: map[string]*model.SocialAuthState{}}
+	return NewSocialService([]config.SocialProvider{provider}, identityRepo, stateRepo, userRepo, nil)
+}
+
+func startSocialLogin(t *testing.T, svc *SocialService, challenge *string) string {
+	authURL, state, err := svc.AuthorizationURL(context.Background(), "mock")
+	require.NoError(t, err)
+
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "idp-client", u.Query().Get("client_id"))
+	assert.Equal(t, state, u.Query().Get("state"))
+	assert.Equal(t, "S256", u.Query().Get("code_challenge_method"))
+	*challenge = u.Query().Get("code_challenge")
+	return state
+}
+
+func TestSocialService_Authenticate(t *testing.T) {
+	server, challenge := mockIdP(t, map[string]interface{}{
+		"sub": "idp-user-1", "email": "User@Zeno.test", "email_verified": true, "name": "Test User",
+	})
+	svc := newTestSocialService(t, server.URL, new(MockUserIdentityRepo), new(MockUserRepo), config.SocialProvider{})
+	ctx := context.Background()
+
+	state := startSocialLogin(t, svc, challenge)
+	profile, err := svc.Authenticate(ctx, "mock", "idp-code", state)
+	require.NoError(t, err)
+	assert.Equal(t, &SocialProfile{
+		Provider: "mock", Subject: "idp-user-1", Email: "user@zeno.test", EmailVerified: true, Name: "Test User",
+	}, profile)
+
+	// States are single-use
+	_, err = svc.Authenticate(ctx, "mock", "idp-code", state)
+	assert.ErrorIs(t, err, appErrors.ErrSocialLoginFailed)
+
+	t.Run("unknown state", func(t *testing.T) {
+		_, err := svc.Authenticate(ctx, "mock", "idp-code", "forged")
+		assert.ErrorIs(t, err, appErrors.ErrSocialLoginFailed)
+	})
+
+	t.Run("code rejected by provider", func(t *testing.T) {
+		state := startSocialLogin(t, svc, challenge)
+		_, err := svc.Authenticate(ctx, "mock", "stolen-code", state)
+		assert.ErrorIs(t, err, appErrors.ErrSocialLoginFailed)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		_, _, err := svc.AuthorizationURL(ctx, "myspace")
+		assert.ErrorIs(t, err, appErrors.ErrSocialProviderNotFound)
+	})
+}
+
+func TestSocialService_AuthenticateCustomClaims(t *testing.T) {
+	// GitHub-like profile: numeric id and no email_verified claim
+	server, challenge := mockIdP(t, map[string]interface{}{"id": 583231, "email": "octocat@zeno.test", "login": "octocat"})
+	svc := newTestSocialService(t, server.URL, new(MockUserIdentityRepo), new(MockUserRepo), config.SocialProvider{
+		SubjectClaim: "id",
+		TrustEmail:   true,
+	})
+
+	state := startSocialLogin(t, svc, challenge)
+	profile, err := svc.Authenticate(context.Background(), "mock", "idp-code", state)
+	require.NoError(t, err)
+	assert.Equal(t, "583231", profile.Subject)
+	assert.True(t, profile.EmailVerified)
+}
+
+func TestSocialService_ResolveUser(t *testing.T) {
+	ctx := context.Background()
+	profile := &SocialProfile{Provider: "mock", Subject: "idp-user-1", Email: "user@zeno.test", EmailVerified: true}
+
+	t.Run("linked identity", func(t *testing.T) {
+		identityRepo := new(MockUserIdentityRepo)
+		svc := NewSocialService(nil, identityRepo, nil, new(MockUserRepo), nil)
+		identity := &model.UserIdentity{ID: uuid.New(), UserID: uuid.New()}
+		identityRepo.On("GetByProviderSubject", ctx, "mock", "idp-user-1").Return(identity, nil)
+		identityRepo.On("UpdateLogin", ctx, identity.ID, mock.Anything).Return(nil)
+
+		userID, err := svc.ResolveUser(ctx, profile, "127.0.0.1", "test-agent")
+		require.NoError(t, err)
+		assert.Equal(t, identity.UserID, userID)
+	})
+
+	t.Run("links by verified email", func(t *testing.T) {
+		identityRepo := new(MockUserIdentityRepo)
+		userRepo := new(MockUserRepo)
+		svc := NewSocialService(nil, identityRepo, nil, userRepo, nil)
+		user := &model.User{ID: uuid.New(), Email: profile.Email, IsActive: true}
+		identityRepo.On("GetByProviderSubject", ctx, "mock", "idp-user-1").Return(nil, nil)
+		userRepo.On("GetByEmail", ctx, profile.Email).Return(user, nil)
+		identityRepo.On("Create", ctx, mock.MatchedBy(func(identity *model.UserIdentity) bool {
+			return identity.UserID == user.ID && identity.Subject == "idp-user-1"
+		})).Return(nil)
+
+		userID, err := svc.ResolveUser(ctx, profile, "127.0.0.1", "test-agent")
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, userID)
+		identityRepo.AssertExpectations(t)
+	})
+
+	t.Run("unverified email is not linked", func(t *testing.T) {
+		identityRepo := new(MockUserIdentityRepo)
+		svc := NewSocialService(nil, identityRepo, nil, new(MockUserRepo), nil)
+		identityRepo.On("GetByProviderSubject", ctx, "mock", "idp-user-2").Return(nil, nil)
+
+		_, err := svc.ResolveUser(ctx, &SocialProfile{Provider: "mock", Subject: "idp-user-2", Email: "user@zeno.test"}, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrSocialEmailNotVerified)
+		identityRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unverified account is not linked", func(t *testing.T) {
+		identityRepo := new(MockUserIdentityRepo)
+		userRepo := new(MockUserRepo)
+		svc := NewSocialService(nil, identityRepo, nil, userRepo, nil)
+		identityRepo.On("GetByProviderSubject", ctx, "mock", "idp-user-1").Return(nil, nil)
+		userRepo.On("GetByEmail", ctx, profile.Email).Return(&model.User{ID: uuid.New(), IsActive: false}, nil)
+
+		_, err := svc.ResolveUser(ctx, profile, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrEmailAlreadyUsed)
+		identityRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("new user", func(t *testing.T) {
+		identityRepo := new(MockUserIdentityRepo)
+		userRepo := new(MockUserRepo)
+		svc := NewSocialService(nil, identityRepo, nil, userRepo, nil)
+		identityRepo.On("GetByProviderSubject", ctx, "mock", "idp-user-1").Return(nil, nil)
+		userRepo.On("GetByEmail", ctx, profile.Email).Return(nil, pgx.ErrNoRows)
+
+		_, err := svc.ResolveUser(ctx, profile, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrUserNotFound)
+	})
+}
+
+func TestSocialService_Unlink(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	identity := &model.UserIdentity{ID: uuid.New(), UserID: userID, Provider: "mock"}
+
+	t.Run("last login method", func(t *testing.T) {
+		identityRepo := new(MockUserIdentityRepo)
+		userRepo := new(MockUserRepo)
+		svc := NewSocialService(nil, identityRepo, nil, userRepo, nil)
+		identityRepo.On("GetByUserID", ctx, userID).Return([]*model.UserIdentity{identity}, nil)
+		userRepo.On("GetByID", ctx, userID).Return(&model.User{ID: userID}, nil)
+
+		err := svc.Unlink(ctx, userID, identity.ID, "", "")
+		assert.ErrorIs(t, err, appErrors.ErrLastLoginMethod)
+		identityRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("user with password", func(t *testing.T) {
+		identityRepo := new(MockUserIdentityRepo)
+		userRepo := new(MockUserRepo)
+		svc := NewSocialService(nil, identityRepo, nil, userRepo, nil)
+		identityRepo.On("GetByUserID", ctx, userID).Return([]*model.UserIdentity{identity}, nil)
+		userRepo.On("GetByID", ctx, userID).Return(&model.User{ID: userID, PasswordHash: "hash"}, nil)
+		identityRepo.On("Delete", ctx, identity.ID, userID).Return(true, nil)
+
+		require.NoError(t, svc.Unlink(ctx, userID, identity.ID, "", ""))
+	})
+
+	t.Run("identity of another user", func(t *testing.T) {
+		identityRepo := new(MockUserIdentityRepo)
+		svc := NewSocialService(nil, identityRepo, nil, new(MockUserRepo), nil)
+		identityRepo.On("GetByUserID", ctx, userID).Return([]*model.UserIdentity{identity}, nil)
+
+		err := svc.Unlink(ctx, userID, uuid.New(), "", "")
+		assert.ErrorIs(t, err, appErrors.ErrIdentityNotFound)
+	})
+}
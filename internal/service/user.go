@@ -2,25 +2,39 @@ package service
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
 
+	"github.com/ZenoN-Cloud/zeno-auth/internal/errors"
 	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
 	"github.com/ZenoN-Cloud/zeno-auth/internal/repository"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/validator"
 )
 
+// ProfileUpdate holds the profile fields to change; nil fields are kept.
+type ProfileUpdate struct {
+	FullName *string
+	Locale   *string
+	Timezone *string
+}
+
 type UserService struct {
 	userRepo       repository.UserRepository
 	membershipRepo repository.MembershipRepository
+	auditService   *AuditService
 }
 
 func NewUserService(
 	userRepo repository.UserRepository,
 	membershipRepo repository.MembershipRepository,
+	auditService *AuditService,
 ) *UserService {
 	return &UserService{
 		userRepo:       userRepo,
 		membershipRepo: membershipRepo,
+		auditService:   auditService,
 	}
 }
 
@@ -28,6 +42,71 @@ func (s *UserService) GetProfile(ctx context.Context, userID uuid.UUID) (*model.
 	return s.userRepo.GetByID(ctx, userID)
 }
 
+// UpdateProfile changes the name and preferences of the user. It is also the
+// GDPR Art. 16 rectification path, so every change is audited with the
+// names of the fields changed.
+func (s *UserService) UpdateProfile(ctx context.Context, userID uuid.UUID, update ProfileUpdate, ipAddress, userAgent string) (*model.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	inputValidator := validator.NewInputValidator()
+	var changed []string
+
+	if update.FullName != nil {
+		if err := inputValidator.ValidateName(*update.FullName); err != nil {
+			return nil, err
+		}
+		fullName := inputValidator.SanitizeName(*update.FullName)
+		if fullName == "" {
+			return nil, errors.ErrInvalidInput
+		}
+		if fullName != user.FullName {
+			user.FullName = fullName
+			changed = append(changed, "full_name")
+		}
+	}
+
+	if update.Locale != nil {
+		locale := inputValidator.NormalizeLocale(*update.Locale)
+		if err := inputValidator.ValidateLocale(locale); err != nil {
+			return nil, err
+		}
+		if locale != user.Locale {
+			user.Locale = locale
+			changed = append(changed, "locale")
+		}
+	}
+
+	if update.Timezone != nil {
+		if err := inputValidator.ValidateTimezone(*update.Timezone); err != nil {
+			return nil, err
+		}
+		if *update.Timezone != user.Timezone {
+			user.Timezone = *update.Timezone
+			changed = append(changed, "timezone")
+		}
+	}
+
+	if len(changed) == 0 {
+		return user, nil
+	}
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update profile: %w", err)
+	}
+
+	if s.auditService != nil {
+		if err := s.auditService.Log(ctx, &user.ID, model.EventProfileUpdated, map[string]interface{}{
+			"fields": changed,
+		}, ipAddress, userAgent); err != nil {
+			log.Error().Err(err).Str("user_id", user.ID.String()).Msg("Failed to log profile update")
+		}
+	}
+	return user, nil
+}
+
 func (s *UserService) GetMemberships(ctx context.Context, userID uuid.UUID) ([]*model.OrgMembership, error) {
 	return s.membershipRepo.GetByUserID(ctx, userID)
 }
@@ -4,8 +4,16 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	appErrors "github.com/ZenoN-Cloud/zeno-auth/internal/errors"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/model"
+	"github.com/ZenoN-Cloud/zeno-auth/internal/validator"
 )
 
 func TestUserService_ValidateEmail(t *testing.T) {
@@ -69,3 +77,76 @@ func TestUserService_Context(t *testing.T) {
 		}
 	})
 }
+
+func TestUserService_UpdateProfile(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	stored := func() *model.User {
+		return &model.User{ID: userID, Email: "jane@example.com", FullName: "Jane Doe", Locale: model.DefaultLocale, Timezone: model.DefaultTimezone}
+	}
+	ptr := func(s string) *string { return &s }
+
+	t.Run("updates given fields only", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		userRepo.On("GetByID", ctx, userID).Return(stored(), nil)
+		userRepo.On("Update", ctx, mock.MatchedBy(func(u *model.User) bool {
+			return u.FullName == "Jane Smith" && u.Locale == "de-DE" && u.Timezone == model.DefaultTimezone
+		})).Return(nil)
+
+		user, err := NewUserService(userRepo, nil, nil).UpdateProfile(ctx, userID, ProfileUpdate{
+			FullName: ptr(" Jane Smith "),
+			Locale:   ptr("de_de"),
+		}, "", "")
+		require.NoError(t, err)
+		assert.Equal(t, "Jane Smith", user.FullName)
+		assert.Equal(t, "de-DE", user.Locale)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("skips the write without changes", func(t *testing.T) {
+		userRepo := new(MockUserRepo)
+		userRepo.On("GetByID", ctx, userID).Return(stored(), nil)
+
+		_, err := NewUserService(userRepo, nil, nil).UpdateProfile(ctx, userID, ProfileUpdate{Timezone: ptr("UTC")}, "", "")
+		require.NoError(t, err)
+		userRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		tests := []struct {
+			update  ProfileUpdate
+			wantErr error
+		}{
+			{ProfileUpdate{FullName: ptr("  ")}, appErrors.ErrInvalidInput},
+			{ProfileUpdate{FullName: ptr("<script>")}, validator.ErrNameInvalidChars},
+			{ProfileUpdate{Locale: ptr("english")}, validator.ErrInvalidLocale},
+			{ProfileUpdate{Timezone: ptr("Mars/Olympus")}, validator.ErrInvalidTimezone},
+		}
+		for _, tt := range tests {
+			userRepo := new(MockUserRepo)
+			userRepo.On("GetByID", ctx, userID).Return(stored(), nil)
+
+			_, err := NewUserService(userRepo, nil, nil).UpdateProfile(ctx, userID, tt.update, "", "")
+			assert.ErrorIs(t, err, tt.wantErr)
+			userRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestFormatTimeForUser(t *testing.T) {
+	at := time.Date(2026, 3, 9, 14, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		locale, timezone, expected string
+	}{
+		{"en", "UTC", "9 Mar 2026 14:30 UTC"},
+		{"en-US", "America/New_York", "Mar 9, 2026 10:30 AM EDT"},
+		{"de-DE", "Europe/Berlin", "09.03.2026 15:30 CET"},
+		{"ja", "Asia/Tokyo", "2026-03-09 23:30 JST"},
+		{"", "", "2026-03-09 14:30 UTC"},
+	}
+	for _, tt := range tests {
+		user := &model.User{Locale: tt.locale, Timezone: tt.timezone}
+		assert.Equal(t, tt.expected, formatTimeForUser(at, user), tt.locale)
+	}
+}
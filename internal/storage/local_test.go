@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStorage_PutOpenDelete(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	size, err := s.Put(ctx, "export.zip", strings.NewReader("archive"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), size)
+
+	r, err := s.Open(ctx, "export.zip")
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, "archive", string(data))
+
+	require.NoError(t, s.Delete(ctx, "export.zip"))
+	_, err = s.Open(ctx, "export.zip")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// Deleting twice is fine
+	assert.NoError(t, s.Delete(ctx, "export.zip"))
+}
+
+func TestLocalStorage_RejectsKeysOutsideDirectory(t *testing.T) {
+	ctx := context.Background()
+	s, err := NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "../export.zip", "nested/export.zip", "/etc/passwd", ".", ".."} {
+		_, err := s.Put(ctx, key, strings.NewReader("archive"))
+		assert.ErrorIs(t, err, ErrInvalidKey, key)
+		_, err = s.Open(ctx, key)
+		assert.ErrorIs(t, err, ErrInvalidKey, key)
+	}
+}
+
+func TestNew_UnknownBackend(t *testing.T) {
+	_, err := New("s3", t.TempDir())
+	assert.Error(t, err)
+}
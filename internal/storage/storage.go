@@ -0,0 +1,36 @@
+// Package storage keeps files produced by the service, such as personal data
+// export archives, outside the database.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrNotFound   = errors.New("storage: object not found")
+	ErrInvalidKey = errors.New("storage: invalid key")
+)
+
+// Storage stores objects under flat keys. Put replaces an existing object and
+// returns the number of bytes written; Open returns ErrNotFound for keys that
+// were never written or have been deleted. Deleting a missing key is not an
+// error.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New returns the storage for the backend name, as configured by
+// DATA_EXPORT_STORAGE_BACKEND. The local filesystem is the default.
+func New(backend, dir string) (Storage, error) {
+	switch backend {
+	case "", "local":
+		return NewLocalStorage(dir)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
+}
@@ -2,6 +2,9 @@ package token
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"encoding/base64"
 	"errors"
 	"math/big"
@@ -12,12 +15,18 @@ var (
 	ErrNilBigInt       = errors.New("big.Int cannot be nil")
 )
 
+// JWK is a public key in RFC 7517 form. RSA keys use N/E, EC keys Crv/X/Y and
+// OKP (Ed25519) keys Crv/X.
 type JWK struct {
 	Kty string `json:"kty"`
 	Use string `json:"use"`
 	Kid string `json:"kid"`
-	N   string `json:"n"`
-	E   string `json:"e"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
 }
 
 type JWKS struct {
@@ -31,21 +40,51 @@ func (j *JWTManager) GetJWKS(ctx context.Context) (*JWKS, error) {
 	default:
 	}
 
-	if j.publicKey == nil || j.publicKey.N == nil {
-		return nil, ErrPublicKeyNotSet
+	// Retired keys stay published until the tokens they signed have expired
+	keys := j.verificationKeys()
+	jwks := &JWKS{Keys: make([]JWK, 0, len(keys))}
+	for _, key := range keys {
+		jwk, err := j.toJWK(key)
+		if err != nil {
+			return nil, err
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
 	}
 
-	return &JWKS{
-		Keys: []JWK{
-			{
-				Kty: "RSA",
-				Use: "sig",
-				Kid: "2024-01", // Match the kid in JWT header
-				N:   j.encodeBase64BigInt(j.publicKey.N),
-				E:   j.encodeBase64BigInt(big.NewInt(int64(j.publicKey.E))),
-			},
-		},
-	}, nil
+	return jwks, nil
+}
+
+func (j *JWTManager) toJWK(key *signingKey) (JWK, error) {
+	jwk := JWK{Use: "sig", Kid: key.kid, Alg: key.method.Alg()}
+
+	switch pub := key.publicKey.(type) {
+	case *rsa.PublicKey:
+		if pub.N == nil {
+			return JWK{}, ErrPublicKeyNotSet
+		}
+		jwk.Kty = "RSA"
+		jwk.N = j.encodeBase64BigInt(pub.N)
+		jwk.E = j.encodeBase64BigInt(big.NewInt(int64(pub.E)))
+	case *ecdsa.PublicKey:
+		// Uncompressed point: 0x04 | X | Y, coordinates padded to the curve size
+		point, err := pub.Bytes()
+		if err != nil {
+			return JWK{}, err
+		}
+		size := (len(point) - 1) / 2
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(point[1 : 1+size])
+		jwk.Y = base64.RawURLEncoding.EncodeToString(point[1+size:])
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	default:
+		return JWK{}, ErrPublicKeyNotSet
+	}
+
+	return jwk, nil
 }
 
 func (j *JWTManager) encodeBase64BigInt(n *big.Int) string {
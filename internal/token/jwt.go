@@ -2,56 +2,353 @@ package token
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+// Claims of an access token. Tokens issued to machine clients through the
+// client_credentials grant carry client_id and no user_id.
 type Claims struct {
-	UserID             uuid.UUID `json:"user_id"`
+	UserID             uuid.UUID `json:"user_id,omitzero"`
 	OrgID              uuid.UUID `json:"org_id"`
 	Roles              []string  `json:"roles"`
+	Permissions        []string  `json:"permissions,omitempty"`
 	OrgStatus          string    `json:"org_status"`
 	SubscriptionStatus string    `json:"subscription_status,omitempty"`
 	TrialEndsAt        *int64    `json:"trial_ends_at,omitempty"`
+	SessionID          string    `json:"sid,omitempty"`
+	AMR                []string  `json:"amr,omitempty"`
+	ClientID           string    `json:"client_id,omitempty"`
+	Scope              string    `json:"scope,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// HasPermission reports whether the permissions claim contains permission.
+func (c *Claims) HasPermission(permission string) bool {
+	return slices.Contains(c.Permissions, permission)
+}
+
+// HasMFA reports whether the session was authenticated with more than one
+// factor.
+func (c *Claims) HasMFA() bool {
+	return slices.Contains(c.AMR, AMRMultiFactor)
+}
+
+// HasScope reports whether the space-separated scope claim contains scope.
+func (c *Claims) HasScope(scope string) bool {
+	for _, s := range strings.Fields(c.Scope) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IDTokenClaims are the claims of an OpenID Connect ID token. The caller sets
+// issuer, subject, audience and lifetime; SignIDToken only signs them.
+type IDTokenClaims struct {
+	Nonce         string `json:"nonce,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+	Name          string `json:"name,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Authentication methods of the amr claim (RFC 8176). A session keeps the
+// methods it was started with across refreshes.
+const (
+	AMRPassword    = "pwd"
+	AMROTP         = "otp"
+	AMRHardwareKey = "hwk"
+	AMRFederated   = "fed"
+	AMRMultiFactor = "mfa"
+	// AMREmail is not registered in RFC 8176; it marks sign-in with a link
+	// sent by email.
+	AMREmail = "email"
+)
+
+// defaultAudience is the audience of first-party access tokens accepted by
+// Validate.
+var defaultAudience = []string{"zeno-frontend", "zeno-api"}
+
+// InternalAudience is the audience of machine client tokens accepted by the
+// internal API.
+const InternalAudience = "zeno-internal"
+
+// AccessTokenParams describes the access token issued by GenerateAccessToken.
+// SessionID ties the token to its refresh token family so it can be revoked
+// together with the session.
+type AccessTokenParams struct {
+	UserID             uuid.UUID
+	OrgID              uuid.UUID
+	SessionID          uuid.UUID
+	Roles              []string
+	Permissions        []string
+	OrgStatus          string
+	SubscriptionStatus string
+	TrialEndsAt        *int64
+	AMR                []string
+	TTLSeconds         int
+	// ClientID and Scope are set for tokens issued to OAuth clients, which
+	// also use their own Audience instead of the first-party one. Machine
+	// client tokens have no UserID; their subject is the ClientID.
+	ClientID string
+	Scope    string
+	Audience []string
+}
+
+// DefaultKeyID is the kid of the key configured via JWT_PRIVATE_KEY. It
+// matches the kid of every token issued before key rotation was introduced.
+const DefaultKeyID = "2024-01"
+
+var supportedSigningAlgs = []string{"RS256", "ES256", "EdDSA"}
+
+var (
+	ErrNoActiveKey    = errors.New("no active signing key")
+	ErrDuplicateKey   = errors.New("duplicate key id")
+	ErrUnknownKeyID   = errors.New("unknown key id")
+	ErrKeyNotSigning  = errors.New("active key has no private key")
+	ErrUnsupportedKey = errors.New("unsupported key type: use RSA, ECDSA P-256 or Ed25519")
+	ErrKeyMismatch    = errors.New("public key does not match private key type")
+)
+
+// KeyConfig describes one key of the signing keyring. Retired keys no longer
+// sign tokens but stay available for verification (and are published in the
+// JWKS) until every token they signed has expired.
+type KeyConfig struct {
+	KID           string
+	PrivateKeyPEM string
+	PublicKeyPEM  string
+	RetiredAt     *time.Time
+}
+
+type signingKey struct {
+	kid        string
+	method     jwt.SigningMethod
+	privateKey crypto.Signer
+	publicKey  crypto.PublicKey
+	retiredAt  *time.Time
+}
+
 type JWTManager struct {
-	privateKey *rsa.PrivateKey
-	publicKey  *rsa.PublicKey
+	keys      []*signingKey // active key first
+	active    *signingKey
+	maxTTL    time.Duration
+	clockFunc func() time.Time
 }
 
+// NewJWTManager creates a manager with a single key using DefaultKeyID.
 func NewJWTManager(privateKeyPEM, publicKeyPEM string) (*JWTManager, error) {
-	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(privateKeyPEM))
-	if err != nil {
-		return nil, err
+	return NewJWTManagerWithKeys([]KeyConfig{{
+		KID:           DefaultKeyID,
+		PrivateKeyPEM: privateKeyPEM,
+		PublicKeyPEM:  publicKeyPEM,
+	}}, DefaultKeyID, 0)
+}
+
+// NewJWTManagerWithKeys creates a manager from a keyring. activeKID selects
+// the signing key; maxTokenTTL is how long a retired key is kept after its
+// retirement so that tokens it signed can still be verified.
+func NewJWTManagerWithKeys(configs []KeyConfig, activeKID string, maxTokenTTL time.Duration) (*JWTManager, error) {
+	j := &JWTManager{maxTTL: maxTokenTTL, clockFunc: time.Now}
+
+	seen := make(map[string]bool, len(configs))
+	for _, cfg := range configs {
+		if cfg.KID == "" {
+			return nil, fmt.Errorf("key id is required")
+		}
+		if seen[cfg.KID] {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateKey, cfg.KID)
+		}
+		seen[cfg.KID] = true
+
+		key, err := parseSigningKey(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", cfg.KID, err)
+		}
+
+		if cfg.KID == activeKID {
+			if key.privateKey == nil {
+				return nil, fmt.Errorf("key %s: %w", cfg.KID, ErrKeyNotSigning)
+			}
+			if key.retiredAt != nil {
+				return nil, fmt.Errorf("key %s: active key cannot be retired", cfg.KID)
+			}
+			j.active = key
+			j.keys = append([]*signingKey{key}, j.keys...)
+			continue
+		}
+		j.keys = append(j.keys, key)
+	}
+
+	if j.active == nil {
+		return nil, fmt.Errorf("%w: %q", ErrNoActiveKey, activeKID)
 	}
 
-	var publicKey *rsa.PublicKey
-	if publicKeyPEM != "" {
-		publicKey, err = jwt.ParseRSAPublicKeyFromPEM([]byte(publicKeyPEM))
+	return j, nil
+}
+
+func parseSigningKey(cfg KeyConfig) (*signingKey, error) {
+	key := &signingKey{kid: cfg.KID, retiredAt: cfg.RetiredAt}
+
+	if cfg.PrivateKeyPEM != "" {
+		privateKey, err := parsePrivateKeyPEM(cfg.PrivateKeyPEM)
 		if err != nil {
 			return nil, err
 		}
-	} else {
-		// Extract public key from private key
-		publicKey = &privateKey.PublicKey
+		key.privateKey = privateKey
+		key.publicKey = privateKey.Public()
+	}
+
+	if cfg.PublicKeyPEM != "" {
+		publicKey, err := parsePublicKeyPEM(cfg.PublicKeyPEM)
+		if err != nil {
+			return nil, err
+		}
+		key.publicKey = publicKey
+	}
+
+	if key.publicKey == nil {
+		return nil, ErrPublicKeyNotSet
+	}
+
+	method, err := signingMethodFor(key.publicKey)
+	if err != nil {
+		return nil, err
+	}
+	key.method = method
+
+	if key.privateKey != nil {
+		if _, err := signingMethodFor(key.privateKey.Public()); err != nil || key.method.Alg() != method.Alg() {
+			return nil, ErrKeyMismatch
+		}
+	}
+
+	return key, nil
+}
+
+// signingMethodFor picks the JWS algorithm for a public key: RS256 for RSA,
+// ES256 for P-256 and EdDSA for Ed25519.
+func signingMethodFor(publicKey crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch pub := publicKey.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return nil, ErrUnsupportedKey
+		}
+		return jwt.SigningMethodES256, nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
+
+func parsePrivateKeyPEM(data string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, jwt.ErrKeyMustBePEMEncoded
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, ErrUnsupportedKey
+		}
+		return signer, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, ErrUnsupportedKey
+}
+
+func parsePublicKeyPEM(data string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, jwt.ErrKeyMustBePEMEncoded
+	}
+
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+		return cert.PublicKey, nil
 	}
+	return nil, ErrUnsupportedKey
+}
+
+// verificationKeys returns the keys whose tokens may still be valid: the
+// active key, keys not yet retired and retired keys within maxTTL of retirement.
+func (j *JWTManager) verificationKeys() []*signingKey {
+	now := j.clockFunc()
+	keys := make([]*signingKey, 0, len(j.keys))
+	for _, key := range j.keys {
+		if key.retiredAt != nil && now.After(key.retiredAt.Add(j.maxTTL)) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (j *JWTManager) verificationKey(kid string) (*signingKey, error) {
+	// Tokens without a kid can only have been signed by the active key
+	if kid == "" {
+		return j.active, nil
+	}
+	for _, key := range j.verificationKeys() {
+		if key.kid == kid {
+			return key, nil
+		}
+	}
+	return nil, ErrUnknownKeyID
+}
 
-	return &JWTManager{
-		privateKey: privateKey,
-		publicKey:  publicKey,
-	}, nil
+// ActiveKeyID returns the kid used for newly issued tokens.
+func (j *JWTManager) ActiveKeyID() string {
+	return j.active.kid
 }
 
+// Generate issues a token without organization status claims. Sessions get
+// theirs from AuthService, which fills them from the organization.
 func (j *JWTManager) Generate(ctx context.Context, userID, orgID uuid.UUID, roles []string, ttlSeconds int) (string, error) {
 	return j.GenerateWithOrgStatus(ctx, userID, orgID, roles, "created", "", nil, ttlSeconds)
 }
 
 func (j *JWTManager) GenerateWithOrgStatus(ctx context.Context, userID, orgID uuid.UUID, roles []string, orgStatus, subStatus string, trialEndsAt *int64, ttlSeconds int) (string, error) {
+	return j.GenerateAccessToken(ctx, AccessTokenParams{
+		UserID:             userID,
+		OrgID:              orgID,
+		Roles:              roles,
+		OrgStatus:          orgStatus,
+		SubscriptionStatus: subStatus,
+		TrialEndsAt:        trialEndsAt,
+		TTLSeconds:         ttlSeconds,
+	})
+}
+
+func (j *JWTManager) GenerateAccessToken(ctx context.Context, params AccessTokenParams) (string, error) {
 	select {
 	case <-ctx.Done():
 		return "", ctx.Err()
@@ -59,32 +356,78 @@ func (j *JWTManager) GenerateWithOrgStatus(ctx context.Context, userID, orgID uu
 	}
 
 	now := time.Now()
-	jti := uuid.New().String() // Unique token ID for revocation
+	// Unique token ID for revocation. Version 7 carries the issue time in
+	// milliseconds, which revocations of a user or organization compare with.
+	id, err := uuid.NewV7()
+	if err != nil {
+		return "", err
+	}
+	jti := id.String()
+
+	orgStatus := params.OrgStatus
+	if orgStatus == "" {
+		orgStatus = "created"
+	}
 
 	claims := Claims{
-		UserID:             userID,
-		OrgID:              orgID,
-		Roles:              roles,
+		UserID:             params.UserID,
+		OrgID:              params.OrgID,
+		Roles:              params.Roles,
+		Permissions:        params.Permissions,
 		OrgStatus:          orgStatus,
-		SubscriptionStatus: subStatus,
-		TrialEndsAt:        trialEndsAt,
+		SubscriptionStatus: params.SubscriptionStatus,
+		TrialEndsAt:        params.TrialEndsAt,
+		AMR:                params.AMR,
+		ClientID:           params.ClientID,
+		Scope:              params.Scope,
 		RegisteredClaims: jwt.RegisteredClaims{
-			Subject:   userID.String(),
+			Subject:   params.UserID.String(),
 			ID:        jti,
-			ExpiresAt: jwt.NewNumericDate(now.Add(time.Duration(ttlSeconds) * time.Second)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Duration(params.TTLSeconds) * time.Second)),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
 			Issuer:    "zeno-auth",
-			Audience:  []string{"zeno-frontend", "zeno-api"},
+			Audience:  defaultAudience,
 		},
 	}
+	if params.UserID == uuid.Nil && params.ClientID != "" {
+		claims.Subject = params.ClientID
+	}
+	if params.SessionID != uuid.Nil {
+		claims.SessionID = params.SessionID.String()
+	}
+	if len(params.Audience) > 0 {
+		claims.Audience = params.Audience
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-	token.Header["kid"] = "2024-01" // Key ID for key rotation
-	return token.SignedString(j.privateKey)
+	return j.sign(claims)
 }
 
+// SignIDToken signs OpenID Connect ID token claims with the active key.
+func (j *JWTManager) SignIDToken(ctx context.Context, claims IDTokenClaims) (string, error) {
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	default:
+	}
+
+	return j.sign(claims)
+}
+
+func (j *JWTManager) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(j.active.method, claims)
+	token.Header["kid"] = j.active.kid
+	return token.SignedString(j.active.privateKey)
+}
+
+// Validate checks a first-party access token.
 func (j *JWTManager) Validate(ctx context.Context, tokenString string) (*Claims, error) {
+	return j.ValidateForAudience(ctx, tokenString, defaultAudience...)
+}
+
+// ValidateForAudience checks an access token that must be issued for one of
+// the given audiences.
+func (j *JWTManager) ValidateForAudience(ctx context.Context, tokenString string, audiences ...string) (*Claims, error) {
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
@@ -92,12 +435,17 @@ func (j *JWTManager) Validate(ctx context.Context, tokenString string) (*Claims,
 	}
 
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, err := j.verificationKey(kid)
+		if err != nil {
+			return nil, err
+		}
+		// The algorithm is fixed by the key, never chosen by the token
+		if token.Method.Alg() != key.method.Alg() {
 			return nil, jwt.ErrSignatureInvalid
 		}
-		return j.publicKey, nil
-	}, jwt.WithValidMethods([]string{"RS256"}))
+		return key.publicKey, nil
+	}, jwt.WithValidMethods(supportedSigningAlgs))
 
 	if err != nil {
 		return nil, err
@@ -110,7 +458,7 @@ func (j *JWTManager) Validate(ctx context.Context, tokenString string) (*Claims,
 		}
 		validAudience := false
 		for _, aud := range claims.Audience {
-			if aud == "zeno-frontend" || aud == "zeno-api" {
+			if slices.Contains(audiences, aud) {
 				validAudience = true
 				break
 			}
@@ -124,6 +472,12 @@ func (j *JWTManager) Validate(ctx context.Context, tokenString string) (*Claims,
 	return nil, jwt.ErrTokenInvalidClaims
 }
 
-func (j *JWTManager) GetPublicKey() *rsa.PublicKey {
-	return j.publicKey
+// GetPublicKey returns the public key of the active signing key.
+func (j *JWTManager) GetPublicKey() crypto.PublicKey {
+	return j.active.publicKey
+}
+
+// SigningAlgorithm returns the JWS alg of the active signing key.
+func (j *JWTManager) SigningAlgorithm() string {
+	return j.active.method.Alg()
 }
@@ -2,12 +2,17 @@ package token
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -67,3 +72,173 @@ func TestJWTManager_Validate(t *testing.T) {
 	assert.Equal(t, orgID, claims.OrgID)
 	assert.Equal(t, roles, claims.Roles)
 }
+
+func TestJWTManager_KeyRotation(t *testing.T) {
+	oldPrivate, oldPublic := generateTestKeys()
+	newPrivate, _ := generateTestKeys()
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+
+	// Tokens issued before rotation
+	before, err := NewJWTManager(oldPrivate, oldPublic)
+	require.NoError(t, err)
+	oldToken, err := before.Generate(ctx, userID, orgID, []string{"OWNER"}, 1800)
+	require.NoError(t, err)
+
+	retiredAt := time.Now()
+	after, err := NewJWTManagerWithKeys([]KeyConfig{
+		{KID: "2025-01", PrivateKeyPEM: newPrivate},
+		{KID: DefaultKeyID, PublicKeyPEM: oldPublic, RetiredAt: &retiredAt},
+	}, "2025-01", 30*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "2025-01", after.ActiveKeyID())
+
+	// New tokens are signed with the active key
+	newToken, err := after.Generate(ctx, userID, orgID, []string{"OWNER"}, 1800)
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, "2025-01", parsed.Header["kid"])
+
+	// Both old and new tokens verify while the retired key is in its grace period
+	_, err = after.Validate(ctx, oldToken)
+	assert.NoError(t, err)
+	_, err = after.Validate(ctx, newToken)
+	assert.NoError(t, err)
+
+	jwks, err := after.GetJWKS(ctx)
+	require.NoError(t, err)
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "2025-01", jwks.Keys[0].Kid)
+	assert.Equal(t, DefaultKeyID, jwks.Keys[1].Kid)
+
+	// Once the grace period has passed the retired key is dropped
+	after.clockFunc = func() time.Time { return retiredAt.Add(31 * time.Minute) }
+	_, err = after.Validate(ctx, oldToken)
+	assert.ErrorIs(t, err, ErrUnknownKeyID)
+
+	jwks, err = after.GetJWKS(ctx)
+	require.NoError(t, err)
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "2025-01", jwks.Keys[0].Kid)
+}
+
+func TestJWTManager_UnknownKid(t *testing.T) {
+	privateA, _ := generateTestKeys()
+	privateB, _ := generateTestKeys()
+	ctx := context.Background()
+
+	other, err := NewJWTManagerWithKeys([]KeyConfig{{KID: "other", PrivateKeyPEM: privateB}}, "other", 0)
+	require.NoError(t, err)
+	foreignToken, err := other.Generate(ctx, uuid.New(), uuid.New(), nil, 1800)
+	require.NoError(t, err)
+
+	manager, err := NewJWTManagerWithKeys([]KeyConfig{{KID: "mine", PrivateKeyPEM: privateA}}, "mine", 0)
+	require.NoError(t, err)
+	_, err = manager.Validate(ctx, foreignToken)
+	assert.ErrorIs(t, err, ErrUnknownKeyID)
+}
+
+func TestNewJWTManagerWithKeys_InvalidKeyring(t *testing.T) {
+	private, public := generateTestKeys()
+	retired := time.Now()
+
+	_, err := NewJWTManagerWithKeys([]KeyConfig{{KID: "a", PrivateKeyPEM: private}}, "b", 0)
+	assert.ErrorIs(t, err, ErrNoActiveKey)
+
+	_, err = NewJWTManagerWithKeys([]KeyConfig{{KID: "a", PublicKeyPEM: public}}, "a", 0)
+	assert.ErrorIs(t, err, ErrKeyNotSigning)
+
+	_, err = NewJWTManagerWithKeys([]KeyConfig{{KID: "a", PrivateKeyPEM: private}, {KID: "a", PublicKeyPEM: public}}, "a", 0)
+	assert.ErrorIs(t, err, ErrDuplicateKey)
+
+	_, err = NewJWTManagerWithKeys([]KeyConfig{{KID: "a", PrivateKeyPEM: private, RetiredAt: &retired}}, "a", 0)
+	assert.Error(t, err)
+}
+
+func encodePrivateKeyPEM(t *testing.T, key interface{}) string {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func TestJWTManager_ECDSAAndEdDSA(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		pem  string
+		alg  string
+		kty  string
+		crv  string
+	}{
+		{"ES256", encodePrivateKeyPEM(t, ecKey), "ES256", "EC", "P-256"},
+		{"EdDSA", encodePrivateKeyPEM(t, edKey), "EdDSA", "OKP", "Ed25519"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			manager, err := NewJWTManagerWithKeys([]KeyConfig{{KID: "k1", PrivateKeyPEM: tt.pem}}, "k1", 0)
+			require.NoError(t, err)
+			assert.Equal(t, tt.alg, manager.SigningAlgorithm())
+
+			userID := uuid.New()
+			signed, err := manager.Generate(ctx, userID, uuid.New(), []string{"MEMBER"}, 1800)
+			require.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(signed, &Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.alg, parsed.Method.Alg())
+
+			claims, err := manager.Validate(ctx, signed)
+			require.NoError(t, err)
+			assert.Equal(t, userID, claims.UserID)
+
+			jwks, err := manager.GetJWKS(ctx)
+			require.NoError(t, err)
+			require.Len(t, jwks.Keys, 1)
+			jwk := jwks.Keys[0]
+			assert.Equal(t, tt.kty, jwk.Kty)
+			assert.Equal(t, tt.crv, jwk.Crv)
+			assert.Equal(t, tt.alg, jwk.Alg)
+			assert.NotEmpty(t, jwk.X)
+			assert.Empty(t, jwk.N)
+			if tt.kty == "EC" {
+				assert.Len(t, jwk.Y, 43) // 32 bytes base64url
+			} else {
+				assert.Empty(t, jwk.Y)
+			}
+		})
+	}
+}
+
+func TestJWTManager_RejectsAlgorithmMismatch(t *testing.T) {
+	ctx := context.Background()
+	rsaPrivate, _ := generateTestKeys()
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	rsaManager, err := NewJWTManagerWithKeys([]KeyConfig{{KID: "shared", PrivateKeyPEM: rsaPrivate}}, "shared", 0)
+	require.NoError(t, err)
+	ecManager, err := NewJWTManagerWithKeys([]KeyConfig{{KID: "shared", PrivateKeyPEM: encodePrivateKeyPEM(t, ecKey)}}, "shared", 0)
+	require.NoError(t, err)
+
+	// An ES256 token claiming the kid of an RSA key must not verify
+	signed, err := ecManager.Generate(ctx, uuid.New(), uuid.New(), nil, 1800)
+	require.NoError(t, err)
+	_, err = rsaManager.Validate(ctx, signed)
+	assert.Error(t, err)
+}
+
+func TestNewJWTManagerWithKeys_UnsupportedCurve(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	_, err = NewJWTManagerWithKeys([]KeyConfig{{KID: "k", PrivateKeyPEM: encodePrivateKeyPEM(t, key)}}, "k", 0)
+	assert.ErrorIs(t, err, ErrUnsupportedKey)
+}
@@ -2,33 +2,152 @@ package token
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
-	"github.com/redis/go-redis/v9"
+	"github.com/google/uuid"
 )
 
+var ErrTokenRevoked = errors.New("token revoked")
+
+// RevocationStore keeps denylist entries until their TTL elapses. Get reports
+// found=false for keys that were never set or have expired.
+type RevocationStore interface {
+	Set(ctx context.Context, key string, value int64, ttl time.Duration) error
+	Get(ctx context.Context, key string) (value int64, found bool, err error)
+}
+
+// RevocationService denylists access tokens before they expire. Tokens can be
+// revoked individually by jti, per session (the sid claim, i.e. the refresh
+// token family), or for a user or an organization as a whole, which rejects
+// every token issued up to that moment. Entries only need to outlive the access token TTL.
 type RevocationService struct {
-	redis *redis.Client
+	store          RevocationStore
+	accessTokenTTL time.Duration
+	clockFunc      func() time.Time
 }
 
-func NewRevocationService(redis *redis.Client) *RevocationService {
-	return &RevocationService{redis: redis}
+func NewRevocationService(store RevocationStore, accessTokenTTL time.Duration) *RevocationService {
+	return &RevocationService{
+		store:          store,
+		accessTokenTTL: accessTokenTTL,
+		clockFunc:      time.Now,
+	}
 }
 
 func (s *RevocationService) RevokeToken(ctx context.Context, jti string, ttl time.Duration) error {
-	key := fmt.Sprintf("revoked:%s", jti)
-	return s.redis.Set(ctx, key, "1", ttl).Err()
+	if ttl <= 0 {
+		return nil
+	}
+	return s.store.Set(ctx, revokedTokenKey(jti), 1, ttl)
 }
 
 func (s *RevocationService) IsRevoked(ctx context.Context, jti string) (bool, error) {
-	key := fmt.Sprintf("revoked:%s", jti)
-	val, err := s.redis.Get(ctx, key).Result()
-	if err == redis.Nil {
-		return false, nil
+	_, found, err := s.store.Get(ctx, revokedTokenKey(jti))
+	return found, err
+}
+
+// RevokeSession rejects all access tokens issued for the given session.
+func (s *RevocationService) RevokeSession(ctx context.Context, sessionID uuid.UUID) error {
+	return s.store.Set(ctx, revokedSessionKey(sessionID.String()), 1, s.accessTokenTTL)
+}
+
+// RevokeUser rejects all access tokens issued to the user so far. Tokens
+// issued afterwards, e.g. on the next login, are not affected.
+func (s *RevocationService) RevokeUser(ctx context.Context, userID uuid.UUID) error {
+	return s.store.Set(ctx, revokedUserKey(userID.String()), s.clockFunc().UnixMilli(), s.accessTokenTTL)
+}
+
+// RevokeOrganization rejects all access tokens issued for the organization so
+// far. Sessions stay valid, so clients get tokens with the current state of
+// the organization on their next refresh.
+func (s *RevocationService) RevokeOrganization(ctx context.Context, orgID uuid.UUID) error {
+	return s.store.Set(ctx, revokedOrgKey(orgID.String()), s.clockFunc().UnixMilli(), s.accessTokenTTL)
+}
+
+// Check returns ErrTokenRevoked if the token was revoked by any of its jti,
+// session, user or organization.
+func (s *RevocationService) Check(ctx context.Context, claims *Claims) error {
+	if claims.ID != "" {
+		if revoked, err := s.IsRevoked(ctx, claims.ID); err != nil || revoked {
+			return revocationResult(revoked, err)
+		}
+	}
+
+	if claims.SessionID != "" {
+		_, revoked, err := s.store.Get(ctx, revokedSessionKey(claims.SessionID))
+		if err != nil || revoked {
+			return revocationResult(revoked, err)
+		}
+	}
+
+	if err := s.checkIssuedBefore(ctx, revokedUserKey(claims.UserID.String()), claims); err != nil {
+		return err
+	}
+
+	if claims.OrgID != uuid.Nil {
+		return s.checkIssuedBefore(ctx, revokedOrgKey(claims.OrgID.String()), claims)
 	}
+
+	return nil
+}
+
+// checkIssuedBefore rejects the token if it was issued before the revocation
+// recorded under key, in Unix milliseconds.
+func (s *RevocationService) checkIssuedBefore(ctx context.Context, key string, claims *Claims) error {
+	revokedAt, found, err := s.store.Get(ctx, key)
+	if err != nil {
+		return revocationResult(false, err)
+	}
+	if !found {
+		return nil
+	}
+	issuedAt, ok := issuedAtMilli(claims)
+	if !ok || issuedAt <= revokedAt {
+		return ErrTokenRevoked
+	}
+	return nil
+}
+
+// issuedAtMilli returns when the token was issued in Unix milliseconds. iat
+// only has second precision, so a token issued in the same second as a
+// revocation, e.g. on a login right after a password change, is told apart by
+// the time in its version 7 jti. Tokens without one fall back to the start of
+// the iat second, which treats them as revoked in that second.
+func issuedAtMilli(claims *Claims) (int64, bool) {
+	if id, err := uuid.Parse(claims.ID); err == nil && id.Version() == 7 {
+		sec, nsec := id.Time().UnixTime()
+		return sec*1000 + nsec/int64(time.Millisecond), true
+	}
+	if claims.IssuedAt == nil {
+		return 0, false
+	}
+	return claims.IssuedAt.Unix() * 1000, true
+}
+
+func revocationResult(revoked bool, err error) error {
 	if err != nil {
-		return false, err
+		return fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	if revoked {
+		return ErrTokenRevoked
 	}
-	return val == "1", nil
+	return nil
+}
+
+func revokedTokenKey(jti string) string {
+	return fmt.Sprintf("revoked:%s", jti)
+}
+
+func revokedSessionKey(sessionID string) string {
+	return fmt.Sprintf("revoked:session:%s", sessionID)
+}
+
+func revokedUserKey(userID string) string {
+	return fmt.Sprintf("revoked:user:%s", userID)
+}
+
+func revokedOrgKey(orgID string) string {
+	return fmt.Sprintf("revoked:org:%s", orgID)
 }
@@ -0,0 +1,86 @@
+package token
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisRevocationStore shares the denylist between all instances.
+type RedisRevocationStore struct {
+	redis *redis.Client
+}
+
+func NewRedisRevocationStore(redis *redis.Client) *RedisRevocationStore {
+	return &RedisRevocationStore{redis: redis}
+}
+
+func (s *RedisRevocationStore) Set(ctx context.Context, key string, value int64, ttl time.Duration) error {
+	return s.redis.Set(ctx, key, strconv.FormatInt(value, 10), ttl).Err()
+}
+
+func (s *RedisRevocationStore) Get(ctx context.Context, key string) (int64, bool, error) {
+	val, err := s.redis.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	value, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	return value, true, nil
+}
+
+// MemoryRevocationStore keeps the denylist in process memory. It is only
+// suitable for single-instance deployments: entries are lost on restart and
+// not shared between replicas.
+type MemoryRevocationStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryRevocationEntry
+	clockFunc func() time.Time
+}
+
+type memoryRevocationEntry struct {
+	value     int64
+	expiresAt time.Time
+}
+
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		entries:   make(map[string]memoryRevocationEntry),
+		clockFunc: time.Now,
+	}
+}
+
+func (s *MemoryRevocationStore) Set(_ context.Context, key string, value int64, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clockFunc()
+	// Expired entries are swept on write so the map cannot grow unbounded
+	for k, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+
+	s.entries[key] = memoryRevocationEntry{value: value, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (s *MemoryRevocationStore) Get(_ context.Context, key string) (int64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || !s.clockFunc().Before(entry.expiresAt) {
+		return 0, false, nil
+	}
+	return entry.value, true, nil
+}
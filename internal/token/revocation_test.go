@@ -0,0 +1,137 @@
+package token
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevocationService_Check(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	svc := NewRevocationService(NewMemoryRevocationStore(), 15*time.Minute)
+	svc.clockFunc = func() time.Time { return now }
+
+	userID := uuid.New()
+	sessionID := uuid.New()
+	claims := &Claims{
+		UserID:    userID,
+		SessionID: sessionID.String(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:       "jti-1",
+			IssuedAt: jwt.NewNumericDate(now.Add(-time.Minute)),
+		},
+	}
+
+	require.NoError(t, svc.Check(ctx, claims))
+
+	t.Run("by jti", func(t *testing.T) {
+		other := *claims
+		other.ID = "jti-2"
+		require.NoError(t, svc.RevokeToken(ctx, "jti-2", time.Minute))
+		assert.ErrorIs(t, svc.Check(ctx, &other), ErrTokenRevoked)
+		assert.NoError(t, svc.Check(ctx, claims))
+	})
+
+	t.Run("by session", func(t *testing.T) {
+		other := *claims
+		other.SessionID = uuid.New().String()
+		require.NoError(t, svc.RevokeSession(ctx, uuid.MustParse(other.SessionID)))
+		assert.ErrorIs(t, svc.Check(ctx, &other), ErrTokenRevoked)
+		assert.NoError(t, svc.Check(ctx, claims))
+	})
+
+	t.Run("by organization", func(t *testing.T) {
+		other := *claims
+		other.OrgID = uuid.New()
+		require.NoError(t, svc.RevokeOrganization(ctx, other.OrgID))
+		assert.ErrorIs(t, svc.Check(ctx, &other), ErrTokenRevoked)
+		assert.NoError(t, svc.Check(ctx, claims))
+
+		// Tokens issued after the change, e.g. on refresh, stay valid
+		fresh := other
+		fresh.IssuedAt = jwt.NewNumericDate(now.Add(time.Second))
+		assert.NoError(t, svc.Check(ctx, &fresh))
+	})
+
+	t.Run("by user", func(t *testing.T) {
+		require.NoError(t, svc.RevokeUser(ctx, userID))
+		assert.ErrorIs(t, svc.Check(ctx, claims), ErrTokenRevoked)
+
+		// Tokens from a later login stay valid
+		fresh := *claims
+		fresh.IssuedAt = jwt.NewNumericDate(now.Add(time.Second))
+		assert.NoError(t, svc.Check(ctx, &fresh))
+	})
+}
+
+// jtiAt returns a version 7 token ID carrying the time t.
+func jtiAt(t *testing.T, at time.Time) string {
+	t.Helper()
+	id, err := uuid.NewV7()
+	require.NoError(t, err)
+	ms := uint64(at.UnixMilli()) // #nosec G115 -- test timestamps are positive
+	for i := 0; i < 6; i++ {
+		id[i] = byte(ms >> (40 - 8*i))
+	}
+	return id.String()
+}
+
+func TestRevocationService_CheckSameSecond(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 500*int64(time.Millisecond))
+	svc := NewRevocationService(NewMemoryRevocationStore(), 15*time.Minute)
+	svc.clockFunc = func() time.Time { return now }
+
+	userID, orgID := uuid.New(), uuid.New()
+	tokenAt := func(at time.Time) *Claims {
+		return &Claims{
+			UserID: userID,
+			OrgID:  orgID,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:       jtiAt(t, at),
+				IssuedAt: jwt.NewNumericDate(at),
+			},
+		}
+	}
+	before := tokenAt(now.Add(-100 * time.Millisecond))
+	after := tokenAt(now.Add(100 * time.Millisecond))
+	require.Equal(t, before.IssuedAt.Unix(), after.IssuedAt.Unix())
+
+	require.NoError(t, svc.RevokeUser(ctx, userID))
+	assert.ErrorIs(t, svc.Check(ctx, before), ErrTokenRevoked)
+	// E.g. the login right after a password change
+	assert.NoError(t, svc.Check(ctx, after))
+
+	require.NoError(t, svc.RevokeOrganization(ctx, orgID))
+	assert.ErrorIs(t, svc.Check(ctx, before), ErrTokenRevoked)
+	assert.NoError(t, svc.Check(ctx, after))
+
+	// Without a version 7 jti the whole second counts as revoked
+	legacy := *after
+	legacy.ID = uuid.New().String()
+	assert.ErrorIs(t, svc.Check(ctx, &legacy), ErrTokenRevoked)
+}
+
+func TestMemoryRevocationStore_Expiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := NewMemoryRevocationStore()
+	store.clockFunc = func() time.Time { return now }
+
+	require.NoError(t, store.Set(ctx, "key", 42, time.Minute))
+	value, found, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, int64(42), value)
+
+	now = now.Add(time.Minute)
+	_, found, err = store.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
@@ -0,0 +1,427 @@
+package token
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidSAMLMetadata = errors.New("invalid SAML metadata")
+	ErrInvalidSAMLResponse = errors.New("invalid SAML response")
+)
+
+const (
+	samlMetadataNamespace  = "urn:oasis:names:tc:SAML:2.0:metadata"
+	samlProtocolNamespace  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	samlAssertionNamespace = "urn:oasis:names:tc:SAML:2.0:assertion"
+
+	samlBindingRedirect = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	samlBindingPOST     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+
+	samlStatusSuccess = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	samlBearer        = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+
+	SAMLNameIDFormatEmail       = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+	SAMLNameIDFormatUnspecified = "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"
+
+	// Allowed difference between our clock and the IdP's
+	samlClockSkew = 3 * time.Minute
+)
+
+// SAMLIdPMetadata is what we need to know about an identity provider.
+type SAMLIdPMetadata struct {
+	EntityID     string
+	SSOURL       string
+	Certificates []*x509.Certificate
+}
+
+// ParseSAMLIdPMetadata reads the entity ID, the HTTP-Redirect single sign-on
+// endpoint and the signing certificates from IdP metadata. The certificates
+// are pinned: their validity period and issuer are not checked, as IdPs
+// commonly publish long-lived self-signed certificates.
+func ParseSAMLIdPMetadata(data []byte) (*SAMLIdPMetadata, error) {
+	root, err := ParseXML(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSAMLMetadata, err)
+	}
+
+	entity := root
+	if root.Space == samlMetadataNamespace && root.Local == "EntitiesDescriptor" {
+		entity = nil
+		for _, candidate := range root.Children(samlMetadataNamespace, "EntityDescriptor") {
+			if candidate.Child(samlMetadataNamespace, "IDPSSODescriptor") != nil {
+				entity = candidate
+				break
+			}
+		}
+	}
+	if entity == nil || entity.Space != samlMetadataNamespace || entity.Local != "EntityDescriptor" {
+		return nil, fmt.Errorf("%w: no EntityDescriptor", ErrInvalidSAMLMetadata)
+	}
+
+	descriptor := entity.Child(samlMetadataNamespace, "IDPSSODescriptor")
+	if descriptor == nil {
+		return nil, fmt.Errorf("%w: no IDPSSODescriptor", ErrInvalidSAMLMetadata)
+	}
+
+	metadata := &SAMLIdPMetadata{EntityID: entity.Attr("entityID")}
+	if metadata.EntityID == "" {
+		return nil, fmt.Errorf("%w: missing entityID", ErrInvalidSAMLMetadata)
+	}
+
+	for _, sso := range descriptor.Children(samlMetadataNamespace, "SingleSignOnService") {
+		if sso.Attr("Binding") == samlBindingRedirect {
+			metadata.SSOURL = sso.Attr("Location")
+			break
+		}
+	}
+	if u, err := url.Parse(metadata.SSOURL); err != nil || u.Host == "" {
+		return nil, fmt.Errorf("%w: no HTTP-Redirect SingleSignOnService", ErrInvalidSAMLMetadata)
+	}
+
+	for _, key := range descriptor.Children(samlMetadataNamespace, "KeyDescriptor") {
+		if use := key.Attr("use"); use != "" && use != "signing" {
+			continue
+		}
+		keyInfo := key.Child(XMLDSigNamespace, "KeyInfo")
+		if keyInfo == nil {
+			continue
+		}
+		for _, data := range keyInfo.Children(XMLDSigNamespace, "X509Data") {
+			for _, encoded := range data.Children(XMLDSigNamespace, "X509Certificate") {
+				der, err := decodeXMLBase64(encoded.Text())
+				if err != nil {
+					return nil, fmt.Errorf("%w: malformed certificate", ErrInvalidSAMLMetadata)
+				}
+				cert, err := x509.ParseCertificate(der)
+				if err != nil {
+					return nil, fmt.Errorf("%w: malformed certificate: %v", ErrInvalidSAMLMetadata, err)
+				}
+				metadata.Certificates = append(metadata.Certificates, cert)
+			}
+		}
+	}
+	if len(metadata.Certificates) == 0 {
+		return nil, fmt.Errorf("%w: no signing certificate", ErrInvalidSAMLMetadata)
+	}
+
+	return metadata, nil
+}
+
+// EncodeCertificatesPEM serializes certificates for storage.
+func EncodeCertificatesPEM(certs []*x509.Certificate) []string {
+	encoded := make([]string, 0, len(certs))
+	for _, cert := range certs {
+		encoded = append(encoded, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})))
+	}
+	return encoded
+}
+
+// ParseCertificatesPEM is the inverse of EncodeCertificatesPEM.
+func ParseCertificatesPEM(encoded []string) ([]*x509.Certificate, error) {
+	certs := make([]*x509.Certificate, 0, len(encoded))
+	for _, data := range encoded {
+		block, _ := pem.Decode([]byte(data))
+		if block == nil || block.Type != "CERTIFICATE" {
+			return nil, errors.New("invalid certificate PEM")
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+// SAMLAssertion is the data of a verified assertion.
+type SAMLAssertion struct {
+	ID           string
+	Issuer       string
+	NameID       string
+	NameIDFormat string
+	InResponseTo string
+	SessionIndex string
+	Attributes   map[string][]string
+}
+
+// Attribute returns the first value of the attribute.
+func (a *SAMLAssertion) Attribute(name string) string {
+	if values := a.Attributes[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// SAMLServiceProvider is our side of the trust relationship with one IdP.
+type SAMLServiceProvider struct {
+	EntityID string
+	ACSURL   string
+	IdP      *SAMLIdPMetadata
+}
+
+// Metadata returns the SP metadata to register at the IdP.
+func (sp *SAMLServiceProvider) Metadata() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	buf.WriteString(`<md:EntityDescriptor xmlns:md="` + samlMetadataNamespace + `" entityID="`)
+	xmlEscape(&buf, sp.EntityID)
+	buf.WriteString(`">`)
+	buf.WriteString(`<md:SPSSODescriptor AuthnRequestsSigned="false" WantAssertionsSigned="true" protocolSupportEnumeration="` + samlProtocolNamespace + `">`)
+	buf.WriteString(`<md:NameIDFormat>` + SAMLNameIDFormatEmail + `</md:NameIDFormat>`)
+	buf.WriteString(`<md:AssertionConsumerService Binding="` + samlBindingPOST + `" Location="`)
+	xmlEscape(&buf, sp.ACSURL)
+	buf.WriteString(`" index="0" isDefault="true"/>`)
+	buf.WriteString(`</md:SPSSODescriptor></md:EntityDescriptor>` + "\n")
+	return buf.Bytes()
+}
+
+// AuthnRequestURL builds an HTTP-Redirect binding login URL at the IdP and
+// returns it together with the request ID the response must refer to.
+func (sp *SAMLServiceProvider) AuthnRequestURL(now time.Time) (string, string, error) {
+	id := make([]byte, 20)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+	requestID := "_" + hex.EncodeToString(id)
+
+	var request bytes.Buffer
+	request.WriteString(`<samlp:AuthnRequest xmlns:samlp="` + samlProtocolNamespace + `" xmlns:saml="` + samlAssertionNamespace + `"`)
+	request.WriteString(` ID="` + requestID + `" Version="2.0" IssueInstant="` + now.UTC().Format(time.RFC3339) + `" Destination="`)
+	xmlEscape(&request, sp.IdP.SSOURL)
+	request.WriteString(`" AssertionConsumerServiceURL="`)
+	xmlEscape(&request, sp.ACSURL)
+	request.WriteString(`" ProtocolBinding="` + samlBindingPOST + `">`)
+	request.WriteString(`<saml:Issuer>`)
+	xmlEscape(&request, sp.EntityID)
+	request.WriteString(`</saml:Issuer>`)
+	request.WriteString(`<samlp:NameIDPolicy Format="` + SAMLNameIDFormatUnspecified + `" AllowCreate="true"/>`)
+	request.WriteString(`</samlp:AuthnRequest>`)
+
+	var deflated bytes.Buffer
+	writer, err := flate.NewWriter(&deflated, flate.BestCompression)
+	if err != nil {
+		return "", "", err
+	}
+	if _, err := writer.Write(request.Bytes()); err != nil {
+		return "", "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", "", err
+	}
+
+	params := url.Values{"SAMLRequest": {base64.StdEncoding.EncodeToString(deflated.Bytes())}}
+	separator := "?"
+	if strings.Contains(sp.IdP.SSOURL, "?") {
+		separator = "&"
+	}
+	return sp.IdP.SSOURL + separator + params.Encode(), requestID, nil
+}
+
+// ParseResponse verifies a base64 encoded HTTP-POST binding response and
+// returns its assertion. Either the response or the assertion must be signed
+// by one of the IdP certificates; data is only read from the signed element.
+// Encrypted assertions are not supported.
+func (sp *SAMLServiceProvider) ParseResponse(encoded string, now time.Time) (*SAMLAssertion, error) {
+	data, err := decodeXMLBase64(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed encoding", ErrInvalidSAMLResponse)
+	}
+	response, err := ParseXML(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSAMLResponse, err)
+	}
+	if response.Space != samlProtocolNamespace || response.Local != "Response" {
+		return nil, fmt.Errorf("%w: not a Response", ErrInvalidSAMLResponse)
+	}
+	if response.HasDuplicateIDs() {
+		return nil, fmt.Errorf("%w: duplicate IDs", ErrInvalidSAMLResponse)
+	}
+
+	if destination := response.Attr("Destination"); destination != "" && destination != sp.ACSURL {
+		return nil, fmt.Errorf("%w: wrong destination %q", ErrInvalidSAMLResponse, destination)
+	}
+	if issuer := response.Child(samlAssertionNamespace, "Issuer"); issuer != nil && strings.TrimSpace(issuer.Text()) != sp.IdP.EntityID {
+		return nil, fmt.Errorf("%w: unexpected issuer", ErrInvalidSAMLResponse)
+	}
+	if status := samlStatusCode(response); status != samlStatusSuccess {
+		return nil, fmt.Errorf("%w: status %q", ErrInvalidSAMLResponse, status)
+	}
+
+	if response.Child(samlAssertionNamespace, "EncryptedAssertion") != nil {
+		return nil, fmt.Errorf("%w: encrypted assertions are not supported", ErrInvalidSAMLResponse)
+	}
+	assertions := response.Children(samlAssertionNamespace, "Assertion")
+	if len(assertions) != 1 {
+		return nil, fmt.Errorf("%w: expected one assertion, found %d", ErrInvalidSAMLResponse, len(assertions))
+	}
+	assertion := assertions[0]
+
+	signed := false
+	if response.SignatureElement() != nil {
+		if err := VerifyEnvelopedSignature(response, sp.IdP.Certificates); err != nil {
+			return nil, fmt.Errorf("%w: response signature: %v", ErrInvalidSAMLResponse, err)
+		}
+		signed = true
+	}
+	if assertion.SignatureElement() != nil {
+		if err := VerifyEnvelopedSignature(assertion, sp.IdP.Certificates); err != nil {
+			return nil, fmt.Errorf("%w: assertion signature: %v", ErrInvalidSAMLResponse, err)
+		}
+		signed = true
+	}
+	if !signed {
+		return nil, fmt.Errorf("%w: neither the response nor the assertion is signed", ErrInvalidSAMLResponse)
+	}
+
+	result, err := sp.checkAssertion(assertion, now)
+	if err != nil {
+		return nil, err
+	}
+	if inResponseTo := response.Attr("InResponseTo"); inResponseTo != "" {
+		if result.InResponseTo != "" && result.InResponseTo != inResponseTo {
+			return nil, fmt.Errorf("%w: InResponseTo mismatch", ErrInvalidSAMLResponse)
+		}
+		result.InResponseTo = inResponseTo
+	}
+	return result, nil
+}
+
+func samlStatusCode(response *XMLElement) string {
+	status := response.Child(samlProtocolNamespace, "Status")
+	if status == nil {
+		return ""
+	}
+	code := status.Child(samlProtocolNamespace, "StatusCode")
+	if code == nil {
+		return ""
+	}
+	return code.Attr("Value")
+}
+
+// checkAssertion validates issuer, subject confirmation, conditions and
+// audience of a signed assertion.
+func (sp *SAMLServiceProvider) checkAssertion(assertion *XMLElement, now time.Time) (*SAMLAssertion, error) {
+	result := &SAMLAssertion{ID: assertion.Attr("ID"), Attributes: map[string][]string{}}
+
+	issuer := assertion.Child(samlAssertionNamespace, "Issuer")
+	if issuer == nil || strings.TrimSpace(issuer.Text()) != sp.IdP.EntityID {
+		return nil, fmt.Errorf("%w: unexpected assertion issuer", ErrInvalidSAMLResponse)
+	}
+	result.Issuer = sp.IdP.EntityID
+
+	subject := assertion.Child(samlAssertionNamespace, "Subject")
+	if subject == nil {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidSAMLResponse)
+	}
+	nameID := subject.Child(samlAssertionNamespace, "NameID")
+	if nameID == nil || strings.TrimSpace(nameID.Text()) == "" {
+		return nil, fmt.Errorf("%w: missing NameID", ErrInvalidSAMLResponse)
+	}
+	result.NameID = strings.TrimSpace(nameID.Text())
+	result.NameIDFormat = nameID.Attr("Format")
+
+	confirmed := false
+	for _, confirmation := range subject.Children(samlAssertionNamespace, "SubjectConfirmation") {
+		if confirmation.Attr("Method") != samlBearer {
+			continue
+		}
+		data := confirmation.Child(samlAssertionNamespace, "SubjectConfirmationData")
+		if data == nil || data.Attr("Recipient") != sp.ACSURL {
+			continue
+		}
+		if err := checkValidity(data, now, true); err != nil {
+			continue
+		}
+		result.InResponseTo = data.Attr("InResponseTo")
+		confirmed = true
+		break
+	}
+	if !confirmed {
+		return nil, fmt.Errorf("%w: no valid bearer subject confirmation", ErrInvalidSAMLResponse)
+	}
+
+	conditions := assertion.Child(samlAssertionNamespace, "Conditions")
+	if conditions == nil {
+		return nil, fmt.Errorf("%w: missing conditions", ErrInvalidSAMLResponse)
+	}
+	if err := checkValidity(conditions, now, false); err != nil {
+		return nil, err
+	}
+	restrictions := conditions.Children(samlAssertionNamespace, "AudienceRestriction")
+	if len(restrictions) == 0 {
+		return nil, fmt.Errorf("%w: missing audience restriction", ErrInvalidSAMLResponse)
+	}
+	// Every restriction must be satisfied
+	for _, restriction := range restrictions {
+		found := false
+		for _, audience := range restriction.Children(samlAssertionNamespace, "Audience") {
+			if strings.TrimSpace(audience.Text()) == sp.EntityID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: assertion is not intended for this service provider", ErrInvalidSAMLResponse)
+		}
+	}
+
+	if authn := assertion.Child(samlAssertionNamespace, "AuthnStatement"); authn != nil {
+		result.SessionIndex = authn.Attr("SessionIndex")
+	}
+
+	for _, statement := range assertion.Children(samlAssertionNamespace, "AttributeStatement") {
+		for _, attribute := range statement.Children(samlAssertionNamespace, "Attribute") {
+			name := attribute.Attr("Name")
+			for _, value := range attribute.Children(samlAssertionNamespace, "AttributeValue") {
+				result.Attributes[name] = append(result.Attributes[name], strings.TrimSpace(value.Text()))
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// checkValidity checks the NotBefore and NotOnOrAfter attributes of an
+// element, allowing for clock skew. requireExpiry makes NotOnOrAfter mandatory.
+func checkValidity(e *XMLElement, now time.Time, requireExpiry bool) error {
+	if notBefore := e.Attr("NotBefore"); notBefore != "" {
+		t, err := time.Parse(time.RFC3339Nano, notBefore)
+		if err != nil {
+			return fmt.Errorf("%w: malformed NotBefore", ErrInvalidSAMLResponse)
+		}
+		if now.Add(samlClockSkew).Before(t) {
+			return fmt.Errorf("%w: not yet valid", ErrInvalidSAMLResponse)
+		}
+	}
+	notOnOrAfter := e.Attr("NotOnOrAfter")
+	if notOnOrAfter == "" {
+		if requireExpiry {
+			return fmt.Errorf("%w: missing NotOnOrAfter", ErrInvalidSAMLResponse)
+		}
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, notOnOrAfter)
+	if err != nil {
+		return fmt.Errorf("%w: malformed NotOnOrAfter", ErrInvalidSAMLResponse)
+	}
+	if !now.Add(-samlClockSkew).Before(t) {
+		return fmt.Errorf("%w: expired", ErrInvalidSAMLResponse)
+	}
+	return nil
+}
+
+func xmlEscape(buf *bytes.Buffer, s string) {
+	_ = xml.EscapeText(buf, []byte(s))
+}
@@ -0,0 +1,258 @@
+package token
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIdPEntityID = "https://idp.example.test/metadata"
+	testSPEntityID  = "https://auth.zeno.test/v1/saml/org/metadata"
+	testACSURL      = "https://auth.zeno.test/v1/saml/org/acs"
+)
+
+func testIdPMetadataXML(idp *testIdP) string {
+	return `<?xml version="1.0"?>
+<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="` + testIdPEntityID + `">
+  <md:IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <md:KeyDescriptor use="encryption"><ds:KeyInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#"><ds:X509Data><ds:X509Certificate>bm90IGEgY2VydA==</ds:X509Certificate></ds:X509Data></ds:KeyInfo></md:KeyDescriptor>
+    <md:KeyDescriptor use="signing">
+      <ds:KeyInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#"><ds:X509Data><ds:X509Certificate>
+` + base64.StdEncoding.EncodeToString(idp.cert.Raw) + `
+      </ds:X509Certificate></ds:X509Data></ds:KeyInfo>
+    </md:KeyDescriptor>
+    <md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST" Location="https://idp.example.test/sso/post"/>
+    <md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://idp.example.test/sso?tenant=1"/>
+  </md:IDPSSODescriptor>
+</md:EntityDescriptor>`
+}
+
+func newTestServiceProvider(t *testing.T, idp *testIdP) *SAMLServiceProvider {
+	t.Helper()
+	metadata, err := ParseSAMLIdPMetadata([]byte(testIdPMetadataXML(idp)))
+	require.NoError(t, err)
+	return &SAMLServiceProvider{EntityID: testSPEntityID, ACSURL: testACSURL, IdP: metadata}
+}
+
+type testSAMLResponse struct {
+	issuer       string
+	audience     string
+	recipient    string
+	inResponseTo string
+	status       string
+	notOnOrAfter time.Time
+}
+
+func defaultTestSAMLResponse(now time.Time) testSAMLResponse {
+	return testSAMLResponse{
+		issuer:       testIdPEntityID,
+		audience:     testSPEntityID,
+		recipient:    testACSURL,
+		inResponseTo: "_req1",
+		status:       samlStatusSuccess,
+		notOnOrAfter: now.Add(5 * time.Minute),
+	}
+}
+
+func (r testSAMLResponse) xml(now time.Time) string {
+	return `<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="r1" Version="2.0" IssueInstant="` + now.UTC().Format(time.RFC3339) + `" Destination="` + testACSURL + `" InResponseTo="` + r.inResponseTo + `">
+  <saml:Issuer>` + r.issuer + `</saml:Issuer><!--SIG:r1-->
+  <samlp:Status><samlp:StatusCode Value="` + r.status + `"/></samlp:Status>
+  <saml:Assertion xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" ID="a1" Version="2.0" IssueInstant="` + now.UTC().Format(time.RFC3339) + `">
+    <saml:Issuer>` + r.issuer + `</saml:Issuer><!--SIG:a1-->
+    <saml:Subject>
+      <saml:NameID Format="urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress">Alice@Example.test</saml:NameID>
+      <saml:SubjectConfirmation Method="urn:oasis:names:tc:SAML:2.0:cm:bearer">
+        <saml:SubjectConfirmationData InResponseTo="` + r.inResponseTo + `" NotOnOrAfter="` + r.notOnOrAfter.UTC().Format(time.RFC3339Nano) + `" Recipient="` + r.recipient + `"/>
+      </saml:SubjectConfirmation>
+    </saml:Subject>
+    <saml:Conditions NotBefore="` + now.Add(-time.Minute).UTC().Format(time.RFC3339) + `" NotOnOrAfter="` + r.notOnOrAfter.UTC().Format(time.RFC3339) + `">
+      <saml:AudienceRestriction><saml:Audience>` + r.audience + `</saml:Audience></saml:AudienceRestriction>
+    </saml:Conditions>
+    <saml:AuthnStatement AuthnInstant="` + now.UTC().Format(time.RFC3339) + `" SessionIndex="_s1"/>
+    <saml:AttributeStatement>
+      <saml:Attribute Name="displayName"><saml:AttributeValue xsi:type="xs:string">Alice Example</saml:AttributeValue></saml:Attribute>
+      <saml:Attribute Name="groups"><saml:AttributeValue xsi:type="xs:string">eng</saml:AttributeValue><saml:AttributeValue xsi:type="xs:string">ops</saml:AttributeValue></saml:Attribute>
+    </saml:AttributeStatement>
+  </saml:Assertion>
+</samlp:Response>`
+}
+
+func encodeSAMLResponse(doc string) string {
+	return base64.StdEncoding.EncodeToString([]byte(doc))
+}
+
+func TestParseSAMLIdPMetadata(t *testing.T) {
+	idp := newTestIdP(t)
+
+	metadata, err := ParseSAMLIdPMetadata([]byte(testIdPMetadataXML(idp)))
+	require.NoError(t, err)
+	assert.Equal(t, testIdPEntityID, metadata.EntityID)
+	assert.Equal(t, "https://idp.example.test/sso?tenant=1", metadata.SSOURL)
+	require.Len(t, metadata.Certificates, 1)
+	assert.True(t, metadata.Certificates[0].Equal(idp.cert))
+
+	certs, err := ParseCertificatesPEM(EncodeCertificatesPEM(metadata.Certificates))
+	require.NoError(t, err)
+	assert.True(t, certs[0].Equal(idp.cert))
+
+	wrapped := `<md:EntitiesDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata">` +
+		strings.TrimPrefix(testIdPMetadataXML(idp), `<?xml version="1.0"?>`) + `</md:EntitiesDescriptor>`
+	metadata, err = ParseSAMLIdPMetadata([]byte(wrapped))
+	require.NoError(t, err)
+	assert.Equal(t, testIdPEntityID, metadata.EntityID)
+
+	invalid := map[string]string{
+		"not XML":          "metadata",
+		"no IdP":           `<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="x"/>`,
+		"no redirect SSO":  strings.Replace(testIdPMetadataXML(idp), "HTTP-Redirect", "SOAP", 1),
+		"no signing cert":  strings.Replace(testIdPMetadataXML(idp), `use="signing"`, `use="encryption"`, 1),
+		"missing entityID": strings.Replace(testIdPMetadataXML(idp), `entityID="`+testIdPEntityID+`"`, "", 1),
+	}
+	for name, doc := range invalid {
+		_, err := ParseSAMLIdPMetadata([]byte(doc))
+		assert.ErrorIs(t, err, ErrInvalidSAMLMetadata, name)
+	}
+}
+
+func TestSAMLServiceProvider_Metadata(t *testing.T) {
+	sp := &SAMLServiceProvider{EntityID: testSPEntityID + "?a=1&b=2", ACSURL: testACSURL}
+
+	root, err := ParseXML(sp.Metadata())
+	require.NoError(t, err)
+	assert.Equal(t, testSPEntityID+"?a=1&b=2", root.Attr("entityID"))
+	acs := root.Child(samlMetadataNamespace, "SPSSODescriptor").Child(samlMetadataNamespace, "AssertionConsumerService")
+	require.NotNil(t, acs)
+	assert.Equal(t, testACSURL, acs.Attr("Location"))
+	assert.Equal(t, samlBindingPOST, acs.Attr("Binding"))
+}
+
+func TestSAMLServiceProvider_AuthnRequestURL(t *testing.T) {
+	sp := newTestServiceProvider(t, newTestIdP(t))
+	now := time.Now()
+
+	loginURL, requestID, err := sp.AuthnRequestURL(now)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(requestID, "_"))
+
+	u, err := url.Parse(loginURL)
+	require.NoError(t, err)
+	assert.Equal(t, "1", u.Query().Get("tenant"))
+
+	deflated, err := base64.StdEncoding.DecodeString(u.Query().Get("SAMLRequest"))
+	require.NoError(t, err)
+	inflated, err := io.ReadAll(flate.NewReader(bytes.NewReader(deflated)))
+	require.NoError(t, err)
+
+	request, err := ParseXML(inflated)
+	require.NoError(t, err)
+	assert.Equal(t, "AuthnRequest", request.Local)
+	assert.Equal(t, requestID, request.Attr("ID"))
+	assert.Equal(t, testACSURL, request.Attr("AssertionConsumerServiceURL"))
+	assert.Equal(t, testSPEntityID, strings.TrimSpace(request.Child(samlAssertionNamespace, "Issuer").Text()))
+}
+
+func TestSAMLServiceProvider_ParseResponse(t *testing.T) {
+	idp := newTestIdP(t)
+	sp := newTestServiceProvider(t, idp)
+	now := time.Now()
+
+	t.Run("signed assertion", func(t *testing.T) {
+		doc := idp.sign(t, defaultTestSAMLResponse(now).xml(now), "a1")
+
+		assertion, err := sp.ParseResponse(encodeSAMLResponse(doc), now)
+		require.NoError(t, err)
+		assert.Equal(t, "a1", assertion.ID)
+		assert.Equal(t, "Alice@Example.test", assertion.NameID)
+		assert.Equal(t, SAMLNameIDFormatEmail, assertion.NameIDFormat)
+		assert.Equal(t, "_req1", assertion.InResponseTo)
+		assert.Equal(t, "_s1", assertion.SessionIndex)
+		assert.Equal(t, "Alice Example", assertion.Attribute("displayName"))
+		assert.Equal(t, []string{"eng", "ops"}, assertion.Attributes["groups"])
+	})
+
+	t.Run("signed response", func(t *testing.T) {
+		doc := idp.sign(t, defaultTestSAMLResponse(now).xml(now), "r1")
+
+		assertion, err := sp.ParseResponse(encodeSAMLResponse(doc), now)
+		require.NoError(t, err)
+		assert.Equal(t, "Alice@Example.test", assertion.NameID)
+	})
+
+	t.Run("signed response and assertion", func(t *testing.T) {
+		doc := idp.sign(t, idp.sign(t, defaultTestSAMLResponse(now).xml(now), "a1"), "r1")
+
+		_, err := sp.ParseResponse(encodeSAMLResponse(doc), now)
+		assert.NoError(t, err)
+	})
+
+	t.Run("signed by another key", func(t *testing.T) {
+		doc := newTestIdP(t).sign(t, defaultTestSAMLResponse(now).xml(now), "a1")
+
+		_, err := sp.ParseResponse(encodeSAMLResponse(doc), now)
+		assert.ErrorIs(t, err, ErrInvalidSAMLResponse)
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		_, err := sp.ParseResponse(encodeSAMLResponse(defaultTestSAMLResponse(now).xml(now)), now)
+		assert.ErrorIs(t, err, ErrInvalidSAMLResponse)
+	})
+
+	t.Run("signed assertion moved out of the way", func(t *testing.T) {
+		signed := idp.sign(t, defaultTestSAMLResponse(now).xml(now), "a1")
+		start := strings.Index(signed, "<saml:Assertion")
+		end := strings.Index(signed, "</saml:Assertion>") + len("</saml:Assertion>")
+		original := signed[start:end]
+		forged := strings.Replace(strings.Replace(original, `ID="a1"`, `ID="evil"`, 1), "Alice@Example.test", "mallory@example.test", 1)
+		start = strings.Index(forged, "<ds:Signature")
+		end = strings.Index(forged, "</ds:Signature>") + len("</ds:Signature>")
+		forged = forged[:start] + forged[end:]
+		wrapped := strings.Replace(signed, original,
+			`<samlp:Extensions>`+original+`</samlp:Extensions>`+forged, 1)
+
+		_, err := sp.ParseResponse(encodeSAMLResponse(wrapped), now)
+		assert.ErrorIs(t, err, ErrInvalidSAMLResponse)
+	})
+
+	t.Run("duplicate IDs", func(t *testing.T) {
+		signed := idp.sign(t, defaultTestSAMLResponse(now).xml(now), "a1")
+		duplicated := strings.Replace(signed, "<samlp:Status>", `<samlp:Extensions><x ID="a1"/></samlp:Extensions><samlp:Status>`, 1)
+
+		_, err := sp.ParseResponse(encodeSAMLResponse(duplicated), now)
+		assert.ErrorIs(t, err, ErrInvalidSAMLResponse)
+	})
+
+	invalid := map[string]func(r *testSAMLResponse){
+		"wrong issuer":    func(r *testSAMLResponse) { r.issuer = "https://evil.example.test" },
+		"wrong audience":  func(r *testSAMLResponse) { r.audience = "https://other-sp.example.test" },
+		"wrong recipient": func(r *testSAMLResponse) { r.recipient = "https://other-sp.example.test/acs" },
+		"failed status":   func(r *testSAMLResponse) { r.status = "urn:oasis:names:tc:SAML:2.0:status:Requester" },
+		"expired":         func(r *testSAMLResponse) { r.notOnOrAfter = now.Add(-5 * time.Minute) },
+	}
+	for name, mutate := range invalid {
+		t.Run(name, func(t *testing.T) {
+			r := defaultTestSAMLResponse(now)
+			mutate(&r)
+			doc := idp.sign(t, r.xml(now), "a1")
+
+			_, err := sp.ParseResponse(encodeSAMLResponse(doc), now)
+			assert.ErrorIs(t, err, ErrInvalidSAMLResponse)
+		})
+	}
+
+	t.Run("not yet valid", func(t *testing.T) {
+		doc := idp.sign(t, defaultTestSAMLResponse(now).xml(now), "a1")
+
+		_, err := sp.ParseResponse(encodeSAMLResponse(doc), now.Add(-10*time.Minute))
+		assert.ErrorIs(t, err, ErrInvalidSAMLResponse)
+	})
+}
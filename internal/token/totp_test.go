@@ -0,0 +1,74 @@
+package token
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RFC 6238 Appendix B test secret ("12345678901234567890") in base32
+const rfcTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPManager_GenerateCode_RFCVectors(t *testing.T) {
+	tm := NewTOTPManager("ZenoN Cloud")
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		code, err := tm.GenerateCode(rfcTOTPSecret, time.Unix(tt.unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, code, "unix time %d", tt.unix)
+	}
+}
+
+func TestTOTPManager_Validate(t *testing.T) {
+	tm := NewTOTPManager("ZenoN Cloud")
+	secret, err := tm.GenerateSecret()
+	require.NoError(t, err)
+
+	now := time.Now()
+	code, err := tm.GenerateCode(secret, now)
+	require.NoError(t, err)
+
+	step, err := tm.Validate(secret, code, now)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(now.Unix())/30, step)
+
+	// Previous step is accepted to tolerate clock drift
+	_, err = tm.Validate(secret, code, now.Add(30*time.Second))
+	assert.NoError(t, err)
+
+	// Codes far outside the window are rejected
+	_, err = tm.Validate(secret, code, now.Add(5*time.Minute))
+	assert.ErrorIs(t, err, ErrInvalidTOTPCode)
+
+	_, err = tm.Validate(secret, "12345", now)
+	assert.ErrorIs(t, err, ErrInvalidTOTPCode)
+
+	_, err = tm.Validate("not base32!", "123456", now)
+	assert.ErrorIs(t, err, ErrInvalidTOTPSecret)
+}
+
+func TestTOTPManager_ProvisioningURI(t *testing.T) {
+	tm := NewTOTPManager("ZenoN Cloud")
+
+	uri := tm.ProvisioningURI(rfcTOTPSecret, "user@example.com")
+
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/ZenoN%20Cloud:user@example.com?"))
+	assert.Contains(t, uri, "secret="+rfcTOTPSecret)
+	assert.Contains(t, uri, "issuer=ZenoN+Cloud")
+	assert.Contains(t, uri, "digits=6")
+	assert.Contains(t, uri, "period=30")
+}
@@ -0,0 +1,315 @@
+package token
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var ErrInvalidWebAuthnResponse = errors.New("invalid WebAuthn response")
+
+// COSE algorithm identifiers accepted for passkeys.
+const (
+	COSEAlgES256 int64 = -7
+	COSEAlgEdDSA int64 = -8
+	COSEAlgRS256 int64 = -257
+)
+
+const (
+	webAuthnFlagUserPresent  = 0x01
+	webAuthnFlagUserVerified = 0x04
+	webAuthnFlagAttestedData = 0x40
+
+	webAuthnTypeCreate = "webauthn.create"
+	webAuthnTypeGet    = "webauthn.get"
+)
+
+// WebAuthnClientData is the parsed clientDataJSON sent by the browser.
+type WebAuthnClientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// WebAuthnCredential is the credential extracted from a verified registration.
+type WebAuthnCredential struct {
+	ID        []byte
+	PublicKey []byte // COSE_Key as sent by the authenticator
+	Algorithm int64
+	SignCount uint32
+	AAGUID    []byte
+}
+
+// WebAuthnVerifier checks registration and assertion responses for a single
+// relying party. It requires user verification and does not verify
+// attestation statements: the server requests "none" conveyance and trusts
+// any authenticator the user chooses.
+type WebAuthnVerifier struct {
+	rpID     string
+	rpIDHash [32]byte
+	origins  []string
+}
+
+func NewWebAuthnVerifier(rpID string, origins []string) *WebAuthnVerifier {
+	return &WebAuthnVerifier{
+		rpID:     rpID,
+		rpIDHash: sha256.Sum256([]byte(rpID)),
+		origins:  origins,
+	}
+}
+
+func (v *WebAuthnVerifier) RPID() string {
+	return v.rpID
+}
+
+// ParseClientData decodes clientDataJSON so the caller can look up the
+// challenge it was issued for. It does not verify anything.
+func (v *WebAuthnVerifier) ParseClientData(clientDataJSON []byte) (*WebAuthnClientData, error) {
+	var clientData WebAuthnClientData
+	if err := json.Unmarshal(clientDataJSON, &clientData); err != nil {
+		return nil, fmt.Errorf("%w: malformed client data", ErrInvalidWebAuthnResponse)
+	}
+	if clientData.Challenge == "" {
+		return nil, fmt.Errorf("%w: missing challenge", ErrInvalidWebAuthnResponse)
+	}
+	return &clientData, nil
+}
+
+// VerifyRegistration validates a navigator.credentials.create() response
+// against the expected challenge and returns the new credential.
+func (v *WebAuthnVerifier) VerifyRegistration(challenge string, clientDataJSON, attestationObject []byte) (*WebAuthnCredential, error) {
+	if err := v.verifyClientData(clientDataJSON, webAuthnTypeCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	decoded, rest, err := decodeCBOR(attestationObject)
+	if err != nil || len(rest) != 0 {
+		return nil, fmt.Errorf("%w: malformed attestation object", ErrInvalidWebAuthnResponse)
+	}
+	attestation, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: malformed attestation object", ErrInvalidWebAuthnResponse)
+	}
+	authData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("%w: missing authenticator data", ErrInvalidWebAuthnResponse)
+	}
+
+	flags, signCount, attested, err := v.verifyAuthenticatorData(authData)
+	if err != nil {
+		return nil, err
+	}
+	if flags&webAuthnFlagAttestedData == 0 {
+		return nil, fmt.Errorf("%w: missing attested credential data", ErrInvalidWebAuthnResponse)
+	}
+
+	// attestedCredentialData: aaguid (16) | credentialIdLength (2) | credentialId | credentialPublicKey
+	if len(attested) < 18 {
+		return nil, fmt.Errorf("%w: truncated attested credential data", ErrInvalidWebAuthnResponse)
+	}
+	aaguid := attested[:16]
+	idLen := int(binary.BigEndian.Uint16(attested[16:18]))
+	if len(attested) < 18+idLen || idLen == 0 {
+		return nil, fmt.Errorf("%w: truncated credential id", ErrInvalidWebAuthnResponse)
+	}
+	credentialID := attested[18 : 18+idLen]
+	keyData := attested[18+idLen:]
+
+	_, extensions, err := decodeCBOR(keyData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed credential public key", ErrInvalidWebAuthnResponse)
+	}
+	publicKey := keyData[:len(keyData)-len(extensions)]
+
+	alg, _, err := parseCOSEKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebAuthnCredential{
+		ID:        append([]byte(nil), credentialID...),
+		PublicKey: append([]byte(nil), publicKey...),
+		Algorithm: alg,
+		SignCount: signCount,
+		AAGUID:    append([]byte(nil), aaguid...),
+	}, nil
+}
+
+// VerifyAssertion validates a navigator.credentials.get() response signed by
+// the credential with the given COSE public key and returns the new signature
+// counter reported by the authenticator.
+func (v *WebAuthnVerifier) VerifyAssertion(challenge string, clientDataJSON, authenticatorData, signature, publicKey []byte) (uint32, error) {
+	if err := v.verifyClientData(clientDataJSON, webAuthnTypeGet, challenge); err != nil {
+		return 0, err
+	}
+
+	_, signCount, _, err := v.verifyAuthenticatorData(authenticatorData)
+	if err != nil {
+		return 0, err
+	}
+
+	_, key, err := parseCOSEKey(publicKey)
+	if err != nil {
+		return 0, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := make([]byte, 0, len(authenticatorData)+len(clientDataHash))
+	signed = append(signed, authenticatorData...)
+	signed = append(signed, clientDataHash[:]...)
+
+	if !verifyCOSESignature(key, signed, signature) {
+		return 0, fmt.Errorf("%w: signature mismatch", ErrInvalidWebAuthnResponse)
+	}
+
+	return signCount, nil
+}
+
+func (v *WebAuthnVerifier) verifyClientData(clientDataJSON []byte, ceremony, challenge string) error {
+	clientData, err := v.ParseClientData(clientDataJSON)
+	if err != nil {
+		return err
+	}
+	if clientData.Type != ceremony {
+		return fmt.Errorf("%w: unexpected ceremony type", ErrInvalidWebAuthnResponse)
+	}
+	if subtle.ConstantTimeCompare([]byte(clientData.Challenge), []byte(challenge)) != 1 {
+		return fmt.Errorf("%w: challenge mismatch", ErrInvalidWebAuthnResponse)
+	}
+	if clientData.CrossOrigin {
+		return fmt.Errorf("%w: cross-origin requests are not allowed", ErrInvalidWebAuthnResponse)
+	}
+
+	// The origin check is what makes passkeys phishing-resistant
+	for _, origin := range v.origins {
+		if clientData.Origin == origin {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: origin not allowed", ErrInvalidWebAuthnResponse)
+}
+
+// verifyAuthenticatorData checks the RP ID hash and flags and returns the
+// flags, signature counter and any trailing attested credential data.
+func (v *WebAuthnVerifier) verifyAuthenticatorData(authData []byte) (byte, uint32, []byte, error) {
+	// rpIdHash (32) | flags (1) | signCount (4)
+	if len(authData) < 37 {
+		return 0, 0, nil, fmt.Errorf("%w: truncated authenticator data", ErrInvalidWebAuthnResponse)
+	}
+	if subtle.ConstantTimeCompare(authData[:32], v.rpIDHash[:]) != 1 {
+		return 0, 0, nil, fmt.Errorf("%w: RP ID mismatch", ErrInvalidWebAuthnResponse)
+	}
+
+	flags := authData[32]
+	if flags&webAuthnFlagUserPresent == 0 {
+		return 0, 0, nil, fmt.Errorf("%w: user not present", ErrInvalidWebAuthnResponse)
+	}
+	if flags&webAuthnFlagUserVerified == 0 {
+		return 0, 0, nil, fmt.Errorf("%w: user not verified", ErrInvalidWebAuthnResponse)
+	}
+
+	return flags, binary.BigEndian.Uint32(authData[33:37]), authData[37:], nil
+}
+
+// parseCOSEKey decodes a COSE_Key (RFC 9053) for one of the supported
+// algorithms into a Go public key.
+func parseCOSEKey(data []byte) (int64, crypto.PublicKey, error) {
+	invalid := fmt.Errorf("%w: unsupported credential public key", ErrInvalidWebAuthnResponse)
+
+	decoded, rest, err := decodeCBOR(data)
+	if err != nil || len(rest) != 0 {
+		return 0, nil, invalid
+	}
+	key, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return 0, nil, invalid
+	}
+
+	kty, _ := key[int64(1)].(int64)
+	alg, _ := key[int64(3)].(int64)
+
+	switch {
+	case kty == 2 && alg == COSEAlgES256:
+		crv, _ := key[int64(-1)].(int64)
+		x, _ := key[int64(-2)].([]byte)
+		y, _ := key[int64(-3)].([]byte)
+		if crv != 1 || len(x) != 32 || len(y) != 32 {
+			return 0, nil, invalid
+		}
+		// Parsing the uncompressed point also rejects points not on the curve
+		uncompressed := make([]byte, 0, 65)
+		uncompressed = append(uncompressed, 0x04)
+		uncompressed = append(uncompressed, x...)
+		uncompressed = append(uncompressed, y...)
+		pub, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), uncompressed)
+		if err != nil {
+			return 0, nil, invalid
+		}
+		return alg, pub, nil
+	case kty == 1 && alg == COSEAlgEdDSA:
+		crv, _ := key[int64(-1)].(int64)
+		x, _ := key[int64(-2)].([]byte)
+		if crv != 6 || len(x) != ed25519.PublicKeySize {
+			return 0, nil, invalid
+		}
+		return alg, ed25519.PublicKey(bytes.Clone(x)), nil
+	case kty == 3 && alg == COSEAlgRS256:
+		n, _ := key[int64(-1)].([]byte)
+		e, _ := key[int64(-2)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return 0, nil, invalid
+		}
+		exponent := 0
+		for _, b := range e {
+			exponent = exponent<<8 | int(b)
+		}
+		return alg, &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}, nil
+	default:
+		return 0, nil, invalid
+	}
+}
+
+func verifyCOSESignature(key crypto.PublicKey, signed, signature []byte) bool {
+	switch pub := key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(signed)
+		return ecdsa.VerifyASN1(pub, digest[:], signature)
+	case ed25519.PublicKey:
+		return ed25519.Verify(pub, signed, signature)
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(signed)
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature) == nil
+	default:
+		return false
+	}
+}
+
+// EncodeWebAuthnBase64 encodes binary WebAuthn values the way browsers do
+// (base64url without padding).
+func EncodeWebAuthnBase64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeWebAuthnBase64 accepts base64url with or without padding.
+func DecodeWebAuthnBase64(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(trimBase64Padding(s))
+}
+
+func trimBase64Padding(s string) string {
+	for len(s) > 0 && s[len(s)-1] == '=' {
+		s = s[:len(s)-1]
+	}
+	return s
+}
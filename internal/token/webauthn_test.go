@@ -0,0 +1,205 @@
+package token
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRPID   = "app.zeno.test"
+	testOrigin = "https://app.zeno.test"
+)
+
+// cborEncode is a minimal CBOR encoder for building authenticator responses.
+func cborEncode(v interface{}) []byte {
+	head := func(major byte, n uint64) []byte {
+		switch {
+		case n < 24:
+			return []byte{major<<5 | byte(n)}
+		case n <= 0xff:
+			return []byte{major<<5 | 24, byte(n)}
+		case n <= 0xffff:
+			b := []byte{major<<5 | 25, 0, 0}
+			binary.BigEndian.PutUint16(b[1:], uint16(n))
+			return b
+		default:
+			b := []byte{major<<5 | 26, 0, 0, 0, 0}
+			binary.BigEndian.PutUint32(b[1:], uint32(n))
+			return b
+		}
+	}
+
+	switch val := v.(type) {
+	case int:
+		if val >= 0 {
+			return head(0, uint64(val))
+		}
+		return head(1, uint64(-1-val))
+	case []byte:
+		return append(head(2, uint64(len(val))), val...)
+	case string:
+		return append(head(3, uint64(len(val))), val...)
+	case [][2]interface{}:
+		out := head(5, uint64(len(val)))
+		for _, kv := range val {
+			out = append(out, cborEncode(kv[0])...)
+			out = append(out, cborEncode(kv[1])...)
+		}
+		return out
+	default:
+		panic("unsupported type")
+	}
+}
+
+type testAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+}
+
+func newTestAuthenticator(t *testing.T) *testAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return &testAuthenticator{key: key, credentialID: []byte("credential-1")}
+}
+
+func (a *testAuthenticator) coseKey() []byte {
+	pub, err := a.key.PublicKey.Bytes()
+	if err != nil {
+		panic(err)
+	}
+	return cborEncode([][2]interface{}{
+		{1, 2},
+		{3, -7},
+		{-1, 1},
+		{-2, pub[1:33]},
+		{-3, pub[33:65]},
+	})
+}
+
+func authenticatorData(rpID string, flags byte, signCount uint32, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append([]byte(nil), rpIDHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, signCount)
+	return append(data, attested...)
+}
+
+func clientDataJSON(t *testing.T, typ, challenge, origin string) []byte {
+	data, err := json.Marshal(WebAuthnClientData{Type: typ, Challenge: challenge, Origin: origin})
+	require.NoError(t, err)
+	return data
+}
+
+func (a *testAuthenticator) attestationObject(rpID string, flags byte) []byte {
+	attested := make([]byte, 16)
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, a.coseKey()...)
+
+	return cborEncode([][2]interface{}{
+		{"fmt", "none"},
+		{"attStmt", [][2]interface{}{}},
+		{"authData", authenticatorData(rpID, flags|webAuthnFlagAttestedData, 0, attested)},
+	})
+}
+
+func (a *testAuthenticator) sign(t *testing.T, authData, clientData []byte) []byte {
+	hash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), hash[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	require.NoError(t, err)
+	return sig
+}
+
+func TestWebAuthnVerifier_Registration(t *testing.T) {
+	v := NewWebAuthnVerifier(testRPID, []string{testOrigin})
+	auth := newTestAuthenticator(t)
+	uvFlags := byte(webAuthnFlagUserPresent | webAuthnFlagUserVerified)
+
+	cred, err := v.VerifyRegistration("challenge-1",
+		clientDataJSON(t, webAuthnTypeCreate, "challenge-1", testOrigin),
+		auth.attestationObject(testRPID, uvFlags))
+	require.NoError(t, err)
+	assert.Equal(t, auth.credentialID, cred.ID)
+	assert.Equal(t, COSEAlgES256, cred.Algorithm)
+	assert.Equal(t, auth.coseKey(), cred.PublicKey)
+
+	tests := []struct {
+		name       string
+		clientData []byte
+		attObj     []byte
+	}{
+		{"phishing origin", clientDataJSON(t, webAuthnTypeCreate, "challenge-1", "https://evil.test"), auth.attestationObject(testRPID, uvFlags)},
+		{"wrong challenge", clientDataJSON(t, webAuthnTypeCreate, "other", testOrigin), auth.attestationObject(testRPID, uvFlags)},
+		{"wrong ceremony", clientDataJSON(t, webAuthnTypeGet, "challenge-1", testOrigin), auth.attestationObject(testRPID, uvFlags)},
+		{"wrong rp id", clientDataJSON(t, webAuthnTypeCreate, "challenge-1", testOrigin), auth.attestationObject("evil.test", uvFlags)},
+		{"no user verification", clientDataJSON(t, webAuthnTypeCreate, "challenge-1", testOrigin), auth.attestationObject(testRPID, webAuthnFlagUserPresent)},
+		{"garbage attestation", clientDataJSON(t, webAuthnTypeCreate, "challenge-1", testOrigin), []byte{0xff, 0x00}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.VerifyRegistration("challenge-1", tt.clientData, tt.attObj)
+			assert.ErrorIs(t, err, ErrInvalidWebAuthnResponse)
+		})
+	}
+}
+
+func TestWebAuthnVerifier_Assertion(t *testing.T) {
+	v := NewWebAuthnVerifier(testRPID, []string{testOrigin})
+	auth := newTestAuthenticator(t)
+
+	authData := authenticatorData(testRPID, webAuthnFlagUserPresent|webAuthnFlagUserVerified, 7, nil)
+	clientData := clientDataJSON(t, webAuthnTypeGet, "challenge-2", testOrigin)
+	sig := auth.sign(t, authData, clientData)
+
+	signCount, err := v.VerifyAssertion("challenge-2", clientData, authData, sig, auth.coseKey())
+	require.NoError(t, err)
+	assert.Equal(t, uint32(7), signCount)
+
+	// Signature from a different key
+	other := newTestAuthenticator(t)
+	_, err = v.VerifyAssertion("challenge-2", clientData, authData, sig, other.coseKey())
+	assert.ErrorIs(t, err, ErrInvalidWebAuthnResponse)
+
+	// Tampered authenticator data
+	tampered := append([]byte(nil), authData...)
+	tampered[len(tampered)-1] = 8
+	_, err = v.VerifyAssertion("challenge-2", clientData, tampered, sig, auth.coseKey())
+	assert.ErrorIs(t, err, ErrInvalidWebAuthnResponse)
+}
+
+func TestWebAuthnVerifier_AssertionEd25519(t *testing.T) {
+	v := NewWebAuthnVerifier(testRPID, []string{testOrigin})
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	coseKey := cborEncode([][2]interface{}{{1, 1}, {3, -8}, {-1, 6}, {-2, []byte(pub)}})
+
+	authData := authenticatorData(testRPID, webAuthnFlagUserPresent|webAuthnFlagUserVerified, 0, nil)
+	clientData := clientDataJSON(t, webAuthnTypeGet, "challenge-3", testOrigin)
+	hash := sha256.Sum256(clientData)
+	sig := ed25519.Sign(priv, append(append([]byte(nil), authData...), hash[:]...))
+
+	_, err = v.VerifyAssertion("challenge-3", clientData, authData, sig, coseKey)
+	assert.NoError(t, err)
+}
+
+func TestDecodeCBOR_RejectsTruncatedInput(t *testing.T) {
+	encoded := cborEncode([][2]interface{}{{"key", []byte("value")}})
+
+	_, _, err := decodeCBOR(encoded[:len(encoded)-2])
+	assert.ErrorIs(t, err, errInvalidCBOR)
+
+	value, rest, err := decodeCBOR(encoded)
+	require.NoError(t, err)
+	assert.Empty(t, rest)
+	assert.Equal(t, map[interface{}]interface{}{"key": []byte("value")}, value)
+}
@@ -0,0 +1,554 @@
+package token
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrInvalidXML          = errors.New("invalid XML document")
+	ErrInvalidXMLSignature = errors.New("invalid XML signature")
+)
+
+// XML namespaces and XML-DSig algorithm identifiers. Only exclusive
+// canonicalization and SHA-2 digests are accepted.
+const (
+	xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+	XMLDSigNamespace      = "http://www.w3.org/2000/09/xmldsig#"
+	xmlExcC14N            = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	xmlEnvelopedSignature = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+
+	xmlDigestSHA256 = "http://www.w3.org/2001/04/xmlenc#sha256"
+	xmlDigestSHA512 = "http://www.w3.org/2001/04/xmlenc#sha512"
+
+	xmlSigRSASHA256   = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	xmlSigRSASHA512   = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha512"
+	xmlSigECDSASHA256 = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha256"
+	xmlSigECDSASHA512 = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha512"
+)
+
+// XMLElement is an element of a parsed document. Unlike encoding/xml
+// unmarshalling it keeps namespace prefixes, declarations and text exactly
+// as sent, which is what signature verification needs.
+type XMLElement struct {
+	Space  string // namespace URI
+	Prefix string
+	Local  string
+	Attrs  []XMLAttr
+
+	parent   *XMLElement
+	children []xmlNode
+	nsDecls  map[string]string // prefix ("" for the default namespace) -> URI
+}
+
+// XMLAttr is an attribute other than a namespace declaration.
+type XMLAttr struct {
+	Space  string // namespace URI, empty for unprefixed attributes
+	Prefix string
+	Local  string
+	Value  string
+}
+
+// xmlNode is either a child element or character data.
+type xmlNode struct {
+	elem *XMLElement
+	text string
+}
+
+// ParseXML parses a document into an element tree. Documents with a DTD are
+// rejected, comments and processing instructions are dropped.
+func ParseXML(data []byte) (*XMLElement, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = true
+
+	var root, current *XMLElement
+	for {
+		tok, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidXML, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if current == nil && root != nil {
+				return nil, fmt.Errorf("%w: multiple root elements", ErrInvalidXML)
+			}
+			elem, err := newXMLElement(t, current)
+			if err != nil {
+				return nil, err
+			}
+			if current == nil {
+				root = elem
+			} else {
+				current.children = append(current.children, xmlNode{elem: elem})
+			}
+			current = elem
+		case xml.EndElement:
+			if current == nil || current.Prefix != t.Name.Space || current.Local != t.Name.Local {
+				return nil, fmt.Errorf("%w: unexpected end element %s", ErrInvalidXML, t.Name.Local)
+			}
+			current = current.parent
+		case xml.CharData:
+			if current != nil {
+				current.children = append(current.children, xmlNode{text: string(t)})
+			} else if len(bytes.TrimSpace(t)) > 0 {
+				return nil, fmt.Errorf("%w: text outside the root element", ErrInvalidXML)
+			}
+		case xml.Directive:
+			return nil, fmt.Errorf("%w: DTDs are not allowed", ErrInvalidXML)
+		}
+	}
+
+	if root == nil || current != nil {
+		return nil, fmt.Errorf("%w: unexpected end of document", ErrInvalidXML)
+	}
+	return root, nil
+}
+
+func newXMLElement(t xml.StartElement, parent *XMLElement) (*XMLElement, error) {
+	elem := &XMLElement{Prefix: t.Name.Space, Local: t.Name.Local, parent: parent}
+
+	for _, attr := range t.Attr {
+		switch {
+		case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+			elem.declare("", attr.Value)
+		case attr.Name.Space == "xmlns":
+			elem.declare(attr.Name.Local, attr.Value)
+		default:
+			elem.Attrs = append(elem.Attrs, XMLAttr{Prefix: attr.Name.Space, Local: attr.Name.Local, Value: attr.Value})
+		}
+	}
+
+	space, ok := elem.lookupNamespace(elem.Prefix)
+	if !ok {
+		return nil, fmt.Errorf("%w: undeclared prefix %q", ErrInvalidXML, elem.Prefix)
+	}
+	elem.Space = space
+	for i := range elem.Attrs {
+		if elem.Attrs[i].Prefix == "" {
+			continue
+		}
+		space, ok := elem.lookupNamespace(elem.Attrs[i].Prefix)
+		if !ok {
+			return nil, fmt.Errorf("%w: undeclared prefix %q", ErrInvalidXML, elem.Attrs[i].Prefix)
+		}
+		elem.Attrs[i].Space = space
+	}
+	return elem, nil
+}
+
+func (e *XMLElement) declare(prefix, uri string) {
+	if e.nsDecls == nil {
+		e.nsDecls = make(map[string]string)
+	}
+	e.nsDecls[prefix] = uri
+}
+
+// lookupNamespace resolves a prefix in the scope of the element. The default
+// namespace is always in scope, possibly as the empty namespace.
+func (e *XMLElement) lookupNamespace(prefix string) (string, bool) {
+	if prefix == "xml" {
+		return xmlNamespace, true
+	}
+	for el := e; el != nil; el = el.parent {
+		if uri, ok := el.nsDecls[prefix]; ok {
+			return uri, true
+		}
+	}
+	return "", prefix == ""
+}
+
+// Attr returns the value of an unprefixed attribute.
+func (e *XMLElement) Attr(local string) string {
+	for _, attr := range e.Attrs {
+		if attr.Space == "" && attr.Local == local {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// Child returns the first child element with the given name.
+func (e *XMLElement) Child(space, local string) *XMLElement {
+	for _, child := range e.children {
+		if child.elem != nil && child.elem.Space == space && child.elem.Local == local {
+			return child.elem
+		}
+	}
+	return nil
+}
+
+// Children returns all child elements with the given name.
+func (e *XMLElement) Children(space, local string) []*XMLElement {
+	var elems []*XMLElement
+	for _, child := range e.children {
+		if child.elem != nil && child.elem.Space == space && child.elem.Local == local {
+			elems = append(elems, child.elem)
+		}
+	}
+	return elems
+}
+
+// Text returns the character data of the element, without descendants.
+func (e *XMLElement) Text() string {
+	var b strings.Builder
+	for _, child := range e.children {
+		if child.elem == nil {
+			b.WriteString(child.text)
+		}
+	}
+	return b.String()
+}
+
+// walk calls fn for the element and all of its descendants.
+func (e *XMLElement) walk(fn func(*XMLElement)) {
+	fn(e)
+	for _, child := range e.children {
+		if child.elem != nil {
+			child.elem.walk(fn)
+		}
+	}
+}
+
+// HasDuplicateIDs reports whether two elements in the tree carry the same ID
+// attribute. Such documents are rejected to rule out signature wrapping.
+func (e *XMLElement) HasDuplicateIDs() bool {
+	seen := map[string]bool{}
+	duplicate := false
+	e.walk(func(el *XMLElement) {
+		if id := el.Attr("ID"); id != "" {
+			if seen[id] {
+				duplicate = true
+			}
+			seen[id] = true
+		}
+	})
+	return duplicate
+}
+
+// CanonicalizeExclusive serializes the element with Exclusive XML
+// Canonicalization 1.0 (without comments). Prefixes in inclusive are rendered
+// wherever they are in scope ("#default" for the default namespace); the
+// excluded element and its descendants are omitted, which implements the
+// enveloped-signature transform.
+func CanonicalizeExclusive(e *XMLElement, inclusive []string, exclude *XMLElement) []byte {
+	inclusivePrefixes := make(map[string]bool, len(inclusive))
+	for _, prefix := range inclusive {
+		if prefix == "#default" {
+			prefix = ""
+		}
+		inclusivePrefixes[prefix] = true
+	}
+
+	var buf bytes.Buffer
+	e.canonicalize(&buf, map[string]string{}, inclusivePrefixes, exclude)
+	return buf.Bytes()
+}
+
+func (e *XMLElement) canonicalize(buf *bytes.Buffer, rendered map[string]string, inclusive map[string]bool, exclude *XMLElement) {
+	// Namespaces visibly utilized by the element and its attributes
+	utilized := map[string]bool{e.Prefix: true}
+	for _, attr := range e.Attrs {
+		if attr.Prefix != "" && attr.Prefix != "xml" {
+			utilized[attr.Prefix] = true
+		}
+	}
+	for prefix := range inclusive {
+		if _, ok := e.lookupNamespace(prefix); ok {
+			utilized[prefix] = true
+		}
+	}
+
+	type nsDecl struct{ prefix, uri string }
+	var decls []nsDecl
+	for prefix := range utilized {
+		uri, ok := e.lookupNamespace(prefix)
+		if !ok {
+			continue
+		}
+		current, isRendered := rendered[prefix]
+		if prefix == "" && uri == "" {
+			// xmlns="" is only needed to undo a default namespace of an output ancestor
+			if !isRendered || current == "" {
+				continue
+			}
+		} else if isRendered && current == uri {
+			continue
+		}
+		decls = append(decls, nsDecl{prefix, uri})
+	}
+	sort.Slice(decls, func(i, j int) bool { return decls[i].prefix < decls[j].prefix })
+
+	attrs := append([]XMLAttr(nil), e.Attrs...)
+	sort.Slice(attrs, func(i, j int) bool {
+		if attrs[i].Space != attrs[j].Space {
+			return attrs[i].Space < attrs[j].Space
+		}
+		return attrs[i].Local < attrs[j].Local
+	})
+
+	buf.WriteByte('<')
+	buf.WriteString(qualifiedName(e.Prefix, e.Local))
+	for _, decl := range decls {
+		if decl.prefix == "" {
+			buf.WriteString(` xmlns="`)
+		} else {
+			buf.WriteString(` xmlns:` + decl.prefix + `="`)
+		}
+		writeC14NAttrValue(buf, decl.uri)
+		buf.WriteByte('"')
+	}
+	for _, attr := range attrs {
+		buf.WriteString(" " + qualifiedName(attr.Prefix, attr.Local) + `="`)
+		writeC14NAttrValue(buf, attr.Value)
+		buf.WriteByte('"')
+	}
+	buf.WriteByte('>')
+
+	childRendered := rendered
+	if len(decls) > 0 {
+		childRendered = make(map[string]string, len(rendered)+len(decls))
+		for prefix, uri := range rendered {
+			childRendered[prefix] = uri
+		}
+		for _, decl := range decls {
+			childRendered[decl.prefix] = decl.uri
+		}
+	}
+	for _, child := range e.children {
+		switch {
+		case child.elem == nil:
+			writeC14NText(buf, child.text)
+		case child.elem != exclude:
+			child.elem.canonicalize(buf, childRendered, inclusive, exclude)
+		}
+	}
+
+	buf.WriteString("</" + qualifiedName(e.Prefix, e.Local) + ">")
+}
+
+func qualifiedName(prefix, local string) string {
+	if prefix == "" {
+		return local
+	}
+	return prefix + ":" + local
+}
+
+func writeC14NText(buf *bytes.Buffer, s string) {
+	for _, r := range s {
+		switch r {
+		case '&':
+			buf.WriteString("&amp;")
+		case '<':
+			buf.WriteString("&lt;")
+		case '>':
+			buf.WriteString("&gt;")
+		case '\r':
+			buf.WriteString("&#xD;")
+		default:
+			buf.WriteRune(r)
+		}
+	}
+}
+
+func writeC14NAttrValue(buf *bytes.Buffer, s string) {
+	for _, r := range s {
+		switch r {
+		case '&':
+			buf.WriteString("&amp;")
+		case '<':
+			buf.WriteString("&lt;")
+		case '"':
+			buf.WriteString("&quot;")
+		case '\t':
+			buf.WriteString("&#x9;")
+		case '\n':
+			buf.WriteString("&#xA;")
+		case '\r':
+			buf.WriteString("&#xD;")
+		default:
+			buf.WriteRune(r)
+		}
+	}
+}
+
+// SignatureElement returns the enveloped ds:Signature child of the element, or
+// nil if it is not signed.
+func (e *XMLElement) SignatureElement() *XMLElement {
+	return e.Child(XMLDSigNamespace, "Signature")
+}
+
+// VerifyEnvelopedSignature checks the ds:Signature child of the element
+// against the trusted certificates. The signature must have a single
+// reference to the element itself, so a valid signature always covers
+// exactly the element the caller reads data from. Certificates embedded in
+// the signature's KeyInfo are ignored.
+func VerifyEnvelopedSignature(e *XMLElement, certs []*x509.Certificate) error {
+	signatures := e.Children(XMLDSigNamespace, "Signature")
+	if len(signatures) != 1 {
+		return fmt.Errorf("%w: expected one signature, found %d", ErrInvalidXMLSignature, len(signatures))
+	}
+	signature := signatures[0]
+
+	signedInfo := signature.Child(XMLDSigNamespace, "SignedInfo")
+	if signedInfo == nil {
+		return fmt.Errorf("%w: missing SignedInfo", ErrInvalidXMLSignature)
+	}
+
+	c14nMethod := signedInfo.Child(XMLDSigNamespace, "CanonicalizationMethod")
+	if c14nMethod == nil || c14nMethod.Attr("Algorithm") != xmlExcC14N {
+		return fmt.Errorf("%w: unsupported canonicalization method", ErrInvalidXMLSignature)
+	}
+	signatureMethod := signedInfo.Child(XMLDSigNamespace, "SignatureMethod")
+	if signatureMethod == nil {
+		return fmt.Errorf("%w: missing SignatureMethod", ErrInvalidXMLSignature)
+	}
+
+	references := signedInfo.Children(XMLDSigNamespace, "Reference")
+	if len(references) != 1 {
+		return fmt.Errorf("%w: expected one reference, found %d", ErrInvalidXMLSignature, len(references))
+	}
+	if err := verifyReference(e, signature, references[0]); err != nil {
+		return err
+	}
+
+	signatureValue := signature.Child(XMLDSigNamespace, "SignatureValue")
+	if signatureValue == nil {
+		return fmt.Errorf("%w: missing SignatureValue", ErrInvalidXMLSignature)
+	}
+	sig, err := decodeXMLBase64(signatureValue.Text())
+	if err != nil {
+		return fmt.Errorf("%w: malformed SignatureValue", ErrInvalidXMLSignature)
+	}
+
+	canonical := CanonicalizeExclusive(signedInfo, inclusiveNamespaces(c14nMethod), nil)
+	for _, cert := range certs {
+		if err := verifyXMLSignatureValue(signatureMethod.Attr("Algorithm"), cert.PublicKey, canonical, sig); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: signature does not match any trusted certificate", ErrInvalidXMLSignature)
+}
+
+func verifyReference(e, signature, reference *XMLElement) error {
+	id := e.Attr("ID")
+	if id == "" || reference.Attr("URI") != "#"+id {
+		return fmt.Errorf("%w: reference does not point to the signed element", ErrInvalidXMLSignature)
+	}
+
+	var inclusive []string
+	enveloped, canonicalized := false, false
+	if transforms := reference.Child(XMLDSigNamespace, "Transforms"); transforms != nil {
+		for _, transform := range transforms.Children(XMLDSigNamespace, "Transform") {
+			switch transform.Attr("Algorithm") {
+			case xmlEnvelopedSignature:
+				enveloped = true
+			case xmlExcC14N:
+				canonicalized = true
+				inclusive = inclusiveNamespaces(transform)
+			default:
+				return fmt.Errorf("%w: unsupported transform %q", ErrInvalidXMLSignature, transform.Attr("Algorithm"))
+			}
+		}
+	}
+	if !enveloped || !canonicalized {
+		return fmt.Errorf("%w: reference must use the enveloped-signature and exclusive c14n transforms", ErrInvalidXMLSignature)
+	}
+
+	digestMethod := reference.Child(XMLDSigNamespace, "DigestMethod")
+	digestValue := reference.Child(XMLDSigNamespace, "DigestValue")
+	if digestMethod == nil || digestValue == nil {
+		return fmt.Errorf("%w: missing digest", ErrInvalidXMLSignature)
+	}
+	var hash crypto.Hash
+	switch digestMethod.Attr("Algorithm") {
+	case xmlDigestSHA256:
+		hash = crypto.SHA256
+	case xmlDigestSHA512:
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("%w: unsupported digest method %q", ErrInvalidXMLSignature, digestMethod.Attr("Algorithm"))
+	}
+	expected, err := decodeXMLBase64(digestValue.Text())
+	if err != nil {
+		return fmt.Errorf("%w: malformed DigestValue", ErrInvalidXMLSignature)
+	}
+
+	h := hash.New()
+	h.Write(CanonicalizeExclusive(e, inclusive, signature))
+	if subtle.ConstantTimeCompare(h.Sum(nil), expected) != 1 {
+		return fmt.Errorf("%w: digest mismatch", ErrInvalidXMLSignature)
+	}
+	return nil
+}
+
+// inclusiveNamespaces reads the ec:InclusiveNamespaces PrefixList of a
+// canonicalization method or transform.
+func inclusiveNamespaces(method *XMLElement) []string {
+	list := method.Child(xmlExcC14N, "InclusiveNamespaces")
+	if list == nil {
+		return nil
+	}
+	return strings.Fields(list.Attr("PrefixList"))
+}
+
+func verifyXMLSignatureValue(algorithm string, publicKey crypto.PublicKey, signed, sig []byte) error {
+	var hash crypto.Hash
+	switch algorithm {
+	case xmlSigRSASHA256, xmlSigECDSASHA256:
+		hash = crypto.SHA256
+	case xmlSigRSASHA512, xmlSigECDSASHA512:
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("%w: unsupported signature method %q", ErrInvalidXMLSignature, algorithm)
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		if algorithm != xmlSigRSASHA256 && algorithm != xmlSigRSASHA512 {
+			return ErrInvalidXMLSignature
+		}
+		return rsa.VerifyPKCS1v15(key, hash, digest, sig)
+	case *ecdsa.PublicKey:
+		if algorithm != xmlSigECDSASHA256 && algorithm != xmlSigECDSASHA512 {
+			return ErrInvalidXMLSignature
+		}
+		// XML-DSig encodes ECDSA signatures as r || s
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return ErrInvalidXMLSignature
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return ErrInvalidXMLSignature
+		}
+		return nil
+	default:
+		return ErrInvalidXMLSignature
+	}
+}
+
+// decodeXMLBase64 decodes base64 content that may be wrapped over several lines.
+func decodeXMLBase64(s string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+}
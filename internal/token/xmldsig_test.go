@@ -0,0 +1,227 @@
+package token
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testIdP signs documents the way SAML identity providers do, with a locally
+// generated self-signed certificate.
+type testIdP struct {
+	key  *rsa.PrivateKey
+	cert *x509.Certificate
+}
+
+func newTestIdP(t *testing.T) *testIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp.example.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testIdP{key: key, cert: cert}
+}
+
+// sign replaces the <!--SIG:id--> placeholder with an enveloped signature
+// over the element with that ID.
+func (idp *testIdP) sign(t *testing.T, doc, id string, inclusive ...string) string {
+	t.Helper()
+	root, err := ParseXML([]byte(doc))
+	require.NoError(t, err)
+
+	var target *XMLElement
+	root.walk(func(e *XMLElement) {
+		if e.Attr("ID") == id {
+			target = e
+		}
+	})
+	require.NotNil(t, target)
+
+	prefixList := ""
+	if len(inclusive) > 0 {
+		prefixList = `<ec:InclusiveNamespaces xmlns:ec="` + xmlExcC14N + `" PrefixList="` + strings.Join(inclusive, " ") + `"/>`
+	}
+
+	digest := sha256.Sum256(CanonicalizeExclusive(target, inclusive, nil))
+	signedInfo := `<ds:SignedInfo>` +
+		`<ds:CanonicalizationMethod Algorithm="` + xmlExcC14N + `"/>` +
+		`<ds:SignatureMethod Algorithm="` + xmlSigRSASHA256 + `"/>` +
+		`<ds:Reference URI="#` + id + `"><ds:Transforms>` +
+		`<ds:Transform Algorithm="` + xmlEnvelopedSignature + `"/>` +
+		`<ds:Transform Algorithm="` + xmlExcC14N + `">` + prefixList + `</ds:Transform>` +
+		`</ds:Transforms><ds:DigestMethod Algorithm="` + xmlDigestSHA256 + `"/>` +
+		`<ds:DigestValue>` + base64.StdEncoding.EncodeToString(digest[:]) + `</ds:DigestValue>` +
+		`</ds:Reference></ds:SignedInfo>`
+
+	standalone, err := ParseXML([]byte(strings.Replace(signedInfo, "<ds:SignedInfo>", `<ds:SignedInfo xmlns:ds="`+XMLDSigNamespace+`">`, 1)))
+	require.NoError(t, err)
+	hashed := sha256.Sum256(CanonicalizeExclusive(standalone, nil, nil))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, hashed[:])
+	require.NoError(t, err)
+
+	signature := `<ds:Signature xmlns:ds="` + XMLDSigNamespace + `">` + signedInfo +
+		"<ds:SignatureValue>\n" + base64.StdEncoding.EncodeToString(sig) + "\n</ds:SignatureValue>" +
+		`<ds:KeyInfo><ds:X509Data><ds:X509Certificate>` + base64.StdEncoding.EncodeToString(idp.cert.Raw) +
+		`</ds:X509Certificate></ds:X509Data></ds:KeyInfo></ds:Signature>`
+	return strings.Replace(doc, "<!--SIG:"+id+"-->", signature, 1)
+}
+
+func TestParseXML(t *testing.T) {
+	root, err := ParseXML([]byte(`<?xml version="1.0"?><!-- c --><a:root xmlns:a="urn:a" xmlns="urn:d" ID="x"><child>t&amp;x<![CDATA[<y>]]></child></a:root>`))
+	require.NoError(t, err)
+	assert.Equal(t, "urn:a", root.Space)
+	assert.Equal(t, "x", root.Attr("ID"))
+	child := root.Child("urn:d", "child")
+	require.NotNil(t, child)
+	assert.Equal(t, "t&x<y>", child.Text())
+
+	invalid := []string{
+		`<!DOCTYPE a [<!ENTITY e "x">]><a>&e;</a>`,
+		`<a><b></a></b>`,
+		`<a></a><b></b>`,
+		`<p:a></p:a>`,
+		`<a>`,
+		``,
+	}
+	for _, doc := range invalid {
+		_, err := ParseXML([]byte(doc))
+		assert.ErrorIs(t, err, ErrInvalidXML, doc)
+	}
+}
+
+func TestCanonicalizeExclusive(t *testing.T) {
+	tests := []struct {
+		name      string
+		doc       string
+		id        string
+		inclusive []string
+		want      string
+	}{
+		{
+			name: "renders only visibly utilized namespaces",
+			doc: `<samlp:Response xmlns:samlp="urn:p" xmlns:saml="urn:a" xmlns:unused="urn:u" ID="r1">` +
+				`<saml:Assertion Version="2.0" ID="a1"><saml:Issuer>idp</saml:Issuer>` +
+				`<saml:AttributeValue xmlns:xs="urn:xs" xmlns:xsi="urn:xsi" xsi:type="xs:string">v &amp; &lt;x&gt;</saml:AttributeValue>` +
+				`<empty/></saml:Assertion></samlp:Response>`,
+			id: "a1",
+			want: `<saml:Assertion xmlns:saml="urn:a" ID="a1" Version="2.0"><saml:Issuer>idp</saml:Issuer>` +
+				`<saml:AttributeValue xmlns:xsi="urn:xsi" xsi:type="xs:string">v &amp; &lt;x&gt;</saml:AttributeValue>` +
+				`<empty></empty></saml:Assertion>`,
+		},
+		{
+			name:      "inclusive prefixes are rendered where in scope",
+			doc:       `<a:r xmlns:a="urn:a" xmlns:xs="urn:xs" ID="r1"><a:v xmlns:xsi="urn:xsi" xsi:type="xs:string">1</a:v></a:r>`,
+			id:        "r1",
+			inclusive: []string{"xs"},
+			want:      `<a:r xmlns:a="urn:a" xmlns:xs="urn:xs" ID="r1"><a:v xmlns:xsi="urn:xsi" xsi:type="xs:string">1</a:v></a:r>`,
+		},
+		{
+			name: "default namespace is undeclared only when rendered",
+			doc:  `<a xmlns="urn:d" ID="r1"><b xmlns=""><c ID="c1"/></b></a>`,
+			id:   "r1",
+			want: `<a xmlns="urn:d" ID="r1"><b xmlns=""><c ID="c1"></c></b></a>`,
+		},
+		{
+			name: "empty default namespace of the apex is not rendered",
+			doc:  `<a xmlns="urn:d"><b xmlns=""><c ID="c1"/></b></a>`,
+			id:   "c1",
+			want: `<c ID="c1"></c>`,
+		},
+		{
+			name: "sorts namespaces by prefix and attributes by namespace URI",
+			doc:  `<e xmlns:b="urn:b" xmlns:a="urn:z" b:x="1" a:y="2" z="3" ID="4"/>`,
+			id:   "4",
+			want: `<e xmlns:a="urn:z" xmlns:b="urn:b" ID="4" z="3" b:x="1" a:y="2"></e>`,
+		},
+		{
+			name: "escapes attribute values and text",
+			doc:  "<e ID=\"1\" v=\"a&#10;b&quot;&#9;&gt;\">x\r\ny&#13;z\"</e>",
+			id:   "1",
+			want: "<e ID=\"1\" v=\"a&#xA;b&quot;&#x9;>\">x\ny&#xD;z\"</e>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := ParseXML([]byte(tt.doc))
+			require.NoError(t, err)
+
+			var target *XMLElement
+			root.walk(func(e *XMLElement) {
+				if e.Attr("ID") == tt.id {
+					target = e
+				}
+			})
+			require.NotNil(t, target)
+			assert.Equal(t, tt.want, string(CanonicalizeExclusive(target, tt.inclusive, nil)))
+		})
+	}
+}
+
+func TestVerifyEnvelopedSignature(t *testing.T) {
+	idp := newTestIdP(t)
+	other := newTestIdP(t)
+
+	doc := `<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" ID="r1">
+  <saml:Assertion xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" xmlns:xs="http://www.w3.org/2001/XMLSchema" ID="a1">
+    <saml:Issuer>https://idp.example.test</saml:Issuer><!--SIG:a1-->
+    <saml:Subject><saml:NameID>alice@example.test</saml:NameID></saml:Subject>
+  </saml:Assertion>
+</samlp:Response>`
+	signed := idp.sign(t, doc, "a1", "xs")
+
+	verify := func(doc string, certs ...*x509.Certificate) error {
+		root, err := ParseXML([]byte(doc))
+		require.NoError(t, err)
+		return VerifyEnvelopedSignature(root.Child(samlAssertionNamespace, "Assertion"), certs)
+	}
+
+	t.Run("valid signature", func(t *testing.T) {
+		assert.NoError(t, verify(signed, idp.cert))
+		assert.NoError(t, verify(signed, other.cert, idp.cert))
+	})
+
+	t.Run("untrusted certificate", func(t *testing.T) {
+		assert.ErrorIs(t, verify(signed, other.cert), ErrInvalidXMLSignature)
+	})
+
+	t.Run("tampered content", func(t *testing.T) {
+		tampered := strings.Replace(signed, "alice@example.test", "mallory@example.test", 1)
+		assert.ErrorIs(t, verify(tampered, idp.cert), ErrInvalidXMLSignature)
+	})
+
+	t.Run("unsigned element", func(t *testing.T) {
+		assert.ErrorIs(t, verify(strings.Replace(doc, "<!--SIG:a1-->", "", 1), idp.cert), ErrInvalidXMLSignature)
+	})
+
+	t.Run("reference to another element", func(t *testing.T) {
+		moved := strings.Replace(signed, `ID="a1"`, `ID="a2"`, 1)
+		assert.ErrorIs(t, verify(moved, idp.cert), ErrInvalidXMLSignature)
+	})
+
+	t.Run("whitespace outside the signed element does not matter", func(t *testing.T) {
+		reformatted := strings.Replace(signed, "\n</samlp:Response>", "\n\n   </samlp:Response>", 1)
+		assert.NoError(t, verify(reformatted, idp.cert))
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"regexp"
 	"strings"
+	"time"
 	"unicode"
 )
 
@@ -12,10 +13,16 @@ var (
 	ErrNameTooLong      = errors.New("name must be less than 100 characters")
 	ErrNameInvalidChars = errors.New("name contains invalid characters")
 	ErrEmailTooLong     = errors.New("email must be less than 255 characters")
+	ErrInvalidLocale    = errors.New("locale must be a language tag such as en or de-DE")
+	ErrInvalidTimezone  = errors.New("timezone must be an IANA time zone such as Europe/Berlin")
 )
 
 var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
 
+// localeRegex matches BCP 47 tags made of a language, an optional script and
+// an optional region, e.g. en, de-DE, zh-Hant-TW or es-419.
+var localeRegex = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z]{4})?(-([a-zA-Z]{2}|[0-9]{3}))?$`)
+
 // InputValidator validates and sanitizes user input
 type InputValidator struct{}
 
@@ -84,6 +91,44 @@ func (v *InputValidator) SanitizeEmail(email string) string {
 	return strings.ToLower(strings.TrimSpace(email))
 }
 
+// NormalizeLocale returns the locale in canonical case (de-DE, zh-Hant-TW),
+// accepting underscores as separators.
+func (v *InputValidator) NormalizeLocale(locale string) string {
+	parts := strings.Split(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"), "-")
+	for i, part := range parts {
+		switch {
+		case i == 0:
+			parts[i] = strings.ToLower(part)
+		case len(part) == 4:
+			parts[i] = strings.ToUpper(part[:1]) + strings.ToLower(part[1:])
+		default:
+			parts[i] = strings.ToUpper(part)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// ValidateLocale checks that locale is a BCP 47 language tag
+func (v *InputValidator) ValidateLocale(locale string) error {
+	if !localeRegex.MatchString(locale) {
+		return ErrInvalidLocale
+	}
+	return nil
+}
+
+// ValidateTimezone checks that timezone is a name from the IANA time zone
+// database
+func (v *InputValidator) ValidateTimezone(timezone string) error {
+	// LoadLocation also accepts "" and "Local", which are not zones
+	if timezone == "" || timezone == "Local" {
+		return ErrInvalidTimezone
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return ErrInvalidTimezone
+	}
+	return nil
+}
+
 func removeHTMLTags(s string) string {
 	if s == "" {
 		return ""
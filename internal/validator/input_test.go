@@ -160,3 +160,40 @@ func TestInputValidator_SanitizeEmail(t *testing.T) {
 		})
 	}
 }
+
+func TestInputValidator_Locale(t *testing.T) {
+	validator := NewInputValidator()
+
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  error
+	}{
+		{"en", "en", nil},
+		{" de_de ", "de-DE", nil},
+		{"zh-hant-tw", "zh-Hant-TW", nil},
+		{"es-419", "es-419", nil},
+		{"english", "english", ErrInvalidLocale},
+		{"en-US-x-private", "en-US-X-PRIVATE", ErrInvalidLocale},
+		{"", "", ErrInvalidLocale},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			locale := validator.NormalizeLocale(tt.input)
+			assert.Equal(t, tt.expected, locale)
+			assert.Equal(t, tt.wantErr, validator.ValidateLocale(locale))
+		})
+	}
+}
+
+func TestInputValidator_ValidateTimezone(t *testing.T) {
+	validator := NewInputValidator()
+
+	for _, timezone := range []string{"UTC", "Europe/Berlin", "America/Argentina/Buenos_Aires"} {
+		assert.NoError(t, validator.ValidateTimezone(timezone), timezone)
+	}
+	for _, timezone := range []string{"", "Local", "Mars/Olympus", "../etc/passwd", "+02:00"} {
+		assert.Equal(t, ErrInvalidTimezone, validator.ValidateTimezone(timezone), timezone)
+	}
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS webauthn_challenges CASCADE;
+DROP TABLE IF EXISTS webauthn_credentials CASCADE;
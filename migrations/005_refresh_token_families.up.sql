@@ -0,0 +1,9 @@
+-- Refresh token rotation: every login starts a family, every refresh replaces
+-- the presented token with a new one in the same family.
+ALTER TABLE refresh_tokens ADD COLUMN family_id UUID;
+ALTER TABLE refresh_tokens ADD COLUMN replaced_by_id UUID REFERENCES refresh_tokens(id) ON DELETE SET NULL;
+
+UPDATE refresh_tokens SET family_id = id WHERE family_id IS NULL;
+ALTER TABLE refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens(family_id);
@@ -0,0 +1,9 @@
+-- Access token denylist for single-instance and Redis-less deployments.
+-- Keys are revoked:<jti>, revoked:session:<sid> and revoked:user:<user_id>.
+CREATE TABLE token_revocations (
+    key        TEXT PRIMARY KEY,
+    value      BIGINT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_token_revocations_expires_at ON token_revocations(expires_at);
@@ -0,0 +1,2 @@
+ALTER TABLE oauth_clients DROP CONSTRAINT IF EXISTS oauth_clients_client_credentials_secret;
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS grant_types;
@@ -0,0 +1,7 @@
+-- Grants a client may use. Machine clients use client_credentials to obtain
+-- tokens for the internal API; they have no redirect URIs.
+ALTER TABLE oauth_clients ADD COLUMN grant_types TEXT[] NOT NULL DEFAULT '{authorization_code}';
+
+-- Machine clients must be confidential
+ALTER TABLE oauth_clients ADD CONSTRAINT oauth_clients_client_credentials_secret
+    CHECK (NOT ('client_credentials' = ANY(grant_types)) OR client_secret_hash IS NOT NULL);
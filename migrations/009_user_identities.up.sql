@@ -0,0 +1,28 @@
+-- Accounts at upstream identity providers (Google, Microsoft, GitHub, ...)
+-- linked to users. subject is the provider's stable user id.
+CREATE TABLE user_identities (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    subject TEXT NOT NULL,
+    email VARCHAR(255),
+    last_login_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (provider, subject)
+);
+
+CREATE INDEX idx_user_identities_user_id ON user_identities(user_id);
+
+-- Pending social logins. The PKCE verifier is sent to the provider's token
+-- endpoint, so it is stored as is; only the state is hashed.
+CREATE TABLE social_auth_states (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    state_hash TEXT NOT NULL UNIQUE,
+    provider VARCHAR(50) NOT NULL,
+    code_verifier TEXT NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_social_auth_states_expires_at ON social_auth_states(expires_at);
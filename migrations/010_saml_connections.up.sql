@@ -0,0 +1,43 @@
+-- SAML identity provider of an organization. The signing certificates are
+-- parsed from the uploaded metadata and stored as PEM.
+CREATE TABLE saml_connections (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    org_id UUID NOT NULL UNIQUE REFERENCES organizations(id) ON DELETE CASCADE,
+    idp_entity_id TEXT NOT NULL,
+    idp_sso_url TEXT NOT NULL,
+    idp_certificates TEXT[] NOT NULL,
+    metadata_xml TEXT NOT NULL,
+    email_attribute VARCHAR(255),
+    name_attribute VARCHAR(255),
+    default_role TEXT NOT NULL DEFAULT 'MEMBER' CHECK (default_role IN ('ADMIN', 'MEMBER', 'VIEWER')),
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- AuthnRequests sent to IdPs. Responses must refer to an unused request,
+-- which also rules out replaying a captured response.
+CREATE TABLE saml_requests (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    request_id TEXT NOT NULL UNIQUE,
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_saml_requests_expires_at ON saml_requests(expires_at);
+
+-- One-time codes handed to the frontend after a successful assertion and
+-- exchanged for tokens. Only the hash is stored.
+CREATE TABLE saml_login_codes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    code_hash TEXT NOT NULL UNIQUE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_saml_login_codes_expires_at ON saml_login_codes(expires_at);
@@ -0,0 +1,17 @@
+-- Email domains claimed by organizations. A domain is proven through a DNS
+-- TXT record with the verification token; only one organization can hold a
+-- verified claim on a domain.
+CREATE TABLE organization_domains (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    domain VARCHAR(253) NOT NULL,
+    verification_token TEXT NOT NULL,
+    verified_at TIMESTAMP WITH TIME ZONE,
+    sso_enforced BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (org_id, domain),
+    CHECK (NOT sso_enforced OR verified_at IS NOT NULL)
+);
+
+CREATE UNIQUE INDEX idx_organization_domains_verified ON organization_domains(domain) WHERE verified_at IS NOT NULL;
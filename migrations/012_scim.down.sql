@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_org_memberships_external_id;
+ALTER TABLE org_memberships DROP COLUMN IF EXISTS external_id;
+DROP TABLE IF EXISTS scim_tokens;
@@ -0,0 +1,18 @@
+-- Bearer tokens identity providers use to provision an organization over
+-- SCIM. Only the hash is stored.
+CREATE TABLE scim_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_scim_tokens_org_id ON scim_tokens(org_id);
+
+-- Identifier of the member in the provisioning client
+ALTER TABLE org_memberships ADD COLUMN external_id TEXT;
+
+CREATE UNIQUE INDEX idx_org_memberships_external_id ON org_memberships(org_id, external_id) WHERE external_id IS NOT NULL;
@@ -0,0 +1,16 @@
+-- Invitations to join an organization. Only the hash of the token sent to
+-- the invitee is stored.
+CREATE TABLE org_invitations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(50) NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    invited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    accepted_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- At most one pending invitation per email and organization
+CREATE UNIQUE INDEX idx_org_invitations_pending ON org_invitations(org_id, email) WHERE accepted_at IS NULL;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_org_memberships_single_owner;
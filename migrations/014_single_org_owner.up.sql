@@ -0,0 +1,2 @@
+-- An organization has exactly one owner; ownership moves by transfer only
+CREATE UNIQUE INDEX idx_org_memberships_single_owner ON org_memberships(org_id) WHERE role = 'OWNER';
@@ -0,0 +1,2 @@
+-- Organization requested at login, kept until the second factor is verified
+ALTER TABLE mfa_challenges ADD COLUMN org_id UUID REFERENCES organizations(id) ON DELETE SET NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_org_memberships_custom_role_id;
+ALTER TABLE org_memberships DROP COLUMN IF EXISTS custom_role_id;
+DROP TABLE IF EXISTS org_roles;
@@ -0,0 +1,15 @@
+-- Custom roles defined by an organization. A member has one of the built-in
+-- roles and may additionally be assigned a custom role.
+CREATE TABLE org_roles (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (org_id, name)
+);
+
+ALTER TABLE org_memberships ADD COLUMN custom_role_id UUID REFERENCES org_roles(id) ON DELETE SET NULL;
+CREATE INDEX idx_org_memberships_custom_role_id ON org_memberships(custom_role_id) WHERE custom_role_id IS NOT NULL;
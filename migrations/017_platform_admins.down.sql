@@ -0,0 +1,2 @@
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS amr;
+ALTER TABLE users DROP COLUMN IF EXISTS is_platform_admin;
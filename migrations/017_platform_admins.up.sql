@@ -0,0 +1,7 @@
+-- Platform administrators manage users across organizations through /admin.
+-- The flag is only granted directly in the database.
+ALTER TABLE users ADD COLUMN is_platform_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Authentication methods a session was started with (amr claim), kept across
+-- refresh token rotation
+ALTER TABLE refresh_tokens ADD COLUMN amr TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS magic_link_tokens;
+ALTER TABLE organizations DROP COLUMN IF EXISTS magic_link_enabled;
@@ -0,0 +1,16 @@
+-- Organizations can turn off sign-in by email link for their members
+ALTER TABLE organizations ADD COLUMN magic_link_enabled BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- Single-use sign-in links sent by email. Only the hash of the token is
+-- stored; org_id is the organization requested when the link was sent.
+CREATE TABLE magic_link_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    org_id UUID REFERENCES organizations(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_magic_link_tokens_user_id ON magic_link_tokens(user_id);
@@ -0,0 +1,14 @@
+-- Pending changes of the account email address. The new address is only
+-- written to users.email once the link sent to it is confirmed. Only the
+-- hash of the token is stored.
+CREATE TABLE email_change_requests (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    new_email TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_email_change_requests_user_id ON email_change_requests(user_id);
@@ -0,0 +1,3 @@
+-- Preferences of the user: BCP 47 language tag and IANA time zone
+ALTER TABLE users ADD COLUMN locale TEXT NOT NULL DEFAULT 'en';
+ALTER TABLE users ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_audit_logs_event_data;
+DROP TABLE IF EXISTS account_deletion_requests;
@@ -0,0 +1,19 @@
+-- Accounts scheduled for deletion. users.deleted_at is set when deletion is
+-- requested; the cleanup job purges the account after purge_after unless the
+-- user cancels with the link emailed to them. Only the hash of the
+-- cancellation token is stored. restore_active keeps users.is_active from
+-- before the request, so that cancelling does not reactivate an account a
+-- platform admin deactivated.
+CREATE TABLE account_deletion_requests (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    purge_after TIMESTAMP WITH TIME ZONE NOT NULL,
+    restore_active BOOLEAN NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_account_deletion_requests_purge_after ON account_deletion_requests(purge_after);
+
+-- Lets the purge find the audit events of other users that name the purged
+-- user's email (event_data @> '{"email": ...}') without scanning the table.
+CREATE INDEX idx_audit_logs_event_data ON audit_logs USING GIN (event_data jsonb_path_ops);
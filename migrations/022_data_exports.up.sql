@@ -0,0 +1,19 @@
+-- Personal data exports (GDPR Art. 15, 20). The archive is built in the
+-- background and written to the export storage under storage_key; the user
+-- downloads it with the link emailed to them until expires_at. Only the hash
+-- of the download token is stored. Purging an account detaches its exports
+-- (user_id = NULL) and expires them, so cmd/cleanup deletes the archives.
+CREATE TABLE data_exports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'completed', 'failed')),
+    storage_key TEXT,
+    size_bytes BIGINT,
+    token_hash TEXT UNIQUE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_data_exports_user_id ON data_exports(user_id);
+CREATE INDEX idx_data_exports_expires_at ON data_exports(expires_at);